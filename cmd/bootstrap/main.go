@@ -0,0 +1,267 @@
+// Command bootstrap turns empty PostgreSQL and ClickHouse servers, such as the
+// ones docker-compose starts, into a working stack: it creates both databases,
+// applies every migration, registers the exchanges, seeds tokens, mappings
+// and pairs with tokenctl and then checks the result.
+//
+// Usage:
+//
+//	bootstrap [-skip-seed] [-verify-only] [-tokens configs/tokens.json] [-exchanges configs/exchanges.json]
+//
+// Connection settings come from the POSTGRES_* and CLICKHOUSE_* variables the
+// API server reads, or .env. Every step can be run again: databases and
+// migrations already in place are left alone and seeding upserts.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/config"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/tokenctl"
+	"github.com/golang-migrate/migrate/v4"
+	chmigrate "github.com/golang-migrate/migrate/v4/database/clickhouse"
+	pgmigrate "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/joho/godotenv"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	var (
+		migrationsDir = flag.String("migrations", "migrations", "Directory holding the postgres and clickhouse migrations")
+		tokensFile    = flag.String("tokens", "configs/tokens.json", "Token file to seed from")
+		exchangesFile = flag.String("exchanges", exchangeConfigPath(), "Exchange configs to register, as the server does on startup (default $EXCHANGES_CONFIG or configs/exchanges.json)")
+		maintenanceDB = flag.String("maintenance-db", "postgres", "PostgreSQL database to connect to while creating the platform's")
+		skipSeed      = flag.Bool("skip-seed", false, "Do not register exchanges or seed tokens, mappings and pairs")
+		verifyOnly    = flag.Bool("verify-only", false, "Only run the verification pass")
+	)
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if !*verifyOnly {
+		step("Creating databases")
+		if err := createPostgresDatabase(ctx, cfg.Postgres, *maintenanceDB); err != nil {
+			log.Fatalf("Failed to create PostgreSQL database: %v", err)
+		}
+		if err := createClickHouseDatabase(ctx, cfg.ClickHouse); err != nil {
+			log.Fatalf("Failed to create ClickHouse database: %v", err)
+		}
+	}
+
+	pg, err := sql.Open("postgres", cfg.Postgres.ConnectionString())
+	if err == nil {
+		err = pg.PingContext(ctx)
+	}
+	if err != nil {
+		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer pg.Close()
+
+	ch, err := openClickHouse(ctx, cfg.ClickHouse, cfg.ClickHouse.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to ClickHouse: %v", err)
+	}
+	defer ch.Close()
+
+	if !*verifyOnly {
+		step("Applying migrations")
+		if err := migratePostgres(pg, filepath.Join(*migrationsDir, "postgres")); err != nil {
+			log.Fatalf("PostgreSQL migrations failed: %v", err)
+		}
+		if err := migrateClickHouse(cfg.ClickHouse, filepath.Join(*migrationsDir, "clickhouse")); err != nil {
+			log.Fatalf("ClickHouse migrations failed: %v", err)
+		}
+
+		if !*skipSeed {
+			// Trading pairs must be on a registered exchange
+			step("Registering exchanges")
+			added, err := registerExchanges(ctx, pg, *exchangesFile)
+			if err != nil {
+				log.Fatalf("Failed to register exchanges: %v", err)
+			}
+			fmt.Printf("✓ %d exchanges registered\n", added)
+
+			step("Seeding tokens, mappings and pairs")
+			for _, args := range [][]string{
+				{"seed", *tokensFile},
+				{"map", "-set", "curated"},
+				{"pairs", "-set", "curated"},
+			} {
+				if code := tokenctl.Main(args); code != 0 {
+					log.Fatalf("tokenctl %s exited with %d", args[0], code)
+				}
+			}
+		}
+	}
+
+	step("Verifying")
+	if err := verify(ctx, pg, ch, *migrationsDir); err != nil {
+		log.Fatalf("Verification failed: %v", err)
+	}
+	fmt.Println("\nThe stack is ready: go run ./cmd serve all")
+}
+
+// exchangeConfigPath is the exchange config the server would read
+func exchangeConfigPath() string {
+	if path := os.Getenv("EXCHANGES_CONFIG"); path != "" {
+		return path
+	}
+	return "configs/exchanges.json"
+}
+
+// registerExchanges adds the exchanges of the config file that are not in
+// the registry yet and returns how many it added
+func registerExchanges(ctx context.Context, pg *sql.DB, path string) (int, error) {
+	factory, err := exchanges.NewExchangeFactory(path, zap.NewNop())
+	if err != nil {
+		return 0, err
+	}
+	return db.SeedExchanges(ctx, pg, factory.Configs())
+}
+
+// step prints the heading of a bootstrap step
+func step(name string) {
+	fmt.Printf("\n==> %s\n", name)
+}
+
+// createPostgresDatabase creates the platform's database from the
+// maintenance database on the same server, unless it exists
+func createPostgresDatabase(ctx context.Context, cfg config.PostgresConfig, maintenanceDB string) error {
+	name := cfg.Database
+	cfg.Database = maintenanceDB
+	admin, err := sql.Open("postgres", cfg.ConnectionString())
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	var exists bool
+	err = admin.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)`, name).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to look up database %s: %w", name, err)
+	}
+	if exists {
+		fmt.Printf("✓ PostgreSQL database %s exists\n", name)
+		return nil
+	}
+	if _, err := admin.ExecContext(ctx, `CREATE DATABASE `+pq.QuoteIdentifier(name)); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	fmt.Printf("✓ Created PostgreSQL database %s\n", name)
+	return nil
+}
+
+// createClickHouseDatabase creates the platform's database, unless it exists
+func createClickHouseDatabase(ctx context.Context, cfg config.ClickhouseConfig) error {
+	admin, err := openClickHouse(ctx, cfg, "default")
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	if err := admin.Exec(ctx, "CREATE DATABASE IF NOT EXISTS `"+cfg.Database+"`"); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", cfg.Database, err)
+	}
+	fmt.Printf("✓ ClickHouse database %s exists\n", cfg.Database)
+	return nil
+}
+
+func openClickHouse(ctx context.Context, cfg config.ClickhouseConfig, database string) (driver.Conn, error) {
+	conn, err := clickhouse.Open(clickHouseOptions(cfg, database))
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Ping(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to ping ClickHouse at %s:%d: %w", cfg.Host, cfg.Port, err)
+	}
+	return conn, nil
+}
+
+func clickHouseOptions(cfg config.ClickhouseConfig, database string) *clickhouse.Options {
+	return &clickhouse.Options{
+		Addr: []string{fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)},
+		Auth: clickhouse.Auth{
+			Database: database,
+			Username: cfg.Username,
+			Password: cfg.Password,
+		},
+		DialTimeout: 5 * time.Second,
+	}
+}
+
+func migratePostgres(db *sql.DB, dir string) error {
+	driver, err := pgmigrate.WithInstance(db, &pgmigrate.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to create postgres driver: %w", err)
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://"+filepath.ToSlash(dir), "postgres", driver)
+	if err != nil {
+		return fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	return runMigrations(m, "PostgreSQL")
+}
+
+// migrateClickHouse applies the ClickHouse migrations. Most hold several
+// statements, which the native protocol runs one at a time, so the driver
+// splits them.
+func migrateClickHouse(cfg config.ClickhouseConfig, dir string) error {
+	conn := clickhouse.OpenDB(clickHouseOptions(cfg, cfg.Database))
+	driver, err := chmigrate.WithInstance(conn, &chmigrate.Config{
+		DatabaseName:          cfg.Database,
+		MultiStatementEnabled: true,
+	})
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to create clickhouse driver: %w", err)
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://"+filepath.ToSlash(dir), "clickhouse", driver)
+	if err != nil {
+		return fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	return runMigrations(m, "ClickHouse")
+}
+
+// runMigrations applies the pending migrations and closes m
+func runMigrations(m *migrate.Migrate, name string) error {
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		if !errors.Is(err, migrate.ErrNoChange) {
+			return err
+		}
+	}
+	version, dirty, err := m.Version()
+	if err != nil {
+		return fmt.Errorf("failed to get version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("version %d is dirty: fix it and run cmd/migrate -force", version)
+	}
+	fmt.Printf("✓ %s migrated to version %d\n", name, version)
+	return nil
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/shopspring/decimal"
+)
+
+// checkExchange is the exchange ID the sample inserts are written under
+const checkExchange = "bootstrap_check"
+
+// schema is what a directory of up migrations creates
+type schema struct {
+	version int64
+	tables  []string
+	views   []string // views and materialized views
+}
+
+var (
+	migrationFile = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+	createObject  = regexp.MustCompile(`(?i)\bCREATE\s+(?:OR\s+REPLACE\s+)?(MATERIALIZED\s+VIEW|VIEW|TABLE)\s+(?:IF\s+NOT\s+EXISTS\s+)?([a-z_][a-z0-9_]*)`)
+)
+
+// readSchema reads the tables and views that the up migrations in dir
+// create, and the version of the last one
+func readSchema(dir string) (schema, error) {
+	var s schema
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		return s, fmt.Errorf("no migrations found in %s", dir)
+	}
+	tables, views := make(map[string]bool), make(map[string]bool)
+	for _, file := range files {
+		m := migrationFile.FindStringSubmatch(filepath.Base(file))
+		if m == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		if version > s.version {
+			s.version = version
+		}
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return s, err
+		}
+		for _, obj := range createObject.FindAllStringSubmatch(string(body), -1) {
+			name := strings.ToLower(obj[2])
+			if strings.EqualFold(obj[1], "TABLE") {
+				tables[name] = true
+			} else {
+				views[name] = true
+			}
+		}
+	}
+	s.tables, s.views = sortedKeys(tables), sortedKeys(views)
+	return s, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// verify checks that both databases are at the latest migration, hold every
+// table and view the migrations create and take sample writes
+func verify(ctx context.Context, pg *sql.DB, ch driver.Conn, migrationsDir string) error {
+	pgSchema, err := readSchema(filepath.Join(migrationsDir, "postgres"))
+	if err != nil {
+		return err
+	}
+	chSchema, err := readSchema(filepath.Join(migrationsDir, "clickhouse"))
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	check := func(what string, err error) {
+		if err != nil {
+			fmt.Printf("✗ %s: %v\n", what, err)
+			failed = append(failed, what)
+			return
+		}
+		fmt.Printf("✓ %s\n", what)
+	}
+
+	check("PostgreSQL migration version", verifyPostgresVersion(ctx, pg, pgSchema.version))
+	check(fmt.Sprintf("PostgreSQL tables and views (%d)", len(pgSchema.tables)+len(pgSchema.views)),
+		verifyPostgresObjects(ctx, pg, pgSchema))
+	check("PostgreSQL sample insert", verifyPostgresInsert(ctx, pg))
+	check("ClickHouse migration version", verifyClickHouseVersion(ctx, ch, chSchema.version))
+	check(fmt.Sprintf("ClickHouse tables and views (%d)", len(chSchema.tables)+len(chSchema.views)),
+		verifyClickHouseObjects(ctx, ch, chSchema))
+	check("ClickHouse sample insert", verifyClickHouseInsert(ctx, ch))
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of 6 checks failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func checkVersion(version int64, dirty bool, want int64) error {
+	switch {
+	case dirty:
+		return fmt.Errorf("version %d is dirty", version)
+	case version != want:
+		return fmt.Errorf("at version %d, the migrations go up to %d", version, want)
+	}
+	return nil
+}
+
+func verifyPostgresVersion(ctx context.Context, pg *sql.DB, want int64) error {
+	var version int64
+	var dirty bool
+	if err := pg.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations`).Scan(&version, &dirty); err != nil {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	return checkVersion(version, dirty, want)
+}
+
+func verifyClickHouseVersion(ctx context.Context, ch driver.Conn, want int64) error {
+	var version int64
+	var dirty uint8
+	err := ch.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations ORDER BY sequence DESC LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	return checkVersion(version, dirty == 1, want)
+}
+
+// missingObjects compares the tables and views found, by name to whether
+// each is a view, with the ones the schema expects
+func missingObjects(s schema, found map[string]bool) error {
+	var problems []string
+	for _, name := range s.tables {
+		if isView, ok := found[name]; !ok {
+			problems = append(problems, "table "+name+" is missing")
+		} else if isView {
+			problems = append(problems, name+" is a view, not a table")
+		}
+	}
+	for _, name := range s.views {
+		if isView, ok := found[name]; !ok {
+			problems = append(problems, "view "+name+" is missing")
+		} else if !isView {
+			problems = append(problems, name+" is a table, not a view")
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func verifyPostgresObjects(ctx context.Context, pg *sql.DB, s schema) error {
+	rows, err := pg.QueryContext(ctx, `
+		SELECT table_name, table_type = 'VIEW'
+		FROM information_schema.tables
+		WHERE table_schema = current_schema()
+		UNION ALL
+		SELECT matviewname, true FROM pg_matviews WHERE schemaname = current_schema()`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	found := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var isView bool
+		if err := rows.Scan(&name, &isView); err != nil {
+			return err
+		}
+		found[name] = isView
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return missingObjects(s, found)
+}
+
+func verifyClickHouseObjects(ctx context.Context, ch driver.Conn, s schema) error {
+	rows, err := ch.Query(ctx, `SELECT name, engine IN ('View', 'MaterializedView') FROM system.tables WHERE database = currentDatabase()`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	found := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var isView bool
+		if err := rows.Scan(&name, &isView); err != nil {
+			return err
+		}
+		found[name] = isView
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return missingObjects(s, found)
+}
+
+// verifyPostgresInsert writes two tokens, a mapping and a pair, reads the
+// mapping back through unverified_mappings and rolls it all back
+func verifyPostgresInsert(ctx context.Context, pg *sql.DB) error {
+	tx, err := pg.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var baseID, quoteID int
+	insertToken := `INSERT INTO tokens (symbol, name) VALUES ($1, $2) RETURNING id`
+	if err := tx.QueryRowContext(ctx, insertToken, "BOOTSTRAPBASE", "Bootstrap check base").Scan(&baseID); err != nil {
+		return fmt.Errorf("inserting a token: %w", err)
+	}
+	if err := tx.QueryRowContext(ctx, insertToken, "BOOTSTRAPQUOTE", "Bootstrap check quote").Scan(&quoteID); err != nil {
+		return fmt.Errorf("inserting a token: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO token_exchange_symbols (token_id, exchange_id, exchange_symbol, normalized_symbol, mapping_method, needs_verification)
+		VALUES ($1, $2, 'BOOTSTRAPBASE', 'BOOTSTRAPBASE', 'symbol', true)`, baseID, checkExchange)
+	if err != nil {
+		return fmt.Errorf("inserting an exchange symbol: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO exchanges (exchange_id, name, base_url, ticker_endpoint)
+		VALUES ($1, 'Bootstrap check', '', '')`, checkExchange)
+	if err != nil {
+		return fmt.Errorf("registering an exchange: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO trading_pairs (base_token_id, quote_token_id, exchange_id, exchange_pair_symbol)
+		VALUES ($1, $2, $3, 'BOOTSTRAPBASEBOOTSTRAPQUOTE')`, baseID, quoteID, checkExchange)
+	if err != nil {
+		return fmt.Errorf("inserting a trading pair: %w", err)
+	}
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT count(*) FROM unverified_mappings WHERE exchange_id = $1`, checkExchange).Scan(&n); err != nil {
+		return fmt.Errorf("reading unverified_mappings: %w", err)
+	}
+	if n != 1 {
+		return fmt.Errorf("unverified_mappings has %d rows for the sample mapping, want 1", n)
+	}
+	return nil
+}
+
+// verifyClickHouseInsert writes a trade, checks that trades_ohlcv_1m
+// aggregated it and drops the partitions the check wrote to. Both tables
+// are partitioned by exchange, so nothing else is touched.
+func verifyClickHouseInsert(ctx context.Context, ch driver.Conn) (err error) {
+	now := time.Now().UTC()
+	defer func() {
+		for _, stmt := range []string{
+			fmt.Sprintf("ALTER TABLE trades DROP PARTITION ('%s', %s)", checkExchange, now.Format("20060102")),
+			fmt.Sprintf("ALTER TABLE trades_ohlcv_1m DROP PARTITION ('%s', %s)", checkExchange, now.Format("200601")),
+		} {
+			if dropErr := ch.Exec(ctx, stmt); dropErr != nil && err == nil {
+				err = fmt.Errorf("removing the sample trade: %w", dropErr)
+			}
+		}
+	}()
+
+	batch, err := ch.PrepareBatch(ctx, `INSERT INTO trades (timestamp, exchange_id, base_token_id, quote_token_id, symbol, price, quantity, trade_id, is_buyer_maker)`)
+	if err != nil {
+		return fmt.Errorf("preparing the sample trade: %w", err)
+	}
+	if err := batch.Append(now, checkExchange, uint32(0), uint32(0), "BOOTSTRAPCHECK",
+		decimal.NewFromInt(1), decimal.NewFromInt(1), uint64(1), uint8(0)); err != nil {
+		return fmt.Errorf("appending the sample trade: %w", err)
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("inserting the sample trade: %w", err)
+	}
+
+	var n uint64
+	if err := ch.QueryRow(ctx, `SELECT countMerge(trades_count) FROM trades_ohlcv_1m WHERE exchange_id = ?`, checkExchange).Scan(&n); err != nil {
+		return fmt.Errorf("reading trades_ohlcv_1m: %w", err)
+	}
+	if n != 1 {
+		return fmt.Errorf("trades_ohlcv_1m counted %d sample trades, want 1", n)
+	}
+	return nil
+}
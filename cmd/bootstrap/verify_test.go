@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadSchema(t *testing.T) {
+	pg, err := readSchema(filepath.Join("..", "..", "migrations", "postgres"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"tokens", "token_exchange_symbols", "trading_pairs", "exchanges"} {
+		if !slices.Contains(pg.tables, table) {
+			t.Errorf("postgres tables = %v, missing %s", pg.tables, table)
+		}
+	}
+	if !slices.Contains(pg.views, "unverified_mappings") {
+		t.Errorf("postgres views = %v, missing unverified_mappings", pg.views)
+	}
+
+	ch, err := readSchema(filepath.Join("..", "..", "migrations", "clickhouse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(ch.tables, "trades") || slices.Contains(ch.tables, "trades_ohlcv_1m") {
+		t.Errorf("clickhouse tables = %v", ch.tables)
+	}
+	for _, view := range []string{"trades_ohlcv_1m", "latest_vwap_prices"} {
+		if !slices.Contains(ch.views, view) {
+			t.Errorf("clickhouse views = %v, missing %s", ch.views, view)
+		}
+	}
+	if pg.version < 26 || ch.version < 15 {
+		t.Errorf("versions = %d and %d", pg.version, ch.version)
+	}
+}
+
+func TestMissingObjects(t *testing.T) {
+	s := schema{tables: []string{"trades", "tokens"}, views: []string{"trades_ohlcv_1m"}}
+	if err := missingObjects(s, map[string]bool{"trades": false, "tokens": false, "trades_ohlcv_1m": true, "extra": false}); err != nil {
+		t.Errorf("complete schema: %v", err)
+	}
+	err := missingObjects(s, map[string]bool{"trades": true, "trades_ohlcv_1m": true})
+	if err == nil || !strings.Contains(err.Error(), "table tokens is missing") ||
+		!strings.Contains(err.Error(), "trades is a view, not a table") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// duration is a time.Duration written as a string such as "250ms" in JSON
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// Faults are the failures injected into one exchange's responses. Rates are
+// the fraction of requests affected, checked in the order of the fields.
+type Faults struct {
+	Down          bool     `json:"down"`            // connections are closed without a response
+	Latency       duration `json:"latency"`         // added before every response
+	Jitter        duration `json:"jitter"`          // up to this much more, at random
+	ErrorRate     float64  `json:"error_rate"`      // 500, 502 and 503 responses
+	RateLimitRate float64  `json:"rate_limit_rate"` // 429 responses
+	MalformedRate float64  `json:"malformed_rate"`  // 200 responses with a broken body
+}
+
+// malformations are the broken bodies a malformed response has, picked at
+// random
+var malformations = []string{"truncated", "html", "wrong_shape", "deep", "huge", "empty"}
+
+// exchangeStats count what an exchange has served
+type exchangeStats struct {
+	Requests  int64 `json:"requests"`
+	Errors    int64 `json:"errors"`
+	Malformed int64 `json:"malformed"`
+	Dropped   int64 `json:"dropped"`
+}
+
+// simExchange is the state of one simulated exchange
+type simExchange struct {
+	mu     sync.Mutex
+	faults Faults
+	stats  exchangeStats
+}
+
+// inject applies the exchange's faults to a request. It reports whether it
+// wrote the response itself, or else returns the malformation to give a
+// successful response, if any.
+func (e *simExchange) inject(w http.ResponseWriter, rng func() float64) (handled bool, malformed string) {
+	e.mu.Lock()
+	f := e.faults
+	e.stats.Requests++
+	roll := rng()
+	kind := malformations[int(rng()*float64(len(malformations)))%len(malformations)]
+	jitter := time.Duration(rng() * float64(f.Jitter))
+	e.mu.Unlock()
+
+	if f.Down {
+		e.count(func(s *exchangeStats) { s.Dropped++ })
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return true, ""
+			}
+		}
+		http.Error(w, "exchange down", http.StatusServiceUnavailable)
+		return true, ""
+	}
+
+	time.Sleep(time.Duration(f.Latency) + jitter)
+
+	switch {
+	case roll < f.ErrorRate:
+		e.count(func(s *exchangeStats) { s.Errors++ })
+		status := []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable}[int(roll*1e6)%3]
+		http.Error(w, `{"code":-1000,"msg":"An unknown error occurred while processing the request."}`, status)
+		return true, ""
+	case roll < f.ErrorRate+f.RateLimitRate:
+		e.count(func(s *exchangeStats) { s.Errors++ })
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, `{"code":-1003,"msg":"Too many requests."}`, http.StatusTooManyRequests)
+		return true, ""
+	case roll < f.ErrorRate+f.RateLimitRate+f.MalformedRate:
+		e.count(func(s *exchangeStats) { s.Malformed++ })
+		return false, kind
+	}
+	return false, ""
+}
+
+// setFaults replaces the exchange's faults
+func (e *simExchange) setFaults(f Faults) {
+	e.mu.Lock()
+	e.faults = f
+	e.mu.Unlock()
+}
+
+// snapshot returns the exchange's faults and what it has served so far
+func (e *simExchange) snapshot() (Faults, exchangeStats) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.faults, e.stats
+}
+
+func (e *simExchange) count(fn func(*exchangeStats)) {
+	e.mu.Lock()
+	fn(&e.stats)
+	e.mu.Unlock()
+}
+
+// writeMalformed writes body broken in the way kind names, with a 200 status
+func writeMalformed(w http.ResponseWriter, kind string, body []byte, hugeBytes int64) {
+	w.Header().Set("Content-Type", "application/json")
+	switch kind {
+	case "truncated":
+		w.Write(body[:len(body)/2])
+	case "html":
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><body><h1>502 Bad Gateway</h1><p>cloudflare</p></body></html>")
+	case "wrong_shape":
+		io.WriteString(w, `{"code":"0","data":"maintenance","result":{"list":null}}`)
+	case "deep":
+		io.WriteString(w, strings.Repeat("[", 100000)+strings.Repeat("]", 100000))
+	case "huge":
+		writeHuge(w, hugeBytes)
+	case "empty":
+	default:
+		w.Write(body)
+	}
+}
+
+// writeHuge streams a JSON array of about n bytes of filler tickers without
+// holding it in memory, chunked so no Content-Length gives it away
+func writeHuge(w http.ResponseWriter, n int64) {
+	row := []byte(`{"symbol":"FILLERUSDT","lastPrice":"1.00000000","volume":"1.00000000"},`)
+	io.WriteString(w, "[")
+	var written int64
+	for written < n {
+		if _, err := w.Write(row); err != nil {
+			return // the client gave up, as it should
+		}
+		written += int64(len(row))
+	}
+	io.WriteString(w, `{}]`)
+}
+
+// parseFaults reads faults from the command line's defaults
+func parseFaults(latency, jitter time.Duration, errorRate, rateLimitRate, malformedRate float64) (Faults, error) {
+	f := Faults{
+		Latency:       duration(latency),
+		Jitter:        duration(jitter),
+		ErrorRate:     errorRate,
+		RateLimitRate: rateLimitRate,
+		MalformedRate: malformedRate,
+	}
+	return f, f.validate()
+}
+
+func (f Faults) validate() error {
+	for name, rate := range map[string]float64{"error_rate": f.ErrorRate, "rate_limit_rate": f.RateLimitRate, "malformed_rate": f.MalformedRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %g", name, rate)
+		}
+	}
+	if f.ErrorRate+f.RateLimitRate+f.MalformedRate > 1 {
+		return fmt.Errorf("error_rate, rate_limit_rate and malformed_rate add up to over 1")
+	}
+	if f.Latency < 0 || f.Jitter < 0 {
+		return fmt.Errorf("latency and jitter cannot be negative")
+	}
+	return nil
+}
+
+// lockedRand is a math/rand source safe for the server's goroutines
+type lockedRand struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func (r *lockedRand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Float64()
+}
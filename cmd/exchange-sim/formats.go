@@ -0,0 +1,334 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// format renders an exchange's responses in the shape of its real API, as
+// the parser chosen for it in internal/exchanges reads them
+type format struct {
+	quote   string // the currency every simulated pair is quoted in
+	tickers func([]ticker) any
+	symbols func([]ticker) any // nil when the symbols endpoint returns tickers
+	trades  func([]trade) any  // nil when the exchange has no trades endpoint
+}
+
+// formats are the exchanges that can be simulated
+var formats = map[string]format{
+	"binance":  {quote: "USDT", tickers: binanceTickers, symbols: binanceSymbols},
+	"mexc":     {quote: "USDT", tickers: binanceTickers, symbols: binanceSymbols},
+	"toobit":   {quote: "USDT", tickers: binanceTickers},
+	"bitrue":   {quote: "USDT", tickers: binanceTickers},
+	"coinbase": {quote: "USD", tickers: coinbaseProducts, trades: coinbaseTrades},
+	"kraken":   {quote: "USD", tickers: krakenTickers, symbols: krakenSymbols},
+	"okx":      {quote: "USDT", tickers: okxTickers, symbols: okxInstruments, trades: okxTrades},
+	"bybit":    {quote: "USDT", tickers: bybitTickers, trades: bybitTrades},
+	"kucoin":   {quote: "USDT", tickers: kucoinTickers, trades: kucoinTrades},
+	"bitmart":  {quote: "USDT", tickers: bitmartTickers},
+	"whitebit": {quote: "USDT", tickers: whitebitTickers},
+	"coinw":    {quote: "USDT", tickers: coinwTickers},
+	"pionex":   {quote: "USDT", tickers: pionexTickers},
+}
+
+// num writes a price or amount as exchanges do, as a string
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', 8, 64)
+}
+
+func millis(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+func side(t trade) string {
+	if t.buy {
+		return "buy"
+	}
+	return "sell"
+}
+
+func binanceTickers(ts []ticker) any {
+	out := make([]map[string]any, 0, len(ts))
+	for _, t := range ts {
+		out = append(out, map[string]any{
+			"symbol":      t.symbol,
+			"lastPrice":   num(t.last),
+			"openPrice":   num(t.open),
+			"highPrice":   num(t.high),
+			"lowPrice":    num(t.low),
+			"priceChange": num(t.change()),
+			"volume":      num(t.volume),
+			"quoteVolume": num(t.quoteVolume),
+		})
+	}
+	return out
+}
+
+func binanceSymbols(ts []ticker) any {
+	symbols := make([]map[string]any, 0, len(ts))
+	for _, t := range ts {
+		symbols = append(symbols, map[string]any{
+			"symbol":     t.symbol,
+			"status":     "TRADING",
+			"baseAsset":  t.base,
+			"quoteAsset": t.quote,
+			"filters": []map[string]any{
+				{"filterType": "PRICE_FILTER", "tickSize": "0.00000100"},
+				{"filterType": "LOT_SIZE", "stepSize": "0.00010000", "minQty": "0.00010000"},
+				{"filterType": "NOTIONAL", "minNotional": "5.00000000"},
+			},
+		})
+	}
+	return map[string]any{"timezone": "UTC", "symbols": symbols}
+}
+
+// coinbaseProducts serves both tickers and symbols: Coinbase lists products
+// with their 24h stats on the one endpoint
+func coinbaseProducts(ts []ticker) any {
+	out := make([]map[string]any, 0, len(ts))
+	for _, t := range ts {
+		out = append(out, map[string]any{
+			"id":               t.symbol,
+			"base_currency":    t.base,
+			"quote_currency":   t.quote,
+			"status":           "online",
+			"min_market_funds": "1",
+			"quote_increment":  "0.000001",
+			"base_increment":   "0.00000001",
+			"stats": map[string]any{
+				"open":         num(t.open),
+				"high":         num(t.high),
+				"low":          num(t.low),
+				"last":         num(t.last),
+				"volume":       num(t.volume),
+				"volume_30day": num(t.quoteVolume * 30),
+			},
+		})
+	}
+	return out
+}
+
+func coinbaseTrades(trades []trade) any {
+	out := make([]map[string]any, 0, len(trades))
+	// Coinbase lists the newest first
+	for i := len(trades) - 1; i >= 0; i-- {
+		t := trades[i]
+		out = append(out, map[string]any{
+			"trade_id": t.id,
+			"price":    num(t.price),
+			"size":     num(t.size),
+			"time":     t.at.UTC().Format(time.RFC3339Nano),
+			"side":     side(t),
+		})
+	}
+	return out
+}
+
+func krakenTickers(ts []ticker) any {
+	result := make(map[string]any, len(ts))
+	for _, t := range ts {
+		result[t.symbol] = map[string]any{
+			"c": []string{num(t.last), "1.00000000"},
+			"v": []string{num(t.volume / 2), num(t.volume)},
+			"h": []string{num(t.high), num(t.high)},
+			"l": []string{num(t.low), num(t.low)},
+			"o": num(t.open),
+		}
+	}
+	return map[string]any{"error": []string{}, "result": result}
+}
+
+func krakenSymbols(ts []ticker) any {
+	result := make(map[string]any, len(ts))
+	for _, t := range ts {
+		result[t.symbol] = map[string]any{
+			"altname":       t.base + t.quote,
+			"base":          krakenAsset(t.base),
+			"quote":         krakenAsset(t.quote),
+			"status":        "online",
+			"ordermin":      "0.0001",
+			"costmin":       "0.5",
+			"pair_decimals": 6,
+			"lot_decimals":  8,
+			"fees":          [][]float64{{0, 0.26}},
+			"fees_maker":    [][]float64{{0, 0.16}},
+		}
+	}
+	return map[string]any{"error": []string{}, "result": result}
+}
+
+func okxTickers(ts []ticker) any {
+	data := make([]map[string]any, 0, len(ts))
+	now := millis(time.Now())
+	for _, t := range ts {
+		data = append(data, map[string]any{
+			"instType":  "SPOT",
+			"instId":    t.symbol,
+			"last":      num(t.last),
+			"open24h":   num(t.open),
+			"high24h":   num(t.high),
+			"low24h":    num(t.low),
+			"vol24h":    num(t.volume),
+			"volCcy24h": num(t.quoteVolume),
+			"ts":        now,
+		})
+	}
+	return map[string]any{"code": "0", "msg": "", "data": data}
+}
+
+func okxInstruments(ts []ticker) any {
+	data := make([]map[string]any, 0, len(ts))
+	for _, t := range ts {
+		data = append(data, map[string]any{
+			"instType": "SPOT",
+			"instId":   t.symbol,
+			"baseCcy":  t.base,
+			"quoteCcy": t.quote,
+			"state":    "live",
+			"tickSz":   "0.000001",
+			"lotSz":    "0.00000001",
+			"minSz":    "0.00001",
+		})
+	}
+	return map[string]any{"code": "0", "msg": "", "data": data}
+}
+
+func okxTrades(trades []trade) any {
+	data := make([]map[string]any, 0, len(trades))
+	for i := len(trades) - 1; i >= 0; i-- {
+		t := trades[i]
+		data = append(data, map[string]any{
+			"tradeId": strconv.FormatUint(t.id, 10),
+			"px":      num(t.price),
+			"sz":      num(t.size),
+			"side":    side(t),
+			"ts":      millis(t.at),
+		})
+	}
+	return map[string]any{"code": "0", "msg": "", "data": data}
+}
+
+func bybitTickers(ts []ticker) any {
+	list := make([]map[string]any, 0, len(ts))
+	for _, t := range ts {
+		list = append(list, map[string]any{
+			"symbol":       t.symbol,
+			"lastPrice":    num(t.last),
+			"prevPrice24h": num(t.open),
+			"highPrice24h": num(t.high),
+			"lowPrice24h":  num(t.low),
+			"price24hPcnt": strconv.FormatFloat(t.changeFraction(), 'f', 4, 64),
+			"volume24h":    num(t.volume),
+			"turnover24h":  num(t.quoteVolume),
+		})
+	}
+	return map[string]any{"retCode": 0, "retMsg": "OK", "result": map[string]any{"category": "spot", "list": list}}
+}
+
+func bybitTrades(trades []trade) any {
+	list := make([]map[string]any, 0, len(trades))
+	for i := len(trades) - 1; i >= 0; i-- {
+		t := trades[i]
+		s := "Sell"
+		if t.buy {
+			s = "Buy"
+		}
+		list = append(list, map[string]any{
+			"execId": strconv.FormatUint(t.id, 10),
+			"price":  num(t.price),
+			"size":   num(t.size),
+			"side":   s,
+			"time":   millis(t.at),
+		})
+	}
+	return map[string]any{"retCode": 0, "retMsg": "OK", "result": map[string]any{"category": "spot", "list": list}}
+}
+
+func kucoinTickers(ts []ticker) any {
+	list := make([]map[string]any, 0, len(ts))
+	for _, t := range ts {
+		list = append(list, map[string]any{
+			"symbol":     t.symbol,
+			"last":       num(t.last),
+			"high":       num(t.high),
+			"low":        num(t.low),
+			"changeRate": strconv.FormatFloat(t.changeFraction(), 'f', 4, 64),
+			"vol":        num(t.volume),
+			"volValue":   num(t.quoteVolume),
+		})
+	}
+	return map[string]any{"code": "200000", "data": map[string]any{"time": time.Now().UnixMilli(), "ticker": list}}
+}
+
+func kucoinTrades(trades []trade) any {
+	data := make([]map[string]any, 0, len(trades))
+	for _, t := range trades {
+		data = append(data, map[string]any{
+			"sequence": strconv.FormatUint(t.id, 10),
+			"price":    num(t.price),
+			"size":     num(t.size),
+			"side":     side(t),
+			"time":     t.at.UnixNano(),
+		})
+	}
+	return map[string]any{"code": "200000", "data": data}
+}
+
+func bitmartTickers(ts []ticker) any {
+	list := make([]map[string]any, 0, len(ts))
+	for _, t := range ts {
+		list = append(list, map[string]any{
+			"symbol":           t.symbol,
+			"last_price":       num(t.last),
+			"high_24h":         num(t.high),
+			"low_24h":          num(t.low),
+			"fluctuation":      strconv.FormatFloat(t.changeFraction(), 'f', 4, 64),
+			"base_volume_24h":  num(t.volume),
+			"quote_volume_24h": num(t.quoteVolume),
+		})
+	}
+	return map[string]any{"code": 1000, "message": "OK", "data": map[string]any{"tickers": list}}
+}
+
+func whitebitTickers(ts []ticker) any {
+	out := make(map[string]any, len(ts))
+	for _, t := range ts {
+		out[t.symbol] = map[string]any{
+			"last_price":   num(t.last),
+			"base_volume":  num(t.volume),
+			"quote_volume": num(t.quoteVolume),
+			"change":       strconv.FormatFloat(t.changeFraction()*100, 'f', 2, 64),
+		}
+	}
+	return out
+}
+
+func coinwTickers(ts []ticker) any {
+	data := make(map[string]any, len(ts))
+	for _, t := range ts {
+		data[t.symbol] = map[string]any{
+			"last":          num(t.last),
+			"high24hr":      num(t.high),
+			"low24hr":       num(t.low),
+			"percentChange": strconv.FormatFloat(t.changeFraction(), 'f', 4, 64),
+			"baseVolume":    num(t.volume),
+		}
+	}
+	return map[string]any{"code": "200", "data": data}
+}
+
+func pionexTickers(ts []ticker) any {
+	list := make([]map[string]any, 0, len(ts))
+	for _, t := range ts {
+		list = append(list, map[string]any{
+			"symbol": t.symbol,
+			"open":   num(t.open),
+			"close":  num(t.last),
+			"high":   num(t.high),
+			"low":    num(t.low),
+			"volume": num(t.volume),
+			"amount": num(t.quoteVolume),
+		})
+	}
+	return map[string]any{"result": true, "data": map[string]any{"tickers": list}}
+}
@@ -0,0 +1,332 @@
+// Command exchange-sim serves fake exchange APIs in the formats of the real
+// ones, with latency, errors and malformed payloads injected on demand, so
+// the poller, parsers and exchange health tracking can be load- and
+// chaos-tested locally.
+//
+// Usage:
+//
+//	exchange-sim [-addr :9900] [-pairs 50] [-error-rate 0.05] [-write-config configs/exchanges.sim.json]
+//
+// Each exchange is served under its ID, e.g. http://localhost:9900/binance,
+// at the endpoints configs/exchanges.json gives it. -write-config writes a
+// copy of that file pointing the simulated exchanges at the simulator, which
+// the server polls with EXCHANGES_CONFIG set to it.
+//
+// Faults can be changed while running:
+//
+//	curl localhost:9900/_sim/exchanges
+//	curl -X PUT localhost:9900/_sim/exchanges/kraken/faults -d '{"down":true}'
+//	curl -X PUT localhost:9900/_sim/exchanges/all/faults -d '{"latency":"2s","malformed_rate":0.1}'
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"go.uber.org/zap"
+)
+
+// tradesPerResponse is how many recent trades a trades request returns
+const tradesPerResponse = 50
+
+func main() {
+	var (
+		addr          = flag.String("addr", ":9900", "Address to listen on")
+		configPath    = flag.String("config", "configs/exchanges.json", "Exchange configs whose endpoints and symbol formats are simulated")
+		only          = flag.String("exchanges", "", "Comma-separated exchanges to simulate (default every supported one)")
+		pairs         = flag.Int("pairs", 50, "Pairs listed on every exchange")
+		seed          = flag.Int64("seed", 1, "Random seed for prices and injected faults")
+		latency       = flag.Duration("latency", 0, "Latency added to every response")
+		jitter        = flag.Duration("jitter", 0, "Up to this much more latency, at random")
+		errorRate     = flag.Float64("error-rate", 0, "Fraction of requests answered with a 5xx")
+		rateLimitRate = flag.Float64("rate-limit-rate", 0, "Fraction of requests answered with a 429")
+		malformedRate = flag.Float64("malformed-rate", 0, "Fraction of requests answered with a malformed body")
+		hugeBytes     = flag.Int64("huge-bytes", 128<<20, "Size of the oversized bodies among malformed responses")
+		writeConfig   = flag.String("write-config", "", "Write exchange configs pointing at the simulator to this file")
+	)
+	flag.Parse()
+
+	faults, err := parseFaults(*latency, *jitter, *errorRate, *rateLimitRate, *malformedRate)
+	if err != nil {
+		log.Fatalf("Invalid faults: %v", err)
+	}
+
+	factory, err := exchanges.NewExchangeFactory(*configPath, zap.NewNop())
+	if err != nil {
+		log.Fatalf("Failed to load exchange configs: %v", err)
+	}
+	configs, err := simulatedConfigs(factory.Configs(), *only)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := newServer(configs, newBook(*pairs, *seed), faults, *seed, *hugeBytes)
+	if *writeConfig != "" {
+		if err := s.writeConfigs(*writeConfig, advertiseAddr(*addr)); err != nil {
+			log.Fatalf("Failed to write exchange configs: %v", err)
+		}
+		log.Printf("Wrote configs for %d exchanges to %s; run the server with EXCHANGES_CONFIG=%s", len(configs), *writeConfig, *writeConfig)
+	}
+
+	log.Printf("Simulating %d exchanges with %d pairs each on %s", len(configs), *pairs, *addr)
+	srv := &http.Server{Addr: *addr, Handler: s.routes(), ReadHeaderTimeout: 5 * time.Second}
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+// simulatedConfigs returns the configs of the exchanges to simulate: those
+// named in only, or every one with a format when only is empty
+func simulatedConfigs(all []exchanges.ExchangeConfig, only string) ([]exchanges.ExchangeConfig, error) {
+	byID := make(map[string]exchanges.ExchangeConfig, len(all))
+	for _, c := range all {
+		byID[c.ID] = c
+	}
+
+	var out []exchanges.ExchangeConfig
+	if only == "" {
+		for _, c := range all {
+			if _, ok := formats[c.ID]; ok {
+				out = append(out, c)
+			}
+		}
+		return out, nil
+	}
+	for _, id := range strings.Split(only, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		c, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("no config for exchange %s", id)
+		}
+		if _, ok := formats[id]; !ok {
+			return nil, fmt.Errorf("exchange %s cannot be simulated: no response format for it", id)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// advertiseAddr turns a listen address such as :9900 into one to reach it at
+func advertiseAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// server serves the simulated exchanges and the /_sim control API
+type server struct {
+	book      *book
+	configs   map[string]exchanges.ExchangeConfig
+	exchanges map[string]*simExchange
+	rng       *lockedRand
+	hugeBytes int64
+}
+
+func newServer(configs []exchanges.ExchangeConfig, b *book, faults Faults, seed, hugeBytes int64) *server {
+	s := &server{
+		book:      b,
+		configs:   make(map[string]exchanges.ExchangeConfig, len(configs)),
+		exchanges: make(map[string]*simExchange, len(configs)),
+		rng:       &lockedRand{rng: rand.New(rand.NewSource(seed))},
+		hugeBytes: hugeBytes,
+	}
+	for _, c := range configs {
+		s.configs[c.ID] = c
+		s.exchanges[c.ID] = &simExchange{faults: faults}
+	}
+	return s
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /_sim/exchanges", s.listExchanges)
+	mux.HandleFunc("PUT /_sim/exchanges/{id}/faults", s.setFaults)
+	mux.HandleFunc("/", s.serveExchange)
+	return mux
+}
+
+// writeConfigs writes the simulated exchanges' configs with their base URLs
+// pointing at addr, enabled whether or not the real exchange is
+func (s *server) writeConfigs(path, addr string) error {
+	configs := make([]exchanges.ExchangeConfig, 0, len(s.configs))
+	for _, c := range s.configs {
+		c.BaseURL = "http://" + addr + "/" + c.ID
+		c.Disabled = false
+		configs = append(configs, c)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].ID < configs[j].ID })
+
+	data, err := json.MarshalIndent(map[string]any{"exchanges": configs}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (s *server) listExchanges(w http.ResponseWriter, r *http.Request) {
+	type status struct {
+		Faults Faults        `json:"faults"`
+		Stats  exchangeStats `json:"stats"`
+	}
+	out := make(map[string]status, len(s.exchanges))
+	for id, e := range s.exchanges {
+		faults, stats := e.snapshot()
+		out[id] = status{faults, stats}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// setFaults replaces the faults of an exchange, or of every exchange when
+// the ID is all
+func (s *server) setFaults(w http.ResponseWriter, r *http.Request) {
+	var f Faults
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := f.validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	id := r.PathValue("id")
+	var targets []*simExchange
+	if id == "all" {
+		for _, e := range s.exchanges {
+			targets = append(targets, e)
+		}
+	} else if e, ok := s.exchanges[id]; ok {
+		targets = append(targets, e)
+	} else {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "exchange " + id + " is not simulated"})
+		return
+	}
+	for _, e := range targets {
+		e.setFaults(f)
+	}
+	log.Printf("Faults of %s set to %+v", id, f)
+	writeJSON(w, http.StatusOK, f)
+}
+
+// serveExchange answers /<exchange>/<endpoint> with the exchange's tickers,
+// symbols or trades
+func (s *server) serveExchange(w http.ResponseWriter, r *http.Request) {
+	id, path, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	path = "/" + path
+	config, ok := s.configs[id]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "exchange " + id + " is not simulated"})
+		return
+	}
+	f := formats[id]
+	e := s.exchanges[id]
+
+	var render func() any
+	if symbol, ok := matchTrades(config.TradesEndpoint, path, r.URL.Query()); ok && f.trades != nil {
+		render = func() any {
+			for _, t := range s.book.tickers(id, f.quote, config.SymbolFormat) {
+				if t.symbol == symbol {
+					return f.trades(recentTrades(t, tradesPerResponse))
+				}
+			}
+			return nil
+		}
+	} else if path == endpointPath(config.TickerEndpoint) {
+		render = func() any { return f.tickers(s.book.tickers(id, f.quote, config.SymbolFormat)) }
+	} else if path == endpointPath(config.SymbolsEndpoint) {
+		render = func() any {
+			ts := s.book.tickers(id, f.quote, config.SymbolFormat)
+			if f.symbols == nil {
+				return f.tickers(ts)
+			}
+			return f.symbols(ts)
+		}
+	} else {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no such endpoint " + path})
+		return
+	}
+
+	handled, malformed := e.inject(w, s.rng.Float64)
+	if handled {
+		return
+	}
+	payload := render()
+	if payload == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown symbol"})
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if malformed != "" {
+		writeMalformed(w, malformed, body, s.hugeBytes)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// endpointPath is the path of an endpoint from the exchange config, without
+// its query
+func endpointPath(endpoint string) string {
+	path, _, _ := strings.Cut(endpoint, "?")
+	return path
+}
+
+// matchTrades reports whether a request is for the trades endpoint template,
+// in which {symbol} stands for the pair in the path or a query parameter,
+// and returns the pair
+func matchTrades(template, path string, query url.Values) (string, bool) {
+	if template == "" {
+		return "", false
+	}
+	tPath, tQuery, _ := strings.Cut(template, "?")
+	if before, after, ok := strings.Cut(tPath, "{symbol}"); ok {
+		if !strings.HasPrefix(path, before) || !strings.HasSuffix(path, after) || len(path) <= len(before)+len(after) {
+			return "", false
+		}
+		return path[len(before) : len(path)-len(after)], true
+	}
+	if path != tPath {
+		return "", false
+	}
+	params, err := url.ParseQuery(tQuery)
+	if err != nil {
+		return "", false
+	}
+	for key, values := range params {
+		if len(values) == 1 && values[0] == "{symbol}" {
+			symbol := query.Get(key)
+			return symbol, symbol != ""
+		}
+	}
+	return "", false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
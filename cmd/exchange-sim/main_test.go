@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"go.uber.org/zap"
+)
+
+const testPairs = 20
+
+// newTestSim serves every simulated exchange and returns a factory whose
+// configs point at them
+func newTestSim(t *testing.T, faults Faults) (*server, *exchanges.ExchangeFactory) {
+	t.Helper()
+	factory, err := exchanges.NewExchangeFactory("../../configs/exchanges.json", zap.NewNop())
+	if err != nil {
+		t.Fatalf("load configs: %v", err)
+	}
+	configs, err := simulatedConfigs(factory.Configs(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newServer(configs, newBook(testPairs, 1), faults, 1, 1<<20)
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(ts.Close)
+
+	pointed := make([]exchanges.ExchangeConfig, 0, len(configs))
+	for _, c := range configs {
+		c.BaseURL = ts.URL + "/" + c.ID
+		c.Disabled = false
+		pointed = append(pointed, c)
+	}
+	factory.SetConfigs(pointed)
+	factory.SetLimits(exchanges.Limits{MaxResponseBytes: 512 << 10, MaxJSONDepth: 64, ParseTimeout: 5 * time.Second})
+	return s, factory
+}
+
+func TestSimulatedExchangesParse(t *testing.T) {
+	s, factory := newTestSim(t, Faults{})
+	ids := make([]string, 0, len(s.configs))
+	for id := range s.configs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		t.Run(id, func(t *testing.T) {
+			client, err := factory.CreateClient(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			tickers, err := client.GetAllTickers(ctx)
+			if err != nil {
+				t.Fatalf("GetAllTickers: %v", err)
+			}
+			if len(tickers) != testPairs {
+				t.Fatalf("got %d tickers, want %d", len(tickers), testPairs)
+			}
+			for _, tk := range tickers {
+				if !tk.Price.IsPositive() {
+					t.Errorf("%s has price %s", tk.Symbol, tk.Price)
+				}
+			}
+
+			tc, ok := client.(exchanges.TradesClient)
+			if !ok || !tc.SupportsTrades() || formats[id].trades == nil {
+				return
+			}
+			symbol := formatSymbol("BTC", formats[id].quote, s.configs[id].SymbolFormat)
+			trades, err := tc.GetRecentTrades(ctx, symbol)
+			if err != nil {
+				t.Fatalf("GetRecentTrades: %v", err)
+			}
+			if len(trades) != tradesPerResponse {
+				t.Fatalf("got %d trades, want %d", len(trades), tradesPerResponse)
+			}
+		})
+	}
+}
+
+func TestInjectedFaults(t *testing.T) {
+	s, factory := newTestSim(t, Faults{})
+	client, err := factory.CreateClient("binance")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Malformed bodies are served on their own, since which one a
+	// malformed response gets is random
+	for _, kind := range malformations {
+		t.Run(kind, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeMalformed(w, kind, []byte(`[{"symbol":"BTCUSDT","lastPrice":"1"}]`), 1<<20)
+			}))
+			defer srv.Close()
+
+			c := s.configs["binance"]
+			c.BaseURL = srv.URL
+			factory.SetConfigs([]exchanges.ExchangeConfig{c})
+			broken, err := factory.CreateClient("binance")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tickers, err := broken.GetAllTickers(context.Background())
+			if err == nil && len(tickers) > 0 {
+				t.Fatalf("malformed %s response gave %d tickers", kind, len(tickers))
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		s.exchanges["binance"].setFaults(Faults{ErrorRate: 1})
+		_, err := client.GetAllTickers(context.Background())
+		var statusErr *exchanges.StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode < 500 {
+			t.Fatalf("got %v, want a 5xx StatusError", err)
+		}
+	})
+
+	t.Run("down", func(t *testing.T) {
+		s.exchanges["binance"].setFaults(Faults{Down: true})
+		if _, err := client.GetAllTickers(context.Background()); err == nil {
+			t.Fatal("got no error from an exchange that is down")
+		}
+		if _, stats := s.exchanges["binance"].snapshot(); stats.Dropped == 0 {
+			t.Fatal("dropped request was not counted")
+		}
+	})
+}
+
+func TestMatchTrades(t *testing.T) {
+	tests := []struct {
+		template, target string
+		want             string
+		ok               bool
+	}{
+		{"/products/{symbol}/trades", "/products/BTC-USD/trades", "BTC-USD", true},
+		{"/products/{symbol}/trades", "/products", "", false},
+		{"/api/v5/market/trades?instId={symbol}&limit=500", "/api/v5/market/trades?instId=BTC-USDT&limit=500", "BTC-USDT", true},
+		{"/api/v5/market/trades?instId={symbol}", "/api/v5/market/tickers?instType=SPOT", "", false},
+		{"", "/anything", "", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		got, ok := matchTrades(tt.template, req.URL.Path, req.URL.Query())
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("matchTrades(%q, %q) = %q, %v; want %q, %v", tt.template, tt.target, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSetFaults(t *testing.T) {
+	s, _ := newTestSim(t, Faults{})
+	h := s.routes()
+
+	req := httptest.NewRequest(http.MethodPut, "/_sim/exchanges/all/faults", strings.NewReader(`{"latency":"5ms","error_rate":0.5}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	for id, e := range s.exchanges {
+		if f, _ := e.snapshot(); f.ErrorRate != 0.5 || time.Duration(f.Latency) != 5*time.Millisecond {
+			t.Fatalf("%s has faults %+v", id, f)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/_sim/exchanges/kraken/faults", strings.NewReader(`{"error_rate":0.7,"malformed_rate":0.5}`))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("rates over 1 gave status %d", rec.Code)
+	}
+}
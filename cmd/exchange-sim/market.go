@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// majors are listed first on every exchange, at roughly real prices, so
+// the simulated VWAP of well-known tokens looks plausible
+var majors = []struct {
+	symbol string
+	price  float64
+}{
+	{"BTC", 65000}, {"ETH", 3200}, {"SOL", 150}, {"XRP", 0.6}, {"DOGE", 0.15},
+	{"ADA", 0.45}, {"AVAX", 35}, {"LINK", 15}, {"DOT", 7}, {"LTC", 80},
+	{"TRX", 0.12}, {"ATOM", 8}, {"UNI", 9}, {"NEAR", 6}, {"APT", 9},
+}
+
+// market is a token every simulated exchange lists, priced by a random walk
+type market struct {
+	base   string
+	price  float64
+	open   float64 // price 24h ago, for the change fields
+	high   float64
+	low    float64
+	volume float64 // base volume over 24h
+}
+
+// book holds the markets. Prices move on every read by the time since the
+// last one, so polling faster does not make them more volatile.
+type book struct {
+	mu       sync.Mutex
+	rng      *rand.Rand
+	markets  []*market
+	lastStep time.Time
+}
+
+// hourlyVolatility is the standard deviation of a market's hourly log return
+const hourlyVolatility = 0.01
+
+func newBook(pairs int, seed int64) *book {
+	rng := rand.New(rand.NewSource(seed))
+	b := &book{rng: rng, lastStep: time.Now()}
+	for i := 0; i < pairs; i++ {
+		m := &market{}
+		if i < len(majors) {
+			m.base, m.price = majors[i].symbol, majors[i].price
+		} else {
+			m.base = fmt.Sprintf("SIM%03d", i-len(majors)+1)
+			m.price = math.Pow(10, rng.Float64()*6-3) // 0.001 to 1000
+		}
+		m.open, m.high, m.low = m.price, m.price, m.price
+		m.volume = 1e6 / m.price * (0.5 + rng.Float64())
+		b.markets = append(b.markets, m)
+	}
+	return b
+}
+
+// ticker is a market as one exchange quotes it
+type ticker struct {
+	symbol      string // in the exchange's format
+	base, quote string
+	last        float64
+	open        float64
+	high        float64
+	low         float64
+	volume      float64
+	quoteVolume float64
+}
+
+func (t ticker) change() float64         { return t.last - t.open }
+func (t ticker) changeFraction() float64 { return (t.last - t.open) / t.open }
+
+// tickers steps the random walk and returns every market as exchangeID
+// quotes it against quote: off the common price by a small spread of its
+// own, with a share of the volume
+func (b *book) tickers(exchangeID, quote, symbolFormat string) []ticker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if hours := now.Sub(b.lastStep).Hours(); hours > 0 {
+		sigma := hourlyVolatility * math.Sqrt(hours)
+		for _, m := range b.markets {
+			m.price *= math.Exp(b.rng.NormFloat64() * sigma)
+			m.high = math.Max(m.high, m.price)
+			m.low = math.Min(m.low, m.price)
+		}
+		b.lastStep = now
+	}
+
+	spread, share := exchangeBias(exchangeID)
+	out := make([]ticker, 0, len(b.markets))
+	for _, m := range b.markets {
+		last := m.price * (1 + spread + b.rng.NormFloat64()*0.0002)
+		volume := m.volume * share
+		out = append(out, ticker{
+			symbol:      formatSymbol(m.base, quote, symbolFormat),
+			base:        m.base,
+			quote:       quote,
+			last:        last,
+			open:        m.open * (1 + spread),
+			high:        math.Max(m.high*(1+spread), last),
+			low:         math.Min(m.low*(1+spread), last),
+			volume:      volume,
+			quoteVolume: volume * last,
+		})
+	}
+	return out
+}
+
+// exchangeBias gives every exchange a fixed price offset within ±0.1% and
+// a volume share between 0.2 and 1, so exchanges disagree slightly and
+// VWAP weighting has something to weigh
+func exchangeBias(exchangeID string) (spread, share float64) {
+	h := fnv.New64a()
+	h.Write([]byte(exchangeID))
+	v := h.Sum64()
+	spread = (float64(v%2001) - 1000) / 1e6
+	share = 0.2 + float64((v>>16)%801)/1000
+	return spread, share
+}
+
+// trade is one simulated trade
+type trade struct {
+	id    uint64
+	at    time.Time
+	price float64
+	size  float64
+	buy   bool // taker side
+}
+
+// tradeInterval is how often each simulated pair trades
+const tradeInterval = 100 * time.Millisecond
+
+// recentTrades returns the last n trades of a ticker, oldest first. IDs
+// count tradeIntervals since the epoch, so they only grow between polls.
+func recentTrades(t ticker, n int) []trade {
+	latest := uint64(time.Now().UnixNano() / int64(tradeInterval))
+	trades := make([]trade, 0, n)
+	for id := latest - uint64(n) + 1; id <= latest; id++ {
+		// Vary price and size by ID, so a trade looks the same every poll
+		f := float64(id%1000) / 1000
+		trades = append(trades, trade{
+			id:    id,
+			at:    time.Unix(0, int64(id)*int64(tradeInterval)),
+			price: t.last * (1 + (f-0.5)*0.001),
+			size:  t.volume / 864000 * (0.1 + f*2),
+			buy:   id%2 == 0,
+		})
+	}
+	return trades
+}
+
+// formatSymbol writes a pair the way an exchange with symbolFormat, from
+// configs/exchanges.json, does
+func formatSymbol(base, quote, symbolFormat string) string {
+	switch symbolFormat {
+	case "BTC-USDT", "BTC-USD":
+		return base + "-" + quote
+	case "BTC_USDT":
+		return base + "_" + quote
+	case "btcusdt":
+		return strings.ToLower(base + quote)
+	case "btc_usdt":
+		return strings.ToLower(base + "_" + quote)
+	case "XXBTZUSD":
+		return krakenAsset(base) + krakenAsset(quote)
+	}
+	return base + quote
+}
+
+// krakenAsset names an asset as Kraken's older pairs do
+func krakenAsset(asset string) string {
+	switch asset {
+	case "BTC":
+		return "XXBT"
+	case "ETH", "XRP":
+		return "X" + asset
+	case "USD", "EUR", "GBP":
+		return "Z" + asset
+	}
+	return asset
+}
@@ -3,51 +3,147 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 
+	_ "github.com/ashmitsharp/trading/docs"
+	"github.com/ashmitsharp/trading/internal/analytics"
+	"github.com/ashmitsharp/trading/internal/api"
 	"github.com/ashmitsharp/trading/internal/calculator"
+	"github.com/ashmitsharp/trading/internal/confidence"
+	"github.com/ashmitsharp/trading/internal/config"
+	"github.com/ashmitsharp/trading/internal/db"
 	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/features"
+	"github.com/ashmitsharp/trading/internal/fx"
 	"github.com/ashmitsharp/trading/internal/handler"
+	"github.com/ashmitsharp/trading/internal/indices"
+	"github.com/ashmitsharp/trading/internal/ingester"
+	"github.com/ashmitsharp/trading/internal/klines"
+	"github.com/ashmitsharp/trading/internal/listings"
+	"github.com/ashmitsharp/trading/internal/maintenance"
+	"github.com/ashmitsharp/trading/internal/marketcap"
 	"github.com/ashmitsharp/trading/internal/outlier"
+	"github.com/ashmitsharp/trading/internal/polling"
+	"github.com/ashmitsharp/trading/internal/reconcile"
+	"github.com/ashmitsharp/trading/internal/scheduler"
 	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/ashmitsharp/trading/internal/stream"
+	"github.com/ashmitsharp/trading/internal/supervisor"
 	"github.com/ashmitsharp/trading/internal/symbol"
+	"github.com/ashmitsharp/trading/internal/tokenops"
+	"github.com/ashmitsharp/trading/internal/trust"
+	"github.com/ashmitsharp/trading/internal/volumeshare"
+	"github.com/ashmitsharp/trading/internal/vwap"
+	"github.com/ashmitsharp/trading/internal/webhooks"
+	"github.com/ashmitsharp/trading/internal/weighting"
+	"github.com/ashmitsharp/trading/pkg/utils"
 )
 
 type Application struct {
 	logger               *zap.Logger
+	tasks                *supervisor.Group
 	postgresDB           *sql.DB
 	clickhouseDB         clickhouse.Conn
 	factory              *exchanges.ExchangeFactory
-	vwapCalc             *calculator.VWAPCalculator
+	vwapService          *vwap.Service
 	priceStorage         *storage.PriceStorage
+	opsStorage           *storage.OpsStorage
+	requestLog           *storage.RequestLog
 	vwapStorage          *storage.VWAPStorage
 	symbolResolver       *symbol.Resolver
+	tokenOps             *tokenops.Service
 	outlierDetector      *outlier.Detector
+	outlierThresholds    *outlier.Overrides
+	featureFlags         *features.Flags
 	verificationHandler  *handler.VerificationHandler
+	thresholdHandler     *handler.OutlierThresholdHandler
+	featureFlagHandler   *handler.FeatureFlagHandler
+	resolverHandler      *handler.ResolverHandler
+	tokenAdminHandler    *handler.TokenAdminHandler
+	mappingAdminHandler  *handler.MappingAdminHandler
+	graphqlHandler       *handler.GraphQLHandler
+	ohlcvHandler         *handler.OHLCVHandler
+	tradeHandler         *handler.TradeHandler
+	vwapHandler          *handler.VWAPHandler
+	priceHandler         *handler.PriceHandler
+	watchlistHandler     *handler.WatchlistHandler
+	tickerHub            *stream.TickerHub
+	streamHandler        *handler.StreamHandler
+	webhookDispatcher    *webhooks.Dispatcher
+	webhookHandler       *handler.WebhookHandler
+	tokenHandler         *handler.TokenHandler
+	tickerHandler        *handler.TickerHandler
+	analyticsHandler     *handler.AnalyticsHandler
+	correlationService   *analytics.CorrelationService
+	indexStorage         *storage.IndexStorage
+	indexService         *indices.Service
+	indexHandler         *handler.IndexHandler
+	rateLimiter          *handler.RateLimiter
+	apiKeys              []string
+	adminKeys            []string
+	healthHandler        *handler.HealthHandler
+	pollStatus           *polling.Status
+	delistingTracker     *polling.DelistingTracker
+	listingDetector      *listings.Detector
+	listingsHandler      *handler.ListingsHandler
+	exchangeHandler      *handler.ExchangeHandler
+	pairHandler          *handler.PairHandler
+	marketCapService     *marketcap.Service
+	mappingScores        *confidence.Service
+	reconciler           *reconcile.Service
+	reconcileHandler     *handler.ReconciliationHandler
+	operationsHandler    *handler.OperationsHandler
+	volumeShares         *volumeshare.Service
+	exchangeWeights      *weighting.Weights
+	maintenance          *maintenance.Schedule
+	dynamicWeights       *weighting.Service
+	trustScores          *trust.Service
+	volumeShareHandler   *handler.VolumeShareHandler
+	mappingSetHandler    *handler.MappingSetHandler
+	fxService            *fx.Service
+	ingester             *ingester.BinanceIngester
+	scheduler            *scheduler.Scheduler
+	statusHandler        *handler.StatusHandler
 }
 
+// @title Crypto Market Data API
+// @version 1.0
+// @description Cross-exchange prices, VWAP and token metadata served by the REST poller application.
+// @BasePath /
+// @schemes http https
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Printf("No .env file found: %v", err)
 	}
 
+	roles, ok, err := parseCommand(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n\n%s", err, usage)
+		os.Exit(2)
+	}
+	if !ok {
+		fmt.Print(usage)
+		return
+	}
+
 	// Initialize logger
-	logger, err := zap.NewProduction()
+	logger, err := utils.InitLogger(config.LoadLogConfig())
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
@@ -65,59 +161,181 @@ func main() {
 	defer app.closeDatabases()
 
 	// Initialize exchange factory
-	factory, err := exchanges.NewExchangeFactory("configs/exchanges.json", logger)
+	factory, err := exchanges.NewExchangeFactory(getEnv("EXCHANGES_CONFIG", "configs/exchanges.json"), logger.Named("exchanges"))
 	if err != nil {
 		logger.Fatal("Failed to create exchange factory", zap.Error(err))
 	}
+	factory.SetTransport(exchanges.NewTransport(loadTransportConfig()))
+	factory.SetLimits(loadExchangeLimits())
 	app.factory = factory
+	app.syncExchangeRegistry()
 
 	// Initialize symbol resolver
-	app.symbolResolver = symbol.NewResolver(app.postgresDB, logger)
-
-	// Initialize VWAP calculator
-	app.vwapCalc = calculator.NewVWAPCalculator(logger)
+	app.symbolResolver = symbol.NewResolver(app.postgresDB, logger.Named("resolver"))
 
 	// Initialize storage services
-	app.priceStorage = storage.NewPriceStorage(app.clickhouseDB, logger)
-	app.vwapStorage = storage.NewVWAPStorage(app.clickhouseDB, logger)
+	app.priceStorage = storage.NewPriceStorage(app.clickhouseDB, logger.Named("storage"))
+	app.opsStorage = storage.NewOpsStorage(app.clickhouseDB, logger.Named("storage"))
+	app.vwapStorage = storage.NewVWAPStorage(app.clickhouseDB, logger.Named("storage"))
+	app.indexStorage = storage.NewIndexStorage(app.clickhouseDB, logger.Named("storage"))
+
+	// Outlier thresholds, shared by VWAP outlier removal and the detector
+	vwapConfig := loadVWAPConfig()
+	app.outlierThresholds = outlier.NewOverrides(app.postgresDB, vwapConfig.Outliers, logger.Named("outlier"))
+	vwapConfig.OutlierOverrides = app.outlierThresholds
+
+	// Feature flags gating pipeline changes, from FEATURE_FLAGS and their
+	// overrides in PostgreSQL
+	flagValues, err := features.ParseEnv(os.Getenv("FEATURE_FLAGS"))
+	if err != nil {
+		logger.Fatal("Invalid FEATURE_FLAGS", zap.Error(err))
+	}
+	app.featureFlags = features.NewFlags(app.postgresDB, flagValues, logger.Named("features"))
+
+	// VWAP weights: the registry's, or the daily dynamic ones while the
+	// dynamic_exchange_weights flag is on
+	app.exchangeWeights = weighting.NewWeights(app.postgresDB, factory.Weights(), app.featureFlags, logger.Named("weighting"))
+
+	// Scheduled exchange maintenance, during which the pollers skip the exchange
+	app.maintenance = maintenance.NewSchedule(app.postgresDB, logger.Named("maintenance"))
+
+	// Initialize VWAP service, which calculates from the stored tickers
+	app.vwapService = vwap.NewService(app.clickhouseDB, app.postgresDB, app.vwapStorage, vwap.Config{
+		Calculator:           vwapConfig,
+		ExchangeWeights:      app.exchangeWeights,
+		MinMappingConfidence: getEnvFloat("VWAP_MIN_MAPPING_CONFIDENCE", 0.5),
+		FlaggedMappingWindow: getEnvDuration("VWAP_FLAGGED_MAPPING_WINDOW", 24*time.Hour),
+		FXMaxAge:             getEnvDuration("FX_MAX_AGE", 96*time.Hour),
+		Flags:                app.featureFlags,
+	}, logger.Named("vwap"))
+	app.fxService = fx.NewService(app.postgresDB, os.Getenv("FX_URL"), logger.Named("fx"))
 
 	// Initialize outlier detector
-	app.outlierDetector = outlier.NewDetector(app.postgresDB, app.clickhouseDB, logger)
+	app.outlierDetector = outlier.NewDetector(app.postgresDB, app.clickhouseDB, app.outlierThresholds, logger.Named("outlier"))
+
+	// Handlers share one module logger, so LOG_MODULE_LEVELS=api=... covers them
+	apiLogger := logger.Named("api")
 
 	// Initialize verification handler
-	app.verificationHandler = handler.NewVerificationHandler(app.postgresDB, app.outlierDetector, logger)
+	app.verificationHandler = handler.NewVerificationHandler(app.postgresDB, app.outlierDetector, apiLogger)
+	app.thresholdHandler = handler.NewOutlierThresholdHandler(app.postgresDB, app.outlierThresholds, apiLogger)
+	app.featureFlagHandler = handler.NewFeatureFlagHandler(app.postgresDB, app.featureFlags, apiLogger)
+	app.resolverHandler = handler.NewResolverHandler(app.symbolResolver, apiLogger)
+	app.listingsHandler = handler.NewListingsHandler(app.postgresDB, apiLogger)
+	app.exchangeHandler = handler.NewExchangeHandler(app.postgresDB, apiLogger)
+	app.pairHandler = handler.NewPairHandler(app.postgresDB, apiLogger)
+	app.tokenOps = tokenops.NewService(app.postgresDB, app.clickhouseDB, logger.Named("tokenops"))
+	app.tokenAdminHandler = handler.NewTokenAdminHandler(app.tokenOps, app.symbolResolver, apiLogger)
+	app.mappingAdminHandler = handler.NewMappingAdminHandler(app.postgresDB, app.symbolResolver, apiLogger)
+
+	// Initialize GraphQL handler
+	app.graphqlHandler = handler.NewGraphQLHandler(app.postgresDB, app.vwapStorage, apiLogger)
+
+	// Initialize market data handlers
+	app.ohlcvHandler = handler.NewOHLCVHandler(app.clickhouseDB, app.postgresDB, apiLogger)
+	app.tradeHandler = handler.NewTradeHandler(app.clickhouseDB, apiLogger)
+	app.vwapHandler = handler.NewVWAPHandler(app.postgresDB, app.vwapStorage, apiLogger)
+	app.priceHandler = handler.NewPriceHandler(app.postgresDB, app.vwapStorage, apiLogger)
+	app.watchlistHandler = handler.NewWatchlistHandler(app.postgresDB, app.vwapStorage, apiLogger)
+	app.tickerHub = stream.NewTickerHub(app.postgresDB, app.vwapStorage, getEnvInt("STREAM_MAX_CLIENTS", 1000), logger.Named("stream"))
+	app.streamHandler = handler.NewStreamHandler(app.postgresDB, app.tickerHub, getEnvDuration("STREAM_HEARTBEAT", 15*time.Second), apiLogger)
+	app.webhookDispatcher = webhooks.NewDispatcher(app.postgresDB, app.tickerHub, loadWebhookConfig(), logger.Named("webhooks"))
+	app.webhookHandler = handler.NewWebhookHandler(app.postgresDB, app.webhookDispatcher, apiLogger)
+	app.tokenHandler = handler.NewTokenHandler(app.postgresDB, app.vwapStorage, app.priceStorage, apiLogger)
+	app.tickerHandler = handler.NewTickerHandler(app.clickhouseDB, app.postgresDB, apiLogger)
+	windows, err := analytics.ParseWindows(getEnv("CORRELATION_WINDOWS", "7d,30d"))
+	if err != nil {
+		logger.Fatal("Invalid CORRELATION_WINDOWS", zap.Error(err))
+	}
+	app.correlationService = analytics.NewCorrelationService(app.postgresDB, app.vwapStorage, getEnvInt("CORRELATION_TOP_N", 20), windows, logger.Named("analytics"))
+	app.analyticsHandler = handler.NewAnalyticsHandler(app.clickhouseDB, app.correlationService, apiLogger)
+
+	// Initialize market cap and index computation
+	app.marketCapService = marketcap.NewService(app.postgresDB, app.vwapStorage, logger.Named("marketcap"))
+	app.mappingScores = confidence.NewService(app.postgresDB, app.clickhouseDB, logger.Named("confidence"))
+	app.reconciler = reconcile.NewService(app.postgresDB, app.clickhouseDB, loadReconcileConfig(), logger.Named("reconcile"))
+	app.reconcileHandler = handler.NewReconciliationHandler(app.postgresDB, app.reconciler, apiLogger)
+	app.operationsHandler = handler.NewOperationsHandler(app.opsStorage, apiLogger)
+	app.volumeShares = volumeshare.NewService(app.postgresDB, app.clickhouseDB, app.vwapStorage,
+		app.exchangeWeights, vwap.DefaultExchangeWeight, logger.Named("volumeshare"))
+	app.dynamicWeights = weighting.NewService(app.postgresDB, app.volumeShares, app.opsStorage,
+		app.exchangeWeights, loadWeightingConfig(), logger.Named("weighting"))
+	app.trustScores = trust.NewService(app.postgresDB, app.clickhouseDB, logger.Named("trust"))
+	app.volumeShareHandler = handler.NewVolumeShareHandler(app.postgresDB, app.volumeShares, apiLogger)
+	app.mappingSetHandler = handler.NewMappingSetHandler(app.postgresDB, apiLogger)
+	app.indexService = indices.NewService(app.postgresDB, app.vwapStorage, app.indexStorage, logger.Named("indices"))
+	app.indexHandler = handler.NewIndexHandler(app.postgresDB, app.indexStorage, apiLogger)
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	app.tasks = supervisor.NewGroup(ctx, supervisor.DefaultPolicy, logger.Named("supervisor"))
 
 	// Handle shutdown signals
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Determine service mode
-	serviceMode := os.Getenv("SERVICE_MODE")
-	if serviceMode == "" {
-		serviceMode = "all"
+	logger.Info("Starting", zap.String("roles", roles.String()))
+
+	// Track poll freshness for readiness checks when the poller runs here
+	if roles[rolePoller] {
+		app.pollStatus = polling.NewStatus()
+		app.delistingTracker = polling.NewDelistingTracker(app.postgresDB,
+			getEnvInt("DELIST_AFTER_POLLS", polling.DefaultDelistAfterPolls), logger.Named("polling"))
+		app.listingDetector = listings.NewDetector(app.postgresDB, logger.Named("listings"))
+	}
+	if roles[roleIngester] {
+		pipeline, err := loadIngesterPipelineConfig()
+		if err != nil {
+			logger.Fatal("Invalid ingester configuration", zap.Error(err))
+		}
+		app.ingester = ingester.NewBinanceIngester(app.clickhouseDB, logger.Named("ingester"), loadBinanceConfig(), pipeline,
+			loadReconnectPolicy())
 	}
+	if roles[roleScheduler] {
+		app.scheduler = scheduler.NewScheduler(app.postgresDB, logger.Named("scheduler"))
+	}
+	maxPollAge := 3 * pollInterval()
+	if v := os.Getenv("READY_MAX_POLL_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			maxPollAge = d
+		}
+	}
+	app.healthHandler = handler.NewHealthHandler(app.postgresDB, app.clickhouseDB, app.pollStatus, maxPollAge, app.ingester, app.tasks, apiLogger)
+	app.statusHandler = handler.NewStatusHandler(roles.String(), app.pollStatus, app.ingester, app.scheduler,
+		app.symbolResolver, app.tasks, apiLogger)
 
-	// Start services
+	// Start services. Background jobs run supervised, so a panic is logged
+	// and the job restarted with backoff; restart counts show in /readyz.
 	var wg sync.WaitGroup
 
-	switch serviceMode {
-	case "poller":
-		wg.Add(1)
-		go app.runPoller(ctx, &wg)
-	case "api":
-		wg.Add(1)
-		go app.runAPI(ctx, &wg)
-	case "all":
-		wg.Add(2)
-		go app.runPoller(ctx, &wg)
+	if roles[rolePoller] {
+		app.startPollerJobs()
+	}
+	if app.ingester != nil {
+		app.tasks.Go("ingester", app.runIngester)
+	}
+	if app.scheduler != nil {
+		app.tasks.Go("scheduler", app.runScheduler)
+	}
+	wg.Add(1)
+	if roles[roleAPI] {
+		app.tasks.Go("correlation", app.runCorrelationJob)
 		go app.runAPI(ctx, &wg)
-	default:
-		logger.Fatal("Invalid SERVICE_MODE", zap.String("mode", serviceMode))
+	} else {
+		// Without the API, the probes are still served for the orchestrator
+		go app.runProbes(ctx, &wg)
 	}
+	app.tasks.Go("resolver", app.symbolResolver.Run)
+	app.tasks.Go("resolver_writeback", app.symbolResolver.RunWriteBack)
+	thresholdsRefresh := getEnvDuration("OUTLIER_THRESHOLDS_REFRESH", time.Minute)
+	app.tasks.Go("outlier_thresholds", func(ctx context.Context) error {
+		return app.outlierThresholds.Run(ctx, thresholdsRefresh)
+	})
+	flagsRefresh := getEnvDuration("FEATURE_FLAGS_REFRESH", 30*time.Second)
+	app.tasks.Go("feature_flags", func(ctx context.Context) error {
+		return app.featureFlags.Run(ctx, flagsRefresh)
+	})
 
 	// Wait for shutdown signal
 	<-sigChan
@@ -126,6 +344,7 @@ func main() {
 
 	// Wait for services to finish
 	wg.Wait()
+	app.tasks.Wait()
 	logger.Info("All services stopped")
 }
 
@@ -194,23 +413,105 @@ func (app *Application) closeDatabases() {
 	}
 }
 
-func (app *Application) runPoller(ctx context.Context, wg *sync.WaitGroup) {
-	defer wg.Done()
+// syncExchangeRegistry registers the exchanges in configs/exchanges.json that
+// the exchanges table does not have yet, then points the factory at the
+// table, so exchanges added, reweighted or deactivated through the admin API
+// are polled that way. Without the table the config file is used as is.
+func (app *Application) syncExchangeRegistry() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	added, err := db.SeedExchanges(ctx, app.postgresDB, app.factory.Configs())
+	if err != nil {
+		app.logger.Warn("Failed to seed exchange registry, using configs/exchanges.json", zap.Error(err))
+		return
+	}
+	registered, err := db.ListExchanges(ctx, app.postgresDB, true)
+	if err != nil {
+		app.logger.Warn("Failed to load exchange registry, using configs/exchanges.json", zap.Error(err))
+		return
+	}
+
+	configs := make([]exchanges.ExchangeConfig, 0, len(registered))
+	for _, e := range registered {
+		configs = append(configs, e.ExchangeConfig)
+	}
+	app.factory.SetConfigs(configs)
+	app.logger.Info("Loaded exchange registry",
+		zap.Int("exchanges", len(configs)),
+		zap.Int("added_from_config", added))
+}
+
+// startPollerJobs starts the exchange poller and the jobs computing from the
+// prices it collects
+func (app *Application) startPollerJobs() {
+	app.tasks.Go("poller", app.runPoller)
+	app.tasks.Go("vwap", app.runVWAPJob)
+	app.tasks.Go("fx", app.runFXJob)
+	app.tasks.Go("marketcap", app.runMarketCapJob)
+	scoreAt := getEnvDuration("MAPPING_CONFIDENCE_AT", 3*time.Hour)
+	app.tasks.Go("mapping_confidence", func(ctx context.Context) error {
+		return app.mappingScores.Run(ctx, scoreAt)
+	})
+	shareAt := getEnvDuration("VOLUME_SHARE_AT", 23*time.Hour+30*time.Minute)
+	app.tasks.Go("volume_share", func(ctx context.Context) error {
+		return app.volumeShares.Run(ctx, shareAt)
+	})
+	weightsAt := getEnvDuration("EXCHANGE_WEIGHTS_AT", 23*time.Hour+45*time.Minute)
+	app.tasks.Go("dynamic_weights", func(ctx context.Context) error {
+		return app.dynamicWeights.Run(ctx, weightsAt)
+	})
+	trustInterval := getEnvDuration("TRUST_SCORE_INTERVAL", time.Hour)
+	app.tasks.Go("trust", func(ctx context.Context) error {
+		return app.trustScores.Run(ctx, trustInterval)
+	})
+	maintenanceRefresh := getEnvDuration("MAINTENANCE_REFRESH", time.Minute)
+	app.tasks.Go("maintenance", func(ctx context.Context) error {
+		return app.maintenance.Run(ctx, maintenanceRefresh)
+	})
+	weightsRefresh := getEnvDuration("EXCHANGE_WEIGHTS_REFRESH", 5*time.Minute)
+	app.tasks.Go("exchange_weights", func(ctx context.Context) error {
+		return app.exchangeWeights.Run(ctx, weightsRefresh)
+	})
+	app.tasks.Go("reconciliation", app.reconciler.Run)
+	if archiveAfter := getEnvDuration("TOKEN_ARCHIVE_AFTER", 0); archiveAfter > 0 {
+		archiveInterval := getEnvDuration("TOKEN_ARCHIVE_INTERVAL", 24*time.Hour)
+		app.tasks.Go("token_archival", func(ctx context.Context) error {
+			return app.tokenOps.RunArchival(ctx, archiveInterval, archiveAfter)
+		})
+	}
+	if getEnv("TRADES_POLL_ENABLED", "false") == "true" {
+		app.tasks.Go("trade_poller", app.runTradePoller)
+	}
+	if getEnv("KLINES_PAIRS", "") != "" {
+		app.tasks.Go("klines", app.runKlineFetcher)
+	}
+}
+
+// runIngester streams Binance trades into ClickHouse until shutdown
+func (app *Application) runIngester(ctx context.Context) error {
+	app.ingester.Start()
+	<-ctx.Done()
+	app.ingester.Stop()
+	return nil
+}
+
+// runScheduler runs the cron jobs until shutdown
+func (app *Application) runScheduler(ctx context.Context) error {
+	app.scheduler.Start()
+	<-ctx.Done()
+	app.scheduler.Stop()
+	return nil
+}
+
+func (app *Application) runPoller(ctx context.Context) error {
 	app.logger.Info("Starting polling service...")
 
 	// Get all exchange clients
 	clients := app.factory.CreateAllClients()
 	app.logger.Info("Created exchange clients", zap.Int("count", len(clients)))
 
-	// Polling interval
-	pollInterval := 15 * time.Second
-	if interval := os.Getenv("POLL_INTERVAL"); interval != "" {
-		if d, err := time.ParseDuration(interval); err == nil {
-			pollInterval = d
-		}
-	}
-
-	ticker := time.NewTicker(pollInterval)
+	ticker := time.NewTicker(pollInterval())
 	defer ticker.Stop()
 
 	// Initial poll
@@ -220,14 +521,180 @@ func (app *Application) runPoller(ctx context.Context, wg *sync.WaitGroup) {
 		select {
 		case <-ctx.Done():
 			app.logger.Info("Polling service stopped")
-			return
+			return nil
 		case <-ticker.C:
 			app.pollExchanges(ctx, clients)
 		}
 	}
 }
 
-func (app *Application) resolveTokenIDs(tickers []exchanges.TickerData) {
+// runTradePoller fetches recent trades from the exchanges with a trades
+// endpoint, using clients of its own so trade requests do not count against
+// the ticker poller's exchange health
+func (app *Application) runTradePoller(ctx context.Context) error {
+	cfg := polling.DefaultTradeConfig()
+	cfg.Interval = getEnvDuration("TRADES_POLL_INTERVAL", cfg.Interval)
+	cfg.PairsPerExchange = getEnvInt("TRADES_POLL_PAIRS", cfg.PairsPerExchange)
+	cfg.PairsRefresh = getEnvDuration("TRADES_POLL_PAIRS_REFRESH", cfg.PairsRefresh)
+
+	poller := polling.NewTradePoller(app.clickhouseDB, app.factory.CreateAllClients(), app.maintenance, cfg, app.logger.Named("trades"))
+	return poller.Run(ctx)
+}
+
+// runKlineFetcher stores the exchanges' own candles for KLINES_PAIRS at
+// KLINES_INTERVALS. An invalid setting is logged and the fetcher is not
+// started, leaving the other jobs running.
+func (app *Application) runKlineFetcher(ctx context.Context) error {
+	pairs, err := klines.ParsePairs(getEnv("KLINES_PAIRS", ""))
+	if err != nil {
+		app.logger.Error("Invalid KLINES_PAIRS, not fetching klines", zap.Error(err))
+		return nil
+	}
+	intervals, err := klines.ParseIntervals(getEnv("KLINES_INTERVALS", "1m,1h"))
+	if err != nil {
+		app.logger.Error("Invalid KLINES_INTERVALS, not fetching klines", zap.Error(err))
+		return nil
+	}
+
+	cfg := klines.Config{
+		Pairs:     pairs,
+		Intervals: intervals,
+		Every:     getEnvDuration("KLINES_EVERY", time.Minute),
+	}
+	fetcher := klines.NewFetcher(app.clickhouseDB, app.factory.Configs(), app.factory.Transport(), cfg, app.logger.Named("klines"))
+	return fetcher.Run(ctx)
+}
+
+// runVWAPJob calculates VWAP from the stored tickers every VWAP_INTERVAL,
+// clamped to 1s-60s, then updates index levels from the new VWAPs
+func (app *Application) runVWAPJob(ctx context.Context) error {
+	interval := vwapInterval()
+	app.logger.Info("Starting VWAP job...", zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			app.logger.Info("VWAP job stopped")
+			return nil
+		case <-ticker.C:
+			if _, err := app.vwapService.CalculateAndStore(ctx); err != nil {
+				app.logger.Error("Failed to calculate VWAP", zap.Error(err))
+				continue
+			}
+
+			// Index levels follow the VWAPs just stored
+			if err := app.indexService.ComputeAndStore(ctx); err != nil {
+				app.logger.Error("Failed to compute index levels", zap.Error(err))
+			}
+		}
+	}
+}
+
+// vwapInterval returns VWAP_INTERVAL clamped to 1s-60s, defaulting to 15s
+func vwapInterval() time.Duration {
+	interval := getEnvDuration("VWAP_INTERVAL", 15*time.Second)
+	if interval < time.Second {
+		interval = time.Second
+	}
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	return interval
+}
+
+// runFXJob fetches the daily fiat rates on start and then every FX_INTERVAL,
+// so fiat-quoted pairs can be converted into USD VWAPs
+func (app *Application) runFXJob(ctx context.Context) error {
+	interval := getEnvDuration("FX_INTERVAL", 24*time.Hour)
+	app.logger.Info("Starting FX job...", zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := app.fxService.Refresh(ctx); err != nil && ctx.Err() == nil {
+			app.logger.Error("Failed to refresh fx rates", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			app.logger.Info("FX job stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runMarketCapJob recomputes market caps and ranks from our own VWAP every
+// MARKET_CAP_INTERVAL
+func (app *Application) runMarketCapJob(ctx context.Context) error {
+	interval := getEnvDuration("MARKET_CAP_INTERVAL", 5*time.Minute)
+	app.logger.Info("Starting market cap job...", zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			app.logger.Info("Market cap job stopped")
+			return nil
+		case <-ticker.C:
+			if err := app.marketCapService.ComputeAndStore(ctx); err != nil {
+				app.logger.Error("Failed to compute market caps", zap.Error(err))
+			}
+		}
+	}
+}
+
+// runCorrelationJob refreshes the cached correlation matrices served by the
+// API on start and then every CORRELATION_REFRESH_INTERVAL
+func (app *Application) runCorrelationJob(ctx context.Context) error {
+	interval := getEnvDuration("CORRELATION_REFRESH_INTERVAL", time.Hour)
+	app.logger.Info("Starting correlation job...", zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := app.correlationService.Refresh(ctx); err != nil && ctx.Err() == nil {
+			app.logger.Error("Failed to refresh correlations", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			app.logger.Info("Correlation job stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollInterval returns POLL_INTERVAL, defaulting to 15s
+func pollInterval() time.Duration {
+	if interval := os.Getenv("POLL_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil {
+			return d
+		}
+	}
+	return 15 * time.Second
+}
+
+// resolveTokenIDs sets the token IDs of tickers. Everything the cache lacks
+// is prefetched in a few queries up front, and mappings and pairs found here
+// are written back in the background.
+func (app *Application) resolveTokenIDs(ctx context.Context, tickers []exchanges.TickerData) {
+	lookups := make([]symbol.Lookup, len(tickers))
+	for i, t := range tickers {
+		lookups[i] = symbol.Lookup{ExchangeID: t.ExchangeID, PairSymbol: t.Symbol, Base: t.BaseSymbol, Quote: t.QuoteSymbol}
+	}
+	if err := app.symbolResolver.Prefetch(ctx, lookups); err != nil {
+		app.logger.Error("Failed to prefetch symbols", zap.Error(err))
+	}
+
 	for i := range tickers {
 		ticker := &tickers[i]
 		
@@ -241,8 +708,8 @@ func (app *Application) resolveTokenIDs(tickers []exchanges.TickerData) {
 		
 		// Fallback: try to resolve individual symbols
 		// Note: In the future, we should check if we have slug data from exchange
-		baseID, baseMethod, err1 := app.resolveToken(ticker.ExchangeID, ticker.BaseSymbol, "")
-		quoteID, quoteMethod, err2 := app.resolveToken(ticker.ExchangeID, ticker.QuoteSymbol, "")
+		baseID, baseMethod, err1 := app.resolveToken(ticker.ExchangeID, ticker.BaseSymbol, "", ticker.Chain)
+		quoteID, quoteMethod, err2 := app.resolveToken(ticker.ExchangeID, ticker.QuoteSymbol, "", "")
 		
 		if err1 == nil && err2 == nil {
 			ticker.BaseTokenID = baseID
@@ -255,7 +722,7 @@ func (app *Application) resolveTokenIDs(tickers []exchanges.TickerData) {
 			}
 			
 			// Add this pair to the database for future use
-			app.symbolResolver.AddTradingPair(baseID, quoteID, ticker.ExchangeID, ticker.Symbol)
+			app.symbolResolver.QueueTradingPair(baseID, quoteID, ticker.ExchangeID, ticker.Symbol, string(ticker.Instrument()))
 			
 			// Log if symbol-based mapping was used
 			if method == "symbol" {
@@ -278,15 +745,16 @@ func (app *Application) resolveTokenIDs(tickers []exchanges.TickerData) {
 	}
 }
 
-// resolveToken attempts to resolve a single token with method tracking
-func (app *Application) resolveToken(exchangeID, symbol, slug string) (int, string, error) {
+// resolveToken attempts to resolve a single token with method tracking. chain
+// is set when the exchange says which chain the listing is on.
+func (app *Application) resolveToken(exchangeID, symbol, slug, chain string) (int, string, error) {
 	// If we have a slug, use the slug-based resolver
 	if slug != "" {
 		return app.symbolResolver.ResolveWithSlug(exchangeID, symbol, slug)
 	}
 	
 	// Try direct symbol resolution
-	tokenID, err := app.symbolResolver.ResolveSymbol(exchangeID, symbol)
+	tokenID, err := app.symbolResolver.ResolveSymbolOnChain(exchangeID, symbol, chain)
 	if err == nil {
 		return tokenID, "symbol", nil
 	}
@@ -294,27 +762,45 @@ func (app *Application) resolveToken(exchangeID, symbol, slug string) (int, stri
 	// Try normalized symbol as last resort
 	if id, err := app.symbolResolver.GetTokenByNormalizedSymbol(symbol); err == nil {
 		// Add mapping for future use with lower confidence
-		app.symbolResolver.AddSymbolMappingWithMethod(id, exchangeID, symbol, 
-			symbol, "symbol", 0.75)
+		app.symbolResolver.QueueSymbolMapping(id, exchangeID, symbol, symbol, 0.75)
 		return id, "symbol", nil
 	}
 	
 	return 0, "", fmt.Errorf("unable to resolve %s", symbol)
 }
 
+// pollExchanges fetches tickers from every healthy exchange, resolves their
+// token IDs and stores them. VWAP is calculated from the stored tickers by
+// runVWAPJob.
 func (app *Application) pollExchanges(ctx context.Context, clients map[string]exchanges.ExchangeClient) {
 	app.logger.Debug("Starting poll cycle")
 
 	// Collect prices from all exchanges
 	var wg sync.WaitGroup
 	pricesChan := make(chan []exchanges.TickerData, len(clients))
+	polled := 0
+	var outcomesMu sync.Mutex
+	outcomes := make(map[string]bool, len(clients))
+	dropped := make(map[string]polling.Dropped, len(clients))
+	samples := make([]storage.ExchangeHealthSample, 0, len(clients))
 
+	now := time.Now()
 	for id, client := range clients {
+		// Nothing is recorded for an exchange in maintenance, so the downtime
+		// counts neither as poll failures nor against its uptime
+		if w, ok := app.maintenance.Active(id, now); ok {
+			app.logger.Debug("Skipping exchange in maintenance",
+				zap.String("exchange", id),
+				zap.Time("until", w.End),
+				zap.String("reason", w.Reason))
+			continue
+		}
 		if !client.IsHealthy() {
 			app.logger.Warn("Skipping unhealthy exchange", zap.String("exchange", id))
 			continue
 		}
 
+		polled++
 		wg.Add(1)
 		go func(exchangeID string, c exchanges.ExchangeClient) {
 			defer wg.Done()
@@ -322,7 +808,40 @@ func (app *Application) pollExchanges(ctx context.Context, clients map[string]ex
 			ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 			defer cancel()
 
-			tickers, err := c.GetAllTickers(ctx)
+			// A panicking client counts as a failed exchange for this cycle
+			var tickers []exchanges.TickerData
+			started := time.Now()
+			err := supervisor.Call(ctx, func(ctx context.Context) (err error) {
+				tickers, err = c.GetAllTickers(ctx)
+				return err
+			})
+			sample := storage.ExchangeHealthSample{
+				Timestamp:      started,
+				ExchangeID:     exchangeID,
+				ResponseTime:   time.Since(started),
+				Success:        err == nil,
+				SymbolsFetched: len(tickers),
+			}
+			if err != nil {
+				sample.ErrorMessage = err.Error()
+			}
+			outcomesMu.Lock()
+			outcomes[exchangeID] = err == nil
+			samples = append(samples, sample)
+			// Leveraged tokens and derivatives are tagged by the client and only
+			// stored when non-spot ingest is on; composites read spot either way
+			ingestNonSpot := app.featureFlags.Enabled(features.NonSpotIngest)
+			if fc, ok := c.(exchanges.FilteringClient); ok && err == nil {
+				d := polling.Dropped{Filtered: fc.FilteredTickers()}
+				if !ingestNonSpot {
+					d.NonSpot = fc.NonSpotTickers()
+				}
+				dropped[exchangeID] = d
+			}
+			outcomesMu.Unlock()
+			if err == nil && !ingestNonSpot {
+				tickers = exchanges.DropNonSpot(tickers)
+			}
 			if err != nil {
 				app.logger.Error("Failed to get tickers",
 					zap.String("exchange", exchangeID),
@@ -330,6 +849,14 @@ func (app *Application) pollExchanges(ctx context.Context, clients map[string]ex
 				return
 			}
 
+			if app.delistingTracker != nil {
+				if err := app.delistingTracker.Observe(ctx, exchangeID, tickers); err != nil {
+					app.logger.Error("Failed to track delisted pairs",
+						zap.String("exchange", exchangeID),
+						zap.Error(err))
+				}
+			}
+
 			pricesChan <- tickers
 		}(id, client)
 	}
@@ -340,81 +867,106 @@ func (app *Application) pollExchanges(ctx context.Context, clients map[string]ex
 		close(pricesChan)
 	}()
 
-	// Collect all prices
-	var allPrices []exchanges.TickerData
-	for prices := range pricesChan {
-		allPrices = append(allPrices, prices...)
+	// Handle each exchange's tickers as they arrive instead of concatenating
+	// every exchange into one slice, so a cycle holds at most a few
+	// exchanges' tickers at once and slow exchanges overlap with storing
+	succeeded, total := 0, 0
+	var storeErrs []error
+	for tickers := range pricesChan {
+		succeeded++
+		total += len(tickers)
+		if err := app.processTickers(ctx, tickers); err != nil {
+			storeErrs = append(storeErrs, err)
+		}
 	}
 
 	app.logger.Info("Collected prices",
-		zap.Int("total", len(allPrices)),
+		zap.Int("total", total),
 		zap.Int("exchanges", len(clients)))
 
-	// Resolve token IDs for all tickers
-	app.resolveTokenIDs(allPrices)
-
-	// Store raw price tickers in ClickHouse
-	if err := app.priceStorage.StorePriceTickers(ctx, allPrices); err != nil {
-		app.logger.Error("Failed to store price tickers", zap.Error(err))
+	if err := db.RecordExchangePolls(ctx, app.postgresDB, outcomes); err != nil {
+		app.logger.Warn("Failed to record exchange poll health", zap.Error(err))
 	}
-
-	// Group prices by token pair for VWAP calculation
-	pricesByPair := make(map[string][]calculator.PriceData)
-	for _, ticker := range allPrices {
-		// Skip if tokens are not resolved
-		if ticker.BaseTokenID == 0 || ticker.QuoteTokenID == 0 {
-			continue
-		}
-		// Use token IDs as the key for consistent grouping
-		pairKey := fmt.Sprintf("%d-%d", ticker.BaseTokenID, ticker.QuoteTokenID)
-
-		// Get exchange weight from client
-		weight := decimal.NewFromFloat(0.01) // Default weight
-		if client, ok := clients[ticker.ExchangeID]; ok {
-			weight = decimal.NewFromFloat(client.GetWeight())
-		}
-
-		pricesByPair[pairKey] = append(pricesByPair[pairKey], calculator.PriceData{
-			ExchangeID:   ticker.ExchangeID,
-			Symbol:       ticker.Symbol,
-			BaseTokenID:  ticker.BaseTokenID,
-			QuoteTokenID: ticker.QuoteTokenID,
-			Price:        ticker.Price,
-			Volume:       ticker.Volume24h,
-			Weight:       weight, // Use exchange weight from config
-			Timestamp:    ticker.Timestamp,
-		})
+	if err := app.opsStorage.RecordExchangeHealth(ctx, samples); err != nil {
+		app.logger.Warn("Failed to store exchange poll health", zap.Error(err))
+	}
+	if app.pollStatus != nil {
+		app.pollStatus.RecordPoll(polled, succeeded, errors.Join(storeErrs...))
+		app.pollStatus.RecordFiltered(dropped)
 	}
-
-	// Calculate VWAP for each token pair
-	vwapResults := app.vwapCalc.CalculateBatch(pricesByPair)
-
-	// Store VWAP prices in ClickHouse
-	app.storeVWAPPrices(ctx, vwapResults)
 }
 
-func (app *Application) storeVWAPPrices(ctx context.Context, results map[string]*calculator.VWAPResult) {
-	if len(results) == 0 {
-		return
+// processTickers resolves the token IDs of one exchange's tickers, checks
+// them for new listings and stores them in ClickHouse
+func (app *Application) processTickers(ctx context.Context, tickers []exchanges.TickerData) error {
+	app.resolveTokenIDs(ctx, tickers)
+
+	if app.listingDetector != nil {
+		if err := app.listingDetector.Observe(ctx, tickers); err != nil {
+			app.logger.Error("Failed to detect new listings", zap.Error(err))
+		}
 	}
 
-	// Use the storage service to store VWAP results
-	if err := app.vwapStorage.StoreVWAPResults(ctx, results); err != nil {
-		app.logger.Error("Failed to store VWAP results", zap.Error(err))
+	if err := app.priceStorage.StorePriceTickers(ctx, tickers); err != nil {
+		app.logger.Error("Failed to store price tickers",
+			zap.String("exchange", tickers[0].ExchangeID),
+			zap.Error(err))
+		return err
 	}
+	return nil
 }
 
 func (app *Application) runAPI(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 	app.logger.Info("Starting API service...")
 
-	// Create Gin router
-	router := gin.New()
-	router.Use(gin.Recovery())
-	router.Use(gin.Logger())
+	// Requests are recorded for /api/v1/admin/api-usage
+	if getEnv("API_REQUEST_LOG_ENABLED", "true") == "true" {
+		app.requestLog = storage.NewRequestLog(app.clickhouseDB, getEnvInt("API_REQUEST_LOG_BUFFER", 10000), app.logger.Named("requestlog"))
+		flushEvery := getEnvDuration("API_REQUEST_LOG_FLUSH_INTERVAL", 5*time.Second)
+		app.tasks.Go("request_log", func(ctx context.Context) error {
+			app.requestLog.Run(ctx, flushEvery)
+			return nil
+		})
+	}
+
+	// Per-client rate limiting for public endpoints. The API keys also
+	// identify watchlist owners.
+	rateLimits := loadRateLimitConfig()
+	app.rateLimiter = handler.NewRateLimiter(rateLimits)
+	app.apiKeys = rateLimits.APIKeys
+
+	// Admin endpoints take their own keys so watchlist and webhook callers
+	// cannot remap tokens. Without any, every admin request is refused.
+	app.adminKeys = getEnvList("ADMIN_API_KEYS")
+	if len(app.adminKeys) == 0 {
+		app.logger.Warn("ADMIN_API_KEYS is not set; /api/v1/admin endpoints will reject every request")
+	}
+	app.tasks.Go("ratelimit_cleanup", func(ctx context.Context) error {
+		app.rateLimiter.RunCleanup(ctx)
+		return nil
+	})
+
+	// Shared price polling for /api/v1/stream/ticker; stopping it ends open
+	// streams so shutdown does not wait on them
+	streamInterval := getEnvDuration("STREAM_INTERVAL", 5*time.Second)
+	app.tasks.Go("stream", func(ctx context.Context) error {
+		app.tickerHub.Run(ctx, streamInterval)
+		return nil
+	})
+	app.tasks.Go("webhooks", func(ctx context.Context) error {
+		app.webhookDispatcher.Run(ctx)
+		return nil
+	})
 
-	// Setup routes
-	app.setupRoutes(router)
+	router := api.NewRouter(app.apiHandlers(), api.Options{
+		TrustedProxies:  getEnvList("TRUSTED_PROXIES"),
+		RequestLog:      app.requestLog,
+		CompressMinSize: getEnvInt("COMPRESS_MIN_BYTES", handler.DefaultCompressMinSize),
+		RateLimiter:     app.rateLimiter,
+		APIKeys:         app.apiKeys,
+		AdminKeys:       app.adminKeys,
+	}, app.logger.Named("http"))
 
 	// Start server
 	port := getEnv("SERVER_PORT", ":8080")
@@ -445,312 +997,253 @@ func (app *Application) runAPI(ctx context.Context, wg *sync.WaitGroup) {
 	app.logger.Info("API service stopped")
 }
 
-func (app *Application) setupRoutes(router *gin.Engine) {
-	// Health check
-	router.GET("/health", app.healthCheck)
-
-	// Serve admin dashboard
-	router.Static("/admin", "./web/admin")
-
-	// API v1 routes
-	v1 := router.Group("/api/v1")
-	{
-		// Exchange endpoints
-		v1.GET("/exchanges", app.getExchanges)
-		v1.GET("/exchanges/:id", app.getExchange)
-
-		// Token endpoints
-		v1.GET("/tokens", app.getTokens)
-		v1.GET("/tokens/:id", app.getToken)
-
-		// Ticker endpoints
-		v1.GET("/tickers", app.getAllTickers)
-		v1.GET("/tickers/:symbol", app.getTicker)
-
-		// VWAP endpoints
-		v1.GET("/vwap/:symbol", app.getVWAPPrice)
-		
-		// Verification endpoints (admin)
-		admin := v1.Group("/admin")
-		{
-			admin.GET("/mappings/unverified", app.getUnverifiedMappings)
-			admin.POST("/mappings/:id/verify", app.verifyMapping)
-			admin.POST("/mappings/:id/flag", app.flagMapping)
-			admin.GET("/outliers", app.getOutliers)
-			admin.POST("/outliers/:id/resolve", app.resolveOutlier)
-		}
-	}
-}
-
-// Handler functions
-func (app *Application) healthCheck(c *gin.Context) {
-	// Check database connections
-	pgHealthy := app.postgresDB.Ping() == nil
-	chHealthy := app.clickhouseDB.Ping(c.Request.Context()) == nil
-
-	status := "healthy"
-	if !pgHealthy || !chHealthy {
-		status = "degraded"
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"status": status,
-		"services": gin.H{
-			"postgres":   pgHealthy,
-			"clickhouse": chHealthy,
-		},
-		"timestamp": time.Now().Unix(),
-	})
-}
+// runProbes serves only the health checks, on SERVER_PORT, for processes
+// running without the API role
+func (app *Application) runProbes(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
 
-func (app *Application) getExchanges(c *gin.Context) {
-	query := `
-		SELECT exchange_id, name, is_active, last_successful_poll, consecutive_failures
-		FROM exchanges
-		WHERE is_active = true
-		ORDER BY weight DESC
-	`
+	router := api.NewProbeRouter(app.healthHandler, app.logger.Named("http"))
 
-	rows, err := app.postgresDB.Query(query)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	port := getEnv("SERVER_PORT", ":8080")
+	srv := &http.Server{
+		Addr:    port,
+		Handler: router,
 	}
-	defer rows.Close()
 
-	var exchanges []map[string]interface{}
-	for rows.Next() {
-		var id, name string
-		var isActive bool
-		var lastPoll sql.NullTime
-		var failures int
-
-		if err := rows.Scan(&id, &name, &isActive, &lastPoll, &failures); err != nil {
-			continue
+	go func() {
+		app.logger.Info("Health server starting", zap.String("port", port))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			app.logger.Fatal("Failed to start health server", zap.Error(err))
 		}
+	}()
 
-		exchange := map[string]interface{}{
-			"id":                   id,
-			"name":                 name,
-			"is_active":            isActive,
-			"consecutive_failures": failures,
-		}
+	<-ctx.Done()
 
-		if lastPoll.Valid {
-			exchange["last_successful_poll"] = lastPoll.Time
-		}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-		exchanges = append(exchanges, exchange)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		app.logger.Error("Failed to shutdown health server gracefully", zap.Error(err))
 	}
-
-	c.JSON(http.StatusOK, exchanges)
 }
 
-func (app *Application) getExchange(c *gin.Context) {
-	exchangeID := c.Param("id")
-
-	var id, name string
-	var isActive bool
-	var weight float64
-
-	query := `
-		SELECT exchange_id, name, is_active, weight
-		FROM exchanges
-		WHERE exchange_id = $1
-	`
-
-	err := app.postgresDB.QueryRow(query, exchangeID).Scan(&id, &name, &isActive, &weight)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Exchange not found"})
-		return
+// apiHandlers collects the handlers the API routes are registered on
+func (app *Application) apiHandlers() api.Handlers {
+	return api.Handlers{
+		Health:       app.healthHandler,
+		GraphQL:      app.graphqlHandler,
+		Exchange:     app.exchangeHandler,
+		VolumeShare:  app.volumeShareHandler,
+		Pair:         app.pairHandler,
+		Token:        app.tokenHandler,
+		Ticker:       app.tickerHandler,
+		VWAP:         app.vwapHandler,
+		Price:        app.priceHandler,
+		Stream:       app.streamHandler,
+		Watchlist:    app.watchlistHandler,
+		Webhook:      app.webhookHandler,
+		OHLCV:        app.ohlcvHandler,
+		Trade:        app.tradeHandler,
+		Listings:     app.listingsHandler,
+		Index:        app.indexHandler,
+		Analytics:    app.analyticsHandler,
+		Verification: app.verificationHandler,
+		MappingAdmin: app.mappingAdminHandler,
+		MappingSet:   app.mappingSetHandler,
+		Threshold:    app.thresholdHandler,
+		FeatureFlag:  app.featureFlagHandler,
+		Status:       app.statusHandler,
+		Resolver:     app.resolverHandler,
+		TokenAdmin:   app.tokenAdminHandler,
+		Reconcile:    app.reconcileHandler,
+		Operations:   app.operationsHandler,
 	}
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"id":        id,
-		"name":      name,
-		"is_active": isActive,
-		"weight":    weight,
-	})
 }
 
-func (app *Application) getTokens(c *gin.Context) {
-	query := `
-		SELECT id, symbol, name, current_price, market_cap, market_cap_rank
-		FROM tokens
-		WHERE is_active = true
-		ORDER BY market_cap_rank ASC NULLS LAST
-		LIMIT 100
-	`
-
-	rows, err := app.postgresDB.Query(query)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
-	defer rows.Close()
-
-	var tokens []map[string]interface{}
-	for rows.Next() {
-		var id, symbol, name string
-		var price, marketCap sql.NullFloat64
-		var rank sql.NullInt64
-
-		if err := rows.Scan(&id, &symbol, &name, &price, &marketCap, &rank); err != nil {
-			continue
-		}
-
-		token := map[string]interface{}{
-			"id":     id,
-			"symbol": symbol,
-			"name":   name,
-		}
+	return defaultValue
+}
 
-		if price.Valid {
-			token["price"] = price.Float64
-		}
-		if marketCap.Valid {
-			token["market_cap"] = marketCap.Float64
-		}
-		if rank.Valid {
-			token["rank"] = rank.Int64
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
 		}
-
-		tokens = append(tokens, token)
+		log.Printf("Invalid integer for %s: %q, using %d", key, value, defaultValue)
 	}
-
-	c.JSON(http.StatusOK, tokens)
+	return defaultValue
 }
 
-func (app *Application) getToken(c *gin.Context) {
-	tokenID := c.Param("id")
-
-	var id, symbol, name string
-	var price sql.NullFloat64
-
-	query := `
-		SELECT id, symbol, name, current_price
-		FROM tokens
-		WHERE id = $1
-	`
-
-	err := app.postgresDB.QueryRow(query, tokenID).Scan(&id, &symbol, &name, &price)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
-		return
-	}
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+		log.Printf("Invalid number for %s: %q, using %g", key, value, defaultValue)
 	}
+	return defaultValue
+}
 
-	result := gin.H{
-		"id":     id,
-		"symbol": symbol,
-		"name":   name,
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+		log.Printf("Invalid duration for %s: %q, using %s", key, value, defaultValue)
 	}
+	return defaultValue
+}
 
-	if price.Valid {
-		result["price"] = price.Float64
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
 	}
-
-	c.JSON(http.StatusOK, result)
+	return out
 }
 
-func (app *Application) getAllTickers(c *gin.Context) {
-	// For now, return from PostgreSQL tokens table
-	query := `
-		SELECT symbol, name, current_price, price_change_24h, trading_volume_24h
-		FROM tokens
-		WHERE is_active = true AND current_price > 0
-		ORDER BY market_cap_rank ASC NULLS LAST
-		LIMIT 100
-	`
-
-	rows, err := app.postgresDB.Query(query)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+// loadWeightingConfig reads the cap and smoothing of the dynamic exchange
+// weights from the environment
+func loadWeightingConfig() weighting.Config {
+	return weighting.Config{
+		Cap:       getEnvFloat("EXCHANGE_WEIGHT_CAP", 0.25),
+		Smoothing: getEnvFloat("EXCHANGE_WEIGHT_SMOOTHING", 0.3),
 	}
-	defer rows.Close()
-
-	var tickers []map[string]interface{}
-	for rows.Next() {
-		var symbol, name string
-		var price, priceChange, volume sql.NullFloat64
-
-		if err := rows.Scan(&symbol, &name, &price, &priceChange, &volume); err != nil {
-			continue
-		}
-
-		ticker := map[string]interface{}{
-			"symbol": symbol,
-			"name":   name,
-		}
+}
 
-		if price.Valid {
-			ticker["price"] = price.Float64
-		}
-		if priceChange.Valid {
-			ticker["price_change_24h"] = priceChange.Float64
-		}
-		if volume.Valid {
-			ticker["volume_24h"] = volume.Float64
+// loadVWAPConfig reads the VWAP quorum, staleness and default outlier rules
+// from the environment
+func loadVWAPConfig() calculator.Config {
+	cfg := calculator.DefaultConfig()
+	cfg.Outliers.MaxDeviation = getEnvFloat("OUTLIER_MAX_DEVIATION", cfg.Outliers.MaxDeviation)
+	cfg.Outliers.MaxStdDevs = getEnvFloat("OUTLIER_MAX_STD_DEVS", cfg.Outliers.MaxStdDevs)
+	cfg.Outliers.MinSamples = getEnvInt("OUTLIER_MIN_SAMPLES", cfg.Outliers.MinSamples)
+	cfg.MinExchanges = getEnvInt("VWAP_MIN_EXCHANGES", cfg.MinExchanges)
+	cfg.MinVolumeShare = getEnvFloat("VWAP_MIN_VOLUME_SHARE", cfg.MinVolumeShare)
+	cfg.MaxPriceAge = getEnvDuration("VWAP_MAX_PRICE_AGE", cfg.MaxPriceAge)
+	if spec := os.Getenv("VWAP_EXCHANGE_MAX_PRICE_AGE"); spec != "" {
+		ages, err := calculator.ParseExchangeMaxPriceAge(spec)
+		if err != nil {
+			log.Printf("Invalid VWAP_EXCHANGE_MAX_PRICE_AGE: %v", err)
+		} else {
+			cfg.ExchangeMaxPriceAge = ages
 		}
-
-		tickers = append(tickers, ticker)
 	}
-
-	c.JSON(http.StatusOK, tickers)
+	return cfg
 }
 
-func (app *Application) getTicker(c *gin.Context) {
-	symbol := c.Param("symbol")
-
-	// Try to get from latest VWAP prices in ClickHouse
-	c.JSON(http.StatusOK, gin.H{
-		"symbol":  symbol,
-		"message": "VWAP price calculation coming soon",
-	})
+// loadRateLimitConfig reads rate limits from the environment. A value of 0
+// for RATE_LIMIT_RPS disables limiting.
+func loadRateLimitConfig() handler.RateLimitConfig {
+	cfg := handler.DefaultRateLimitConfig()
+	cfg.RPS = getEnvFloat("RATE_LIMIT_RPS", cfg.RPS)
+	cfg.Burst = getEnvInt("RATE_LIMIT_BURST", cfg.Burst)
+	cfg.APIKeyRPS = getEnvFloat("RATE_LIMIT_API_KEY_RPS", cfg.APIKeyRPS)
+	cfg.APIKeyBurst = getEnvInt("RATE_LIMIT_API_KEY_BURST", cfg.APIKeyBurst)
+	cfg.APIKeys = getEnvList("RATE_LIMIT_API_KEYS")
+	return cfg
 }
 
-func (app *Application) getVWAPPrice(c *gin.Context) {
-	symbol := c.Param("symbol")
-
-	// Query latest VWAP price from ClickHouse
-	c.JSON(http.StatusOK, gin.H{
-		"symbol":  symbol,
-		"message": "VWAP price endpoint coming soon",
-	})
+// loadTransportConfig reads the connection pooling, TLS and HTTP/2
+// settings of the transport the exchange clients share from the environment
+func loadTransportConfig() exchanges.TransportConfig {
+	cfg := exchanges.DefaultTransportConfig()
+	cfg.MaxIdleConns = getEnvInt("HTTP_MAX_IDLE_CONNS", cfg.MaxIdleConns)
+	cfg.MaxIdleConnsPerHost = getEnvInt("HTTP_MAX_IDLE_CONNS_PER_HOST", cfg.MaxIdleConnsPerHost)
+	cfg.MaxConnsPerHost = getEnvInt("HTTP_MAX_CONNS_PER_HOST", cfg.MaxConnsPerHost)
+	cfg.IdleConnTimeout = getEnvDuration("HTTP_IDLE_CONN_TIMEOUT", cfg.IdleConnTimeout)
+	cfg.DialTimeout = getEnvDuration("HTTP_DIAL_TIMEOUT", cfg.DialTimeout)
+	cfg.TLSHandshakeTimeout = getEnvDuration("HTTP_TLS_HANDSHAKE_TIMEOUT", cfg.TLSHandshakeTimeout)
+	cfg.DisableCompression = getEnv("HTTP_DISABLE_COMPRESSION", "false") == "true"
+	cfg.DisableHTTP2 = getEnv("HTTP_DISABLE_HTTP2", "false") == "true"
+	cfg.InsecureSkipVerify = getEnv("HTTP_TLS_INSECURE_SKIP_VERIFY", "false") == "true"
+	if v := os.Getenv("HTTP_TLS_MIN_VERSION"); v != "" {
+		version, err := exchanges.ParseTLSVersion(v)
+		if err != nil {
+			log.Printf("Invalid HTTP_TLS_MIN_VERSION: %v", err)
+		} else {
+			cfg.TLSMinVersion = version
+		}
+	}
+	return cfg
 }
 
-// Admin verification endpoints
-func (app *Application) getUnverifiedMappings(c *gin.Context) {
-	app.verificationHandler.GetUnverifiedMappings(c)
+// loadBinanceConfig reads the WebSocket ingester's endpoint and symbols
+func loadBinanceConfig() config.BinanceConfig {
+	symbols := getEnvList("BINANCE_SYMBOLS")
+	if len(symbols) == 0 {
+		symbols = []string{"btcusdt"}
+	}
+	return config.BinanceConfig{
+		WSBaseURL:            getEnv("BINANCE_WS_URL", "wss://stream.binance.com:9443"),
+		Symbols:              symbols,
+		StreamsPerConnection: getEnvInt("BINANCE_STREAMS_PER_CONNECTION", 200),
+	}
 }
 
-func (app *Application) verifyMapping(c *gin.Context) {
-	app.verificationHandler.VerifyMapping(c)
+// loadIngesterPipelineConfig reads the size of the ingester's trade queue
+// and what happens when it fills
+func loadIngesterPipelineConfig() (ingester.PipelineConfig, error) {
+	cfg := ingester.DefaultPipelineConfig()
+	cfg.QueueSize = getEnvInt("INGESTER_QUEUE_SIZE", cfg.QueueSize)
+	cfg.BatchSize = getEnvInt("INGESTER_BATCH_SIZE", cfg.BatchSize)
+	cfg.FlushInterval = getEnvDuration("INGESTER_FLUSH_INTERVAL", cfg.FlushInterval)
+	cfg.ParkTimeout = getEnvDuration("INGESTER_PARK_TIMEOUT", cfg.ParkTimeout)
+	overflow, err := ingester.ParseOverflowPolicy(getEnv("INGESTER_OVERFLOW", string(cfg.Overflow)))
+	if err != nil {
+		return cfg, fmt.Errorf("INGESTER_OVERFLOW: %w", err)
+	}
+	cfg.Overflow = overflow
+	return cfg, nil
 }
 
-func (app *Application) flagMapping(c *gin.Context) {
-	app.verificationHandler.FlagMapping(c)
+// loadReconnectPolicy reads how the ingester retries dropped WebSocket
+// connections. INGESTER_MAX_RECONNECT_ATTEMPTS=0 retries forever.
+func loadReconnectPolicy() ingester.ReconnectPolicy {
+	policy := ingester.DefaultReconnectPolicy()
+	policy.MaxAttempts = getEnvInt("INGESTER_MAX_RECONNECT_ATTEMPTS", policy.MaxAttempts)
+	policy.BaseDelay = getEnvDuration("INGESTER_RECONNECT_DELAY", policy.BaseDelay)
+	policy.MaxDelay = getEnvDuration("INGESTER_MAX_RECONNECT_DELAY", policy.MaxDelay)
+	policy.HealthyAfter = getEnvDuration("INGESTER_HEALTHY_AFTER", policy.HealthyAfter)
+	return policy
 }
 
-func (app *Application) getOutliers(c *gin.Context) {
-	app.verificationHandler.GetOutliers(c)
+// loadExchangeLimits reads the largest, most deeply nested and slowest to
+// parse exchange response accepted from the environment
+func loadExchangeLimits() exchanges.Limits {
+	limits := exchanges.DefaultLimits()
+	limits.MaxResponseBytes = int64(getEnvInt("EXCHANGE_MAX_RESPONSE_BYTES", int(limits.MaxResponseBytes)))
+	limits.MaxJSONDepth = getEnvInt("EXCHANGE_MAX_JSON_DEPTH", limits.MaxJSONDepth)
+	limits.ParseTimeout = getEnvDuration("EXCHANGE_PARSE_TIMEOUT", limits.ParseTimeout)
+	return limits
 }
 
-func (app *Application) resolveOutlier(c *gin.Context) {
-	app.verificationHandler.ResolveOutlier(c)
+// loadReconcileConfig reads when the nightly mapping reconciliation runs and
+// where its report is sent
+func loadReconcileConfig() reconcile.Config {
+	cfg := reconcile.DefaultConfig()
+	cfg.At = getEnvDuration("RECONCILE_AT", cfg.At)
+	cfg.StaleAfter = getEnvDuration("RECONCILE_STALE_AFTER", cfg.StaleAfter)
+	cfg.WebhookURL = os.Getenv("RECONCILE_WEBHOOK_URL")
+	cfg.WebhookSecret = os.Getenv("RECONCILE_WEBHOOK_SECRET")
+	cfg.Timeout = getEnvDuration("RECONCILE_WEBHOOK_TIMEOUT", cfg.Timeout)
+	return cfg
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
+// loadWebhookConfig reads webhook delivery settings from the environment
+func loadWebhookConfig() webhooks.Config {
+	cfg := webhooks.DefaultConfig()
+	cfg.Timeout = getEnvDuration("WEBHOOK_TIMEOUT", cfg.Timeout)
+	cfg.MaxAttempts = getEnvInt("WEBHOOK_MAX_ATTEMPTS", cfg.MaxAttempts)
+	cfg.Backoff = getEnvDuration("WEBHOOK_BACKOFF", cfg.Backoff)
+	cfg.MaxFailures = getEnvInt("WEBHOOK_MAX_FAILURES", cfg.MaxFailures)
+	cfg.Workers = getEnvInt("WEBHOOK_WORKERS", cfg.Workers)
+	cfg.AllowPrivate = getEnv("WEBHOOK_ALLOW_PRIVATE", "false") == "true"
+	return cfg
 }
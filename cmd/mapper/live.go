@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+)
+
+// liveSource marks the ProcessingResult file of a live fetch: "live:binance"
+const liveSource = "live:"
+
+// loadLiveMarketPairs fetches the current market pairs of exchanges straight
+// from their ticker endpoints, using the same clients and parsers as the
+// poller. Pair symbols are therefore the exchange's own (BTCUSDT, BTC_USDT,
+// ...), as the poller looks them up. Tickers carry no slugs, so pairs are
+// mapped by symbol only. An empty exchangeIDs fetches every enabled exchange.
+func loadLiveMarketPairs(ctx context.Context, factory *exchanges.ExchangeFactory, exchangeIDs []string, workers int, timeout time.Duration) ([]MarketPair, []ProcessingResult, error) {
+	clients := factory.CreateAllClients()
+	if len(exchangeIDs) == 0 {
+		for id := range clients {
+			exchangeIDs = append(exchangeIDs, id)
+		}
+	}
+	sort.Strings(exchangeIDs)
+
+	selected := make([]exchanges.ExchangeClient, 0, len(exchangeIDs))
+	for _, id := range exchangeIDs {
+		client, ok := clients[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s (or disabled)", exchanges.ErrUnknownExchange, id)
+		}
+		selected = append(selected, client)
+	}
+
+	if workers > len(selected) {
+		workers = len(selected)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	pairsByExchange := make([][]MarketPair, len(selected))
+	processingResults := make([]ProcessingResult, len(selected))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				pairsByExchange[i], processingResults[i] = fetchExchangePairs(ctx, selected[i], timeout)
+				if result := processingResults[i]; result.Success {
+					log.Printf("✓ Fetched %d market pairs from %s", result.PairsLoaded, result.ExchangeName)
+				} else {
+					log.Printf("✗ %s: %s", result.File, result.Error)
+				}
+			}
+		}()
+	}
+	for i := range selected {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var allMarketPairs []MarketPair
+	for _, pairs := range pairsByExchange {
+		allMarketPairs = append(allMarketPairs, pairs...)
+	}
+	log.Printf("Total fetched market pairs: %d from %d exchanges", len(allMarketPairs), len(selected))
+	return allMarketPairs, processingResults, nil
+}
+
+// fetchExchangePairs turns one exchange's tickers into market pairs
+func fetchExchangePairs(ctx context.Context, client exchanges.ExchangeClient, timeout time.Duration) ([]MarketPair, ProcessingResult) {
+	result := ProcessingResult{
+		File:         liveSource + client.GetID(),
+		ExchangeName: client.GetName(),
+		ExchangeSlug: client.GetID(),
+		Timestamp:    time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tickers, err := client.GetAllTickers(ctx)
+	if err != nil {
+		result.Error = fmt.Sprintf("Failed to fetch tickers: %v", err)
+		return nil, result
+	}
+
+	pairs := make([]MarketPair, 0, len(tickers))
+	for _, ticker := range tickers {
+		if ticker.BaseSymbol == "" || ticker.QuoteSymbol == "" {
+			continue
+		}
+		price, _ := ticker.Price.Float64()
+		pairs = append(pairs, MarketPair{
+			BaseSymbol:   strings.ToUpper(ticker.BaseSymbol),
+			QuoteSymbol:  strings.ToUpper(ticker.QuoteSymbol),
+			MarketPair:   ticker.Symbol,
+			Price:        price,
+			VolumeUSD:    usdVolume(ticker),
+			ExchangeName: client.GetName(),
+			ExchangeSlug: client.GetID(),
+			SourceFile:   result.File,
+		})
+	}
+
+	result.Success = true
+	result.PairsLoaded = len(pairs)
+	return pairs, result
+}
+
+// usdVolume is a ticker's 24h volume in USD when its quote is a USD token, and
+// 0 otherwise since the tickers carry no USD rate for other quotes
+func usdVolume(ticker exchanges.TickerData) float64 {
+	quote := strings.ToUpper(ticker.QuoteSymbol)
+	for _, usd := range db.USDQuoteSymbols {
+		if quote != usd {
+			continue
+		}
+		volume := ticker.QuoteVolume24h
+		if !volume.IsPositive() {
+			volume = ticker.Volume24h.Mul(ticker.Price)
+		}
+		f, _ := volume.Round(2).Float64()
+		return f
+	}
+	return 0
+}
+
+// splitExchangeIDs parses the -exchanges flag
+func splitExchangeIDs(s string) []string {
+	var ids []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			ids = append(ids, part)
+		}
+	}
+	return ids
+}
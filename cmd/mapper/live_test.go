@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/shopspring/decimal"
+)
+
+type stubClient struct {
+	exchanges.ExchangeClient
+	tickers []exchanges.TickerData
+	err     error
+}
+
+func (s stubClient) GetID() string   { return "gateio" }
+func (s stubClient) GetName() string { return "Gate.io" }
+func (s stubClient) GetAllTickers(ctx context.Context) ([]exchanges.TickerData, error) {
+	return s.tickers, s.err
+}
+
+func TestFetchExchangePairs(t *testing.T) {
+	d := decimal.RequireFromString
+	client := stubClient{tickers: []exchanges.TickerData{
+		{Symbol: "BTC_USDT", BaseSymbol: "btc", QuoteSymbol: "usdt", Price: d("60000"), Volume24h: d("10"), QuoteVolume24h: d("600000.123")},
+		{Symbol: "ETH_USDC", BaseSymbol: "ETH", QuoteSymbol: "USDC", Price: d("3000"), Volume24h: d("2")},
+		{Symbol: "ETH_BTC", BaseSymbol: "ETH", QuoteSymbol: "BTC", Price: d("0.05"), Volume24h: d("100")},
+		{Symbol: "WEIRD", Price: d("1")},
+	}}
+
+	pairs, result := fetchExchangePairs(context.Background(), client, time.Second)
+	if !result.Success || result.PairsLoaded != 3 || result.File != "live:gateio" {
+		t.Fatalf("result = %+v", result)
+	}
+	want := []struct {
+		base, pair string
+		volume     float64
+	}{
+		{"BTC", "BTC_USDT", 600000.12},
+		{"ETH", "ETH_USDC", 6000},
+		{"ETH", "ETH_BTC", 0},
+	}
+	for i, w := range want {
+		p := pairs[i]
+		if p.BaseSymbol != w.base || p.MarketPair != w.pair || p.VolumeUSD != w.volume || p.ExchangeSlug != "gateio" || p.BaseCurrencySlug != "" {
+			t.Errorf("pairs[%d] = %+v, want %s %s volume %v", i, p, w.base, w.pair, w.volume)
+		}
+	}
+
+	_, result = fetchExchangePairs(context.Background(), stubClient{err: errors.New("timeout")}, time.Second)
+	if result.Success || result.Error == "" {
+		t.Errorf("failed fetch result = %+v", result)
+	}
+}
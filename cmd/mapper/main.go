@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/mappingset"
 	_ "github.com/lib/pq"
+	"go.uber.org/zap"
 )
 
 // Database models
@@ -100,21 +108,6 @@ type MappingData struct {
 	Statistics         map[string]int             `json:"statistics"`
 }
 
-type ComprehensiveResult struct {
-	ProcessingSummary struct {
-		Timestamp         time.Time          `json:"timestamp"`
-		FilesProcessed    int                `json:"files_processed"`
-		SuccessfulFiles   int                `json:"successful_files"`
-		FailedFiles       int                `json:"failed_files"`
-		ProcessingDetails []ProcessingResult `json:"processing_details"`
-	} `json:"processing_summary"`
-	MappingStatistics   map[string]int                    `json:"mapping_statistics"`
-	TokenCoverage       map[string]map[string]interface{} `json:"token_coverage"`
-	MultiExchangeTokens map[string]map[string]interface{} `json:"multi_exchange_tokens"`
-	AllMappings         []TokenMapping                    `json:"all_mappings"`
-	UnmappedTokens      map[string][]UnmappedToken        `json:"unmapped_tokens"`
-}
-
 // Helper function to get environment variables with default
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -258,94 +251,256 @@ func getTokensBySlug(db *sql.DB) (map[string]int, error) {
 	return slugToID, nil
 }
 
-// Find all exchange folders
-func findExchangeFolders(rootPath string) ([]string, error) {
+// Find all exchange folders. With a manifest the folders it lists are used, one
+// per line relative to rootPath, otherwise every subdirectory matching pattern.
+// Folders come back in the order of their numeric prefix, so "2bitget" sorts
+// before "10bitmart".
+func findExchangeFolders(rootPath, pattern, manifest string) ([]string, error) {
+	var candidates []string
+	if manifest != "" {
+		data, err := os.ReadFile(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %v", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if !filepath.IsAbs(line) {
+				line = filepath.Join(rootPath, line)
+			}
+			candidates = append(candidates, line)
+		}
+	} else {
+		if pattern == "" {
+			pattern = "*"
+		}
+		matches, err := filepath.Glob(filepath.Join(rootPath, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid folder pattern %q: %v", pattern, err)
+		}
+		candidates = matches
+	}
+
 	var exchangeFolders []string
+	for _, folderPath := range candidates {
+		if strings.HasPrefix(filepath.Base(folderPath), ".") {
+			continue
+		}
+		info, err := os.Stat(folderPath)
+		if err != nil || !info.IsDir() {
+			if manifest != "" {
+				return nil, fmt.Errorf("manifest folder %s is not a directory", folderPath)
+			}
+			continue
+		}
+		exchangeFolders = append(exchangeFolders, folderPath)
+	}
 
-	// List of exchange folder names based on your structure
-	exchangeNames := []string{
-		"1binance", "2bitget", "3bybit", "4okx", "5mexc", "6htx", "7cryptocom", "8kucoin",
-		"9lbank", "10bitmart", "11deepcoin", "12kraken", "13gateio", "14gemini", "15coinbase",
-		"16whitebit", "17biconomy", "18coinw", "19toobit", "20pionex", "21bitunix", "22bitstamp",
-		"23hashkey", "24digifinex", "25digifinex", "26coinstore", "27bitrue", "28bigone",
-		"29coinex", "30btse",
+	if manifest == "" {
+		sort.SliceStable(exchangeFolders, func(i, j int) bool {
+			return naturalLess(filepath.Base(exchangeFolders[i]), filepath.Base(exchangeFolders[j]))
+		})
 	}
+	return exchangeFolders, nil
+}
 
-	for _, exchangeName := range exchangeNames {
-		folderPath := filepath.Join(rootPath, exchangeName)
-		if info, err := os.Stat(folderPath); err == nil && info.IsDir() {
-			exchangeFolders = append(exchangeFolders, folderPath)
+// exchangeFiles lists the JSON files of an exchange folder in numeric order
+func exchangeFiles(folderPath string) ([]string, error) {
+	entries, err := os.ReadDir(folderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.EqualFold(filepath.Ext(name), ".json") {
+			continue
 		}
+		files = append(files, name)
 	}
+	sort.SliceStable(files, func(i, j int) bool { return naturalLess(files[i], files[j]) })
 
-	return exchangeFolders, nil
+	for i, name := range files {
+		files[i] = filepath.Join(folderPath, name)
+	}
+	return files, nil
 }
 
-// Load exchange data with tracking
-func loadExchangeDataWithTracking(exchangeFolders []string) ([]MarketPair, []ProcessingResult) {
-	var allMarketPairs []MarketPair
-	var processingResults []ProcessingResult
+// naturalLess orders names by their leading number, then by the rest of the
+// name. Names without a number sort after those with one.
+func naturalLess(a, b string) bool {
+	na, resta := leadingNumber(a)
+	nb, restb := leadingNumber(b)
+	switch {
+	case na >= 0 && nb >= 0 && na != nb:
+		return na < nb
+	case na >= 0 && nb < 0:
+		return true
+	case na < 0 && nb >= 0:
+		return false
+	}
+	return resta < restb
+}
 
+// leadingNumber splits "13gateio" into 13 and "gateio", returning -1 when the
+// name does not start with a digit
+func leadingNumber(name string) (int, string) {
+	i := 0
+	for i < len(name) && name[i] >= '0' && name[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return -1, name
+	}
+	n, err := strconv.Atoi(name[:i])
+	if err != nil {
+		return -1, name
+	}
+	return n, name[i:]
+}
+
+// folderExchange derives an exchange identity from a folder name, for files
+// that do not carry one: "13gateio" becomes "gateio"
+func folderExchange(folderPath string) string {
+	_, name := leadingNumber(filepath.Base(folderPath))
+	return strings.ToLower(strings.TrimLeft(name, "-_ "))
+}
+
+// Load exchange data with tracking. Files are parsed by a pool of workers and
+// aggregated as each one finishes; pairs and results still come back in folder
+// and file order.
+func loadExchangeDataWithTracking(exchangeFolders []string, workers int) ([]MarketPair, []ProcessingResult) {
+	var files []string
 	for _, folderPath := range exchangeFolders {
-		// Check for 1.json and 2.json in each folder
-		for i := 1; i <= 2; i++ {
-			jsonFile := filepath.Join(folderPath, fmt.Sprintf("%d.json", i))
+		folderFiles, err := exchangeFiles(folderPath)
+		if err != nil {
+			log.Printf("✗ Failed to list %s: %v", folderPath, err)
+			continue
+		}
+		if len(folderFiles) == 0 {
+			log.Printf("No JSON files in %s", folderPath)
+		}
+		files = append(files, folderFiles...)
+	}
 
-			// Skip if file doesn't exist
-			if _, err := os.Stat(jsonFile); os.IsNotExist(err) {
-				continue
-			}
+	if workers > len(files) {
+		workers = len(files)
+	}
+	if workers < 1 {
+		workers = 1
+	}
 
-			result := ProcessingResult{
-				File:      jsonFile,
-				Timestamp: time.Now(),
-			}
+	type loadedFile struct {
+		index  int
+		pairs  []MarketPair
+		result ProcessingResult
+	}
 
-			// Read file
-			data, err := ioutil.ReadFile(jsonFile)
-			if err != nil {
-				result.Error = fmt.Sprintf("Failed to read file: %v", err)
-				processingResults = append(processingResults, result)
-				log.Printf("✗ Failed to read %s: %v", jsonFile, err)
-				continue
+	jobs := make(chan int)
+	loaded := make(chan loadedFile)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				pairs, result := loadExchangeFile(files[i])
+				loaded <- loadedFile{index: i, pairs: pairs, result: result}
 			}
+		}()
+	}
+	go func() {
+		for i := range files {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(loaded)
+	}()
+
+	pairsByFile := make([][]MarketPair, len(files))
+	processingResults := make([]ProcessingResult, len(files))
+	totalPairs := 0
+	for f := range loaded {
+		pairsByFile[f.index] = f.pairs
+		processingResults[f.index] = f.result
+		totalPairs += len(f.pairs)
+
+		if f.result.Success {
+			log.Printf("✓ Loaded %d market pairs from %s (%s)", f.result.PairsLoaded, f.result.ExchangeName, f.result.File)
+		} else {
+			log.Printf("✗ %s: %s", f.result.File, f.result.Error)
+		}
+	}
 
-			// Parse JSON
-			var exchangeData ExchangeData
-			if err := json.Unmarshal(data, &exchangeData); err != nil {
-				result.Error = fmt.Sprintf("Failed to parse JSON: %v", err)
-				processingResults = append(processingResults, result)
-				log.Printf("✗ Failed to parse %s: %v", jsonFile, err)
-				continue
-			}
+	allMarketPairs := make([]MarketPair, 0, totalPairs)
+	for _, pairs := range pairsByFile {
+		allMarketPairs = append(allMarketPairs, pairs...)
+	}
 
-			// Add exchange info to each pair
-			for idx := range exchangeData.Data.MarketPairs {
-				exchangeData.Data.MarketPairs[idx].ExchangeName = exchangeData.Data.Name
-				exchangeData.Data.MarketPairs[idx].ExchangeSlug = exchangeData.Data.Slug
-				exchangeData.Data.MarketPairs[idx].SourceFile = jsonFile
-			}
+	log.Printf("Total loaded market pairs: %d from %d files (%d workers)", len(allMarketPairs), len(files), workers)
+	return allMarketPairs, processingResults
+}
+
+// loadExchangeFile decodes one exchange file straight from disk
+func loadExchangeFile(jsonFile string) ([]MarketPair, ProcessingResult) {
+	result := ProcessingResult{
+		File:      jsonFile,
+		Timestamp: time.Now(),
+	}
 
-			allMarketPairs = append(allMarketPairs, exchangeData.Data.MarketPairs...)
+	file, err := os.Open(jsonFile)
+	if err != nil {
+		result.Error = fmt.Sprintf("Failed to read file: %v", err)
+		return nil, result
+	}
+	defer file.Close()
 
-			// Update result
-			result.Success = true
-			result.ExchangeName = exchangeData.Data.Name
-			result.ExchangeSlug = exchangeData.Data.Slug
-			result.PairsLoaded = len(exchangeData.Data.MarketPairs)
+	var exchangeData ExchangeData
+	if err := json.NewDecoder(file).Decode(&exchangeData); err != nil {
+		result.Error = fmt.Sprintf("Failed to parse JSON: %v", err)
+		return nil, result
+	}
 
-			processingResults = append(processingResults, result)
-			log.Printf("✓ Loaded %d market pairs from %s (%s)",
-				len(exchangeData.Data.MarketPairs), exchangeData.Data.Name, jsonFile)
+	// The exchange is identified by the file itself, falling back to the
+	// exchange the pairs name and then to the folder name
+	name, slug := exchangeData.Data.Name, exchangeData.Data.Slug
+	if len(exchangeData.Data.MarketPairs) > 0 {
+		first := exchangeData.Data.MarketPairs[0]
+		if name == "" {
+			name = first.ExchangeName
+		}
+		if slug == "" {
+			slug = first.ExchangeSlug
 		}
 	}
+	if slug == "" {
+		slug = folderExchange(filepath.Dir(jsonFile))
+	}
+	if name == "" {
+		name = slug
+	}
 
-	log.Printf("Total loaded market pairs: %d", len(allMarketPairs))
-	return allMarketPairs, processingResults
+	// Add exchange info to each pair
+	for idx := range exchangeData.Data.MarketPairs {
+		exchangeData.Data.MarketPairs[idx].ExchangeName = name
+		exchangeData.Data.MarketPairs[idx].ExchangeSlug = slug
+		exchangeData.Data.MarketPairs[idx].SourceFile = jsonFile
+	}
+
+	result.Success = true
+	result.ExchangeName = name
+	result.ExchangeSlug = slug
+	result.PairsLoaded = len(exchangeData.Data.MarketPairs)
+	return exchangeData.Data.MarketPairs, result
 }
 
-// Map tokens with relationships
-func mapTokensWithRelationships(marketPairs []MarketPair, slugToID map[string]int) *MappingData {
+// Map tokens with relationships. Pairs are mapped by slug; pairs fetched live
+// from the exchanges carry no slug and are mapped by symbol instead.
+func mapTokensWithRelationships(marketPairs []MarketPair, slugToID, symbolToID map[string]int) *MappingData {
 	mappingData := &MappingData{
 		AllMappings:        []TokenMapping{},
 		TokenToExchanges:   make(map[int][]ExchangeInfo),
@@ -355,7 +510,8 @@ func mapTokensWithRelationships(marketPairs []MarketPair, slugToID map[string]in
 	}
 
 	for _, pair := range marketPairs {
-		if tokenID, exists := slugToID[pair.BaseCurrencySlug]; exists {
+		tokenID, exists := mapBaseToken(pair, slugToID, symbolToID)
+		if exists {
 			// Create mapping entry
 			tokenMapping := TokenMapping{
 				ExchangeName:    pair.ExchangeName,
@@ -411,64 +567,33 @@ func mapTokensWithRelationships(marketPairs []MarketPair, slugToID map[string]in
 	return mappingData
 }
 
-// Save comprehensive results
-func saveComprehensiveResults(mappingData *MappingData, processingResults []ProcessingResult, outputFile string) error {
-	result := ComprehensiveResult{}
-
-	// Processing summary
-	result.ProcessingSummary.Timestamp = time.Now()
-	result.ProcessingSummary.FilesProcessed = len(processingResults)
-	result.ProcessingSummary.ProcessingDetails = processingResults
-
-	for _, pr := range processingResults {
-		if pr.Success {
-			result.ProcessingSummary.SuccessfulFiles++
-		} else {
-			result.ProcessingSummary.FailedFiles++
-		}
+// mapBaseToken finds the token of a pair's base currency: by slug, or by symbol
+// when the pair has no slug
+func mapBaseToken(pair MarketPair, slugToID, symbolToID map[string]int) (int, bool) {
+	if pair.BaseCurrencySlug == "" {
+		tokenID, exists := symbolToID[strings.ToUpper(pair.BaseSymbol)]
+		return tokenID, exists
 	}
+	tokenID, exists := slugToID[pair.BaseCurrencySlug]
+	return tokenID, exists
+}
 
-	// Mapping statistics
-	result.MappingStatistics = mappingData.Statistics
-
-	// Token coverage analysis
-	result.TokenCoverage = make(map[string]map[string]interface{})
-	result.MultiExchangeTokens = make(map[string]map[string]interface{})
-
-	for tokenID, exchanges := range mappingData.TokenToExchanges {
-		exchangeNames := []string{}
-		for _, ex := range exchanges {
-			exchangeNames = append(exchangeNames, ex.ExchangeName)
-		}
-
-		tokenIDStr := fmt.Sprintf("%d", tokenID)
-		result.TokenCoverage[tokenIDStr] = map[string]interface{}{
-			"exchange_count": len(exchangeNames),
-			"exchanges":      exchangeNames,
-		}
-
-		if len(exchangeNames) > 1 {
-			result.MultiExchangeTokens[tokenIDStr] = result.TokenCoverage[tokenIDStr]
-		}
-	}
-
-	// All mappings and unmapped tokens
-	result.AllMappings = mappingData.AllMappings
-	result.UnmappedTokens = mappingData.UnmappedByExchange
-
-	// Save to file
-	jsonData, err := json.MarshalIndent(result, "", "  ")
+// saveSnapshot writes the mapping dataset in the database as a bundle that
+// tokenctl import or POST /api/v1/admin/mappings/import load elsewhere
+func saveSnapshot(db *sql.DB, path string) error {
+	bundle, err := mappingset.Export(context.Background(), db)
 	if err != nil {
-		return fmt.Errorf("failed to marshal result: %v", err)
+		return err
 	}
-
-	err = ioutil.WriteFile(outputFile, jsonData, 0644)
+	f, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("failed to write file: %v", err)
+		return err
 	}
-
-	log.Printf("Comprehensive results saved to %s", outputFile)
-	return nil
+	if err := bundle.Write(f, mappingset.FormatJSON); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 // Print enhanced summary
@@ -500,6 +625,9 @@ func printEnhancedSummary(mappingData *MappingData, processingResults []Processi
 		fileName := filepath.Base(result.File)
 		folderName := filepath.Base(filepath.Dir(result.File))
 		displayPath := fmt.Sprintf("%s/%s", folderName, fileName)
+		if strings.HasPrefix(result.File, liveSource) {
+			displayPath = result.File
+		}
 
 		fmt.Printf("%-50s %-10s %-20s %-10d %s\n",
 			displayPath, status, exchange, result.PairsLoaded, error)
@@ -554,92 +682,158 @@ func printEnhancedSummary(mappingData *MappingData, processingResults []Processi
 	}
 }
 
-// Save mappings to database
-func saveMappingsToDatabase(db *sql.DB, marketPairs []MarketPair, slugToID map[string]int) error {
-	// First, we need to get all tokens including quote currencies
-	allTokens, err := getAllTokens(db)
-	if err != nil {
-		return fmt.Errorf("failed to get all tokens: %v", err)
+// maxBatchSize keeps a batch's placeholders under PostgreSQL's limit of 65535
+const maxBatchSize = 10000
+
+// SaveSummary counts what saveMappingsToDatabase wrote
+type SaveSummary struct {
+	Inserted  int
+	Updated   int
+	Skipped   int
+	Batches   int
+	Committed int // pairs written by committed batches
+	Duration  time.Duration
+}
+
+// Save mappings to database: the inserts and updates of the plan, as multi-row
+// upserts in one transaction per batch. A failed batch is rolled back and
+// stops the save; the batches before it stay committed, and since every write
+// is an upsert the mapper can simply be run again.
+func saveMappingsToDatabase(db *sql.DB, plan []PairChange, batchSize int) (SaveSummary, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	if batchSize > maxBatchSize {
+		batchSize = maxBatchSize
+	}
+
+	var summary SaveSummary
+	var writes []PairChange
+	for _, change := range plan {
+		switch change.Action {
+		case ActionInsert, ActionUpdate:
+			writes = append(writes, change)
+		case ActionSkip:
+			summary.Skipped++
+		}
 	}
 
-	// Prepare the insert statement for trading_pairs
-	insertQuery := `
+	started := time.Now()
+	progress := newProgressBar(len(writes))
+	for start := 0; start < len(writes); start += batchSize {
+		end := start + batchSize
+		if end > len(writes) {
+			end = len(writes)
+		}
+
+		inserted, updated, err := saveBatch(db, writes[start:end])
+		if err != nil {
+			progress.done()
+			summary.Duration = time.Since(started)
+			return summary, fmt.Errorf("batch %d (pairs %d-%d) rolled back: %v", summary.Batches+1, start+1, end, err)
+		}
+		summary.Inserted += inserted
+		summary.Updated += updated
+		summary.Batches++
+		summary.Committed = end
+		progress.set(end)
+	}
+	progress.done()
+	summary.Duration = time.Since(started)
+	return summary, nil
+}
+
+// saveBatch upserts one batch of pairs in a transaction and counts the rows it
+// inserted and updated
+func saveBatch(db *sql.DB, batch []PairChange) (inserted, updated int, err error) {
+	const columns = 6
+	values := make([]string, len(batch))
+	args := make([]interface{}, 0, len(batch)*columns)
+	for i, change := range batch {
+		n := i * columns
+		values[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, NOW(), NOW())", n+1, n+2, n+3, n+4, n+5, n+6)
+		args = append(args,
+			change.BaseTokenID,  // base_token_id
+			change.QuoteTokenID, // quote_token_id
+			change.ExchangeID,   // exchange_id
+			change.PairSymbol,   // exchange_pair_symbol
+			true,                // is_active
+			change.VolumeUSD,    // last_volume_24h
+		)
+	}
+
+	// xmax is 0 only on rows the statement inserted
+	query := `
 		INSERT INTO trading_pairs (
 			base_token_id, quote_token_id,
 			exchange_id, exchange_pair_symbol,
 			is_active, last_volume_24h,
 			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		ON CONFLICT (exchange_id, exchange_pair_symbol) 
-		DO UPDATE SET 
+		) VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (exchange_id, exchange_pair_symbol)
+		DO UPDATE SET
 			last_volume_24h = EXCLUDED.last_volume_24h,
 			updated_at = NOW()
+		RETURNING xmax = 0
 	`
 
-	stmt, err := db.Prepare(insertQuery)
+	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to prepare insert statement: %v", err)
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	successCount := 0
-	failCount := 0
-	skipCount := 0
-
-	for _, pair := range marketPairs {
-		// Get base token ID
-		baseTokenID, baseExists := allTokens[strings.ToUpper(pair.BaseSymbol)]
-		if !baseExists {
-			// Try with slug
-			baseTokenID, baseExists = slugToID[pair.BaseCurrencySlug]
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	for rows.Next() {
+		var isInsert bool
+		if err := rows.Scan(&isInsert); err != nil {
+			rows.Close()
+			return 0, 0, err
 		}
-
-		// Get quote token ID - use the quote symbol from JSON
-		quoteTokenID, quoteExists := allTokens[strings.ToUpper(pair.QuoteSymbol)]
-		if !quoteExists && pair.QuoteCurrencySlug != "" {
-			// Try with slug
-			quoteTokenID, quoteExists = slugToID[pair.QuoteCurrencySlug]
+		if isInsert {
+			inserted++
+		} else {
+			updated++
 		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
 
-		// Skip if we can't find both tokens
-		if !baseExists || !quoteExists {
-			if !baseExists {
-				log.Printf("Skipping %s on %s: base token %s (slug: %s) not found", 
-					pair.MarketPair, pair.ExchangeName, pair.BaseSymbol, pair.BaseCurrencySlug)
-			}
-			if !quoteExists {
-				log.Printf("Skipping %s on %s: quote token %s (slug: %s) not found", 
-					pair.MarketPair, pair.ExchangeName, pair.QuoteSymbol, pair.QuoteCurrencySlug)
-			}
-			skipCount++
-			continue
-		}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit: %v", err)
+	}
+	return inserted, updated, nil
+}
 
-		// Create exchange ID from slug (remove spaces, lowercase)
-		exchangeID := strings.ToLower(strings.ReplaceAll(pair.ExchangeSlug, " ", ""))
+// progressBar draws save progress on stderr
+type progressBar struct {
+	total int
+}
 
-		// Execute insert
-		_, err := stmt.Exec(
-			baseTokenID,                   // base_token_id
-			quoteTokenID,                  // quote_token_id
-			exchangeID,                    // exchange_id
-			pair.MarketPair,               // exchange_pair_symbol
-			true,                          // is_active
-			pair.VolumeUSD,                // last_volume_24h
-			time.Now(),                    // created_at
-			time.Now(),                    // updated_at
-		)
+func newProgressBar(total int) *progressBar {
+	p := &progressBar{total: total}
+	p.set(0)
+	return p
+}
 
-		if err != nil {
-			log.Printf("Failed to insert pair %s on %s: %v", pair.MarketPair, pair.ExchangeName, err)
-			failCount++
-		} else {
-			successCount++
-		}
+func (p *progressBar) set(n int) {
+	const width = 40
+	filled := width
+	percent := 100
+	if p.total > 0 {
+		filled = n * width / p.total
+		percent = n * 100 / p.total
 	}
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %d/%d pairs (%d%%)", strings.Repeat("=", filled), strings.Repeat(" ", width-filled), n, p.total, percent)
+}
 
-	log.Printf("Database save complete: %d successful, %d failed, %d skipped (missing tokens)", successCount, failCount, skipCount)
-	return nil
+func (p *progressBar) done() {
+	fmt.Fprintln(os.Stderr)
 }
 
 // Find which exchanges have a specific token
@@ -661,6 +855,23 @@ func findTokenExchanges(mappingData *MappingData, tokenSymbol string) []string {
 }
 
 func main() {
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of exchange files parsed, or exchanges fetched, concurrently")
+	pattern := flag.String("folders", "*", "Glob, relative to EXCHANGE_DATA_PATH, of the exchange folders to load")
+	manifest := flag.String("manifest", "", "File listing the exchange folders to load, one per line; overrides -folders")
+	dryRun := flag.Bool("dry-run", false, "Report the trading_pairs changes without writing them")
+	batchSize := flag.Int("batch-size", 500, "Trading pairs written per transaction")
+	planFile := flag.String("plan-file", "trading_pairs_plan.json", "Where to write every planned change and its reason")
+	snapshotFile := flag.String("snapshot-file", "mapping_snapshot.json", "Where to write the mapping dataset after saving, as a tokenctl export bundle (empty to skip)")
+	source := flag.String("source", "files", "Where market pairs come from: files (EXCHANGE_DATA_PATH dumps) or live (exchange APIs)")
+	exchangeList := flag.String("exchanges", "", "live: comma-separated exchange IDs to fetch (default all enabled)")
+	exchangeConfig := flag.String("exchange-config", "configs/exchanges.json", "live: exchange client configuration")
+	fetchTimeout := flag.Duration("fetch-timeout", 30*time.Second, "live: timeout per exchange")
+	flag.Parse()
+
+	if *source != "files" && *source != "live" {
+		log.Fatalf("Unknown -source %q, want files or live", *source)
+	}
+
 	// Database configuration - using environment variables or defaults
 	dbConfig := struct {
 		Host     string
@@ -692,29 +903,89 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// Find all exchange folders
-	exchangeFolders, err := findExchangeFolders(rootPath)
+	allTokens, err := getAllTokens(db)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	log.Printf("Found %d exchange folders", len(exchangeFolders))
+	var marketPairs []MarketPair
+	var processingResults []ProcessingResult
+	if *source == "live" {
+		factory, err := exchanges.NewExchangeFactory(*exchangeConfig, zap.NewNop())
+		if err != nil {
+			log.Fatal(err)
+		}
+		marketPairs, processingResults, err = loadLiveMarketPairs(context.Background(), factory, splitExchangeIDs(*exchangeList), *workers, *fetchTimeout)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		// Find all exchange folders
+		exchangeFolders, err := findExchangeFolders(rootPath, *pattern, *manifest)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("Found %d exchange folders", len(exchangeFolders))
 
-	// Load exchange data with tracking
-	marketPairs, processingResults := loadExchangeDataWithTracking(exchangeFolders)
+		// Load exchange data with tracking
+		marketPairs, processingResults = loadExchangeDataWithTracking(exchangeFolders, *workers)
+	}
 
 	// Map tokens with relationship tracking
-	mappingData := mapTokensWithRelationships(marketPairs, slugToID)
+	mappingData := mapTokensWithRelationships(marketPairs, slugToID, allTokens)
 
-	// Save mappings to database
-	log.Println("Saving mappings to database...")
-	if err := saveMappingsToDatabase(db, marketPairs, slugToID); err != nil {
-		log.Printf("Warning: Failed to save mappings to database: %v", err)
+	// Plan the trading_pairs changes against what is in the database
+	existing, err := loadExistingPairs(db, planExchangeIDs(marketPairs))
+	if err != nil {
+		log.Fatal(err)
+	}
+	registered, err := loadRegisteredExchanges(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	plan := planPairs(marketPairs, allTokens, slugToID, existing, registered)
+	printPlan(plan)
+	if err := savePlan(plan, *planFile); err != nil {
+		log.Printf("Failed to save plan: %v", err)
+	} else {
+		log.Printf("Plan saved to %s", *planFile)
+	}
+
+	if *dryRun {
+		log.Println("Dry run: not writing to the database")
+	} else {
+		// Save mappings to database
+		log.Println("Saving mappings to database...")
+		summary, err := saveMappingsToDatabase(db, plan, *batchSize)
+		log.Printf("Database save: %d inserted, %d updated, %d skipped in %d batches (%s)",
+			summary.Inserted, summary.Updated, summary.Skipped, summary.Batches, summary.Duration.Round(time.Millisecond))
+		if err != nil {
+			log.Printf("Warning: Failed to save mappings to database after %d pairs: %v", summary.Committed, err)
+		}
 	}
 
-	// Save comprehensive results
-	if err := saveComprehensiveResults(mappingData, processingResults, "multi_exchange_mapping_results.json"); err != nil {
-		log.Printf("Failed to save results: %v", err)
+	// Queue the unmapped base currencies for review
+	candidateTokens, err := loadCandidateTokens(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pending := buildPendingMappings(marketPairs, slugToID, allTokens, candidateTokens)
+	if *dryRun {
+		log.Printf("Dry run: %d unmapped tokens would be queued in pending_mappings", len(pending))
+	} else if added, err := queuePendingMappings(db, pending); err != nil {
+		log.Printf("Warning: %v", err)
+	} else {
+		log.Printf("Pending mappings: %d unmapped tokens queued, %d new", len(pending), added)
+	}
+
+	// Snapshot what is now in the database for other environments
+	if *snapshotFile != "" && !*dryRun {
+		if err := saveSnapshot(db, *snapshotFile); err != nil {
+			log.Printf("Failed to save mapping snapshot: %v", err)
+		} else {
+			log.Printf("Mapping snapshot saved to %s", *snapshotFile)
+		}
 	}
 
 	// Print enhanced summary
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindExchangeFolders(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"10bitmart", "2bitget", "1binance", "kraken", ".cache"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(root, ".DS_Store"), "")
+
+	base := func(folders []string) string {
+		names := make([]string, len(folders))
+		for i, f := range folders {
+			names[i] = filepath.Base(f)
+		}
+		return strings.Join(names, ",")
+	}
+
+	folders, err := findExchangeFolders(root, "*", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := base(folders); got != "1binance,2bitget,10bitmart,kraken" {
+		t.Errorf("discovered %s", got)
+	}
+
+	if folders, _ = findExchangeFolders(root, "*bit*", ""); base(folders) != "2bitget,10bitmart" {
+		t.Errorf("glob matched %s", base(folders))
+	}
+
+	manifest := filepath.Join(t.TempDir(), "exchanges.txt")
+	writeFile(t, manifest, "# lowest volume last\nkraken\n\n1binance\n")
+	if folders, err = findExchangeFolders(root, "*", manifest); err != nil || base(folders) != "kraken,1binance" {
+		t.Errorf("manifest gave %s, %v", base(folders), err)
+	}
+	writeFile(t, manifest, "okx\n")
+	if _, err := findExchangeFolders(root, "*", manifest); err == nil {
+		t.Error("manifest naming a missing folder did not fail")
+	}
+}
+
+func TestLoadExchangeDataWithTracking(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "1a")
+	writeFile(t, filepath.Join(a, "1.json"), `{"data":{"name":"A","slug":"a","marketPairs":[{"baseSymbol":"BTC","marketPair":"BTC/USDT"},{"baseSymbol":"ETH","marketPair":"ETH/USDT"}]}}`)
+	writeFile(t, filepath.Join(a, "10.json"), `{"data":{"name":"A","slug":"a","marketPairs":[{"baseSymbol":"DOGE","marketPair":"DOGE/USDT"}]}}`)
+	writeFile(t, filepath.Join(a, "2.json"), `{"data":{"name":"A","slug":"a","marketPairs":[{"baseSymbol":"SOL","marketPair":"SOL/USDT"}]}}`)
+	writeFile(t, filepath.Join(a, "notes.txt"), "not market data")
+	b := filepath.Join(root, "2b")
+	writeFile(t, filepath.Join(b, "1.json"), `{"data":`)
+	// No exchange identity at the top level: taken from the pairs, then the folder
+	c := filepath.Join(root, "3gate-io")
+	writeFile(t, filepath.Join(c, "1.json"), `{"data":{"marketPairs":[{"baseSymbol":"XRP","marketPair":"XRP/USDT","exchangeName":"Gate","exchangeSlug":"gate"}]}}`)
+	d := filepath.Join(root, "4coinex")
+	writeFile(t, filepath.Join(d, "1.json"), `{"data":{"marketPairs":[{"baseSymbol":"ADA","marketPair":"ADA/USDT"}]}}`)
+
+	for _, workers := range []int{1, 4} {
+		pairs, results := loadExchangeDataWithTracking([]string{a, b, c, d}, workers)
+
+		var got []string
+		for _, p := range pairs {
+			got = append(got, p.ExchangeName+"/"+p.ExchangeSlug+":"+p.BaseSymbol)
+		}
+		want := "A/a:BTC,A/a:ETH,A/a:SOL,A/a:DOGE,Gate/gate:XRP,coinex/coinex:ADA"
+		if strings.Join(got, ",") != want {
+			t.Errorf("%d workers: pairs = %v, want %s", workers, got, want)
+		}
+
+		if len(results) != 6 || !results[0].Success || results[3].Success || results[3].Error == "" || results[4].PairsLoaded != 1 {
+			t.Errorf("%d workers: results = %+v", workers, results)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+)
+
+// maxCandidates is how many suggested tokens a pending mapping keeps
+const maxCandidates = 5
+
+// Candidate scores: a matching symbol counts most, then name, then slug
+const (
+	symbolMatchScore = 0.5
+	nameMatchScore   = 0.3
+	slugMatchScore   = 0.2
+)
+
+// candidateToken is a token a pending mapping could be resolved to
+type candidateToken struct {
+	id     int
+	symbol string
+	name   string
+	slug   string
+}
+
+// loadCandidateTokens reads the active tokens pending mappings are scored
+// against. The slug is the column, or the metadata slug getTokensBySlug uses.
+func loadCandidateTokens(conn *sql.DB) ([]candidateToken, error) {
+	rows, err := conn.Query(`
+		SELECT id, symbol, name,
+		       COALESCE(NULLIF(slug, ''), metadata->>'slug', metadata->>'coinmarketcap_slug', metadata->>'coingecko_id', '')
+		FROM tokens
+		WHERE is_active = true
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tokens: %v", err)
+	}
+	defer rows.Close()
+
+	var tokens []candidateToken
+	for rows.Next() {
+		var t candidateToken
+		if err := rows.Scan(&t.id, &t.symbol, &t.name, &t.slug); err != nil {
+			return nil, fmt.Errorf("failed to scan token: %v", err)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// candidateIndex looks tokens up by normalized symbol, name and slug
+type candidateIndex struct {
+	tokens   []candidateToken
+	bySymbol map[string][]int
+	byName   map[string][]int
+	bySlug   map[string][]int
+}
+
+func newCandidateIndex(tokens []candidateToken) *candidateIndex {
+	idx := &candidateIndex{
+		tokens:   tokens,
+		bySymbol: make(map[string][]int),
+		byName:   make(map[string][]int),
+		bySlug:   make(map[string][]int),
+	}
+	for i, t := range tokens {
+		idx.bySymbol[strings.ToUpper(t.symbol)] = append(idx.bySymbol[strings.ToUpper(t.symbol)], i)
+		if name := normalizeName(t.name); name != "" {
+			idx.byName[name] = append(idx.byName[name], i)
+		}
+		if t.slug != "" {
+			idx.bySlug[strings.ToLower(t.slug)] = append(idx.bySlug[strings.ToLower(t.slug)], i)
+		}
+	}
+	return idx
+}
+
+// normalizeName lowercases a token name and drops everything but letters and
+// digits, so "Wrapped Bitcoin" and "wrapped-bitcoin" match
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// candidates scores the tokens sharing a symbol, name or slug with an unmapped
+// base currency and returns the best, highest score first
+func (idx *candidateIndex) candidates(symbol, name, slug string) []db.MappingCandidate {
+	type match struct {
+		score   float64
+		reasons []string
+	}
+	matches := make(map[int]*match)
+	add := func(indexes []int, score float64, reason string) {
+		for _, i := range indexes {
+			m := matches[i]
+			if m == nil {
+				m = &match{}
+				matches[i] = m
+			}
+			m.score += score
+			m.reasons = append(m.reasons, reason)
+		}
+	}
+
+	add(idx.bySymbol[strings.ToUpper(symbol)], symbolMatchScore, "symbol")
+	if name := normalizeName(name); name != "" {
+		add(idx.byName[name], nameMatchScore, "name")
+	}
+	if slug != "" {
+		add(idx.bySlug[strings.ToLower(slug)], slugMatchScore, "slug")
+	}
+
+	out := make([]db.MappingCandidate, 0, len(matches))
+	for i, m := range matches {
+		t := idx.tokens[i]
+		out = append(out, db.MappingCandidate{
+			TokenID: t.id,
+			Symbol:  t.symbol,
+			Name:    t.name,
+			// Round away float noise such as 0.30000000000000004
+			Score:  float64(int(m.score*100+0.5)) / 100,
+			Reason: strings.Join(m.reasons, ", "),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].TokenID < out[j].TokenID
+	})
+	if len(out) > maxCandidates {
+		out = out[:maxCandidates]
+	}
+	return out
+}
+
+// buildPendingMappings lists the base currencies mapTokensWithRelationships
+// could not map, once per exchange and symbol, with their likely tokens
+func buildPendingMappings(marketPairs []MarketPair, slugToID, symbolToID map[string]int, tokens []candidateToken) []db.PendingMapping {
+	idx := newCandidateIndex(tokens)
+	seen := make(map[string]bool)
+	var pending []db.PendingMapping
+
+	for _, pair := range marketPairs {
+		if _, exists := mapBaseToken(pair, slugToID, symbolToID); exists {
+			continue
+		}
+		exchangeID := pairExchangeID(pair)
+		symbol := strings.ToUpper(pair.BaseSymbol)
+		if exchangeID == "" || symbol == "" {
+			continue
+		}
+		key := pairKey(exchangeID, symbol)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		pending = append(pending, db.PendingMapping{
+			ExchangeID:     exchangeID,
+			ExchangeSymbol: symbol,
+			Slug:           pair.BaseCurrencySlug,
+			Name:           pair.BaseCurrencyName,
+			MarketPair:     pair.MarketPair,
+			Candidates:     idx.candidates(symbol, pair.BaseCurrencyName, pair.BaseCurrencySlug),
+		})
+	}
+	return pending
+}
+
+// queuePendingMappings writes the unmapped base currencies to pending_mappings
+// for review through the verification endpoints
+func queuePendingMappings(conn *sql.DB, pending []db.PendingMapping) (added int, err error) {
+	added, err = db.UpsertPendingMappings(context.Background(), conn, pending)
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue pending mappings: %v", err)
+	}
+	return added, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestBuildPendingMappings(t *testing.T) {
+	tokens := []candidateToken{
+		{id: 1, symbol: "BTC", name: "Bitcoin", slug: "bitcoin"},
+		{id: 2, symbol: "WBTC", name: "Wrapped Bitcoin", slug: "wrapped-bitcoin"},
+		{id: 3, symbol: "TON", name: "Toncoin", slug: "toncoin"},
+		{id: 4, symbol: "TON", name: "Tokamak Network", slug: "tokamak-network"},
+	}
+	slugToID := map[string]int{"bitcoin": 1}
+	symbolToID := map[string]int{"BTC": 1, "TON": 3}
+	marketPairs := []MarketPair{
+		{BaseSymbol: "BTC", BaseCurrencySlug: "bitcoin", MarketPair: "BTC/USDT", ExchangeSlug: "Gate"},
+		{BaseSymbol: "ton", BaseCurrencyName: "Toncoin", BaseCurrencySlug: "the-open-network", MarketPair: "TON/USDT", ExchangeSlug: "Gate"},
+		{BaseSymbol: "TON", BaseCurrencyName: "Toncoin", BaseCurrencySlug: "the-open-network", MarketPair: "TON/BTC", ExchangeSlug: "Gate"},
+		{BaseSymbol: "BTCW", BaseCurrencyName: "Wrapped-Bitcoin", BaseCurrencySlug: "wrapped-bitcoin", MarketPair: "BTCW/USDT", ExchangeSlug: "Gate"},
+		{BaseSymbol: "NEW", BaseCurrencyName: "Brand New", BaseCurrencySlug: "brand-new", MarketPair: "NEW/USDT", ExchangeSlug: "Big One"},
+		// Live pairs carry no slug and map by symbol
+		{BaseSymbol: "TON", MarketPair: "TONUSDT", ExchangeSlug: "binance"},
+	}
+
+	pending := buildPendingMappings(marketPairs, slugToID, symbolToID, tokens)
+
+	if len(pending) != 3 {
+		t.Fatalf("pending = %+v", pending)
+	}
+
+	ton := pending[0]
+	if ton.ExchangeID != "gateio" || ton.ExchangeSymbol != "TON" || ton.MarketPair != "TON/USDT" {
+		t.Errorf("pending[0] = %+v", ton)
+	}
+	if len(ton.Candidates) != 2 ||
+		ton.Candidates[0].TokenID != 3 || ton.Candidates[0].Score != 0.8 || ton.Candidates[0].Reason != "symbol, name" ||
+		ton.Candidates[1].TokenID != 4 || ton.Candidates[1].Score != 0.5 {
+		t.Errorf("TON candidates = %+v", ton.Candidates)
+	}
+
+	btcw := pending[1]
+	if btcw.ExchangeSymbol != "BTCW" || len(btcw.Candidates) != 1 ||
+		btcw.Candidates[0].TokenID != 2 || btcw.Candidates[0].Score != 0.5 || btcw.Candidates[0].Reason != "name, slug" {
+		t.Errorf("BTCW = %+v", btcw)
+	}
+
+	if pending[2].ExchangeID != "bigone" || len(pending[2].Candidates) != 0 {
+		t.Errorf("pending[2] = %+v", pending[2])
+	}
+}
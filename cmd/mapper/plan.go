@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/lib/pq"
+)
+
+// PairAction is what the mapper does with a market pair
+type PairAction string
+
+const (
+	ActionInsert    PairAction = "insert"
+	ActionUpdate    PairAction = "update"
+	ActionUnchanged PairAction = "unchanged"
+	ActionSkip      PairAction = "skip"
+	// ActionNotInInput marks trading_pairs rows of a loaded exchange that the
+	// input no longer lists. The mapper leaves them alone.
+	ActionNotInInput PairAction = "not_in_input"
+)
+
+// PairChange is one line of the mapping plan
+type PairChange struct {
+	Action       PairAction `json:"action"`
+	ExchangeID   string     `json:"exchange_id"`
+	PairSymbol   string     `json:"pair_symbol"`
+	BaseTokenID  int        `json:"base_token_id,omitempty"`
+	QuoteTokenID int        `json:"quote_token_id,omitempty"`
+	VolumeUSD    float64    `json:"volume_usd"`
+	Reason       string     `json:"reason,omitempty"`
+	Changes      []string   `json:"changes,omitempty"`
+	SourceFile   string     `json:"source_file,omitempty"`
+}
+
+// existingPair is the current trading_pairs row of a pair
+type existingPair struct {
+	baseTokenID  int
+	quoteTokenID int
+	volume       float64
+	active       bool
+}
+
+func pairKey(exchangeID, pairSymbol string) string {
+	return exchangeID + "\x00" + pairSymbol
+}
+
+// pairExchangeID creates the exchange ID of a pair from its exchange slug, in
+// the registry's form, so "Gate" and "gate-io" are both "gateio"
+func pairExchangeID(pair MarketPair) string {
+	return exchanges.NormalizeExchangeID(pair.ExchangeSlug)
+}
+
+// loadRegisteredExchanges reads the IDs of the registered exchanges, the only
+// ones trading pairs may be on
+func loadRegisteredExchanges(conn *sql.DB) (map[string]bool, error) {
+	registered, err := db.RegisteredExchangeIDs(context.Background(), conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the exchange registry: %v", err)
+	}
+	return registered, nil
+}
+
+// loadExistingPairs reads the current trading_pairs rows of the given exchanges
+func loadExistingPairs(db *sql.DB, exchangeIDs []string) (map[string]existingPair, error) {
+	rows, err := db.Query(`
+		SELECT exchange_id, exchange_pair_symbol, base_token_id, quote_token_id,
+		       COALESCE(last_volume_24h, 0), COALESCE(is_active, true)
+		FROM trading_pairs
+		WHERE exchange_id = ANY($1)
+	`, pq.Array(exchangeIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trading pairs: %v", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]existingPair)
+	for rows.Next() {
+		var exchangeID, symbol string
+		var p existingPair
+		if err := rows.Scan(&exchangeID, &symbol, &p.baseTokenID, &p.quoteTokenID, &p.volume, &p.active); err != nil {
+			return nil, fmt.Errorf("failed to scan trading pair: %v", err)
+		}
+		existing[pairKey(exchangeID, symbol)] = p
+	}
+	return existing, rows.Err()
+}
+
+// planPairs works out what saving marketPairs would do to trading_pairs. Base and
+// quote tokens are resolved by symbol, then by slug. A pair listed twice keeps
+// its first row. Existing rows only ever have their volume updated, so a pair
+// whose tokens now resolve differently is reported but not changed. Pairs of
+// exchanges not in registered are skipped; a nil registered skips the check.
+func planPairs(marketPairs []MarketPair, allTokens, slugToID map[string]int, existing map[string]existingPair, registered map[string]bool) []PairChange {
+	var plan []PairChange
+	seen := make(map[string]bool)
+	loadedExchanges := make(map[string]bool)
+
+	for _, pair := range marketPairs {
+		exchangeID := pairExchangeID(pair)
+		loadedExchanges[exchangeID] = true
+		change := PairChange{
+			ExchangeID: exchangeID,
+			PairSymbol: pair.MarketPair,
+			VolumeUSD:  pair.VolumeUSD,
+			SourceFile: pair.SourceFile,
+		}
+
+		key := pairKey(exchangeID, pair.MarketPair)
+		if seen[key] {
+			change.Action = ActionSkip
+			change.Reason = "duplicate pair in input"
+			plan = append(plan, change)
+			continue
+		}
+		seen[key] = true
+
+		if registered != nil && !registered[exchangeID] {
+			change.Action = ActionSkip
+			change.Reason = fmt.Sprintf("exchange %s is not in the registry", exchangeID)
+			plan = append(plan, change)
+			continue
+		}
+
+		// Get base token ID
+		baseTokenID, baseExists := allTokens[strings.ToUpper(pair.BaseSymbol)]
+		if !baseExists {
+			// Try with slug
+			baseTokenID, baseExists = slugToID[pair.BaseCurrencySlug]
+		}
+
+		// Get quote token ID - use the quote symbol from JSON
+		quoteTokenID, quoteExists := allTokens[strings.ToUpper(pair.QuoteSymbol)]
+		if !quoteExists && pair.QuoteCurrencySlug != "" {
+			// Try with slug
+			quoteTokenID, quoteExists = slugToID[pair.QuoteCurrencySlug]
+		}
+
+		var missing []string
+		if !baseExists {
+			missing = append(missing, fmt.Sprintf("base token %s (slug: %s) not found", pair.BaseSymbol, pair.BaseCurrencySlug))
+		}
+		if !quoteExists {
+			missing = append(missing, fmt.Sprintf("quote token %s (slug: %s) not found", pair.QuoteSymbol, pair.QuoteCurrencySlug))
+		}
+		if len(missing) > 0 {
+			change.Action = ActionSkip
+			change.Reason = strings.Join(missing, "; ")
+			plan = append(plan, change)
+			continue
+		}
+		change.BaseTokenID = baseTokenID
+		change.QuoteTokenID = quoteTokenID
+
+		current, exists := existing[key]
+		if !exists {
+			change.Action = ActionInsert
+			plan = append(plan, change)
+			continue
+		}
+
+		// last_volume_24h is stored with two decimals
+		volume := math.Round(pair.VolumeUSD*100) / 100
+		if volume != current.volume {
+			change.Action = ActionUpdate
+			change.Changes = append(change.Changes, fmt.Sprintf("last_volume_24h: %.2f -> %.2f", current.volume, volume))
+		} else {
+			change.Action = ActionUnchanged
+		}
+
+		var notes []string
+		if current.baseTokenID != baseTokenID || current.quoteTokenID != quoteTokenID {
+			notes = append(notes, fmt.Sprintf("tokens %d/%d in database, %d/%d mapped now; kept",
+				current.baseTokenID, current.quoteTokenID, baseTokenID, quoteTokenID))
+		}
+		if !current.active {
+			notes = append(notes, "inactive in database; kept")
+		}
+		change.Reason = strings.Join(notes, "; ")
+		plan = append(plan, change)
+	}
+
+	// Rows of the loaded exchanges the input does not list
+	var stale []PairChange
+	for key, current := range existing {
+		parts := strings.SplitN(key, "\x00", 2)
+		if !loadedExchanges[parts[0]] || seen[key] {
+			continue
+		}
+		stale = append(stale, PairChange{
+			Action:       ActionNotInInput,
+			ExchangeID:   parts[0],
+			PairSymbol:   parts[1],
+			BaseTokenID:  current.baseTokenID,
+			QuoteTokenID: current.quoteTokenID,
+			VolumeUSD:    current.volume,
+		})
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		if stale[i].ExchangeID != stale[j].ExchangeID {
+			return stale[i].ExchangeID < stale[j].ExchangeID
+		}
+		return stale[i].PairSymbol < stale[j].PairSymbol
+	})
+
+	return append(plan, stale...)
+}
+
+// planExchangeIDs lists the exchanges the market pairs belong to
+func planExchangeIDs(marketPairs []MarketPair) []string {
+	set := make(map[string]bool)
+	for _, pair := range marketPairs {
+		set[pairExchangeID(pair)] = true
+	}
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Print plan summary: counts by exchange and the most common skip reasons
+func printPlan(plan []PairChange) {
+	byExchange := make(map[string]map[PairAction]int)
+	totals := make(map[PairAction]int)
+	reasons := make(map[string]int)
+	kept := 0
+
+	for _, change := range plan {
+		if byExchange[change.ExchangeID] == nil {
+			byExchange[change.ExchangeID] = make(map[PairAction]int)
+		}
+		byExchange[change.ExchangeID][change.Action]++
+		totals[change.Action]++
+
+		switch {
+		case change.Action == ActionSkip:
+			// Group "base token FOO (slug: foo) not found" style reasons by kind
+			reason := change.Reason
+			if strings.Contains(reason, "not found") {
+				var kinds []string
+				for _, part := range strings.Split(reason, "; ") {
+					kinds = append(kinds, strings.SplitN(part, " token ", 2)[0]+" token not found")
+				}
+				reason = strings.Join(kinds, "; ")
+			}
+			reasons[reason]++
+		case change.Reason != "":
+			kept++
+		}
+	}
+
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Println("Trading Pair Plan")
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Printf("%-24s %10s %10s %10s %10s %12s\n", "Exchange", "Insert", "Update", "Unchanged", "Skip", "Not in input")
+	fmt.Println(strings.Repeat("-", 80))
+
+	exchangeIDs := make([]string, 0, len(byExchange))
+	for id := range byExchange {
+		exchangeIDs = append(exchangeIDs, id)
+	}
+	sort.Strings(exchangeIDs)
+	for _, id := range exchangeIDs {
+		counts := byExchange[id]
+		fmt.Printf("%-24s %10d %10d %10d %10d %12d\n", id,
+			counts[ActionInsert], counts[ActionUpdate], counts[ActionUnchanged], counts[ActionSkip], counts[ActionNotInInput])
+	}
+	fmt.Println(strings.Repeat("-", 80))
+	fmt.Printf("%-24s %10d %10d %10d %10d %12d\n", "Total",
+		totals[ActionInsert], totals[ActionUpdate], totals[ActionUnchanged], totals[ActionSkip], totals[ActionNotInInput])
+
+	if len(reasons) > 0 {
+		fmt.Println("\nSkip reasons:")
+		type reasonCount struct {
+			reason string
+			count  int
+		}
+		var sorted []reasonCount
+		for reason, count := range reasons {
+			sorted = append(sorted, reasonCount{reason, count})
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].count > sorted[j].count })
+		for _, rc := range sorted {
+			fmt.Printf("  - %s: %d\n", rc.reason, rc.count)
+		}
+	}
+	if kept > 0 {
+		fmt.Printf("\n%d existing pairs differ from the mapping in ways the mapper does not change (see the plan file)\n", kept)
+	}
+}
+
+// Save the full plan as JSON
+func savePlan(plan []PairChange, outputFile string) error {
+	jsonData, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %v", err)
+	}
+	if err := os.WriteFile(outputFile, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlanPairs(t *testing.T) {
+	pair := func(base, quote string, volume float64) MarketPair {
+		return MarketPair{
+			BaseSymbol:       base,
+			BaseCurrencySlug: strings.ToLower(base),
+			QuoteSymbol:      quote,
+			MarketPair:       base + "/" + quote,
+			VolumeUSD:        volume,
+			ExchangeSlug:     "Gate",
+		}
+	}
+	allTokens := map[string]int{"BTC": 1, "ETH": 2, "USDT": 3}
+	slugToID := map[string]int{"solana": 4}
+	existing := map[string]existingPair{
+		pairKey("gateio", "ETH/USDT"):  {baseTokenID: 2, quoteTokenID: 3, volume: 10.5, active: true},
+		pairKey("gateio", "SOL/USDT"):  {baseTokenID: 9, quoteTokenID: 3, volume: 20, active: true},
+		pairKey("gateio", "LUNA/USDT"): {baseTokenID: 7, quoteTokenID: 3, volume: 1, active: true},
+		pairKey("okx", "BTC/USDT"):     {baseTokenID: 1, quoteTokenID: 3, volume: 5, active: true},
+	}
+	marketPairs := []MarketPair{
+		pair("BTC", "USDT", 100),
+		pair("ETH", "USDT", 10.501),
+		{BaseSymbol: "SOL", BaseCurrencySlug: "solana", QuoteSymbol: "USDT", MarketPair: "SOL/USDT", VolumeUSD: 30, ExchangeSlug: "Gate"},
+		pair("PEPE", "USDT", 1),
+		pair("BTC", "USDT", 50),
+	}
+
+	plan := planPairs(marketPairs, allTokens, slugToID, existing, map[string]bool{"gateio": true})
+
+	want := []struct {
+		action PairAction
+		symbol string
+		reason string
+	}{
+		{ActionInsert, "BTC/USDT", ""},
+		{ActionUnchanged, "ETH/USDT", ""},
+		{ActionUpdate, "SOL/USDT", "tokens 9/3 in database, 4/3 mapped now; kept"},
+		{ActionSkip, "PEPE/USDT", "base token PEPE (slug: pepe) not found"},
+		{ActionSkip, "BTC/USDT", "duplicate pair in input"},
+		{ActionNotInInput, "LUNA/USDT", ""},
+	}
+	if len(plan) != len(want) {
+		t.Fatalf("plan = %+v", plan)
+	}
+	for i, w := range want {
+		got := plan[i]
+		if got.Action != w.action || got.PairSymbol != w.symbol || got.Reason != w.reason || got.ExchangeID != "gateio" {
+			t.Errorf("plan[%d] = %s %s/%s %q, want %s %s %q", i, got.Action, got.ExchangeID, got.PairSymbol, got.Reason, w.action, w.symbol, w.reason)
+		}
+	}
+	if changes := plan[2].Changes; len(changes) != 1 || changes[0] != "last_volume_24h: 20.00 -> 30.00" {
+		t.Errorf("SOL changes = %v", changes)
+	}
+
+	// Pairs of exchanges outside the registry are not written
+	plan = planPairs([]MarketPair{{BaseSymbol: "BTC", QuoteSymbol: "USDT", MarketPair: "BTC/USDT", ExchangeSlug: "Some Exchange"}},
+		allTokens, slugToID, nil, map[string]bool{"gateio": true})
+	if len(plan) != 1 || plan[0].Action != ActionSkip || plan[0].Reason != "exchange someexchange is not in the registry" {
+		t.Errorf("unregistered exchange plan = %+v", plan)
+	}
+}
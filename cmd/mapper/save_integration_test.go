@@ -0,0 +1,55 @@
+//go:build integration
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestSaveMappingsToDatabase(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ids := testutil.SeedTokens(t, pg, "BTC", "ETH", "USDT")
+	testutil.SeedTradingPair(t, pg, ids["ETH"], ids["USDT"], "gateio", "ETH/USDT")
+
+	plan := []PairChange{
+		{Action: ActionUpdate, ExchangeID: "gateio", PairSymbol: "ETH/USDT", BaseTokenID: ids["ETH"], QuoteTokenID: ids["USDT"], VolumeUSD: 42},
+		{Action: ActionSkip, ExchangeID: "gateio", PairSymbol: "PEPE/USDT", Reason: "base token PEPE (slug: pepe) not found"},
+		{Action: ActionUnchanged, ExchangeID: "gateio", PairSymbol: "SOL/USDT"},
+	}
+	for i := 0; i < 5; i++ {
+		plan = append(plan, PairChange{Action: ActionInsert, ExchangeID: "gateio", PairSymbol: fmt.Sprintf("BTC/USDT-%d", i),
+			BaseTokenID: ids["BTC"], QuoteTokenID: ids["USDT"], VolumeUSD: 1})
+	}
+
+	summary, err := saveMappingsToDatabase(pg, plan, 2)
+	if err != nil {
+		t.Fatalf("saveMappingsToDatabase: %v", err)
+	}
+	if summary.Inserted != 5 || summary.Updated != 1 || summary.Skipped != 1 || summary.Batches != 3 || summary.Committed != 6 {
+		t.Errorf("summary = %+v", summary)
+	}
+	var volume float64
+	pg.QueryRow(`SELECT last_volume_24h FROM trading_pairs WHERE exchange_id = 'gateio' AND exchange_pair_symbol = 'ETH/USDT'`).Scan(&volume)
+	if volume != 42 {
+		t.Errorf("ETH/USDT volume = %v, want 42", volume)
+	}
+
+	// A failing batch is rolled back whole and stops the save
+	failing := []PairChange{
+		{Action: ActionInsert, ExchangeID: "gateio", PairSymbol: "ETH/BTC", BaseTokenID: ids["ETH"], QuoteTokenID: ids["BTC"]},
+		{Action: ActionInsert, ExchangeID: "gateio", PairSymbol: "BAD/USDT", BaseTokenID: 999999, QuoteTokenID: ids["USDT"]},
+		{Action: ActionInsert, ExchangeID: "gateio", PairSymbol: "ETH/BTC-2", BaseTokenID: ids["ETH"], QuoteTokenID: ids["BTC"]},
+	}
+	summary, err = saveMappingsToDatabase(pg, failing, 2)
+	if err == nil || summary.Committed != 0 {
+		t.Fatalf("save with a bad token: %+v, %v", summary, err)
+	}
+	var n int
+	pg.QueryRow(`SELECT COUNT(*) FROM trading_pairs WHERE exchange_pair_symbol LIKE 'ETH/BTC%'`).Scan(&n)
+	if n != 0 {
+		t.Errorf("%d pairs of the failed batch or after it were written", n)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Roles one process can take. Deployments run one image and pick roles with
+// `serve <role>...`, e.g. an API deployment and a poller deployment.
+const (
+	roleAPI       = "api"       // REST API, streams, webhooks and correlations
+	rolePoller    = "poller"    // ticker poller and the jobs computing from it
+	roleIngester  = "ingester"  // Binance WebSocket trade ingester
+	roleScheduler = "scheduler" // cron jobs of internal/scheduler
+)
+
+const usage = `Usage: trading serve <role>...
+
+Roles:
+  api        REST API, streams, webhooks and correlations
+  poller     exchange ticker poller, VWAP, FX, market cap and mapping jobs
+  ingester   Binance WebSocket trade ingester
+  scheduler  token metadata and supply snapshot cron jobs
+  all        api and poller, plus ingester and scheduler when
+             INGESTER_ENABLED or SCHEDULER_ENABLED is true
+
+Roles may also be given comma separated (serve api,poller). Without a
+command the roles are read from SERVICE_MODE, which defaults to all.
+`
+
+// roleSet is the roles this process runs
+type roleSet map[string]bool
+
+// String lists the roles in order, comma separated
+func (r roleSet) String() string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// parseCommand reads the roles from the command line arguments after the
+// program name. It reports false, with no error, when usage was asked for.
+func parseCommand(args []string) (roleSet, bool, error) {
+	if len(args) == 0 {
+		return parseRoles([]string{getEnv("SERVICE_MODE", "all")}, os.Getenv)
+	}
+	switch args[0] {
+	case "serve":
+		if len(args) == 1 {
+			args = append(args, "all")
+		}
+		return parseRoles(args[1:], os.Getenv)
+	case "help", "-h", "-help", "--help":
+		return nil, false, nil
+	}
+	return nil, false, fmt.Errorf("unknown command %q", args[0])
+}
+
+// parseRoles expands role names, which may be comma separated, into a set.
+// getenv reads the INGESTER_ENABLED and SCHEDULER_ENABLED switches that add
+// those roles to a poller.
+func parseRoles(names []string, getenv func(string) string) (roleSet, bool, error) {
+	roles := roleSet{}
+	for _, arg := range names {
+		for _, name := range strings.Split(arg, ",") {
+			switch name = strings.TrimSpace(name); name {
+			case roleAPI, rolePoller, roleIngester, roleScheduler:
+				roles[name] = true
+			case "all":
+				roles[roleAPI] = true
+				roles[rolePoller] = true
+			case "":
+			default:
+				return nil, false, fmt.Errorf("unknown role %q", name)
+			}
+		}
+	}
+	if len(roles) == 0 {
+		return nil, false, fmt.Errorf("no role given")
+	}
+
+	// The switches predate the ingester and scheduler roles and still add
+	// them to a process running the poller
+	if roles[rolePoller] {
+		if getenv("INGESTER_ENABLED") == "true" {
+			roles[roleIngester] = true
+		}
+		if getenv("SCHEDULER_ENABLED") == "true" {
+			roles[roleScheduler] = true
+		}
+	}
+	return roles, true, nil
+}
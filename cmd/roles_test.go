@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestParseRoles(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+	tests := []struct {
+		names []string
+		env   map[string]string
+		want  string
+	}{
+		{[]string{"all"}, nil, "api,poller"},
+		{[]string{"api"}, map[string]string{"INGESTER_ENABLED": "true"}, "api"},
+		{[]string{"poller"}, map[string]string{"INGESTER_ENABLED": "true", "SCHEDULER_ENABLED": "true"}, "ingester,poller,scheduler"},
+		{[]string{"api,ingester", "scheduler"}, nil, "api,ingester,scheduler"},
+		{[]string{"ingester", "ingester"}, nil, "ingester"},
+	}
+	for _, tt := range tests {
+		roles, ok, err := parseRoles(tt.names, env(tt.env))
+		if err != nil || !ok {
+			t.Errorf("parseRoles(%q) = %v, %v", tt.names, ok, err)
+			continue
+		}
+		if got := roles.String(); got != tt.want {
+			t.Errorf("parseRoles(%q) = %s, want %s", tt.names, got, tt.want)
+		}
+	}
+
+	for _, names := range [][]string{{"web"}, {""}, {" , "}} {
+		if _, _, err := parseRoles(names, env(nil)); err == nil {
+			t.Errorf("parseRoles(%q) succeeded, want an error", names)
+		}
+	}
+}
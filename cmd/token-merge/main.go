@@ -0,0 +1,189 @@
+// Command token-merge merges duplicate token rows, splits a token whose
+// listings cover two assets, and resumes the ClickHouse step of either.
+//
+// Usage:
+//
+//	token-merge merge  -source 812 -target 1 -by alice -reason "duplicate BTC row"
+//	token-merge split  -source 42 -exchanges gate,mexc -symbol GMT -name GoMining -slug gomining -by alice
+//	token-merge resume -log 17
+//
+// Every change is written to token_merge_log. Running processes pick up the new
+// mappings on their next resolver refresh, or at once through
+// POST /api/v1/admin/resolver/refresh.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ashmitsharp/trading/internal/tokenops"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cmd := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	var (
+		source    = cmd.Int("source", 0, "Token ID to merge away or split")
+		target    = cmd.Int("target", 0, "merge: token ID to merge into")
+		exchanges = cmd.String("exchanges", "", "split: comma-separated exchanges whose listings move to the new token")
+		symbol    = cmd.String("symbol", "", "split: new token symbol")
+		name      = cmd.String("name", "", "split: new token name")
+		slug      = cmd.String("slug", "", "split: new token slug")
+		chain     = cmd.String("chain", "", "split: new token chain")
+		contract  = cmd.String("contract", "", "split: new token contract address")
+		logID     = cmd.Int("log", 0, "resume: token_merge_log ID")
+		by        = cmd.String("by", os.Getenv("USER"), "Who is performing the change, for the audit log")
+		reason    = cmd.String("reason", "", "Why, for the audit log")
+		skipCH    = cmd.Bool("skip-clickhouse", false, "Change PostgreSQL only and leave the ClickHouse step pending")
+	)
+	cmd.Parse(os.Args[2:])
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Sync()
+
+	db, err := openPostgres()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	var ch clickhouse.Conn
+	if !*skipCH {
+		if ch, err = openClickHouse(); err != nil {
+			log.Fatal(err)
+		}
+		defer ch.Close()
+	}
+
+	service := tokenops.NewService(db, ch, logger)
+	ctx := context.Background()
+
+	var result *tokenops.Result
+	switch os.Args[1] {
+	case "merge":
+		if *source == 0 || *target == 0 || *by == "" {
+			log.Fatal("merge needs -source, -target and -by")
+		}
+		result, err = service.Merge(ctx, tokenops.MergeRequest{
+			SourceTokenID: *source,
+			TargetTokenID: *target,
+			PerformedBy:   *by,
+			Reason:        *reason,
+		})
+	case "split":
+		if *source == 0 || *exchanges == "" || *symbol == "" || *name == "" || *by == "" {
+			log.Fatal("split needs -source, -exchanges, -symbol, -name and -by")
+		}
+		result, err = service.Split(ctx, tokenops.SplitRequest{
+			SourceTokenID:   *source,
+			Exchanges:       splitList(*exchanges),
+			Symbol:          *symbol,
+			Name:            *name,
+			Slug:            *slug,
+			Chain:           *chain,
+			ContractAddress: *contract,
+			PerformedBy:     *by,
+			Reason:          *reason,
+		})
+	case "resume":
+		if *logID == 0 {
+			log.Fatal("resume needs -log")
+		}
+		if ch == nil {
+			log.Fatal("resume cannot run with -skip-clickhouse")
+		}
+		result, err = service.ResumeClickHouse(ctx, *logID)
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("%s failed: %v", os.Args[1], err)
+	}
+
+	fmt.Printf("%s logged as token_merge_log %d: token %d -> %d\n", result.Action, result.LogID, result.SourceTokenID, result.TargetTokenID)
+	for table, n := range result.Moved {
+		fmt.Printf("  %-26s %d rows\n", table, n)
+	}
+	fmt.Printf("  clickhouse: %s %s\n", result.ClickHouseStatus, strings.Join(result.ClickHouseTables, ", "))
+	if result.ClickHouseError != nil {
+		log.Fatalf("ClickHouse step failed: %v\nPostgreSQL is updated; run `token-merge resume -log %d` to finish", result.ClickHouseError, result.LogID)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: token-merge merge|split|resume [flags]")
+	os.Exit(2)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func openPostgres() (*sql.DB, error) {
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		getEnv("POSTGRES_HOST", "localhost"),
+		getEnv("POSTGRES_PORT", "5432"),
+		getEnv("POSTGRES_USER", "crypto_user"),
+		getEnv("POSTGRES_PASSWORD", "crypto_password"),
+		getEnv("POSTGRES_DB", "crypto_platform"))
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping PostgreSQL: %w", err)
+	}
+	return db, nil
+}
+
+func openClickHouse() (clickhouse.Conn, error) {
+	conn, err := clickhouse.Open(&clickhouse.Options{
+		Addr: []string{fmt.Sprintf("%s:%s", getEnv("CLICKHOUSE_HOST", "localhost"), getEnv("CLICKHOUSE_PORT", "9001"))},
+		Auth: clickhouse.Auth{
+			Database: getEnv("CLICKHOUSE_DATABASE", "crypto_platform"),
+			Username: getEnv("CLICKHOUSE_USER", "default"),
+			Password: getEnv("CLICKHOUSE_PASSWORD", "clickhouse123"),
+		},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	if err := conn.Ping(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to ping ClickHouse: %w", err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,13 @@
+// Command tokenctl seeds tokens, exchange symbol mappings and trading pairs,
+// and checks them. Run tokenctl help for the subcommands.
+package main
+
+import (
+	"os"
+
+	"github.com/ashmitsharp/trading/internal/tokenctl"
+)
+
+func main() {
+	os.Exit(tokenctl.Main(os.Args[1:]))
+}
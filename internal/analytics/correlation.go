@@ -0,0 +1,64 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+)
+
+// Correlation returns the Pearson correlation of two equally long series. It
+// returns false when there are fewer than three points or either series is
+// constant.
+func Correlation(a, b []float64) (float64, bool) {
+	n := len(a)
+	if n != len(b) || n < 3 {
+		return 0, false
+	}
+
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= float64(n)
+	meanB /= float64(n)
+
+	var cov, varA, varB float64
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varA*varB), true
+}
+
+// AlignedReturns computes log returns for two hourly price series using only
+// the hours present in both, so gaps in one series do not shift the other
+func AlignedReturns(a, b map[int64]float64) ([]float64, []float64) {
+	hours := make([]int64, 0, len(a))
+	for h := range a {
+		if _, ok := b[h]; ok {
+			hours = append(hours, h)
+		}
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i] < hours[j] })
+
+	pricesA := make([]float64, len(hours))
+	pricesB := make([]float64, len(hours))
+	for i, h := range hours {
+		pricesA[i], pricesB[i] = a[h], b[h]
+	}
+
+	retA, retB := make([]float64, 0, len(hours)), make([]float64, 0, len(hours))
+	for i := 1; i < len(hours); i++ {
+		if pricesA[i-1] <= 0 || pricesB[i-1] <= 0 || pricesA[i] <= 0 || pricesB[i] <= 0 {
+			continue
+		}
+		retA = append(retA, math.Log(pricesA[i]/pricesA[i-1]))
+		retB = append(retB, math.Log(pricesB[i]/pricesB[i-1]))
+	}
+	return retA, retB
+}
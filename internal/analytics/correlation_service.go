@@ -0,0 +1,229 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Window is a named trailing lookback, e.g. "7d"
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+// ParseWindows parses a comma separated list of windows such as "7d,30d".
+// Each window is a positive number of hours (h) or days (d).
+func ParseWindows(spec string) ([]Window, error) {
+	var windows []Window
+	for _, part := range strings.Split(spec, ",") {
+		name := strings.TrimSpace(part)
+		if name == "" {
+			continue
+		}
+		if len(name) < 2 {
+			return nil, fmt.Errorf("invalid window %q", name)
+		}
+		n, err := strconv.Atoi(name[:len(name)-1])
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid window %q", name)
+		}
+		var unit time.Duration
+		switch name[len(name)-1] {
+		case 'h':
+			unit = time.Hour
+		case 'd':
+			unit = 24 * time.Hour
+		default:
+			return nil, fmt.Errorf("invalid window %q: unit must be h or d", name)
+		}
+		windows = append(windows, Window{Name: name, Duration: time.Duration(n) * unit})
+	}
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("no windows in %q", spec)
+	}
+	return windows, nil
+}
+
+// CorrelationMatrix holds pairwise hourly return correlations for a set of
+// tokens. Values[i][j] is nil when the two series overlap too little.
+type CorrelationMatrix struct {
+	Window     string
+	TokenIDs   []int
+	Symbols    []string
+	Values     [][]*float64
+	ComputedAt time.Time
+}
+
+// CorrelationService periodically computes return correlations between the
+// top-N tokens and caches the matrices in memory
+type CorrelationService struct {
+	postgresDB  *sql.DB
+	vwapStorage *storage.VWAPStorage
+	topN        int
+	windows     []Window
+	logger      *zap.Logger
+
+	mu       sync.RWMutex
+	matrices map[string]*CorrelationMatrix
+}
+
+// NewCorrelationService creates a correlation service over the topN tokens by
+// rank for each of windows
+func NewCorrelationService(postgresDB *sql.DB, vwapStorage *storage.VWAPStorage, topN int, windows []Window, logger *zap.Logger) *CorrelationService {
+	return &CorrelationService{
+		postgresDB:  postgresDB,
+		vwapStorage: vwapStorage,
+		topN:        topN,
+		windows:     windows,
+		logger:      logger,
+		matrices:    make(map[string]*CorrelationMatrix),
+	}
+}
+
+// Windows returns the names of the configured windows
+func (s *CorrelationService) Windows() []string {
+	names := make([]string, len(s.windows))
+	for i, w := range s.windows {
+		names[i] = w.Name
+	}
+	return names
+}
+
+// Matrix returns the cached matrix for a window, or false if the window is
+// not configured or has not been computed yet
+func (s *CorrelationService) Matrix(window string) (*CorrelationMatrix, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.matrices[window]
+	return m, ok
+}
+
+// Refresh recomputes every window's matrix from hourly USD VWAP closes
+func (s *CorrelationService) Refresh(ctx context.Context) error {
+	ids, symbols, err := s.topTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	quotes, err := db.GetUSDQuoteTokens(ctx, s.postgresDB)
+	if err != nil {
+		return err
+	}
+	quoteID, ok := 0, false
+	for _, symbol := range db.USDQuoteSymbols {
+		if quoteID, ok = quotes[symbol]; ok {
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("no USD quote token found")
+	}
+
+	var longest time.Duration
+	for _, w := range s.windows {
+		if w.Duration > longest {
+			longest = w.Duration
+		}
+	}
+
+	now := time.Now()
+	closes, err := s.vwapStorage.GetHourlyVWAPCloses(ctx, ids, quoteID, now.Add(-longest))
+	if err != nil {
+		return fmt.Errorf("failed to load hourly prices: %w", err)
+	}
+
+	matrices := make(map[string]*CorrelationMatrix, len(s.windows))
+	for _, w := range s.windows {
+		since := now.Add(-w.Duration).Unix()
+		series := make([]map[int64]float64, len(ids))
+		for i, id := range ids {
+			series[i] = make(map[int64]float64)
+			for hour, price := range closes[id] {
+				if hour >= since {
+					series[i][hour] = price.InexactFloat64()
+				}
+			}
+		}
+
+		matrices[w.Name] = &CorrelationMatrix{
+			Window:     w.Name,
+			TokenIDs:   ids,
+			Symbols:    symbols,
+			Values:     correlationMatrix(series),
+			ComputedAt: now,
+		}
+	}
+
+	s.mu.Lock()
+	s.matrices = matrices
+	s.mu.Unlock()
+
+	s.logger.Info("Correlation matrices refreshed",
+		zap.Int("tokens", len(ids)),
+		zap.Int("windows", len(matrices)))
+	return nil
+}
+
+// correlationMatrix computes the symmetric matrix of pairwise correlations
+func correlationMatrix(series []map[int64]float64) [][]*float64 {
+	n := len(series)
+	values := make([][]*float64, n)
+	for i := range values {
+		values[i] = make([]*float64, n)
+	}
+
+	for i := 0; i < n; i++ {
+		if len(series[i]) > 0 {
+			one := 1.0
+			values[i][i] = &one
+		}
+		for j := i + 1; j < n; j++ {
+			a, b := AlignedReturns(series[i], series[j])
+			if corr, ok := Correlation(a, b); ok {
+				values[i][j], values[j][i] = &corr, &corr
+			}
+		}
+	}
+	return values
+}
+
+// topTokens returns the topN active tokens by our computed rank, falling back
+// to the imported rank, excluding the USD quote tokens themselves
+func (s *CorrelationService) topTokens(ctx context.Context) ([]int, []string, error) {
+	rows, err := s.postgresDB.QueryContext(ctx, `
+		SELECT id, symbol
+		FROM tokens
+		WHERE is_active = true
+		  AND (computed_rank IS NOT NULL OR market_cap_rank IS NOT NULL)
+		  AND NOT (symbol = ANY($2))
+		ORDER BY computed_rank ASC NULLS LAST, market_cap_rank ASC NULLS LAST, id
+		LIMIT $1
+	`, s.topN, pq.Array(db.USDQuoteSymbols))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query top tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	var symbols []string
+	for rows.Next() {
+		var id int
+		var symbol string
+		if err := rows.Scan(&id, &symbol); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan top token: %w", err)
+		}
+		ids = append(ids, id)
+		symbols = append(symbols, symbol)
+	}
+	return ids, symbols, rows.Err()
+}
@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"math"
+)
+
+// HoursPerYear annualizes volatility computed from hourly returns; crypto
+// markets trade around the clock
+const HoursPerYear = 24 * 365
+
+// LogReturns returns the log return between each pair of consecutive prices.
+// Non-positive prices are skipped along with the return that would use them.
+func LogReturns(prices []float64) []float64 {
+	returns := make([]float64, 0, len(prices))
+	prev := 0.0
+	for _, p := range prices {
+		if p <= 0 {
+			continue
+		}
+		if prev > 0 {
+			returns = append(returns, math.Log(p/prev))
+		}
+		prev = p
+	}
+	return returns
+}
+
+// RealizedVolatility returns the annualized sample standard deviation of
+// returns, given how many return periods make up a year. It returns false
+// when there are fewer than two returns.
+func RealizedVolatility(returns []float64, periodsPerYear float64) (float64, bool) {
+	n := len(returns)
+	if n < 2 {
+		return 0, false
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(n)
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(n - 1)
+
+	return math.Sqrt(variance) * math.Sqrt(periodsPerYear), true
+}
+
+// MaxDrawdown returns the largest peak-to-trough decline in prices as a
+// positive fraction, e.g. 0.25 for a 25% drawdown. It returns false when
+// there are fewer than two prices.
+func MaxDrawdown(prices []float64) (float64, bool) {
+	if len(prices) < 2 {
+		return 0, false
+	}
+
+	peak, maxDD := 0.0, 0.0
+	for _, p := range prices {
+		if p > peak {
+			peak = p
+		}
+		if peak > 0 {
+			if dd := (peak - p) / peak; dd > maxDD {
+				maxDD = dd
+			}
+		}
+	}
+	return maxDD, true
+}
+
+// SimpleReturn returns the fractional change from the first to the last
+// price. It returns false when the window is too short or starts at zero.
+func SimpleReturn(prices []float64) (float64, bool) {
+	if len(prices) < 2 || prices[0] <= 0 {
+		return 0, false
+	}
+	return prices[len(prices)-1]/prices[0] - 1, true
+}
@@ -0,0 +1,240 @@
+// Package api assembles the HTTP API: the middleware every API route shares
+// and the one route table, registered on the handlers in package handler.
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/zap"
+
+	"github.com/ashmitsharp/trading/internal/handler"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/ashmitsharp/trading/pkg/utils"
+)
+
+// Handlers are the handlers the route table registers
+type Handlers struct {
+	Health       *handler.HealthHandler
+	GraphQL      *handler.GraphQLHandler
+	Exchange     *handler.ExchangeHandler
+	VolumeShare  *handler.VolumeShareHandler
+	Pair         *handler.PairHandler
+	Token        *handler.TokenHandler
+	Ticker       *handler.TickerHandler
+	VWAP         *handler.VWAPHandler
+	Price        *handler.PriceHandler
+	Stream       *handler.StreamHandler
+	Watchlist    *handler.WatchlistHandler
+	Webhook      *handler.WebhookHandler
+	OHLCV        *handler.OHLCVHandler
+	Trade        *handler.TradeHandler
+	Listings     *handler.ListingsHandler
+	Index        *handler.IndexHandler
+	Analytics    *handler.AnalyticsHandler
+	Verification *handler.VerificationHandler
+	MappingAdmin *handler.MappingAdminHandler
+	MappingSet   *handler.MappingSetHandler
+	Threshold    *handler.OutlierThresholdHandler
+	FeatureFlag  *handler.FeatureFlagHandler
+	Status       *handler.StatusHandler
+	Resolver     *handler.ResolverHandler
+	TokenAdmin   *handler.TokenAdminHandler
+	Reconcile    *handler.ReconciliationHandler
+	Operations   *handler.OperationsHandler
+}
+
+// Options configures the middleware of the API router
+type Options struct {
+	// TrustedProxies are the proxies whose X-Forwarded-For is used as the
+	// client IP; none are trusted by default
+	TrustedProxies []string
+	// RequestLog records every request for the usage rollups; nil records none
+	RequestLog      *storage.RequestLog
+	CompressMinSize int
+	RateLimiter     *handler.RateLimiter
+	// APIKeys identify watchlist and webhook owners; AdminKeys open /api/v1/admin
+	APIKeys   []string
+	AdminKeys []string
+}
+
+// NewRouter returns the API router with every route registered
+func NewRouter(h Handlers, opts Options, logger *zap.Logger) *gin.Engine {
+	router := gin.New()
+	// X-Forwarded-For is only believed from TrustedProxies, so clients
+	// cannot pick the IP their rate limit bucket is keyed on
+	if err := router.SetTrustedProxies(opts.TrustedProxies); err != nil {
+		logger.Error("Invalid trusted proxies, trusting none", zap.Error(err))
+		router.SetTrustedProxies(nil)
+	}
+	router.Use(handler.RequestID())
+	// Requests are logged for the usage rollups outside Recovery, so a
+	// panicking handler is logged with the 500 it turned into
+	if opts.RequestLog != nil {
+		router.Use(handler.LogRequests(opts.RequestLog))
+	}
+	router.Use(handler.Recovery(logger))
+	router.Use(utils.LoggerMiddleware(logger))
+	router.Use(handler.Compress(opts.CompressMinSize))
+
+	registerRoutes(router, h, opts)
+	return router
+}
+
+// NewProbeRouter returns a router serving only the health checks, for
+// processes running without the API role
+func NewProbeRouter(health *handler.HealthHandler, logger *zap.Logger) *gin.Engine {
+	router := gin.New()
+	router.Use(handler.RequestID())
+	router.Use(handler.Recovery(logger))
+	registerHealthRoutes(router, health)
+	return router
+}
+
+// registerHealthRoutes registers the health checks, served by every process
+// whichever roles it runs: /livez for liveness, /readyz for readiness
+func registerHealthRoutes(router *gin.Engine, health *handler.HealthHandler) {
+	router.GET("/livez", health.Livez)
+	router.GET("/readyz", health.Readyz)
+	router.GET("/health", health.Health)
+}
+
+// registerRoutes registers every route of the API
+func registerRoutes(router *gin.Engine, h Handlers, opts Options) {
+	registerHealthRoutes(router, h.Health)
+
+	// API documentation
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// GraphQL endpoint for combined metadata and market data queries
+	limit := opts.RateLimiter.Middleware()
+	router.GET("/graphql", limit, h.GraphQL.Query)
+	router.POST("/graphql", limit, h.GraphQL.Query)
+
+	// Serve admin dashboard
+	router.Static("/admin", "./web/admin")
+
+	// API v1 routes
+	v1 := router.Group("/api/v1", limit)
+	{
+		// Exchange endpoints
+		v1.GET("/exchanges", h.Exchange.ListExchanges)
+		v1.GET("/exchanges/volume-share", h.VolumeShare.GetVolumeShare)
+		v1.GET("/exchanges/:id", h.Exchange.GetExchange)
+
+		// Trading pair metadata
+		v1.GET("/pairs/:exchange/:symbol", h.Pair.GetPair)
+
+		// Token endpoints
+		v1.GET("/tokens", h.Token.ListTokens)
+		v1.GET("/tokens/:id", h.Token.GetToken)
+		v1.GET("/tokens/:id/full", h.Token.GetTokenFull) // :id accepts a symbol
+		v1.GET("/tokens/:id/coverage", h.Token.GetTokenCoverage)
+		v1.GET("/tokens/:id/supply-history", h.Token.GetSupplyHistory)
+		v1.GET("/categories", h.Token.ListCategories)
+
+		// Ticker endpoints
+		v1.GET("/tickers", h.Ticker.GetTicker)
+		v1.GET("/tickers/:symbol", h.Ticker.GetTickerBySymbol)
+
+		// VWAP endpoints
+		v1.GET("/vwap", h.VWAP.ListVWAP)
+		v1.GET("/vwap/:symbol", handler.ValidateSymbolParam(), h.VWAP.GetVWAP)
+		v1.GET("/vwap/:symbol/composition", handler.ValidateSymbolParam(), h.VWAP.GetVWAPComposition)
+		v1.GET("/vwap/:symbol/at", handler.ValidateSymbolParam(), h.VWAP.GetVWAPAt)
+
+		// Price endpoints
+		v1.GET("/price/:base", h.Price.GetPrice)
+		v1.POST("/prices/batch", h.Price.BatchPrices)
+
+		// Streaming endpoints (Server-Sent Events)
+		v1.GET("/stream/ticker", h.Stream.StreamTicker)
+
+		// Watchlist endpoints, per API key
+		watchlists := v1.Group("/watchlists", handler.RequireAPIKey(opts.APIKeys))
+		watchlists.GET("", h.Watchlist.ListWatchlists)
+		watchlists.POST("", h.Watchlist.CreateWatchlist)
+		watchlists.GET("/:id", h.Watchlist.GetWatchlist)
+		watchlists.PUT("/:id", h.Watchlist.UpdateWatchlist)
+		watchlists.DELETE("/:id", h.Watchlist.DeleteWatchlist)
+		watchlists.GET("/:id/quotes", h.Watchlist.GetWatchlistQuotes)
+
+		// Webhook subscriptions, per API key
+		hooks := v1.Group("/webhooks", handler.RequireAPIKey(opts.APIKeys))
+		hooks.GET("", h.Webhook.ListWebhooks)
+		hooks.POST("", h.Webhook.CreateWebhook)
+		hooks.GET("/:id", h.Webhook.GetWebhook)
+		hooks.DELETE("/:id", h.Webhook.DeleteWebhook)
+
+		// OHLCV endpoints
+		v1.GET("/ohlcv/symbols", h.OHLCV.GetSupportedSymbols)
+		v1.GET("/ohlcv/:symbol", handler.ValidateSymbolParam(), h.OHLCV.GetOHLCV)
+
+		// Raw trade export
+		v1.GET("/trades/:symbol", handler.ValidateSymbolParam(), h.Trade.ExportTrades)
+		v1.GET("/trades/:symbol/stats", handler.ValidateSymbolParam(), h.Trade.GetTradeStats)
+
+		// Listing endpoints
+		v1.GET("/listings/new", h.Listings.GetNewListings)
+
+		// Index endpoints
+		v1.GET("/indices", h.Index.ListIndices)
+		v1.GET("/indices/:id", h.Index.GetIndex)
+
+		// Analytics endpoints
+		v1.GET("/analytics/correlations", h.Analytics.GetCorrelations)
+		v1.GET("/analytics/:symbol", handler.ValidateSymbolParam(), h.Analytics.GetAnalytics)
+
+		// Verification and other admin endpoints, open to AdminKeys only
+		admin := v1.Group("/admin", handler.RequireAPIKey(opts.AdminKeys))
+		{
+			admin.GET("/mappings", h.MappingAdmin.ListSymbolMappings)
+			admin.POST("/mappings", h.MappingAdmin.CreateSymbolMapping)
+			admin.PUT("/mappings/:id", h.MappingAdmin.UpdateSymbolMapping)
+			admin.DELETE("/mappings/:id", h.MappingAdmin.DeleteSymbolMapping)
+			admin.GET("/mappings/unverified", h.Verification.GetUnverifiedMappings)
+			admin.POST("/mappings/:id/verify", h.Verification.VerifyMapping)
+			admin.POST("/mappings/:id/flag", h.Verification.FlagMapping)
+			admin.GET("/mappings/pending", h.Verification.GetPendingMappings)
+			admin.GET("/mappings/export", h.MappingSet.ExportMappings)
+			admin.POST("/mappings/import", h.MappingSet.ImportMappings)
+			admin.POST("/mappings/pending/:id/resolve", h.Verification.ResolvePendingMapping)
+			admin.POST("/mappings/pending/:id/ignore", h.Verification.IgnorePendingMapping)
+			admin.GET("/outliers", h.Verification.GetOutliers)
+			admin.POST("/outliers/:id/resolve", h.Verification.ResolveOutlier)
+			admin.POST("/outliers/:id/apply", h.Verification.ApplyOutlierSuggestion)
+			admin.GET("/outlier-thresholds", h.Threshold.ListOutlierThresholds)
+			admin.PUT("/outlier-thresholds/:base/:quote", h.Threshold.SetOutlierThreshold)
+			admin.DELETE("/outlier-thresholds/:base/:quote", h.Threshold.DeleteOutlierThreshold)
+			admin.GET("/feature-flags", h.FeatureFlag.ListFeatureFlags)
+			admin.PUT("/feature-flags/:name", h.FeatureFlag.SetFeatureFlag)
+			admin.DELETE("/feature-flags/:name", h.FeatureFlag.DeleteFeatureFlag)
+			admin.GET("/status", h.Status.GetStatus)
+			admin.GET("/resolver", h.Resolver.GetStats)
+			admin.POST("/resolver/refresh", h.Resolver.Refresh)
+			admin.GET("/pairs", h.MappingAdmin.ListTradingPairs)
+			admin.POST("/pairs", h.MappingAdmin.CreateTradingPair)
+			admin.PUT("/pairs/:id", h.MappingAdmin.UpdateTradingPair)
+			admin.DELETE("/pairs/:id", h.MappingAdmin.DeleteTradingPair)
+			admin.POST("/tokens", h.TokenAdmin.CreateToken)
+			admin.PUT("/tokens/:id", h.TokenAdmin.UpdateToken)
+			admin.DELETE("/tokens/:id", h.TokenAdmin.DeactivateToken)
+			admin.POST("/tokens/:id/merge", h.TokenAdmin.MergeToken)
+			admin.POST("/tokens/:id/split", h.TokenAdmin.SplitToken)
+			admin.POST("/tokens/:id/archive", h.TokenAdmin.ArchiveToken)
+			admin.POST("/tokens/:id/restore", h.TokenAdmin.RestoreToken)
+			admin.POST("/token-merges/:id/resume", h.TokenAdmin.ResumeClickHouse)
+			admin.POST("/exchanges", h.Exchange.CreateExchange)
+			admin.PUT("/exchanges/:id", h.Exchange.UpdateExchange)
+			admin.DELETE("/exchanges/:id", h.Exchange.DeleteExchange)
+			admin.GET("/exchanges/:id/maintenance", h.Exchange.ListMaintenanceWindows)
+			admin.POST("/exchanges/:id/maintenance", h.Exchange.CreateMaintenanceWindow)
+			admin.DELETE("/exchanges/:id/maintenance/:window_id", h.Exchange.DeleteMaintenanceWindow)
+			admin.GET("/reconciliation-reports", h.Reconcile.ListReconciliationReports)
+			admin.GET("/exchange-uptime", h.Operations.GetExchangeUptime)
+			admin.GET("/exchange-weights", h.Exchange.ListWeightHistory)
+			admin.GET("/api-usage", h.Operations.GetAPIUsage)
+			admin.POST("/reconciliation-reports", h.Reconcile.RunReconciliation)
+		}
+	}
+}
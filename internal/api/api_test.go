@@ -0,0 +1,32 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TestNewRouter registers the whole route table, which panics on a route
+// registered twice or conflicting with another
+func TestNewRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := NewRouter(Handlers{}, Options{}, zap.NewNop())
+
+	routes := make(map[string]bool)
+	for _, r := range router.Routes() {
+		routes[r.Method+" "+r.Path] = true
+	}
+	for _, want := range []string{
+		"GET /readyz",
+		"GET /health",
+		"GET /api/v1/tokens",
+		"GET /api/v1/tickers/:symbol",
+		"POST /api/v1/admin/mappings/:id/verify",
+		"DELETE /api/v1/admin/pairs/:id",
+	} {
+		if !routes[want] {
+			t.Errorf("route %s is not registered", want)
+		}
+	}
+}
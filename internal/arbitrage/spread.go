@@ -0,0 +1,78 @@
+package arbitrage
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+var bps = decimal.NewFromInt(10000)
+
+// Quote is an exchange's price for a pair and the taker fee it charges, as a
+// fraction of the notional
+type Quote struct {
+	Exchange string
+	Price    decimal.Decimal
+	TakerFee decimal.Decimal
+}
+
+// EffectiveBuy is what buying one unit at price costs once the taker fee is
+// paid
+func EffectiveBuy(price, fee decimal.Decimal) decimal.Decimal {
+	return price.Mul(decimal.NewFromInt(1).Add(fee))
+}
+
+// EffectiveSell is what selling one unit at price returns once the taker fee
+// is paid
+func EffectiveSell(price, fee decimal.Decimal) decimal.Decimal {
+	return price.Mul(decimal.NewFromInt(1).Sub(fee))
+}
+
+// Spread is buying on one exchange and selling on another. GrossBps is the
+// gap between the two prices and NetBps the gap left after both legs' taker
+// fees, both relative to the buy price. NetBps is negative when the fees eat
+// the whole gap.
+type Spread struct {
+	BuyExchange   string
+	SellExchange  string
+	BuyPrice      decimal.Decimal
+	SellPrice     decimal.Decimal
+	EffectiveBuy  decimal.Decimal
+	EffectiveSell decimal.Decimal
+	GrossBps      float64
+	NetBps        float64
+}
+
+// Best returns the pair of exchanges with the widest spread after fees. It
+// returns false for fewer than two exchanges with a positive price.
+func Best(quotes []Quote) (Spread, bool) {
+	var best Spread
+	found := false
+	for _, buy := range quotes {
+		if !buy.Price.IsPositive() {
+			continue
+		}
+		for _, sell := range quotes {
+			if sell.Exchange == buy.Exchange || !sell.Price.IsPositive() {
+				continue
+			}
+			s := spread(buy, sell)
+			if !found || s.NetBps > best.NetBps {
+				best, found = s, true
+			}
+		}
+	}
+	return best, found
+}
+
+func spread(buy, sell Quote) Spread {
+	s := Spread{
+		BuyExchange:   buy.Exchange,
+		SellExchange:  sell.Exchange,
+		BuyPrice:      buy.Price,
+		SellPrice:     sell.Price,
+		EffectiveBuy:  EffectiveBuy(buy.Price, buy.TakerFee),
+		EffectiveSell: EffectiveSell(sell.Price, sell.TakerFee),
+	}
+	s.GrossBps = sell.Price.Sub(buy.Price).Div(buy.Price).Mul(bps).Round(2).InexactFloat64()
+	s.NetBps = s.EffectiveSell.Sub(s.EffectiveBuy).Div(s.EffectiveBuy).Mul(bps).Round(2).InexactFloat64()
+	return s
+}
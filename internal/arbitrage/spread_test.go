@@ -0,0 +1,49 @@
+package arbitrage
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func quote(exchange string, price, fee float64) Quote {
+	return Quote{Exchange: exchange, Price: decimal.NewFromFloat(price), TakerFee: decimal.NewFromFloat(fee)}
+}
+
+func TestBest(t *testing.T) {
+	tests := []struct {
+		name      string
+		quotes    []Quote
+		buy, sell string
+		gross     float64
+		net       float64
+		found     bool
+	}{
+		{"no quotes", nil, "", "", 0, 0, false},
+		{"one exchange", []Quote{quote("binance", 100, 0.001)}, "", "", 0, 0, false},
+		{"gap wider than fees", []Quote{quote("binance", 100, 0.001), quote("kraken", 101, 0.001)},
+			"binance", "kraken", 100, 79.82, true},
+		{"fees eat a small gap", []Quote{quote("binance", 100, 0.001), quote("kraken", 100.1, 0.0026)},
+			"binance", "kraken", 10, -26, true},
+		{"cheaper fees beat a wider raw gap", []Quote{
+			quote("binance", 100, 0.001), quote("kraken", 100.3, 0.004), quote("okx", 100.25, 0.0008)},
+			"binance", "okx", 25, 6.97, true},
+		{"zero price ignored", []Quote{quote("binance", 0, 0.001), quote("kraken", 100, 0.001)}, "", "", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, found := Best(tt.quotes)
+			if found != tt.found {
+				t.Fatalf("found = %v, want %v", found, tt.found)
+			}
+			if !found {
+				return
+			}
+			if s.BuyExchange != tt.buy || s.SellExchange != tt.sell || s.GrossBps != tt.gross || s.NetBps != tt.net {
+				t.Errorf("Best = %s -> %s gross %v net %v, want %s -> %s gross %v net %v",
+					s.BuyExchange, s.SellExchange, s.GrossBps, s.NetBps, tt.buy, tt.sell, tt.gross, tt.net)
+			}
+		})
+	}
+}
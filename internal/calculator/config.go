@@ -0,0 +1,80 @@
+package calculator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/outlier"
+)
+
+// Config holds the rules a VWAP must meet to be published as a composite
+type Config struct {
+	// MinExchanges is the number of distinct exchanges that must contribute
+	// for a VWAP to count as a composite
+	MinExchanges int
+	// MinVolumeShare is the share (0-1) of the volume reported for the pair
+	// across all exchanges this cycle that must survive filtering, so a VWAP
+	// left with one small venue after outlier removal is not a composite
+	MinVolumeShare float64
+	// MaxPriceAge is how old a price may be before it is excluded. Prices
+	// older than half of it contribute with a weight that decays linearly to
+	// zero at MaxPriceAge. Zero disables the staleness check.
+	MaxPriceAge time.Duration
+	// ExchangeMaxPriceAge overrides MaxPriceAge for individual exchanges,
+	// e.g. venues whose tickers only refresh every few minutes
+	ExchangeMaxPriceAge map[string]time.Duration
+	// Outliers decides which prices are dropped before averaging
+	Outliers outlier.Thresholds
+	// OutlierOverrides, when set, replaces Outliers with the thresholds it
+	// holds for each pair, e.g. wider bands for illiquid tokens
+	OutlierOverrides *outlier.Overrides
+}
+
+// DefaultConfig returns the configuration used when none is given
+func DefaultConfig() Config {
+	return Config{
+		MinExchanges:   2,
+		MinVolumeShare: 0.5,
+		MaxPriceAge:    2 * time.Minute,
+		Outliers:       outlier.DefaultThresholds(),
+	}
+}
+
+// maxPriceAge returns the staleness limit for an exchange
+func (c Config) maxPriceAge(exchangeID string) time.Duration {
+	if age, ok := c.ExchangeMaxPriceAge[exchangeID]; ok {
+		return age
+	}
+	return c.MaxPriceAge
+}
+
+// outlierThresholds returns the outlier thresholds for a pair
+func (c Config) outlierThresholds(baseTokenID, quoteTokenID int) outlier.Thresholds {
+	if c.OutlierOverrides != nil {
+		return c.OutlierOverrides.For(baseTokenID, quoteTokenID)
+	}
+	return c.Outliers
+}
+
+// ParseExchangeMaxPriceAge parses per-exchange age limits such as
+// "kraken=5m,bitstamp=90s"
+func ParseExchangeMaxPriceAge(spec string) (map[string]time.Duration, error) {
+	ages := make(map[string]time.Duration)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		exchange, raw, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(exchange) == "" {
+			return nil, fmt.Errorf("invalid exchange max age %q: want exchange=duration", part)
+		}
+		age, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || age < 0 {
+			return nil, fmt.Errorf("invalid max age for %s: %q", exchange, raw)
+		}
+		ages[strings.ToLower(strings.TrimSpace(exchange))] = age
+	}
+	return ages, nil
+}
@@ -5,19 +5,23 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ashmitsharp/trading/internal/liquidity"
+	"github.com/ashmitsharp/trading/internal/outlier"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
 // VWAPCalculator calculates Volume Weighted Average Price across exchanges
 type VWAPCalculator struct {
+	config Config
 	logger *zap.Logger
 	mu     sync.RWMutex
 }
 
 // NewVWAPCalculator creates a new VWAP calculator
-func NewVWAPCalculator(logger *zap.Logger) *VWAPCalculator {
+func NewVWAPCalculator(config Config, logger *zap.Logger) *VWAPCalculator {
 	return &VWAPCalculator{
+		config: config,
 		logger: logger,
 	}
 }
@@ -34,6 +38,41 @@ type PriceData struct {
 	Timestamp    time.Time
 }
 
+// PairKey identifies a token pair by resolved token IDs
+type PairKey struct {
+	BaseTokenID  int
+	QuoteTokenID int
+}
+
+// String formats the key as "base-quote" for logging
+func (k PairKey) String() string {
+	return fmt.Sprintf("%d-%d", k.BaseTokenID, k.QuoteTokenID)
+}
+
+// Resolved reports whether both token IDs are set
+func (k PairKey) Resolved() bool {
+	return k.BaseTokenID > 0 && k.QuoteTokenID > 0
+}
+
+// Key returns the pair this price belongs to
+func (p PriceData) Key() PairKey {
+	return PairKey{BaseTokenID: p.BaseTokenID, QuoteTokenID: p.QuoteTokenID}
+}
+
+// GroupByPair groups prices by token pair, dropping prices whose token IDs
+// have not been resolved
+func GroupByPair(prices []PriceData) map[PairKey][]PriceData {
+	grouped := make(map[PairKey][]PriceData)
+	for _, p := range prices {
+		key := p.Key()
+		if !key.Resolved() {
+			continue
+		}
+		grouped[key] = append(grouped[key], p)
+	}
+	return grouped
+}
+
 // VWAPResult represents the calculated VWAP price
 type VWAPResult struct {
 	BaseTokenID          int
@@ -43,6 +82,10 @@ type VWAPResult struct {
 	ExchangeCount        int
 	ContributingExchanges []string
 	PriceSources         []PriceSource
+	SpreadBps            float64 // Highest vs lowest source price, in basis points of VWAP
+	LiquidityScore       float64 // 0-100, see liquidity.Score
+	VolumeShare          float64 // Contributing volume over volume reported by all exchanges
+	Indicative           bool    // Below the configured quorum; not a true composite
 	Timestamp            time.Time
 }
 
@@ -59,6 +102,15 @@ func (v *VWAPCalculator) Calculate(prices []PriceData) (*VWAPResult, error) {
 	if len(prices) == 0 {
 		return nil, fmt.Errorf("no price data provided")
 	}
+	key := prices[0].Key()
+	if !key.Resolved() {
+		return nil, fmt.Errorf("unresolved token IDs for pair %s", key)
+	}
+	for _, p := range prices[1:] {
+		if p.Key() != key {
+			return nil, fmt.Errorf("mixed pairs in price data: %s and %s", key, p.Key())
+		}
+	}
 
 	v.mu.Lock()
 	defer v.mu.Unlock()
@@ -69,6 +121,12 @@ func (v *VWAPCalculator) Calculate(prices []PriceData) (*VWAPResult, error) {
 		return nil, fmt.Errorf("no valid prices after filtering")
 	}
 
+	// Drop or down-weight prices from venues that stopped updating
+	validPrices = v.applyStaleness(validPrices, time.Now())
+	if len(validPrices) == 0 {
+		return nil, fmt.Errorf("no fresh prices after staleness check")
+	}
+
 	// Detect and remove outliers
 	cleanPrices := v.removeOutliers(validPrices)
 	if len(cleanPrices) == 0 {
@@ -77,16 +135,45 @@ func (v *VWAPCalculator) Calculate(prices []PriceData) (*VWAPResult, error) {
 
 	// Calculate VWAP
 	result := v.calculateVWAP(cleanPrices)
-	
+	v.applyQuorum(result, referenceVolume(prices))
+
 	v.logger.Debug("VWAP calculated",
 		zap.Int("base_token_id", result.BaseTokenID),
 		zap.Int("quote_token_id", result.QuoteTokenID),
 		zap.String("vwap_price", result.VWAPPrice.String()),
-		zap.Int("exchanges", result.ExchangeCount))
+		zap.Int("exchanges", result.ExchangeCount),
+		zap.Bool("indicative", result.Indicative))
 
 	return result, nil
 }
 
+// applyQuorum flags a result as indicative when too few exchanges or too
+// little of the reference volume contributed to it
+func (v *VWAPCalculator) applyQuorum(result *VWAPResult, reference decimal.Decimal) {
+	if reference.IsPositive() {
+		result.VolumeShare = result.TotalVolume.Div(reference).InexactFloat64()
+	}
+	result.Indicative = result.ExchangeCount < v.config.MinExchanges ||
+		result.VolumeShare < v.config.MinVolumeShare
+}
+
+// referenceVolume sums the volume every exchange reported for the pair before
+// any filtering, counting each exchange's largest listing once as
+// calculateVWAP does
+func referenceVolume(prices []PriceData) decimal.Decimal {
+	byExchange := make(map[string]decimal.Decimal)
+	for _, p := range prices {
+		if p.Volume.GreaterThan(byExchange[p.ExchangeID]) {
+			byExchange[p.ExchangeID] = p.Volume
+		}
+	}
+	total := decimal.Zero
+	for _, vol := range byExchange {
+		total = total.Add(vol)
+	}
+	return total
+}
+
 // filterValidPrices removes invalid price entries
 func (v *VWAPCalculator) filterValidPrices(prices []PriceData) []PriceData {
 	valid := make([]PriceData, 0, len(prices))
@@ -115,52 +202,63 @@ func (v *VWAPCalculator) filterValidPrices(prices []PriceData) []PriceData {
 	return valid
 }
 
-// removeOutliers removes prices that deviate too much from median
-func (v *VWAPCalculator) removeOutliers(prices []PriceData) []PriceData {
-	if len(prices) < 3 {
-		// Not enough data points to detect outliers
-		return prices
-	}
-
-	// Calculate median price
-	median := v.calculateMedianPrice(prices)
-	
-	// Define outlier threshold (e.g., 50% deviation from median for crypto volatility)
-	threshold := decimal.NewFromFloat(0.50)
-	maxDeviation := median.Mul(threshold)
-	
-	cleaned := make([]PriceData, 0, len(prices))
-	
+// applyStaleness excludes prices older than their exchange's max age and
+// scales the weight of prices past half of it down linearly, so a venue that
+// stopped updating fades out of the composite instead of dragging it. Prices
+// without a timestamp are kept as is.
+func (v *VWAPCalculator) applyStaleness(prices []PriceData, now time.Time) []PriceData {
+	fresh := make([]PriceData, 0, len(prices))
 	for _, p := range prices {
-		deviation := p.Price.Sub(median).Abs()
-		if deviation.LessThanOrEqual(maxDeviation) {
-			cleaned = append(cleaned, p)
-		} else {
-			v.logger.Warn("Removed outlier price",
+		maxAge := v.config.maxPriceAge(p.ExchangeID)
+		if maxAge <= 0 || p.Timestamp.IsZero() {
+			fresh = append(fresh, p)
+			continue
+		}
+
+		age := now.Sub(p.Timestamp)
+		if age >= maxAge {
+			v.logger.Debug("Excluded stale price",
 				zap.String("exchange", p.ExchangeID),
-				zap.String("price", p.Price.String()),
-				zap.String("median", median.String()),
-				zap.String("deviation", deviation.String()))
+				zap.String("symbol", p.Symbol),
+				zap.Duration("age", age),
+				zap.Duration("max_age", maxAge))
+			continue
+		}
+		if grace := maxAge / 2; age > grace {
+			factor := float64(maxAge-age) / float64(maxAge-grace)
+			p.Weight = p.Weight.Mul(decimal.NewFromFloat(factor))
 		}
+		fresh = append(fresh, p)
 	}
-	
-	// If we removed too many prices, return original
-	if len(cleaned) < len(prices)/2 {
-		v.logger.Warn("Too many outliers detected, using all prices")
+	return fresh
+}
+
+// removeOutliers removes prices the pair's outlier thresholds flag, so the
+// prices dropped here match those the outlier detector reports
+func (v *VWAPCalculator) removeOutliers(prices []PriceData) []PriceData {
+	values := make([]decimal.Decimal, len(prices))
+	for i, p := range prices {
+		values[i] = p.Price
+	}
+	analysis := v.config.outlierThresholds(prices[0].BaseTokenID, prices[0].QuoteTokenID).Analyze(values)
+	if analysis.Inconclusive {
+		v.logger.Warn("Too many outliers detected, using all prices",
+			zap.Int("base_token_id", prices[0].BaseTokenID),
+			zap.Int("quote_token_id", prices[0].QuoteTokenID),
+			zap.Int("prices", len(prices)))
 		return prices
 	}
-	
-	return cleaned
-}
 
-// calculateMedianPrice finds the median price
-func (v *VWAPCalculator) calculateMedianPrice(prices []PriceData) decimal.Decimal {
-	// Simple median calculation
-	sum := decimal.Zero
-	for _, p := range prices {
-		sum = sum.Add(p.Price)
+	cleaned := make([]PriceData, 0, len(prices))
+	for i, p := range prices {
+		if analysis.Points[i].Outlier {
+			outlier.LogOutlier(v.logger, "Removed outlier price", p.ExchangeID,
+				p.BaseTokenID, p.QuoteTokenID, analysis.Points[i], analysis.Median)
+			continue
+		}
+		cleaned = append(cleaned, p)
 	}
-	return sum.Div(decimal.NewFromInt(int64(len(prices))))
+	return cleaned
 }
 
 // calculateVWAP performs the actual VWAP calculation
@@ -217,6 +315,12 @@ func (v *VWAPCalculator) calculateVWAP(prices []PriceData) *VWAPResult {
 	// Round to 8 decimal places
 	vwapPrice = vwapPrice.Round(8)
 
+	sourcePrices := make([]decimal.Decimal, len(priceSources))
+	for i, src := range priceSources {
+		sourcePrices[i] = src.Price
+	}
+	spreadBps := liquidity.SpreadBps(sourcePrices, vwapPrice)
+
 	return &VWAPResult{
 		BaseTokenID:           prices[0].BaseTokenID,
 		QuoteTokenID:          prices[0].QuoteTokenID,
@@ -225,25 +329,27 @@ func (v *VWAPCalculator) calculateVWAP(prices []PriceData) *VWAPResult {
 		ExchangeCount:         len(exchangeMap),
 		ContributingExchanges: exchanges,
 		PriceSources:          priceSources,
+		SpreadBps:             spreadBps,
+		LiquidityScore:        liquidity.Score(totalVolume.Mul(vwapPrice), len(exchangeMap), spreadBps),
 		Timestamp:             time.Now(),
 	}
 }
 
 // CalculateBatch processes multiple token pairs in parallel
-func (v *VWAPCalculator) CalculateBatch(pricesByPair map[string][]PriceData) map[string]*VWAPResult {
-	results := make(map[string]*VWAPResult)
+func (v *VWAPCalculator) CalculateBatch(pricesByPair map[PairKey][]PriceData) map[PairKey]*VWAPResult {
+	results := make(map[PairKey]*VWAPResult)
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
 	for pair, prices := range pricesByPair {
 		wg.Add(1)
-		go func(p string, priceData []PriceData) {
+		go func(p PairKey, priceData []PriceData) {
 			defer wg.Done()
 			
 			result, err := v.Calculate(priceData)
 			if err != nil {
 				v.logger.Error("Failed to calculate VWAP",
-					zap.String("pair", p),
+					zap.Stringer("pair", p),
 					zap.Error(err))
 				return
 			}
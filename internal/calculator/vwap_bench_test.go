@@ -0,0 +1,82 @@
+package calculator
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Production-sized cycle: every pair quoted on every exchange
+const (
+	benchExchanges = 30
+	benchPairs     = 2000
+)
+
+// benchPrices builds one polling cycle of prices. Each pair trades within
+// 0.5% of its reference price, with the occasional stale or outlying venue
+// so the staleness and outlier paths are exercised too.
+func benchPrices(exchanges, pairs int) map[PairKey][]PriceData {
+	rng := rand.New(rand.NewSource(1))
+	now := time.Now()
+
+	var prices []PriceData
+	for pair := 1; pair <= pairs; pair++ {
+		reference := 0.001 + rng.Float64()*1000
+		for ex := 0; ex < exchanges; ex++ {
+			price := reference * (1 + (rng.Float64()-0.5)*0.01)
+			age := time.Duration(rng.Intn(30)) * time.Second
+			switch rng.Intn(50) {
+			case 0:
+				price *= 1.2
+			case 1:
+				age = 10 * time.Minute
+			}
+			prices = append(prices, PriceData{
+				ExchangeID:   fmt.Sprintf("exchange%02d", ex),
+				Symbol:       fmt.Sprintf("TOKEN%dUSDT", pair),
+				BaseTokenID:  pair,
+				QuoteTokenID: benchPairs + 1,
+				Price:        decimal.NewFromFloat(price),
+				Volume:       decimal.NewFromFloat(rng.Float64() * 1e6),
+				Weight:       decimal.NewFromFloat(0.01 + rng.Float64()*0.1),
+				Timestamp:    now.Add(-age),
+			})
+		}
+	}
+	return GroupByPair(prices)
+}
+
+func BenchmarkCalculateBatch(b *testing.B) {
+	calc := NewVWAPCalculator(DefaultConfig(), zap.NewNop())
+	pricesByPair := benchPrices(benchExchanges, benchPairs)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		results := calc.CalculateBatch(pricesByPair)
+		if len(results) != benchPairs {
+			b.Fatalf("got %d results, want %d", len(results), benchPairs)
+		}
+	}
+	b.ReportMetric(float64(benchExchanges*benchPairs*b.N)/b.Elapsed().Seconds(), "prices/s")
+}
+
+func BenchmarkCalculate(b *testing.B) {
+	calc := NewVWAPCalculator(DefaultConfig(), zap.NewNop())
+	var prices []PriceData
+	for _, p := range benchPrices(benchExchanges, 1) {
+		prices = p
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := calc.Calculate(prices); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package confidence
+
+import (
+	"math"
+
+	"github.com/ashmitsharp/trading/internal/outlier"
+	"github.com/shopspring/decimal"
+)
+
+// Listing is an exchange's latest ticker for a pair
+type Listing struct {
+	ExchangeID   string
+	BaseTokenID  int
+	QuoteTokenID int
+	Price        decimal.Decimal
+	Volume       decimal.Decimal // 24h volume in the base token
+}
+
+// MappingKey identifies a token's mapping on an exchange
+type MappingKey struct {
+	ExchangeID string
+	TokenID    int
+}
+
+type pairKey struct {
+	base, quote int
+}
+
+// Markets compares each listing with the same pair on other exchanges and
+// returns, per exchange and base token, the comparison of its highest-volume
+// pair that another exchange also lists. Exchanges whose pairs no other
+// exchange lists are left out.
+func Markets(listings []Listing) map[MappingKey]Market {
+	byPair := make(map[pairKey][]Listing)
+	for _, l := range listings {
+		if !l.Price.IsPositive() {
+			continue
+		}
+		k := pairKey{l.BaseTokenID, l.QuoteTokenID}
+		byPair[k] = append(byPair[k], l)
+	}
+
+	markets := make(map[MappingKey]Market)
+	bestVolume := make(map[MappingKey]float64)
+	for _, pair := range byPair {
+		if len(pair) < 2 {
+			continue
+		}
+		var total float64
+		for _, l := range pair {
+			total += l.Volume.InexactFloat64()
+		}
+		average := total / float64(len(pair))
+
+		for i, l := range pair {
+			others := make([]decimal.Decimal, 0, len(pair)-1)
+			for j, o := range pair {
+				if j != i {
+					others = append(others, o.Price)
+				}
+			}
+			median := outlier.Median(others).InexactFloat64()
+			if median <= 0 {
+				continue
+			}
+
+			key := MappingKey{ExchangeID: l.ExchangeID, TokenID: l.BaseTokenID}
+			volume := l.Volume.InexactFloat64()
+			if best, ok := bestVolume[key]; ok && best >= volume {
+				continue
+			}
+			bestVolume[key] = volume
+
+			relative := 1.0
+			if average > 0 {
+				relative = volume / average
+			}
+			markets[key] = Market{
+				Deviation:      math.Abs(l.Price.InexactFloat64()-median) / median,
+				RelativeVolume: relative,
+			}
+		}
+	}
+	return markets
+}
@@ -0,0 +1,66 @@
+// Package confidence scores how likely each exchange symbol mapping is to
+// point at the right token, from how it was matched and how the exchange's
+// market for it compares with other venues. Scores are stored on
+// token_exchange_symbols.confidence_score, where VWAP leaves out mappings
+// below VWAP_MIN_MAPPING_CONFIDENCE and reviewers see the lowest first.
+package confidence
+
+import "math"
+
+// Weights of the score's components. They sum to 1.
+const (
+	methodWeight = 0.5
+	priceWeight  = 0.35
+	volumeWeight = 0.15
+)
+
+// priceTolerance is the deviation from other venues' median price, as a
+// fraction, at which the price component reaches zero
+const priceTolerance = 0.1
+
+// neutral is used for the market components of a mapping no other venue
+// lists, so it is neither rewarded nor penalised for the lack of evidence
+const neutral = 0.5
+
+// methodScores rates each mapping method by how rarely it maps a symbol to
+// the wrong token. Unlisted methods score defaultMethodScore.
+var methodScores = map[string]float64{
+	"manual":   1.0,
+	"contract": 1.0,
+	"slug":     0.9,
+	"symbol":   0.6,
+	"auto":     0.6, // symbol matches the poller wrote back
+	"name":     0.4,
+}
+
+const defaultMethodScore = 0.5
+
+// Market is how an exchange's market for a mapped token compares with the
+// same pair on other exchanges
+type Market struct {
+	// Deviation is the distance of the exchange's price from the median of
+	// other venues, as a fraction of that median
+	Deviation float64
+	// RelativeVolume is the exchange's base volume divided by the average
+	// across venues, including itself
+	RelativeVolume float64
+}
+
+// Score combines a mapping's method with its market into a confidence
+// between 0 and 1, rounded to the two decimals confidence_score stores.
+// market is nil when no other exchange lists the pair.
+func Score(method string, market *Market) float64 {
+	m, ok := methodScores[method]
+	if !ok {
+		m = defaultMethodScore
+	}
+
+	price, volume := neutral, neutral
+	if market != nil {
+		price = 1 - math.Min(market.Deviation/priceTolerance, 1)
+		volume = math.Min(math.Max(market.RelativeVolume, 0), 1)
+	}
+
+	score := methodWeight*m + priceWeight*price + volumeWeight*volume
+	return math.Round(math.Min(math.Max(score, 0), 1)*100) / 100
+}
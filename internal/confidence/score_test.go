@@ -0,0 +1,55 @@
+package confidence
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		market *Market
+		want   float64
+	}{
+		{"slug, agreeing and liquid", "slug", &Market{Deviation: 0, RelativeVolume: 1.5}, 0.95},
+		{"symbol, no other venue", "symbol", nil, 0.55},
+		{"symbol, agreeing and liquid", "symbol", &Market{Deviation: 0.01, RelativeVolume: 1}, 0.77},
+		{"symbol, far off and thin", "symbol", &Market{Deviation: 0.5, RelativeVolume: 0.01}, 0.3},
+		{"unknown method", "guess", nil, 0.5},
+	}
+	for _, tt := range tests {
+		if got := Score(tt.method, tt.market); got != tt.want {
+			t.Errorf("%s: Score = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMarkets(t *testing.T) {
+	d := decimal.NewFromInt
+	listings := []Listing{
+		{ExchangeID: "binance", BaseTokenID: 1, QuoteTokenID: 2, Price: d(100), Volume: d(300)},
+		{ExchangeID: "kraken", BaseTokenID: 1, QuoteTokenID: 2, Price: d(102), Volume: d(100)},
+		{ExchangeID: "gate", BaseTokenID: 1, QuoteTokenID: 2, Price: d(150), Volume: d(200)},
+		// Only gate lists 1/3, so it has nothing to compare with
+		{ExchangeID: "gate", BaseTokenID: 1, QuoteTokenID: 3, Price: d(1), Volume: d(5000)},
+		{ExchangeID: "mexc", BaseTokenID: 4, QuoteTokenID: 2, Price: d(1), Volume: d(1)},
+	}
+
+	markets := Markets(listings)
+	if len(markets) != 3 {
+		t.Fatalf("Markets returned %d entries, want 3: %+v", len(markets), markets)
+	}
+	gate := markets[MappingKey{ExchangeID: "gate", TokenID: 1}]
+	if gate.Deviation < 0.48 || gate.Deviation > 0.49 || gate.RelativeVolume != 1 {
+		t.Errorf("gate = %+v, want deviation ~0.485 from median 101 and relative volume 1", gate)
+	}
+	binance := markets[MappingKey{ExchangeID: "binance", TokenID: 1}]
+	if binance.RelativeVolume != 1.5 {
+		t.Errorf("binance relative volume = %v, want 1.5", binance.RelativeVolume)
+	}
+	if _, ok := markets[MappingKey{ExchangeID: "mexc", TokenID: 4}]; ok {
+		t.Error("single-venue pair should be left out")
+	}
+}
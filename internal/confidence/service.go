@@ -0,0 +1,198 @@
+package confidence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// marketWindow is how far back tickers are read to compare venues
+const marketWindow = time.Hour
+
+// Service recomputes the confidence of automatic mappings. Manual and
+// human-verified mappings keep the score they were given.
+type Service struct {
+	postgresDB     *sql.DB
+	clickhouseConn driver.Conn
+	logger         *zap.Logger
+}
+
+// NewService creates a new mapping confidence service
+func NewService(postgresDB *sql.DB, clickhouseConn driver.Conn, logger *zap.Logger) *Service {
+	return &Service{
+		postgresDB:     postgresDB,
+		clickhouseConn: clickhouseConn,
+		logger:         logger,
+	}
+}
+
+// mapping is a token_exchange_symbols row to rescore
+type mapping struct {
+	id         int
+	exchangeID string
+	tokenID    int
+	method     string
+	score      float64
+}
+
+// Rescore scores every active, unverified automatic mapping and stores the
+// scores that changed. It returns the number of mappings scored and changed.
+func (s *Service) Rescore(ctx context.Context) (scored, changed int, err error) {
+	mappings, err := s.mappings(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	listings, err := s.listings(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	markets := Markets(listings)
+
+	var ids []int64
+	var scores []float64
+	for _, m := range mappings {
+		var market *Market
+		if mk, ok := markets[MappingKey{ExchangeID: m.exchangeID, TokenID: m.tokenID}]; ok {
+			market = &mk
+		}
+		score := Score(m.method, market)
+		if score != m.score {
+			ids = append(ids, int64(m.id))
+			scores = append(scores, score)
+		}
+	}
+
+	if err := s.store(ctx, mappings, ids, scores); err != nil {
+		return 0, 0, err
+	}
+	return len(mappings), len(ids), nil
+}
+
+func (s *Service) mappings(ctx context.Context) ([]mapping, error) {
+	rows, err := s.postgresDB.QueryContext(ctx, `
+		SELECT id, exchange_id, token_id, COALESCE(mapping_method, ''), COALESCE(confidence_score, 1)
+		FROM token_exchange_symbols
+		WHERE is_active = true
+		  AND verified_at IS NULL
+		  AND COALESCE(mapping_method, 'manual') <> 'manual'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mappings to score: %w", err)
+	}
+	defer rows.Close()
+
+	var out []mapping
+	for rows.Next() {
+		var m mapping
+		if err := rows.Scan(&m.id, &m.exchangeID, &m.tokenID, &m.method, &m.score); err != nil {
+			return nil, fmt.Errorf("failed to scan mapping: %w", err)
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// listings returns each exchange's latest ticker per pair from the last
+// marketWindow
+func (s *Service) listings(ctx context.Context) ([]Listing, error) {
+	rows, err := s.clickhouseConn.Query(ctx, `
+		SELECT
+			exchange_id,
+			base_token_id,
+			quote_token_id,
+			argMax(price, timestamp) AS latest_price,
+			argMax(volume_24h, timestamp) AS latest_volume
+		FROM price_tickers
+		WHERE timestamp >= now() - INTERVAL ? SECOND
+			AND instrument_type = 'spot'
+			AND base_token_id > 0
+			AND quote_token_id > 0
+			AND price > 0
+		GROUP BY exchange_id, base_token_id, quote_token_id
+	`, int(marketWindow.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("querying tickers: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Listing
+	for rows.Next() {
+		var l Listing
+		var base, quote uint32
+		var price, volume decimal.Decimal
+		if err := rows.Scan(&l.ExchangeID, &base, &quote, &price, &volume); err != nil {
+			return nil, fmt.Errorf("scanning ticker: %w", err)
+		}
+		l.BaseTokenID, l.QuoteTokenID, l.Price, l.Volume = int(base), int(quote), price, volume
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// store writes the changed scores and marks every scored mapping as
+// checked, in one transaction
+func (s *Service) store(ctx context.Context, mappings []mapping, ids []int64, scores []float64) error {
+	checked := make([]int64, len(mappings))
+	for i, m := range mappings {
+		checked[i] = int64(m.id)
+	}
+
+	tx, err := s.postgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE token_exchange_symbols t
+		SET confidence_score = v.score
+		FROM unnest($1::int[], $2::numeric[]) AS v(id, score)
+		WHERE t.id = v.id
+	`, pq.Array(ids), pq.Array(scores)); err != nil {
+		return fmt.Errorf("failed to update confidence scores: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE token_exchange_symbols SET last_price_check = NOW() WHERE id = ANY($1)
+	`, pq.Array(checked)); err != nil {
+		return fmt.Errorf("failed to mark mappings checked: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Run rescores the mappings every day at at past midnight UTC until ctx is
+// done
+func (s *Service) Run(ctx context.Context, at time.Duration) error {
+	for {
+		next := timeutil.NextDaily(time.Now(), at)
+		s.logger.Info("Next mapping confidence run scheduled", zap.Time("at", next))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		scored, changed, err := s.Rescore(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to rescore mappings", zap.Error(err))
+			}
+			continue
+		}
+		s.logger.Info("Mapping confidence rescored",
+			zap.Int("scored", scored),
+			zap.Int("changed", changed),
+			zap.Duration("took", time.Since(start)))
+	}
+}
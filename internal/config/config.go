@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,6 +13,7 @@ type Config struct {
 	ClickHouse ClickhouseConfig
 	Postgres   PostgresConfig
 	Binance    BinanceConfig
+	Log        LogConfig
 }
 
 type ServerConfig struct {
@@ -39,9 +41,21 @@ type ClickhouseConfig struct {
 	Debug    bool
 }
 
+// LogConfig configures the application logger. Modules maps a logger name,
+// as given to zap.Logger.Named, to a level that overrides Level for it and
+// its children.
+type LogConfig struct {
+	Level              string
+	Format             string // json or console
+	SamplingInitial    int    // entries per message and second logged before sampling
+	SamplingThereafter int    // then every Nth; 0 disables sampling
+	Modules            map[string]string
+}
+
 type BinanceConfig struct {
-	WSBaseURL string
-	Symbols   []string
+	WSBaseURL            string
+	Symbols              []string
+	StreamsPerConnection int // symbols sharded onto each WebSocket connection
 }
 
 func Load() (*Config, error) {
@@ -69,14 +83,36 @@ func Load() (*Config, error) {
 			Debug:    getBoolEnv("CLICKHOUSE_DEBUG", true),
 		},
 		Binance: BinanceConfig{
-			WSBaseURL: getEnv("BINANCE_WS_URL", "wss://stream.binance.com:9443"),
-			Symbols:   []string{"btcusdt"},
+			WSBaseURL:            getEnv("BINANCE_WS_URL", "wss://stream.binance.com:9443"),
+			Symbols:              []string{"btcusdt"},
+			StreamsPerConnection: getIntEnv("BINANCE_STREAMS_PER_CONNECTION", 200),
 		},
+		Log: LoadLogConfig(),
 	}
 
 	return cfg, nil
 }
 
+// LoadLogConfig reads the logger configuration from LOG_LEVEL, LOG_FORMAT,
+// LOG_SAMPLING_INITIAL, LOG_SAMPLING_THEREAFTER and LOG_MODULE_LEVELS, the
+// last a comma-separated list of module=level
+func LoadLogConfig() LogConfig {
+	cfg := LogConfig{
+		Level:              getEnv("LOG_LEVEL", "info"),
+		Format:             getEnv("LOG_FORMAT", "json"),
+		SamplingInitial:    getIntEnv("LOG_SAMPLING_INITIAL", 100),
+		SamplingThereafter: getIntEnv("LOG_SAMPLING_THEREAFTER", 100),
+		Modules:            make(map[string]string),
+	}
+	for _, pair := range strings.Split(os.Getenv("LOG_MODULE_LEVELS"), ",") {
+		module, level, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && strings.TrimSpace(module) != "" {
+			cfg.Modules[strings.TrimSpace(module)] = strings.TrimSpace(level)
+		}
+	}
+	return cfg
+}
+
 func (c *ClickhouseConfig) ConnectionString() string {
 	return fmt.Sprintf("tcp://%s:%d?database=%s&username=%s&password=%s&debug=%t",
 		c.Host, c.Port, c.Database, c.Username, c.Password, c.Debug)
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Category kinds: curated categories such as defi or layer-1, and looser
+// tags
+const (
+	CategoryKindCategory = "category"
+	CategoryKindTag      = "tag"
+)
+
+// Category is a category or tag of the token taxonomy
+type Category struct {
+	ID     int
+	Slug   string
+	Name   string
+	Kind   string
+	Source string // manual, seed or coingecko
+	Tokens int    // active tokens linked to it
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CategorySlug turns a category name into its slug, "Layer 1" into
+// "layer-1", as the taxonomy migration does
+func CategorySlug(name string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// ListCategories returns the categories of a kind, or of every kind when
+// kind is empty, ordered by slug
+func ListCategories(ctx context.Context, db *sql.DB, kind string) ([]Category, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT c.id, c.slug, c.name, c.kind, c.source, COUNT(t.id)
+		FROM categories c
+		LEFT JOIN token_categories tc ON tc.category_id = c.id
+		LEFT JOIN tokens t ON t.id = tc.token_id AND t.is_active = true
+		WHERE $1 = '' OR c.kind = $1
+		GROUP BY c.id
+		ORDER BY c.slug
+	`, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Category
+	for rows.Next() {
+		var c Category
+		if err := rows.Scan(&c.ID, &c.Slug, &c.Name, &c.Kind, &c.Source, &c.Tokens); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// GetTokenCategories returns the category and tag slugs of each of the
+// tokens, ordered by slug. Tokens without any are left out.
+func GetTokenCategories(ctx context.Context, db *sql.DB, tokenIDs []int) (map[int][]string, error) {
+	out := make(map[int][]string)
+	if len(tokenIDs) == 0 {
+		return out, nil
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT tc.token_id, c.slug
+		FROM token_categories tc
+		JOIN categories c ON c.id = tc.category_id
+		WHERE tc.token_id = ANY($1)
+		ORDER BY tc.token_id, c.slug
+	`, pq.Array(tokenIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query token categories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			return nil, fmt.Errorf("failed to scan token category: %w", err)
+		}
+		out[id] = append(out[id], slug)
+	}
+	return out, rows.Err()
+}
@@ -3,11 +3,13 @@ package db
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/ashmitsharp/trading/internal/config"
+	"github.com/ashmitsharp/trading/internal/timeutil"
 	"github.com/shopspring/decimal"
 )
 
@@ -61,7 +63,8 @@ func CreateClickHouseTables(conn driver.Conn) error {
 			quantity     Decimal(20, 8),
 			trade_id     UInt64,
 			timestamp    DateTime64(3, 'UTC'),
-			is_buyer_maker UInt8
+			is_buyer_maker UInt8,
+			exchange_id  LowCardinality(String) DEFAULT ''
 		) ENGINE = MergeTree()
 		PARTITION BY symbol
 		ORDER BY (symbol, timestamp)
@@ -77,9 +80,10 @@ func CreateClickHouseTables(conn driver.Conn) error {
 		CREATE MATERIALIZED VIEW IF NOT EXISTS trades_ohlcv_1m
 		ENGINE = AggregatingMergeTree()
 		PARTITION BY symbol
-		ORDER BY (symbol, minute)
+		ORDER BY (symbol, exchange_id, minute)
 		AS SELECT
 			symbol,
+			exchange_id,
 			toStartOfMinute(timestamp) as minute,
 			argMinState(price, timestamp) as open,
 			maxState(price) as high,
@@ -88,7 +92,7 @@ func CreateClickHouseTables(conn driver.Conn) error {
 			sumState(quantity) as volume,
 			countState() as trades_count
 		FROM trades
-		GROUP BY symbol, minute
+		GROUP BY symbol, exchange_id, minute
 	`
 
 	if err := conn.Exec(ctx, ohlcvViewSQL); err != nil {
@@ -106,7 +110,11 @@ func InsertTrades(conn driver.Conn, trades []TradeData) error {
 
 	ctx := context.Background()
 
-	batch, err := conn.PrepareBatch(ctx, "INSERT INTO trades")
+	// Name the columns so the batch also fits the migrated table, which adds
+	// exchange and token ID columns
+	batch, err := conn.PrepareBatch(ctx, `
+		INSERT INTO trades (symbol, price, quantity, trade_id, timestamp, is_buyer_maker)
+	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare batch: %w", err)
 	}
@@ -117,7 +125,7 @@ func InsertTrades(conn driver.Conn, trades []TradeData) error {
 			trade.Price,
 			trade.Quantity,
 			trade.TradeID,
-			trade.Timestamp,
+			trade.Timestamp.Time(),
 			trade.IsBuyerMaker,
 		); err != nil {
 			return fmt.Errorf("failed to append trade to batch: %w", err)
@@ -131,14 +139,80 @@ func InsertTrades(conn driver.Conn, trades []TradeData) error {
 	return nil
 }
 
-// TradeData represents a single trade record
+// TradeData represents a single trade record. The exchange and token IDs
+// are only written by InsertExchangeTrades.
 type TradeData struct {
 	Symbol       string
 	Price        decimal.Decimal
 	Quantity     decimal.Decimal
 	TradeID      uint64
-	Timestamp    int64
+	Timestamp    timeutil.Millis
 	IsBuyerMaker uint8
+	ExchangeID   string
+	BaseTokenID  int
+	QuoteTokenID int
+}
+
+// InsertExchangeTrades inserts trades polled from an exchange's REST API,
+// with the exchange and the pair's token IDs
+func InsertExchangeTrades(ctx context.Context, conn driver.Conn, trades []TradeData) error {
+	if len(trades) == 0 {
+		return nil
+	}
+
+	batch, err := conn.PrepareBatch(ctx, `
+		INSERT INTO trades (timestamp, exchange_id, base_token_id, quote_token_id, symbol, price, quantity, trade_id, is_buyer_maker)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	for _, trade := range trades {
+		if err := batch.Append(
+			trade.Timestamp.Time(),
+			trade.ExchangeID,
+			uint32(trade.BaseTokenID),
+			uint32(trade.QuoteTokenID),
+			trade.Symbol,
+			trade.Price,
+			trade.Quantity,
+			trade.TradeID,
+			trade.IsBuyerMaker,
+		); err != nil {
+			return fmt.Errorf("failed to append trade to batch: %w", err)
+		}
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+	return nil
+}
+
+// GetTradeCursors returns the highest stored trade ID of each of an
+// exchange's pairs, so a restarted poller skips trades it already stored
+func GetTradeCursors(ctx context.Context, conn driver.Conn, exchangeID string) (map[string]uint64, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT symbol, max(trade_id)
+		FROM trades
+		WHERE exchange_id = ?
+		GROUP BY symbol
+	`, exchangeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trade cursors: %w", err)
+	}
+	defer rows.Close()
+
+	cursors := make(map[string]uint64)
+	for rows.Next() {
+		var symbol string
+		var id uint64
+		if err := rows.Scan(&symbol, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan trade cursor: %w", err)
+		}
+		cursors[symbol] = id
+	}
+	return cursors, rows.Err()
 }
 
 // GetLatestPrices gets the latest price for each symbol
@@ -150,7 +224,8 @@ func GetLatestPrices(conn driver.Conn) (map[string]LatestPrice, error) {
 			symbol,
 			anyLast(price) as price,
 			anyLast(timestamp) as timestamp,
-			anyLast(quantity) as volume
+			anyLast(quantity) as volume,
+			groupUniqArray(if(exchange_id = '', 'binance', exchange_id)) as venues
 		FROM trades
 		GROUP BY symbol
 	`
@@ -168,16 +243,19 @@ func GetLatestPrices(conn driver.Conn) (map[string]LatestPrice, error) {
 		var price decimal.Decimal
 		var timestamp int64
 		var volume decimal.Decimal
+		var venues []string
 
-		if err := rows.Scan(&symbol, &price, &timestamp, &volume); err != nil {
+		if err := rows.Scan(&symbol, &price, &timestamp, &volume, &venues); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
+		sort.Strings(venues)
 
 		prices[symbol] = LatestPrice{
 			Symbol:    symbol,
 			Price:     price,
-			Timestamp: timestamp,
+			Timestamp: timeutil.Millis(timestamp),
 			Volume:    volume,
+			Venues:    venues,
 		}
 	}
 
@@ -188,14 +266,34 @@ func GetLatestPrices(conn driver.Conn) (map[string]LatestPrice, error) {
 type LatestPrice struct {
 	Symbol    string          `json:"symbol"`
 	Price     decimal.Decimal `json:"price"`
-	Timestamp int64           `json:"timestamp"`
+	Timestamp timeutil.Millis `json:"timestamp"`
 	Volume    decimal.Decimal `json:"volume"`
+	Venues    []string        `json:"venues"` // exchanges with trades of the symbol
+}
+
+// GetOHLCVData gets OHLCV data for a Binance symbol between two Unix times
+// in seconds. It returns ErrNoData when no candles fall in the range.
+func GetOHLCVData(conn driver.Conn, symbol string, fromTime, toTime timeutil.Seconds, interval string) ([]OHLCVData, error) {
+	return GetExchangeOHLCVData(conn, "binance", symbol, fromTime, toTime, interval)
 }
 
-// GetOHLCVData gets OHLCV data for a symbol within a time range
-func GetOHLCVData(conn driver.Conn, symbol string, fromTime, toTime int64, interval string) ([]OHLCVData, error) {
+// tradeExchangeIDs returns the exchange_id values an exchange's trades are
+// stored under. The Binance WebSocket ingester leaves exchange_id empty.
+func tradeExchangeIDs(exchangeID string) []string {
+	if exchangeID == "binance" {
+		return []string{exchangeID, ""}
+	}
+	return []string{exchangeID}
+}
+
+// GetExchangeOHLCVData gets OHLCV data built from one exchange's trades for
+// a symbol as the exchange writes it. It returns ErrNoData when no candles
+// fall in the range.
+func GetExchangeOHLCVData(conn driver.Conn, exchangeID, symbol string, fromTime, toTime timeutil.Seconds, interval string) ([]OHLCVData, error) {
 	ctx := context.Background()
 
+	exchangeIDs := tradeExchangeIDs(exchangeID)
+
 	var query string
 	switch interval {
 	case "1m":
@@ -210,7 +308,8 @@ func GetOHLCVData(conn driver.Conn, symbol string, fromTime, toTime int64, inter
 				sumMerge(volume) as volume,
 				countMerge(trades_count) as trades_count
 			FROM trades_ohlcv_1m
-			WHERE symbol = ? AND minute >= toDateTime64(?, 3) AND minute <= toDateTime64(?, 3)
+			WHERE symbol = ? AND exchange_id IN (?)
+			  AND minute >= toDateTime64(?, 3) AND minute <= toDateTime64(?, 3)
 			GROUP BY symbol, minute
 			ORDER BY minute
 		`
@@ -227,7 +326,8 @@ func GetOHLCVData(conn driver.Conn, symbol string, fromTime, toTime int64, inter
 				sum(quantity) as volume,
 				count() as trades_count
 			FROM trades
-			WHERE symbol = ? AND timestamp >= toDateTime64(?, 3) AND timestamp <= toDateTime64(?, 3)
+			WHERE symbol = ? AND exchange_id IN (?)
+			  AND timestamp >= toDateTime64(?, 3) AND timestamp <= toDateTime64(?, 3)
 			GROUP BY symbol, interval_start
 			ORDER BY interval_start
 		`
@@ -237,10 +337,10 @@ func GetOHLCVData(conn driver.Conn, symbol string, fromTime, toTime int64, inter
 	var err error
 
 	if interval == "1m" {
-		rows, err = conn.Query(ctx, query, symbol, fromTime, toTime)
+		rows, err = conn.Query(ctx, query, symbol, exchangeIDs, int64(fromTime), int64(toTime))
 	} else {
 		intervalMinutes := parseInterval(interval)
-		rows, err = conn.Query(ctx, query, intervalMinutes, symbol, fromTime, toTime)
+		rows, err = conn.Query(ctx, query, intervalMinutes, symbol, exchangeIDs, int64(fromTime), int64(toTime))
 	}
 
 	if err != nil {
@@ -259,23 +359,81 @@ func GetOHLCVData(conn driver.Conn, symbol string, fromTime, toTime int64, inter
 			return nil, fmt.Errorf("failed to scan OHLCV row: %w", err)
 		}
 
-		ohlcv.Timestamp = minute.Unix()
+		ohlcv.Timestamp = timeutil.SecondsOf(minute)
+		data = append(data, ohlcv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read OHLCV rows: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, ErrNoData
+	}
+
+	return data, nil
+}
+
+// GetCompositeOHLCVData gets candles of the multi-exchange VWAP for a token
+// pair from vwap_ohlcv_1m. Volume is the rolling 24h volume at each candle
+// close and TradesCount the number of VWAP samples. It returns ErrNoData when
+// no candles fall in the range.
+func GetCompositeOHLCVData(conn driver.Conn, baseTokenID, quoteTokenID int, fromTime, toTime timeutil.Seconds, interval string) ([]OHLCVData, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT
+			toStartOfInterval(minute, INTERVAL ? MINUTE) as bucket,
+			argMinMerge(open) as open,
+			maxMerge(high) as high,
+			minMerge(low) as low,
+			argMaxMerge(close) as close,
+			argMaxMerge(volume) as volume,
+			countMerge(samples) as samples
+		FROM vwap_ohlcv_1m
+		WHERE base_token_id = ? AND quote_token_id = ?
+		  AND minute >= toDateTime64(?, 3) AND minute <= toDateTime64(?, 3)
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+
+	rows, err := conn.Query(ctx, query, parseInterval(interval), uint32(baseTokenID), uint32(quoteTokenID), int64(fromTime), int64(toTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query composite OHLCV data: %w", err)
+	}
+	defer rows.Close()
+
+	var data []OHLCVData
+	for rows.Next() {
+		var ohlcv OHLCVData
+		var bucket time.Time
+
+		if err := rows.Scan(&bucket, &ohlcv.Open, &ohlcv.High, &ohlcv.Low,
+			&ohlcv.Close, &ohlcv.Volume, &ohlcv.TradesCount); err != nil {
+			return nil, fmt.Errorf("failed to scan composite OHLCV row: %w", err)
+		}
+
+		ohlcv.Timestamp = timeutil.SecondsOf(bucket)
 		data = append(data, ohlcv)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read composite OHLCV rows: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, ErrNoData
+	}
 
 	return data, nil
 }
 
 // OHLCVData represents OHLCV candlestick data
 type OHLCVData struct {
-	Symbol      string          `json:"symbol"`
-	Timestamp   int64           `json:"timestamp"`
-	Open        decimal.Decimal `json:"open"`
-	High        decimal.Decimal `json:"high"`
-	Low         decimal.Decimal `json:"low"`
-	Close       decimal.Decimal `json:"close"`
-	Volume      decimal.Decimal `json:"volume"`
-	TradesCount uint64          `json:"trades_count"`
+	Symbol      string           `json:"symbol"`
+	Timestamp   timeutil.Seconds `json:"timestamp"`
+	Open        decimal.Decimal  `json:"open"`
+	High        decimal.Decimal  `json:"high"`
+	Low         decimal.Decimal  `json:"low"`
+	Close       decimal.Decimal  `json:"close"`
+	Volume      decimal.Decimal  `json:"volume"`
+	TradesCount uint64           `json:"trades_count"`
 }
 
 // parseInterval converts interval string to minutes
@@ -0,0 +1,42 @@
+//go:build integration
+
+package db
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/shopspring/decimal"
+)
+
+// benchTradeBatch matches the ingester's flush size
+const benchTradeBatch = 1000
+
+func BenchmarkInsertTrades(b *testing.B) {
+	conn := testutil.ClickHouse(b)
+	start := timeutil.MillisOf(time.Now())
+
+	trades := make([]TradeData, benchTradeBatch)
+	for i := range trades {
+		trades[i] = TradeData{
+			Symbol:       fmt.Sprintf("TOKEN%dUSDT", i%2000),
+			Price:        decimal.NewFromFloat(100 + float64(i%500)*0.01),
+			Quantity:     decimal.RequireFromString("0.0125"),
+			TradeID:      uint64(i),
+			Timestamp:    start + timeutil.Millis(i),
+			IsBuyerMaker: uint8(i % 2),
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := InsertTrades(conn, trades); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(trades)*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
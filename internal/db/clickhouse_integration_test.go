@@ -0,0 +1,183 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/shopspring/decimal"
+)
+
+func TestGetOHLCVData(t *testing.T) {
+	conn := testutil.ClickHouse(t)
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+
+	testutil.SeedTrades(t, conn,
+		testutil.Trade{Timestamp: hour.Add(time.Minute), Symbol: "BTCUSDT", Price: decimal.NewFromInt(100), Quantity: decimal.NewFromInt(1)},
+		testutil.Trade{Timestamp: hour.Add(10 * time.Minute), Symbol: "BTCUSDT", Price: decimal.NewFromInt(110), Quantity: decimal.NewFromInt(2)},
+		testutil.Trade{Timestamp: hour.Add(20 * time.Minute), Symbol: "BTCUSDT", Price: decimal.NewFromInt(95), Quantity: decimal.NewFromInt(1)},
+		testutil.Trade{Timestamp: hour.Add(50 * time.Minute), Symbol: "BTCUSDT", Price: decimal.NewFromInt(105), Quantity: decimal.NewFromInt(3)},
+		testutil.Trade{Timestamp: hour.Add(70 * time.Minute), Symbol: "BTCUSDT", Price: decimal.NewFromInt(107), Quantity: decimal.NewFromInt(1)},
+		testutil.Trade{Timestamp: hour.Add(5 * time.Minute), Symbol: "ETHUSDT", Price: decimal.NewFromInt(3000), Quantity: decimal.NewFromInt(1)},
+	)
+
+	from, to := timeutil.SecondsOf(hour), timeutil.SecondsOf(hour.Add(2*time.Hour))
+	candles, err := GetOHLCVData(conn, "BTCUSDT", from, to, "1h")
+	if err != nil {
+		t.Fatalf("GetOHLCVData: %v", err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("got %d candles, want 2: %+v", len(candles), candles)
+	}
+
+	first := candles[0]
+	if first.Timestamp != timeutil.SecondsOf(hour) {
+		t.Errorf("first candle at %d, want %d", first.Timestamp, hour.Unix())
+	}
+	want := map[string]decimal.Decimal{
+		"open": decimal.NewFromInt(100), "high": decimal.NewFromInt(110),
+		"low": decimal.NewFromInt(95), "close": decimal.NewFromInt(105),
+		"volume": decimal.NewFromInt(7),
+	}
+	got := map[string]decimal.Decimal{
+		"open": first.Open, "high": first.High, "low": first.Low,
+		"close": first.Close, "volume": first.Volume,
+	}
+	for field, w := range want {
+		if !got[field].Equal(w) {
+			t.Errorf("%s = %s, want %s", field, got[field], w)
+		}
+	}
+	if first.TradesCount != 4 {
+		t.Errorf("trades_count = %d, want 4", first.TradesCount)
+	}
+
+	if _, err := GetOHLCVData(conn, "BTCUSDT", from-7200, from-3600, "1h"); !errors.Is(err, ErrNoData) {
+		t.Errorf("empty range returned %v, want ErrNoData", err)
+	}
+}
+
+func TestGetLatestPrices(t *testing.T) {
+	conn := testutil.ClickHouse(t)
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	testutil.SeedTrades(t, conn,
+		testutil.Trade{Timestamp: now, Symbol: "BTCUSDT", Price: decimal.NewFromInt(64000), Quantity: decimal.NewFromInt(1)},
+		testutil.Trade{Timestamp: now, Symbol: "ETHUSDT", Price: decimal.NewFromInt(3100), Quantity: decimal.NewFromInt(2)},
+	)
+
+	prices, err := GetLatestPrices(conn)
+	if err != nil {
+		t.Fatalf("GetLatestPrices: %v", err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("got %d symbols, want 2", len(prices))
+	}
+	if !prices["ETHUSDT"].Price.Equal(decimal.NewFromInt(3100)) {
+		t.Errorf("ETHUSDT price = %s, want 3100", prices["ETHUSDT"].Price)
+	}
+	if got := prices["ETHUSDT"].Timestamp; got != timeutil.MillisOf(now) {
+		t.Errorf("ETHUSDT timestamp = %d, want %d", got, timeutil.MillisOf(now))
+	}
+}
+
+// Trades go in as milliseconds and must come back as the same instant
+func TestInsertTradesTimestamps(t *testing.T) {
+	conn := testutil.ClickHouse(t)
+	at := timeutil.MillisOf(time.Now().Add(-time.Minute))
+
+	err := InsertTrades(conn, []TradeData{{
+		Symbol: "BTCUSDT", Price: decimal.NewFromInt(64000), Quantity: decimal.NewFromInt(1),
+		TradeID: 1, Timestamp: at,
+	}})
+	if err != nil {
+		t.Fatalf("InsertTrades: %v", err)
+	}
+
+	prices, err := GetLatestPrices(conn)
+	if err != nil {
+		t.Fatalf("GetLatestPrices: %v", err)
+	}
+	if got := prices["BTCUSDT"].Timestamp; got != at {
+		t.Errorf("stored timestamp = %d, want %d", got, at)
+	}
+
+	candles, err := GetOHLCVData(conn, "BTCUSDT", at.Seconds()-3600, at.Seconds()+3600, "1h")
+	if err != nil || len(candles) != 1 {
+		t.Fatalf("GetOHLCVData around the trade = %+v, %v", candles, err)
+	}
+}
+
+func TestExchangeTrades(t *testing.T) {
+	conn := testutil.ClickHouse(t)
+	ctx := context.Background()
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-time.Hour)
+
+	// bybit writes the same symbol as Binance; its trades must stay separate
+	testutil.SeedTrades(t, conn,
+		testutil.Trade{Timestamp: hour.Add(time.Minute), Symbol: "BTCUSDT", Price: decimal.NewFromInt(100), Quantity: decimal.NewFromInt(1)},
+	)
+	err := InsertExchangeTrades(ctx, conn, []TradeData{
+		{ExchangeID: "bybit", Symbol: "BTCUSDT", BaseTokenID: 1, QuoteTokenID: 2, TradeID: 41,
+			Price: decimal.NewFromInt(101), Quantity: decimal.NewFromInt(2), Timestamp: timeutil.MillisOf(hour.Add(2 * time.Minute))},
+		{ExchangeID: "bybit", Symbol: "BTCUSDT", BaseTokenID: 1, QuoteTokenID: 2, TradeID: 42,
+			Price: decimal.NewFromInt(103), Quantity: decimal.NewFromInt(1), Timestamp: timeutil.MillisOf(hour.Add(3 * time.Minute))},
+	})
+	if err != nil {
+		t.Fatalf("InsertExchangeTrades: %v", err)
+	}
+
+	cursors, err := GetTradeCursors(ctx, conn, "bybit")
+	if err != nil || cursors["BTCUSDT"] != 42 {
+		t.Fatalf("GetTradeCursors = %v, %v; want BTCUSDT at 42", cursors, err)
+	}
+
+	from, to := timeutil.SecondsOf(hour), timeutil.SecondsOf(hour.Add(time.Hour))
+	candles, err := GetExchangeOHLCVData(conn, "bybit", "BTCUSDT", from, to, "1h")
+	if err != nil || len(candles) != 1 {
+		t.Fatalf("GetExchangeOHLCVData = %+v, %v", candles, err)
+	}
+	if candles[0].TradesCount != 2 || !candles[0].Close.Equal(decimal.NewFromInt(103)) {
+		t.Errorf("bybit candle = %+v, want its 2 trades closing at 103", candles[0])
+	}
+
+	binance, err := GetOHLCVData(conn, "BTCUSDT", from, to, "1h")
+	if err != nil || len(binance) != 1 || binance[0].TradesCount != 1 {
+		t.Errorf("binance candles = %+v, %v; want only the Binance trade", binance, err)
+	}
+}
+
+func TestExchangeCandles(t *testing.T) {
+	conn := testutil.ClickHouse(t)
+	ctx := context.Background()
+	minute := time.Now().UTC().Truncate(time.Minute).Add(-10 * time.Minute)
+
+	candle := ExchangeCandle{OpenTime: minute, Open: decimal.NewFromInt(100), High: decimal.NewFromInt(101),
+		Low: decimal.NewFromInt(99), Close: decimal.NewFromInt(100), Volume: decimal.NewFromInt(5), TradesCount: 7}
+	if err := InsertExchangeCandles(ctx, conn, "okx", "BTC-USDT", "1m", []ExchangeCandle{candle}); err != nil {
+		t.Fatalf("InsertExchangeCandles: %v", err)
+	}
+	// Fetching the candle again while it is open replaces it
+	candle.Close, candle.Volume = decimal.NewFromInt(102), decimal.NewFromInt(8)
+	if err := InsertExchangeCandles(ctx, conn, "okx", "BTC-USDT", "1m", []ExchangeCandle{candle}); err != nil {
+		t.Fatalf("InsertExchangeCandles: %v", err)
+	}
+
+	from, to := timeutil.SecondsOf(minute.Add(-time.Hour)), timeutil.SecondsOf(minute.Add(time.Hour))
+	candles, err := GetExchangeCandles(conn, "okx", "BTC-USDT", from, to, "1m")
+	if err != nil || len(candles) != 1 {
+		t.Fatalf("GetExchangeCandles = %+v, %v", candles, err)
+	}
+	if !candles[0].Close.Equal(decimal.NewFromInt(102)) || candles[0].TradesCount != 7 {
+		t.Errorf("candle = %+v, want the refetched close of 102", candles[0])
+	}
+
+	if _, err := GetExchangeCandles(conn, "okx", "BTC-USDT", from, to, "1h"); !errors.Is(err, ErrNoData) {
+		t.Errorf("unfetched interval: err = %v, want ErrNoData", err)
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import "errors"
+
+var (
+	// ErrSymbolNotFound is returned when no token matches the requested symbol
+	ErrSymbolNotFound = errors.New("symbol not found")
+
+	// ErrNoData is returned when a query for a symbol or range matched no rows
+	ErrNoData = errors.New("no data")
+
+	// ErrTokenNotFound is returned when no active token has the requested ID
+	ErrTokenNotFound = errors.New("token not found")
+
+	// ErrPendingMappingNotFound is returned when no pending mapping has the requested ID
+	ErrPendingMappingNotFound = errors.New("pending mapping not found")
+
+	// ErrPendingMappingClosed is returned when a pending mapping was already
+	// resolved or ignored
+	ErrPendingMappingClosed = errors.New("pending mapping already closed")
+
+	// ErrExchangeNotFound is returned when no registered exchange has the requested ID
+	ErrExchangeNotFound = errors.New("exchange not found")
+
+	// ErrPairNotFound is returned when an exchange has no trading pair with the requested symbol
+	ErrPairNotFound = errors.New("trading pair not found")
+
+	// ErrExchangeExists is returned when registering an exchange whose ID is taken
+	ErrExchangeExists = errors.New("exchange already exists")
+
+	// ErrExchangeInUse is returned when deleting an exchange that still has trading pairs
+	ErrExchangeInUse = errors.New("exchange has trading pairs")
+
+	// ErrWatchlistNotFound is returned when the caller has no watchlist with the requested ID
+	ErrWatchlistNotFound = errors.New("watchlist not found")
+
+	// ErrWatchlistExists is returned when the caller already has a watchlist with the requested name
+	ErrWatchlistExists = errors.New("watchlist already exists")
+
+	// ErrWebhookNotFound is returned when the caller has no webhook with the requested ID
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	// ErrOutlierThresholdNotFound is returned when a pair has no outlier threshold override
+	ErrOutlierThresholdNotFound = errors.New("outlier threshold override not found")
+
+	// ErrFeatureFlagNotFound is returned when a feature flag has no override to delete
+	ErrFeatureFlagNotFound = errors.New("feature flag override not found")
+
+	// ErrOutlierNotFound is returned when no price outlier has the requested ID
+	ErrOutlierNotFound = errors.New("outlier not found")
+
+	// ErrOutlierResolved is returned when fixing a price outlier that was already resolved
+	ErrOutlierResolved = errors.New("outlier already resolved")
+
+	// ErrMappingNotFound is returned when a token has no symbol mapping on the requested exchange, or no mapping has the requested ID
+	ErrMappingNotFound = errors.New("mapping not found")
+
+	// ErrMappingExists is returned when creating a mapping for an exchange symbol that is mapped already
+	ErrMappingExists = errors.New("mapping already exists")
+
+	// ErrPairExists is returned when creating a trading pair an exchange already has
+	ErrPairExists = errors.New("trading pair already exists")
+
+	// ErrMaintenanceWindowNotFound is returned when an exchange has no maintenance window with the requested ID
+	ErrMaintenanceWindowNotFound = errors.New("maintenance window not found")
+)
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/lib/pq"
+)
+
+// MaintenanceWindow is a scheduled downtime of an exchange
+type MaintenanceWindow struct {
+	ID         int64
+	ExchangeID string
+	exchanges.MaintenanceWindow
+	CreatedAt time.Time
+}
+
+// CreateMaintenanceWindow schedules a maintenance window, returning the
+// existing one with its reason replaced when the exchange already has a
+// window with the same start and end. It returns ErrExchangeNotFound when
+// the exchange is not registered.
+func CreateMaintenanceWindow(ctx context.Context, db *sql.DB, exchangeID string, w exchanges.MaintenanceWindow) (MaintenanceWindow, error) {
+	out := MaintenanceWindow{ExchangeID: exchangeID}
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO exchange_maintenance_windows (exchange_id, starts_at, ends_at, reason)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (exchange_id, starts_at, ends_at) DO UPDATE SET reason = EXCLUDED.reason
+		RETURNING id, starts_at, ends_at, reason, created_at
+	`, exchangeID, w.Start.UTC(), w.End.UTC(), w.Reason).Scan(&out.ID, &out.Start, &out.End, &out.Reason, &out.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return MaintenanceWindow{}, fmt.Errorf("%w: %s", ErrExchangeNotFound, exchangeID)
+	}
+	if err != nil {
+		return MaintenanceWindow{}, fmt.Errorf("failed to create maintenance window for %s: %w", exchangeID, err)
+	}
+	return out, nil
+}
+
+// ListMaintenanceWindows returns the maintenance windows ending after
+// endsAfter, soonest first, of one exchange or of every exchange when
+// exchangeID is empty
+func ListMaintenanceWindows(ctx context.Context, db *sql.DB, exchangeID string, endsAfter time.Time) ([]MaintenanceWindow, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, exchange_id, starts_at, ends_at, reason, created_at
+		FROM exchange_maintenance_windows
+		WHERE ends_at > $1 AND ($2 = '' OR exchange_id = $2)
+		ORDER BY starts_at, exchange_id
+	`, endsAfter.UTC(), exchangeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	var out []MaintenanceWindow
+	for rows.Next() {
+		var w MaintenanceWindow
+		if err := rows.Scan(&w.ID, &w.ExchangeID, &w.Start, &w.End, &w.Reason, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// DeleteMaintenanceWindow removes one of an exchange's maintenance windows
+func DeleteMaintenanceWindow(ctx context.Context, db *sql.DB, exchangeID string, id int64) error {
+	res, err := db.ExecContext(ctx, `
+		DELETE FROM exchange_maintenance_windows WHERE id = $1 AND exchange_id = $2
+	`, id, exchangeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %d", ErrMaintenanceWindowNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestMaintenanceWindows(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	upgrade := exchanges.MaintenanceWindow{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour), Reason: "upgrade"}
+	if _, err := SeedExchanges(ctx, conn, []exchanges.ExchangeConfig{
+		{ID: "kraken", Name: "Kraken", BaseURL: "https://api.kraken.com", TickerEndpoint: "/0/public/Ticker", Weight: 0.1,
+			MaintenanceWindows: []exchanges.MaintenanceWindow{
+				upgrade,
+				{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}, // over, not scheduled
+			}},
+	}); err != nil {
+		t.Fatalf("SeedExchanges: %v", err)
+	}
+
+	current, err := CreateMaintenanceWindow(ctx, conn, "kraken", exchanges.MaintenanceWindow{Start: now.Add(-time.Minute), End: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("CreateMaintenanceWindow: %v", err)
+	}
+	if _, err := CreateMaintenanceWindow(ctx, conn, "nope", upgrade); !errors.Is(err, ErrExchangeNotFound) {
+		t.Errorf("unknown exchange: err %v, want ErrExchangeNotFound", err)
+	}
+	// Seeding again or scheduling the same window does not add it twice
+	if _, err := SeedExchanges(ctx, conn, []exchanges.ExchangeConfig{
+		{ID: "kraken", Name: "Kraken", BaseURL: "https://api.kraken.com", TickerEndpoint: "/0/public/Ticker",
+			MaintenanceWindows: []exchanges.MaintenanceWindow{upgrade}},
+	}); err != nil {
+		t.Fatalf("SeedExchanges again: %v", err)
+	}
+	upgrade.Reason = "matching engine upgrade"
+	if w, err := CreateMaintenanceWindow(ctx, conn, "kraken", upgrade); err != nil || w.Reason != upgrade.Reason {
+		t.Fatalf("rescheduling = %+v, err %v", w, err)
+	}
+
+	windows, err := ListMaintenanceWindows(ctx, conn, "kraken", now)
+	if err != nil || len(windows) != 2 {
+		t.Fatalf("windows = %+v, err %v", windows, err)
+	}
+	if windows[0].ID != current.ID || !windows[1].Start.Equal(upgrade.Start) || windows[1].Reason != upgrade.Reason {
+		t.Errorf("windows = %+v", windows)
+	}
+
+	if err := DeleteMaintenanceWindow(ctx, conn, "kraken", current.ID); err != nil {
+		t.Fatalf("DeleteMaintenanceWindow: %v", err)
+	}
+	if err := DeleteMaintenanceWindow(ctx, conn, "kraken", current.ID); !errors.Is(err, ErrMaintenanceWindowNotFound) {
+		t.Errorf("deleting again: err %v, want ErrMaintenanceWindowNotFound", err)
+	}
+	if all, err := ListMaintenanceWindows(ctx, conn, "", now); err != nil || len(all) != 1 {
+		t.Errorf("all windows = %+v, err %v", all, err)
+	}
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ExchangeTrust is the trust score of an exchange's volume and the wash
+// trading signals it came from, see the trust package. An exchange not
+// scored yet has a score of 1.
+type ExchangeTrust struct {
+	ExchangeID   string
+	Score        float64
+	Trades       int64 // checked for round trips
+	RoundTripPct float64
+	Pairs        int // compared with other venues
+	MismatchPct  float64
+	UpdatedAt    time.Time // zero until first scored
+}
+
+// SaveExchangeTrust stores the trust scores of the exchanges, in one
+// transaction
+func SaveExchangeTrust(ctx context.Context, db *sql.DB, scores []ExchangeTrust) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, t := range scores {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE exchanges SET
+				trust_score = $2, trust_trades = $3, trust_round_trip_pct = $4,
+				trust_pairs = $5, trust_mismatch_pct = $6, trust_score_updated_at = NOW()
+			WHERE exchange_id = $1
+		`, t.ExchangeID, t.Score, t.Trades, t.RoundTripPct, t.Pairs, t.MismatchPct); err != nil {
+			return fmt.Errorf("failed to store trust score of %s: %w", t.ExchangeID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit trust scores: %w", err)
+	}
+	return nil
+}
+
+// GetTrustScores returns the trust score of every active exchange that has
+// been scored
+func GetTrustScores(ctx context.Context, db *sql.DB) (map[string]float64, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT exchange_id, trust_score
+		FROM exchanges
+		WHERE is_active = true AND trust_score IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trust scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make(map[string]float64)
+	for rows.Next() {
+		var id string
+		var score float64
+		if err := rows.Scan(&id, &score); err != nil {
+			return nil, fmt.Errorf("failed to scan trust score: %w", err)
+		}
+		scores[id] = score
+	}
+	return scores, rows.Err()
+}
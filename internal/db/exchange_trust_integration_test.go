@@ -0,0 +1,43 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestExchangeTrust(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+
+	if _, err := SeedExchanges(ctx, conn, []exchanges.ExchangeConfig{
+		{ID: "binance", Name: "Binance", BaseURL: "https://api.binance.com", TickerEndpoint: "/api/v3/ticker/24hr", Weight: 0.08},
+		{ID: "kraken", Name: "Kraken", BaseURL: "https://api.kraken.com", TickerEndpoint: "/0/public/Ticker", Weight: 0.1},
+	}); err != nil {
+		t.Fatalf("SeedExchanges: %v", err)
+	}
+
+	// Unscored exchanges are fully trusted
+	kraken, err := GetExchange(ctx, conn, "kraken")
+	if err != nil || kraken.Trust.Score != 1 || !kraken.Trust.UpdatedAt.IsZero() {
+		t.Fatalf("unscored kraken = %+v, err %v", kraken.Trust, err)
+	}
+
+	if err := SaveExchangeTrust(ctx, conn, []ExchangeTrust{
+		{ExchangeID: "binance", Score: 0.625, Trades: 5000, RoundTripPct: 20, Pairs: 12, MismatchPct: 8},
+	}); err != nil {
+		t.Fatalf("SaveExchangeTrust: %v", err)
+	}
+	binance, err := GetExchange(ctx, conn, "binance")
+	if err != nil || binance.Trust.Score != 0.625 || binance.Trust.Trades != 5000 || binance.Trust.Pairs != 12 ||
+		binance.Trust.UpdatedAt.IsZero() {
+		t.Errorf("binance = %+v, err %v", binance.Trust, err)
+	}
+	if scores, err := GetTrustScores(ctx, conn); err != nil || len(scores) != 1 || scores["binance"] != 0.625 {
+		t.Errorf("trust scores = %v, err %v", scores, err)
+	}
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ExchangeWeightChange is one computation of an exchange's dynamic weight:
+// the weight it came to and what it came from
+type ExchangeWeightChange struct {
+	ExchangeID     string
+	StaticWeight   float64
+	PreviousWeight *float64 // nil on the first computation
+	TargetWeight   float64  // before smoothing
+	Weight         float64
+	VolumeSharePct float64
+	UptimePct      float64
+	ComputedAt     time.Time
+}
+
+// SaveDynamicWeights stores the dynamic weights on the exchanges and adds
+// the changes to the weight history, in one transaction
+func SaveDynamicWeights(ctx context.Context, db *sql.DB, changes []ExchangeWeightChange) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, c := range changes {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE exchanges SET dynamic_weight = $2, dynamic_weight_updated_at = NOW()
+			WHERE exchange_id = $1
+		`, c.ExchangeID, c.Weight); err != nil {
+			return fmt.Errorf("failed to store dynamic weight of %s: %w", c.ExchangeID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO exchange_weight_history (
+				exchange_id, static_weight, previous_weight, target_weight, weight, volume_share_pct, uptime_pct
+			) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, c.ExchangeID, c.StaticWeight, c.PreviousWeight, c.TargetWeight, c.Weight, c.VolumeSharePct, c.UptimePct); err != nil {
+			return fmt.Errorf("failed to record dynamic weight of %s: %w", c.ExchangeID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit dynamic weights: %w", err)
+	}
+	return nil
+}
+
+// GetDynamicWeights returns the dynamic weight of every active exchange
+// that has one
+func GetDynamicWeights(ctx context.Context, db *sql.DB) (map[string]float64, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT exchange_id, dynamic_weight
+		FROM exchanges
+		WHERE is_active = true AND dynamic_weight IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dynamic weights: %w", err)
+	}
+	defer rows.Close()
+
+	weights := make(map[string]float64)
+	for rows.Next() {
+		var id string
+		var weight float64
+		if err := rows.Scan(&id, &weight); err != nil {
+			return nil, fmt.Errorf("failed to scan dynamic weight: %w", err)
+		}
+		weights[id] = weight
+	}
+	return weights, rows.Err()
+}
+
+// ListExchangeWeightHistory returns the latest weight changes of an
+// exchange, or of every exchange when exchangeID is empty, newest first
+func ListExchangeWeightHistory(ctx context.Context, db *sql.DB, exchangeID string, limit int) ([]ExchangeWeightChange, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT exchange_id, static_weight, previous_weight, target_weight, weight,
+			volume_share_pct, uptime_pct, computed_at
+		FROM exchange_weight_history
+		WHERE $1 = '' OR exchange_id = $1
+		ORDER BY computed_at DESC, id DESC
+		LIMIT $2
+	`, exchangeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange weight history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ExchangeWeightChange
+	for rows.Next() {
+		var c ExchangeWeightChange
+		var previous sql.NullFloat64
+		if err := rows.Scan(&c.ExchangeID, &c.StaticWeight, &previous, &c.TargetWeight, &c.Weight,
+			&c.VolumeSharePct, &c.UptimePct, &c.ComputedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange weight change: %w", err)
+		}
+		if previous.Valid {
+			c.PreviousWeight = &previous.Float64
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,60 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestDynamicWeights(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+
+	if _, err := SeedExchanges(ctx, conn, []exchanges.ExchangeConfig{
+		{ID: "binance", Name: "Binance", BaseURL: "https://api.binance.com", TickerEndpoint: "/api/v3/ticker/24hr", Weight: 0.08},
+		{ID: "kraken", Name: "Kraken", BaseURL: "https://api.kraken.com", TickerEndpoint: "/0/public/Ticker", Weight: 0.1},
+	}); err != nil {
+		t.Fatalf("SeedExchanges: %v", err)
+	}
+
+	first := []ExchangeWeightChange{
+		{ExchangeID: "binance", StaticWeight: 0.08, TargetWeight: 0.7, Weight: 0.7, VolumeSharePct: 70, UptimePct: 100},
+		{ExchangeID: "kraken", StaticWeight: 0.1, TargetWeight: 0.3, Weight: 0.3, VolumeSharePct: 30, UptimePct: 100},
+	}
+	if err := SaveDynamicWeights(ctx, conn, first); err != nil {
+		t.Fatalf("SaveDynamicWeights: %v", err)
+	}
+	previous := 0.7
+	if err := SaveDynamicWeights(ctx, conn, []ExchangeWeightChange{
+		{ExchangeID: "binance", StaticWeight: 0.08, PreviousWeight: &previous, TargetWeight: 0.5, Weight: 0.64, VolumeSharePct: 50, UptimePct: 100},
+	}); err != nil {
+		t.Fatalf("SaveDynamicWeights: %v", err)
+	}
+
+	weights, err := GetDynamicWeights(ctx, conn)
+	if err != nil || len(weights) != 2 || weights["binance"] != 0.64 || weights["kraken"] != 0.3 {
+		t.Fatalf("dynamic weights = %v, err %v", weights, err)
+	}
+	binance, err := GetExchange(ctx, conn, "binance")
+	if err != nil || binance.DynamicWeight != 0.64 || binance.DynamicWeightAt.IsZero() || binance.Weight != 0.08 {
+		t.Errorf("binance = %+v, err %v", binance, err)
+	}
+
+	history, err := ListExchangeWeightHistory(ctx, conn, "binance", 10)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("binance history = %+v, err %v", history, err)
+	}
+	if h := history[0]; h.Weight != 0.64 || h.PreviousWeight == nil || *h.PreviousWeight != 0.7 {
+		t.Errorf("latest change = %+v", h)
+	}
+	if history[1].PreviousWeight != nil {
+		t.Errorf("first change has a previous weight: %+v", history[1])
+	}
+	if all, err := ListExchangeWeightHistory(ctx, conn, "", 10); err != nil || len(all) != 3 {
+		t.Errorf("all history = %+v, err %v", all, err)
+	}
+}
@@ -0,0 +1,276 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/lib/pq"
+)
+
+// Exchange is a row of the exchange registry: the client config the poller
+// builds from, with Disabled set for inactive exchanges, and its poll health
+type Exchange struct {
+	exchanges.ExchangeConfig
+	LastSuccessfulPoll  time.Time // zero until the first successful poll
+	ConsecutiveFailures int
+	DynamicWeight       float64   // computed from volume share and uptime
+	DynamicWeightAt     time.Time // zero until the dynamic weight is first computed
+	Trust               ExchangeTrust
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+const exchangeColumns = `
+	exchange_id, name, base_url, ticker_endpoint, symbols_endpoint, rate_limit_per_minute,
+	request_timeout_ms, retry_attempts, weight, symbol_format, quote_currencies, is_active,
+	COALESCE(taker_fee, 0), COALESCE(maker_fee, 0), COALESCE(trades_endpoint, ''), allow_symbols, deny_symbols,
+	last_successful_poll, consecutive_failures, COALESCE(dynamic_weight, 0), dynamic_weight_updated_at,
+	COALESCE(trust_score, 1), COALESCE(trust_trades, 0), COALESCE(trust_round_trip_pct, 0),
+	COALESCE(trust_pairs, 0), COALESCE(trust_mismatch_pct, 0), trust_score_updated_at,
+	created_at, updated_at`
+
+func scanExchange(row interface{ Scan(...any) error }) (Exchange, error) {
+	var e Exchange
+	var active bool
+	var lastPoll, dynamicAt, trustAt sql.NullTime
+	err := row.Scan(&e.ID, &e.Name, &e.BaseURL, &e.TickerEndpoint, &e.SymbolsEndpoint, &e.RateLimitPerMinute,
+		&e.RequestTimeout, &e.RetryAttempts, &e.Weight, &e.SymbolFormat, pq.Array(&e.QuoteCurrencies), &active,
+		&e.TakerFee, &e.MakerFee, &e.TradesEndpoint, pq.Array(&e.AllowSymbols), pq.Array(&e.DenySymbols), &lastPoll, &e.ConsecutiveFailures, &e.DynamicWeight, &dynamicAt,
+		&e.Trust.Score, &e.Trust.Trades, &e.Trust.RoundTripPct, &e.Trust.Pairs, &e.Trust.MismatchPct, &trustAt,
+		&e.CreatedAt, &e.UpdatedAt)
+	e.Disabled = !active
+	e.LastSuccessfulPoll = lastPoll.Time
+	e.DynamicWeightAt = dynamicAt.Time
+	e.Trust.ExchangeID, e.Trust.UpdatedAt = e.ID, trustAt.Time
+	return e, err
+}
+
+// ListExchanges returns the registered exchanges, heaviest first, leaving out
+// inactive ones unless includeInactive is set
+func ListExchanges(ctx context.Context, db *sql.DB, includeInactive bool) ([]Exchange, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+exchangeColumns+`
+		FROM exchanges
+		WHERE $1 OR is_active = true
+		ORDER BY weight DESC, exchange_id
+	`, includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchanges: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Exchange
+	for rows.Next() {
+		e, err := scanExchange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan exchange: %w", err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// GetExchange returns a registered exchange, active or not
+func GetExchange(ctx context.Context, db *sql.DB, exchangeID string) (Exchange, error) {
+	e, err := scanExchange(db.QueryRowContext(ctx, `SELECT `+exchangeColumns+` FROM exchanges WHERE exchange_id = $1`, exchangeID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Exchange{}, fmt.Errorf("%w: %s", ErrExchangeNotFound, exchangeID)
+	}
+	if err != nil {
+		return Exchange{}, fmt.Errorf("failed to load exchange %s: %w", exchangeID, err)
+	}
+	return e, nil
+}
+
+// CreateExchange registers an exchange. It returns ErrExchangeExists when the
+// ID is taken.
+func CreateExchange(ctx context.Context, db *sql.DB, c exchanges.ExchangeConfig) (Exchange, error) {
+	e, err := scanExchange(db.QueryRowContext(ctx, `
+		INSERT INTO exchanges (
+			exchange_id, name, base_url, ticker_endpoint, symbols_endpoint, rate_limit_per_minute,
+			request_timeout_ms, retry_attempts, weight, symbol_format, quote_currencies, is_active,
+			taker_fee, maker_fee, trades_endpoint, allow_symbols, deny_symbols
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13::numeric, 0), NULLIF($14::numeric, 0), NULLIF($15, ''), $16, $17)
+		RETURNING `+exchangeColumns,
+		c.ID, c.Name, c.BaseURL, c.TickerEndpoint, c.SymbolsEndpoint, c.RateLimitPerMinute,
+		c.RequestTimeout, c.RetryAttempts, c.Weight, c.SymbolFormat, pq.Array(nonNil(c.QuoteCurrencies)), !c.Disabled,
+		c.TakerFee, c.MakerFee, c.TradesEndpoint, pq.Array(nonNil(c.AllowSymbols)), pq.Array(nonNil(c.DenySymbols))))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return Exchange{}, fmt.Errorf("%w: %s", ErrExchangeExists, c.ID)
+	}
+	if err != nil {
+		return Exchange{}, fmt.Errorf("failed to create exchange %s: %w", c.ID, err)
+	}
+	return e, nil
+}
+
+// UpdateExchange replaces the config of a registered exchange. Its poll
+// health is kept.
+func UpdateExchange(ctx context.Context, db *sql.DB, c exchanges.ExchangeConfig) (Exchange, error) {
+	e, err := scanExchange(db.QueryRowContext(ctx, `
+		UPDATE exchanges SET
+			name = $2, base_url = $3, ticker_endpoint = $4, symbols_endpoint = $5,
+			rate_limit_per_minute = $6, request_timeout_ms = $7, retry_attempts = $8,
+			weight = $9, symbol_format = $10, quote_currencies = $11, is_active = $12,
+			taker_fee = NULLIF($13::numeric, 0), maker_fee = NULLIF($14::numeric, 0),
+			trades_endpoint = NULLIF($15, ''), allow_symbols = $16, deny_symbols = $17
+		WHERE exchange_id = $1
+		RETURNING `+exchangeColumns,
+		c.ID, c.Name, c.BaseURL, c.TickerEndpoint, c.SymbolsEndpoint, c.RateLimitPerMinute,
+		c.RequestTimeout, c.RetryAttempts, c.Weight, c.SymbolFormat, pq.Array(nonNil(c.QuoteCurrencies)), !c.Disabled,
+		c.TakerFee, c.MakerFee, c.TradesEndpoint, pq.Array(nonNil(c.AllowSymbols)), pq.Array(nonNil(c.DenySymbols))))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Exchange{}, fmt.Errorf("%w: %s", ErrExchangeNotFound, c.ID)
+	}
+	if err != nil {
+		return Exchange{}, fmt.Errorf("failed to update exchange %s: %w", c.ID, err)
+	}
+	return e, nil
+}
+
+// DeleteExchange removes an exchange from the registry. Its mappings and
+// stored prices are kept, so registering it again picks up where it left off.
+// Trading pairs reference the registry, so an exchange that still has some
+// returns ErrExchangeInUse; deactivate it instead.
+func DeleteExchange(ctx context.Context, db *sql.DB, exchangeID string) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM exchanges WHERE exchange_id = $1`, exchangeID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return fmt.Errorf("%w: %s", ErrExchangeInUse, exchangeID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete exchange %s: %w", exchangeID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ErrExchangeNotFound, exchangeID)
+	}
+	return nil
+}
+
+// RegisteredExchangeIDs returns the IDs of every registered exchange, active
+// or not: the exchange IDs trading pairs may have
+func RegisteredExchangeIDs(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT exchange_id FROM exchanges`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange IDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange ID: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// IsUnregisteredExchange reports whether err is a trading pair write
+// rejected because its exchange is not in the registry
+func IsUnregisteredExchange(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "trading_pairs_exchange_id_fkey"
+}
+
+// SeedExchanges registers the configs whose exchange is not in the registry
+// yet and returns how many were added. Registered exchanges are left alone,
+// so changes made through the API survive a restart. Maintenance windows in
+// the configs that have not ended are scheduled for every exchange, once.
+func SeedExchanges(ctx context.Context, db *sql.DB, configs []exchanges.ExchangeConfig) (added int, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO exchanges (
+			exchange_id, name, base_url, ticker_endpoint, symbols_endpoint, rate_limit_per_minute,
+			request_timeout_ms, retry_attempts, weight, symbol_format, quote_currencies, is_active,
+			taker_fee, maker_fee, trades_endpoint, allow_symbols, deny_symbols
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13::numeric, 0), NULLIF($14::numeric, 0), NULLIF($15, ''), $16, $17)
+		ON CONFLICT (exchange_id) DO NOTHING
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare exchange insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, c := range configs {
+		res, err := stmt.ExecContext(ctx, c.ID, c.Name, c.BaseURL, c.TickerEndpoint, c.SymbolsEndpoint, c.RateLimitPerMinute,
+			c.RequestTimeout, c.RetryAttempts, c.Weight, c.SymbolFormat, pq.Array(nonNil(c.QuoteCurrencies)), !c.Disabled,
+			c.TakerFee, c.MakerFee, c.TradesEndpoint, pq.Array(nonNil(c.AllowSymbols)), pq.Array(nonNil(c.DenySymbols)))
+		if err != nil {
+			return 0, fmt.Errorf("failed to register exchange %s: %w", c.ID, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+
+	windowStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO exchange_maintenance_windows (exchange_id, starts_at, ends_at, reason)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (exchange_id, starts_at, ends_at) DO NOTHING
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare maintenance window insert: %w", err)
+	}
+	defer windowStmt.Close()
+
+	now := time.Now()
+	for _, c := range configs {
+		for _, w := range c.MaintenanceWindows {
+			if !w.End.After(now) || !w.End.After(w.Start) {
+				continue
+			}
+			if _, err := windowStmt.ExecContext(ctx, c.ID, w.Start.UTC(), w.End.UTC(), w.Reason); err != nil {
+				return 0, fmt.Errorf("failed to schedule maintenance of %s: %w", c.ID, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit exchanges: %w", err)
+	}
+	return added, nil
+}
+
+// RecordExchangePolls updates the poll health of the polled exchanges: a
+// success sets the last successful poll and clears the failure count, a
+// failure adds to it
+func RecordExchangePolls(ctx context.Context, db *sql.DB, succeeded map[string]bool) error {
+	ids := make([]string, 0, len(succeeded))
+	oks := make([]bool, 0, len(succeeded))
+	for id, ok := range succeeded {
+		ids = append(ids, id)
+		oks = append(oks, ok)
+	}
+
+	_, err := db.ExecContext(ctx, `
+		UPDATE exchanges e SET
+			last_successful_poll = CASE WHEN p.ok THEN NOW() ELSE e.last_successful_poll END,
+			consecutive_failures = CASE WHEN p.ok THEN 0 ELSE e.consecutive_failures + 1 END
+		FROM unnest($1::text[], $2::boolean[]) AS p(exchange_id, ok)
+		WHERE e.exchange_id = p.exchange_id
+	`, pq.Array(ids), pq.Array(oks))
+	if err != nil {
+		return fmt.Errorf("failed to record exchange polls: %w", err)
+	}
+	return nil
+}
+
+// nonNil keeps an empty list from being written as NULL
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
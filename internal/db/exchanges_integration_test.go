@@ -0,0 +1,79 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestExchangeRegistry(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+
+	configs := []exchanges.ExchangeConfig{
+		{ID: "binance", Name: "Binance", BaseURL: "https://api.binance.com", TickerEndpoint: "/api/v3/ticker/24hr",
+			Weight: 0.08, QuoteCurrencies: []string{"USDT", "BTC"}},
+		{ID: "kraken", Name: "Kraken", BaseURL: "https://api.kraken.com", TickerEndpoint: "/0/public/Ticker", Weight: 0.1},
+	}
+	added, err := SeedExchanges(ctx, conn, configs)
+	if err != nil || added != 2 {
+		t.Fatalf("first seed: added %d, err %v", added, err)
+	}
+
+	// Changes made in the registry survive seeding from the file again
+	binance := configs[0]
+	binance.Weight, binance.Disabled = 0.2, true
+	if _, err := UpdateExchange(ctx, conn, binance); err != nil {
+		t.Fatalf("UpdateExchange: %v", err)
+	}
+	added, err = SeedExchanges(ctx, conn, configs)
+	if err != nil || added != 0 {
+		t.Fatalf("second seed: added %d, err %v", added, err)
+	}
+
+	active, err := ListExchanges(ctx, conn, false)
+	if err != nil || len(active) != 1 || active[0].ID != "kraken" {
+		t.Fatalf("active exchanges = %+v, err %v", active, err)
+	}
+	all, err := ListExchanges(ctx, conn, true)
+	if err != nil || len(all) != 2 || all[0].ID != "binance" || all[0].Weight != 0.2 || !all[0].Disabled ||
+		len(all[0].QuoteCurrencies) != 2 {
+		t.Fatalf("all exchanges = %+v, err %v", all, err)
+	}
+
+	if _, err := CreateExchange(ctx, conn, configs[1]); !errors.Is(err, ErrExchangeExists) {
+		t.Errorf("creating a registered exchange: err = %v, want ErrExchangeExists", err)
+	}
+	if _, err := UpdateExchange(ctx, conn, exchanges.ExchangeConfig{ID: "nope", Name: "Nope"}); !errors.Is(err, ErrExchangeNotFound) {
+		t.Errorf("updating an unknown exchange: err = %v, want ErrExchangeNotFound", err)
+	}
+
+	if err := RecordExchangePolls(ctx, conn, map[string]bool{"kraken": false, "binance": true, "nope": true}); err != nil {
+		t.Fatalf("RecordExchangePolls: %v", err)
+	}
+	if err := RecordExchangePolls(ctx, conn, map[string]bool{"kraken": false}); err != nil {
+		t.Fatalf("RecordExchangePolls: %v", err)
+	}
+	kraken, err := GetExchange(ctx, conn, "kraken")
+	if err != nil || kraken.ConsecutiveFailures != 2 || !kraken.LastSuccessfulPoll.IsZero() {
+		t.Errorf("kraken = %+v, err %v", kraken, err)
+	}
+	if binance, err := GetExchange(ctx, conn, "binance"); err != nil || binance.LastSuccessfulPoll.IsZero() {
+		t.Errorf("binance = %+v, err %v", binance, err)
+	}
+
+	if err := DeleteExchange(ctx, conn, "kraken"); err != nil {
+		t.Fatalf("DeleteExchange: %v", err)
+	}
+	if _, err := GetExchange(ctx, conn, "kraken"); !errors.Is(err, ErrExchangeNotFound) {
+		t.Errorf("deleted exchange: err = %v, want ErrExchangeNotFound", err)
+	}
+	if err := DeleteExchange(ctx, conn, "kraken"); !errors.Is(err, ErrExchangeNotFound) {
+		t.Errorf("deleting twice: err = %v, want ErrExchangeNotFound", err)
+	}
+}
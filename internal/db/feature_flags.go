@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FeatureFlag is a feature flag's override, which takes precedence over the
+// environment and the flag's default
+type FeatureFlag struct {
+	Name      string
+	Enabled   bool
+	Notes     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+const featureFlagColumns = `name, enabled, COALESCE(notes, ''), created_at, updated_at`
+
+func scanFeatureFlag(row interface{ Scan(...any) error }) (FeatureFlag, error) {
+	var f FeatureFlag
+	err := row.Scan(&f.Name, &f.Enabled, &f.Notes, &f.CreatedAt, &f.UpdatedAt)
+	return f, err
+}
+
+// ListFeatureFlags returns every feature flag override, ordered by name
+func ListFeatureFlags(ctx context.Context, db *sql.DB) ([]FeatureFlag, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+featureFlagColumns+` FROM feature_flags ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	var out []FeatureFlag
+	for rows.Next() {
+		f, err := scanFeatureFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %w", err)
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// UpsertFeatureFlag creates or replaces the override of a feature flag
+func UpsertFeatureFlag(ctx context.Context, db *sql.DB, f FeatureFlag) (FeatureFlag, error) {
+	saved, err := scanFeatureFlag(db.QueryRowContext(ctx, `
+		INSERT INTO feature_flags (name, enabled, notes)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (name) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			notes = EXCLUDED.notes
+		RETURNING `+featureFlagColumns,
+		f.Name, f.Enabled, f.Notes))
+	if err != nil {
+		return FeatureFlag{}, fmt.Errorf("failed to save feature flag %s: %w", f.Name, err)
+	}
+	return saved, nil
+}
+
+// DeleteFeatureFlag removes the override of a feature flag, which then takes
+// its value from the environment or its default again
+func DeleteFeatureFlag(ctx context.Context, db *sql.DB, name string) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM feature_flags WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete feature flag %s: %w", name, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ErrFeatureFlagNotFound, name)
+	}
+	return nil
+}
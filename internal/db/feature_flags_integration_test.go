@@ -0,0 +1,42 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+
+	if _, err := UpsertFeatureFlag(ctx, conn, FeatureFlag{Name: "vwap_fx_conversion", Enabled: false, Notes: "bad EUR rates"}); err != nil {
+		t.Fatalf("UpsertFeatureFlag: %v", err)
+	}
+	saved, err := UpsertFeatureFlag(ctx, conn, FeatureFlag{Name: "vwap_fx_conversion", Enabled: true})
+	if err != nil {
+		t.Fatalf("UpsertFeatureFlag again: %v", err)
+	}
+	if !saved.Enabled || saved.Notes != "" {
+		t.Errorf("saved = %+v, want enabled without notes", saved)
+	}
+
+	flags, err := ListFeatureFlags(ctx, conn)
+	if err != nil {
+		t.Fatalf("ListFeatureFlags: %v", err)
+	}
+	if len(flags) != 1 || flags[0].Name != "vwap_fx_conversion" {
+		t.Fatalf("flags = %+v, want vwap_fx_conversion only", flags)
+	}
+
+	if err := DeleteFeatureFlag(ctx, conn, "vwap_fx_conversion"); err != nil {
+		t.Fatalf("DeleteFeatureFlag: %v", err)
+	}
+	if err := DeleteFeatureFlag(ctx, conn, "vwap_fx_conversion"); !errors.Is(err, ErrFeatureFlagNotFound) {
+		t.Errorf("second delete: err = %v, want ErrFeatureFlagNotFound", err)
+	}
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StoreFXRates records a day's fiat rates, in US dollars per unit of each
+// currency, replacing any already stored for that day
+func StoreFXRates(ctx context.Context, db *sql.DB, date time.Time, source string, rates map[string]decimal.Decimal) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO fx_rates (currency, rate_date, usd_rate, source)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (currency, rate_date) DO UPDATE SET
+			usd_rate = EXCLUDED.usd_rate,
+			source = EXCLUDED.source,
+			fetched_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare fx rate insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for currency, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, currency, date.Format("2006-01-02"), rate, source); err != nil {
+			return fmt.Errorf("failed to store fx rate %s: %w", currency, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit fx rates: %w", err)
+	}
+	return nil
+}
+
+// GetFiatUSDRates maps the token ID of each fiat currency to its latest rate
+// in US dollars, leaving out currencies whose latest rate is older than
+// maxAge. USD itself is not included.
+func GetFiatUSDRates(ctx context.Context, db *sql.DB, maxAge time.Duration) (map[int]decimal.Decimal, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT ON (t.id) t.id, r.usd_rate
+		FROM fx_rates r
+		JOIN tokens t ON t.symbol = r.currency AND 'fiat' = ANY(t.categories)
+		WHERE r.currency <> 'USD' AND r.rate_date >= CURRENT_DATE - $1::integer
+		ORDER BY t.id, r.rate_date DESC
+	`, int(maxAge.Hours()/24))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fx rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := make(map[int]decimal.Decimal)
+	for rows.Next() {
+		var id int
+		var rate decimal.Decimal
+		if err := rows.Scan(&id, &rate); err != nil {
+			return nil, fmt.Errorf("failed to scan fx rate: %w", err)
+		}
+		rates[id] = rate
+	}
+	return rates, rows.Err()
+}
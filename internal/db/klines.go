@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeCandle is a candle as an exchange computed it. TradesCount is 0
+// when the exchange does not give it.
+type ExchangeCandle struct {
+	OpenTime    time.Time
+	Open        decimal.Decimal
+	High        decimal.Decimal
+	Low         decimal.Decimal
+	Close       decimal.Decimal
+	Volume      decimal.Decimal
+	TradesCount uint64
+}
+
+// InsertExchangeCandles stores an exchange's candles for a symbol and
+// interval. Candles already stored are replaced, which updates the one that
+// was still open.
+func InsertExchangeCandles(ctx context.Context, conn driver.Conn, exchangeID, symbol, interval string, candles []ExchangeCandle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	batch, err := conn.PrepareBatch(ctx, `
+		INSERT INTO exchange_ohlcv (exchange_id, symbol, interval, open_time, open, high, low, close, volume, trades_count)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	for _, c := range candles {
+		if err := batch.Append(exchangeID, symbol, interval, c.OpenTime.UTC(),
+			c.Open, c.High, c.Low, c.Close, c.Volume, c.TradesCount); err != nil {
+			return fmt.Errorf("failed to append candle to batch: %w", err)
+		}
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+	return nil
+}
+
+// GetExchangeCandles gets an exchange's own candles for a symbol as the
+// exchange writes it, between two Unix times in seconds. It returns
+// ErrNoData when none are stored for the range, including when the interval
+// is not fetched.
+func GetExchangeCandles(conn driver.Conn, exchangeID, symbol string, fromTime, toTime timeutil.Seconds, interval string) ([]OHLCVData, error) {
+	ctx := context.Background()
+
+	rows, err := conn.Query(ctx, `
+		SELECT open_time, open, high, low, close, volume, trades_count
+		FROM exchange_ohlcv FINAL
+		WHERE exchange_id = ? AND symbol = ? AND interval = ?
+		  AND open_time >= toDateTime(?) AND open_time <= toDateTime(?)
+		ORDER BY open_time
+	`, exchangeID, symbol, interval, int64(fromTime), int64(toTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange candles: %w", err)
+	}
+	defer rows.Close()
+
+	var data []OHLCVData
+	for rows.Next() {
+		var ohlcv OHLCVData
+		var openTime time.Time
+		var trades uint64
+		if err := rows.Scan(&openTime, &ohlcv.Open, &ohlcv.High, &ohlcv.Low,
+			&ohlcv.Close, &ohlcv.Volume, &trades); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange candle: %w", err)
+		}
+		ohlcv.Symbol = symbol
+		ohlcv.Timestamp = timeutil.SecondsOf(openTime)
+		ohlcv.TradesCount = trades
+		data = append(data, ohlcv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read exchange candles: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, ErrNoData
+	}
+	return data, nil
+}
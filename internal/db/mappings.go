@@ -0,0 +1,361 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UntrustedMappings holds the exchange symbols and pairs whose mapping to a
+// token has not been approved. Tickers using them are still stored but must
+// not feed VWAP.
+type UntrustedMappings struct {
+	symbols map[string]map[string]bool // exchangeID -> exchange symbol
+	pairs   map[string]map[string]bool // exchangeID -> exchange pair symbol
+}
+
+// Excludes reports whether a ticker's pair or either of its symbols relies on
+// an untrusted mapping on the exchange
+func (m *UntrustedMappings) Excludes(exchangeID, pairSymbol, baseSymbol, quoteSymbol string) bool {
+	if m == nil {
+		return false
+	}
+	if m.pairs[exchangeID][pairSymbol] {
+		return true
+	}
+	symbols := m.symbols[exchangeID]
+	return symbols[baseSymbol] || symbols[quoteSymbol]
+}
+
+// Count returns the number of untrusted symbols and pairs
+func (m *UntrustedMappings) Count() int {
+	n := 0
+	for _, s := range m.symbols {
+		n += len(s)
+	}
+	for _, p := range m.pairs {
+		n += len(p)
+	}
+	return n
+}
+
+// GetUntrustedMappings loads mappings that need verification, have a
+// confidence below minConfidence, or were flagged within flaggedWithin without
+// having been verified since. The last case catches flagged mappings that an
+// automatic re-mapping has since marked as not needing verification. Pairs
+// deactivated as delisted are included so their last tickers do not linger in
+// VWAP for the rest of the lookback.
+func GetUntrustedMappings(ctx context.Context, db *sql.DB, minConfidence float64, flaggedWithin time.Duration) (*UntrustedMappings, error) {
+	m := &UntrustedMappings{
+		symbols: make(map[string]map[string]bool),
+		pairs:   make(map[string]map[string]bool),
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT tes.exchange_id, tes.exchange_symbol
+		FROM token_exchange_symbols tes
+		WHERE tes.is_active = true
+		  AND (
+		    tes.needs_verification = true
+		    OR COALESCE(tes.confidence_score, 1) < $1
+		    OR EXISTS (
+		      SELECT 1 FROM mapping_audit_log mal
+		      WHERE mal.exchange_id = tes.exchange_id
+		        AND mal.exchange_symbol = tes.exchange_symbol
+		        AND mal.action = 'flagged'
+		        AND mal.created_at >= NOW() - $2 * INTERVAL '1 second'
+		        AND (tes.verified_at IS NULL OR tes.verified_at < mal.created_at)
+		    )
+		  )
+	`, minConfidence, flaggedWithin.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query untrusted symbol mappings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var exchangeID, symbol string
+		if err := rows.Scan(&exchangeID, &symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan untrusted symbol mapping: %w", err)
+		}
+		if m.symbols[exchangeID] == nil {
+			m.symbols[exchangeID] = make(map[string]bool)
+		}
+		m.symbols[exchangeID][symbol] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	pairRows, err := db.QueryContext(ctx, `
+		SELECT exchange_id, exchange_pair_symbol
+		FROM trading_pairs
+		WHERE (is_active = true AND (needs_verification = true OR COALESCE(confidence_score, 1) < $1))
+		   OR delisted_at IS NOT NULL
+	`, minConfidence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query untrusted trading pairs: %w", err)
+	}
+	defer pairRows.Close()
+
+	for pairRows.Next() {
+		var exchangeID, pair string
+		if err := pairRows.Scan(&exchangeID, &pair); err != nil {
+			return nil, fmt.Errorf("failed to scan untrusted trading pair: %w", err)
+		}
+		if m.pairs[exchangeID] == nil {
+			m.pairs[exchangeID] = make(map[string]bool)
+		}
+		m.pairs[exchangeID][pair] = true
+	}
+	return m, pairRows.Err()
+}
+
+// SymbolMapping is a token_exchange_symbols row
+type SymbolMapping struct {
+	ID                int
+	TokenID           int
+	TokenSymbol       string
+	ExchangeID        string
+	ExchangeSymbol    string
+	NormalizedSymbol  string
+	Chain             string
+	MappingMethod     string
+	ConfidenceScore   float64
+	NeedsVerification bool
+	IsActive          bool
+	VerifiedBy        string
+	VerifiedAt        time.Time // zero until verified
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// MappingChange is who changes a symbol mapping or trading pair by hand and
+// why, as mapping_audit_log records it
+type MappingChange struct {
+	PerformedBy string
+	Notes       string
+}
+
+const mappingColumns = `
+	tes.id, tes.token_id, t.symbol, tes.exchange_id, tes.exchange_symbol, tes.normalized_symbol,
+	COALESCE(tes.chain, ''), COALESCE(tes.mapping_method, ''), COALESCE(tes.confidence_score, 0),
+	COALESCE(tes.needs_verification, false), COALESCE(tes.is_active, false), COALESCE(tes.verified_by, ''),
+	tes.verified_at, tes.created_at, tes.updated_at`
+
+func scanMapping(row interface{ Scan(...any) error }) (SymbolMapping, error) {
+	var m SymbolMapping
+	var verifiedAt, createdAt, updatedAt sql.NullTime
+	err := row.Scan(&m.ID, &m.TokenID, &m.TokenSymbol, &m.ExchangeID, &m.ExchangeSymbol, &m.NormalizedSymbol,
+		&m.Chain, &m.MappingMethod, &m.ConfidenceScore, &m.NeedsVerification, &m.IsActive, &m.VerifiedBy,
+		&verifiedAt, &createdAt, &updatedAt)
+	m.VerifiedAt, m.CreatedAt, m.UpdatedAt = verifiedAt.Time, createdAt.Time, updatedAt.Time
+	return m, err
+}
+
+// MappingFilter narrows down a page of symbol mappings or trading pairs.
+// Empty fields match everything.
+type MappingFilter struct {
+	ExchangeID string
+	TokenID    int // a pair matches as either its base or its quote
+	AfterID    int
+	Limit      int
+}
+
+// ListSymbolMappings returns a page of symbol mappings by ID, active or not
+func ListSymbolMappings(ctx context.Context, db *sql.DB, f MappingFilter) ([]SymbolMapping, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+mappingColumns+`
+		FROM token_exchange_symbols tes
+		JOIN tokens t ON t.id = tes.token_id
+		WHERE ($1 = '' OR tes.exchange_id = $1) AND ($2 = 0 OR tes.token_id = $2) AND tes.id > $3
+		ORDER BY tes.id
+		LIMIT $4
+	`, f.ExchangeID, f.TokenID, f.AfterID, f.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query symbol mappings: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SymbolMapping
+	for rows.Next() {
+		m, err := scanMapping(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan symbol mapping: %w", err)
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// GetSymbolMapping returns a symbol mapping by ID, active or not
+func GetSymbolMapping(ctx context.Context, db *sql.DB, id int) (SymbolMapping, error) {
+	m, err := scanMapping(db.QueryRowContext(ctx, `
+		SELECT `+mappingColumns+`
+		FROM token_exchange_symbols tes
+		JOIN tokens t ON t.id = tes.token_id
+		WHERE tes.id = $1
+	`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return SymbolMapping{}, fmt.Errorf("%w: %d", ErrMappingNotFound, id)
+	}
+	if err != nil {
+		return SymbolMapping{}, fmt.Errorf("failed to load symbol mapping %d: %w", id, err)
+	}
+	return m, nil
+}
+
+// CreateSymbolMapping maps an exchange symbol to a token as a verified manual
+// mapping, as Resolver.AddSymbolMapping does for discovered ones. The token
+// must be active; its symbol is the normalized symbol when m has none. It
+// returns ErrMappingExists when the exchange symbol is mapped already.
+func CreateSymbolMapping(ctx context.Context, db *sql.DB, m SymbolMapping, change MappingChange) (SymbolMapping, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return SymbolMapping{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	symbol, chain, err := activeToken(ctx, tx, m.TokenID)
+	if err != nil {
+		return SymbolMapping{}, err
+	}
+	if m.NormalizedSymbol == "" {
+		m.NormalizedSymbol = symbol
+	}
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO token_exchange_symbols (
+			token_id, exchange_id, exchange_symbol, normalized_symbol, chain,
+			mapping_method, confidence_score, needs_verification, verified_by, verified_at, is_active
+		) VALUES ($1, $2, $3, $4, $5, 'manual', 1.0, false, $6, NOW(), $7)
+		RETURNING id
+	`, m.TokenID, m.ExchangeID, m.ExchangeSymbol, m.NormalizedSymbol, chain, change.PerformedBy, m.IsActive).Scan(&id)
+	if isUniqueViolation(err) {
+		return SymbolMapping{}, fmt.Errorf("%w: %s on %s", ErrMappingExists, m.ExchangeSymbol, m.ExchangeID)
+	}
+	if err != nil {
+		return SymbolMapping{}, fmt.Errorf("failed to map %s on %s: %w", m.ExchangeSymbol, m.ExchangeID, err)
+	}
+	if err := logMappingChange(ctx, tx, m.TokenID, m.ExchangeID, m.ExchangeSymbol, "created", change); err != nil {
+		return SymbolMapping{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return SymbolMapping{}, fmt.Errorf("failed to commit: %w", err)
+	}
+	return GetSymbolMapping(ctx, db, id)
+}
+
+// UpdateSymbolMapping replaces the token, exchange symbol, normalized symbol
+// and state of mapping id. The mapping becomes a verified manual one, so
+// automatic re-mapping leaves it alone.
+func UpdateSymbolMapping(ctx context.Context, db *sql.DB, id int, m SymbolMapping, change MappingChange) (SymbolMapping, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return SymbolMapping{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	symbol, chain, err := activeToken(ctx, tx, m.TokenID)
+	if err != nil {
+		return SymbolMapping{}, err
+	}
+	if m.NormalizedSymbol == "" {
+		m.NormalizedSymbol = symbol
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE token_exchange_symbols SET
+			token_id = $2, exchange_id = $3, exchange_symbol = $4, normalized_symbol = $5, chain = $6,
+			mapping_method = 'manual', confidence_score = 1.0, needs_verification = false,
+			verified_by = $7, verified_at = NOW(), is_active = $8,
+			archived_at = CASE WHEN $8 THEN NULL ELSE archived_at END
+		WHERE id = $1
+	`, id, m.TokenID, m.ExchangeID, m.ExchangeSymbol, m.NormalizedSymbol, chain, change.PerformedBy, m.IsActive)
+	if isUniqueViolation(err) {
+		return SymbolMapping{}, fmt.Errorf("%w: %s on %s", ErrMappingExists, m.ExchangeSymbol, m.ExchangeID)
+	}
+	if err != nil {
+		return SymbolMapping{}, fmt.Errorf("failed to update symbol mapping %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return SymbolMapping{}, fmt.Errorf("%w: %d", ErrMappingNotFound, id)
+	}
+	if err := logMappingChange(ctx, tx, m.TokenID, m.ExchangeID, m.ExchangeSymbol, "updated", change); err != nil {
+		return SymbolMapping{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return SymbolMapping{}, fmt.Errorf("failed to commit: %w", err)
+	}
+	return GetSymbolMapping(ctx, db, id)
+}
+
+// DeleteSymbolMapping removes mapping id. The audit log keeps what it was.
+func DeleteSymbolMapping(ctx context.Context, db *sql.DB, id int, change MappingChange) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var tokenID int
+	var exchangeID, exchangeSymbol string
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM token_exchange_symbols WHERE id = $1
+		RETURNING token_id, exchange_id, exchange_symbol
+	`, id).Scan(&tokenID, &exchangeID, &exchangeSymbol)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrMappingNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete symbol mapping %d: %w", id, err)
+	}
+	if err := logMappingChange(ctx, tx, tokenID, exchangeID, exchangeSymbol, "deleted", change); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// activeToken returns the symbol and chain of an active token, or
+// ErrTokenNotFound
+func activeToken(ctx context.Context, tx *sql.Tx, id int) (string, sql.NullString, error) {
+	var symbol string
+	var chain sql.NullString
+	err := tx.QueryRowContext(ctx, `SELECT symbol, chain FROM tokens WHERE id = $1 AND is_active = true`, id).
+		Scan(&symbol, &chain)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", chain, fmt.Errorf("%w: %d", ErrTokenNotFound, id)
+	}
+	if err != nil {
+		return "", chain, fmt.Errorf("failed to load token %d: %w", id, err)
+	}
+	return symbol, chain, nil
+}
+
+// logMappingChange records a manual change of a mapping or pair. Pairs are
+// logged under their base token and pair symbol, as delistings are.
+func logMappingChange(ctx context.Context, tx *sql.Tx, tokenID int, exchangeID, symbol, action string, change MappingChange) error {
+	if len(symbol) > 50 {
+		symbol = symbol[:50]
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO mapping_audit_log (token_id, exchange_id, exchange_symbol, mapping_method, confidence_score, action, performed_by, notes)
+		VALUES ($1, $2, $3, 'manual', 1.0, $4, $5, NULLIF($6, ''))
+	`, tokenID, exchangeID, symbol, action, change.PerformedBy, change.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,70 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestSymbolMappingAndPairCRUD(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+	tokens := testutil.SeedTokens(t, conn, "GMT", "STEPN", "USDT")
+	testutil.SeedExchange(t, conn, "gateio")
+	change := MappingChange{PerformedBy: "alice", Notes: "wrong token"}
+
+	m, err := CreateSymbolMapping(ctx, conn, SymbolMapping{TokenID: tokens["GMT"], ExchangeID: "gateio", ExchangeSymbol: "GMT", IsActive: true}, change)
+	if err != nil {
+		t.Fatalf("CreateSymbolMapping: %v", err)
+	}
+	if m.NormalizedSymbol != "GMT" || m.MappingMethod != "manual" || m.NeedsVerification || m.VerifiedBy != "alice" {
+		t.Errorf("created mapping = %+v", m)
+	}
+	if _, err := CreateSymbolMapping(ctx, conn, SymbolMapping{TokenID: tokens["STEPN"], ExchangeID: "gateio", ExchangeSymbol: "GMT"}, change); !errors.Is(err, ErrMappingExists) {
+		t.Errorf("mapping twice: err = %v, want ErrMappingExists", err)
+	}
+
+	m, err = UpdateSymbolMapping(ctx, conn, m.ID, SymbolMapping{TokenID: tokens["STEPN"], ExchangeID: "gateio", ExchangeSymbol: "GMT", IsActive: true}, change)
+	if err != nil || m.TokenID != tokens["STEPN"] || m.TokenSymbol != "STEPN" {
+		t.Fatalf("UpdateSymbolMapping = %+v, %v", m, err)
+	}
+	page, err := ListSymbolMappings(ctx, conn, MappingFilter{ExchangeID: "gateio", TokenID: tokens["STEPN"], Limit: 10})
+	if err != nil || len(page) != 1 || page[0].ID != m.ID {
+		t.Errorf("ListSymbolMappings = %+v, %v", page, err)
+	}
+
+	p, err := CreateTradingPair(ctx, conn, TradingPair{BaseTokenID: tokens["STEPN"], QuoteTokenID: tokens["USDT"],
+		ExchangeID: "gateio", Symbol: "GMT_USDT", InstrumentType: "spot", IsActive: true}, change)
+	if err != nil || p.BaseSymbol != "STEPN" || p.MappingMethod != "manual" {
+		t.Fatalf("CreateTradingPair = %+v, %v", p, err)
+	}
+	if _, err := CreateTradingPair(ctx, conn, TradingPair{BaseTokenID: tokens["GMT"], QuoteTokenID: tokens["USDT"],
+		ExchangeID: "nowhere", Symbol: "GMTUSDT", InstrumentType: "spot"}, change); !errors.Is(err, ErrExchangeNotFound) {
+		t.Errorf("pair on an unregistered exchange: err = %v, want ErrExchangeNotFound", err)
+	}
+	p, err = UpdateTradingPair(ctx, conn, p.ID, TradingPair{BaseTokenID: tokens["STEPN"], QuoteTokenID: tokens["USDT"],
+		ExchangeID: "gateio", Symbol: "GMT_USDT", InstrumentType: "spot", IsActive: false}, change)
+	if err != nil || p.IsActive {
+		t.Fatalf("UpdateTradingPair = %+v, %v", p, err)
+	}
+
+	if err := DeleteTradingPair(ctx, conn, p.ID, change); err != nil {
+		t.Fatalf("DeleteTradingPair: %v", err)
+	}
+	if err := DeleteSymbolMapping(ctx, conn, m.ID, change); err != nil {
+		t.Fatalf("DeleteSymbolMapping: %v", err)
+	}
+	if err := DeleteSymbolMapping(ctx, conn, m.ID, change); !errors.Is(err, ErrMappingNotFound) {
+		t.Errorf("deleting twice: err = %v, want ErrMappingNotFound", err)
+	}
+
+	var logged int
+	conn.QueryRow(`SELECT COUNT(*) FROM mapping_audit_log WHERE exchange_id = 'gateio' AND performed_by = 'alice'`).Scan(&logged)
+	if logged != 6 {
+		t.Errorf("audit log has %d entries, want 6", logged)
+	}
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// OutlierThreshold overrides the outlier thresholds of one token pair. Nil
+// fields keep the configured default.
+type OutlierThreshold struct {
+	BaseTokenID  int
+	QuoteTokenID int
+	BaseSymbol   string
+	QuoteSymbol  string
+	MaxDeviation *float64
+	MaxStdDevs   *float64
+	MinSamples   *int
+	Notes        string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+const outlierThresholdColumns = `
+	o.base_token_id, o.quote_token_id, b.symbol, q.symbol,
+	o.max_deviation, o.max_std_devs, o.min_samples, COALESCE(o.notes, ''),
+	o.created_at, o.updated_at`
+
+const outlierThresholdFrom = `
+	FROM outlier_thresholds o
+	JOIN tokens b ON b.id = o.base_token_id
+	JOIN tokens q ON q.id = o.quote_token_id`
+
+func scanOutlierThreshold(row interface{ Scan(...any) error }) (OutlierThreshold, error) {
+	var t OutlierThreshold
+	var maxDev, maxStd sql.NullFloat64
+	var minSamples sql.NullInt64
+	err := row.Scan(&t.BaseTokenID, &t.QuoteTokenID, &t.BaseSymbol, &t.QuoteSymbol,
+		&maxDev, &maxStd, &minSamples, &t.Notes, &t.CreatedAt, &t.UpdatedAt)
+	if maxDev.Valid {
+		t.MaxDeviation = &maxDev.Float64
+	}
+	if maxStd.Valid {
+		t.MaxStdDevs = &maxStd.Float64
+	}
+	if minSamples.Valid {
+		n := int(minSamples.Int64)
+		t.MinSamples = &n
+	}
+	return t, err
+}
+
+// ListOutlierThresholds returns every pair's outlier threshold override,
+// ordered by base and quote symbol
+func ListOutlierThresholds(ctx context.Context, db *sql.DB) ([]OutlierThreshold, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+outlierThresholdColumns+outlierThresholdFrom+`
+		ORDER BY b.symbol, q.symbol
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outlier thresholds: %w", err)
+	}
+	defer rows.Close()
+
+	var out []OutlierThreshold
+	for rows.Next() {
+		t, err := scanOutlierThreshold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outlier threshold: %w", err)
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// UpsertOutlierThreshold creates or replaces the override of a pair
+func UpsertOutlierThreshold(ctx context.Context, db *sql.DB, t OutlierThreshold) (OutlierThreshold, error) {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO outlier_thresholds (
+			base_token_id, quote_token_id, max_deviation, max_std_devs, min_samples, notes
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		ON CONFLICT (base_token_id, quote_token_id) DO UPDATE SET
+			max_deviation = EXCLUDED.max_deviation,
+			max_std_devs = EXCLUDED.max_std_devs,
+			min_samples = EXCLUDED.min_samples,
+			notes = EXCLUDED.notes
+	`, t.BaseTokenID, t.QuoteTokenID, t.MaxDeviation, t.MaxStdDevs, t.MinSamples, t.Notes)
+	if err != nil {
+		return OutlierThreshold{}, fmt.Errorf("failed to save outlier threshold for %d/%d: %w", t.BaseTokenID, t.QuoteTokenID, err)
+	}
+
+	saved, err := scanOutlierThreshold(db.QueryRowContext(ctx, `
+		SELECT `+outlierThresholdColumns+outlierThresholdFrom+`
+		WHERE o.base_token_id = $1 AND o.quote_token_id = $2
+	`, t.BaseTokenID, t.QuoteTokenID))
+	if err != nil {
+		return OutlierThreshold{}, fmt.Errorf("failed to load outlier threshold for %d/%d: %w", t.BaseTokenID, t.QuoteTokenID, err)
+	}
+	return saved, nil
+}
+
+// DeleteOutlierThreshold removes the override of a pair, which then uses the
+// configured defaults again
+func DeleteOutlierThreshold(ctx context.Context, db *sql.DB, baseTokenID, quoteTokenID int) error {
+	res, err := db.ExecContext(ctx, `
+		DELETE FROM outlier_thresholds WHERE base_token_id = $1 AND quote_token_id = $2
+	`, baseTokenID, quoteTokenID)
+	if err != nil {
+		return fmt.Errorf("failed to delete outlier threshold for %d/%d: %w", baseTokenID, quoteTokenID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %d/%d", ErrOutlierThresholdNotFound, baseTokenID, quoteTokenID)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestOutlierThresholds(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+	ids := testutil.SeedTokens(t, conn, "PEPE", "USDT")
+
+	wide := 0.25
+	saved, err := UpsertOutlierThreshold(ctx, conn, OutlierThreshold{
+		BaseTokenID:  ids["PEPE"],
+		QuoteTokenID: ids["USDT"],
+		MaxDeviation: &wide,
+		Notes:        "thin order books",
+	})
+	if err != nil {
+		t.Fatalf("UpsertOutlierThreshold: %v", err)
+	}
+	if saved.BaseSymbol != "PEPE" || saved.QuoteSymbol != "USDT" || saved.MaxDeviation == nil || *saved.MaxDeviation != wide {
+		t.Fatalf("saved = %+v, want PEPE/USDT with max deviation 0.25", saved)
+	}
+	if saved.MaxStdDevs != nil || saved.MinSamples != nil {
+		t.Errorf("unset fields = %v, %v, want nil", saved.MaxStdDevs, saved.MinSamples)
+	}
+
+	// Replacing clears fields left out
+	samples := 2
+	if _, err := UpsertOutlierThreshold(ctx, conn, OutlierThreshold{
+		BaseTokenID:  ids["PEPE"],
+		QuoteTokenID: ids["USDT"],
+		MinSamples:   &samples,
+	}); err != nil {
+		t.Fatalf("UpsertOutlierThreshold replace: %v", err)
+	}
+	list, err := ListOutlierThresholds(ctx, conn)
+	if err != nil {
+		t.Fatalf("ListOutlierThresholds: %v", err)
+	}
+	if len(list) != 1 || list[0].MaxDeviation != nil || list[0].MinSamples == nil || *list[0].MinSamples != 2 || list[0].Notes != "" {
+		t.Fatalf("list = %+v, want one override with only min samples", list)
+	}
+
+	if err := DeleteOutlierThreshold(ctx, conn, ids["PEPE"], ids["USDT"]); err != nil {
+		t.Fatalf("DeleteOutlierThreshold: %v", err)
+	}
+	if err := DeleteOutlierThreshold(ctx, conn, ids["PEPE"], ids["USDT"]); !errors.Is(err, ErrOutlierThresholdNotFound) {
+		t.Errorf("second delete: err = %v, want ErrOutlierThresholdNotFound", err)
+	}
+}
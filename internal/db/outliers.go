@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// RemapCandidate is an active token an exchange symbol may belong to instead
+// of the token it is mapped to
+type RemapCandidate struct {
+	TokenID int
+	Symbol  string
+	Name    string
+}
+
+// GetRemapCandidates returns the active tokens, other than tokenID, that
+// share its symbol or the normalized symbol of one of its mappings on the
+// exchange. These are the tokens a symbol-based mapping most likely confused
+// it with.
+func GetRemapCandidates(ctx context.Context, db *sql.DB, exchangeID string, tokenID int) ([]RemapCandidate, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.id, t.symbol, t.name
+		FROM tokens t
+		JOIN tokens cur ON cur.id = $2
+		WHERE t.is_active = true
+		  AND t.id <> cur.id
+		  AND (
+		    UPPER(t.symbol) = UPPER(cur.symbol)
+		    OR UPPER(t.symbol) IN (
+		      SELECT UPPER(normalized_symbol) FROM token_exchange_symbols
+		      WHERE exchange_id = $1 AND token_id = $2
+		    )
+		  )
+		ORDER BY t.id
+		LIMIT 20
+	`, exchangeID, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query remap candidates for %d on %s: %w", tokenID, exchangeID, err)
+	}
+	defer rows.Close()
+
+	var out []RemapCandidate
+	for rows.Next() {
+		var c RemapCandidate
+		if err := rows.Scan(&c.TokenID, &c.Symbol, &c.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan remap candidate: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// RemapOutlier fixes an outlier caused by a wrong mapping in one
+// transaction: every symbol of the outlier's base token on its exchange is
+// mapped to tokenID as a verified manual mapping, the exchange's pairs on
+// that base are moved to tokenID, and the outlier is resolved
+func RemapOutlier(ctx context.Context, db *sql.DB, id, tokenID int, resolvedBy, notes string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	exchangeID, baseTokenID, _, err := lockOutlier(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	var tokenSymbol string
+	err = tx.QueryRowContext(ctx, `SELECT symbol FROM tokens WHERE id = $1 AND is_active = true`, tokenID).
+		Scan(&tokenSymbol)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrTokenNotFound, tokenID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load token %d: %w", tokenID, err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE token_exchange_symbols
+		SET token_id = $3,
+		    normalized_symbol = $4,
+		    mapping_method = 'manual',
+		    confidence_score = 1.0,
+		    needs_verification = false,
+		    verified_by = $5,
+		    verified_at = NOW()
+		WHERE exchange_id = $1 AND token_id = $2
+		RETURNING exchange_symbol
+	`, exchangeID, baseTokenID, tokenID, tokenSymbol, resolvedBy)
+	if err != nil {
+		return fmt.Errorf("failed to remap token %d on %s: %w", baseTokenID, exchangeID, err)
+	}
+	var symbols []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan remapped symbol: %w", err)
+		}
+		symbols = append(symbols, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to remap token %d on %s: %w", baseTokenID, exchangeID, err)
+	}
+	if len(symbols) == 0 {
+		return fmt.Errorf("%w: token %d on %s", ErrMappingNotFound, baseTokenID, exchangeID)
+	}
+
+	for _, s := range symbols {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO mapping_audit_log (token_id, exchange_id, exchange_symbol, mapping_method, confidence_score, action, performed_by, notes)
+			VALUES ($1, $2, $3, 'manual', 1.0, 'updated', $4, $5)
+		`, tokenID, exchangeID, s, resolvedBy, outlierNote(fmt.Sprintf("outlier %d: remapped from token %d", id, baseTokenID), notes))
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE trading_pairs SET base_token_id = $3 WHERE exchange_id = $1 AND base_token_id = $2
+	`, exchangeID, baseTokenID, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to move pairs of token %d on %s: %w", baseTokenID, exchangeID, err)
+	}
+
+	if err := closeOutlier(ctx, tx, id, resolvedBy, outlierNote(fmt.Sprintf("remapped to token %d", tokenID), notes)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// DisableOutlierPair fixes an outlier by deactivating the pair it was
+// detected on and resolving it, in one transaction. The pair is not marked
+// delisted, so the poller leaves it inactive.
+func DisableOutlierPair(ctx context.Context, db *sql.DB, id int, resolvedBy, notes string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	exchangeID, baseTokenID, quoteTokenID, err := lockOutlier(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE trading_pairs
+		SET is_active = false, delisted_at = NULL
+		WHERE exchange_id = $1 AND base_token_id = $2 AND quote_token_id = $3
+	`, exchangeID, baseTokenID, quoteTokenID)
+	if err != nil {
+		return fmt.Errorf("failed to disable pair %d/%d on %s: %w", baseTokenID, quoteTokenID, exchangeID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %d/%d on %s", ErrPairNotFound, baseTokenID, quoteTokenID, exchangeID)
+	}
+
+	if err := closeOutlier(ctx, tx, id, resolvedBy, outlierNote("disabled pair", notes)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+func lockOutlier(ctx context.Context, tx *sql.Tx, id int) (exchangeID string, baseTokenID, quoteTokenID int, err error) {
+	var resolved bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT exchange_id, base_token_id, quote_token_id, COALESCE(is_resolved, false)
+		FROM price_outliers WHERE id = $1 FOR UPDATE
+	`, id).Scan(&exchangeID, &baseTokenID, &quoteTokenID, &resolved)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, 0, fmt.Errorf("%w: %d", ErrOutlierNotFound, id)
+	}
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("failed to load outlier %d: %w", id, err)
+	}
+	if resolved {
+		return "", 0, 0, fmt.Errorf("%w: %d", ErrOutlierResolved, id)
+	}
+	return exchangeID, baseTokenID, quoteTokenID, nil
+}
+
+func closeOutlier(ctx context.Context, tx *sql.Tx, id int, by, notes string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE price_outliers
+		SET is_resolved = true, resolved_at = NOW(), resolved_by = $2, resolution_notes = $3
+		WHERE id = $1
+	`, id, by, notes)
+	if err != nil {
+		return fmt.Errorf("failed to resolve outlier %d: %w", id, err)
+	}
+	return nil
+}
+
+// outlierNote prefixes notes with the fix applied
+func outlierNote(fix, notes string) string {
+	if notes == "" {
+		return fix
+	}
+	return fix + ": " + notes
+}
@@ -0,0 +1,88 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func seedOutlier(t *testing.T, conn *sql.DB, exchangeID string, baseTokenID, quoteTokenID int) int {
+	t.Helper()
+	var id int
+	err := conn.QueryRow(`
+		INSERT INTO price_outliers (exchange_id, base_token_id, quote_token_id, exchange_price, average_price, mapping_method)
+		VALUES ($1, $2, $3, 100, 1, 'symbol')
+		RETURNING id
+	`, exchangeID, baseTokenID, quoteTokenID).Scan(&id)
+	if err != nil {
+		t.Fatalf("seeding outlier: %v", err)
+	}
+	return id
+}
+
+func TestOutlierFixes(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+	tokens := testutil.SeedTokens(t, conn, "PEPE", "USDT")
+
+	var bridged int
+	err := conn.QueryRow(`
+		INSERT INTO tokens (symbol, name, chain) VALUES ('PEPE', 'Bridged Pepe', 'bsc') RETURNING id
+	`).Scan(&bridged)
+	if err != nil {
+		t.Fatalf("seeding bridged token: %v", err)
+	}
+	testutil.SeedSymbolMapping(t, conn, tokens["PEPE"], "gate", "PEPE", "PEPE")
+	testutil.SeedTradingPair(t, conn, tokens["PEPE"], tokens["USDT"], "gate", "PEPE_USDT")
+	testutil.SeedTradingPair(t, conn, tokens["USDT"], tokens["PEPE"], "mexc", "USDTPEPE")
+
+	candidates, err := GetRemapCandidates(ctx, conn, "gate", tokens["PEPE"])
+	if err != nil || len(candidates) != 1 || candidates[0].TokenID != bridged {
+		t.Fatalf("GetRemapCandidates = %+v, err %v", candidates, err)
+	}
+
+	remapped := seedOutlier(t, conn, "gate", tokens["PEPE"], tokens["USDT"])
+	if err := RemapOutlier(ctx, conn, remapped, 999999, "alice", ""); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("unknown token: err = %v, want ErrTokenNotFound", err)
+	}
+	if err := RemapOutlier(ctx, conn, remapped, bridged, "alice", "bsc pepe"); err != nil {
+		t.Fatalf("RemapOutlier: %v", err)
+	}
+	if err := RemapOutlier(ctx, conn, remapped, bridged, "alice", ""); !errors.Is(err, ErrOutlierResolved) {
+		t.Errorf("remapping twice: err = %v, want ErrOutlierResolved", err)
+	}
+
+	var tokenID, pairBase int
+	var method string
+	err = conn.QueryRow(`
+		SELECT token_id, mapping_method FROM token_exchange_symbols WHERE exchange_id = 'gate' AND exchange_symbol = 'PEPE'
+	`).Scan(&tokenID, &method)
+	if err != nil || tokenID != bridged || method != "manual" {
+		t.Errorf("mapping = %d %s, err %v", tokenID, method, err)
+	}
+	err = conn.QueryRow(`SELECT base_token_id FROM trading_pairs WHERE exchange_pair_symbol = 'PEPE_USDT'`).Scan(&pairBase)
+	if err != nil || pairBase != bridged {
+		t.Errorf("pair base = %d, err %v", pairBase, err)
+	}
+	var resolved bool
+	if err := conn.QueryRow(`SELECT is_resolved FROM price_outliers WHERE id = $1`, remapped).Scan(&resolved); err != nil || !resolved {
+		t.Errorf("outlier resolved = %v, err %v", resolved, err)
+	}
+
+	disabled := seedOutlier(t, conn, "mexc", tokens["USDT"], tokens["PEPE"])
+	if err := DisableOutlierPair(ctx, conn, disabled, "alice", ""); err != nil {
+		t.Fatalf("DisableOutlierPair: %v", err)
+	}
+	var active bool
+	if err := conn.QueryRow(`SELECT is_active FROM trading_pairs WHERE exchange_pair_symbol = 'USDTPEPE'`).Scan(&active); err != nil || active {
+		t.Errorf("pair active = %v, err %v", active, err)
+	}
+	if err := DisableOutlierPair(ctx, conn, 999999, "alice", ""); !errors.Is(err, ErrOutlierNotFound) {
+		t.Errorf("unknown outlier: err = %v, want ErrOutlierNotFound", err)
+	}
+}
@@ -0,0 +1,350 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// ResolvePairSymbol resolves a pair symbol such as BTCUSDT, BTC-USDT or
+// BTC/USDT to base and quote token IDs. Unseparated symbols are split at
+// every position and the split whose halves are both known tokens wins,
+// preferring USD quotes and then the longest quote. It returns
+// ErrSymbolNotFound when no split matches.
+func ResolvePairSymbol(ctx context.Context, db *sql.DB, symbol string) (baseID, quoteID int, err error) {
+	symbol = strings.ToUpper(symbol)
+
+	type split struct{ base, quote string }
+	var splits []split
+	if i := strings.IndexAny(symbol, "-/_"); i > 0 {
+		splits = append(splits, split{symbol[:i], symbol[i+1:]})
+	} else {
+		for i := len(symbol) - 1; i > 0; i-- {
+			splits = append(splits, split{symbol[:i], symbol[i:]})
+		}
+	}
+
+	candidates := make([]string, 0, len(splits)*2)
+	for _, s := range splits {
+		candidates = append(candidates, s.base, s.quote)
+	}
+
+	// Inactive and archived tokens resolve too, so their history stays
+	// queryable, but an active token sharing the symbol is read last and wins
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, symbol FROM tokens WHERE symbol = ANY($1)
+		ORDER BY is_active, id
+	`, pq.Array(candidates))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query pair tokens: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var sym string
+		if err := rows.Scan(&id, &sym); err != nil {
+			return 0, 0, fmt.Errorf("failed to scan pair token: %w", err)
+		}
+		ids[sym] = id
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	usd := make(map[string]bool, len(USDQuoteSymbols))
+	for _, q := range USDQuoteSymbols {
+		usd[q] = true
+	}
+
+	var best *split
+	for i := range splits {
+		s := &splits[i]
+		if _, ok := ids[s.base]; !ok {
+			continue
+		}
+		if _, ok := ids[s.quote]; !ok {
+			continue
+		}
+		if best == nil || (usd[s.quote] && !usd[best.quote]) ||
+			(usd[s.quote] == usd[best.quote] && len(s.quote) > len(best.quote)) {
+			best = s
+		}
+	}
+	if best == nil {
+		return 0, 0, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+	}
+	return ids[best.base], ids[best.quote], nil
+}
+
+// TradingPair is a trading_pairs row with its tokens' symbols. The order
+// rules and fees are null where the exchange's listing does not give them;
+// fees fall back to the exchange's base tier.
+type TradingPair struct {
+	ID                int
+	ExchangeID        string
+	Symbol            string
+	BaseTokenID       int
+	QuoteTokenID      int
+	BaseSymbol        string
+	QuoteSymbol       string
+	InstrumentType    string // spot, perp, futures or leveraged_token
+	IsActive          bool
+	MappingMethod     string
+	NeedsVerification bool
+	TickSize          decimal.NullDecimal
+	StepSize          decimal.NullDecimal
+	MinQuantity       decimal.NullDecimal
+	MinNotional       decimal.NullDecimal
+	TakerFee          decimal.NullDecimal
+	MakerFee          decimal.NullDecimal
+	MetadataUpdatedAt time.Time // zero until metadata was first written
+}
+
+// GetTradingPair returns the pair an exchange lists under symbol, matched
+// case-insensitively when there is no exact match. Inactive pairs are
+// returned too.
+func GetTradingPair(ctx context.Context, db *sql.DB, exchangeID, symbol string) (TradingPair, error) {
+	p, err := scanPair(db.QueryRowContext(ctx, `
+		SELECT `+pairColumns+`
+		FROM trading_pairs tp
+		JOIN tokens b ON b.id = tp.base_token_id
+		JOIN tokens q ON q.id = tp.quote_token_id
+		LEFT JOIN exchanges e ON e.exchange_id = tp.exchange_id
+		WHERE tp.exchange_id = $1 AND UPPER(tp.exchange_pair_symbol) = UPPER($2)
+		ORDER BY tp.exchange_pair_symbol = $2 DESC
+		LIMIT 1
+	`, exchangeID, symbol))
+	if errors.Is(err, sql.ErrNoRows) {
+		return TradingPair{}, fmt.Errorf("%w: %s on %s", ErrPairNotFound, symbol, exchangeID)
+	}
+	if err != nil {
+		return TradingPair{}, fmt.Errorf("failed to load pair %s on %s: %w", symbol, exchangeID, err)
+	}
+	return p, nil
+}
+
+const pairColumns = `
+	tp.id, tp.exchange_id, tp.exchange_pair_symbol, tp.base_token_id, tp.quote_token_id,
+	b.symbol, q.symbol, tp.instrument_type, COALESCE(tp.is_active, false), COALESCE(tp.mapping_method, ''),
+	COALESCE(tp.needs_verification, false), tp.tick_size, tp.step_size, tp.min_quantity,
+	tp.min_notional, COALESCE(tp.taker_fee, e.taker_fee), COALESCE(tp.maker_fee, e.maker_fee),
+	tp.metadata_updated_at`
+
+func scanPair(row interface{ Scan(...any) error }) (TradingPair, error) {
+	var p TradingPair
+	var metadataAt sql.NullTime
+	err := row.Scan(&p.ID, &p.ExchangeID, &p.Symbol, &p.BaseTokenID, &p.QuoteTokenID,
+		&p.BaseSymbol, &p.QuoteSymbol, &p.InstrumentType, &p.IsActive, &p.MappingMethod, &p.NeedsVerification,
+		&p.TickSize, &p.StepSize, &p.MinQuantity, &p.MinNotional, &p.TakerFee, &p.MakerFee, &metadataAt)
+	p.MetadataUpdatedAt = metadataAt.Time
+	return p, err
+}
+
+// ListTradingPairs returns a page of trading pairs by ID, active or not
+func ListTradingPairs(ctx context.Context, db *sql.DB, f MappingFilter) ([]TradingPair, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+pairColumns+`
+		FROM trading_pairs tp
+		JOIN tokens b ON b.id = tp.base_token_id
+		JOIN tokens q ON q.id = tp.quote_token_id
+		LEFT JOIN exchanges e ON e.exchange_id = tp.exchange_id
+		WHERE ($1 = '' OR tp.exchange_id = $1) AND ($2 = 0 OR $2 IN (tp.base_token_id, tp.quote_token_id)) AND tp.id > $3
+		ORDER BY tp.id
+		LIMIT $4
+	`, f.ExchangeID, f.TokenID, f.AfterID, f.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trading pairs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TradingPair
+	for rows.Next() {
+		p, err := scanPair(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trading pair: %w", err)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// GetTradingPairByID returns a trading pair by ID, active or not
+func GetTradingPairByID(ctx context.Context, db *sql.DB, id int) (TradingPair, error) {
+	p, err := scanPair(db.QueryRowContext(ctx, `
+		SELECT `+pairColumns+`
+		FROM trading_pairs tp
+		JOIN tokens b ON b.id = tp.base_token_id
+		JOIN tokens q ON q.id = tp.quote_token_id
+		LEFT JOIN exchanges e ON e.exchange_id = tp.exchange_id
+		WHERE tp.id = $1
+	`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return TradingPair{}, fmt.Errorf("%w: %d", ErrPairNotFound, id)
+	}
+	if err != nil {
+		return TradingPair{}, fmt.Errorf("failed to load pair %d: %w", id, err)
+	}
+	return p, nil
+}
+
+// CreateTradingPair adds a pair as a verified manual one, as
+// Resolver.AddTradingPair does for discovered ones. Both tokens must be
+// active and the exchange registered. It returns ErrPairExists when the
+// exchange already has the pair symbol.
+func CreateTradingPair(ctx context.Context, db *sql.DB, p TradingPair, change MappingChange) (TradingPair, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return TradingPair{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := activePairTokens(ctx, tx, p); err != nil {
+		return TradingPair{}, err
+	}
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO trading_pairs (
+			base_token_id, quote_token_id, exchange_id, exchange_pair_symbol, instrument_type, is_active,
+			mapping_method, confidence_score, needs_verification, verified_by, verified_at
+		) VALUES ($1, $2, $3, $4, $5, $6, 'manual', 1.0, false, $7, NOW())
+		RETURNING id
+	`, p.BaseTokenID, p.QuoteTokenID, p.ExchangeID, p.Symbol, p.InstrumentType, p.IsActive, change.PerformedBy).Scan(&id)
+	if err := pairWriteError(err, p); err != nil {
+		return TradingPair{}, fmt.Errorf("failed to add pair %s on %s: %w", p.Symbol, p.ExchangeID, err)
+	}
+	if err := logMappingChange(ctx, tx, p.BaseTokenID, p.ExchangeID, p.Symbol, "created", change); err != nil {
+		return TradingPair{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return TradingPair{}, fmt.Errorf("failed to commit: %w", err)
+	}
+	return GetTradingPairByID(ctx, db, id)
+}
+
+// UpdateTradingPair replaces the tokens, exchange, symbol, instrument type and
+// state of pair id, making it a verified manual pair. Order rules, fees and
+// volumes are kept.
+func UpdateTradingPair(ctx context.Context, db *sql.DB, id int, p TradingPair, change MappingChange) (TradingPair, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return TradingPair{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := activePairTokens(ctx, tx, p); err != nil {
+		return TradingPair{}, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE trading_pairs SET
+			base_token_id = $2, quote_token_id = $3, exchange_id = $4, exchange_pair_symbol = $5,
+			instrument_type = $6, is_active = $7,
+			mapping_method = 'manual', confidence_score = 1.0, needs_verification = false,
+			verified_by = $8, verified_at = NOW(),
+			delisted_at = CASE WHEN $7 THEN NULL ELSE delisted_at END,
+			archived_at = CASE WHEN $7 THEN NULL ELSE archived_at END
+		WHERE id = $1
+	`, id, p.BaseTokenID, p.QuoteTokenID, p.ExchangeID, p.Symbol, p.InstrumentType, p.IsActive, change.PerformedBy)
+	if err := pairWriteError(err, p); err != nil {
+		return TradingPair{}, fmt.Errorf("failed to update pair %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return TradingPair{}, fmt.Errorf("%w: %d", ErrPairNotFound, id)
+	}
+	if err := logMappingChange(ctx, tx, p.BaseTokenID, p.ExchangeID, p.Symbol, "updated", change); err != nil {
+		return TradingPair{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return TradingPair{}, fmt.Errorf("failed to commit: %w", err)
+	}
+	return GetTradingPairByID(ctx, db, id)
+}
+
+// DeleteTradingPair removes pair id. The audit log keeps what it was.
+func DeleteTradingPair(ctx context.Context, db *sql.DB, id int, change MappingChange) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var baseTokenID int
+	var exchangeID, symbol string
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM trading_pairs WHERE id = $1
+		RETURNING base_token_id, exchange_id, exchange_pair_symbol
+	`, id).Scan(&baseTokenID, &exchangeID, &symbol)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrPairNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete pair %d: %w", id, err)
+	}
+	if err := logMappingChange(ctx, tx, baseTokenID, exchangeID, symbol, "deleted", change); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// activePairTokens returns ErrTokenNotFound unless both tokens of p are active
+func activePairTokens(ctx context.Context, tx *sql.Tx, p TradingPair) error {
+	for _, id := range []int{p.BaseTokenID, p.QuoteTokenID} {
+		if _, _, err := activeToken(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pairWriteError turns the constraint violations of writing p into
+// ErrPairExists and ErrExchangeNotFound
+func pairWriteError(err error, p TradingPair) error {
+	switch {
+	case isUniqueViolation(err):
+		return fmt.Errorf("%w: %s on %s", ErrPairExists, p.Symbol, p.ExchangeID)
+	case IsUnregisteredExchange(err):
+		return fmt.Errorf("%w: %s", ErrExchangeNotFound, p.ExchangeID)
+	}
+	return err
+}
+
+// GetPairTakerFees returns the taker fee of each exchange for a pair, as a
+// fraction. A fee from the pair's listing wins over the exchange's base tier,
+// and the highest is taken when an exchange lists the pair more than once.
+// Exchanges with no known fee are left out.
+func GetPairTakerFees(ctx context.Context, db *sql.DB, baseID, quoteID int) (map[string]decimal.Decimal, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.exchange_id, COALESCE(MAX(tp.taker_fee), e.taker_fee)
+		FROM exchanges e
+		LEFT JOIN trading_pairs tp
+		  ON tp.exchange_id = e.exchange_id AND tp.base_token_id = $1 AND tp.quote_token_id = $2
+		 AND tp.instrument_type = 'spot'
+		GROUP BY e.exchange_id, e.taker_fee
+		HAVING COALESCE(MAX(tp.taker_fee), e.taker_fee) IS NOT NULL
+	`, baseID, quoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pair fees: %w", err)
+	}
+	defer rows.Close()
+
+	fees := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var exchangeID string
+		var fee decimal.Decimal
+		if err := rows.Scan(&exchangeID, &fee); err != nil {
+			return nil, fmt.Errorf("failed to scan pair fee: %w", err)
+		}
+		fees[exchangeID] = fee
+	}
+	return fees, rows.Err()
+}
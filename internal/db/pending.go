@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Pending mapping statuses
+const (
+	PendingStatusPending  = "pending"
+	PendingStatusResolved = "resolved"
+	PendingStatusIgnored  = "ignored"
+)
+
+// MappingCandidate is a token suggested for a pending mapping
+type MappingCandidate struct {
+	TokenID int     `json:"token_id"`
+	Symbol  string  `json:"symbol"`
+	Name    string  `json:"name"`
+	Score   float64 `json:"score"`
+	Reason  string  `json:"reason"`
+}
+
+// PendingMapping is an exchange symbol queued for mapping to a token by hand
+type PendingMapping struct {
+	ID              int
+	ExchangeID      string
+	ExchangeSymbol  string
+	Slug            string
+	Name            string
+	MarketPair      string
+	Candidates      []MappingCandidate
+	Status          string
+	ResolvedTokenID int // 0 unless resolved
+	ResolvedBy      string
+	ResolvedAt      time.Time // zero unless resolved or ignored
+	Notes           string
+	SeenCount       int
+	FirstSeenAt     time.Time
+	LastSeenAt      time.Time
+}
+
+// UpsertPendingMappings queues unmapped exchange symbols and returns how many
+// were new. Symbols already queued get their details and candidates refreshed
+// and their seen count bumped; their status is left alone, so an ignored
+// symbol stays ignored.
+func UpsertPendingMappings(ctx context.Context, db *sql.DB, mappings []PendingMapping) (added int, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO pending_mappings (exchange_id, exchange_symbol, slug, name, market_pair, candidates)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6)
+		ON CONFLICT (exchange_id, exchange_symbol) DO UPDATE SET
+			slug = EXCLUDED.slug,
+			name = EXCLUDED.name,
+			market_pair = EXCLUDED.market_pair,
+			candidates = EXCLUDED.candidates,
+			seen_count = pending_mappings.seen_count + 1,
+			last_seen_at = NOW()
+		RETURNING xmax = 0
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare pending mapping upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, m := range mappings {
+		candidates := m.Candidates
+		if candidates == nil {
+			candidates = []MappingCandidate{}
+		}
+		candidatesJSON, err := json.Marshal(candidates)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode candidates: %w", err)
+		}
+
+		var inserted bool
+		if err := stmt.QueryRowContext(ctx, m.ExchangeID, m.ExchangeSymbol, m.Slug, m.Name, m.MarketPair, candidatesJSON).Scan(&inserted); err != nil {
+			return 0, fmt.Errorf("failed to queue %s on %s: %w", m.ExchangeSymbol, m.ExchangeID, err)
+		}
+		if inserted {
+			added++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit pending mappings: %w", err)
+	}
+	return added, nil
+}
+
+// PendingFilter narrows ListPendingMappings
+type PendingFilter struct {
+	Status     string // empty for any status
+	ExchangeID string // empty for all exchanges
+	AfterID    int    // only entries with a larger ID, for paging through the queue
+	Limit      int
+}
+
+// ListPendingMappings returns queued mappings in ID order
+func ListPendingMappings(ctx context.Context, db *sql.DB, f PendingFilter) ([]PendingMapping, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, exchange_id, exchange_symbol, COALESCE(slug, ''), COALESCE(name, ''),
+		       COALESCE(market_pair, ''), candidates, status, COALESCE(resolved_token_id, 0),
+		       COALESCE(resolved_by, ''), resolved_at, COALESCE(notes, ''), seen_count,
+		       first_seen_at, last_seen_at
+		FROM pending_mappings
+		WHERE ($1 = '' OR status = $1)
+		  AND ($2 = '' OR exchange_id = $2)
+		  AND id > $3
+		ORDER BY id
+		LIMIT $4
+	`, f.Status, f.ExchangeID, f.AfterID, f.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending mappings: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PendingMapping
+	for rows.Next() {
+		var m PendingMapping
+		var candidatesJSON []byte
+		var resolvedAt sql.NullTime
+		if err := rows.Scan(&m.ID, &m.ExchangeID, &m.ExchangeSymbol, &m.Slug, &m.Name, &m.MarketPair,
+			&candidatesJSON, &m.Status, &m.ResolvedTokenID, &m.ResolvedBy, &resolvedAt, &m.Notes,
+			&m.SeenCount, &m.FirstSeenAt, &m.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pending mapping: %w", err)
+		}
+		if err := json.Unmarshal(candidatesJSON, &m.Candidates); err != nil {
+			return nil, fmt.Errorf("failed to decode candidates of pending mapping %d: %w", m.ID, err)
+		}
+		m.ResolvedAt = resolvedAt.Time
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// ResolvePendingMapping maps a pending exchange symbol to tokenID as a
+// verified manual mapping, records it in the audit log and closes the queue
+// entry, all in one transaction
+func ResolvePendingMapping(ctx context.Context, db *sql.DB, id, tokenID int, resolvedBy, notes string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	exchangeID, exchangeSymbol, err := lockPendingMapping(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	var tokenSymbol string
+	var chain sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT symbol, chain FROM tokens WHERE id = $1 AND is_active = true`, tokenID).
+		Scan(&tokenSymbol, &chain)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrTokenNotFound, tokenID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load token %d: %w", tokenID, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO token_exchange_symbols (
+			token_id, exchange_id, exchange_symbol, normalized_symbol, chain,
+			mapping_method, confidence_score, needs_verification, verified_by, verified_at
+		) VALUES ($1, $2, $3, $4, $5, 'manual', 1.0, false, $6, NOW())
+		ON CONFLICT (exchange_id, exchange_symbol) DO UPDATE SET
+			token_id = EXCLUDED.token_id,
+			normalized_symbol = EXCLUDED.normalized_symbol,
+			chain = EXCLUDED.chain,
+			mapping_method = 'manual',
+			confidence_score = 1.0,
+			needs_verification = false,
+			verified_by = EXCLUDED.verified_by,
+			verified_at = NOW(),
+			is_active = true,
+			updated_at = NOW()
+	`, tokenID, exchangeID, exchangeSymbol, tokenSymbol, chain, resolvedBy)
+	if err != nil {
+		return fmt.Errorf("failed to map %s on %s: %w", exchangeSymbol, exchangeID, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO mapping_audit_log (token_id, exchange_id, exchange_symbol, mapping_method, confidence_score, action, performed_by, notes)
+		VALUES ($1, $2, $3, 'manual', 1.0, 'created', $4, $5)
+	`, tokenID, exchangeID, exchangeSymbol, resolvedBy, fmt.Sprintf("pending mapping %d: %s", id, notes))
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if err := closePendingMapping(ctx, tx, id, PendingStatusResolved, tokenID, resolvedBy, notes); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// IgnorePendingMapping closes a queue entry without mapping it, for symbols
+// that are not worth tracking
+func IgnorePendingMapping(ctx context.Context, db *sql.DB, id int, ignoredBy, notes string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, _, err := lockPendingMapping(ctx, tx, id); err != nil {
+		return err
+	}
+	if err := closePendingMapping(ctx, tx, id, PendingStatusIgnored, 0, ignoredBy, notes); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// lockPendingMapping locks a queue entry that is still pending
+func lockPendingMapping(ctx context.Context, tx *sql.Tx, id int) (exchangeID, exchangeSymbol string, err error) {
+	var status string
+	err = tx.QueryRowContext(ctx, `
+		SELECT exchange_id, exchange_symbol, status FROM pending_mappings WHERE id = $1 FOR UPDATE
+	`, id).Scan(&exchangeID, &exchangeSymbol, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", fmt.Errorf("%w: %d", ErrPendingMappingNotFound, id)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load pending mapping %d: %w", id, err)
+	}
+	if status != PendingStatusPending {
+		return "", "", fmt.Errorf("%w: %d is %s", ErrPendingMappingClosed, id, status)
+	}
+	return exchangeID, exchangeSymbol, nil
+}
+
+func closePendingMapping(ctx context.Context, tx *sql.Tx, id int, status string, tokenID int, by, notes string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE pending_mappings
+		SET status = $2, resolved_token_id = NULLIF($3, 0), resolved_by = $4, resolved_at = NOW(), notes = NULLIF($5, '')
+		WHERE id = $1
+	`, id, status, tokenID, by, notes)
+	if err != nil {
+		return fmt.Errorf("failed to close pending mapping %d: %w", id, err)
+	}
+	return nil
+}
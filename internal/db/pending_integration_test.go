@@ -0,0 +1,86 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestPendingMappings(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+	tokens := testutil.SeedTokens(t, conn, "TON", "TKM")
+
+	queue := []PendingMapping{
+		{ExchangeID: "gate", ExchangeSymbol: "TON", Slug: "the-open-network", MarketPair: "TON/USDT",
+			Candidates: []MappingCandidate{{TokenID: tokens["TON"], Symbol: "TON", Score: 0.5, Reason: "symbol"}}},
+		{ExchangeID: "gate", ExchangeSymbol: "TKM"},
+		{ExchangeID: "mexc", ExchangeSymbol: "TON"},
+	}
+	added, err := UpsertPendingMappings(ctx, conn, queue)
+	if err != nil || added != 3 {
+		t.Fatalf("first upsert: added %d, err %v", added, err)
+	}
+	added, err = UpsertPendingMappings(ctx, conn, queue[:2])
+	if err != nil || added != 0 {
+		t.Fatalf("second upsert: added %d, err %v", added, err)
+	}
+
+	page, err := ListPendingMappings(ctx, conn, PendingFilter{Status: PendingStatusPending, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListPendingMappings: %v", err)
+	}
+	if len(page) != 2 || page[0].ExchangeSymbol != "TON" || page[0].SeenCount != 2 ||
+		len(page[0].Candidates) != 1 || page[0].Candidates[0].TokenID != tokens["TON"] {
+		t.Fatalf("first page = %+v", page)
+	}
+	ton, tkm := page[0], page[1]
+
+	if err := ResolvePendingMapping(ctx, conn, ton.ID, tokens["TON"], "alice", "checked the contract"); err != nil {
+		t.Fatalf("ResolvePendingMapping: %v", err)
+	}
+	if err := ResolvePendingMapping(ctx, conn, ton.ID, tokens["TON"], "alice", ""); !errors.Is(err, ErrPendingMappingClosed) {
+		t.Errorf("resolving twice: err = %v, want ErrPendingMappingClosed", err)
+	}
+	if err := ResolvePendingMapping(ctx, conn, tkm.ID, 999999, "alice", ""); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("unknown token: err = %v, want ErrTokenNotFound", err)
+	}
+	if err := IgnorePendingMapping(ctx, conn, tkm.ID, "alice", "not worth tracking"); err != nil {
+		t.Fatalf("IgnorePendingMapping: %v", err)
+	}
+	if err := IgnorePendingMapping(ctx, conn, 999999, "alice", ""); !errors.Is(err, ErrPendingMappingNotFound) {
+		t.Errorf("unknown entry: err = %v, want ErrPendingMappingNotFound", err)
+	}
+
+	var tokenID int
+	var method string
+	var needsVerification bool
+	err = conn.QueryRow(`
+		SELECT token_id, mapping_method, needs_verification FROM token_exchange_symbols
+		WHERE exchange_id = 'gate' AND exchange_symbol = 'TON'
+	`).Scan(&tokenID, &method, &needsVerification)
+	if err != nil || tokenID != tokens["TON"] || method != "manual" || needsVerification {
+		t.Errorf("mapping = %d %s %v, err %v", tokenID, method, needsVerification, err)
+	}
+	var audits int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM mapping_audit_log WHERE exchange_symbol = 'TON' AND performed_by = 'alice'`).Scan(&audits); err != nil || audits != 1 {
+		t.Errorf("audit rows = %d, err %v", audits, err)
+	}
+
+	// Closed entries stay closed when the mapper sees them again
+	if _, err := UpsertPendingMappings(ctx, conn, queue[1:2]); err != nil {
+		t.Fatalf("third upsert: %v", err)
+	}
+	remaining, err := ListPendingMappings(ctx, conn, PendingFilter{Status: PendingStatusPending, Limit: 10})
+	if err != nil || len(remaining) != 1 || remaining[0].ExchangeID != "mexc" {
+		t.Errorf("remaining = %+v, err %v", remaining, err)
+	}
+	ignored, err := ListPendingMappings(ctx, conn, PendingFilter{Status: PendingStatusIgnored, ExchangeID: "gate", Limit: 10})
+	if err != nil || len(ignored) != 1 || ignored[0].ResolvedBy != "alice" || ignored[0].ResolvedAt.IsZero() {
+		t.Errorf("ignored = %+v, err %v", ignored, err)
+	}
+}
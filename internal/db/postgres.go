@@ -171,7 +171,7 @@ func InsertInitialTokens(db *sql.DB) error {
 		query := `
 			INSERT INTO tokens (symbol, name, category, description)
 			VALUES ($1, $2, $3, $4)
-			ON CONFLICT (symbol) DO UPDATE SET
+			ON CONFLICT (symbol) WHERE chain IS NULL DO UPDATE SET
 				name = EXCLUDED.name,
 				category = EXCLUDED.category,
 				description = EXCLUDED.description,
@@ -204,7 +204,7 @@ func GetTokenBySymbol(db *sql.DB, symbol string) (*Token, error) {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("token not found: %s", symbol)
+			return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
 		}
 		return nil, fmt.Errorf("failed to get token: %w", err)
 	}
@@ -264,7 +264,7 @@ func UpdateTokenMarketData(db *sql.DB, symbol string, marketCap, circulatingSupp
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no token found with symbol: %s", symbol)
+		return fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
 	}
 
 	return nil
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// USDQuoteSymbols are the quote tokens treated as USD when pricing tokens, in
+// order of preference
+var USDQuoteSymbols = []string{"USDT", "USD", "USDC"}
+
+// GetUSDQuoteTokens maps each of USDQuoteSymbols that exists to its token ID
+func GetUSDQuoteTokens(ctx context.Context, db *sql.DB) (map[string]int, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, symbol FROM tokens WHERE symbol = ANY($1)`, pq.Array(USDQuoteSymbols))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quote tokens: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var symbol string
+		if err := rows.Scan(&id, &symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan quote token: %w", err)
+		}
+		ids[symbol] = id
+	}
+	return ids, rows.Err()
+}
+
+// GetUSDQuoteTokenIDs returns the token IDs of USDQuoteSymbols
+func GetUSDQuoteTokenIDs(ctx context.Context, db *sql.DB) ([]int, error) {
+	bySymbol, err := GetUSDQuoteTokens(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(bySymbol))
+	for _, id := range bySymbol {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// GetTokenIDsBySymbol maps each of symbols that names an active token to its
+// ID, preferring the chain-agnostic token and then the highest-ranked one
+// when several share a symbol. Unknown symbols are left out.
+func GetTokenIDsBySymbol(ctx context.Context, db *sql.DB, symbols []string) (map[string]int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT ON (symbol) symbol, id
+		FROM tokens
+		WHERE symbol = ANY($1) AND is_active = true
+		ORDER BY symbol, chain IS NULL DESC, market_cap_rank ASC NULLS LAST, id ASC
+	`, pq.Array(symbols))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tokens: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int, len(symbols))
+	for rows.Next() {
+		var symbol string
+		var id int
+		if err := rows.Scan(&symbol, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		ids[symbol] = id
+	}
+	return ids, rows.Err()
+}
+
+// GetTokenSymbols maps each of ids that names a token to its symbol
+func GetTokenSymbols(ctx context.Context, db *sql.DB, ids []int) (map[int]string, error) {
+	tokenIDs := make([]int64, len(ids))
+	for i, id := range ids {
+		tokenIDs[i] = int64(id)
+	}
+	rows, err := db.QueryContext(ctx, `SELECT id, symbol FROM tokens WHERE id = ANY($1)`, pq.Array(tokenIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query token symbols: %w", err)
+	}
+	defer rows.Close()
+
+	symbols := make(map[int]string, len(ids))
+	for rows.Next() {
+		var id int
+		var symbol string
+		if err := rows.Scan(&id, &symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan token symbol: %w", err)
+		}
+		symbols[id] = symbol
+	}
+	return symbols, rows.Err()
+}
@@ -0,0 +1,254 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UnmappedSymbol is a pair an exchange quoted whose base or quote symbol has
+// no mapping to a token
+type UnmappedSymbol struct {
+	ExchangeID   string    `json:"exchange_id"`
+	Symbol       string    `json:"symbol"`
+	BaseSymbol   string    `json:"base_symbol"`
+	QuoteSymbol  string    `json:"quote_symbol"`
+	MissingBase  bool      `json:"missing_base"`
+	MissingQuote bool      `json:"missing_quote"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+}
+
+// StaleMapping is an active mapping no ticker has used recently.
+// LastSeenAt is nil if none has since tracking began.
+type StaleMapping struct {
+	ID             int        `json:"id"`
+	ExchangeID     string     `json:"exchange_id"`
+	ExchangeSymbol string     `json:"exchange_symbol"`
+	TokenID        int        `json:"token_id"`
+	TokenSymbol    string     `json:"token_symbol"`
+	MappingMethod  string     `json:"mapping_method"`
+	LastSeenAt     *time.Time `json:"last_seen_at"`
+}
+
+// DuplicateMapping is an exchange symbol mapped more than once on an
+// exchange under different letter cases
+type DuplicateMapping struct {
+	ExchangeID      string   `json:"exchange_id"`
+	ExchangeSymbol  string   `json:"exchange_symbol"`
+	MappingIDs      []int    `json:"mapping_ids"`
+	ExchangeSymbols []string `json:"exchange_symbols"`
+	TokenIDs        []int    `json:"token_ids"`
+}
+
+// ReconciliationFindings are the results of comparing exchange symbols with
+// token_exchange_symbols
+type ReconciliationFindings struct {
+	Unmapped   []UnmappedSymbol   `json:"unmapped"`
+	Stale      []StaleMapping     `json:"stale"`
+	Duplicates []DuplicateMapping `json:"duplicates"`
+}
+
+// ReconciliationReport is a stored reconciliation run
+type ReconciliationReport struct {
+	ID             int
+	UnmappedCount  int
+	StaleCount     int
+	DuplicateCount int
+	Findings       ReconciliationFindings
+	DeliveredAt    *time.Time
+	DeliveryError  string
+	CreatedAt      time.Time
+}
+
+// SeenSymbol records that an exchange quoted a symbol at At
+type SeenSymbol struct {
+	ExchangeID string
+	Symbol     string
+	At         time.Time
+}
+
+// MarkSymbolsSeen moves the last_seen_at of each symbol's mapping forward to
+// when it was seen, and returns the number of mappings updated
+func MarkSymbolsSeen(ctx context.Context, db *sql.DB, seen []SeenSymbol) (int, error) {
+	if len(seen) == 0 {
+		return 0, nil
+	}
+	exchanges := make([]string, len(seen))
+	symbols := make([]string, len(seen))
+	times := make([]time.Time, len(seen))
+	for i, s := range seen {
+		exchanges[i], symbols[i], times[i] = s.ExchangeID, s.Symbol, s.At.UTC()
+	}
+
+	res, err := db.ExecContext(ctx, `
+		UPDATE token_exchange_symbols tes
+		SET last_seen_at = v.seen
+		FROM unnest($1::text[], $2::text[], $3::timestamp[]) AS v(exchange_id, exchange_symbol, seen)
+		WHERE tes.exchange_id = v.exchange_id
+		  AND tes.exchange_symbol = v.exchange_symbol
+		  AND (tes.last_seen_at IS NULL OR tes.last_seen_at < v.seen)
+	`, pq.Array(exchanges), pq.Array(symbols), pq.Array(times))
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark symbols seen: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// GetStaleMappings returns active mappings last seen, or created if never
+// seen, before since, oldest first
+func GetStaleMappings(ctx context.Context, db *sql.DB, since time.Time, limit int) ([]StaleMapping, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT tes.id, tes.exchange_id, tes.exchange_symbol, tes.token_id, t.symbol,
+		       COALESCE(tes.mapping_method, 'manual'), tes.last_seen_at
+		FROM token_exchange_symbols tes
+		JOIN tokens t ON t.id = tes.token_id
+		WHERE tes.is_active = true
+		  AND COALESCE(tes.last_seen_at, tes.created_at) < $1
+		ORDER BY COALESCE(tes.last_seen_at, tes.created_at), tes.id
+		LIMIT $2
+	`, since.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stale mappings: %w", err)
+	}
+	defer rows.Close()
+
+	var out []StaleMapping
+	for rows.Next() {
+		var m StaleMapping
+		var lastSeen sql.NullTime
+		if err := rows.Scan(&m.ID, &m.ExchangeID, &m.ExchangeSymbol, &m.TokenID, &m.TokenSymbol,
+			&m.MappingMethod, &lastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan stale mapping: %w", err)
+		}
+		if lastSeen.Valid {
+			m.LastSeenAt = &lastSeen.Time
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// GetDuplicateMappings returns the active exchange symbols mapped more than
+// once on an exchange when letter case is ignored
+func GetDuplicateMappings(ctx context.Context, db *sql.DB) ([]DuplicateMapping, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT exchange_id, UPPER(exchange_symbol),
+		       array_agg(id ORDER BY id), array_agg(exchange_symbol ORDER BY id), array_agg(token_id ORDER BY id)
+		FROM token_exchange_symbols
+		WHERE is_active = true
+		GROUP BY exchange_id, UPPER(exchange_symbol)
+		HAVING COUNT(*) > 1
+		ORDER BY exchange_id, UPPER(exchange_symbol)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicate mappings: %w", err)
+	}
+	defer rows.Close()
+
+	var out []DuplicateMapping
+	for rows.Next() {
+		var d DuplicateMapping
+		var ids, tokenIDs pq.Int64Array
+		if err := rows.Scan(&d.ExchangeID, &d.ExchangeSymbol, &ids, pq.Array(&d.ExchangeSymbols), &tokenIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate mapping: %w", err)
+		}
+		for _, id := range ids {
+			d.MappingIDs = append(d.MappingIDs, int(id))
+		}
+		for _, id := range tokenIDs {
+			d.TokenIDs = append(d.TokenIDs, int(id))
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// InsertReconciliationReport stores the findings of a reconciliation run
+func InsertReconciliationReport(ctx context.Context, db *sql.DB, f ReconciliationFindings) (ReconciliationReport, error) {
+	if f.Unmapped == nil {
+		f.Unmapped = []UnmappedSymbol{}
+	}
+	if f.Stale == nil {
+		f.Stale = []StaleMapping{}
+	}
+	if f.Duplicates == nil {
+		f.Duplicates = []DuplicateMapping{}
+	}
+	body, err := json.Marshal(f)
+	if err != nil {
+		return ReconciliationReport{}, fmt.Errorf("failed to encode reconciliation report: %w", err)
+	}
+
+	r := ReconciliationReport{
+		UnmappedCount:  len(f.Unmapped),
+		StaleCount:     len(f.Stale),
+		DuplicateCount: len(f.Duplicates),
+		Findings:       f,
+	}
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO mapping_reconciliation_reports (unmapped_count, stale_count, duplicate_count, report)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, r.UnmappedCount, r.StaleCount, r.DuplicateCount, body).Scan(&r.ID, &r.CreatedAt)
+	if err != nil {
+		return ReconciliationReport{}, fmt.Errorf("failed to store reconciliation report: %w", err)
+	}
+	return r, nil
+}
+
+// SetReconciliationDelivery records the outcome of sending a report.
+// deliveryErr is nil when it was delivered.
+func SetReconciliationDelivery(ctx context.Context, db *sql.DB, id int, deliveryErr error) error {
+	var query string
+	var args []any
+	if deliveryErr == nil {
+		query = `UPDATE mapping_reconciliation_reports SET delivered_at = NOW(), delivery_error = NULL WHERE id = $1`
+		args = []any{id}
+	} else {
+		query = `UPDATE mapping_reconciliation_reports SET delivery_error = $2 WHERE id = $1`
+		args = []any{id, deliveryErr.Error()}
+	}
+	if _, err := db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to record delivery of reconciliation report %d: %w", id, err)
+	}
+	return nil
+}
+
+// ListReconciliationReports returns the most recent reports, newest first
+func ListReconciliationReports(ctx context.Context, db *sql.DB, limit int) ([]ReconciliationReport, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, unmapped_count, stale_count, duplicate_count, report,
+		       delivered_at, COALESCE(delivery_error, ''), created_at
+		FROM mapping_reconciliation_reports
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reconciliation reports: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ReconciliationReport
+	for rows.Next() {
+		var r ReconciliationReport
+		var body []byte
+		var delivered sql.NullTime
+		if err := rows.Scan(&r.ID, &r.UnmappedCount, &r.StaleCount, &r.DuplicateCount, &body,
+			&delivered, &r.DeliveryError, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reconciliation report: %w", err)
+		}
+		if err := json.Unmarshal(body, &r.Findings); err != nil {
+			return nil, fmt.Errorf("failed to decode reconciliation report %d: %w", r.ID, err)
+		}
+		if delivered.Valid {
+			r.DeliveredAt = &delivered.Time
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,72 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestReconciliation(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+	tokens := testutil.SeedTokens(t, conn, "BTC", "PEPE")
+	testutil.SeedSymbolMapping(t, conn, tokens["BTC"], "gate", "BTC", "BTC")
+	testutil.SeedSymbolMapping(t, conn, tokens["PEPE"], "gate", "PEPE", "PEPE")
+	testutil.SeedSymbolMapping(t, conn, tokens["BTC"], "gate", "pepe", "PEPE")
+
+	now := time.Now()
+	n, err := MarkSymbolsSeen(ctx, conn, []SeenSymbol{
+		{ExchangeID: "gate", Symbol: "BTC", At: now},
+		{ExchangeID: "gate", Symbol: "UNKNOWN", At: now},
+	})
+	if err != nil || n != 1 {
+		t.Fatalf("MarkSymbolsSeen = %d, %v; want 1 mapping updated", n, err)
+	}
+
+	stale, err := GetStaleMappings(ctx, conn, now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("GetStaleMappings: %v", err)
+	}
+	if len(stale) != 3 {
+		t.Fatalf("stale = %+v, want every mapping when since is in the future", stale)
+	}
+	if stale[len(stale)-1].ExchangeSymbol != "BTC" || stale[len(stale)-1].LastSeenAt == nil {
+		t.Errorf("the mapping just seen should come last with last_seen_at set: %+v", stale)
+	}
+	if stale, _ := GetStaleMappings(ctx, conn, now.Add(-time.Hour), 10); len(stale) != 0 {
+		t.Errorf("nothing was created or seen over an hour ago, got %+v", stale)
+	}
+
+	duplicates, err := GetDuplicateMappings(ctx, conn)
+	if err != nil {
+		t.Fatalf("GetDuplicateMappings: %v", err)
+	}
+	if len(duplicates) != 1 || duplicates[0].ExchangeSymbol != "PEPE" || len(duplicates[0].MappingIDs) != 2 {
+		t.Fatalf("duplicates = %+v, want PEPE and pepe on gate", duplicates)
+	}
+
+	report, err := InsertReconciliationReport(ctx, conn, ReconciliationFindings{Duplicates: duplicates})
+	if err != nil {
+		t.Fatalf("InsertReconciliationReport: %v", err)
+	}
+	if err := SetReconciliationDelivery(ctx, conn, report.ID, errors.New("webhook returned 502")); err != nil {
+		t.Fatalf("SetReconciliationDelivery: %v", err)
+	}
+
+	reports, err := ListReconciliationReports(ctx, conn, 5)
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("ListReconciliationReports = %+v, %v", reports, err)
+	}
+	got := reports[0]
+	if got.DuplicateCount != 1 || got.DeliveryError != "webhook returned 502" || got.DeliveredAt != nil {
+		t.Errorf("report = %+v", got)
+	}
+	if len(got.Findings.Duplicates) != 1 || got.Findings.Unmapped == nil {
+		t.Errorf("findings = %+v, want one duplicate and empty lists", got.Findings)
+	}
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+)
+
+// SourceInfo is where a symbol's stored data came from: the exchanges that
+// contributed and when it was last updated. LastUpdated is zero when
+// nothing is stored.
+type SourceInfo struct {
+	Venues      []string
+	LastUpdated time.Time
+}
+
+// GetTradeSourceInfo returns when an exchange's newest trade of a symbol was
+// made
+func GetTradeSourceInfo(ctx context.Context, conn driver.Conn, exchangeID, symbol string) (SourceInfo, error) {
+	var count uint64
+	var newest time.Time
+	if err := conn.QueryRow(ctx, `
+		SELECT count(), max(timestamp)
+		FROM trades
+		WHERE symbol = ? AND exchange_id IN (?)
+	`, symbol, tradeExchangeIDs(exchangeID)).Scan(&count, &newest); err != nil {
+		return SourceInfo{}, fmt.Errorf("failed to query newest trade: %w", err)
+	}
+
+	info := SourceInfo{Venues: []string{exchangeID}}
+	if count > 0 {
+		info.LastUpdated = newest.UTC()
+	}
+	return info, nil
+}
+
+// GetExchangeCandleSourceInfo returns when an exchange's candles of a symbol
+// at an interval were last fetched
+func GetExchangeCandleSourceInfo(ctx context.Context, conn driver.Conn, exchangeID, symbol, interval string) (SourceInfo, error) {
+	var count uint64
+	var newest time.Time
+	if err := conn.QueryRow(ctx, `
+		SELECT count(), max(fetched_at)
+		FROM exchange_ohlcv
+		WHERE exchange_id = ? AND symbol = ? AND interval = ?
+	`, exchangeID, symbol, interval).Scan(&count, &newest); err != nil {
+		return SourceInfo{}, fmt.Errorf("failed to query newest exchange candle: %w", err)
+	}
+
+	info := SourceInfo{Venues: []string{exchangeID}}
+	if count > 0 {
+		info.LastUpdated = newest.UTC()
+	}
+	return info, nil
+}
+
+// GetCompositeSourceInfo returns the exchanges that contributed to a pair's
+// VWAPs between two Unix times in seconds, and when the newest of them was
+// calculated
+func GetCompositeSourceInfo(ctx context.Context, conn driver.Conn, baseTokenID, quoteTokenID int, fromTime, toTime timeutil.Seconds) (SourceInfo, error) {
+	var count uint64
+	var venues []string
+	var newest time.Time
+	if err := conn.QueryRow(ctx, `
+		SELECT count(), groupUniqArrayArray(contributing_exchanges), max(timestamp)
+		FROM vwap_prices
+		WHERE base_token_id = ? AND quote_token_id = ?
+		  AND timestamp >= toDateTime64(?, 3) AND timestamp <= toDateTime64(?, 3)
+	`, uint32(baseTokenID), uint32(quoteTokenID), int64(fromTime), int64(toTime)).Scan(&count, &venues, &newest); err != nil {
+		return SourceInfo{}, fmt.Errorf("failed to query VWAP sources: %w", err)
+	}
+
+	sort.Strings(venues)
+	info := SourceInfo{Venues: venues}
+	if count > 0 {
+		info.LastUpdated = newest.UTC()
+	}
+	return info, nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SupplySnapshot is a token's supply as recorded on one day
+type SupplySnapshot struct {
+	Date              time.Time
+	CirculatingSupply sql.NullFloat64
+	TotalSupply       sql.NullFloat64
+	MaxSupply         sql.NullFloat64
+}
+
+// SnapshotTokenSupply records today's supply for every active token. The first
+// snapshot of a day wins, so later metadata updates the same day never
+// rewrite history. It returns the number of snapshots written.
+func SnapshotTokenSupply(ctx context.Context, db *sql.DB) (int64, error) {
+	query := `
+		INSERT INTO token_supply_snapshots (token_id, snapshot_date, circulating_supply, total_supply, max_supply)
+		SELECT id, CURRENT_DATE, circulating_supply, total_supply, max_supply
+		FROM tokens
+		WHERE is_active = true
+		  AND (circulating_supply IS NOT NULL OR total_supply IS NOT NULL OR max_supply IS NOT NULL)
+		ON CONFLICT (token_id, snapshot_date) DO NOTHING
+	`
+
+	result, err := db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to snapshot token supply: %w", err)
+	}
+
+	written, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return written, nil
+}
+
+// GetSupplyHistory returns a token's supply snapshots since the given day,
+// oldest first
+func GetSupplyHistory(ctx context.Context, db *sql.DB, tokenID int, since time.Time) ([]SupplySnapshot, error) {
+	query := `
+		SELECT snapshot_date, circulating_supply, total_supply, max_supply
+		FROM token_supply_snapshots
+		WHERE token_id = $1 AND snapshot_date >= $2
+		ORDER BY snapshot_date ASC
+	`
+
+	rows, err := db.QueryContext(ctx, query, tokenID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query supply history: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []SupplySnapshot
+	for rows.Next() {
+		var s SupplySnapshot
+		if err := rows.Scan(&s.Date, &s.CirculatingSupply, &s.TotalSupply, &s.MaxSupply); err != nil {
+			return nil, fmt.Errorf("failed to scan supply snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/shopspring/decimal"
+)
+
+// TradeKey is the position of a trade in an export: trades are ordered by
+// time, then by trade ID
+type TradeKey struct {
+	Timestamp timeutil.Millis
+	TradeID   uint64
+}
+
+// TradeRange selects one exchange's trades of a symbol between two Unix
+// times in seconds, the end excluded. After and Through, when set, narrow it
+// to the trades after one key and up to and including another.
+type TradeRange struct {
+	ExchangeID string
+	Symbol     string
+	From, To   timeutil.Seconds
+	After      *TradeKey
+	Through    *TradeKey
+}
+
+// where returns the range's WHERE clause and its arguments
+func (r TradeRange) where() (string, []interface{}) {
+	conds := []string{"symbol = ?", "exchange_id IN (?)", "timestamp >= ?", "timestamp < ?"}
+	args := []interface{}{r.Symbol, tradeExchangeIDs(r.ExchangeID), r.From.Time(), r.To.Time()}
+	if r.After != nil {
+		conds = append(conds, "(timestamp, trade_id) > (?, ?)")
+		args = append(args, r.After.Timestamp.Time(), r.After.TradeID)
+	}
+	if r.Through != nil {
+		conds = append(conds, "(timestamp, trade_id) <= (?, ?)")
+		args = append(args, r.Through.Timestamp.Time(), r.Through.TradeID)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// GetTradePageEnd returns the key of the limit-th trade of a range. It
+// reports false when the range holds fewer trades, so the page of limit
+// trades is its last.
+func GetTradePageEnd(ctx context.Context, conn driver.Conn, r TradeRange, limit int) (TradeKey, bool, error) {
+	where, args := r.where()
+	rows, err := conn.Query(ctx, `
+		SELECT timestamp, trade_id
+		FROM trades
+		WHERE `+where+`
+		ORDER BY timestamp, trade_id
+		LIMIT 1 OFFSET ?
+	`, append(args, limit-1)...)
+	if err != nil {
+		return TradeKey{}, false, fmt.Errorf("failed to query trade page end: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return TradeKey{}, false, rows.Err()
+	}
+	var key TradeKey
+	var ts time.Time
+	if err := rows.Scan(&ts, &key.TradeID); err != nil {
+		return TradeKey{}, false, fmt.Errorf("failed to scan trade page end: %w", err)
+	}
+	key.Timestamp = timeutil.MillisOf(ts)
+	return key, true, nil
+}
+
+// StreamTrades calls fn with up to limit trades of a range in key order as
+// ClickHouse returns them, without holding the page in memory. It stops at
+// the first error fn returns.
+func StreamTrades(ctx context.Context, conn driver.Conn, r TradeRange, limit int, fn func(TradeData) error) error {
+	where, args := r.where()
+	rows, err := conn.Query(ctx, `
+		SELECT symbol, if(exchange_id = '', 'binance', exchange_id), price, quantity, trade_id, toUnixTimestamp64Milli(timestamp), is_buyer_maker
+		FROM trades
+		WHERE `+where+`
+		ORDER BY timestamp, trade_id
+		LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return fmt.Errorf("failed to query trades: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trade TradeData
+		var ts int64
+		if err := rows.Scan(&trade.Symbol, &trade.ExchangeID, &trade.Price, &trade.Quantity, &trade.TradeID, &ts, &trade.IsBuyerMaker); err != nil {
+			return fmt.Errorf("failed to scan trade: %w", err)
+		}
+		trade.Timestamp = timeutil.Millis(ts)
+		if err := fn(trade); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// TradeStats aggregates the trades of a range. AvgPrice is the mean trade
+// price, not volume weighted.
+type TradeStats struct {
+	Count      uint64
+	Volume     decimal.Decimal
+	AvgPrice   decimal.Decimal
+	MinPrice   decimal.Decimal
+	MaxPrice   decimal.Decimal
+	FirstTrade time.Time
+	LastTrade  time.Time
+}
+
+// GetTradeStats aggregates a range's trades in one query. A range without
+// trades has a zero Count and zero values.
+func GetTradeStats(ctx context.Context, conn driver.Conn, r TradeRange) (TradeStats, error) {
+	where, args := r.where()
+	var stats TradeStats
+	var priceSum decimal.Decimal
+	if err := conn.QueryRow(ctx, `
+		SELECT count(), sum(quantity), sum(price), min(price), max(price), min(timestamp), max(timestamp)
+		FROM trades
+		WHERE `+where, args...).Scan(
+		&stats.Count, &stats.Volume, &priceSum, &stats.MinPrice, &stats.MaxPrice, &stats.FirstTrade, &stats.LastTrade,
+	); err != nil {
+		return TradeStats{}, fmt.Errorf("failed to query trade stats: %w", err)
+	}
+	if stats.Count == 0 {
+		return TradeStats{}, nil
+	}
+	stats.AvgPrice = priceSum.DivRound(decimal.NewFromInt(int64(stats.Count)), 8)
+	return stats, nil
+}
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Watchlist is a named list of tokens kept by one API client
+type Watchlist struct {
+	ID        int
+	Owner     string
+	Name      string
+	Tokens    []WatchlistToken // in list order
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WatchlistToken is a member of a watchlist
+type WatchlistToken struct {
+	ID     int
+	Symbol string
+}
+
+// ListWatchlists returns owner's watchlists by name
+func ListWatchlists(ctx context.Context, db *sql.DB, owner string) ([]Watchlist, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, owner, name, created_at, updated_at
+		FROM watchlists
+		WHERE owner = $1
+		ORDER BY name
+	`, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watchlists: %w", err)
+	}
+	defer rows.Close()
+
+	var lists []Watchlist
+	byID := make(map[int]int)
+	for rows.Next() {
+		var w Watchlist
+		if err := rows.Scan(&w.ID, &w.Owner, &w.Name, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist: %w", err)
+		}
+		byID[w.ID] = len(lists)
+		lists = append(lists, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return lists, nil
+	}
+
+	ids := make([]int64, 0, len(lists))
+	for _, w := range lists {
+		ids = append(ids, int64(w.ID))
+	}
+	members, err := watchlistTokens(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, tokens := range members {
+		lists[byID[id]].Tokens = tokens
+	}
+	return lists, nil
+}
+
+// GetWatchlist returns one of owner's watchlists. Lists of other owners are
+// reported as not found.
+func GetWatchlist(ctx context.Context, db *sql.DB, owner string, id int) (Watchlist, error) {
+	var w Watchlist
+	err := db.QueryRowContext(ctx, `
+		SELECT id, owner, name, created_at, updated_at
+		FROM watchlists
+		WHERE id = $1 AND owner = $2
+	`, id, owner).Scan(&w.ID, &w.Owner, &w.Name, &w.CreatedAt, &w.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Watchlist{}, fmt.Errorf("%w: %d", ErrWatchlistNotFound, id)
+	}
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("failed to load watchlist %d: %w", id, err)
+	}
+
+	members, err := watchlistTokens(ctx, db, []int64{int64(id)})
+	if err != nil {
+		return Watchlist{}, err
+	}
+	w.Tokens = members[id]
+	return w, nil
+}
+
+// CreateWatchlist creates a watchlist of tokenIDs for owner. It returns
+// ErrWatchlistExists when owner already has a list of that name.
+func CreateWatchlist(ctx context.Context, db *sql.DB, owner, name string, tokenIDs []int) (Watchlist, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO watchlists (owner, name) VALUES ($1, $2) RETURNING id
+	`, owner, name).Scan(&id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return Watchlist{}, fmt.Errorf("%w: %s", ErrWatchlistExists, name)
+	}
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("failed to create watchlist: %w", err)
+	}
+	if err := setWatchlistTokens(ctx, tx, id, tokenIDs); err != nil {
+		return Watchlist{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Watchlist{}, fmt.Errorf("failed to commit watchlist: %w", err)
+	}
+	return GetWatchlist(ctx, db, owner, id)
+}
+
+// UpdateWatchlist renames one of owner's watchlists and replaces its tokens
+func UpdateWatchlist(ctx context.Context, db *sql.DB, owner string, id int, name string, tokenIDs []int) (Watchlist, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE watchlists SET name = $3 WHERE id = $1 AND owner = $2
+	`, id, owner, name)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return Watchlist{}, fmt.Errorf("%w: %s", ErrWatchlistExists, name)
+	}
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("failed to update watchlist %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return Watchlist{}, fmt.Errorf("%w: %d", ErrWatchlistNotFound, id)
+	}
+	if err := setWatchlistTokens(ctx, tx, id, tokenIDs); err != nil {
+		return Watchlist{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Watchlist{}, fmt.Errorf("failed to commit watchlist: %w", err)
+	}
+	return GetWatchlist(ctx, db, owner, id)
+}
+
+// DeleteWatchlist deletes one of owner's watchlists
+func DeleteWatchlist(ctx context.Context, db *sql.DB, owner string, id int) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM watchlists WHERE id = $1 AND owner = $2`, id, owner)
+	if err != nil {
+		return fmt.Errorf("failed to delete watchlist %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %d", ErrWatchlistNotFound, id)
+	}
+	return nil
+}
+
+// setWatchlistTokens replaces the tokens of a watchlist, keeping the first
+// position of a token listed twice
+func setWatchlistTokens(ctx context.Context, tx *sql.Tx, id int, tokenIDs []int) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM watchlist_items WHERE watchlist_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to clear watchlist %d: %w", id, err)
+	}
+	ids := make([]int64, len(tokenIDs))
+	for i, tokenID := range tokenIDs {
+		ids[i] = int64(tokenID)
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO watchlist_items (watchlist_id, token_id, position)
+		SELECT $1, t.token_id, MIN(t.position)
+		FROM unnest($2::integer[]) WITH ORDINALITY AS t(token_id, position)
+		GROUP BY t.token_id
+	`, id, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to fill watchlist %d: %w", id, err)
+	}
+	return nil
+}
+
+// watchlistTokens maps each of the watchlists to its tokens in list order
+func watchlistTokens(ctx context.Context, db *sql.DB, watchlistIDs []int64) (map[int][]WatchlistToken, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT i.watchlist_id, t.id, t.symbol
+		FROM watchlist_items i
+		JOIN tokens t ON t.id = i.token_id
+		WHERE i.watchlist_id = ANY($1)
+		ORDER BY i.watchlist_id, i.position
+	`, pq.Array(watchlistIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watchlist tokens: %w", err)
+	}
+	defer rows.Close()
+
+	members := make(map[int][]WatchlistToken)
+	for rows.Next() {
+		var id int
+		var t WatchlistToken
+		if err := rows.Scan(&id, &t.ID, &t.Symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist token: %w", err)
+		}
+		members[id] = append(members[id], t)
+	}
+	return members, rows.Err()
+}
@@ -0,0 +1,59 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestWatchlists(t *testing.T) {
+	conn := testutil.Postgres(t)
+	ctx := context.Background()
+	ids := testutil.SeedTokens(t, conn, "BTC", "ETH", "SOL")
+
+	w, err := CreateWatchlist(ctx, conn, "alice", "majors", []int{ids["ETH"], ids["BTC"], ids["ETH"]})
+	if err != nil {
+		t.Fatalf("CreateWatchlist: %v", err)
+	}
+	if len(w.Tokens) != 2 || w.Tokens[0].Symbol != "ETH" || w.Tokens[1].Symbol != "BTC" {
+		t.Fatalf("tokens = %+v, want ETH then BTC", w.Tokens)
+	}
+	if _, err := CreateWatchlist(ctx, conn, "alice", "majors", nil); !errors.Is(err, ErrWatchlistExists) {
+		t.Errorf("duplicate name: err = %v, want ErrWatchlistExists", err)
+	}
+	if _, err := CreateWatchlist(ctx, conn, "bob", "majors", nil); err != nil {
+		t.Errorf("same name for another owner: %v", err)
+	}
+
+	// Lists of other owners are invisible
+	if _, err := GetWatchlist(ctx, conn, "bob", w.ID); !errors.Is(err, ErrWatchlistNotFound) {
+		t.Errorf("foreign get: err = %v, want ErrWatchlistNotFound", err)
+	}
+	if _, err := UpdateWatchlist(ctx, conn, "bob", w.ID, "mine", nil); !errors.Is(err, ErrWatchlistNotFound) {
+		t.Errorf("foreign update: err = %v, want ErrWatchlistNotFound", err)
+	}
+	if err := DeleteWatchlist(ctx, conn, "bob", w.ID); !errors.Is(err, ErrWatchlistNotFound) {
+		t.Errorf("foreign delete: err = %v, want ErrWatchlistNotFound", err)
+	}
+
+	w, err = UpdateWatchlist(ctx, conn, "alice", w.ID, "alts", []int{ids["SOL"]})
+	if err != nil || w.Name != "alts" || len(w.Tokens) != 1 || w.Tokens[0].Symbol != "SOL" {
+		t.Fatalf("UpdateWatchlist = %+v, err %v", w, err)
+	}
+
+	lists, err := ListWatchlists(ctx, conn, "alice")
+	if err != nil || len(lists) != 1 || lists[0].ID != w.ID || len(lists[0].Tokens) != 1 {
+		t.Fatalf("ListWatchlists = %+v, err %v", lists, err)
+	}
+
+	if err := DeleteWatchlist(ctx, conn, "alice", w.ID); err != nil {
+		t.Fatalf("DeleteWatchlist: %v", err)
+	}
+	if lists, err := ListWatchlists(ctx, conn, "alice"); err != nil || len(lists) != 0 {
+		t.Errorf("after delete: %+v, err %v", lists, err)
+	}
+}
@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Webhook is a subscription of an API client to price updates of some
+// tokens, POSTed to URL at most every MinInterval
+type Webhook struct {
+	ID                  int
+	Owner               string
+	URL                 string
+	Secret              string
+	MinInterval         time.Duration
+	Active              bool
+	ConsecutiveFailures int
+	LastDeliveredAt     *time.Time
+	LastError           string
+	Tokens              []WebhookToken // by symbol
+	CreatedAt           time.Time
+}
+
+// WebhookToken is a token a webhook is sent updates for
+type WebhookToken struct {
+	ID     int
+	Symbol string
+}
+
+const webhookColumns = `id, owner, url, secret, min_interval_seconds, is_active,
+	consecutive_failures, last_delivered_at, COALESCE(last_error, ''), created_at`
+
+// ListWebhooks returns owner's webhooks, oldest first
+func ListWebhooks(ctx context.Context, db *sql.DB, owner string) ([]Webhook, error) {
+	return queryWebhooks(ctx, db, `WHERE owner = $1`, owner)
+}
+
+// ListActiveWebhooks returns the webhooks of every owner that are still
+// being delivered
+func ListActiveWebhooks(ctx context.Context, db *sql.DB) ([]Webhook, error) {
+	return queryWebhooks(ctx, db, `WHERE is_active = true`)
+}
+
+// GetWebhook returns one of owner's webhooks. Webhooks of other owners are
+// reported as not found.
+func GetWebhook(ctx context.Context, db *sql.DB, owner string, id int) (Webhook, error) {
+	hooks, err := queryWebhooks(ctx, db, `WHERE id = $1 AND owner = $2`, id, owner)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if len(hooks) == 0 {
+		return Webhook{}, fmt.Errorf("%w: %d", ErrWebhookNotFound, id)
+	}
+	return hooks[0], nil
+}
+
+// CreateWebhook stores w, subscribed to tokenIDs, and returns it with its ID
+func CreateWebhook(ctx context.Context, db *sql.DB, w Webhook, tokenIDs []int) (Webhook, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO webhooks (owner, url, secret, min_interval_seconds)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, w.Owner, w.URL, w.Secret, int(w.MinInterval/time.Second)).Scan(&id)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	ids := make([]int64, len(tokenIDs))
+	for i, tokenID := range tokenIDs {
+		ids[i] = int64(tokenID)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_tokens (webhook_id, token_id)
+		SELECT DISTINCT $1::integer, unnest($2::integer[])
+	`, id, pq.Array(ids))
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to subscribe webhook %d: %w", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Webhook{}, fmt.Errorf("failed to commit webhook: %w", err)
+	}
+	return GetWebhook(ctx, db, w.Owner, id)
+}
+
+// DeleteWebhook deletes one of owner's webhooks
+func DeleteWebhook(ctx context.Context, db *sql.DB, owner string, id int) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1 AND owner = $2`, id, owner)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %d", ErrWebhookNotFound, id)
+	}
+	return nil
+}
+
+// RecordWebhookDelivery records the outcome of delivering to a webhook and
+// reports whether it is still active. A failure counts towards maxFailures
+// consecutive failures, after which the webhook is deactivated; 0 never
+// deactivates it. A success resets the count.
+func RecordWebhookDelivery(ctx context.Context, db *sql.DB, id int, at time.Time, deliveryErr error, maxFailures int) (bool, error) {
+	var active bool
+	var err error
+	if deliveryErr == nil {
+		err = db.QueryRowContext(ctx, `
+			UPDATE webhooks
+			SET last_delivered_at = $2, consecutive_failures = 0, last_error = NULL
+			WHERE id = $1
+			RETURNING is_active
+		`, id, at).Scan(&active)
+	} else {
+		err = db.QueryRowContext(ctx, `
+			UPDATE webhooks
+			SET consecutive_failures = consecutive_failures + 1,
+			    last_error = $2,
+			    is_active = ($3::integer = 0 OR consecutive_failures + 1 < $3::integer)
+			WHERE id = $1
+			RETURNING is_active
+		`, id, deliveryErr.Error(), maxFailures).Scan(&active)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil // deleted while being delivered
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record delivery of webhook %d: %w", id, err)
+	}
+	return active, nil
+}
+
+func queryWebhooks(ctx context.Context, db *sql.DB, where string, args ...interface{}) ([]Webhook, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks `+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []Webhook
+	byID := make(map[int]int)
+	for rows.Next() {
+		var w Webhook
+		var intervalSeconds int
+		var lastDelivered sql.NullTime
+		if err := rows.Scan(&w.ID, &w.Owner, &w.URL, &w.Secret, &intervalSeconds, &w.Active,
+			&w.ConsecutiveFailures, &lastDelivered, &w.LastError, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		w.MinInterval = time.Duration(intervalSeconds) * time.Second
+		if lastDelivered.Valid {
+			w.LastDeliveredAt = &lastDelivered.Time
+		}
+		byID[w.ID] = len(hooks)
+		hooks = append(hooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(hooks) == 0 {
+		return hooks, nil
+	}
+
+	ids := make([]int64, 0, len(hooks))
+	for _, w := range hooks {
+		ids = append(ids, int64(w.ID))
+	}
+	tokenRows, err := db.QueryContext(ctx, `
+		SELECT wt.webhook_id, t.id, t.symbol
+		FROM webhook_tokens wt
+		JOIN tokens t ON t.id = wt.token_id
+		WHERE wt.webhook_id = ANY($1)
+		ORDER BY wt.webhook_id, t.symbol
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook tokens: %w", err)
+	}
+	defer tokenRows.Close()
+
+	for tokenRows.Next() {
+		var id int
+		var t WebhookToken
+		if err := tokenRows.Scan(&id, &t.ID, &t.Symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook token: %w", err)
+		}
+		i := byID[id]
+		hooks[i].Tokens = append(hooks[i].Tokens, t)
+	}
+	return hooks, tokenRows.Err()
+}
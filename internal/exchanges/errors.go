@@ -0,0 +1,38 @@
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnknownExchange is returned when no configuration exists for an exchange ID
+	ErrUnknownExchange = errors.New("unknown exchange")
+
+	// ErrExchangeUnhealthy is returned when a request fails and the exchange has
+	// failed enough consecutive requests to be marked unhealthy
+	ErrExchangeUnhealthy = errors.New("exchange unhealthy")
+
+	// ErrTradesNotSupported is returned when recent trades are requested from
+	// an exchange without a trades endpoint
+	ErrTradesNotSupported = errors.New("recent trades not supported")
+
+	// ErrResponseTooLarge is returned when a response body is over the
+	// client's MaxResponseBytes
+	ErrResponseTooLarge = errors.New("response too large")
+
+	// ErrMalformedResponse is returned when a response nests too deeply, or
+	// its parser panics or times out
+	ErrMalformedResponse = errors.New("malformed response")
+)
+
+// StatusError is returned when an exchange API responds with a non-200 status
+type StatusError struct {
+	ExchangeID string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status code %d: %s", e.ExchangeID, e.StatusCode, e.Body)
+}
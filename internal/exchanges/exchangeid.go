@@ -0,0 +1,37 @@
+package exchanges
+
+import "strings"
+
+// exchangeIDAliases are the other names exchanges go by, such as data
+// provider slugs and former names, by their normalized form
+var exchangeIDAliases = map[string]string{
+	"gate":              "gateio",
+	"coinbaseexchange":  "coinbase",
+	"coinbasepro":       "coinbase",
+	"gdax":              "coinbase",
+	"huobi":             "htx",
+	"huobiglobal":       "htx",
+	"okex":              "okx",
+	"cryptocomexchange": "cryptocom",
+	"biconomycom":       "biconomy",
+	"hashkeyexchange":   "hashkey",
+	"bitmartexchange":   "bitmart",
+}
+
+// NormalizeExchangeID turns an exchange name or slug into the registry's form
+// of exchange IDs: lowercase letters and digits only, with known aliases
+// replaced, so "Gate", "gate-io" and "Gate.io" are all "gateio" and "Crypto.com
+// Exchange" is "cryptocom". It does not check the exchange is registered.
+func NormalizeExchangeID(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	id := b.String()
+	if alias, ok := exchangeIDAliases[id]; ok {
+		return alias
+	}
+	return id
+}
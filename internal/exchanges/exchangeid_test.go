@@ -0,0 +1,21 @@
+package exchanges
+
+import "testing"
+
+func TestNormalizeExchangeID(t *testing.T) {
+	for name, want := range map[string]string{
+		"binance":             "binance",
+		"Gate":                "gateio",
+		"gate-io":             "gateio",
+		"Gate.io":             "gateio",
+		"Crypto.com Exchange": "cryptocom",
+		"coinbase-exchange":   "coinbase",
+		"Huobi":               "htx",
+		"Big One":             "bigone",
+		"HashKey Global":      "hashkeyglobal",
+	} {
+		if got := NormalizeExchangeID(name); got != want {
+			t.Errorf("NormalizeExchangeID(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
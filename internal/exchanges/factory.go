@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -13,8 +15,10 @@ import (
 
 // ExchangeFactory creates exchange clients based on configuration
 type ExchangeFactory struct {
-	logger  *zap.Logger
-	configs map[string]ExchangeConfig
+	logger    *zap.Logger
+	configs   map[string]ExchangeConfig
+	transport http.RoundTripper
+	limits    Limits
 }
 
 // NewExchangeFactory creates a new exchange factory
@@ -25,20 +29,65 @@ func NewExchangeFactory(configPath string, logger *zap.Logger) (*ExchangeFactory
 	}
 
 	return &ExchangeFactory{
-		logger:  logger,
-		configs: configs,
+		logger:    logger,
+		configs:   configs,
+		transport: NewTransport(DefaultTransportConfig()),
+		limits:    DefaultLimits(),
 	}, nil
 }
 
+// SetTransport replaces the transport the factory's clients share, such as
+// with one from NewTransport. Clients already created keep theirs.
+func (f *ExchangeFactory) SetTransport(transport http.RoundTripper) {
+	f.transport = transport
+}
+
+// SetLimits replaces the response limits of the clients the factory creates
+func (f *ExchangeFactory) SetLimits(limits Limits) {
+	f.limits = limits
+}
+
+// Transport returns the transport the factory's clients share, for other
+// clients of the exchanges' APIs to use as well
+func (f *ExchangeFactory) Transport() http.RoundTripper {
+	return f.transport
+}
+
+// Configs returns the exchange configs in ID order
+func (f *ExchangeFactory) Configs() []ExchangeConfig {
+	configs := make([]ExchangeConfig, 0, len(f.configs))
+	for _, config := range f.configs {
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].ID < configs[j].ID })
+	return configs
+}
+
+// SetConfigs replaces the exchange configs, such as with the exchange
+// registry's. Clients already created keep their config.
+func (f *ExchangeFactory) SetConfigs(configs []ExchangeConfig) {
+	f.configs = make(map[string]ExchangeConfig, len(configs))
+	for _, config := range configs {
+		f.configs[config.ID] = config
+	}
+}
+
 // CreateClient creates an exchange client for the given exchange ID
 func (f *ExchangeFactory) CreateClient(exchangeID string) (ExchangeClient, error) {
 	config, ok := f.configs[exchangeID]
 	if !ok {
-		return nil, fmt.Errorf("unknown exchange: %s", exchangeID)
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExchange, exchangeID)
+	}
+
+	filter, err := NewSymbolFilter(config.AllowSymbols, config.DenySymbols)
+	if err != nil {
+		return nil, fmt.Errorf("exchange %s: %w", exchangeID, err)
 	}
 
 	parser := f.createParser(exchangeID, config)
-	return NewGenericRESTClient(config, parser, f.logger), nil
+	client := NewGenericRESTClient(config, parser, f.transport, f.limits, f.logger)
+	client.SetSymbolFilter(filter)
+	return client, nil
 }
 
 // CreateAllClients creates clients for all configured exchanges
@@ -66,6 +115,17 @@ func (f *ExchangeFactory) CreateAllClients() map[string]ExchangeClient {
 	return clients
 }
 
+// Weights returns the configured VWAP weight of every enabled exchange
+func (f *ExchangeFactory) Weights() map[string]float64 {
+	weights := make(map[string]float64, len(f.configs))
+	for exchangeID, config := range f.configs {
+		if !config.Disabled {
+			weights[exchangeID] = config.Weight
+		}
+	}
+	return weights
+}
+
 // GetActiveExchanges returns a list of active exchange IDs
 func (f *ExchangeFactory) GetActiveExchanges() []string {
 	exchanges := make([]string, 0, len(f.configs))
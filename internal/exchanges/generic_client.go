@@ -1,6 +1,7 @@
 package exchanges
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -18,9 +20,13 @@ import (
 type GenericRESTClient struct {
 	config     ExchangeConfig
 	httpClient *http.Client
+	limits     Limits
 	logger     *zap.Logger
 	health     Health
 	parser     ResponseParser
+	filter     *SymbolFilter
+	filtered   atomic.Int64 // tickers dropped by filter from the last GetAllTickers
+	nonSpot    atomic.Int64 // leveraged and derivative tickers in it
 	mu         sync.RWMutex
 }
 
@@ -31,13 +37,17 @@ type ResponseParser interface {
 	ParseSymbolPair(symbol string, format string) (base, quote string)
 }
 
-// NewGenericRESTClient creates a new generic REST client for any exchange
-func NewGenericRESTClient(config ExchangeConfig, parser ResponseParser, logger *zap.Logger) *GenericRESTClient {
+// NewGenericRESTClient creates a new generic REST client for any exchange.
+// Requests go through transport, shared with the other clients, or
+// http.DefaultTransport when it is nil, and responses are held to limits.
+func NewGenericRESTClient(config ExchangeConfig, parser ResponseParser, transport http.RoundTripper, limits Limits, logger *zap.Logger) *GenericRESTClient {
 	return &GenericRESTClient{
 		config: config,
 		httpClient: &http.Client{
-			Timeout: time.Duration(config.RequestTimeout) * time.Millisecond,
+			Timeout:   time.Duration(config.RequestTimeout) * time.Millisecond,
+			Transport: transport,
 		},
+		limits: limits,
 		logger: logger,
 		parser: parser,
 		health: Health{
@@ -61,13 +71,44 @@ func (g *GenericRESTClient) GetWeight() float64 {
 func (g *GenericRESTClient) GetAllTickers(ctx context.Context) ([]TickerData, error) {
 	url := g.config.BaseURL + g.config.TickerEndpoint
 	
-	data, err := g.makeRequest(ctx, url)
-	if err != nil {
+	// Ticker responses run to megabytes on large exchanges; reading them into
+	// a pooled buffer avoids regrowing one from scratch every poll
+	buf := getBodyBuffer()
+	if err := g.makeRequest(ctx, url, buf); err != nil {
+		putBodyBuffer(buf)
 		return nil, fmt.Errorf("fetching tickers: %w", err)
 	}
 
-	// Use parser to handle exchange-specific response format
-	return g.parser.ParseTickers(data, g.config.ID)
+	// Use parser to handle exchange-specific response format. Parsers copy
+	// what they keep, so the buffer can be reused once they return.
+	tickers, err := parseResponse(ctx, g.limits, buf, func(data []byte) ([]TickerData, error) {
+		return g.parser.ParseTickers(data, g.config.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	tickers, dropped := g.filter.FilterTickers(tickers)
+	g.filtered.Store(int64(dropped))
+	g.nonSpot.Store(int64(tagInstruments(tickers)))
+	return tickers, nil
+}
+
+// SetSymbolFilter makes the client drop the tickers and symbols filter does
+// not keep. A nil filter keeps everything.
+func (g *GenericRESTClient) SetSymbolFilter(filter *SymbolFilter) {
+	g.filter = filter
+}
+
+// FilteredTickers returns how many tickers the symbol filter dropped from
+// the last GetAllTickers
+func (g *GenericRESTClient) FilteredTickers() int {
+	return int(g.filtered.Load())
+}
+
+// NonSpotTickers returns how many leveraged token and derivative tickers
+// the last GetAllTickers returned
+func (g *GenericRESTClient) NonSpotTickers() int {
+	return int(g.nonSpot.Load())
 }
 
 func (g *GenericRESTClient) GetTickers(ctx context.Context, symbols []string) ([]TickerData, error) {
@@ -98,12 +139,21 @@ func (g *GenericRESTClient) GetTickers(ctx context.Context, symbols []string) ([
 func (g *GenericRESTClient) GetSymbols(ctx context.Context) ([]ExchangeSymbol, error) {
 	url := g.config.BaseURL + g.config.SymbolsEndpoint
 
-	data, err := g.makeRequest(ctx, url)
-	if err != nil {
+	buf := getBodyBuffer()
+	if err := g.makeRequest(ctx, url, buf); err != nil {
+		putBodyBuffer(buf)
 		return nil, fmt.Errorf("fetching symbols: %w", err)
 	}
 
-	return g.parser.ParseSymbols(data, g.config.ID)
+	symbols, err := parseResponse(ctx, g.limits, buf, func(data []byte) ([]ExchangeSymbol, error) {
+		return g.parser.ParseSymbols(data, g.config.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	symbols, _ = g.filter.FilterSymbols(symbols)
+	symbols, _ = spotSymbols(symbols)
+	return symbols, nil
 }
 
 func (g *GenericRESTClient) GetRateLimit() time.Duration {
@@ -133,10 +183,12 @@ func (g *GenericRESTClient) UpdateHealth(success bool, responseTime time.Duratio
 	}
 }
 
-func (g *GenericRESTClient) makeRequest(ctx context.Context, url string) ([]byte, error) {
+// makeRequest GETs url and reads a 200 response body into buf, up to the
+// client's MaxResponseBytes
+func (g *GenericRESTClient) makeRequest(ctx context.Context, url string, buf *bytes.Buffer) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return fmt.Errorf("creating request: %w", err)
 	}
 
 	// Add custom headers based on exchange
@@ -160,23 +212,49 @@ func (g *GenericRESTClient) makeRequest(ctx context.Context, url string) ([]byte
 	resp, err := g.httpClient.Do(req)
 	if err != nil {
 		g.UpdateHealth(false, time.Since(start))
-		return nil, fmt.Errorf("executing request: %w", err)
+		if !g.IsHealthy() {
+			return fmt.Errorf("%w: executing request: %w", ErrExchangeUnhealthy, err)
+		}
+		return fmt.Errorf("executing request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	g.UpdateHealth(resp.StatusCode == http.StatusOK, time.Since(start))
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		statusErr := &StatusError{ExchangeID: g.config.ID, StatusCode: resp.StatusCode, Body: string(body)}
+		if !g.IsHealthy() {
+			return fmt.Errorf("%w: %w", ErrExchangeUnhealthy, statusErr)
+		}
+		return statusErr
 	}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
+	if err := readBody(resp, buf, g.limits.MaxResponseBytes); err != nil {
+		return fmt.Errorf("reading response: %w", err)
 	}
 
-	return data, nil
+	return nil
+}
+
+// maxPooledBodySize keeps an unusually large response from pinning its
+// buffer in the pool
+const maxPooledBodySize = 16 << 20
+
+var bodyBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+func getBodyBuffer() *bytes.Buffer {
+	buf := bodyBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBodyBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBodySize {
+		bodyBuffers.Put(buf)
+	}
 }
 
 func (g *GenericRESTClient) normalizeSymbol(symbol string) string {
@@ -0,0 +1,121 @@
+package exchanges
+
+import (
+	"regexp"
+	"strings"
+)
+
+// InstrumentType is the kind of market a symbol trades
+type InstrumentType string
+
+const (
+	InstrumentSpot           InstrumentType = "spot"
+	InstrumentPerpetual      InstrumentType = "perp"
+	InstrumentFutures        InstrumentType = "futures"
+	InstrumentLeveragedToken InstrumentType = "leveraged_token"
+)
+
+var (
+	// leveragedMultiple matches leveraged token bases such as BTC3L, ETH5S:
+	// the underlying, a multiple of 2 to 5 and long or short
+	leveragedMultiple = regexp.MustCompile(`^([A-Z0-9]{2,})[2-5][LS]$`)
+	// leveragedSuffix matches UP/DOWN and BULL/BEAR/HEDGE tokens, which are
+	// only issued on large underlyings, so the prefix is checked against
+	// leveragedUnderlyings to tell ETHUP from a token like SYRUP
+	leveragedSuffix = regexp.MustCompile(`^([A-Z0-9]{2,})(UP|DOWN|BULL|BEAR|HEDGE)$`)
+
+	// datedContract matches delivery dates in futures symbols:
+	// BTCUSDT_240628, BTC-USD-240329, BTC-29MAR24
+	datedContract = regexp.MustCompile(`[-_](\d{6}|\d{1,2}[A-Z]{3}\d{2})$`)
+)
+
+// leveragedUnderlyings are the underlyings exchanges have issued UP/DOWN and
+// BULL/BEAR tokens on
+var leveragedUnderlyings = map[string]bool{
+	"BTC": true, "ETH": true, "BNB": true, "XRP": true, "ADA": true, "LINK": true,
+	"DOT": true, "LTC": true, "TRX": true, "EOS": true, "XTZ": true, "FIL": true,
+	"SXP": true, "UNI": true, "AAVE": true, "SUSHI": true, "YFI": true, "1INCH": true,
+	"XLM": true, "BCH": true, "DOGE": true, "SOL": true, "MATIC": true, "ALGO": true,
+}
+
+// DetectInstrument classifies a symbol from its exchange symbol and parsed
+// base and quote, which may be empty. Anything not recognized as a
+// derivative or leveraged token is spot.
+func DetectInstrument(symbol, base, quote string) InstrumentType {
+	symbol = strings.ToUpper(symbol)
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+
+	switch {
+	// PERP anywhere, OKX swaps, TradingView-style .P and KuCoin's USDT-margined
+	// contracts such as XBTUSDTM
+	case strings.Contains(symbol, "PERP") || strings.Contains(quote, "PERP"),
+		strings.HasSuffix(symbol, "-SWAP"), strings.HasSuffix(symbol, ".P"),
+		strings.HasSuffix(symbol, "USDTM"):
+		return InstrumentPerpetual
+	case datedContract.MatchString(symbol):
+		return InstrumentFutures
+	}
+
+	if base == "" {
+		return InstrumentSpot
+	}
+	if leveragedMultiple.MatchString(base) {
+		return InstrumentLeveragedToken
+	}
+	if m := leveragedSuffix.FindStringSubmatch(base); m != nil && leveragedUnderlyings[m[1]] {
+		return InstrumentLeveragedToken
+	}
+	return InstrumentSpot
+}
+
+// IsSpot reports whether a symbol trades spot, see DetectInstrument
+func IsSpot(symbol, base, quote string) bool {
+	return DetectInstrument(symbol, base, quote) == InstrumentSpot
+}
+
+// Instrument returns the ticker's instrument type, spot when it was not set
+func (t TickerData) Instrument() InstrumentType {
+	if t.InstrumentType == "" {
+		return InstrumentSpot
+	}
+	return t.InstrumentType
+}
+
+// tagInstruments sets the instrument type of each ticker and returns how
+// many are not spot
+func tagInstruments(tickers []TickerData) int {
+	nonSpot := 0
+	for i := range tickers {
+		t := &tickers[i]
+		t.InstrumentType = DetectInstrument(t.Symbol, t.BaseSymbol, t.QuoteSymbol)
+		if t.InstrumentType != InstrumentSpot {
+			nonSpot++
+		}
+	}
+	return nonSpot
+}
+
+// DropNonSpot drops the leveraged token and derivative tickers, in place,
+// which would otherwise count towards the spot markets of their underlying,
+// and returns the rest. Tickers without an instrument type are spot.
+func DropNonSpot(tickers []TickerData) []TickerData {
+	kept := tickers[:0]
+	for _, t := range tickers {
+		if t.Instrument() == InstrumentSpot {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// spotSymbols drops the leveraged token and derivative symbols, which are
+// not mapped, in place and returns the rest with how many were dropped
+func spotSymbols(symbols []ExchangeSymbol) ([]ExchangeSymbol, int) {
+	kept := symbols[:0]
+	for _, s := range symbols {
+		if IsSpot(s.Symbol, s.BaseSymbol, s.QuoteSymbol) {
+			kept = append(kept, s)
+		}
+	}
+	return kept, len(symbols) - len(kept)
+}
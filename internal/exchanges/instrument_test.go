@@ -0,0 +1,47 @@
+package exchanges
+
+import "testing"
+
+func TestDetectInstrument(t *testing.T) {
+	tests := []struct {
+		symbol, base, quote string
+		want                InstrumentType
+	}{
+		{"BTCUSDT", "BTC", "USDT", InstrumentSpot},
+		{"SYRUPUSDT", "SYRUP", "USDT", InstrumentSpot},
+		{"JUPUSDT", "JUP", "USDT", InstrumentSpot},
+		{"BTC3LUSDT", "BTC3L", "USDT", InstrumentLeveragedToken},
+		{"ETH5S-USDT", "ETH5S", "USDT", InstrumentLeveragedToken},
+		{"ETHUPUSDT", "ETHUP", "USDT", InstrumentLeveragedToken},
+		{"BNBDOWNUSDT", "BNBDOWN", "USDT", InstrumentLeveragedToken},
+		{"BTC-USDT-SWAP", "BTC", "USDT", InstrumentPerpetual},
+		{"BTC-PERP", "BTC", "PERP", InstrumentPerpetual},
+		{"XBTUSDTM", "", "", InstrumentPerpetual},
+		{"BTCUSD_240628", "", "", InstrumentFutures},
+		{"BTC-29MAR24", "", "", InstrumentFutures},
+		{"BTC-USD", "", "", InstrumentSpot},
+	}
+	for _, tt := range tests {
+		if got := DetectInstrument(tt.symbol, tt.base, tt.quote); got != tt.want {
+			t.Errorf("DetectInstrument(%s, %s, %s) = %s, want %s", tt.symbol, tt.base, tt.quote, got, tt.want)
+		}
+	}
+}
+
+func TestDropNonSpot(t *testing.T) {
+	tickers := []TickerData{
+		{Symbol: "BTCUSDT", BaseSymbol: "BTC", QuoteSymbol: "USDT"},
+		{Symbol: "BTC3LUSDT", BaseSymbol: "BTC3L", QuoteSymbol: "USDT"},
+		{Symbol: "BTC-USDT-SWAP", BaseSymbol: "BTC", QuoteSymbol: "USDT"},
+	}
+	if n := tagInstruments(tickers); n != 2 {
+		t.Fatalf("tagInstruments = %d non-spot, want 2", n)
+	}
+	if tickers[2].InstrumentType != InstrumentPerpetual {
+		t.Errorf("swap tagged %s", tickers[2].InstrumentType)
+	}
+	kept := DropNonSpot(append(tickers, TickerData{Symbol: "ETHUSDT"}))
+	if len(kept) != 2 || kept[0].Symbol != "BTCUSDT" || kept[1].Instrument() != InstrumentSpot {
+		t.Errorf("kept = %+v", kept)
+	}
+}
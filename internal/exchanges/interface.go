@@ -20,6 +20,36 @@ type ExchangeClient interface {
 	UpdateHealth(success bool, responseTime time.Duration)
 }
 
+// TradesClient is implemented by clients that can fetch an exchange's
+// recent trades over REST. SupportsTrades is false when the exchange has no
+// trades endpoint configured.
+type TradesClient interface {
+	SupportsTrades() bool
+	GetRecentTrades(ctx context.Context, symbol string) ([]Trade, error)
+}
+
+// FilteringClient is implemented by clients that drop symbols their
+// exchange's allow and deny patterns leave out and set the instrument type
+// of the rest at parse time
+type FilteringClient interface {
+	// FilteredTickers returns how many tickers the last GetAllTickers dropped
+	// by the patterns
+	FilteredTickers() int
+	// NonSpotTickers returns how many of those it returned are leveraged
+	// tokens or derivatives
+	NonSpotTickers() int
+}
+
+// Trade is a public trade from an exchange's recent-trades endpoint. ID is
+// the exchange's trade ID, which increases with each trade on a pair.
+type Trade struct {
+	ID           uint64
+	Price        decimal.Decimal
+	Quantity     decimal.Decimal
+	Timestamp    time.Time
+	IsBuyerMaker bool
+}
+
 // TickerData represents unified ticker data from any exchange
 type TickerData struct {
 	ExchangeID     string          `json:"exchange_id"`
@@ -28,6 +58,7 @@ type TickerData struct {
 	QuoteSymbol    string          `json:"quote_symbol"`
 	BaseTokenID    int             `json:"base_token_id"`    // Added token ID
 	QuoteTokenID   int             `json:"quote_token_id"`   // Added token ID
+	InstrumentType InstrumentType  `json:"instrument_type"`  // set by the client when parsing, see DetectInstrument
 	Price          decimal.Decimal `json:"price"`
 	Volume24h      decimal.Decimal `json:"volume_24h"`
 	QuoteVolume24h decimal.Decimal `json:"quote_volume_24h"`
@@ -35,9 +66,15 @@ type TickerData struct {
 	High24h        decimal.Decimal `json:"high_24h"`
 	Low24h         decimal.Decimal `json:"low_24h"`
 	Timestamp      time.Time       `json:"timestamp"`
+	// Chain is the chain of the base asset, for exchanges that list the
+	// same symbol once per chain
+	Chain string `json:"chain,omitempty"`
 }
 
-// ExchangeSymbol represents a trading pair on an exchange
+// ExchangeSymbol represents a trading pair on an exchange. The order rules
+// are decimal strings as the exchange gives them, empty when it does not:
+// TickSize and StepSize are the price and quantity increments, MinNotional is
+// in the quote currency, and the fees are fractions of the traded amount.
 type ExchangeSymbol struct {
 	ExchangeID  string `json:"exchange_id"`
 	Symbol      string `json:"symbol"`
@@ -46,6 +83,10 @@ type ExchangeSymbol struct {
 	IsActive    bool   `json:"is_active"`
 	MinQuantity string `json:"min_quantity"`
 	MinNotional string `json:"min_notional"`
+	TickSize    string `json:"tick_size"`
+	StepSize    string `json:"step_size"`
+	TakerFee    string `json:"taker_fee"`
+	MakerFee    string `json:"maker_fee"`
 }
 
 // ExchangeConfig represents configuration for an exchange
@@ -62,6 +103,38 @@ type ExchangeConfig struct {
 	SymbolFormat       string   `json:"symbol_format"`
 	QuoteCurrencies    []string `json:"quote_currencies"`
 	Disabled           bool     `json:"disabled"`
+
+	// TradesEndpoint is the recent-trades path, with {symbol} standing for
+	// the pair symbol. Empty when trades are not polled.
+	TradesEndpoint string `json:"trades_endpoint,omitempty"`
+
+	// Base fee tier as fractions, for pairs whose listing has no fees. Zero
+	// when unknown.
+	TakerFee float64 `json:"taker_fee,omitempty"`
+	MakerFee float64 `json:"maker_fee,omitempty"`
+
+	// AllowSymbols and DenySymbols are path.Match patterns of the symbols
+	// kept and dropped when parsing, such as "*3L*", see SymbolFilter.
+	// Without allow patterns every symbol not denied is kept.
+	AllowSymbols []string `json:"allow_symbols,omitempty"`
+	DenySymbols  []string `json:"deny_symbols,omitempty"`
+
+	// MaintenanceWindows are scheduled downtimes, added to the registry's
+	// maintenance windows when the registry is seeded
+	MaintenanceWindows []MaintenanceWindow `json:"maintenance_windows,omitempty"`
+}
+
+// MaintenanceWindow is a scheduled downtime of an exchange, from Start up
+// to End, during which it is not polled
+type MaintenanceWindow struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Contains reports whether t falls in the window
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
 }
 
 // Health represents exchange health status
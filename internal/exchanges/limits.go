@@ -0,0 +1,131 @@
+package exchanges
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxErrorBodyBytes is how much of a non-200 response is kept for its
+// StatusError
+const maxErrorBodyBytes = 4 << 10
+
+// Limits bound what a client accepts from an exchange, so a malformed or
+// hostile response cannot exhaust the poller's memory or stall it
+type Limits struct {
+	MaxResponseBytes int64         // larger bodies are refused; 0 is no limit
+	MaxJSONDepth     int           // deeper nesting of objects and arrays is refused; 0 is no limit
+	ParseTimeout     time.Duration // after which a response's parse is given up; 0 is no limit
+}
+
+// DefaultLimits returns limits well above the largest ticker responses,
+// which run to a few megabytes on the exchanges listing the most pairs
+func DefaultLimits() Limits {
+	return Limits{
+		MaxResponseBytes: 64 << 20,
+		MaxJSONDepth:     64,
+		ParseTimeout:     10 * time.Second,
+	}
+}
+
+// readBody reads resp's body into buf, failing with ErrResponseTooLarge once
+// it passes limit bytes
+func readBody(resp *http.Response, buf *bytes.Buffer, limit int64) error {
+	if limit <= 0 {
+		_, err := buf.ReadFrom(resp.Body)
+		return err
+	}
+	if resp.ContentLength > limit {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrResponseTooLarge, resp.ContentLength, limit)
+	}
+	if _, err := buf.ReadFrom(io.LimitReader(resp.Body, limit+1)); err != nil {
+		return err
+	}
+	if int64(buf.Len()) > limit {
+		return fmt.Errorf("%w: over %d bytes", ErrResponseTooLarge, limit)
+	}
+	return nil
+}
+
+// CheckJSONDepth fails with ErrMalformedResponse when objects and arrays in
+// data nest deeper than max. It only counts brackets outside strings, so it
+// runs before, and far faster than, decoding.
+func CheckJSONDepth(data []byte, max int) error {
+	if max <= 0 {
+		return nil
+	}
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > max {
+				return fmt.Errorf("%w: nested deeper than %d levels", ErrMalformedResponse, max)
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// parseResponse checks the nesting of the body in buf and parses it with
+// parse, which fails with ErrMalformedResponse if it panics or outlasts the
+// parse timeout. It takes buf and returns it to the pool, unless a parse that
+// timed out may still be reading it.
+func parseResponse[T any](ctx context.Context, limits Limits, buf *bytes.Buffer, parse func([]byte) (T, error)) (T, error) {
+	var zero T
+	data := buf.Bytes()
+	if err := CheckJSONDepth(data, limits.MaxJSONDepth); err != nil {
+		putBodyBuffer(buf)
+		return zero, err
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("%w: parser panicked: %v", ErrMalformedResponse, r)}
+			}
+		}()
+		value, err := parse(data)
+		done <- result{value, err}
+	}()
+
+	var timeout <-chan time.Time
+	if limits.ParseTimeout > 0 {
+		timer := time.NewTimer(limits.ParseTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case r := <-done:
+		putBodyBuffer(buf)
+		return r.value, r.err
+	case <-timeout:
+		return zero, fmt.Errorf("%w: parsing took over %s", ErrMalformedResponse, limits.ParseTimeout)
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
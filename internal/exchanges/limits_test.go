@@ -0,0 +1,81 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestCheckJSONDepth(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		ok   bool
+	}{
+		{`{"data":[{"symbol":"BTCUSDT"}]}`, true},
+		{`[[[1]]]`, true},
+		{`[[[[1]]]]`, false},
+		{`{"symbol":"[[[[[[[["}`, true},
+		{`{"symbol":"\"[[[[[["}`, true},
+	} {
+		err := CheckJSONDepth([]byte(tc.data), 3)
+		if (err == nil) != tc.ok || (err != nil && !errors.Is(err, ErrMalformedResponse)) {
+			t.Errorf("CheckJSONDepth(%s) = %v", tc.data, err)
+		}
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	limits := Limits{MaxJSONDepth: 8, ParseTimeout: 50 * time.Millisecond}
+	ctx := context.Background()
+
+	buf := getBodyBuffer()
+	buf.WriteString(`[1]`)
+	n, err := parseResponse(ctx, limits, buf, func(data []byte) (int, error) { return len(data), nil })
+	if err != nil || n != 3 {
+		t.Errorf("parse = %d, %v", n, err)
+	}
+
+	buf = getBodyBuffer()
+	buf.WriteString(`[1]`)
+	_, err = parseResponse(ctx, limits, buf, func([]byte) (int, error) { panic("index out of range") })
+	if !errors.Is(err, ErrMalformedResponse) {
+		t.Errorf("panicking parse: err = %v", err)
+	}
+
+	buf = getBodyBuffer()
+	buf.WriteString(`[1]`)
+	release := make(chan struct{})
+	defer close(release)
+	_, err = parseResponse(ctx, limits, buf, func([]byte) (int, error) { <-release; return 0, nil })
+	if !errors.Is(err, ErrMalformedResponse) {
+		t.Errorf("slow parse: err = %v", err)
+	}
+}
+
+func TestResponseTooLarge(t *testing.T) {
+	body := `[` + strings.Repeat(`{"symbol":"BTCUSDT","lastPrice":"1"},`, 100) + `{}]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Chunked, so the client cannot refuse it by its Content-Length
+		w.(http.Flusher).Flush()
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	config := ExchangeConfig{ID: "test", BaseURL: server.URL, TickerEndpoint: "/tickers", RequestTimeout: 5000}
+	client := NewGenericRESTClient(config, &UnifiedParser{}, nil, Limits{MaxResponseBytes: 1024}, zap.NewNop())
+	if _, err := client.GetAllTickers(context.Background()); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("err = %v, want ErrResponseTooLarge", err)
+	}
+
+	client = NewGenericRESTClient(config, &UnifiedParser{}, nil, DefaultLimits(), zap.NewNop())
+	tickers, err := client.GetAllTickers(context.Background())
+	if err != nil || len(tickers) != 100 {
+		t.Errorf("default limits: %d tickers, %v", len(tickers), err)
+	}
+}
@@ -70,6 +70,13 @@ func (p *BinanceStyleParser) ParseSymbols(data []byte, exchangeID string) ([]Exc
 			Status     string `json:"status"`
 			BaseAsset  string `json:"baseAsset"`
 			QuoteAsset string `json:"quoteAsset"`
+			Filters    []struct {
+				FilterType  string `json:"filterType"`
+				TickSize    string `json:"tickSize"`
+				StepSize    string `json:"stepSize"`
+				MinQty      string `json:"minQty"`
+				MinNotional string `json:"minNotional"`
+			} `json:"filters"`
 		} `json:"symbols"`
 	}
 
@@ -79,15 +86,27 @@ func (p *BinanceStyleParser) ParseSymbols(data []byte, exchangeID string) ([]Exc
 
 	symbols := make([]ExchangeSymbol, 0, len(response.Symbols))
 	for _, s := range response.Symbols {
-		if s.Status == "TRADING" {
-			symbols = append(symbols, ExchangeSymbol{
-				ExchangeID:  exchangeID,
-				Symbol:      s.Symbol,
-				BaseSymbol:  s.BaseAsset,
-				QuoteSymbol: s.QuoteAsset,
-				IsActive:    true,
-			})
+		if s.Status != "TRADING" {
+			continue
+		}
+		symbol := ExchangeSymbol{
+			ExchangeID:  exchangeID,
+			Symbol:      s.Symbol,
+			BaseSymbol:  s.BaseAsset,
+			QuoteSymbol: s.QuoteAsset,
+			IsActive:    true,
+		}
+		for _, f := range s.Filters {
+			switch f.FilterType {
+			case "PRICE_FILTER":
+				symbol.TickSize = f.TickSize
+			case "LOT_SIZE":
+				symbol.StepSize, symbol.MinQuantity = f.StepSize, f.MinQty
+			case "NOTIONAL", "MIN_NOTIONAL":
+				symbol.MinNotional = f.MinNotional
+			}
 		}
+		symbols = append(symbols, symbol)
 	}
 
 	return symbols, nil
@@ -152,6 +171,8 @@ func (p *CoinbaseStyleParser) ParseSymbols(data []byte, exchangeID string) ([]Ex
 		Status         string `json:"status"`
 		MinMarketFunds string `json:"min_market_funds"`
 		MinSize        string `json:"min_size"`
+		QuoteIncrement string `json:"quote_increment"`
+		BaseIncrement  string `json:"base_increment"`
 	}
 
 	if err := json.Unmarshal(data, &products); err != nil {
@@ -169,6 +190,8 @@ func (p *CoinbaseStyleParser) ParseSymbols(data []byte, exchangeID string) ([]Ex
 				IsActive:    true,
 				MinQuantity: p.MinSize,
 				MinNotional: p.MinMarketFunds,
+				TickSize:    p.QuoteIncrement,
+				StepSize:    p.BaseIncrement,
 			})
 		}
 	}
@@ -245,6 +268,12 @@ func (p *KrakenStyleParser) ParseSymbols(data []byte, exchangeID string) ([]Exch
 				BaseSymbol:  base,
 				QuoteSymbol: quote,
 				IsActive:    true,
+				MinQuantity: getStringField(info, "ordermin"),
+				MinNotional: getStringField(info, "costmin"),
+				TickSize:    decimalsToIncrement(info["pair_decimals"]),
+				StepSize:    decimalsToIncrement(info["lot_decimals"]),
+				TakerFee:    krakenBaseFee(info["fees"]),
+				MakerFee:    krakenBaseFee(info["fees_maker"]),
 			})
 		}
 	}
@@ -252,6 +281,30 @@ func (p *KrakenStyleParser) ParseSymbols(data []byte, exchangeID string) ([]Exch
 	return symbols, nil
 }
 
+// decimalsToIncrement turns a number of decimal places into the increment it
+// allows, 2 into "0.01"
+func decimalsToIncrement(places interface{}) string {
+	n, ok := places.(float64)
+	if !ok || n < 0 {
+		return ""
+	}
+	return decimal.New(1, -int32(n)).String()
+}
+
+// krakenBaseFee returns the first tier of a Kraken fee schedule, a list of
+// [volume, percent fee] pairs, as a fraction
+func krakenBaseFee(schedule interface{}) string {
+	tiers, ok := schedule.([]interface{})
+	if !ok || len(tiers) == 0 {
+		return ""
+	}
+	tier, ok := tiers[0].([]interface{})
+	if !ok || len(tier) < 2 {
+		return ""
+	}
+	return parseDecimalSafe(tier[1]).Shift(-2).String()
+}
+
 // Helper functions for parsing fields
 func getStringField(data map[string]interface{}, field string) string {
 	if val, ok := data[field]; ok {
@@ -0,0 +1,137 @@
+package exchanges
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// Regenerate the golden files after an intended parser change with
+//
+//	go test ./internal/exchanges -run Golden -update
+//
+// and review the diff before committing it.
+var update = flag.Bool("update", false, "rewrite golden files from the current parsers")
+
+// goldenTicker is the part of TickerData a parser derives from the response.
+// Decimals are kept as strings so the golden files show exactly what was
+// parsed.
+type goldenTicker struct {
+	Symbol         string `json:"symbol"`
+	BaseSymbol     string `json:"base_symbol"`
+	QuoteSymbol    string `json:"quote_symbol"`
+	Price          string `json:"price"`
+	Volume24h      string `json:"volume_24h"`
+	QuoteVolume24h string `json:"quote_volume_24h"`
+	PriceChange24h string `json:"price_change_24h"`
+	High24h        string `json:"high_24h"`
+	Low24h         string `json:"low_24h"`
+}
+
+// golden is the recorded outcome of parsing one fixture. A parser that cannot
+// handle a response is recorded with its error, so a fix shows up as a
+// golden diff just like a regression does.
+type golden struct {
+	Error   string           `json:"error,omitempty"`
+	Tickers []goldenTicker   `json:"tickers,omitempty"`
+	Symbols []ExchangeSymbol `json:"symbols,omitempty"`
+}
+
+func TestParsersGolden(t *testing.T) {
+	factory, err := NewExchangeFactory(filepath.Join("..", "..", "configs", "exchanges.json"), zap.NewNop())
+	if err != nil {
+		t.Fatalf("loading exchange configs: %v", err)
+	}
+
+	for _, exchangeID := range factory.GetActiveExchanges() {
+		config := factory.configs[exchangeID]
+		if config.Disabled {
+			continue
+		}
+
+		t.Run(exchangeID, func(t *testing.T) {
+			parser := factory.createParser(exchangeID, config)
+			dir := filepath.Join("testdata", exchangeID)
+
+			t.Run("tickers", func(t *testing.T) {
+				var g golden
+				tickers, err := parser.ParseTickers(readFixture(t, dir, "tickers.json"), exchangeID)
+				if err != nil {
+					g.Error = err.Error()
+				}
+				for _, ticker := range tickers {
+					if ticker.ExchangeID != exchangeID {
+						t.Errorf("ticker %s has exchange %q", ticker.Symbol, ticker.ExchangeID)
+					}
+					if ticker.Timestamp.IsZero() {
+						t.Errorf("ticker %s has no timestamp", ticker.Symbol)
+					}
+					g.Tickers = append(g.Tickers, goldenTicker{
+						Symbol:         ticker.Symbol,
+						BaseSymbol:     ticker.BaseSymbol,
+						QuoteSymbol:    ticker.QuoteSymbol,
+						Price:          ticker.Price.String(),
+						Volume24h:      ticker.Volume24h.String(),
+						QuoteVolume24h: ticker.QuoteVolume24h.String(),
+						PriceChange24h: ticker.PriceChange24h.String(),
+						High24h:        ticker.High24h.String(),
+						Low24h:         ticker.Low24h.String(),
+					})
+				}
+				// Map-shaped responses come back in random order
+				sort.Slice(g.Tickers, func(i, j int) bool { return g.Tickers[i].Symbol < g.Tickers[j].Symbol })
+				compareGolden(t, filepath.Join(dir, "tickers.golden.json"), g)
+			})
+
+			t.Run("symbols", func(t *testing.T) {
+				var g golden
+				symbols, err := parser.ParseSymbols(readFixture(t, dir, "symbols.json"), exchangeID)
+				if err != nil {
+					g.Error = err.Error()
+				}
+				g.Symbols = symbols
+				sort.Slice(g.Symbols, func(i, j int) bool { return g.Symbols[i].Symbol < g.Symbols[j].Symbol })
+				compareGolden(t, filepath.Join(dir, "symbols.golden.json"), g)
+			})
+		})
+	}
+}
+
+func readFixture(t *testing.T, dir, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("every enabled exchange needs a recorded %s: %v", name, err)
+	}
+	return data
+}
+
+func compareGolden(t *testing.T, path string, got golden) {
+	t.Helper()
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatalf("marshaling result: %v", err)
+	}
+	data = append(data, '\n')
+
+	if *update {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("parsed output differs from %s (run with -update if intended)\ngot:\n%s\nwant:\n%s", path, data, want)
+	}
+}
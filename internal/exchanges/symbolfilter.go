@@ -0,0 +1,96 @@
+package exchanges
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SymbolFilter keeps an exchange's symbols matching its allow patterns, or
+// every symbol when there are none, unless they match a deny pattern.
+// Patterns use path.Match syntax, such as "*3L*" or "SCAM*", and match
+// case-insensitively against the exchange's symbol (BTCUSDT, BTC-USDT) and
+// against BASE/QUOTE once the pair is split. A nil filter keeps everything.
+type SymbolFilter struct {
+	allow []string
+	deny  []string
+}
+
+// NewSymbolFilter compiles the allow and deny patterns, returning nil when
+// there are none
+func NewSymbolFilter(allow, deny []string) (*SymbolFilter, error) {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil, nil
+	}
+	f := &SymbolFilter{}
+	for _, list := range []struct {
+		patterns []string
+		dst      *[]string
+	}{{allow, &f.allow}, {deny, &f.deny}} {
+		for _, p := range list.patterns {
+			p = strings.ToUpper(strings.TrimSpace(p))
+			if _, err := path.Match(p, ""); err != nil || p == "" {
+				return nil, fmt.Errorf("invalid symbol pattern %q", p)
+			}
+			*list.dst = append(*list.dst, p)
+		}
+	}
+	return f, nil
+}
+
+// Keep reports whether a symbol passes the filter. base and quote may be
+// empty when the pair is not split.
+func (f *SymbolFilter) Keep(symbol, base, quote string) bool {
+	if f == nil {
+		return true
+	}
+	subjects := []string{strings.ToUpper(symbol)}
+	if base != "" && quote != "" {
+		subjects = append(subjects, strings.ToUpper(base+"/"+quote))
+	}
+	if len(f.allow) > 0 && !matchAny(f.allow, subjects) {
+		return false
+	}
+	return !matchAny(f.deny, subjects)
+}
+
+func matchAny(patterns, subjects []string) bool {
+	for _, p := range patterns {
+		for _, s := range subjects {
+			if ok, _ := path.Match(p, s); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterTickers drops the tickers the filter does not keep, in place, and
+// returns the rest with how many were dropped
+func (f *SymbolFilter) FilterTickers(tickers []TickerData) ([]TickerData, int) {
+	if f == nil {
+		return tickers, 0
+	}
+	kept := tickers[:0]
+	for _, t := range tickers {
+		if f.Keep(t.Symbol, t.BaseSymbol, t.QuoteSymbol) {
+			kept = append(kept, t)
+		}
+	}
+	return kept, len(tickers) - len(kept)
+}
+
+// FilterSymbols drops the symbols the filter does not keep, in place, and
+// returns the rest with how many were dropped
+func (f *SymbolFilter) FilterSymbols(symbols []ExchangeSymbol) ([]ExchangeSymbol, int) {
+	if f == nil {
+		return symbols, 0
+	}
+	kept := symbols[:0]
+	for _, s := range symbols {
+		if f.Keep(s.Symbol, s.BaseSymbol, s.QuoteSymbol) {
+			kept = append(kept, s)
+		}
+	}
+	return kept, len(symbols) - len(kept)
+}
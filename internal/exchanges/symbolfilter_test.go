@@ -0,0 +1,29 @@
+package exchanges
+
+import "testing"
+
+func TestSymbolFilter(t *testing.T) {
+	f, err := NewSymbolFilter([]string{"*usdt", "*/USDC"}, []string{"*3L*", "*3S*", "SCAM*"})
+	if err != nil {
+		t.Fatalf("NewSymbolFilter: %v", err)
+	}
+
+	tickers := []TickerData{
+		{Symbol: "BTCUSDT", BaseSymbol: "BTC", QuoteSymbol: "USDT"},
+		{Symbol: "ETH-USDC", BaseSymbol: "ETH", QuoteSymbol: "USDC"}, // allowed as BASE/QUOTE
+		{Symbol: "BTC3LUSDT", BaseSymbol: "BTC3L", QuoteSymbol: "USDT"},
+		{Symbol: "SCAMUSDT", BaseSymbol: "SCAM", QuoteSymbol: "USDT"},
+		{Symbol: "ETHBTC", BaseSymbol: "ETH", QuoteSymbol: "BTC"}, // not allowed
+	}
+	kept, dropped := f.FilterTickers(tickers)
+	if dropped != 3 || len(kept) != 2 || kept[0].Symbol != "BTCUSDT" || kept[1].Symbol != "ETH-USDC" {
+		t.Errorf("kept %+v, dropped %d", kept, dropped)
+	}
+
+	if _, err := NewSymbolFilter(nil, []string{"[BTC"}); err == nil {
+		t.Error("bad pattern accepted")
+	}
+	if f, err := NewSymbolFilter(nil, nil); f != nil || err != nil || !f.Keep("ANY", "", "") {
+		t.Errorf("empty filter = %+v, err %v", f, err)
+	}
+}
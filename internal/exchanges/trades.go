@@ -0,0 +1,179 @@
+package exchanges
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// makerSideExchanges give the maker's side in a trade's side field rather
+// than the taker's
+var makerSideExchanges = map[string]bool{
+	"coinbase": true,
+}
+
+// SupportsTrades reports whether the exchange has a trades endpoint
+func (g *GenericRESTClient) SupportsTrades() bool {
+	return g.config.TradesEndpoint != ""
+}
+
+// GetRecentTrades fetches the most recent trades of a pair, oldest first.
+// Trades without a numeric ID are dropped, since pollers page by ID.
+func (g *GenericRESTClient) GetRecentTrades(ctx context.Context, symbol string) ([]Trade, error) {
+	if g.config.TradesEndpoint == "" {
+		return nil, fmt.Errorf("%w: %s", ErrTradesNotSupported, g.config.ID)
+	}
+	endpoint := strings.ReplaceAll(g.config.TradesEndpoint, "{symbol}", url.QueryEscape(symbol))
+
+	buf := getBodyBuffer()
+	if err := g.makeRequest(ctx, g.config.BaseURL+endpoint, buf); err != nil {
+		putBodyBuffer(buf)
+		return nil, fmt.Errorf("fetching trades for %s: %w", symbol, err)
+	}
+	return parseResponse(ctx, g.limits, buf, func(data []byte) ([]Trade, error) {
+		return ParseTrades(data, makerSideExchanges[g.config.ID])
+	})
+}
+
+// ParseTrades reads a recent-trades response: an array of trades, or one
+// under data, result or result.list. Field names vary by exchange, so the
+// common ones are tried in turn. makerSide is set for exchanges whose side
+// field is the maker's.
+func ParseTrades(data []byte, makerSide bool) ([]Trade, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var body interface{}
+	if err := dec.Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding trades: %w", err)
+	}
+
+	list, ok := tradeList(body)
+	if !ok {
+		return nil, fmt.Errorf("unable to parse trades response")
+	}
+
+	trades := make([]Trade, 0, len(list))
+	for _, item := range list {
+		raw, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		t, ok := parseTrade(raw, makerSide)
+		if ok {
+			trades = append(trades, t)
+		}
+	}
+	sort.Slice(trades, func(i, j int) bool { return trades[i].ID < trades[j].ID })
+	return trades, nil
+}
+
+func tradeList(body interface{}) ([]interface{}, bool) {
+	switch v := body.(type) {
+	case []interface{}:
+		return v, true
+	case map[string]interface{}:
+		for _, field := range []string{"data", "result", "list", "trades"} {
+			if inner, ok := v[field]; ok {
+				if list, ok := tradeList(inner); ok {
+					return list, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+func parseTrade(raw map[string]interface{}, makerSide bool) (Trade, bool) {
+	id, ok := tradeID(firstField(raw, "id", "tradeId", "trade_id", "execId", "sequence", "a"))
+	if !ok {
+		return Trade{}, false
+	}
+	t := Trade{
+		ID:       id,
+		Price:    parseDecimalSafe(firstField(raw, "price", "px", "p")),
+		Quantity: parseDecimalSafe(firstField(raw, "qty", "quantity", "size", "sz", "amount", "q")),
+	}
+	if t.Price.Sign() <= 0 || t.Quantity.Sign() <= 0 {
+		return Trade{}, false
+	}
+	t.Timestamp, ok = tradeTime(firstField(raw, "create_time_ms", "time", "ts", "T", "timestamp", "create_time"))
+	if !ok {
+		return Trade{}, false
+	}
+
+	if m, ok := firstField(raw, "isBuyerMaker", "m").(bool); ok {
+		t.IsBuyerMaker = m
+	} else if side, ok := firstField(raw, "side").(string); ok {
+		buy := strings.EqualFold(side, "buy")
+		// A taker buy means the seller was the maker
+		t.IsBuyerMaker = buy == makerSide
+	}
+	return t, true
+}
+
+func firstField(raw map[string]interface{}, names ...string) interface{} {
+	for _, name := range names {
+		if v, ok := raw[name]; ok && v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func tradeID(v interface{}) (uint64, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		return 0, false
+	}
+	id, err := strconv.ParseUint(s, 10, 64)
+	return id, err == nil && id > 0
+}
+
+// tradeTime reads RFC 3339 times and Unix times in seconds, milliseconds,
+// microseconds or nanoseconds, telling them apart by magnitude
+func tradeTime(v interface{}) (time.Time, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+
+	d, err := decimal.NewFromString(s)
+	if err != nil || d.Sign() <= 0 {
+		return time.Time{}, false
+	}
+	for _, unit := range []struct {
+		min  int64
+		nsec int64
+	}{
+		{1e17, 1},
+		{1e14, 1e3},
+		{1e11, 1e6},
+		{0, 1e9},
+	} {
+		if d.GreaterThanOrEqual(decimal.NewFromInt(unit.min)) {
+			return time.Unix(0, d.Mul(decimal.NewFromInt(unit.nsec)).IntPart()).UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
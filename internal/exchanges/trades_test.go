@@ -0,0 +1,78 @@
+package exchanges
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTrades(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		makerSide bool
+		wantTime  time.Time
+		wantMaker bool
+	}{
+		{
+			name:     "bybit result.list with taker side",
+			body:     `{"retCode":0,"result":{"list":[{"execId":"2100000000007764263","price":"16618.49","size":"0.00012","side":"Buy","time":"1672052955758"}]}}`,
+			wantTime: time.UnixMilli(1672052955758),
+		},
+		{
+			name:      "okx data",
+			body:      `{"code":"0","data":[{"instId":"BTC-USDT","side":"sell","sz":"0.00001","px":"29963.2","tradeId":"242720720","ts":"1654161646974"}]}`,
+			wantTime:  time.UnixMilli(1654161646974),
+			wantMaker: true,
+		},
+		{
+			name:     "gate create_time_ms with fraction",
+			body:     `[{"id":"1232893232","create_time":"1548000000","create_time_ms":"1548000000123.456","side":"buy","amount":"0.1","price":"100"}]`,
+			wantTime: time.Unix(0, 1548000000123456000),
+		},
+		{
+			name:     "kucoin nanoseconds",
+			body:     `{"code":"200000","data":[{"sequence":"1545896668571","price":"0.07","size":"0.004","side":"buy","time":1545904567062140823}]}`,
+			wantTime: time.Unix(0, 1545904567062140823),
+		},
+		{
+			name:      "coinbase maker side",
+			body:      `[{"time":"2014-11-07T22:19:28.578544Z","trade_id":74,"price":"10.00000000","size":"0.01000000","side":"buy"}]`,
+			makerSide: true,
+			wantTime:  time.Date(2014, 11, 7, 22, 19, 28, 578544000, time.UTC),
+			wantMaker: true,
+		},
+		{
+			name:      "binance isBuyerMaker",
+			body:      `[{"id":28457,"price":"4.00000100","qty":"12.00000000","time":1499865549590,"isBuyerMaker":true}]`,
+			wantTime:  time.UnixMilli(1499865549590),
+			wantMaker: true,
+		},
+	}
+	for _, tt := range tests {
+		trades, err := ParseTrades([]byte(tt.body), tt.makerSide)
+		if err != nil || len(trades) != 1 {
+			t.Errorf("%s: ParseTrades = %+v, %v", tt.name, trades, err)
+			continue
+		}
+		got := trades[0]
+		if !got.Timestamp.Equal(tt.wantTime) || got.IsBuyerMaker != tt.wantMaker || got.ID == 0 || got.Price.Sign() <= 0 {
+			t.Errorf("%s: trade = %+v, want time %v and buyer maker %v", tt.name, got, tt.wantTime, tt.wantMaker)
+		}
+	}
+}
+
+func TestParseTradesSkipsUnusable(t *testing.T) {
+	body := `[
+		{"id":"3","price":"1","qty":"1","time":1700000000000},
+		{"id":"abc","price":"1","qty":"1","time":1700000000000},
+		{"id":"2","price":"0","qty":"1","time":1700000000000},
+		{"id":"1","price":"1","qty":"1","time":1700000000000}
+	]`
+	trades, err := ParseTrades([]byte(body), false)
+	if err != nil {
+		t.Fatalf("ParseTrades: %v", err)
+	}
+	if len(trades) != 2 || trades[0].ID != 1 || trades[1].ID != 3 {
+		t.Errorf("trades = %+v, want IDs 1 and 3 in order", trades)
+	}
+}
@@ -0,0 +1,87 @@
+package exchanges
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TransportConfig tunes the HTTP transport the exchange clients share.
+// Polling every exchange on one transport reuses its kept-alive connections,
+// where a transport per client, or Go's default of two idle connections per
+// host, keeps opening new ones and runs out of ephemeral ports under load.
+type TransportConfig struct {
+	MaxIdleConns        int           // across all exchanges
+	MaxIdleConnsPerHost int           // kept alive per exchange host
+	MaxConnsPerHost     int           // open at once per exchange host; 0 is no limit
+	IdleConnTimeout     time.Duration // before an idle connection is closed
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration // TCP keep-alive probe interval
+	TLSHandshakeTimeout time.Duration
+	TLSMinVersion       uint16 // a tls.Version constant
+	InsecureSkipVerify  bool   // for test exchanges with self-signed certificates only
+	DisableCompression  bool   // stop asking for gzip, trading CPU for bandwidth
+	DisableHTTP2        bool   // speak HTTP/1.1 only
+}
+
+// DefaultTransportConfig returns the transport settings for polling the
+// configured exchanges
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        200,
+		MaxIdleConnsPerHost: 10,
+		MaxConnsPerHost:     32,
+		IdleConnTimeout:     90 * time.Second,
+		DialTimeout:         10 * time.Second,
+		KeepAlive:           30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSMinVersion:       tls.VersionTLS12,
+	}
+}
+
+// NewTransport creates a transport from cfg, taking proxies from the
+// environment as http.DefaultTransport does
+func NewTransport(cfg TransportConfig) *http.Transport {
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: cfg.KeepAlive}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.MaxConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+		TLSHandshakeTimeout: cfg.TLSHandshakeTimeout,
+		DisableCompression:  cfg.DisableCompression,
+		TLSClientConfig: &tls.Config{
+			MinVersion:         cfg.TLSMinVersion,
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+		},
+		ExpectContinueTimeout: time.Second,
+	}
+	if cfg.DisableHTTP2 {
+		// A non-nil, empty map is how a transport is kept from upgrading
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	} else {
+		// Needed for HTTP/2 once TLSClientConfig or DialContext are set
+		transport.ForceAttemptHTTP2 = true
+	}
+	return transport
+}
+
+// ParseTLSVersion parses a TLS version such as "1.2"
+func ParseTLSVersion(s string) (uint16, error) {
+	switch strings.TrimPrefix(strings.TrimSpace(s), "TLS") {
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q: want 1.0, 1.1, 1.2 or 1.3", s)
+}
@@ -0,0 +1,41 @@
+package exchanges
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestNewTransport(t *testing.T) {
+	cfg := DefaultTransportConfig()
+	transport := NewTransport(cfg)
+	if transport.MaxIdleConnsPerHost != cfg.MaxIdleConnsPerHost || transport.MaxConnsPerHost != cfg.MaxConnsPerHost {
+		t.Errorf("limits = %d idle, %d open per host", transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+	}
+	if !transport.ForceAttemptHTTP2 || transport.TLSNextProto != nil {
+		t.Error("HTTP/2 is not attempted by default")
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("TLS min version = %x", transport.TLSClientConfig.MinVersion)
+	}
+
+	cfg.DisableHTTP2 = true
+	cfg.DisableCompression = true
+	transport = NewTransport(cfg)
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil || len(transport.TLSNextProto) != 0 {
+		t.Error("HTTP/2 is still enabled")
+	}
+	if !transport.DisableCompression {
+		t.Error("compression is still enabled")
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	for in, want := range map[string]uint16{"1.2": tls.VersionTLS12, "TLS1.3": tls.VersionTLS13, " 1.0": tls.VersionTLS10} {
+		if got, err := ParseTLSVersion(in); err != nil || got != want {
+			t.Errorf("ParseTLSVersion(%q) = %x, %v", in, got, err)
+		}
+	}
+	if _, err := ParseTLSVersion("2.0"); err == nil {
+		t.Error("ParseTLSVersion(2.0) did not fail")
+	}
+}
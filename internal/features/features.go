@@ -0,0 +1,240 @@
+// Package features holds the feature flags gating risky pipeline changes, so
+// a change can be rolled out per environment with FEATURE_FLAGS and turned
+// off again at runtime, from the feature_flags table, without a redeploy.
+package features
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"go.uber.org/zap"
+)
+
+// Flag names
+const (
+	// FXConversion counts pairs quoted in a fiat currency, converted with
+	// the latest FX rate, towards the pair's USD VWAP
+	FXConversion = "vwap_fx_conversion"
+
+	// DynamicWeights weights exchanges in the VWAP by their dynamic weight,
+	// computed daily from volume share and uptime, instead of the static
+	// one
+	DynamicWeights = "dynamic_exchange_weights"
+
+	// TrustWeighting multiplies each exchange's VWAP weight by the trust
+	// score of its volume, down-weighting venues suspected of wash trading
+	TrustWeighting = "vwap_trust_weighting"
+
+	// NonSpotIngest stores leveraged token and derivative tickers, tagged
+	// with their instrument type, instead of dropping them. Spot composites
+	// leave them out either way.
+	NonSpotIngest = "ingest_non_spot"
+)
+
+// Flag is a known feature flag
+type Flag struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// Known lists every feature flag. Only these can be set.
+var Known = []Flag{
+	{
+		Name:        FXConversion,
+		Description: "Convert fiat-quoted pairs to USD with the latest FX rate and count them towards the USD VWAP",
+		Default:     true,
+	},
+	{
+		Name:        DynamicWeights,
+		Description: "Weight exchanges in the VWAP by their daily dynamic weight from volume share and uptime instead of their static weight",
+		Default:     false,
+	},
+	{
+		Name:        TrustWeighting,
+		Description: "Multiply each exchange's VWAP weight by the trust score of its volume, down-weighting venues suspected of wash trading",
+		Default:     false,
+	},
+	{
+		Name:        NonSpotIngest,
+		Description: "Store leveraged token, perpetual and futures tickers tagged with their instrument type instead of dropping them; VWAP and the other spot composites leave them out",
+		Default:     false,
+	},
+}
+
+// ErrUnknownFlag is returned when setting a flag that is not in Known
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// Where a flag's value comes from
+const (
+	SourceDefault  = "default"
+	SourceEnv      = "env"
+	SourceDatabase = "database"
+)
+
+// State is a flag's current value and where it comes from
+type State struct {
+	Flag
+	Enabled bool
+	Source  string
+	// Override is the database override, when Source is SourceDatabase
+	Override *db.FeatureFlag
+}
+
+// Lookup returns the known flag of a name
+func Lookup(name string) (Flag, bool) {
+	for _, f := range Known {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+// ParseEnv parses FEATURE_FLAGS, a comma separated list of name=bool
+// entries
+func ParseEnv(spec string) (map[string]bool, error) {
+	values := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid feature flag %q: want name=true or name=false", part)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for feature flag %s: %q", name, raw)
+		}
+		values[name] = enabled
+	}
+	return values, nil
+}
+
+// Flags resolves each flag from, in order of precedence, its override in
+// the feature_flags table, the environment and its default. Overrides are
+// cached and reloaded periodically, so checking a flag never queries the
+// database. It is safe for concurrent use.
+type Flags struct {
+	postgresDB *sql.DB
+	env        map[string]bool
+	logger     *zap.Logger
+
+	mu        sync.RWMutex
+	overrides map[string]db.FeatureFlag
+}
+
+// NewFlags creates flags on top of the values from the environment.
+// postgresDB may be nil, in which case there are no overrides.
+func NewFlags(postgresDB *sql.DB, env map[string]bool, logger *zap.Logger) *Flags {
+	for name := range env {
+		if _, ok := Lookup(name); !ok {
+			logger.Warn("Ignoring unknown feature flag in FEATURE_FLAGS", zap.String("flag", name))
+		}
+	}
+	return &Flags{
+		postgresDB: postgresDB,
+		env:        env,
+		logger:     logger,
+		overrides:  make(map[string]db.FeatureFlag),
+	}
+}
+
+// Enabled reports whether a flag is on. On nil Flags every flag has its
+// default, and unknown flags are off.
+func (f *Flags) Enabled(name string) bool {
+	return f.state(name).Enabled
+}
+
+// States returns every known flag's state, ordered by name
+func (f *Flags) States() []State {
+	states := make([]State, 0, len(Known))
+	for _, flag := range Known {
+		states = append(states, f.state(flag.Name))
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// State returns a known flag's state
+func (f *Flags) State(name string) (State, error) {
+	if _, ok := Lookup(name); !ok {
+		return State{}, fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+	}
+	return f.state(name), nil
+}
+
+func (f *Flags) state(name string) State {
+	flag, ok := Lookup(name)
+	if !ok {
+		return State{Flag: Flag{Name: name}, Source: SourceDefault}
+	}
+	s := State{Flag: flag, Enabled: flag.Default, Source: SourceDefault}
+	if f == nil {
+		return s
+	}
+	if enabled, ok := f.env[name]; ok {
+		s.Enabled, s.Source = enabled, SourceEnv
+	}
+	f.mu.RLock()
+	override, ok := f.overrides[name]
+	f.mu.RUnlock()
+	if ok {
+		s.Enabled, s.Source, s.Override = override.Enabled, SourceDatabase, &override
+	}
+	return s
+}
+
+// Set replaces all overrides
+func (f *Flags) Set(overrides map[string]db.FeatureFlag) {
+	f.mu.Lock()
+	f.overrides = overrides
+	f.mu.Unlock()
+}
+
+// Reload replaces the overrides with those in the database
+func (f *Flags) Reload(ctx context.Context) error {
+	if f.postgresDB == nil {
+		return nil
+	}
+	rows, err := db.ListFeatureFlags(ctx, f.postgresDB)
+	if err != nil {
+		return fmt.Errorf("loading feature flags: %w", err)
+	}
+	overrides := make(map[string]db.FeatureFlag, len(rows))
+	for _, r := range rows {
+		overrides[r.Name] = r
+	}
+	f.Set(overrides)
+	return nil
+}
+
+// Run reloads the overrides on start and then every interval until ctx is
+// done, so changes made through another process are picked up
+func (f *Flags) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.Reload(ctx); err != nil && ctx.Err() == nil {
+			f.logger.Error("Failed to reload feature flags", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
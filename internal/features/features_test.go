@@ -0,0 +1,48 @@
+package features
+
+import (
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"go.uber.org/zap"
+)
+
+func TestFlagPrecedence(t *testing.T) {
+	var none *Flags
+	if !none.Enabled(FXConversion) {
+		t.Errorf("nil flags: %s off, want its default", FXConversion)
+	}
+
+	f := NewFlags(nil, map[string]bool{FXConversion: false}, zap.NewNop())
+	if s := f.state(FXConversion); s.Enabled || s.Source != SourceEnv {
+		t.Errorf("env: got %v from %s, want false from env", s.Enabled, s.Source)
+	}
+
+	f.Set(map[string]db.FeatureFlag{FXConversion: {Name: FXConversion, Enabled: true}})
+	if s := f.state(FXConversion); !s.Enabled || s.Source != SourceDatabase {
+		t.Errorf("override: got %v from %s, want true from database", s.Enabled, s.Source)
+	}
+
+	if f.Enabled("no_such_flag") {
+		t.Error("unknown flag enabled")
+	}
+	if _, err := f.State("no_such_flag"); err == nil {
+		t.Error("State of an unknown flag succeeded")
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	got, err := ParseEnv(" vwap_fx_conversion=false , Other=1,")
+	if err != nil {
+		t.Fatalf("ParseEnv: %v", err)
+	}
+	if len(got) != 2 || got["vwap_fx_conversion"] || !got["other"] {
+		t.Errorf("ParseEnv = %v", got)
+	}
+
+	for _, spec := range []string{"vwap_fx_conversion", "=true", "vwap_fx_conversion=maybe"} {
+		if _, err := ParseEnv(spec); err == nil {
+			t.Errorf("ParseEnv(%q) succeeded, want an error", spec)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package fx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ECBDailyURL is the European Central Bank's reference rates feed. It is
+// published around 16:00 CET on TARGET working days and needs no API key.
+const ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// Rates is a day's fiat rates in US dollars per unit of each currency
+type Rates struct {
+	Date time.Time
+	USD  map[string]decimal.Decimal
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB reads the ECB feed, whose rates are units of each currency per
+// euro, and rebases them on the dollar. EUR itself is included.
+func ParseECB(r io.Reader) (Rates, error) {
+	var env ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return Rates{}, fmt.Errorf("failed to decode ECB rates: %w", err)
+	}
+	if len(env.Days) == 0 {
+		return Rates{}, fmt.Errorf("no rates in ECB feed")
+	}
+	day := env.Days[0]
+
+	date, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return Rates{}, fmt.Errorf("invalid ECB rate date %q: %w", day.Time, err)
+	}
+
+	perEUR := make(map[string]decimal.Decimal, len(day.Rates))
+	for _, r := range day.Rates {
+		rate, err := decimal.NewFromString(r.Rate)
+		if err != nil || !rate.IsPositive() {
+			continue
+		}
+		perEUR[r.Currency] = rate
+	}
+	usdPerEUR, ok := perEUR["USD"]
+	if !ok {
+		return Rates{}, fmt.Errorf("no USD rate in ECB feed for %s", day.Time)
+	}
+
+	rates := Rates{Date: date, USD: map[string]decimal.Decimal{"EUR": usdPerEUR}}
+	for currency, rate := range perEUR {
+		if currency == "USD" {
+			continue
+		}
+		rates.USD[currency] = usdPerEUR.DivRound(rate, 12)
+	}
+	return rates, nil
+}
@@ -0,0 +1,52 @@
+package fx
+
+import (
+	"strings"
+	"testing"
+)
+
+const ecbFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time="2026-10-13">
+			<Cube currency="USD" rate="1.1000"/>
+			<Cube currency="JPY" rate="165.00"/>
+			<Cube currency="TRY" rate="44.00"/>
+			<Cube currency="BRL" rate="5.5000"/>
+			<Cube currency="XXX" rate="n/a"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestParseECB(t *testing.T) {
+	rates, err := ParseECB(strings.NewReader(ecbFeed))
+	if err != nil {
+		t.Fatalf("ParseECB: %v", err)
+	}
+	if got := rates.Date.Format("2006-01-02"); got != "2026-10-13" {
+		t.Errorf("date = %s", got)
+	}
+
+	want := map[string]string{
+		"EUR": "1.1",
+		"JPY": "0.006666666667",
+		"TRY": "0.025",
+		"BRL": "0.2",
+	}
+	if len(rates.USD) != len(want) {
+		t.Errorf("rates = %v, want %d currencies", rates.USD, len(want))
+	}
+	for currency, rate := range want {
+		if got := rates.USD[currency]; got.String() != rate {
+			t.Errorf("%s = %s, want %s", currency, got, rate)
+		}
+	}
+
+	if _, err := ParseECB(strings.NewReader(`<Envelope><Cube><Cube time="2026-10-13"><Cube currency="JPY" rate="165"/></Cube></Cube></Envelope>`)); err == nil {
+		t.Error("feed without USD: expected an error")
+	}
+}
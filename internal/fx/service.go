@@ -0,0 +1,67 @@
+package fx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"go.uber.org/zap"
+)
+
+// source is recorded against every rate stored from the ECB feed
+const source = "ecb"
+
+// Service fetches the daily fiat rates into fx_rates
+type Service struct {
+	postgresDB *sql.DB
+	httpClient *http.Client
+	url        string
+	logger     *zap.Logger
+}
+
+// NewService creates a new FX service reading the ECB feed at url, or at
+// ECBDailyURL when url is empty
+func NewService(postgresDB *sql.DB, url string, logger *zap.Logger) *Service {
+	if url == "" {
+		url = ECBDailyURL
+	}
+	return &Service{
+		postgresDB: postgresDB,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		url:        url,
+		logger:     logger,
+	}
+}
+
+// Refresh fetches the latest rates and stores them. It returns the number of
+// currencies stored.
+func (s *Service) Refresh(ctx context.Context) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch fx rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("fx rates request failed with status %d", resp.StatusCode)
+	}
+
+	rates, err := ParseECB(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	if err := db.StoreFXRates(ctx, s.postgresDB, rates.Date, source, rates.USD); err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("Stored fx rates",
+		zap.String("date", rates.Date.Format("2006-01-02")),
+		zap.Int("currencies", len(rates.USD)))
+	return len(rates.USD), nil
+}
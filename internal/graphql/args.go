@@ -0,0 +1,42 @@
+package graphql
+
+import (
+	"fmt"
+	"math"
+)
+
+// IntArg reads an integer argument, accepting literals and JSON variables
+func IntArg(args map[string]interface{}, name string, defaultValue int) (int, error) {
+	raw, ok := args[name]
+	if !ok || raw == nil {
+		return defaultValue, nil
+	}
+
+	switch v := raw.(type) {
+	case int64:
+		return int(v), nil
+	case int:
+		return v, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("argument %q must be an integer", name)
+		}
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("argument %q must be an integer", name)
+	}
+}
+
+// StringArg reads a string argument
+func StringArg(args map[string]interface{}, name string, defaultValue string) (string, error) {
+	raw, ok := args[name]
+	if !ok || raw == nil {
+		return defaultValue, nil
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %q must be a string", name)
+	}
+	return s, nil
+}
@@ -0,0 +1,294 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ResolveFunc resolves a field value from its parent object and arguments
+type ResolveFunc func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// Field describes a field on an object type. A nil Type means the field is a
+// scalar (or a JSON-serializable leaf value) and must not have a selection set.
+type Field struct {
+	Type        *Object
+	Description string
+	Resolve     ResolveFunc
+}
+
+// Object is a named object type with its fields
+type Object struct {
+	Name        string
+	Description string
+	Fields      map[string]*Field
+}
+
+// Schema holds the root query type
+type Schema struct {
+	Query *Object
+}
+
+// Request is the standard GraphQL-over-HTTP request body
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Error is a GraphQL error entry
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Result is the GraphQL response document
+type Result struct {
+	Data   *OrderedMap `json:"data"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// OrderedMap preserves field order in the JSON output, as required by the spec
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *OrderedMap {
+	return &OrderedMap{values: make(map[string]interface{})}
+}
+
+// Set stores a key, keeping the position of the first insertion
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get returns the value stored for key
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// MarshalJSON implements json.Marshaler
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Execute parses and runs a request against the schema. Field errors are
+// reported alongside partial data; parse errors produce a nil data document.
+func (s *Schema) Execute(ctx context.Context, req Request) *Result {
+	op, err := Parse(req.Query, req.OperationName)
+	if err != nil {
+		return &Result{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	vars := make(map[string]interface{}, len(op.Variables))
+	for name, def := range op.Variables {
+		vars[name] = def
+	}
+	for name, value := range req.Variables {
+		vars[name] = value
+	}
+
+	e := &executor{schema: s, variables: vars}
+	data := e.executeSelections(ctx, s.Query, nil, op.Selections, nil)
+	return &Result{Data: data, Errors: e.errors}
+}
+
+type executor struct {
+	schema    *Schema
+	variables map[string]interface{}
+	errors    []Error
+}
+
+func (e *executor) addError(path []interface{}, format string, args ...interface{}) {
+	p := make([]interface{}, len(path))
+	copy(p, path)
+	e.errors = append(e.errors, Error{Message: fmt.Sprintf(format, args...), Path: p})
+}
+
+func (e *executor) executeSelections(ctx context.Context, obj *Object, source interface{}, selections []*Selection, path []interface{}) *OrderedMap {
+	result := newOrderedMap()
+
+	for _, sel := range selections {
+		key := sel.ResponseKey()
+		fieldPath := append(path, key)
+
+		if sel.Name == "__typename" {
+			result.Set(key, obj.Name)
+			continue
+		}
+		if obj == e.schema.Query && sel.Name == "__schema" {
+			result.Set(key, e.describeSchema())
+			continue
+		}
+
+		field, ok := obj.Fields[sel.Name]
+		if !ok {
+			e.addError(fieldPath, "cannot query field %q on type %q", sel.Name, obj.Name)
+			continue
+		}
+
+		args, err := e.resolveArguments(sel.Arguments)
+		if err != nil {
+			e.addError(fieldPath, "%s", err.Error())
+			result.Set(key, nil)
+			continue
+		}
+
+		value, err := field.Resolve(ctx, source, args)
+		if err != nil {
+			e.addError(fieldPath, "%s", err.Error())
+			result.Set(key, nil)
+			continue
+		}
+
+		result.Set(key, e.completeValue(ctx, field, sel, value, fieldPath))
+	}
+
+	return result
+}
+
+func (e *executor) completeValue(ctx context.Context, field *Field, sel *Selection, value interface{}, path []interface{}) interface{} {
+	if field.Type == nil {
+		if len(sel.Selections) > 0 {
+			e.addError(path, "field %q is a scalar and cannot have a selection set", sel.Name)
+			return nil
+		}
+		return value
+	}
+
+	if len(sel.Selections) == 0 {
+		e.addError(path, "field %q of type %q must have a selection set", sel.Name, field.Type.Name)
+		return nil
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	if rv.Kind() == reflect.Slice {
+		items := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			items[i] = e.executeSelections(ctx, field.Type, rv.Index(i).Interface(), sel.Selections, append(path, i))
+		}
+		return items
+	}
+
+	return e.executeSelections(ctx, field.Type, value, sel.Selections, path)
+}
+
+func (e *executor) resolveArguments(raw map[string]interface{}) (map[string]interface{}, error) {
+	args := make(map[string]interface{}, len(raw))
+	for name, value := range raw {
+		resolved, err := e.resolveValue(value)
+		if err != nil {
+			return nil, err
+		}
+		args[name] = resolved
+	}
+	return args, nil
+}
+
+func (e *executor) resolveValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case variableRef:
+		resolved, ok := e.variables[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not defined", string(v))
+		}
+		return resolved, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := e.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			r, err := e.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// describeSchema returns a compact, non-standard description of the schema
+// so clients can discover the available types and fields
+func (e *executor) describeSchema() map[string]interface{} {
+	types := make(map[string]interface{})
+	var walk func(obj *Object)
+	walk = func(obj *Object) {
+		if _, seen := types[obj.Name]; seen {
+			return
+		}
+		fields := make([]map[string]interface{}, 0, len(obj.Fields))
+		types[obj.Name] = map[string]interface{}{"description": obj.Description, "fields": &fields}
+
+		names := make([]string, 0, len(obj.Fields))
+		for name := range obj.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			f := obj.Fields[name]
+			typeName := "Scalar"
+			if f.Type != nil {
+				typeName = f.Type.Name
+				walk(f.Type)
+			}
+			fields = append(fields, map[string]interface{}{
+				"name":        name,
+				"type":        typeName,
+				"description": f.Description,
+			})
+		}
+	}
+	walk(e.schema.Query)
+
+	return map[string]interface{}{
+		"queryType": e.schema.Query.Name,
+		"types":     types,
+	}
+}
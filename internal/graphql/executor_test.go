@@ -0,0 +1,99 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func testSchema() *Schema {
+	token := &Object{
+		Name: "Token",
+		Fields: map[string]*Field{
+			"symbol": {Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(string), nil
+			}},
+		},
+	}
+	return &Schema{Query: &Object{
+		Name: "Query",
+		Fields: map[string]*Field{
+			"tokens": {
+				Type: token,
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					limit, err := IntArg(args, "limit", 3)
+					if err != nil {
+						return nil, err
+					}
+					symbols := []string{"BTC", "ETH", "SOL"}
+					if limit < len(symbols) {
+						symbols = symbols[:limit]
+					}
+					return symbols, nil
+				},
+			},
+		},
+	}}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name string
+		req  Request
+		want string
+	}{
+		{
+			name: "literal argument and alias",
+			req:  Request{Query: `{ top: tokens(limit: 1) { symbol __typename } }`},
+			want: `{"data":{"top":[{"symbol":"BTC","__typename":"Token"}]}}`,
+		},
+		{
+			name: "variable default",
+			req:  Request{Query: `query($n: Int = 2) { tokens(limit: $n) { symbol } }`},
+			want: `{"data":{"tokens":[{"symbol":"BTC"},{"symbol":"ETH"}]}}`,
+		},
+		{
+			name: "variable overrides default",
+			req:  Request{Query: `query($n: Int = 2) { tokens(limit: $n) { symbol } }`, Variables: map[string]interface{}{"n": float64(1)}},
+			want: `{"data":{"tokens":[{"symbol":"BTC"}]}}`,
+		},
+		{
+			name: "undefined variable",
+			req:  Request{Query: `{ tokens(limit: $n) { symbol } }`},
+			want: `{"data":{"tokens":null},"errors":[{"message":"variable $n is not defined","path":["tokens"]}]}`,
+		},
+		{
+			name: "resolver error keeps partial data",
+			req:  Request{Query: `{ tokens(limit: "x") { symbol } ok: tokens(limit: 1) { symbol } }`},
+			want: `{"data":{"tokens":null,"ok":[{"symbol":"BTC"}]},"errors":[{"message":"argument \"limit\" must be an integer","path":["tokens"]}]}`,
+		},
+		{
+			name: "unknown field",
+			req:  Request{Query: `{ prices { symbol } }`},
+			want: `{"data":{},"errors":[{"message":"cannot query field \"prices\" on type \"Query\"","path":["prices"]}]}`,
+		},
+		{
+			name: "malformed query",
+			req:  Request{Query: `{ tokens { symbol }`},
+			want: `{"data":null,"errors":[{"message":"unterminated selection set"}]}`,
+		},
+		{
+			name: "fragment",
+			req:  Request{Query: `{ tokens { ...F } } fragment F on Token { symbol }`},
+			want: `{"data":null,"errors":[{"message":"fragments are not supported"}]}`,
+		},
+	}
+
+	schema := testSchema()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(schema.Execute(context.Background(), tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("result =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,486 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Selection is a single field requested in a selection set
+type Selection struct {
+	Alias      string
+	Name       string
+	Arguments  map[string]interface{}
+	Selections []*Selection
+}
+
+// ResponseKey returns the key the field is reported under in the result
+func (s *Selection) ResponseKey() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.Name
+}
+
+// Operation is a parsed query operation
+type Operation struct {
+	Type       string
+	Name       string
+	Variables  map[string]interface{} // variable name -> default value
+	Selections []*Selection
+}
+
+// variableRef marks an argument value that must be substituted at execution time
+type variableRef string
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// MaxDepth is how deeply selection sets, list and object values and variable
+// types may nest. The parser recurses on each level, so without a limit a
+// small request could exhaust the goroutine stack, which is fatal.
+const MaxDepth = 32
+
+// parser is a small recursive-descent parser for the executable subset of
+// GraphQL we support: query operations, aliases, arguments, variables and
+// nested selection sets. Fragments, directives and mutations are rejected.
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// Parse parses a GraphQL document and returns the requested operation.
+// If operationName is empty the document must contain exactly one operation.
+func Parse(query, operationName string) (*Operation, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	var ops []*Operation
+	for p.peek().kind != tokenEOF {
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("document contains no operations")
+	}
+	if operationName == "" {
+		if len(ops) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document contains multiple operations")
+		}
+		return ops[0], nil
+	}
+	for _, op := range ops {
+		if op.Name == operationName {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", operationName)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isPunct(value string) bool {
+	t := p.peek()
+	return t.kind == tokenPunct && t.value == value
+}
+
+func (p *parser) expectPunct(value string) error {
+	t := p.next()
+	if t.kind != tokenPunct || t.value != value {
+		return fmt.Errorf("expected %q at position %d, got %q", value, t.pos, t.value)
+	}
+	return nil
+}
+
+func (p *parser) expectName() (string, error) {
+	t := p.next()
+	if t.kind != tokenName {
+		return "", fmt.Errorf("expected name at position %d, got %q", t.pos, t.value)
+	}
+	return t.value, nil
+}
+
+// enter descends one nesting level, failing past MaxDepth; leave undoes it
+func (p *parser) enter(pos int) error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return fmt.Errorf("nesting deeper than %d levels at position %d", MaxDepth, pos)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) parseOperation() (*Operation, error) {
+	op := &Operation{Type: "query", Variables: make(map[string]interface{})}
+
+	// Shorthand query: { ... }
+	if p.isPunct("{") {
+		selections, err := p.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+		op.Selections = selections
+		return op, nil
+	}
+
+	opType, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	switch opType {
+	case "query":
+	case "mutation", "subscription":
+		return nil, fmt.Errorf("%s operations are not supported", opType)
+	case "fragment":
+		return nil, fmt.Errorf("fragments are not supported")
+	default:
+		return nil, fmt.Errorf("unexpected %q, expected an operation", opType)
+	}
+
+	if p.peek().kind == tokenName {
+		op.Name = p.next().value
+	}
+
+	if p.isPunct("(") {
+		if err := p.parseVariableDefinitions(op); err != nil {
+			return nil, err
+		}
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.Selections = selections
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinitions(op *Operation) error {
+	if err := p.expectPunct("("); err != nil {
+		return err
+	}
+	for !p.isPunct(")") {
+		if err := p.expectPunct("$"); err != nil {
+			return err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+
+		var defaultValue interface{}
+		if p.isPunct("=") {
+			p.next()
+			defaultValue, err = p.parseValue()
+			if err != nil {
+				return err
+			}
+		}
+		op.Variables[name] = defaultValue
+	}
+	return p.expectPunct(")")
+}
+
+// skipType consumes a type reference such as [String!]!; types are not
+// enforced, resolvers validate their own arguments
+func (p *parser) skipType() error {
+	if p.isPunct("[") {
+		if err := p.enter(p.next().pos); err != nil {
+			return err
+		}
+		defer p.leave()
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.expectName(); err != nil {
+		return err
+	}
+	if p.isPunct("!") {
+		p.next()
+	}
+	return nil
+}
+
+func (p *parser) parseSelectionSet() ([]*Selection, error) {
+	start := p.peek().pos
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	if err := p.enter(start); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	var selections []*Selection
+	for !p.isPunct("}") {
+		if p.peek().kind == tokenEOF {
+			return nil, fmt.Errorf("unterminated selection set")
+		}
+		if p.isPunct("...") {
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		if p.isPunct("@") {
+			return nil, fmt.Errorf("directives are not supported")
+		}
+
+		sel, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	p.next()
+
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("selection set must not be empty")
+	}
+	return selections, nil
+}
+
+func (p *parser) parseField() (*Selection, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	sel := &Selection{Name: name, Arguments: make(map[string]interface{})}
+	if p.isPunct(":") {
+		p.next()
+		sel.Alias = name
+		if sel.Name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isPunct("(") {
+		p.next()
+		for !p.isPunct(")") {
+			argName, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			sel.Arguments[argName] = value
+		}
+		p.next()
+	}
+
+	if p.isPunct("{") {
+		if sel.Selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+func (p *parser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenInt:
+		return strconv.ParseInt(t.value, 10, 64)
+	case tokenFloat:
+		return strconv.ParseFloat(t.value, 64)
+	case tokenString:
+		return t.value, nil
+	case tokenName:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			// Enum values are passed to resolvers as plain strings
+			return t.value, nil
+		}
+	case tokenPunct:
+		switch t.value {
+		case "$":
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			return variableRef(name), nil
+		case "[":
+			if err := p.enter(t.pos); err != nil {
+				return nil, err
+			}
+			defer p.leave()
+			var list []interface{}
+			for !p.isPunct("]") {
+				if p.peek().kind == tokenEOF {
+					return nil, fmt.Errorf("unterminated list value")
+				}
+				v, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			p.next()
+			return list, nil
+		case "{":
+			if err := p.enter(t.pos); err != nil {
+				return nil, err
+			}
+			defer p.leave()
+			obj := make(map[string]interface{})
+			for !p.isPunct("}") {
+				key, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectPunct(":"); err != nil {
+					return nil, err
+				}
+				v, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				obj[key] = v
+			}
+			p.next()
+			return obj, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", t.value, t.pos)
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	i := 0
+
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case unicode.IsSpace(r) || r == ',' || r == '\uFEFF':
+			i++
+		case r == '.':
+			if i+2 < len(runes) && runes[i+1] == '.' && runes[i+2] == '.' {
+				tokens = append(tokens, token{kind: tokenPunct, value: "...", pos: i})
+				i += 3
+				continue
+			}
+			return nil, fmt.Errorf("unexpected '.' at position %d", i)
+		case strings.ContainsRune("{}()[]:!$=@|&", r):
+			tokens = append(tokens, token{kind: tokenPunct, value: string(r), pos: i})
+			i++
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenName, value: string(runes[start:i]), pos: start})
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			kind := tokenInt
+			i++
+			for i < len(runes) {
+				c := runes[i]
+				if unicode.IsDigit(c) {
+					i++
+				} else if c == '.' || c == 'e' || c == 'E' || ((c == '+' || c == '-') && (runes[i-1] == 'e' || runes[i-1] == 'E')) {
+					kind = tokenFloat
+					i++
+				} else {
+					break
+				}
+			}
+			tokens = append(tokens, token{kind: kind, value: string(runes[start:i]), pos: start})
+		case r == '"':
+			start := i
+			i++
+			var sb strings.Builder
+			for {
+				if i >= len(runes) || runes[i] == '\n' {
+					return nil, fmt.Errorf("unterminated string at position %d", start)
+				}
+				c := runes[i]
+				if c == '"' {
+					i++
+					break
+				}
+				if c == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					case 'r':
+						sb.WriteRune('\r')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					i++
+					continue
+				}
+				sb.WriteRune(c)
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, value: sb.String(), pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
@@ -0,0 +1,62 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	op, err := Parse(`query Top($limit: Int = 5) { top: tokens(limit: $limit, tags: ["defi"]) { symbol } }`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op.Name != "Top" || op.Variables["limit"] != int64(5) {
+		t.Fatalf("operation = %+v", op)
+	}
+	sel := op.Selections[0]
+	if sel.ResponseKey() != "top" || sel.Name != "tokens" || len(sel.Selections) != 1 {
+		t.Fatalf("selection = %+v", sel)
+	}
+	if sel.Arguments["limit"] != variableRef("limit") {
+		t.Errorf("limit argument = %#v", sel.Arguments["limit"])
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"empty", ``, ""},
+		{"unclosed selection set", `{ tokens { symbol }`, ""},
+		{"unterminated string", `{ token(symbol: "BTC) { name } }`, ""},
+		{"missing argument value", `{ token(symbol:) { name } }`, ""},
+		{"fragment definition", `fragment F on Token { symbol }`, "fragments are not supported"},
+		{"fragment spread", `{ tokens { ...F } }`, "fragments are not supported"},
+		{"mutation", `mutation { addToken { id } }`, "mutation"},
+		{"deep selection sets", strings.Repeat("{ a ", MaxDepth+1) + strings.Repeat("}", MaxDepth+1), "nesting deeper"},
+		{"deep list value", `{ a(x: ` + strings.Repeat("[", 1<<20) + `) }`, "nesting deeper"},
+		{"deep object value", `{ a(x: ` + strings.Repeat("{b: ", 1<<16) + `) }`, "nesting deeper"},
+		{"deep variable type", `query($x: ` + strings.Repeat("[", 1<<16) + `) { a }`, "nesting deeper"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query, "")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAllowsMaxDepth(t *testing.T) {
+	query := strings.Repeat("{ a ", MaxDepth) + strings.Repeat("}", MaxDepth)
+	if _, err := Parse(query, ""); err != nil {
+		t.Fatalf("query nested %d levels: %v", MaxDepth, err)
+	}
+}
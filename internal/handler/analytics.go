@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/analytics"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// analyticsWindows are the trailing windows reported by GetAnalytics, shortest
+// first; the longest one decides how much history is loaded
+var analyticsWindows = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// AnalyticsHandler serves return and risk statistics computed from OHLCV
+type AnalyticsHandler struct {
+	clickhouseConn driver.Conn
+	correlations   *analytics.CorrelationService
+	logger         *zap.Logger
+}
+
+// NewAnalyticsHandler creates a new analytics handler. correlations serves
+// the cached correlation matrices.
+func NewAnalyticsHandler(clickhouseConn driver.Conn, correlations *analytics.CorrelationService, logger *zap.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		clickhouseConn: clickhouseConn,
+		correlations:   correlations,
+		logger:         logger,
+	}
+}
+
+// GetAnalytics returns volatility, drawdown and returns for a trading pair
+// @Summary Get return and volatility analytics
+// @Description Realized volatility (annualized), max drawdown and return over trailing 24h, 7d and 30d windows, computed from hourly OHLCV closes
+// @Tags analytics
+// @Produce json
+// @Param symbol path string true "Trading pair symbol (e.g., BTCUSDT)"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} models.APIResponse{data=models.AnalyticsResponse} "Analytics"
+// @Success 304 "Not modified since the ETag/Last-Modified given"
+// @Failure 404 {object} models.ErrorResponse "No OHLCV data for the symbol"
+// @Failure 422 {object} models.ErrorResponse "Malformed symbol"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/analytics/{symbol} [get]
+func (h *AnalyticsHandler) GetAnalytics(c *gin.Context) {
+	v := NewRequestValidator(c)
+	symbol := v.Symbol("symbol")
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	now := time.Now()
+	longest := analyticsWindows[len(analyticsWindows)-1].duration
+	candles, err := db.GetOHLCVData(h.clickhouseConn, symbol, timeutil.SecondsOf(now.Add(-longest)), timeutil.SecondsOf(now), "1h")
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to get OHLCV data for analytics", zap.Error(err), zap.String("symbol", symbol))
+		RespondServiceError(c, err, "Failed to retrieve OHLCV data")
+		return
+	}
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Timestamp < candles[j].Timestamp })
+
+	last := candles[len(candles)-1]
+	if CheckNotModified(c, last.Timestamp.Time(), last.Close.String()) {
+		return
+	}
+
+	resp := models.AnalyticsResponse{
+		Symbol:    symbol,
+		Interval:  "1h",
+		Candles:   len(candles),
+		From:      int64(candles[0].Timestamp),
+		To:        int64(last.Timestamp),
+		Windows:   make([]models.AnalyticsWindow, 0, len(analyticsWindows)),
+		Timestamp: now.Unix(),
+	}
+
+	for _, w := range analyticsWindows {
+		since := timeutil.SecondsOf(now.Add(-w.duration))
+		closes := make([]float64, 0, len(candles))
+		for _, candle := range candles {
+			if candle.Timestamp >= since {
+				closes = append(closes, candle.Close.InexactFloat64())
+			}
+		}
+		resp.Windows = append(resp.Windows, analyticsWindow(w.name, closes))
+	}
+
+	RespondOK(c, resp)
+}
+
+// GetCorrelations returns the cached return correlation matrix for a window
+// @Summary Get correlation matrix
+// @Description Pairwise correlations of hourly USD VWAP log returns between the top tokens by rank. Matrices are cached and refreshed hourly.
+// @Tags analytics
+// @Produce json
+// @Param window query string false "Lookback window, one of the configured CORRELATION_WINDOWS" default(30d)
+// @Success 200 {object} models.APIResponse{data=models.CorrelationMatrixResponse} "Correlation matrix"
+// @Failure 422 {object} models.ErrorResponse "Unknown window"
+// @Failure 503 {object} models.ErrorResponse "Matrix not computed yet"
+// @Router /api/v1/analytics/correlations [get]
+func (h *AnalyticsHandler) GetCorrelations(c *gin.Context) {
+	windows := h.correlations.Windows()
+	window := c.DefaultQuery("window", windows[len(windows)-1])
+
+	matrix, ok := h.correlations.Matrix(window)
+	if !ok {
+		for _, w := range windows {
+			if w == window {
+				RespondError(c, http.StatusServiceUnavailable, "not_ready", "Correlation matrix has not been computed yet")
+				return
+			}
+		}
+		RespondValidationErrors(c, []models.FieldError{{
+			Field:   "window",
+			Message: "Window must be one of: " + strings.Join(windows, ", "),
+		}})
+		return
+	}
+
+	if CheckNotModified(c, matrix.ComputedAt) {
+		return
+	}
+
+	RespondOK(c, models.CorrelationMatrixResponse{
+		Window:     matrix.Window,
+		TokenIDs:   matrix.TokenIDs,
+		Symbols:    matrix.Symbols,
+		Matrix:     matrix.Values,
+		ComputedAt: matrix.ComputedAt.Unix(),
+	})
+}
+
+// analyticsWindow computes the statistics for one window of hourly closes.
+// Statistics that need more data than the window holds are left nil.
+func analyticsWindow(name string, closes []float64) models.AnalyticsWindow {
+	w := models.AnalyticsWindow{Window: name, Candles: len(closes)}
+	if r, ok := analytics.SimpleReturn(closes); ok {
+		w.Return = &r
+	}
+	if vol, ok := analytics.RealizedVolatility(analytics.LogReturns(closes), analytics.HoursPerYear); ok {
+		w.Volatility = &vol
+	}
+	if dd, ok := analytics.MaxDrawdown(closes); ok {
+		w.MaxDrawdown = &dd
+	}
+	return w
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultCompressMinSize is the smallest response body worth compressing;
+// below roughly one MTU the framing overhead outweighs the savings
+const DefaultCompressMinSize = 1400
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	},
+}
+
+// Compress is middleware that gzip- or deflate-encodes response bodies of at
+// least minSize bytes when the client advertises support. Smaller bodies,
+// already-encoded responses and event streams are passed through unchanged.
+func Compress(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = w
+		defer w.close()
+
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header,
+// honouring q=0 exclusions
+func negotiateEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		accepted[name] = q > 0
+	}
+	for _, enc := range []string{"gzip", "deflate"} {
+		if ok, listed := accepted[enc]; ok || (!listed && accepted["*"]) {
+			return enc
+		}
+	}
+	return ""
+}
+
+// compressWriter buffers the start of the body until it knows whether the
+// response is large enough to compress, then either streams through an
+// encoder or writes the buffered bytes as-is
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.minSize {
+			return len(p), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Size reports the number of uncompressed bytes accepted so far, matching
+// what handlers and gin's logger expect
+func (w *compressWriter) Size() int {
+	if !w.decided {
+		return len(w.buf)
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Written reports whether the body has started, including buffered bytes
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide commits to compressing (when large and eligible) or not, then
+// writes out anything buffered so far
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	if large && w.eligible() {
+		h := w.Header()
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		switch w.encoding {
+		case "gzip":
+			gz := gzipWriterPool.Get().(*gzip.Writer)
+			gz.Reset(w.ResponseWriter)
+			w.enc = gz
+		case "deflate":
+			// HTTP's deflate is the zlib format (RFC 9110), not raw DEFLATE
+			w.enc = zlib.NewWriter(w.ResponseWriter)
+		}
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) eligible() bool {
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	return !strings.HasPrefix(h.Get("Content-Type"), "text/event-stream")
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.enc == nil {
+		return
+	}
+	w.enc.Close()
+	if gz, ok := w.enc.(*gzip.Writer); ok {
+		gz.Reset(io.Discard)
+		gzipWriterPool.Put(gz)
+	}
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"br", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"},
+		{"GZIP;q=0.5", "gzip"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"gzip;q=0, deflate;q=0", ""},
+		{"*", "gzip"},
+		{"*, gzip;q=0", "deflate"},
+		{"*;q=0", ""},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	const minSize = 100
+	small := strings.Repeat("a", minSize-1)
+	large := strings.Repeat("a", minSize)
+
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		handler        gin.HandlerFunc
+		wantEncoding   string
+		wantBody       string
+	}{
+		{
+			name:           "below the threshold",
+			acceptEncoding: "gzip",
+			handler:        func(c *gin.Context) { c.String(http.StatusOK, small) },
+			wantBody:       small,
+		},
+		{
+			name:           "at the threshold",
+			acceptEncoding: "gzip",
+			handler:        func(c *gin.Context) { c.String(http.StatusOK, large) },
+			wantEncoding:   "gzip",
+			wantBody:       large,
+		},
+		{
+			name:           "threshold reached over several writes",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Writer.WriteString(small)
+				c.Writer.WriteString("b")
+			},
+			wantEncoding: "gzip",
+			wantBody:     small + "b",
+		},
+		{
+			name:           "deflate",
+			acceptEncoding: "deflate",
+			handler:        func(c *gin.Context) { c.String(http.StatusOK, large) },
+			wantEncoding:   "deflate",
+			wantBody:       large,
+		},
+		{
+			name:           "gzip refused with q=0",
+			acceptEncoding: "gzip;q=0, deflate",
+			handler:        func(c *gin.Context) { c.String(http.StatusOK, large) },
+			wantEncoding:   "deflate",
+			wantBody:       large,
+		},
+		{
+			name:     "no Accept-Encoding",
+			handler:  func(c *gin.Context) { c.String(http.StatusOK, large) },
+			wantBody: large,
+		},
+		{
+			name:           "already encoded",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Header("Content-Encoding", "br")
+				c.String(http.StatusOK, large)
+			},
+			wantEncoding: "br",
+			wantBody:     large,
+		},
+		{
+			name:           "event stream",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Header("Content-Type", "text/event-stream")
+				c.Status(http.StatusOK)
+				c.Writer.WriteString("data: 1\n\n")
+				c.Writer.Flush()
+				c.Writer.WriteString("data: " + large + "\n\n")
+			},
+			wantBody: "data: 1\n\ndata: " + large + "\n\n",
+		},
+		{
+			name:           "HEAD",
+			method:         http.MethodHead,
+			acceptEncoding: "gzip",
+			handler:        func(c *gin.Context) { c.Status(http.StatusOK) },
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Compress(minSize))
+			router.Handle(http.MethodGet, "/", tt.handler)
+			router.Handle(http.MethodHead, "/", tt.handler)
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			encoding := w.Header().Get("Content-Encoding")
+			if encoding != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", encoding, tt.wantEncoding)
+			}
+			if body := decodeBody(t, encoding, w.Body.Bytes()); body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// decodeBody undoes the encodings Compress applies
+func decodeBody(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	var err error
+	switch encoding {
+	case "gzip":
+		r, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		r, err = zlib.NewReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+	if err != nil {
+		t.Fatalf("opening %s body: %v", encoding, err)
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decoding %s body: %v", encoding, err)
+	}
+	return string(decoded)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckNotModified sets ETag and Last-Modified validators derived from the
+// newest data timestamp and answers conditional GET/HEAD requests. It returns
+// true when a 304 has been written and the handler should stop.
+//
+// The ETag covers the request URI, so different query parameters get different
+// tags, plus any extra version strings the caller supplies for data that can
+// change without its timestamp moving (e.g. a still-open candle).
+func CheckNotModified(c *gin.Context, lastModified time.Time, version ...string) bool {
+	if lastModified.IsZero() {
+		return false
+	}
+	lastModified = lastModified.UTC().Truncate(time.Second)
+
+	h := fnv.New64a()
+	h.Write([]byte(c.Request.URL.RequestURI()))
+	fmt.Fprintf(h, "|%d", lastModified.Unix())
+	for _, v := range version {
+		h.Write([]byte("|" + v))
+	}
+	etag := fmt.Sprintf(`W/"%x"`, h.Sum64())
+
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	if c.Writer.Header().Get("Cache-Control") == "" {
+		// Let browsers and CDNs store the response but revalidate every time
+		c.Header("Cache-Control", "public, no-cache")
+	}
+
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since (RFC 9110 13.2.2)
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		if etagMatches(inm, etag) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+		return false
+	}
+
+	if ims := c.GetHeader("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil && !lastModified.After(t) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatches performs the weak comparison used for If-None-Match
+func etagMatches(header, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"github.com/ashmitsharp/trading/internal/db"
+)
+
+// downsampleOHLCV reduces a candle series to at most points candles by merging
+// consecutive candles into evenly sized buckets. Each bucket keeps the first
+// open, the last close, the max high, the min low and the summed volume/trades,
+// so the result is still a valid (coarser) candlestick series.
+func downsampleOHLCV(data []db.OHLCVData, points int) []db.OHLCVData {
+	if points <= 0 || len(data) <= points {
+		return data
+	}
+
+	result := make([]db.OHLCVData, 0, points)
+	bucketSize := float64(len(data)) / float64(points)
+
+	for i := 0; i < points; i++ {
+		start := int(float64(i) * bucketSize)
+		end := int(float64(i+1) * bucketSize)
+		if i == points-1 {
+			end = len(data)
+		}
+		if start >= end {
+			continue
+		}
+
+		bucket := data[start:end]
+		merged := bucket[0]
+		for _, candle := range bucket[1:] {
+			if candle.High.GreaterThan(merged.High) {
+				merged.High = candle.High
+			}
+			if candle.Low.LessThan(merged.Low) {
+				merged.Low = candle.Low
+			}
+			merged.Volume = merged.Volume.Add(candle.Volume)
+			merged.TradesCount += candle.TradesCount
+		}
+		merged.Close = bucket[len(bucket)-1].Close
+
+		result = append(result, merged)
+	}
+
+	return result
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/shopspring/decimal"
+)
+
+func candle(ts int64, open, high, low, close, volume float64) db.OHLCVData {
+	return db.OHLCVData{
+		Timestamp:   timeutil.Seconds(ts),
+		Open:        decimal.NewFromFloat(open),
+		High:        decimal.NewFromFloat(high),
+		Low:         decimal.NewFromFloat(low),
+		Close:       decimal.NewFromFloat(close),
+		Volume:      decimal.NewFromFloat(volume),
+		TradesCount: 1,
+	}
+}
+
+func TestDownsampleOHLCV(t *testing.T) {
+	data := []db.OHLCVData{
+		candle(0, 10, 12, 9, 11, 1),
+		candle(60, 11, 15, 10, 14, 2),
+		candle(120, 14, 14, 8, 9, 3),
+		candle(180, 9, 10, 7, 8, 4),
+		candle(240, 8, 9, 6, 7, 5),
+	}
+
+	got := downsampleOHLCV(data, 2)
+	want := []db.OHLCVData{
+		{Timestamp: 0, Open: decimal.NewFromInt(10), High: decimal.NewFromInt(15), Low: decimal.NewFromInt(9),
+			Close: decimal.NewFromInt(14), Volume: decimal.NewFromInt(3), TradesCount: 2},
+		{Timestamp: 120, Open: decimal.NewFromInt(14), High: decimal.NewFromInt(14), Low: decimal.NewFromInt(6),
+			Close: decimal.NewFromInt(7), Volume: decimal.NewFromInt(12), TradesCount: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("downsampleOHLCV returned %d candles, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Timestamp != w.Timestamp || !g.Open.Equal(w.Open) || !g.High.Equal(w.High) || !g.Low.Equal(w.Low) ||
+			!g.Close.Equal(w.Close) || !g.Volume.Equal(w.Volume) || g.TradesCount != w.TradesCount {
+			t.Errorf("candle %d = %+v, want %+v", i, g, w)
+		}
+	}
+
+	if got := downsampleOHLCV(data, 10); len(got) != len(data) {
+		t.Errorf("downsampling to more points than candles returned %d candles, want %d", len(got), len(data))
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/features"
+	"github.com/ashmitsharp/trading/internal/symbol"
+	"github.com/ashmitsharp/trading/internal/tokenops"
+	"github.com/gin-gonic/gin"
+)
+
+// errorStatus maps sentinel errors from internal packages to an HTTP status
+// and error code. Unrecognized errors are internal errors.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, db.ErrSymbolNotFound), errors.Is(err, symbol.ErrSymbolNotFound):
+		return http.StatusNotFound, "symbol_not_found"
+	case errors.Is(err, db.ErrNoData):
+		return http.StatusNotFound, "no_data"
+	case errors.Is(err, exchanges.ErrUnknownExchange), errors.Is(err, db.ErrExchangeNotFound):
+		return http.StatusNotFound, "exchange_not_found"
+	case errors.Is(err, db.ErrMaintenanceWindowNotFound):
+		return http.StatusNotFound, "maintenance_window_not_found"
+	case errors.Is(err, db.ErrPairNotFound):
+		return http.StatusNotFound, "pair_not_found"
+	case errors.Is(err, db.ErrExchangeExists):
+		return http.StatusConflict, "exchange_exists"
+	case errors.Is(err, db.ErrExchangeInUse):
+		return http.StatusConflict, "exchange_in_use"
+	case errors.Is(err, db.ErrWatchlistNotFound):
+		return http.StatusNotFound, "watchlist_not_found"
+	case errors.Is(err, db.ErrWatchlistExists):
+		return http.StatusConflict, "watchlist_exists"
+	case errors.Is(err, db.ErrWebhookNotFound):
+		return http.StatusNotFound, "webhook_not_found"
+	case errors.Is(err, db.ErrOutlierThresholdNotFound):
+		return http.StatusNotFound, "outlier_threshold_not_found"
+	case errors.Is(err, features.ErrUnknownFlag):
+		return http.StatusNotFound, "feature_flag_not_found"
+	case errors.Is(err, db.ErrFeatureFlagNotFound):
+		return http.StatusNotFound, "feature_flag_override_not_found"
+	case errors.Is(err, db.ErrOutlierNotFound):
+		return http.StatusNotFound, "outlier_not_found"
+	case errors.Is(err, db.ErrOutlierResolved):
+		return http.StatusConflict, "outlier_resolved"
+	case errors.Is(err, db.ErrMappingNotFound):
+		return http.StatusNotFound, "mapping_not_found"
+	case errors.Is(err, db.ErrMappingExists):
+		return http.StatusConflict, "mapping_exists"
+	case errors.Is(err, db.ErrPairExists):
+		return http.StatusConflict, "pair_exists"
+	case errors.Is(err, exchanges.ErrExchangeUnhealthy):
+		return http.StatusServiceUnavailable, "exchange_unavailable"
+	case errors.Is(err, tokenops.ErrTokenNotFound), errors.Is(err, db.ErrTokenNotFound):
+		return http.StatusNotFound, "token_not_found"
+	case errors.Is(err, tokenops.ErrLogNotFound):
+		return http.StatusNotFound, ErrCodeNotFound
+	case errors.Is(err, tokenops.ErrTokenExists):
+		return http.StatusConflict, "token_exists"
+	case errors.Is(err, tokenops.ErrTokenNotArchived):
+		return http.StatusConflict, "token_not_archived"
+	case errors.Is(err, tokenops.ErrTokenArchived):
+		return http.StatusConflict, "token_archived"
+	case errors.Is(err, tokenops.ErrSameToken), errors.Is(err, tokenops.ErrNothingToMove), errors.Is(err, tokenops.ErrInvalidToken):
+		return http.StatusUnprocessableEntity, ErrCodeValidationFailed
+	case errors.Is(err, db.ErrPendingMappingNotFound):
+		return http.StatusNotFound, ErrCodeNotFound
+	case errors.Is(err, db.ErrPendingMappingClosed):
+		return http.StatusConflict, "pending_mapping_closed"
+	default:
+		return http.StatusInternalServerError, ErrCodeInternal
+	}
+}
+
+// RespondServiceError writes the response for an error returned by an
+// internal package. message is shown to the client; the error itself is not,
+// since it may contain query details.
+func RespondServiceError(c *gin.Context, err error, message string) {
+	status, code := errorStatus(err)
+	RespondError(c, status, code, message)
+}
@@ -0,0 +1,458 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ExchangeHandler serves the exchange registry. The poller reads the
+// registry at startup, so changes take effect on its next restart.
+type ExchangeHandler struct {
+	postgresDB *sql.DB
+	logger     *zap.Logger
+}
+
+// NewExchangeHandler creates a new exchange handler
+func NewExchangeHandler(postgresDB *sql.DB, logger *zap.Logger) *ExchangeHandler {
+	return &ExchangeHandler{
+		postgresDB: postgresDB,
+		logger:     logger,
+	}
+}
+
+// ExchangeRequest is the body of registering or updating an exchange. ID is
+// taken from the path on updates.
+type ExchangeRequest struct {
+	ID                 string   `json:"id" binding:"max=50"`
+	Name               string   `json:"name" binding:"required,max=100"`
+	BaseURL            string   `json:"base_url" binding:"required,url"`
+	TickerEndpoint     string   `json:"ticker_endpoint" binding:"required"`
+	SymbolsEndpoint    string   `json:"symbols_endpoint"`
+	TradesEndpoint     string   `json:"trades_endpoint" binding:"max=255"` // {symbol} is the pair symbol; empty disables trade polling
+	RateLimitPerMinute int      `json:"rate_limit_per_minute" binding:"min=0"`
+	RequestTimeoutMs   int      `json:"request_timeout_ms" binding:"min=0"`
+	RetryAttempts      int      `json:"retry_attempts" binding:"min=0,max=10"`
+	Weight             float64  `json:"weight" binding:"min=0,max=1"`
+	SymbolFormat       string   `json:"symbol_format" binding:"max=20"`
+	QuoteCurrencies    []string `json:"quote_currencies" binding:"dive,required,max=20"`
+	TakerFee           float64  `json:"taker_fee" binding:"min=0,max=0.1"` // base tier fraction, 0 when unknown
+	MakerFee           float64  `json:"maker_fee" binding:"min=0,max=0.1"`
+	AllowSymbols       []string `json:"allow_symbols" binding:"dive,required,max=50"` // path.Match patterns; none keeps all
+	DenySymbols        []string `json:"deny_symbols" binding:"dive,required,max=50"`  // e.g. *3L*
+	IsActive           *bool    `json:"is_active"`                                    // default true
+}
+
+func (r ExchangeRequest) config(id string) exchanges.ExchangeConfig {
+	quotes := make([]string, 0, len(r.QuoteCurrencies))
+	for _, q := range r.QuoteCurrencies {
+		quotes = append(quotes, strings.ToUpper(strings.TrimSpace(q)))
+	}
+	return exchanges.ExchangeConfig{
+		ID:                 id,
+		Name:               r.Name,
+		BaseURL:            strings.TrimRight(r.BaseURL, "/"),
+		TickerEndpoint:     r.TickerEndpoint,
+		SymbolsEndpoint:    r.SymbolsEndpoint,
+		TradesEndpoint:     r.TradesEndpoint,
+		RateLimitPerMinute: r.RateLimitPerMinute,
+		RequestTimeout:     r.RequestTimeoutMs,
+		RetryAttempts:      r.RetryAttempts,
+		Weight:             r.Weight,
+		SymbolFormat:       r.SymbolFormat,
+		QuoteCurrencies:    quotes,
+		TakerFee:           r.TakerFee,
+		MakerFee:           r.MakerFee,
+		AllowSymbols:       r.AllowSymbols,
+		DenySymbols:        r.DenySymbols,
+		Disabled:           r.IsActive != nil && !*r.IsActive,
+	}
+}
+
+// ListExchanges lists registered exchanges
+// @Summary List exchanges
+// @Description List registered exchanges ordered by VWAP weight, with their client config and poll health. Inactive exchanges are left out unless include_inactive is set.
+// @Tags exchanges
+// @Produce json
+// @Param include_inactive query bool false "Include inactive exchanges" default(false)
+// @Success 200 {object} models.APIResponse{data=[]models.ExchangeResponse} "Exchanges"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/exchanges [get]
+func (h *ExchangeHandler) ListExchanges(c *gin.Context) {
+	v := NewRequestValidator(c)
+	includeInactive := v.Bool("include_inactive", false)
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	found, err := db.ListExchanges(c.Request.Context(), h.postgresDB, includeInactive)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load exchanges", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve exchanges")
+		return
+	}
+
+	resp := make([]models.ExchangeResponse, 0, len(found))
+	for _, e := range found {
+		resp = append(resp, exchangeResponse(e))
+	}
+	RespondOK(c, resp)
+}
+
+// GetExchange returns a single exchange
+// @Summary Get exchange
+// @Description Get a registered exchange by its ID, active or not
+// @Tags exchanges
+// @Produce json
+// @Param id path string true "Exchange ID (e.g., binance)"
+// @Success 200 {object} models.APIResponse{data=models.ExchangeResponse} "Exchange"
+// @Failure 404 {object} models.ErrorResponse "Exchange not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/exchanges/{id} [get]
+func (h *ExchangeHandler) GetExchange(c *gin.Context) {
+	e, err := db.GetExchange(c.Request.Context(), h.postgresDB, exchangeIDParam(c))
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve exchange")
+		return
+	}
+	RespondOK(c, exchangeResponse(e))
+}
+
+// CreateExchange registers an exchange
+// @Summary Register exchange
+// @Description Add an exchange to the registry. The poller creates a client for it on its next restart, using the parser for its ID and the generic parser for IDs it does not know.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body ExchangeRequest true "Exchange config"
+// @Success 200 {object} models.APIResponse{data=models.ExchangeResponse} "Registered"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 409 {object} models.ErrorResponse "Exchange already exists"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/exchanges [post]
+func (h *ExchangeHandler) CreateExchange(c *gin.Context) {
+	var req ExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+	id := strings.ToLower(strings.TrimSpace(req.ID))
+	if id == "" {
+		RespondUnprocessable(c, ErrCodeValidationFailed, "id is required")
+		return
+	}
+	if _, err := exchanges.NewSymbolFilter(req.AllowSymbols, req.DenySymbols); err != nil {
+		RespondUnprocessable(c, ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	e, err := db.CreateExchange(c.Request.Context(), h.postgresDB, req.config(id))
+	if err != nil {
+		h.respondError(c, err, "Failed to register exchange")
+		return
+	}
+	RespondOKWithMessage(c, exchangeResponse(e), "Exchange registered successfully")
+}
+
+// UpdateExchange replaces an exchange's config
+// @Summary Update exchange
+// @Description Replace the config of exchange {id}. Set is_active to false to stop polling it and drop it from VWAP weights. Poll health is kept.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Exchange ID"
+// @Param request body ExchangeRequest true "Exchange config"
+// @Success 200 {object} models.APIResponse{data=models.ExchangeResponse} "Updated"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Exchange not found"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/exchanges/{id} [put]
+func (h *ExchangeHandler) UpdateExchange(c *gin.Context) {
+	var req ExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+	if _, err := exchanges.NewSymbolFilter(req.AllowSymbols, req.DenySymbols); err != nil {
+		RespondUnprocessable(c, ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	e, err := db.UpdateExchange(c.Request.Context(), h.postgresDB, req.config(exchangeIDParam(c)))
+	if err != nil {
+		h.respondError(c, err, "Failed to update exchange")
+		return
+	}
+	RespondOK(c, exchangeResponse(e))
+}
+
+// DeleteExchange removes an exchange from the registry
+// @Summary Delete exchange
+// @Description Remove exchange {id} from the registry. Its symbol mappings and stored prices are kept. Trading pairs must be on a registered exchange, so an exchange that still has any cannot be deleted. An exchange still in configs/exchanges.json is registered again on the next restart, so deactivate it instead to stop polling it for good.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Exchange ID"
+// @Success 200 {object} models.APIResponse "Deleted"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 404 {object} models.ErrorResponse "Exchange not found"
+// @Failure 409 {object} models.ErrorResponse "Exchange has trading pairs"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/exchanges/{id} [delete]
+func (h *ExchangeHandler) DeleteExchange(c *gin.Context) {
+	id := exchangeIDParam(c)
+	if err := db.DeleteExchange(c.Request.Context(), h.postgresDB, id); err != nil {
+		h.respondError(c, err, "Failed to delete exchange")
+		return
+	}
+	RespondOKWithMessage(c, gin.H{"id": id}, "Exchange deleted successfully")
+}
+
+// ListWeightHistory lists the dynamic weight computations
+// @Summary Exchange weight history
+// @Description The daily computations of the exchanges' dynamic VWAP weights, newest first: each exchange's volume share across all pairs and poll uptime, the capped target weight they give and the weight after smoothing with the previous one. VWAP uses the dynamic weights while the dynamic_exchange_weights feature flag is on.
+// @Tags admin
+// @Produce json
+// @Param exchange query string false "Exchange ID; defaults to every exchange"
+// @Param limit query int false "Maximum number of changes" default(100) minimum(1) maximum(1000)
+// @Success 200 {object} models.APIResponse{data=[]models.ExchangeWeightChangeResponse} "Weight changes"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/exchange-weights [get]
+func (h *ExchangeHandler) ListWeightHistory(c *gin.Context) {
+	v := NewRequestValidator(c)
+	limit := v.IntRange("limit", 100, 1, 1000)
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+	exchangeID := strings.ToLower(strings.TrimSpace(c.Query("exchange")))
+
+	changes, err := db.ListExchangeWeightHistory(c.Request.Context(), h.postgresDB, exchangeID, limit)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load exchange weight history", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve exchange weight history")
+		return
+	}
+
+	resp := make([]models.ExchangeWeightChangeResponse, 0, len(changes))
+	for _, ch := range changes {
+		resp = append(resp, models.ExchangeWeightChangeResponse{
+			ExchangeID:     ch.ExchangeID,
+			StaticWeight:   ch.StaticWeight,
+			PreviousWeight: ch.PreviousWeight,
+			TargetWeight:   ch.TargetWeight,
+			Weight:         ch.Weight,
+			VolumeSharePct: ch.VolumeSharePct,
+			UptimePct:      ch.UptimePct,
+			ComputedAt:     ch.ComputedAt,
+		})
+	}
+	RespondOK(c, resp)
+}
+
+// MaintenanceWindowRequest is the body of scheduling an exchange's
+// maintenance
+type MaintenanceWindowRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason" binding:"max=500"`
+}
+
+// ListMaintenanceWindows lists an exchange's scheduled maintenance
+// @Summary List maintenance windows
+// @Description The maintenance windows of exchange {id} that have not ended, soonest first. The poller does not poll an exchange during its windows, so the downtime counts neither as poll failures nor against its uptime and dynamic weight.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Exchange ID"
+// @Success 200 {object} models.APIResponse{data=[]models.MaintenanceWindowResponse} "Maintenance windows"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 404 {object} models.ErrorResponse "Exchange not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/exchanges/{id}/maintenance [get]
+func (h *ExchangeHandler) ListMaintenanceWindows(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := exchangeIDParam(c)
+	if _, err := db.GetExchange(ctx, h.postgresDB, id); err != nil {
+		h.respondError(c, err, "Failed to retrieve exchange")
+		return
+	}
+
+	now := time.Now()
+	windows, err := db.ListMaintenanceWindows(ctx, h.postgresDB, id, now)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load maintenance windows", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve maintenance windows")
+		return
+	}
+
+	resp := make([]models.MaintenanceWindowResponse, 0, len(windows))
+	for _, w := range windows {
+		resp = append(resp, maintenanceWindowResponse(w, now))
+	}
+	RespondOK(c, resp)
+}
+
+// CreateMaintenanceWindow schedules an exchange's maintenance
+// @Summary Schedule maintenance
+// @Description Schedule a maintenance window for exchange {id}, from starts_at up to ends_at (RFC 3339). Pollers pick it up within MAINTENANCE_REFRESH. Scheduling a window with the same start and end again replaces its reason.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Exchange ID"
+// @Param request body MaintenanceWindowRequest true "Maintenance window"
+// @Success 200 {object} models.APIResponse{data=models.MaintenanceWindowResponse} "Scheduled"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Exchange not found"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/exchanges/{id}/maintenance [post]
+func (h *ExchangeHandler) CreateMaintenanceWindow(c *gin.Context) {
+	var req MaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+	now := time.Now()
+	if !req.EndsAt.After(req.StartsAt) {
+		RespondUnprocessable(c, ErrCodeValidationFailed, "ends_at must be after starts_at")
+		return
+	}
+	if !req.EndsAt.After(now) {
+		RespondUnprocessable(c, ErrCodeValidationFailed, "ends_at must be in the future")
+		return
+	}
+
+	w, err := db.CreateMaintenanceWindow(c.Request.Context(), h.postgresDB, exchangeIDParam(c), exchanges.MaintenanceWindow{
+		Start:  req.StartsAt,
+		End:    req.EndsAt,
+		Reason: strings.TrimSpace(req.Reason),
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to schedule maintenance")
+		return
+	}
+	RespondOKWithMessage(c, maintenanceWindowResponse(w, now), "Maintenance scheduled successfully")
+}
+
+// DeleteMaintenanceWindow cancels an exchange's scheduled maintenance
+// @Summary Cancel maintenance
+// @Description Remove maintenance window {window_id} of exchange {id}, ending it at once if it has started. A window still in configs/exchanges.json is scheduled again on the next restart.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Exchange ID"
+// @Param window_id path int true "Maintenance window ID"
+// @Success 200 {object} models.APIResponse "Cancelled"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Maintenance window not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/exchanges/{id}/maintenance/{window_id} [delete]
+func (h *ExchangeHandler) DeleteMaintenanceWindow(c *gin.Context) {
+	windowID, err := strconv.ParseInt(c.Param("window_id"), 10, 64)
+	if err != nil || windowID <= 0 {
+		RespondBadRequest(c, ErrCodeInvalidParameter, "Invalid maintenance window ID")
+		return
+	}
+	if err := db.DeleteMaintenanceWindow(c.Request.Context(), h.postgresDB, exchangeIDParam(c), windowID); err != nil {
+		h.respondError(c, err, "Failed to cancel maintenance")
+		return
+	}
+	RespondOKWithMessage(c, gin.H{"id": windowID}, "Maintenance cancelled successfully")
+}
+
+func maintenanceWindowResponse(w db.MaintenanceWindow, now time.Time) models.MaintenanceWindowResponse {
+	return models.MaintenanceWindowResponse{
+		ID:         w.ID,
+		ExchangeID: w.ExchangeID,
+		StartsAt:   w.Start,
+		EndsAt:     w.End,
+		Reason:     w.Reason,
+		Active:     w.Contains(now),
+		CreatedAt:  w.CreatedAt,
+	}
+}
+
+// respondError shows not-found and conflict errors to the client and a
+// generic message otherwise
+func (h *ExchangeHandler) respondError(c *gin.Context, err error, message string) {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c, h.logger).Error(message, zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, message)
+		return
+	}
+	RespondError(c, status, code, err.Error())
+}
+
+func exchangeIDParam(c *gin.Context) string {
+	return strings.ToLower(strings.TrimSpace(c.Param("id")))
+}
+
+func exchangeResponse(e db.Exchange) models.ExchangeResponse {
+	r := models.ExchangeResponse{
+		ID:                  e.ID,
+		Name:                e.Name,
+		IsActive:            !e.Disabled,
+		Weight:              e.Weight,
+		BaseURL:             e.BaseURL,
+		TickerEndpoint:      e.TickerEndpoint,
+		SymbolsEndpoint:     e.SymbolsEndpoint,
+		TradesEndpoint:      e.TradesEndpoint,
+		RateLimitPerMinute:  e.RateLimitPerMinute,
+		RequestTimeoutMs:    e.RequestTimeout,
+		RetryAttempts:       e.RetryAttempts,
+		SymbolFormat:        e.SymbolFormat,
+		QuoteCurrencies:     e.QuoteCurrencies,
+		TakerFee:            e.TakerFee,
+		MakerFee:            e.MakerFee,
+		AllowSymbols:        e.AllowSymbols,
+		DenySymbols:         e.DenySymbols,
+		ConsecutiveFailures: e.ConsecutiveFailures,
+		CreatedAt:           e.CreatedAt,
+		UpdatedAt:           e.UpdatedAt,
+	}
+	if r.QuoteCurrencies == nil {
+		r.QuoteCurrencies = []string{}
+	}
+	if r.AllowSymbols == nil {
+		r.AllowSymbols = []string{}
+	}
+	if r.DenySymbols == nil {
+		r.DenySymbols = []string{}
+	}
+	if !e.LastSuccessfulPoll.IsZero() {
+		at := e.LastSuccessfulPoll
+		r.LastSuccessfulPoll = &at
+	}
+	if !e.DynamicWeightAt.IsZero() {
+		weight, at := e.DynamicWeight, e.DynamicWeightAt
+		r.DynamicWeight, r.DynamicWeightAt = &weight, &at
+	}
+	if t := e.Trust; !t.UpdatedAt.IsZero() {
+		r.Trust = &models.ExchangeTrustResponse{
+			Score:        t.Score,
+			Trades:       t.Trades,
+			RoundTripPct: t.RoundTripPct,
+			Pairs:        t.Pairs,
+			MismatchPct:  t.MismatchPct,
+			UpdatedAt:    t.UpdatedAt,
+		}
+	}
+	return r
+}
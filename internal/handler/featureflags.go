@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/features"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// FeatureFlagHandler serves the feature flags and their overrides
+type FeatureFlagHandler struct {
+	postgresDB *sql.DB
+	flags      *features.Flags
+	logger     *zap.Logger
+}
+
+// NewFeatureFlagHandler creates a new feature flag handler. flags is
+// reloaded after every change so it applies at once in this process.
+func NewFeatureFlagHandler(postgresDB *sql.DB, flags *features.Flags, logger *zap.Logger) *FeatureFlagHandler {
+	return &FeatureFlagHandler{
+		postgresDB: postgresDB,
+		flags:      flags,
+		logger:     logger,
+	}
+}
+
+// FeatureFlagRequest is the body of overriding a feature flag
+type FeatureFlagRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Notes   string `json:"notes" binding:"max=500"`
+}
+
+// ListFeatureFlags returns every feature flag
+// @Summary List feature flags
+// @Description Every feature flag gating a pipeline change, with its value and where it comes from: an override set here (database), FEATURE_FLAGS (env) or its default
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.FeatureFlagResponse} "Feature flags"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Router /api/v1/admin/feature-flags [get]
+func (h *FeatureFlagHandler) ListFeatureFlags(c *gin.Context) {
+	states := h.flags.States()
+	resp := make([]models.FeatureFlagResponse, 0, len(states))
+	for _, s := range states {
+		resp = append(resp, featureFlagResponse(s))
+	}
+	RespondOK(c, resp)
+}
+
+// SetFeatureFlag overrides a feature flag
+// @Summary Set feature flag
+// @Description Turn a feature flag on or off, overriding FEATURE_FLAGS and its default. Takes effect at once in this process and within FEATURE_FLAGS_REFRESH elsewhere.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Flag name"
+// @Param request body FeatureFlagRequest true "Override"
+// @Success 200 {object} models.APIResponse{data=models.FeatureFlagResponse} "Saved"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Unknown flag"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/feature-flags/{name} [put]
+func (h *FeatureFlagHandler) SetFeatureFlag(c *gin.Context) {
+	var req FeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+	name, ok := h.flagParam(c)
+	if !ok {
+		return
+	}
+
+	saved, err := db.UpsertFeatureFlag(c.Request.Context(), h.postgresDB, db.FeatureFlag{
+		Name:    name,
+		Enabled: *req.Enabled,
+		Notes:   strings.TrimSpace(req.Notes),
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to save feature flag")
+		return
+	}
+	requestLogger(c, h.logger).Info("Feature flag set", zap.String("flag", name), zap.Bool("enabled", saved.Enabled))
+	h.reload(c)
+
+	state, _ := h.flags.State(name)
+	if state.Override == nil {
+		// The reload failed; answer with what was saved
+		state.Enabled, state.Source, state.Override = saved.Enabled, features.SourceDatabase, &saved
+	}
+	RespondOKWithMessage(c, featureFlagResponse(state), "Feature flag saved successfully")
+}
+
+// DeleteFeatureFlag removes the override of a feature flag
+// @Summary Delete feature flag override
+// @Description Remove a feature flag's override so it takes its value from FEATURE_FLAGS or its default again
+// @Tags admin
+// @Produce json
+// @Param name path string true "Flag name"
+// @Success 200 {object} models.APIResponse{data=models.FeatureFlagResponse} "Deleted"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 404 {object} models.ErrorResponse "Unknown flag or flag has no override"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/feature-flags/{name} [delete]
+func (h *FeatureFlagHandler) DeleteFeatureFlag(c *gin.Context) {
+	name, ok := h.flagParam(c)
+	if !ok {
+		return
+	}
+	if err := db.DeleteFeatureFlag(c.Request.Context(), h.postgresDB, name); err != nil {
+		h.respondError(c, err, "Failed to delete feature flag")
+		return
+	}
+	requestLogger(c, h.logger).Info("Feature flag override deleted", zap.String("flag", name))
+	h.reload(c)
+
+	state, _ := h.flags.State(name)
+	RespondOKWithMessage(c, featureFlagResponse(state), "Feature flag override deleted successfully")
+}
+
+// flagParam returns the :name of a known flag
+func (h *FeatureFlagHandler) flagParam(c *gin.Context) (string, bool) {
+	name := strings.ToLower(strings.TrimSpace(c.Param("name")))
+	if _, err := h.flags.State(name); err != nil {
+		h.respondError(c, err, "Unknown feature flag")
+		return "", false
+	}
+	return name, true
+}
+
+// reload applies a change to this process's flags. Failing to is only
+// logged: the change is saved and the periodic reload picks it up.
+func (h *FeatureFlagHandler) reload(c *gin.Context) {
+	if err := h.flags.Reload(c.Request.Context()); err != nil {
+		requestLogger(c, h.logger).Warn("Failed to reload feature flags", zap.Error(err))
+	}
+}
+
+// respondError shows not-found errors to the client and a generic message
+// otherwise
+func (h *FeatureFlagHandler) respondError(c *gin.Context, err error, message string) {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c, h.logger).Error(message, zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, message)
+		return
+	}
+	RespondError(c, status, code, err.Error())
+}
+
+func featureFlagResponse(s features.State) models.FeatureFlagResponse {
+	resp := models.FeatureFlagResponse{
+		Name:        s.Name,
+		Description: s.Description,
+		Enabled:     s.Enabled,
+		Default:     s.Default,
+		Source:      s.Source,
+	}
+	if s.Override != nil {
+		resp.Notes = s.Override.Notes
+		resp.UpdatedAt = &s.Override.UpdatedAt
+	}
+	return resp
+}
@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/graphql"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// maxGraphQLBodyBytes caps the size of a POSTed GraphQL request
+const maxGraphQLBodyBytes = 1 << 20
+
+// GraphQLHandler serves /graphql, joining token metadata from PostgreSQL with
+// latest VWAP prices from ClickHouse in a single request
+type GraphQLHandler struct {
+	postgresDB  *sql.DB
+	vwapStorage *storage.VWAPStorage
+	schema      *graphql.Schema
+	logger      *zap.Logger
+}
+
+// NewGraphQLHandler creates a new GraphQL handler
+func NewGraphQLHandler(postgresDB *sql.DB, vwapStorage *storage.VWAPStorage, logger *zap.Logger) *GraphQLHandler {
+	h := &GraphQLHandler{
+		postgresDB:  postgresDB,
+		vwapStorage: vwapStorage,
+		logger:      logger,
+	}
+	h.schema = h.buildSchema()
+	return h
+}
+
+// gqlToken is the source object for the Token type
+type gqlToken struct {
+	ID     int
+	Symbol string
+	Name   string
+	Slug   sql.NullString
+	Rank   sql.NullInt64
+}
+
+// marketLoader lazily loads market data once per request so that a list of
+// tokens costs one ClickHouse and one PostgreSQL query instead of one per token
+type marketLoader struct {
+	h *GraphQLHandler
+
+	vwapOnce sync.Once
+	vwap     map[int]*storage.VWAPSummary
+	vwapErr  error
+
+	coverageOnce sync.Once
+	coverage     map[int][]string
+	coverageErr  error
+}
+
+type marketLoaderKey struct{}
+
+func loaderFromContext(ctx context.Context) *marketLoader {
+	return ctx.Value(marketLoaderKey{}).(*marketLoader)
+}
+
+func (l *marketLoader) vwapFor(ctx context.Context, tokenID int) (*storage.VWAPSummary, error) {
+	l.vwapOnce.Do(func() {
+		var quoteIDs []int
+		quoteIDs, l.vwapErr = db.GetUSDQuoteTokenIDs(ctx, l.h.postgresDB)
+		if l.vwapErr != nil {
+			return
+		}
+		l.vwap, l.vwapErr = l.h.vwapStorage.GetLatestVWAPByQuote(ctx, quoteIDs)
+	})
+	if l.vwapErr != nil {
+		return nil, fmt.Errorf("failed to load prices: %w", l.vwapErr)
+	}
+	return l.vwap[tokenID], nil
+}
+
+func (l *marketLoader) exchangesFor(ctx context.Context, tokenID int) ([]string, error) {
+	l.coverageOnce.Do(func() {
+		l.coverage, l.coverageErr = l.h.loadExchangeCoverage(ctx)
+	})
+	if l.coverageErr != nil {
+		return nil, fmt.Errorf("failed to load exchange coverage: %w", l.coverageErr)
+	}
+	exchanges := l.coverage[tokenID]
+	if exchanges == nil {
+		exchanges = []string{}
+	}
+	return exchanges, nil
+}
+
+// Query executes a GraphQL query
+// @Summary Execute a GraphQL query
+// @Description Query tokens with metadata, latest USD VWAP price, 24h change and exchange coverage
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param request body graphql.Request true "GraphQL request"
+// @Success 200 {object} graphql.Result "Query result"
+// @Failure 400 {object} graphql.Result "Malformed query"
+// @Failure 413 {object} graphql.Result "Request body larger than 1 MiB"
+// @Router /graphql [post]
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req graphql.Request
+
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if vars := c.Query("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				c.JSON(http.StatusBadRequest, graphql.Result{Errors: []graphql.Error{{Message: "variables must be a JSON object"}}})
+				return
+			}
+		}
+	} else {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGraphQLBodyBytes)
+		if err := c.ShouldBindJSON(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, graphql.Result{Errors: []graphql.Error{{Message: "request body is larger than 1 MiB"}}})
+				return
+			}
+			c.JSON(http.StatusBadRequest, graphql.Result{Errors: []graphql.Error{{Message: "request body must be a JSON object with a query"}}})
+			return
+		}
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		c.JSON(http.StatusBadRequest, graphql.Result{Errors: []graphql.Error{{Message: "query is required"}}})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), marketLoaderKey{}, &marketLoader{h: h})
+	result := h.schema.Execute(ctx, req)
+
+	if result.Data == nil {
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+	for _, e := range result.Errors {
+		requestLogger(c, h.logger).Debug("GraphQL field error", zap.String("message", e.Message), zap.Any("path", e.Path))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *GraphQLHandler) buildSchema() *graphql.Schema {
+	tokenType := &graphql.Object{
+		Name:        "Token",
+		Description: "A token with its metadata and latest market data",
+		Fields: map[string]*graphql.Field{
+			"id":     tokenScalar("Token ID", func(t *gqlToken) interface{} { return t.ID }),
+			"symbol": tokenScalar("Canonical symbol", func(t *gqlToken) interface{} { return t.Symbol }),
+			"name":   tokenScalar("Token name", func(t *gqlToken) interface{} { return t.Name }),
+			"slug": tokenScalar("Universal slug", func(t *gqlToken) interface{} {
+				if t.Slug.Valid {
+					return t.Slug.String
+				}
+				return nil
+			}),
+			"marketCapRank": tokenScalar("Market cap rank", func(t *gqlToken) interface{} {
+				if t.Rank.Valid {
+					return t.Rank.Int64
+				}
+				return nil
+			}),
+			"price": vwapScalar("Latest USD VWAP price", func(s *storage.VWAPSummary) interface{} {
+				return s.Price.InexactFloat64()
+			}),
+			"change24h": vwapScalar("24h VWAP price change in percent", func(s *storage.VWAPSummary) interface{} {
+				return s.Change24h().InexactFloat64()
+			}),
+			"volume24h": vwapScalar("24h volume of the VWAP pair", func(s *storage.VWAPSummary) interface{} {
+				return s.Volume.InexactFloat64()
+			}),
+			"vwapExchangeCount": vwapScalar("Exchanges contributing to the latest VWAP", func(s *storage.VWAPSummary) interface{} {
+				return s.ExchangeCount
+			}),
+			"liquidityScore": vwapScalar("0-100 liquidity score of the VWAP pair", func(s *storage.VWAPSummary) interface{} {
+				return s.LiquidityScore
+			}),
+			"vwapIndicative": vwapScalar("Whether the latest VWAP is below the exchange/volume quorum", func(s *storage.VWAPSummary) interface{} {
+				return s.Indicative
+			}),
+			"lastUpdate": vwapScalar("Unix timestamp of the latest VWAP", func(s *storage.VWAPSummary) interface{} {
+				return s.LastUpdate.Unix()
+			}),
+			"exchangeCount": {
+				Description: "Number of exchanges listing a pair with this token as base",
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					exchanges, err := loaderFromContext(ctx).exchangesFor(ctx, source.(*gqlToken).ID)
+					if err != nil {
+						return nil, err
+					}
+					return len(exchanges), nil
+				},
+			},
+			"exchanges": {
+				Description: "Exchanges listing a pair with this token as base",
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					return loaderFromContext(ctx).exchangesFor(ctx, source.(*gqlToken).ID)
+				},
+			},
+		},
+	}
+
+	queryType := &graphql.Object{
+		Name: "Query",
+		Fields: map[string]*graphql.Field{
+			"tokens": {
+				Type:        tokenType,
+				Description: "Active tokens ordered by market cap rank (args: limit, offset)",
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					limit, err := graphql.IntArg(args, "limit", 100)
+					if err != nil {
+						return nil, err
+					}
+					offset, err := graphql.IntArg(args, "offset", 0)
+					if err != nil {
+						return nil, err
+					}
+					if limit < 1 || limit > 500 {
+						return nil, fmt.Errorf("limit must be between 1 and 500")
+					}
+					if offset < 0 {
+						return nil, fmt.Errorf("offset must not be negative")
+					}
+					return h.listTokens(ctx, limit, offset)
+				},
+			},
+			"token": {
+				Type:        tokenType,
+				Description: "A single token by symbol (args: symbol)",
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					symbol, err := graphql.StringArg(args, "symbol", "")
+					if err != nil {
+						return nil, err
+					}
+					if symbol == "" {
+						return nil, fmt.Errorf("argument \"symbol\" is required")
+					}
+					return h.getToken(ctx, strings.ToUpper(symbol))
+				},
+			},
+		},
+	}
+
+	return &graphql.Schema{Query: queryType}
+}
+
+func tokenScalar(description string, get func(t *gqlToken) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Description: description,
+		Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return get(source.(*gqlToken)), nil
+		},
+	}
+}
+
+func vwapScalar(description string, get func(s *storage.VWAPSummary) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Description: description,
+		Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			summary, err := loaderFromContext(ctx).vwapFor(ctx, source.(*gqlToken).ID)
+			if err != nil || summary == nil {
+				return nil, err
+			}
+			return get(summary), nil
+		},
+	}
+}
+
+func (h *GraphQLHandler) listTokens(ctx context.Context, limit, offset int) ([]*gqlToken, error) {
+	rows, err := h.postgresDB.QueryContext(ctx, `
+		SELECT id, symbol, name, slug, market_cap_rank
+		FROM tokens
+		WHERE is_active = true
+		ORDER BY market_cap_rank ASC NULLS LAST, id ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*gqlToken
+	for rows.Next() {
+		t := &gqlToken{}
+		if err := rows.Scan(&t.ID, &t.Symbol, &t.Name, &t.Slug, &t.Rank); err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+func (h *GraphQLHandler) getToken(ctx context.Context, symbol string) (*gqlToken, error) {
+	t := &gqlToken{}
+	err := h.postgresDB.QueryRowContext(ctx, `
+		SELECT id, symbol, name, slug, market_cap_rank
+		FROM tokens
+		WHERE symbol = $1 AND is_active = true
+		LIMIT 1
+	`, symbol).Scan(&t.ID, &t.Symbol, &t.Name, &t.Slug, &t.Rank)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query token: %w", err)
+	}
+	return t, nil
+}
+
+func (h *GraphQLHandler) loadExchangeCoverage(ctx context.Context) (map[int][]string, error) {
+	rows, err := h.postgresDB.QueryContext(ctx, `
+		SELECT base_token_id, array_agg(DISTINCT exchange_id ORDER BY exchange_id)
+		FROM trading_pairs
+		WHERE is_active = true
+		GROUP BY base_token_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trading pairs: %w", err)
+	}
+	defer rows.Close()
+
+	coverage := make(map[int][]string)
+	for rows.Next() {
+		var tokenID int
+		var exchanges []string
+		if err := rows.Scan(&tokenID, pq.Array(&exchanges)); err != nil {
+			return nil, fmt.Errorf("failed to scan coverage: %w", err)
+		}
+		coverage[tokenID] = exchanges
+	}
+	return coverage, rows.Err()
+}
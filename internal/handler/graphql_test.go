@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestGraphQLRejectsBadRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewGraphQLHandler(nil, nil, zap.NewNop())
+	router := gin.New()
+	router.POST("/graphql", h.Query)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"oversized body", `{"query":"` + strings.Repeat(" ", maxGraphQLBodyBytes) + `{ tokens { symbol } }"}`, http.StatusRequestEntityTooLarge},
+		{"not JSON", `{ tokens { symbol } }`, http.StatusBadRequest},
+		{"missing query", `{}`, http.StatusBadRequest},
+		{"deeply nested", `{"query":"{ tokens(limit: ` + strings.Repeat("[", 1<<16) + `) { symbol } }"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+//go:build integration
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// get serves a request through router and decodes the envelope's data into out
+func get(t *testing.T, router *gin.Engine, path string, out interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code == http.StatusOK && out != nil {
+		envelope := models.APIResponse{Data: out}
+		if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("decoding %s: %v", path, err)
+		}
+	}
+	return w
+}
+
+func newTestRouter(t *testing.T) (*gin.Engine, time.Time) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	ch := testutil.ClickHouse(t)
+	pg := testutil.Postgres(t)
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-time.Hour)
+	testutil.SeedTrades(t, ch,
+		testutil.Trade{Timestamp: hour.Add(time.Minute), Symbol: "BTCUSDT", Price: decimal.NewFromInt(100), Quantity: decimal.NewFromInt(1)},
+		testutil.Trade{Timestamp: hour.Add(30 * time.Minute), Symbol: "BTCUSDT", Price: decimal.NewFromInt(104), Quantity: decimal.NewFromInt(2)},
+	)
+
+	logger := zap.NewNop()
+	ticker := NewTickerHandler(ch, pg, logger)
+	ohlcv := NewOHLCVHandler(ch, pg, logger)
+	trades := NewTradeHandler(ch, logger)
+
+	router := gin.New()
+	router.GET("/tickers", ticker.GetTicker)
+	router.GET("/tickers/:symbol", ticker.GetTickerBySymbol)
+	router.GET("/ohlcv/:symbol", ohlcv.GetOHLCV)
+	router.GET("/trades/:symbol", trades.ExportTrades)
+	router.GET("/trades/:symbol/stats", trades.GetTradeStats)
+	return router, hour
+}
+
+func TestTickerHandler(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	var ticker models.TickerResponse
+	w := get(t, router, "/tickers/btcusdt", &ticker)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if ticker.Symbol != "BTCUSDT" || !ticker.Price.Equal(decimal.NewFromInt(104)) {
+		t.Errorf("unexpected ticker: %+v", ticker)
+	}
+	// The 24h stats query the same trades, so they are only present when its
+	// time bounds are in the unit ClickHouse expects
+	if ticker.Volume24h == nil || !ticker.Volume24h.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("24h volume = %v, want 3", ticker.Volume24h)
+	}
+	if src := ticker.Source; src == nil || src.Type != models.SourceTrades ||
+		len(src.Venues) != 1 || src.Venues[0] != "binance" || src.LastUpdated == nil {
+		t.Errorf("ticker source = %+v, want Binance trades", ticker.Source)
+	}
+
+	if w := get(t, router, "/tickers/DOGEUSDT", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown symbol status = %d, want 404", w.Code)
+	}
+
+	var tickers []models.TickerResponse
+	if w := get(t, router, "/tickers", &tickers); w.Code != http.StatusOK {
+		t.Fatalf("list status = %d: %s", w.Code, w.Body)
+	}
+	if len(tickers) != 1 || tickers[0].Symbol != "BTCUSDT" || tickers[0].Source == nil {
+		t.Errorf("tickers = %+v, want BTCUSDT with its source", tickers)
+	}
+}
+
+func TestTickerNotModified(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, path := range []string{"/tickers", "/tickers/BTCUSDT"} {
+		w := get(t, router, path, nil)
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || etag == "" {
+			t.Fatalf("%s: status = %d, ETag = %q", path, w.Code, etag)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("%s with If-None-Match: status = %d, body %q, want an empty 304", path, w.Code, w.Body)
+		}
+	}
+}
+
+func TestOHLCVHandler(t *testing.T) {
+	router, hour := newTestRouter(t)
+	from, to := hour.Unix(), hour.Add(time.Hour).Unix()
+
+	var candles []models.OHLCVResponse
+	w := get(t, router, fmt.Sprintf("/ohlcv/BTCUSDT?interval=1h&from=%d&to=%d", from, to), &candles)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if len(candles) != 1 {
+		t.Fatalf("got %d candles, want 1", len(candles))
+	}
+	c := candles[0]
+	if c.Timestamp != hour.Unix() {
+		t.Errorf("candle timestamp = %d, want %d", c.Timestamp, hour.Unix())
+	}
+	if c.Open.String() != "100" || c.High.String() != "104" || c.Low.String() != "100" ||
+		c.Close.String() != "104" || c.Volume.String() != "3" || c.TradesCount != 2 {
+		t.Errorf("unexpected candle: %+v", c)
+	}
+
+	// Prices are serialized as strings so no precision is lost in JSON
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"close":"104"`)) {
+		t.Errorf("close not serialized as a decimal string: %s", w.Body)
+	}
+
+	var envelope models.APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decoding envelope: %v", err)
+	}
+	want := hour.Add(30 * time.Minute)
+	if src := envelope.Source; src == nil || src.Type != models.SourceTrades ||
+		len(src.Venues) != 1 || src.Venues[0] != "binance" || src.LastUpdated == nil || !src.LastUpdated.Equal(want) {
+		t.Errorf("source = %+v, want Binance trades last updated at %v", envelope.Source, want)
+	}
+
+	if w := get(t, router, fmt.Sprintf("/ohlcv/DOGEUSDT?interval=1h&from=%d&to=%d", from, to), nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown symbol status = %d, want 404", w.Code)
+	}
+	if w := get(t, router, "/ohlcv/BTCUSDT?interval=7m", nil); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("bad interval status = %d, want 422", w.Code)
+	}
+}
+
+func TestTradeExportPages(t *testing.T) {
+	router, hour := newTestRouter(t)
+	base := fmt.Sprintf("/trades/BTCUSDT?from=%d&to=%d&limit=1", hour.Unix(), hour.Add(time.Hour).Unix())
+
+	// Two trades a page each, the second page the last
+	var prices []string
+	path := base
+	for page := 0; ; page++ {
+		if page > 2 {
+			t.Fatal("export did not end after both trades")
+		}
+		w := get(t, router, path, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body)
+		}
+		var trade models.TradeResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &trade); err != nil {
+			t.Fatalf("decoding page %d: %v: %s", page, err, w.Body)
+		}
+		prices = append(prices, trade.Price.String())
+		cursor := w.Header().Get("X-Next-Cursor")
+		if cursor == "" {
+			break
+		}
+		path = base + "&cursor=" + cursor
+	}
+	if fmt.Sprint(prices) != "[100 104]" {
+		t.Errorf("paged prices %v, want [100 104]", prices)
+	}
+
+	w := get(t, router, strings.Replace(base, "limit=1", "format=csv", 1), nil)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if w.Code != http.StatusOK || len(lines) != 3 || lines[0] != "timestamp,trade_id,exchange,symbol,price,quantity,is_buyer_maker" {
+		t.Errorf("CSV export = %d %q", w.Code, w.Body)
+	}
+	if w := get(t, router, base+"&cursor=nope", nil); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("bad cursor status = %d, want 422", w.Code)
+	}
+}
+
+func TestTradeStats(t *testing.T) {
+	router, hour := newTestRouter(t)
+
+	var stats models.TradeStats
+	w := get(t, router, fmt.Sprintf("/trades/BTCUSDT/stats?from=%d&to=%d", hour.Unix(), hour.Add(time.Hour).Unix()), &stats)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if stats.TotalTrades != 2 || stats.TotalVolume.String() != "3" || stats.AvgPrice.String() != "102" ||
+		stats.MinPrice.String() != "100" || stats.MaxPrice.String() != "104" {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.FirstTradeTime != hour.Add(time.Minute).UnixMilli() || stats.LastTradeTime != hour.Add(30*time.Minute).UnixMilli() {
+		t.Errorf("trade times %d..%d, want the seeded trades", stats.FirstTradeTime, stats.LastTradeTime)
+	}
+}
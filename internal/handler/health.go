@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/ingester"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/polling"
+	"github.com/ashmitsharp/trading/internal/requestid"
+	"github.com/ashmitsharp/trading/internal/supervisor"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// dependencyTimeout bounds each readiness check so a hung database cannot
+// stall the probe past the kubelet's own timeout
+const dependencyTimeout = 2 * time.Second
+
+// HealthHandler serves Kubernetes-style liveness and readiness probes
+type HealthHandler struct {
+	postgresDB     *sql.DB
+	clickhouseConn driver.Conn
+	pollStatus     *polling.Status
+	maxPollAge     time.Duration
+	ingester       *ingester.BinanceIngester
+	tasks          *supervisor.Group
+	startedAt      time.Time
+	logger         *zap.Logger
+}
+
+// NewHealthHandler creates a new health handler. pollStatus may be nil when
+// the poller does not run in this process; maxPollAge is how stale the last
+// successful poll may be before the service reports not ready. ingester is
+// nil when the WebSocket ingester does not run here. tasks, when not nil, is
+// reported with its restart counts.
+func NewHealthHandler(postgresDB *sql.DB, clickhouseConn driver.Conn, pollStatus *polling.Status, maxPollAge time.Duration,
+	ingester *ingester.BinanceIngester, tasks *supervisor.Group, logger *zap.Logger) *HealthHandler {
+	return &HealthHandler{
+		postgresDB:     postgresDB,
+		clickhouseConn: clickhouseConn,
+		pollStatus:     pollStatus,
+		maxPollAge:     maxPollAge,
+		ingester:       ingester,
+		tasks:          tasks,
+		startedAt:      time.Now(),
+		logger:         logger,
+	}
+}
+
+// Livez reports that the process is up; it never touches dependencies
+// @Summary Liveness probe
+// @Description Report that the process is running. Does not check dependencies.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.LivenessResponse} "Alive"
+// @Router /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	RespondOK(c, models.LivenessResponse{
+		Status:    "ok",
+		Uptime:    int64(time.Since(h.startedAt).Seconds()),
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// Readyz checks every dependency and returns 503 if any is down
+// @Summary Readiness probe
+// @Description Ping PostgreSQL and ClickHouse and check poller freshness, with per-dependency latency, and list background jobs with their restart counts. A WebSocket ingester connection that gave up reconnecting reports the service degraded, still with a 200, since the API keeps serving.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.ReadinessResponse} "Ready"
+// @Failure 503 {object} models.APIResponse{data=models.ReadinessResponse} "Not ready"
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	resp := h.Check(c.Request.Context())
+
+	status := http.StatusOK
+	if resp.Status == "not_ready" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, models.APIResponse{
+		Success:   status == http.StatusOK,
+		Data:      resp,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// Health reports dependency status in the original /health shape;
+// probes should use /livez and /readyz instead
+// @Summary Service health
+// @Description Summarize dependency status. Deprecated: use /livez and /readyz.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.ServiceHealthResponse} "Health status"
+// @Deprecated
+// @Router /health [get]
+func (h *HealthHandler) Health(c *gin.Context) {
+	readiness := h.Check(c.Request.Context())
+
+	status := "healthy"
+	services := make(map[string]bool, len(readiness.Checks))
+	for name, check := range readiness.Checks {
+		services[name] = check.Status == "up"
+		if !services[name] {
+			status = "degraded"
+		}
+	}
+
+	RespondOK(c, models.ServiceHealthResponse{
+		Status:    status,
+		Services:  services,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// Check runs all readiness checks concurrently
+func (h *HealthHandler) Check(ctx context.Context) models.ReadinessResponse {
+	checks := map[string]func(context.Context) error{
+		"postgres": h.postgresDB.PingContext,
+		"clickhouse": func(ctx context.Context) error {
+			return h.clickhouseConn.Ping(ctx)
+		},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]models.DependencyCheck, len(checks)+2)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, dependencyTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(ctx)
+			result := models.DependencyCheck{
+				Status:    "up",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = "down"
+				result.Error = err.Error()
+				requestid.Logger(ctx, h.logger).Warn("Readiness check failed", zap.String("dependency", name), zap.Error(err))
+			}
+
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	if h.pollStatus != nil {
+		results["poller"] = h.checkPoller()
+	}
+	if h.ingester != nil {
+		results["ingester"] = h.checkIngester()
+	}
+
+	status := "ready"
+	for name, r := range results {
+		if r.Status != "down" {
+			continue
+		}
+		if degradesOnly[name] {
+			if status == "ready" {
+				status = "degraded"
+			}
+		} else {
+			status = "not_ready"
+		}
+	}
+
+	return models.ReadinessResponse{
+		Status:    status,
+		Checks:    results,
+		Tasks:     taskStatuses(h.tasks),
+		Timestamp: time.Now().Unix(),
+	}
+}
+
+// taskStatuses reports the supervised background jobs. Crashes do not make
+// the service unready: the job is restarted, and a poller that keeps failing
+// shows up in the poller check.
+func taskStatuses(tasks *supervisor.Group) []models.TaskStatus {
+	if tasks == nil {
+		return nil
+	}
+	snaps := tasks.Snapshot()
+	statuses := make([]models.TaskStatus, 0, len(snaps))
+	for _, t := range snaps {
+		status := models.TaskStatus{
+			Name:      t.Name,
+			Running:   t.Running,
+			Restarts:  t.Restarts,
+			LastCrash: t.LastCrash,
+		}
+		if !t.CrashedAt.IsZero() {
+			ts := t.CrashedAt.Unix()
+			status.CrashedAt = &ts
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// degradesOnly are the checks that, when down, leave the service degraded
+// but ready: the API still answers from what is stored
+var degradesOnly = map[string]bool{"ingester": true}
+
+// checkIngester reports the ingester down once any of its connections has
+// given up reconnecting, since those symbols are no longer streamed
+func (h *HealthHandler) checkIngester() models.DependencyCheck {
+	shards := h.ingester.ShardStats()
+	var connected, givenUp int
+	var lastError string
+	for _, shard := range shards {
+		if shard.Connected {
+			connected++
+		}
+		if shard.GivenUp {
+			givenUp++
+			lastError = shard.LastError
+		}
+	}
+
+	result := models.DependencyCheck{
+		Status: "up",
+		Detail: fmt.Sprintf("%d/%d connections streaming", connected, len(shards)),
+	}
+	if givenUp > 0 || !h.ingester.IsRunning() {
+		result.Status = "down"
+		result.Error = lastError
+		if givenUp > 0 {
+			result.Detail = fmt.Sprintf("%d/%d connections gave up reconnecting", givenUp, len(shards))
+		}
+	}
+	return result
+}
+
+// checkPoller reports the poller down once its last successful cycle is older
+// than maxPollAge; a freshly started poller gets that long to succeed once
+func (h *HealthHandler) checkPoller() models.DependencyCheck {
+	snap := h.pollStatus.Snapshot()
+	result := models.DependencyCheck{
+		Status: "up",
+		Error:  snap.LastError,
+		Detail: fmt.Sprintf("%d/%d exchanges returned data in the last cycle",
+			snap.ExchangesSucceeded, snap.ExchangesPolled),
+	}
+
+	since := snap.StartedAt
+	if !snap.LastSuccessAt.IsZero() {
+		age := time.Since(snap.LastSuccessAt).Seconds()
+		result.LastSuccessAgeSeconds = &age
+		since = snap.LastSuccessAt
+	}
+	if h.maxPollAge > 0 && time.Since(since) > h.maxPollAge {
+		result.Status = "down"
+	}
+	return result
+}
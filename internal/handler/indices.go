@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/indices"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// IndexHandler serves index/basket definitions and levels
+type IndexHandler struct {
+	postgresDB   *sql.DB
+	indexStorage *storage.IndexStorage
+	logger       *zap.Logger
+}
+
+// NewIndexHandler creates a new index handler
+func NewIndexHandler(postgresDB *sql.DB, indexStorage *storage.IndexStorage, logger *zap.Logger) *IndexHandler {
+	return &IndexHandler{
+		postgresDB:   postgresDB,
+		indexStorage: indexStorage,
+		logger:       logger,
+	}
+}
+
+// ListIndices lists active indices with their latest level
+// @Summary List indices
+// @Description List active index baskets with constituents, weights and latest level
+// @Tags indices
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.IndexResponse} "Indices"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/indices [get]
+func (h *IndexHandler) ListIndices(c *gin.Context) {
+	ctx := c.Request.Context()
+	all, err := indices.LoadIndices(ctx, h.postgresDB, true)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load indices", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve indices")
+		return
+	}
+
+	resp := make([]models.IndexResponse, 0, len(all))
+	for _, idx := range all {
+		r, err := h.indexResponse(ctx, idx)
+		if err != nil {
+			requestLogger(c, h.logger).Error("Failed to load index level", zap.Error(err), zap.String("index", idx.Slug))
+			RespondServiceError(c, err, "Failed to retrieve index levels")
+			return
+		}
+		resp = append(resp, r)
+	}
+
+	RespondOK(c, resp)
+}
+
+// GetIndex returns one index with its constituents and latest level
+// @Summary Get index
+// @Description Get an index basket definition, its constituents and weights, and the latest level computed from VWAPs
+// @Tags indices
+// @Produce json
+// @Param id path string true "Index ID or slug (e.g., top10)"
+// @Success 200 {object} models.APIResponse{data=models.IndexResponse} "Index"
+// @Failure 404 {object} models.ErrorResponse "Index not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/indices/{id} [get]
+func (h *IndexHandler) GetIndex(c *gin.Context) {
+	ctx := c.Request.Context()
+	ident := strings.ToLower(strings.TrimSpace(c.Param("id")))
+
+	idx, err := indices.LoadIndex(ctx, h.postgresDB, ident)
+	if errors.Is(err, indices.ErrIndexNotFound) {
+		RespondNotFound(c, "index_not_found", "Index not found")
+		return
+	}
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load index", zap.Error(err), zap.String("index", ident))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve index")
+		return
+	}
+
+	resp, err := h.indexResponse(ctx, idx)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load index level", zap.Error(err), zap.String("index", idx.Slug))
+		RespondServiceError(c, err, "Failed to retrieve index level")
+		return
+	}
+
+	RespondOK(c, resp)
+}
+
+// indexResponse builds the response for an index; a missing level is left
+// nil since a new index has none until its first computation
+func (h *IndexHandler) indexResponse(ctx context.Context, idx *indices.Index) (models.IndexResponse, error) {
+	resp := models.IndexResponse{
+		ID:            idx.ID,
+		Slug:          idx.Slug,
+		Name:          idx.Name,
+		Description:   idx.Description,
+		Weighting:     idx.Weighting,
+		RebalanceDays: idx.RebalanceDays,
+		BaseLevel:     idx.BaseLevel.InexactFloat64(),
+		Constituents:  make([]models.IndexConstituent, 0, len(idx.Constituents)),
+	}
+	if idx.TopN > 0 {
+		resp.TopN = &idx.TopN
+	}
+	if idx.LastRebalancedAt.Valid {
+		ts := idx.LastRebalancedAt.Time.Unix()
+		resp.LastRebalancedAt = &ts
+	}
+	for _, ct := range idx.Constituents {
+		constituent := models.IndexConstituent{TokenID: ct.TokenID, Symbol: ct.Symbol}
+		if ct.Weight.Valid {
+			w := ct.Weight.Decimal.InexactFloat64()
+			constituent.Weight = &w
+		}
+		resp.Constituents = append(resp.Constituents, constituent)
+	}
+
+	level, open, err := h.indexStorage.GetLatestIndexLevel(ctx, idx.ID)
+	if errors.Is(err, db.ErrNoData) {
+		return resp, nil
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	value := level.Level.InexactFloat64()
+	ts := level.Timestamp.Unix()
+	resp.Level = &value
+	resp.LevelTimestamp = &ts
+	if open.IsPositive() {
+		change := level.Level.Sub(open).Div(open).Mul(decimal.NewFromInt(100)).Round(4).InexactFloat64()
+		resp.Change24h = &change
+	}
+	return resp, nil
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/listings"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultListingsWindow is how far back /listings/new looks without since
+const defaultListingsWindow = 7 * 24 * time.Hour
+
+// ListingsHandler serves the new-listing feed
+type ListingsHandler struct {
+	postgresDB *sql.DB
+	logger     *zap.Logger
+}
+
+// NewListingsHandler creates a new listings handler
+func NewListingsHandler(postgresDB *sql.DB, logger *zap.Logger) *ListingsHandler {
+	return &ListingsHandler{
+		postgresDB: postgresDB,
+		logger:     logger,
+	}
+}
+
+// GetNewListings lists base symbols that recently appeared on an exchange
+// @Summary New listings
+// @Description Base symbols seen on an exchange for the first time, newest first, with the venue, first polled price and whether the token was mapped automatically. What each exchange listed when it was first polled is not included.
+// @Tags listings
+// @Produce json
+// @Param exchange query string false "Only listings on this exchange"
+// @Param since query int false "Unix timestamp in seconds (default: 7 days ago)"
+// @Param limit query int false "Maximum listings (1-500)" default(50)
+// @Success 200 {object} models.APIResponse{data=[]models.ListingResponse} "New listings"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/listings/new [get]
+func (h *ListingsHandler) GetNewListings(c *gin.Context) {
+	v := NewRequestValidator(c)
+	since := v.Timestamp("since", timeutil.SecondsOf(time.Now().Add(-defaultListingsWindow)))
+	limit := v.IntRange("limit", 50, 1, 500)
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	found, err := listings.Recent(c.Request.Context(), h.postgresDB, listings.Filter{
+		ExchangeID: strings.ToLower(strings.TrimSpace(c.Query("exchange"))),
+		Since:      since.Time(),
+		Limit:      limit,
+	})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load new listings", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve new listings")
+		return
+	}
+
+	resp := make([]models.ListingResponse, 0, len(found))
+	for _, l := range found {
+		r := models.ListingResponse{
+			ID:            l.ID,
+			ExchangeID:    l.ExchangeID,
+			BaseSymbol:    l.BaseSymbol,
+			QuoteSymbol:   l.QuoteSymbol,
+			PairSymbol:    l.PairSymbol,
+			FirstPrice:    l.FirstPrice,
+			MappingMethod: l.MappingMethod,
+			AutoMapped:    l.AutoMapped,
+			FirstSeenAt:   l.FirstSeenAt,
+		}
+		if l.TokenID != 0 {
+			id := l.TokenID
+			r.TokenID = &id
+		}
+		resp = append(resp, r)
+	}
+	RespondOK(c, resp)
+}
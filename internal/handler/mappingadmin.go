@@ -0,0 +1,443 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/symbol"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MappingAdminHandler serves editing symbol mappings and trading pairs by
+// hand. Every change is written to mapping_audit_log with who made it.
+type MappingAdminHandler struct {
+	postgresDB *sql.DB
+	resolver   *symbol.Resolver
+	logger     *zap.Logger
+}
+
+// NewMappingAdminHandler creates a new mapping admin handler. The resolver
+// cache is refreshed after each change; other processes pick changes up on
+// their next refresh.
+func NewMappingAdminHandler(postgresDB *sql.DB, resolver *symbol.Resolver, logger *zap.Logger) *MappingAdminHandler {
+	return &MappingAdminHandler{
+		postgresDB: postgresDB,
+		resolver:   resolver,
+		logger:     logger,
+	}
+}
+
+// SymbolMappingRequest is the body of creating or replacing a symbol mapping
+type SymbolMappingRequest struct {
+	TokenID          int    `json:"token_id" binding:"required,min=1"`
+	ExchangeID       string `json:"exchange_id" binding:"required,max=50"`
+	ExchangeSymbol   string `json:"exchange_symbol" binding:"required,max=50"`
+	NormalizedSymbol string `json:"normalized_symbol" binding:"max=50"` // defaults to the token's symbol
+	IsActive         *bool  `json:"is_active"`                          // default true
+	PerformedBy      string `json:"performed_by" binding:"required,max=100"`
+	Notes            string `json:"notes"`
+}
+
+// TradingPairRequest is the body of creating or replacing a trading pair
+type TradingPairRequest struct {
+	BaseTokenID    int    `json:"base_token_id" binding:"required,min=1"`
+	QuoteTokenID   int    `json:"quote_token_id" binding:"required,min=1,nefield=BaseTokenID"`
+	ExchangeID     string `json:"exchange_id" binding:"required,max=50"`
+	Symbol         string `json:"symbol" binding:"required,max=100"`
+	InstrumentType string `json:"instrument_type" binding:"omitempty,oneof=spot perp futures leveraged_token"` // default spot
+	IsActive       *bool  `json:"is_active"`                                                                   // default true
+	PerformedBy    string `json:"performed_by" binding:"required,max=100"`
+	Notes          string `json:"notes"`
+}
+
+func (r SymbolMappingRequest) mapping() (db.SymbolMapping, db.MappingChange) {
+	return db.SymbolMapping{
+		TokenID:          r.TokenID,
+		ExchangeID:       exchanges.NormalizeExchangeID(r.ExchangeID),
+		ExchangeSymbol:   strings.TrimSpace(r.ExchangeSymbol),
+		NormalizedSymbol: strings.ToUpper(strings.TrimSpace(r.NormalizedSymbol)),
+		IsActive:         r.IsActive == nil || *r.IsActive,
+	}, db.MappingChange{
+		PerformedBy: r.PerformedBy,
+		Notes:       strings.TrimSpace(r.Notes),
+	}
+}
+
+func (r TradingPairRequest) pair() (db.TradingPair, db.MappingChange) {
+	instrumentType := r.InstrumentType
+	if instrumentType == "" {
+		instrumentType = string(exchanges.InstrumentSpot)
+	}
+	return db.TradingPair{
+		BaseTokenID:    r.BaseTokenID,
+		QuoteTokenID:   r.QuoteTokenID,
+		ExchangeID:     exchanges.NormalizeExchangeID(r.ExchangeID),
+		Symbol:         strings.TrimSpace(r.Symbol),
+		InstrumentType: instrumentType,
+		IsActive:       r.IsActive == nil || *r.IsActive,
+	}, db.MappingChange{
+		PerformedBy: r.PerformedBy,
+		Notes:       strings.TrimSpace(r.Notes),
+	}
+}
+
+// ListSymbolMappings pages through symbol mappings
+// @Summary List symbol mappings
+// @Description Symbol mappings by ID, active or not. Page with after_id set to the previous page's next_after_id.
+// @Tags admin
+// @Produce json
+// @Param exchange query string false "Only mappings on this exchange"
+// @Param token_id query int false "Only mappings to this token"
+// @Param after_id query int false "Only mappings after this ID" default(0)
+// @Param limit query int false "Maximum mappings (1-500)" default(100)
+// @Success 200 {object} models.APIResponse{data=models.SymbolMappingListResponse} "Symbol mappings"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings [get]
+func (h *MappingAdminHandler) ListSymbolMappings(c *gin.Context) {
+	f, ok := mappingFilter(c)
+	if !ok {
+		return
+	}
+
+	found, err := db.ListSymbolMappings(c.Request.Context(), h.postgresDB, f)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load symbol mappings", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve symbol mappings")
+		return
+	}
+
+	resp := models.SymbolMappingListResponse{Mappings: make([]models.SymbolMappingResponse, 0, len(found))}
+	for _, m := range found {
+		resp.Mappings = append(resp.Mappings, symbolMappingResponse(m))
+	}
+	if len(found) == f.Limit {
+		next := found[len(found)-1].ID
+		resp.NextAfterID = &next
+	}
+	RespondOK(c, resp)
+}
+
+// CreateSymbolMapping maps an exchange symbol to a token
+// @Summary Create symbol mapping
+// @Description Map exchange_symbol on exchange_id to token_id as a verified manual mapping, which automatic re-mapping leaves alone, and record it in the mapping audit log. The token must be active.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body SymbolMappingRequest true "Mapping and audit details"
+// @Success 200 {object} models.APIResponse{data=models.SymbolMappingResponse} "Created"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 409 {object} models.ErrorResponse "Exchange symbol already mapped"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings [post]
+func (h *MappingAdminHandler) CreateSymbolMapping(c *gin.Context) {
+	var req SymbolMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	mapping, change := req.mapping()
+	m, err := db.CreateSymbolMapping(c.Request.Context(), h.postgresDB, mapping, change)
+	if err != nil {
+		h.respondError(c, err, "Failed to create symbol mapping")
+		return
+	}
+	h.refreshResolver(c)
+	RespondOKWithMessage(c, symbolMappingResponse(m), "Mapping created successfully")
+}
+
+// UpdateSymbolMapping replaces a symbol mapping
+// @Summary Update symbol mapping
+// @Description Replace the token, exchange symbol and state of mapping {id}, making it a verified manual mapping, and record the change in the mapping audit log. Set is_active to false to stop resolving the symbol without losing the row.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Mapping ID"
+// @Param request body SymbolMappingRequest true "Mapping and audit details"
+// @Success 200 {object} models.APIResponse{data=models.SymbolMappingResponse} "Updated"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Mapping or token not found"
+// @Failure 409 {object} models.ErrorResponse "Exchange symbol already mapped"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings/{id} [put]
+func (h *MappingAdminHandler) UpdateSymbolMapping(c *gin.Context) {
+	id, ok := idParam(c, "Invalid mapping ID")
+	if !ok {
+		return
+	}
+	var req SymbolMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	mapping, change := req.mapping()
+	m, err := db.UpdateSymbolMapping(c.Request.Context(), h.postgresDB, id, mapping, change)
+	if err != nil {
+		h.respondError(c, err, "Failed to update symbol mapping")
+		return
+	}
+	h.refreshResolver(c)
+	RespondOK(c, symbolMappingResponse(m))
+}
+
+// DeleteSymbolMapping removes a symbol mapping
+// @Summary Delete symbol mapping
+// @Description Remove mapping {id} and record what it was in the mapping audit log. Stored prices are kept.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Mapping ID"
+// @Param performed_by query string true "Who deletes the mapping, for the audit log"
+// @Param notes query string false "Why, for the audit log"
+// @Success 200 {object} models.APIResponse "Deleted"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Mapping not found"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings/{id} [delete]
+func (h *MappingAdminHandler) DeleteSymbolMapping(c *gin.Context) {
+	id, ok := idParam(c, "Invalid mapping ID")
+	if !ok {
+		return
+	}
+	change, ok := deleteChange(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DeleteSymbolMapping(c.Request.Context(), h.postgresDB, id, change); err != nil {
+		h.respondError(c, err, "Failed to delete symbol mapping")
+		return
+	}
+	h.refreshResolver(c)
+	RespondOKWithMessage(c, gin.H{"id": id}, "Mapping deleted successfully")
+}
+
+// ListTradingPairs pages through trading pairs
+// @Summary List trading pairs
+// @Description Trading pairs by ID, active or not. Page with after_id set to the previous page's next_after_id.
+// @Tags admin
+// @Produce json
+// @Param exchange query string false "Only pairs on this exchange"
+// @Param token_id query int false "Only pairs with this token as base or quote"
+// @Param after_id query int false "Only pairs after this ID" default(0)
+// @Param limit query int false "Maximum pairs (1-500)" default(100)
+// @Success 200 {object} models.APIResponse{data=models.PairListResponse} "Trading pairs"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/pairs [get]
+func (h *MappingAdminHandler) ListTradingPairs(c *gin.Context) {
+	f, ok := mappingFilter(c)
+	if !ok {
+		return
+	}
+
+	found, err := db.ListTradingPairs(c.Request.Context(), h.postgresDB, f)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load trading pairs", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve trading pairs")
+		return
+	}
+
+	resp := models.PairListResponse{Pairs: make([]models.PairResponse, 0, len(found))}
+	for _, p := range found {
+		resp.Pairs = append(resp.Pairs, pairResponse(p))
+	}
+	if len(found) == f.Limit {
+		next := found[len(found)-1].ID
+		resp.NextAfterID = &next
+	}
+	RespondOK(c, resp)
+}
+
+// CreateTradingPair adds a trading pair
+// @Summary Create trading pair
+// @Description Add symbol on exchange_id as a verified manual pair of base_token_id and quote_token_id, and record it in the mapping audit log. Both tokens must be active and the exchange registered.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body TradingPairRequest true "Pair and audit details"
+// @Success 200 {object} models.APIResponse{data=models.PairResponse} "Created"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Token or exchange not found"
+// @Failure 409 {object} models.ErrorResponse "Pair already exists"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/pairs [post]
+func (h *MappingAdminHandler) CreateTradingPair(c *gin.Context) {
+	var req TradingPairRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	pair, change := req.pair()
+	p, err := db.CreateTradingPair(c.Request.Context(), h.postgresDB, pair, change)
+	if err != nil {
+		h.respondError(c, err, "Failed to create trading pair")
+		return
+	}
+	h.refreshResolver(c)
+	RespondOKWithMessage(c, pairResponse(p), "Trading pair created successfully")
+}
+
+// UpdateTradingPair replaces a trading pair
+// @Summary Update trading pair
+// @Description Replace the tokens, exchange, symbol, instrument type and state of pair {id}, making it a verified manual pair, and record the change in the mapping audit log. Order rules, fees and volumes are kept. Activating a delisted or archived pair clears its delisting and tombstone.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Trading pair ID"
+// @Param request body TradingPairRequest true "Pair and audit details"
+// @Success 200 {object} models.APIResponse{data=models.PairResponse} "Updated"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Pair, token or exchange not found"
+// @Failure 409 {object} models.ErrorResponse "Pair already exists"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/pairs/{id} [put]
+func (h *MappingAdminHandler) UpdateTradingPair(c *gin.Context) {
+	id, ok := idParam(c, "Invalid trading pair ID")
+	if !ok {
+		return
+	}
+	var req TradingPairRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	pair, change := req.pair()
+	p, err := db.UpdateTradingPair(c.Request.Context(), h.postgresDB, id, pair, change)
+	if err != nil {
+		h.respondError(c, err, "Failed to update trading pair")
+		return
+	}
+	h.refreshResolver(c)
+	RespondOK(c, pairResponse(p))
+}
+
+// DeleteTradingPair removes a trading pair
+// @Summary Delete trading pair
+// @Description Remove pair {id} and record what it was in the mapping audit log. Stored prices are kept.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Trading pair ID"
+// @Param performed_by query string true "Who deletes the pair, for the audit log"
+// @Param notes query string false "Why, for the audit log"
+// @Success 200 {object} models.APIResponse "Deleted"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Pair not found"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/pairs/{id} [delete]
+func (h *MappingAdminHandler) DeleteTradingPair(c *gin.Context) {
+	id, ok := idParam(c, "Invalid trading pair ID")
+	if !ok {
+		return
+	}
+	change, ok := deleteChange(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DeleteTradingPair(c.Request.Context(), h.postgresDB, id, change); err != nil {
+		h.respondError(c, err, "Failed to delete trading pair")
+		return
+	}
+	h.refreshResolver(c)
+	RespondOKWithMessage(c, gin.H{"id": id}, "Trading pair deleted successfully")
+}
+
+func (h *MappingAdminHandler) refreshResolver(c *gin.Context) {
+	if err := h.resolver.RefreshCache(c.Request.Context()); err != nil {
+		requestLogger(c, h.logger).Warn("Failed to refresh resolver cache after mapping change", zap.Error(err))
+	}
+}
+
+// respondError shows not-found and conflict errors to the client and a
+// generic message otherwise
+func (h *MappingAdminHandler) respondError(c *gin.Context, err error, message string) {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c, h.logger).Error(message, zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, message)
+		return
+	}
+	RespondError(c, status, code, err.Error())
+}
+
+// mappingFilter reads the exchange, token_id, after_id and limit query
+// parameters of the list endpoints. It writes the validation errors and
+// returns false when they are malformed.
+func mappingFilter(c *gin.Context) (db.MappingFilter, bool) {
+	v := NewRequestValidator(c)
+	f := db.MappingFilter{
+		ExchangeID: exchanges.NormalizeExchangeID(c.Query("exchange")),
+		TokenID:    v.IntRange("token_id", 0, 0, 1<<31-1),
+		AfterID:    v.IntRange("after_id", 0, 0, 1<<31-1),
+		Limit:      v.IntRange("limit", 100, 1, 500),
+	}
+	if !v.Valid() {
+		v.Respond()
+		return f, false
+	}
+	return f, true
+}
+
+// deleteChange reads who deletes a mapping or pair from the query, since
+// DELETE requests carry no body
+func deleteChange(c *gin.Context) (db.MappingChange, bool) {
+	change := db.MappingChange{
+		PerformedBy: strings.TrimSpace(c.Query("performed_by")),
+		Notes:       strings.TrimSpace(c.Query("notes")),
+	}
+	if change.PerformedBy == "" {
+		v := NewRequestValidator(c)
+		v.Add("performed_by", "Performed by is required for the audit log")
+		v.Respond()
+		return change, false
+	}
+	return change, true
+}
+
+func symbolMappingResponse(m db.SymbolMapping) models.SymbolMappingResponse {
+	resp := models.SymbolMappingResponse{
+		ID:                m.ID,
+		TokenID:           m.TokenID,
+		TokenSymbol:       m.TokenSymbol,
+		ExchangeID:        m.ExchangeID,
+		ExchangeSymbol:    m.ExchangeSymbol,
+		NormalizedSymbol:  m.NormalizedSymbol,
+		Chain:             m.Chain,
+		MappingMethod:     m.MappingMethod,
+		ConfidenceScore:   m.ConfidenceScore,
+		NeedsVerification: m.NeedsVerification,
+		IsActive:          m.IsActive,
+		VerifiedBy:        m.VerifiedBy,
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+	}
+	if !m.VerifiedAt.IsZero() {
+		at := m.VerifiedAt
+		resp.VerifiedAt = &at
+	}
+	return resp
+}
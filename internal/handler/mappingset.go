@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ashmitsharp/trading/internal/mappingset"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxBundleBytes caps the size of an imported mapping bundle
+const maxBundleBytes = 64 << 20
+
+// MappingSetHandler exports and imports the mapping dataset
+type MappingSetHandler struct {
+	postgresDB *sql.DB
+	logger     *zap.Logger
+}
+
+// NewMappingSetHandler creates a new mapping export and import handler
+func NewMappingSetHandler(postgresDB *sql.DB, logger *zap.Logger) *MappingSetHandler {
+	return &MappingSetHandler{
+		postgresDB: postgresDB,
+		logger:     logger,
+	}
+}
+
+// ExportMappings downloads the mapping dataset
+// @Summary Export mappings
+// @Description Download every token, exchange symbol mapping and trading pair, with their status and confidence, as a versioned bundle to import into another environment with POST /api/v1/admin/mappings/import or tokenctl import. format=csv gives a zip of manifest.json and one CSV file per table. The checksum is the same for bundles of the same rows.
+// @Tags admin
+// @Produce json
+// @Produce application/zip
+// @Param format query string false "Bundle format" Enums(json, csv) default(json)
+// @Success 200 {file} file "Bundle"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 422 {object} models.ErrorResponse "Unknown format"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings/export [get]
+func (h *MappingSetHandler) ExportMappings(c *gin.Context) {
+	v := NewRequestValidator(c)
+	format := c.DefaultQuery("format", mappingset.FormatJSON)
+	if format != mappingset.FormatJSON && format != mappingset.FormatCSV {
+		v.Add("format", "Format must be one of: json, csv")
+	}
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	bundle, err := mappingset.Export(c.Request.Context(), h.postgresDB)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to export mappings", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to export mappings")
+		return
+	}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, format); err != nil {
+		requestLogger(c, h.logger).Error("Failed to write mapping bundle", zap.Error(err))
+		RespondInternalError(c, ErrCodeInternal, "Failed to export mappings")
+		return
+	}
+
+	contentType, ext := "application/json", "json"
+	if format == mappingset.FormatCSV {
+		contentType, ext = "application/zip", "zip"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="mappings-%s.%s"`,
+		bundle.ExportedAt.Format("20060102T150405Z"), ext))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// ImportMappings writes a mapping bundle
+// @Summary Import mappings
+// @Description Import a bundle from GET /api/v1/admin/mappings/export, in either format, in one transaction. Tokens are matched by chain and contract, or by symbol and chain when they have no contract, and added when missing. Exchange symbols and pairs are upserted by exchange and symbol and take the bundle's token, status and confidence, verified or not; rows the bundle does not have are left alone. dry_run=true reports what the import would do and rolls it back.
+// @Tags admin
+// @Accept json
+// @Accept application/zip
+// @Produce json
+// @Param dry_run query bool false "Roll back after reporting" default(false)
+// @Param bundle body object true "Bundle from the export endpoint"
+// @Success 200 {object} models.APIResponse{data=models.MappingImportResult} "Imported"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 413 {object} models.ErrorResponse "Bundle larger than 64 MiB"
+// @Failure 422 {object} models.ErrorResponse "Invalid bundle"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings/import [post]
+func (h *MappingSetHandler) ImportMappings(c *gin.Context) {
+	v := NewRequestValidator(c)
+	dryRun := v.Bool("dry_run", false)
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBundleBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		RespondError(c, http.StatusRequestEntityTooLarge, ErrCodeBadRequest, "Bundle is larger than 64 MiB")
+		return
+	}
+	if err != nil {
+		RespondBadRequest(c, ErrCodeBadRequest, "Failed to read bundle")
+		return
+	}
+	bundle, err := mappingset.Read(data)
+	if err == nil {
+		err = bundle.Validate()
+	}
+	if err != nil {
+		RespondUnprocessable(c, "invalid_bundle", err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.postgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to start mapping import", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to import mappings")
+		return
+	}
+	defer tx.Rollback()
+
+	summary, err := mappingset.Import(ctx, tx, bundle)
+	if err == nil && !dryRun {
+		err = tx.Commit()
+	}
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to import mappings", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to import mappings")
+		return
+	}
+
+	result := models.MappingImportResult{
+		Checksum:        bundle.Checksum,
+		ExportedAt:      bundle.ExportedAt,
+		DryRun:          dryRun,
+		Tokens:          models.MappingImportCounts(summary.Tokens),
+		ExchangeSymbols: models.MappingImportCounts(summary.Symbols),
+		Pairs:           models.MappingImportCounts(summary.Pairs),
+	}
+	message := "Mappings imported successfully"
+	if dryRun {
+		message = "Dry run: nothing was written"
+	}
+	RespondOKWithMessage(c, result, message)
+}
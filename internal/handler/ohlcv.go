@@ -1,14 +1,16 @@
 package handler
 
 import (
-	"net/http"
-	"strconv"
+	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/ashmitsharp/trading/internal/db"
 	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/timeutil"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -16,156 +18,144 @@ import (
 // OHLCVHandler handles OHLCV (candlestick) data endpoints
 type OHLCVHandler struct {
 	clickhouseConn driver.Conn
+	postgresDB     *sql.DB
 	logger         *zap.Logger
 }
 
-// NewOHLCVHandler creates a new OHLCV handler
-func NewOHLCVHandler(clickhouseConn driver.Conn, logger *zap.Logger) *OHLCVHandler {
+// NewOHLCVHandler creates a new OHLCV handler. postgresDB resolves pair
+// symbols to token IDs for composite candles.
+func NewOHLCVHandler(clickhouseConn driver.Conn, postgresDB *sql.DB, logger *zap.Logger) *OHLCVHandler {
 	return &OHLCVHandler{
 		clickhouseConn: clickhouseConn,
+		postgresDB:     postgresDB,
 		logger:         logger,
 	}
 }
 
 // GetOHLCV returns OHLCV candlestick data for a symbol
 // @Summary Get OHLCV candlestick data
-// @Description Get OHLCV (Open, High, Low, Close, Volume) candlestick data for a trading pair
+// @Description Get OHLCV (Open, High, Low, Close, Volume) candlestick data for a trading pair.
+// @Description The envelope's source object gives the kind of data the candles are built from, the exchanges it came from and when it was last updated.
+// @Description source=trades (default) builds candles from one exchange's trades, Binance unless exchange is given; exchanges other than Binance have trades when the poller runs with TRADES_POLL_ENABLED and the exchange has a trades endpoint, and take the symbol as the exchange writes it (e.g. BTC-USDT on okx). source=composite builds them from the multi-exchange VWAP, where volume is the rolling 24h volume at candle close and trades_count the number of VWAP samples. source=exchange:binance returns the exchange's own candles for the pairs and intervals the poller fetches with KLINES_PAIRS and KLINES_INTERVALS; trades_count is 0 where the exchange does not give it.
 // @Tags ohlcv
 // @Accept json
 // @Produce json
 // @Param symbol path string true "Trading pair symbol (e.g., BTCUSDT)"
 // @Param interval query string false "Candlestick interval" Enums(1m, 5m, 15m, 1h, 4h, 1d) default(1h)
+// @Param source query string false "Price source: trades, composite or exchange:<exchange ID> (binance, okx, bybit, gateio, kucoin)" default(trades)
+// @Param exchange query string false "Exchange whose trades to use with source=trades" default(binance)
+// @Param minutes query int false "Lookback window in minutes; overrides from/to"
 // @Param from query int false "Start time (Unix timestamp in seconds)"
 // @Param to query int false "End time (Unix timestamp in seconds)"
 // @Param limit query int false "Maximum number of candlesticks to return" default(100) maximum(1000)
+// @Param points query int false "Downsample the whole range server-side to at most this many candlesticks, ignoring limit" minimum(2) maximum(1000)
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} models.APIResponse{data=[]models.OHLCVResponse} "Success"
-// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Success 304 "Not modified since the ETag/Last-Modified given"
 // @Failure 404 {object} models.ErrorResponse "Symbol not found"
+// @Failure 422 {object} models.ErrorResponse "Invalid parameters, with per-field details"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
-// @Router /ohlcv/{symbol} [get]
+// @Router /api/v1/ohlcv/{symbol} [get]
 func (h *OHLCVHandler) GetOHLCV(c *gin.Context) {
-	symbol := strings.ToUpper(c.Param("symbol"))
-	if symbol == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:     "invalid_symbol",
-			Message:   "Symbol parameter is required",
-			Code:      http.StatusBadRequest,
-			Timestamp: time.Now().Unix(),
-		})
-		return
+	v := NewRequestValidator(c)
+	symbol := v.Symbol("symbol")
+	interval := v.Interval("interval", "1h")
+	limit := v.IntRange("limit", 100, 1, 1000)
+	points := v.IntRange("points", 0, 2, 1000)
+	source := c.DefaultQuery("source", "trades")
+	klineExchange, fromExchange := strings.CutPrefix(source, "exchange:")
+	klineExchange = strings.ToLower(strings.TrimSpace(klineExchange))
+	if fromExchange && klineExchange == "" || !fromExchange && source != "trades" && source != "composite" {
+		v.Add("source", "Source must be one of: trades, composite, exchange:<exchange ID>")
+	}
+	exchangeID := strings.ToLower(strings.TrimSpace(c.DefaultQuery("exchange", "binance")))
+	if exchangeID == "" || len(exchangeID) > 50 {
+		v.Add("exchange", "Exchange must be an exchange ID such as binance")
 	}
 
-	// New: Check for 'minutes' param
-	minutesStr := c.Query("minutes")
-	var from, to int64
-	now := time.Now().Unix()
-	if minutesStr != "" {
-		minutes, err := strconv.Atoi(minutesStr)
-		if err != nil || minutes <= 0 {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error:     "invalid_minutes",
-				Message:   "Minutes must be a positive integer",
-				Code:      http.StatusBadRequest,
-				Timestamp: time.Now().Unix(),
-			})
-			return
-		}
-		from = now - int64(minutes*60)
+	// A 'minutes' lookback takes precedence over an explicit from/to range
+	now := timeutil.SecondsOf(time.Now())
+	var from, to timeutil.Seconds
+	if minutes := v.IntRange("minutes", 0, 1, 5*365*24*60); minutes > 0 {
+		from = now - timeutil.Seconds(minutes*60)
 		to = now
 	} else {
-		// Fallback to existing logic (parse 'from' and 'to' from query)
-		params, err := h.parseOHLCVParams(c)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error:     "invalid_parameters",
-				Message:   err.Error(),
-				Code:      http.StatusBadRequest,
-				Timestamp: time.Now().Unix(),
-			})
-			return
-		}
-		from = params.From
-		to = params.To
+		from = v.Timestamp("from", now-24*3600)
+		to = v.Timestamp("to", now)
 	}
 
-	// Parse interval and limit as before
-	interval := c.DefaultQuery("interval", "1h")
-	limitStr := c.DefaultQuery("limit", "100")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 || limit > 1000 {
-		limit = 100
+	if v.Valid() {
+		v.TimeRange(from, to, time.Duration(h.getMaxTimeRange(interval))*time.Second)
 	}
-
-	// Validate time range
-	if to <= from {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:     "invalid_time_range",
-			Message:   "End time must be after start time",
-			Code:      http.StatusBadRequest,
-			Timestamp: time.Now().Unix(),
-		})
+	if !v.Valid() {
+		v.Respond()
 		return
 	}
 
-	// Check if time range is not too large
-	maxRange := h.getMaxTimeRange(interval)
-	if to-from > maxRange {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:     "time_range_too_large",
-			Message:   "Time range exceeds maximum allowed for this interval",
-			Code:      http.StatusBadRequest,
-			Timestamp: time.Now().Unix(),
-		})
+	var ohlcvData []db.OHLCVData
+	var baseID, quoteID int
+	var err error
+	if fromExchange {
+		ohlcvData, err = db.GetExchangeCandles(h.clickhouseConn, klineExchange, symbol, from, to, interval)
+		if errors.Is(err, db.ErrNoData) {
+			RespondOKWithMessage(c, []models.OHLCVResponse{}, "No data found for the specified time range")
+			return
+		}
+	} else if source == "composite" {
+		ohlcvData, baseID, quoteID, err = h.compositeOHLCV(c, symbol, from, to, interval)
+		if errors.Is(err, db.ErrSymbolNotFound) {
+			RespondNotFound(c, "symbol_not_found", "Trading pair not found")
+			return
+		}
+		if errors.Is(err, db.ErrNoData) {
+			RespondOKWithMessage(c, []models.OHLCVResponse{}, "No data found for the specified time range")
+			return
+		}
+	} else {
+		// Get OHLCV data from ClickHouse
+		ohlcvData, err = db.GetExchangeOHLCVData(
+			h.clickhouseConn,
+			exchangeID,
+			symbol,
+			from,
+			to,
+			interval,
+		)
+	}
+	if errors.Is(err, db.ErrNoData) {
+		// Distinguish an unknown symbol from a known one with no candles in range
+		if !h.symbolExists(symbol) {
+			RespondNotFound(c, "symbol_not_found", "Trading pair not found")
+			return
+		}
+		RespondOKWithMessage(c, []models.OHLCVResponse{}, "No data found for the specified time range")
 		return
 	}
-
-	// Get OHLCV data from ClickHouse
-	ohlcvData, err := db.GetOHLCVData(
-		h.clickhouseConn,
-		symbol,
-		from, // Convert to milliseconds
-		to,
-		interval,
-	)
 	if err != nil {
-		h.logger.Error("Failed to get OHLCV data",
+		requestLogger(c, h.logger).Error("Failed to get OHLCV data",
 			zap.Error(err),
 			zap.String("symbol", symbol),
 			zap.String("interval", interval))
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:     "database_error",
-			Message:   "Failed to retrieve OHLCV data",
-			Code:      http.StatusInternalServerError,
-			Timestamp: time.Now().Unix(),
-		})
+		RespondServiceError(c, err, "Failed to retrieve OHLCV data")
 		return
 	}
 
-	// Check if symbol has any data
-	if len(ohlcvData) == 0 {
-		// Check if symbol exists at all
-		if !h.symbolExists(symbol) {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Error:     "symbol_not_found",
-				Message:   "Trading pair not found",
-				Code:      http.StatusNotFound,
-				Timestamp: time.Now().Unix(),
-			})
-			return
+	// Candles are keyed by the newest bucket; the still-open bucket can change
+	// without its timestamp moving, so its close and trade count are in the tag
+	last := ohlcvData[len(ohlcvData)-1]
+	for _, d := range ohlcvData {
+		if d.Timestamp > last.Timestamp {
+			last = d
 		}
-
-		// Symbol exists but no data in time range
-		c.JSON(http.StatusOK, models.APIResponse{
-			Success:   true,
-			Data:      []models.OHLCVResponse{},
-			Message:   "No data found for the specified time range",
-			Timestamp: time.Now().Unix(),
-		})
+	}
+	if CheckNotModified(c, last.Timestamp.Time(), last.Close.String(), fmt.Sprint(last.TradesCount)) {
 		return
 	}
 
-	// Apply limit if specified
-	if limit > 0 && len(ohlcvData) > limit {
+	// Downsampling replaces the limit, so the whole range is represented
+	if points > 0 {
+		ohlcvData = downsampleOHLCV(ohlcvData, points)
+	} else if limit > 0 && len(ohlcvData) > limit {
 		ohlcvData = ohlcvData[:limit]
 	}
 
@@ -175,105 +165,65 @@ func (h *OHLCVHandler) GetOHLCV(c *gin.Context) {
 		response = append(response, models.OHLCVResponse{
 			Symbol:      data.Symbol,
 			Interval:    interval,
-			Timestamp:   data.Timestamp / 1000, // Convert back to seconds
-			Open:        data.Open.InexactFloat64(),
-			High:        data.High.InexactFloat64(),
-			Low:         data.Low.InexactFloat64(),
-			Close:       data.Close.InexactFloat64(),
-			Volume:      data.Volume.InexactFloat64(),
+			Timestamp:   int64(data.Timestamp),
+			Open:        data.Open,
+			High:        data.High,
+			Low:         data.Low,
+			Close:       data.Close,
+			Volume:      data.Volume,
 			TradesCount: int64(data.TradesCount),
 		})
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success:   true,
-		Data:      response,
-		Timestamp: time.Now().Unix(),
-	})
-}
-
-// OHLCVParams represents parsed OHLCV query parameters
-type OHLCVParams struct {
-	Interval string
-	From     int64
-	To       int64
-	Limit    int
-}
-
-// parseOHLCVParams parses and validates OHLCV query parameters
-func (h *OHLCVHandler) parseOHLCVParams(c *gin.Context) (*OHLCVParams, error) {
-	params := &OHLCVParams{}
-
-	// Parse interval (default: 1h)
-	params.Interval = c.DefaultQuery("interval", "1h")
-	if !h.isValidInterval(params.Interval) {
-		return nil, &ValidationError{Field: "interval", Message: "Invalid interval. Supported: 1m, 5m, 15m, 1h, 4h, 1d"}
+	// Say where the candles come from; a failed lookup only leaves it out
+	ctx := c.Request.Context()
+	var dataSource *models.DataSource
+	var info db.SourceInfo
+	switch {
+	case fromExchange:
+		info, err = db.GetExchangeCandleSourceInfo(ctx, h.clickhouseConn, klineExchange, symbol, interval)
+		dataSource = &models.DataSource{Type: models.SourceExchangeKlines}
+	case source == "composite":
+		info, err = db.GetCompositeSourceInfo(ctx, h.clickhouseConn, baseID, quoteID, from, to)
+		dataSource = &models.DataSource{Type: models.SourceCompositeVWAP}
+	default:
+		info, err = db.GetTradeSourceInfo(ctx, h.clickhouseConn, exchangeID, symbol)
+		dataSource = &models.DataSource{Type: models.SourceTrades}
 	}
-
-	// Parse time range
-	now := time.Now()
-
-	// Parse 'from' parameter (default: 24 hours ago)
-	fromStr := c.Query("from")
-	if fromStr != "" {
-		from, err := strconv.ParseInt(fromStr, 10, 64)
-		if err != nil {
-			return nil, &ValidationError{Field: "from", Message: "Invalid from timestamp"}
-		}
-		params.From = from
-	} else {
-		params.From = now.Add(-24 * time.Hour).Unix()
-	}
-
-	// Parse 'to' parameter (default: now)
-	toStr := c.Query("to")
-	if toStr != "" {
-		to, err := strconv.ParseInt(toStr, 10, 64)
-		if err != nil {
-			return nil, &ValidationError{Field: "to", Message: "Invalid to timestamp"}
-		}
-		params.To = to
+	if err != nil {
+		requestLogger(c, h.logger).Warn("Failed to get OHLCV data source",
+			zap.Error(err),
+			zap.String("symbol", symbol))
+		dataSource = nil
 	} else {
-		params.To = now.Unix()
-	}
-
-	// Parse limit (default: 100, max: 1000)
-	limitStr := c.DefaultQuery("limit", "100")
-	if limitStr != "" {
-		limit, err := strconv.Atoi(limitStr)
-		if err != nil {
-			return nil, &ValidationError{Field: "limit", Message: "Invalid limit value"}
+		dataSource.Venues = info.Venues
+		if dataSource.Venues == nil {
+			dataSource.Venues = []string{}
 		}
-		if limit < 1 || limit > 1000 {
-			return nil, &ValidationError{Field: "limit", Message: "Limit must be between 1 and 1000"}
+		if !info.LastUpdated.IsZero() {
+			dataSource.LastUpdated = &info.LastUpdated
 		}
-		params.Limit = limit
 	}
 
-	return params, nil
-}
-
-// ValidationError represents a parameter validation error
-type ValidationError struct {
-	Field   string
-	Message string
+	RespondOKWithSource(c, response, dataSource)
 }
 
-func (e *ValidationError) Error() string {
-	return e.Message
-}
+// compositeOHLCV resolves a pair symbol and loads candles of its VWAP,
+// returning the pair's token IDs with them
+func (h *OHLCVHandler) compositeOHLCV(c *gin.Context, symbol string, from, to timeutil.Seconds, interval string) ([]db.OHLCVData, int, int, error) {
+	baseID, quoteID, err := db.ResolvePairSymbol(c.Request.Context(), h.postgresDB, symbol)
+	if err != nil {
+		return nil, 0, 0, err
+	}
 
-// isValidInterval checks if the interval is supported
-func (h *OHLCVHandler) isValidInterval(interval string) bool {
-	validIntervals := map[string]bool{
-		"1m":  true,
-		"5m":  true,
-		"15m": true,
-		"1h":  true,
-		"4h":  true,
-		"1d":  true,
+	data, err := db.GetCompositeOHLCVData(h.clickhouseConn, baseID, quoteID, from, to, interval)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for i := range data {
+		data[i].Symbol = symbol
 	}
-	return validIntervals[interval]
+	return data, baseID, quoteID, nil
 }
 
 // getMaxTimeRange returns the maximum allowed time range for an interval (in seconds)
@@ -313,18 +263,13 @@ func (h *OHLCVHandler) symbolExists(symbol string) bool {
 // @Produce json
 // @Success 200 {object} models.APIResponse{data=[]string} "Success"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
-// @Router /ohlcv/symbols [get]
+// @Router /api/v1/ohlcv/symbols [get]
 func (h *OHLCVHandler) GetSupportedSymbols(c *gin.Context) {
 	// Get latest prices to extract supported symbols
 	prices, err := db.GetLatestPrices(h.clickhouseConn)
 	if err != nil {
-		h.logger.Error("Failed to get supported symbols", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:     "database_error",
-			Message:   "Failed to retrieve supported symbols",
-			Code:      http.StatusInternalServerError,
-			Timestamp: time.Now().Unix(),
-		})
+		requestLogger(c, h.logger).Error("Failed to get supported symbols", zap.Error(err))
+		RespondInternalError(c, "database_error", "Failed to retrieve supported symbols")
 		return
 	}
 
@@ -333,9 +278,5 @@ func (h *OHLCVHandler) GetSupportedSymbols(c *gin.Context) {
 		symbols = append(symbols, symbol)
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success:   true,
-		Data:      symbols,
-		Timestamp: time.Now().Unix(),
-	})
+	RespondOK(c, symbols)
 }
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OperationsHandler serves exchange uptime and API usage from the hourly
+// and daily rollups of the operational tables
+type OperationsHandler struct {
+	ops    *storage.OpsStorage
+	logger *zap.Logger
+}
+
+// NewOperationsHandler creates a new operations handler
+func NewOperationsHandler(ops *storage.OpsStorage, logger *zap.Logger) *OperationsHandler {
+	return &OperationsHandler{
+		ops:    ops,
+		logger: logger,
+	}
+}
+
+// GetExchangeUptime reports each exchange's poll uptime over a window
+// @Summary Exchange uptime
+// @Description Polls, successful polls, uptime and response times of each exchange over the last days, read from the hourly rollup of exchange_health, or the daily one for windows longer than 90 days. The window starts at the start of its first hour or day.
+// @Tags admin
+// @Produce json
+// @Param days query int false "Window in days (1-730)" default(30)
+// @Success 200 {object} models.APIResponse{data=[]models.ExchangeUptimeResponse} "Uptime per exchange"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/exchange-uptime [get]
+func (h *OperationsHandler) GetExchangeUptime(c *gin.Context) {
+	v := NewRequestValidator(c)
+	days := v.IntRange("days", 30, 1, 730)
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	uptimes, err := h.ops.GetExchangeUptime(c.Request.Context(), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load exchange uptime", zap.Error(err))
+		RespondServiceError(c, err, "Failed to retrieve exchange uptime")
+		return
+	}
+
+	resp := make([]models.ExchangeUptimeResponse, 0, len(uptimes))
+	for _, u := range uptimes {
+		var uptime float64
+		if u.Polls > 0 {
+			uptime = 100 * float64(u.SuccessfulPolls) / float64(u.Polls)
+		}
+		resp = append(resp, models.ExchangeUptimeResponse{
+			ExchangeID:      u.ExchangeID,
+			Polls:           u.Polls,
+			SuccessfulPolls: u.SuccessfulPolls,
+			UptimePercent:   uptime,
+			AvgResponseMs:   u.AvgResponseMs,
+			P95ResponseMs:   u.P95ResponseMs,
+			LastPollAt:      unixOrZero(u.LastPoll),
+		})
+	}
+	RespondOK(c, resp)
+}
+
+// GetAPIUsage reports requests per API route over a window
+// @Summary API usage
+// @Description Requests, client and server errors, durations and bytes sent per route and method over the last days, busiest first, read from the hourly rollup of api_requests, or the daily one for windows longer than 90 days. Requests no route matched are counted under the route "unmatched".
+// @Tags admin
+// @Produce json
+// @Param days query int false "Window in days (1-730)" default(7)
+// @Success 200 {object} models.APIResponse{data=[]models.RouteUsageResponse} "Usage per route"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/api-usage [get]
+func (h *OperationsHandler) GetAPIUsage(c *gin.Context) {
+	v := NewRequestValidator(c)
+	days := v.IntRange("days", 7, 1, 730)
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	usage, err := h.ops.GetAPIUsage(c.Request.Context(), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load API usage", zap.Error(err))
+		RespondServiceError(c, err, "Failed to retrieve API usage")
+		return
+	}
+
+	resp := make([]models.RouteUsageResponse, 0, len(usage))
+	for _, u := range usage {
+		resp = append(resp, models.RouteUsageResponse{
+			Route:         u.Route,
+			Method:        u.Method,
+			Requests:      u.Requests,
+			ClientErrors:  u.ClientErrors,
+			ServerErrors:  u.ServerErrors,
+			AvgDurationMs: u.AvgDurationMs,
+			P95DurationMs: u.P95DurationMs,
+			ResponseBytes: u.ResponseBytes,
+		})
+	}
+	RespondOK(c, resp)
+}
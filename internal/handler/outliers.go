@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/outlier"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ApplyOutlierSuggestionRequest is the body of applying a suggested fix to
+// an outlier. TokenID is the token to remap to and is required for remap.
+type ApplyOutlierSuggestionRequest struct {
+	Action     string `json:"action" binding:"required,oneof=remap disable_pair"`
+	TokenID    int    `json:"token_id" binding:"omitempty,min=1"`
+	ResolvedBy string `json:"resolved_by" binding:"required"`
+	Notes      string `json:"notes" binding:"max=500"`
+}
+
+// ApplyOutlierSuggestion applies a suggested fix and resolves the outlier
+// @Summary Apply outlier suggestion
+// @Description Apply one of an outlier's suggestions from GET /api/v1/admin/outliers and resolve it, in one transaction. remap maps the exchange's symbols for the outlier's base token to token_id as verified manual mappings, moves the exchange's pairs on that base to it and writes the audit log; the symbol resolver picks the change up on its next cache refresh or POST /api/v1/admin/resolver/refresh. disable_pair deactivates the outlier's pair on the exchange.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Outlier ID"
+// @Param request body ApplyOutlierSuggestionRequest true "Suggestion to apply"
+// @Success 200 {object} models.APIResponse "Applied"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Outlier, token, mapping or pair not found"
+// @Failure 409 {object} models.ErrorResponse "Outlier already resolved"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/outliers/{id}/apply [post]
+func (h *VerificationHandler) ApplyOutlierSuggestion(c *gin.Context) {
+	id, ok := idParam(c, "Invalid outlier ID")
+	if !ok {
+		return
+	}
+	var req ApplyOutlierSuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+	if req.Action == outlier.ActionRemap && req.TokenID == 0 {
+		RespondValidationErrors(c, []models.FieldError{{
+			Field:   "token_id",
+			Message: "token_id is required to remap",
+		}})
+		return
+	}
+
+	ctx := c.Request.Context()
+	notes := strings.TrimSpace(req.Notes)
+	var err error
+	if req.Action == outlier.ActionRemap {
+		err = db.RemapOutlier(ctx, h.db, id, req.TokenID, req.ResolvedBy, notes)
+	} else {
+		err = db.DisableOutlierPair(ctx, h.db, id, req.ResolvedBy, notes)
+	}
+	if err != nil {
+		h.respondOutlierError(c, err, "Failed to apply outlier suggestion")
+		return
+	}
+
+	data := gin.H{"id": id, "action": req.Action}
+	if req.Action == outlier.ActionRemap {
+		data["token_id"] = req.TokenID
+	}
+	RespondOKWithMessage(c, data, "Outlier suggestion applied successfully")
+}
+
+// respondOutlierError shows not-found and already-resolved errors to the
+// client and a generic message otherwise
+func (h *VerificationHandler) respondOutlierError(c *gin.Context, err error, message string) {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c, h.logger).Error(message, zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, message)
+		return
+	}
+	RespondError(c, status, code, err.Error())
+}
+
+func suggestionResponses(suggestions []outlier.Suggestion) []models.OutlierSuggestion {
+	out := make([]models.OutlierSuggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		r := models.OutlierSuggestion{
+			Action:      s.Action,
+			Description: s.Description,
+		}
+		if s.Action == outlier.ActionRemap {
+			price, deviation := s.CandidatePrice, s.Deviation
+			r.TokenID = s.TokenID
+			r.TokenSymbol = s.TokenSymbol
+			r.TokenName = s.TokenName
+			r.CandidatePrice = &price
+			r.Deviation = &deviation
+		}
+		out = append(out, r)
+	}
+	return out
+}
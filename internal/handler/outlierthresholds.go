@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/outlier"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OutlierThresholdHandler serves the per-pair outlier threshold overrides
+type OutlierThresholdHandler struct {
+	postgresDB *sql.DB
+	thresholds *outlier.Overrides
+	logger     *zap.Logger
+}
+
+// NewOutlierThresholdHandler creates a new outlier threshold handler.
+// thresholds is reloaded after every change so it applies at once in this
+// process.
+func NewOutlierThresholdHandler(postgresDB *sql.DB, thresholds *outlier.Overrides, logger *zap.Logger) *OutlierThresholdHandler {
+	return &OutlierThresholdHandler{
+		postgresDB: postgresDB,
+		thresholds: thresholds,
+		logger:     logger,
+	}
+}
+
+// OutlierThresholdRequest is the body of setting a pair's override. Fields
+// left out keep the default.
+type OutlierThresholdRequest struct {
+	MaxDeviation *float64 `json:"max_deviation" binding:"omitempty,min=0.001,max=10"`
+	MaxStdDevs   *float64 `json:"max_std_devs" binding:"omitempty,min=0,max=100"`
+	MinSamples   *int     `json:"min_samples" binding:"omitempty,min=2,max=100"`
+	Notes        string   `json:"notes" binding:"max=500"`
+}
+
+// ListOutlierThresholds returns the default thresholds and every override
+// @Summary List outlier thresholds
+// @Description The default outlier thresholds, from OUTLIER_MAX_DEVIATION, OUTLIER_MAX_STD_DEVS and OUTLIER_MIN_SAMPLES, and the per-pair overrides applied by VWAP outlier removal and the outlier detector
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.OutlierThresholdsResponse} "Thresholds"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/outlier-thresholds [get]
+func (h *OutlierThresholdHandler) ListOutlierThresholds(c *gin.Context) {
+	found, err := db.ListOutlierThresholds(c.Request.Context(), h.postgresDB)
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve outlier thresholds")
+		return
+	}
+
+	resp := models.OutlierThresholdsResponse{
+		Defaults:  thresholdValues(h.thresholds.Defaults()),
+		Overrides: make([]models.OutlierThresholdOverride, 0, len(found)),
+	}
+	for _, t := range found {
+		resp.Overrides = append(resp.Overrides, h.overrideResponse(t))
+	}
+	RespondOK(c, resp)
+}
+
+// SetOutlierThreshold creates or replaces the override of a pair
+// @Summary Set outlier threshold override
+// @Description Override the outlier thresholds of a token pair, e.g. a wider max_deviation for an illiquid token. Fields left out keep the default. Takes effect at once in this process and within OUTLIER_THRESHOLDS_REFRESH elsewhere.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param base path string true "Base token symbol"
+// @Param quote path string true "Quote token symbol"
+// @Param request body OutlierThresholdRequest true "Override"
+// @Success 200 {object} models.APIResponse{data=models.OutlierThresholdOverride} "Saved"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 422 {object} models.ErrorResponse "Validation failed or unknown symbols"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/outlier-thresholds/{base}/{quote} [put]
+func (h *OutlierThresholdHandler) SetOutlierThreshold(c *gin.Context) {
+	var req OutlierThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+	if req.MaxDeviation == nil && req.MaxStdDevs == nil && req.MinSamples == nil {
+		RespondValidationErrors(c, []models.FieldError{{
+			Field:   "max_deviation",
+			Message: "Set at least one of max_deviation, max_std_devs and min_samples",
+		}})
+		return
+	}
+	base, quote, ok := h.pairParams(c)
+	if !ok {
+		return
+	}
+
+	saved, err := db.UpsertOutlierThreshold(c.Request.Context(), h.postgresDB, db.OutlierThreshold{
+		BaseTokenID:  base,
+		QuoteTokenID: quote,
+		MaxDeviation: req.MaxDeviation,
+		MaxStdDevs:   req.MaxStdDevs,
+		MinSamples:   req.MinSamples,
+		Notes:        strings.TrimSpace(req.Notes),
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to save outlier threshold")
+		return
+	}
+	h.reload(c)
+	RespondOKWithMessage(c, h.overrideResponse(saved), "Outlier threshold saved successfully")
+}
+
+// DeleteOutlierThreshold removes the override of a pair
+// @Summary Delete outlier threshold override
+// @Description Remove a pair's override so it uses the default thresholds again
+// @Tags admin
+// @Produce json
+// @Param base path string true "Base token symbol"
+// @Param quote path string true "Quote token symbol"
+// @Success 200 {object} models.APIResponse "Deleted"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 404 {object} models.ErrorResponse "Pair has no override"
+// @Failure 422 {object} models.ErrorResponse "Unknown symbols"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/outlier-thresholds/{base}/{quote} [delete]
+func (h *OutlierThresholdHandler) DeleteOutlierThreshold(c *gin.Context) {
+	base, quote, ok := h.pairParams(c)
+	if !ok {
+		return
+	}
+	if err := db.DeleteOutlierThreshold(c.Request.Context(), h.postgresDB, base, quote); err != nil {
+		h.respondError(c, err, "Failed to delete outlier threshold")
+		return
+	}
+	h.reload(c)
+	RespondOKWithMessage(c, gin.H{"base_token_id": base, "quote_token_id": quote},
+		"Outlier threshold deleted successfully")
+}
+
+// pairParams resolves the :base and :quote symbols to token IDs
+func (h *OutlierThresholdHandler) pairParams(c *gin.Context) (base, quote int, ok bool) {
+	v := NewRequestValidator(c)
+	baseSymbol := v.Symbol("base")
+	quoteSymbol := v.Symbol("quote")
+	if !v.Valid() {
+		v.Respond()
+		return 0, 0, false
+	}
+	ids, ok := resolveSymbols(c, h.postgresDB, h.logger, []string{baseSymbol, quoteSymbol})
+	if !ok {
+		return 0, 0, false
+	}
+	return ids[0], ids[1], true
+}
+
+// reload applies a change to this process's thresholds. Failing to is only
+// logged: the change is saved and the periodic reload picks it up.
+func (h *OutlierThresholdHandler) reload(c *gin.Context) {
+	if err := h.thresholds.Reload(c.Request.Context()); err != nil {
+		requestLogger(c, h.logger).Warn("Failed to reload outlier thresholds", zap.Error(err))
+	}
+}
+
+// respondError shows not-found errors to the client and a generic message
+// otherwise
+func (h *OutlierThresholdHandler) respondError(c *gin.Context, err error, message string) {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c, h.logger).Error(message, zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, message)
+		return
+	}
+	RespondError(c, status, code, err.Error())
+}
+
+func (h *OutlierThresholdHandler) overrideResponse(t db.OutlierThreshold) models.OutlierThresholdOverride {
+	effective := outlier.Override{
+		MaxDeviation: t.MaxDeviation,
+		MaxStdDevs:   t.MaxStdDevs,
+		MinSamples:   t.MinSamples,
+	}.Apply(h.thresholds.Defaults())
+	return models.OutlierThresholdOverride{
+		BaseSymbol:   t.BaseSymbol,
+		QuoteSymbol:  t.QuoteSymbol,
+		BaseTokenID:  t.BaseTokenID,
+		QuoteTokenID: t.QuoteTokenID,
+		MaxDeviation: t.MaxDeviation,
+		MaxStdDevs:   t.MaxStdDevs,
+		MinSamples:   t.MinSamples,
+		Effective:    thresholdValues(effective),
+		Notes:        t.Notes,
+		UpdatedAt:    t.UpdatedAt,
+	}
+}
+
+func thresholdValues(t outlier.Thresholds) models.OutlierThresholdValues {
+	return models.OutlierThresholdValues{
+		MaxDeviation: t.MaxDeviation,
+		MaxStdDevs:   t.MaxStdDevs,
+		MinSamples:   t.MinSamples,
+	}
+}
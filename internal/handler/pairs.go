@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// PairHandler serves trading pair metadata
+type PairHandler struct {
+	postgresDB *sql.DB
+	logger     *zap.Logger
+}
+
+// NewPairHandler creates a new pair handler
+func NewPairHandler(postgresDB *sql.DB, logger *zap.Logger) *PairHandler {
+	return &PairHandler{
+		postgresDB: postgresDB,
+		logger:     logger,
+	}
+}
+
+// GetPair returns a pair's order rules and fees
+// @Summary Get trading pair
+// @Description Get a pair as exchange {exchange} lists it, with its tick and step sizes, minimum order quantity and notional, and taker and maker fees. Fees come from the pair's listing where the exchange gives them and from the exchange's base tier otherwise. Metadata is refreshed by tokenctl pairs -set common or all.
+// @Tags pairs
+// @Produce json
+// @Param exchange path string true "Exchange ID (e.g., binance)"
+// @Param symbol path string true "Pair symbol as the exchange writes it (e.g., BTCUSDT)"
+// @Success 200 {object} models.APIResponse{data=models.PairResponse} "Trading pair"
+// @Failure 404 {object} models.ErrorResponse "Pair not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/pairs/{exchange}/{symbol} [get]
+func (h *PairHandler) GetPair(c *gin.Context) {
+	exchangeID := strings.ToLower(strings.TrimSpace(c.Param("exchange")))
+	symbol := strings.TrimSpace(c.Param("symbol"))
+
+	p, err := db.GetTradingPair(c.Request.Context(), h.postgresDB, exchangeID, symbol)
+	if err != nil {
+		status, code := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			requestLogger(c, h.logger).Error("Failed to load trading pair", zap.Error(err))
+			RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve trading pair")
+			return
+		}
+		RespondError(c, status, code, err.Error())
+		return
+	}
+	RespondOK(c, pairResponse(p))
+}
+
+func pairResponse(p db.TradingPair) models.PairResponse {
+	r := models.PairResponse{
+		ID:                p.ID,
+		Exchange:          p.ExchangeID,
+		Symbol:            p.Symbol,
+		Base:              p.BaseSymbol,
+		Quote:             p.QuoteSymbol,
+		BaseTokenID:       p.BaseTokenID,
+		QuoteTokenID:      p.QuoteTokenID,
+		InstrumentType:    p.InstrumentType,
+		IsActive:          p.IsActive,
+		MappingMethod:     p.MappingMethod,
+		NeedsVerification: p.NeedsVerification,
+		TickSize:          nullDecimal(p.TickSize),
+		StepSize:          nullDecimal(p.StepSize),
+		MinQuantity:       nullDecimal(p.MinQuantity),
+		MinNotional:       nullDecimal(p.MinNotional),
+		TakerFee:          nullDecimal(p.TakerFee),
+		MakerFee:          nullDecimal(p.MakerFee),
+	}
+	r.PricePrecision = precisionOf(r.TickSize)
+	r.QuantityPrecision = precisionOf(r.StepSize)
+	if !p.MetadataUpdatedAt.IsZero() {
+		at := p.MetadataUpdatedAt
+		r.MetadataUpdatedAt = &at
+	}
+	return r
+}
+
+// nullDecimal drops the trailing zeros PostgreSQL pads numerics with
+func nullDecimal(d decimal.NullDecimal) *decimal.Decimal {
+	if !d.Valid {
+		return nil
+	}
+	v, err := decimal.NewFromString(d.Decimal.String())
+	if err != nil {
+		v = d.Decimal
+	}
+	return &v
+}
+
+// precisionOf returns the decimal places of an increment, 2 for 0.01 and 0
+// for 1 or 10
+func precisionOf(increment *decimal.Decimal) *int {
+	if increment == nil || !increment.IsPositive() {
+		return nil
+	}
+	places := 0
+	if exp := increment.Exponent(); exp < 0 {
+		places = int(-exp)
+	}
+	return &places
+}
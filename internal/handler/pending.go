@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ResolvePendingMappingRequest is the body of a pending mapping resolution
+type ResolvePendingMappingRequest struct {
+	TokenID    int    `json:"token_id" binding:"required,min=1"`
+	ResolvedBy string `json:"resolved_by" binding:"required"`
+	Notes      string `json:"notes"`
+}
+
+// IgnorePendingMappingRequest is the body of ignoring a pending mapping
+type IgnorePendingMappingRequest struct {
+	ResolvedBy string `json:"resolved_by" binding:"required"`
+	Notes      string `json:"notes"`
+}
+
+// GetPendingMappings pages through the unmapped-token queue
+// @Summary List pending mappings
+// @Description Exchange symbols the mapper could not map to a token, in queue order, with candidate tokens scored by matching symbol, name and slug. Page with after_id set to the previous page's next_after_id.
+// @Tags admin
+// @Produce json
+// @Param status query string false "pending, resolved, ignored or all" default(pending)
+// @Param exchange query string false "Only symbols on this exchange"
+// @Param after_id query int false "Only entries after this ID" default(0)
+// @Param limit query int false "Maximum entries (1-500)" default(100)
+// @Success 200 {object} models.APIResponse{data=models.PendingMappingListResponse} "Pending mappings"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings/pending [get]
+func (h *VerificationHandler) GetPendingMappings(c *gin.Context) {
+	v := NewRequestValidator(c)
+	status := strings.ToLower(c.DefaultQuery("status", db.PendingStatusPending))
+	switch status {
+	case db.PendingStatusPending, db.PendingStatusResolved, db.PendingStatusIgnored:
+	case "all":
+		status = ""
+	default:
+		v.Add("status", "Must be pending, resolved, ignored or all")
+	}
+	afterID := v.IntRange("after_id", 0, 0, 1<<31-1)
+	limit := v.IntRange("limit", 100, 1, 500)
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	found, err := db.ListPendingMappings(c.Request.Context(), h.db, db.PendingFilter{
+		Status:     status,
+		ExchangeID: strings.ToLower(strings.TrimSpace(c.Query("exchange"))),
+		AfterID:    afterID,
+		Limit:      limit,
+	})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load pending mappings", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve pending mappings")
+		return
+	}
+
+	resp := models.PendingMappingListResponse{Mappings: make([]models.PendingMappingResponse, 0, len(found))}
+	for _, m := range found {
+		resp.Mappings = append(resp.Mappings, pendingMappingResponse(m))
+	}
+	if len(found) == limit {
+		next := found[len(found)-1].ID
+		resp.NextAfterID = &next
+	}
+	RespondOK(c, resp)
+}
+
+// ResolvePendingMapping maps a queued exchange symbol to a token
+// @Summary Resolve pending mapping
+// @Description Map the exchange symbol of pending mapping {id} to token_id as a verified manual mapping, record it in the mapping audit log and close the entry.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Pending mapping ID"
+// @Param request body ResolvePendingMappingRequest true "Token and audit details"
+// @Success 200 {object} models.APIResponse "Resolved"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Pending mapping or token not found"
+// @Failure 409 {object} models.ErrorResponse "Already resolved or ignored"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings/pending/{id}/resolve [post]
+func (h *VerificationHandler) ResolvePendingMapping(c *gin.Context) {
+	id, ok := idParam(c, "Invalid pending mapping ID")
+	if !ok {
+		return
+	}
+	var req ResolvePendingMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	if err := db.ResolvePendingMapping(c.Request.Context(), h.db, id, req.TokenID, req.ResolvedBy, req.Notes); err != nil {
+		h.respondPendingError(c, err, "Failed to resolve pending mapping")
+		return
+	}
+	RespondOKWithMessage(c, gin.H{"id": id, "token_id": req.TokenID}, "Pending mapping resolved successfully")
+}
+
+// IgnorePendingMapping closes a queued exchange symbol without mapping it
+// @Summary Ignore pending mapping
+// @Description Close pending mapping {id} without mapping it. Later mapper runs that still find the symbol unmapped leave it ignored.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Pending mapping ID"
+// @Param request body IgnorePendingMappingRequest true "Audit details"
+// @Success 200 {object} models.APIResponse "Ignored"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Pending mapping not found"
+// @Failure 409 {object} models.ErrorResponse "Already resolved or ignored"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings/pending/{id}/ignore [post]
+func (h *VerificationHandler) IgnorePendingMapping(c *gin.Context) {
+	id, ok := idParam(c, "Invalid pending mapping ID")
+	if !ok {
+		return
+	}
+	var req IgnorePendingMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	if err := db.IgnorePendingMapping(c.Request.Context(), h.db, id, req.ResolvedBy, req.Notes); err != nil {
+		h.respondPendingError(c, err, "Failed to ignore pending mapping")
+		return
+	}
+	RespondOKWithMessage(c, gin.H{"id": id}, "Pending mapping ignored successfully")
+}
+
+// respondPendingError shows not-found and already-closed errors to the client
+// and a generic message otherwise
+func (h *VerificationHandler) respondPendingError(c *gin.Context, err error, message string) {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c, h.logger).Error(message, zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, message)
+		return
+	}
+	RespondError(c, status, code, err.Error())
+}
+
+func pendingMappingResponse(m db.PendingMapping) models.PendingMappingResponse {
+	r := models.PendingMappingResponse{
+		ID:             m.ID,
+		ExchangeID:     m.ExchangeID,
+		ExchangeSymbol: m.ExchangeSymbol,
+		Slug:           m.Slug,
+		Name:           m.Name,
+		MarketPair:     m.MarketPair,
+		Candidates:     make([]models.PendingMappingCandidate, 0, len(m.Candidates)),
+		Status:         m.Status,
+		ResolvedBy:     m.ResolvedBy,
+		Notes:          m.Notes,
+		SeenCount:      m.SeenCount,
+		FirstSeenAt:    m.FirstSeenAt,
+		LastSeenAt:     m.LastSeenAt,
+	}
+	for _, cand := range m.Candidates {
+		r.Candidates = append(r.Candidates, models.PendingMappingCandidate(cand))
+	}
+	if m.ResolvedTokenID != 0 {
+		id := m.ResolvedTokenID
+		r.ResolvedTokenID = &id
+	}
+	if !m.ResolvedAt.IsZero() {
+		at := m.ResolvedAt
+		r.ResolvedAt = &at
+	}
+	return r
+}
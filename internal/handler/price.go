@@ -0,0 +1,426 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/calculator"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// snapshotCurrencies are the currencies a price snapshot is given in. USD is
+// priced from the first of db.USDQuoteSymbols with a VWAP.
+var snapshotCurrencies = []string{"USD", "BTC", "ETH"}
+
+// PriceHandler serves token prices derived from the latest VWAPs
+type PriceHandler struct {
+	postgresDB  *sql.DB
+	vwapStorage *storage.VWAPStorage
+	logger      *zap.Logger
+}
+
+// NewPriceHandler creates a new price handler
+func NewPriceHandler(postgresDB *sql.DB, vwapStorage *storage.VWAPStorage, logger *zap.Logger) *PriceHandler {
+	return &PriceHandler{
+		postgresDB:  postgresDB,
+		vwapStorage: vwapStorage,
+		logger:      logger,
+	}
+}
+
+// GetPrice returns a token's latest price in USD, BTC and ETH
+// @Summary Get price snapshot
+// @Description Get the latest price of token {base} in USD, BTC and ETH in one call. A price is the VWAP of the pair against that currency where one is recorded and a composite, and a cross rate through USD otherwise; a USD price with no USD pair goes through BTC or ETH. Each price names the pairs it comes from. Currencies no price can be derived in are left out.
+// @Tags prices
+// @Produce json
+// @Param base path string true "Base token symbol (e.g., ETH)"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} models.APIResponse{data=models.PriceSnapshotResponse} "Price snapshot"
+// @Success 304 "Not modified since the ETag/Last-Modified given"
+// @Failure 404 {object} models.ErrorResponse "Token or price not found"
+// @Failure 422 {object} models.ErrorResponse "Malformed symbol"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/price/{base} [get]
+func (h *PriceHandler) GetPrice(c *gin.Context) {
+	v := NewRequestValidator(c)
+	base := v.Symbol("base")
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	p, err := h.newPricer(ctx, append([]string{base}, snapshotCurrencies...))
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to resolve price tokens", zap.Error(err), zap.String("symbol", base))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to resolve token")
+		return
+	}
+	if _, ok := p.tokens[base]; !ok {
+		RespondNotFound(c, "token_not_found", "Token not found")
+		return
+	}
+
+	snapshot, err := p.snapshot(base)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to price token", zap.Error(err), zap.String("symbol", base))
+		RespondServiceError(c, err, "Failed to retrieve price")
+		return
+	}
+	if len(snapshot.Prices) == 0 {
+		RespondNotFound(c, "price_not_found", "No VWAP recorded for this token")
+		return
+	}
+
+	if CheckNotModified(c, time.Unix(snapshot.Timestamp, 0)) {
+		return
+	}
+	RespondOK(c, snapshot)
+}
+
+// BatchPriceRequest is the body of a batch price request
+type BatchPriceRequest struct {
+	Symbols []string `json:"symbols" binding:"required,min=1,max=500"`
+	Quote   string   `json:"quote"` // defaults to the USD quotes
+}
+
+// BatchPrices returns the latest prices of many tokens
+// @Summary Get prices in bulk
+// @Description Get the latest VWAP of up to 500 tokens in one request. Without quote each token is priced against its highest-volume USD pair (USDT, USD or USDC) with a VWAP in the last 24h. Results come back in request order with duplicates dropped. A symbol that is malformed, unknown or has no recent VWAP gets an error in its result and does not fail the batch.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param request body BatchPriceRequest true "Symbols to price"
+// @Success 200 {object} models.APIResponse{data=models.BatchPriceResponse} "Prices"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Quote token not found"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/prices/batch [post]
+func (h *PriceHandler) BatchPrices(c *gin.Context) {
+	var req BatchPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+	quote := strings.ToUpper(strings.TrimSpace(req.Quote))
+	if quote != "" && !symbolPattern.MatchString(quote) {
+		RespondUnprocessable(c, ErrCodeValidationFailed, "quote must be a token symbol")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// Distinct symbols in request order; malformed ones are answered as such
+	symbols := make([]string, 0, len(req.Symbols))
+	seen := make(map[string]bool, len(req.Symbols))
+	for _, raw := range req.Symbols {
+		symbol := strings.ToUpper(strings.TrimSpace(raw))
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	valid := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if symbolPattern.MatchString(symbol) {
+			valid = append(valid, symbol)
+		}
+	}
+	ids, err := db.GetTokenIDsBySymbol(ctx, h.postgresDB, valid)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to resolve batch tokens", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to resolve tokens")
+		return
+	}
+
+	quoteSymbols, err := quoteTokens(ctx, h.postgresDB, quote)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to resolve quote tokens", zap.Error(err), zap.String("quote", quote))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to resolve quote token")
+		return
+	}
+	if len(quoteSymbols) == 0 {
+		RespondNotFound(c, "token_not_found", "Quote token not found")
+		return
+	}
+	quoteIDs := make([]int, 0, len(quoteSymbols))
+	for id := range quoteSymbols {
+		quoteIDs = append(quoteIDs, id)
+	}
+
+	latest, err := h.vwapStorage.GetLatestVWAPByQuote(ctx, quoteIDs)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load batch prices", zap.Error(err))
+		RespondServiceError(c, err, "Failed to retrieve prices")
+		return
+	}
+
+	resp := models.BatchPriceResponse{Prices: make([]models.BatchPrice, 0, len(symbols))}
+	for _, symbol := range symbols {
+		result := models.BatchPrice{Symbol: symbol}
+		id, known := ids[symbol]
+		summary := latest[id]
+		switch {
+		case !symbolPattern.MatchString(symbol):
+			result.Error = &models.BatchPriceError{Code: "invalid_symbol", Message: "Symbol must be 1-32 letters, digits, '.', '_' or '-'"}
+		case !known:
+			result.Error = &models.BatchPriceError{Code: "token_not_found", Message: "Token not found"}
+		case summary == nil:
+			result.TokenID = id
+			result.Error = &models.BatchPriceError{Code: "price_not_found", Message: "No VWAP recorded in the last 24h"}
+		default:
+			result = summaryPrice(symbol, id, summary, quoteSymbols)
+		}
+		if result.Error != nil {
+			resp.Failed++
+		} else {
+			resp.Found++
+		}
+		resp.Prices = append(resp.Prices, result)
+	}
+	RespondOK(c, resp)
+}
+
+// summaryPrice is the batch result of a token priced by its latest VWAP
+func summaryPrice(symbol string, tokenID int, summary *storage.VWAPSummary, quoteSymbols map[int]string) models.BatchPrice {
+	change := summary.Change24h()
+	return models.BatchPrice{
+		Symbol:         symbol,
+		TokenID:        tokenID,
+		Quote:          quoteSymbols[summary.QuoteTokenID],
+		Price:          &summary.Price,
+		Change24h:      &change,
+		Volume:         &summary.Volume,
+		ExchangeCount:  summary.ExchangeCount,
+		LiquidityScore: summary.LiquidityScore,
+		Indicative:     summary.Indicative,
+		Timestamp:      summary.LastUpdate.Unix(),
+	}
+}
+
+// quoteTokens maps the IDs of the quote tokens to price against to their
+// symbols: quote when given, the USD quotes otherwise
+func quoteTokens(ctx context.Context, postgresDB *sql.DB, quote string) (map[int]string, error) {
+	symbols := db.USDQuoteSymbols
+	if quote != "" {
+		symbols = []string{quote}
+	}
+	ids, err := db.GetTokenIDsBySymbol(ctx, postgresDB, symbols)
+	if err != nil {
+		return nil, err
+	}
+	bySymbol := make(map[int]string, len(ids))
+	for symbol, id := range ids {
+		bySymbol[id] = symbol
+	}
+	return bySymbol, nil
+}
+
+// pricer derives prices from the latest VWAPs, reading each pair at most once
+type pricer struct {
+	ctx         context.Context
+	vwapStorage *storage.VWAPStorage
+	tokens      map[string]int // symbol to token ID, the USD quotes included
+	latest      map[calculator.PairKey]*calculator.VWAPResult
+}
+
+// priceLeg is the latest VWAP of one pair
+type priceLeg struct {
+	pair   string
+	result *calculator.VWAPResult
+}
+
+func (h *PriceHandler) newPricer(ctx context.Context, symbols []string) (*pricer, error) {
+	ids, err := db.GetTokenIDsBySymbol(ctx, h.postgresDB, append(symbols, db.USDQuoteSymbols...))
+	if err != nil {
+		return nil, err
+	}
+	return &pricer{
+		ctx:         ctx,
+		vwapStorage: h.vwapStorage,
+		tokens:      ids,
+		latest:      make(map[calculator.PairKey]*calculator.VWAPResult),
+	}, nil
+}
+
+// snapshot prices base in each of snapshotCurrencies
+func (p *pricer) snapshot(base string) (models.PriceSnapshotResponse, error) {
+	resp := models.PriceSnapshotResponse{
+		Symbol:  base,
+		TokenID: p.tokens[base],
+		Prices:  make(map[string]models.PriceQuote, len(snapshotCurrencies)),
+	}
+	for _, currency := range snapshotCurrencies {
+		q, err := p.price(base, currency)
+		if err != nil {
+			return resp, err
+		}
+		if q == nil {
+			continue
+		}
+		resp.Prices[currency] = *q
+		if q.Timestamp > resp.Timestamp {
+			resp.Timestamp = q.Timestamp
+		}
+	}
+	return resp, nil
+}
+
+// price returns base's price in currency, or nil when there is none
+func (p *pricer) price(base, currency string) (*models.PriceQuote, error) {
+	if currency == "USD" {
+		return p.usdPrice(base)
+	}
+	if base == currency {
+		return &models.PriceQuote{Price: decimal.NewFromInt(1), Method: "identity", Pairs: []string{}, Exchanges: []string{}}, nil
+	}
+
+	direct, err := p.leg(base, currency)
+	if err != nil {
+		return nil, err
+	}
+	if direct != nil && !direct.result.Indicative {
+		return directQuote(direct), nil
+	}
+
+	// Cross through USD: base/USD divided by currency/USD
+	baseUSD, err := p.usd(base)
+	if err != nil {
+		return nil, err
+	}
+	currencyUSD, err := p.usd(currency)
+	if err != nil {
+		return nil, err
+	}
+	if baseUSD != nil && currencyUSD != nil && currencyUSD.result.VWAPPrice.IsPositive() {
+		return crossQuote(baseUSD.result.VWAPPrice.Div(currencyUSD.result.VWAPPrice), baseUSD, currencyUSD), nil
+	}
+
+	// An indicative direct VWAP beats no price at all
+	if direct != nil {
+		return directQuote(direct), nil
+	}
+	return nil, nil
+}
+
+// usdPrice returns base's USD price, through BTC or ETH when base has no USD
+// pair
+func (p *pricer) usdPrice(base string) (*models.PriceQuote, error) {
+	direct, err := p.usd(base)
+	if err != nil {
+		return nil, err
+	}
+	if direct != nil {
+		return directQuote(direct), nil
+	}
+
+	for _, via := range snapshotCurrencies[1:] {
+		if via == base {
+			continue
+		}
+		toVia, err := p.leg(base, via)
+		if err != nil {
+			return nil, err
+		}
+		if toVia == nil {
+			continue
+		}
+		viaUSD, err := p.usd(via)
+		if err != nil {
+			return nil, err
+		}
+		if viaUSD != nil {
+			return crossQuote(toVia.result.VWAPPrice.Mul(viaUSD.result.VWAPPrice), toVia, viaUSD), nil
+		}
+	}
+	return nil, nil
+}
+
+// usd returns the VWAP of base against the first USD quote that has one
+func (p *pricer) usd(base string) (*priceLeg, error) {
+	for _, quote := range db.USDQuoteSymbols {
+		if quote == base {
+			continue
+		}
+		leg, err := p.leg(base, quote)
+		if err != nil || leg != nil {
+			return leg, err
+		}
+	}
+	return nil, nil
+}
+
+// leg returns the latest VWAP of base/quote, or nil when either token is
+// unknown or the pair has none
+func (p *pricer) leg(base, quote string) (*priceLeg, error) {
+	baseID, ok := p.tokens[base]
+	if !ok {
+		return nil, nil
+	}
+	quoteID, ok := p.tokens[quote]
+	if !ok {
+		return nil, nil
+	}
+
+	key := calculator.PairKey{BaseTokenID: baseID, QuoteTokenID: quoteID}
+	result, seen := p.latest[key]
+	if !seen {
+		var err error
+		result, err = p.vwapStorage.GetLatestVWAP(p.ctx, baseID, quoteID)
+		if errors.Is(err, db.ErrNoData) {
+			result, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.latest[key] = result
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return &priceLeg{pair: base + "/" + quote, result: result}, nil
+}
+
+func directQuote(leg *priceLeg) *models.PriceQuote {
+	return &models.PriceQuote{
+		Price:      leg.result.VWAPPrice,
+		Method:     "direct",
+		Pairs:      []string{leg.pair},
+		Exchanges:  exchangesOf(leg.result),
+		Indicative: leg.result.Indicative,
+		Timestamp:  leg.result.Timestamp.Unix(),
+	}
+}
+
+func crossQuote(price decimal.Decimal, first, second *priceLeg) *models.PriceQuote {
+	ts := first.result.Timestamp
+	if second.result.Timestamp.Before(ts) {
+		ts = second.result.Timestamp
+	}
+	return &models.PriceQuote{
+		Price:      price,
+		Method:     "cross",
+		Pairs:      []string{first.pair, second.pair},
+		Exchanges:  exchangesOf(first.result),
+		Indicative: first.result.Indicative || second.result.Indicative,
+		Timestamp:  ts.Unix(),
+	}
+}
+
+func exchangesOf(r *calculator.VWAPResult) []string {
+	if r.ContributingExchanges == nil {
+		return []string{}
+	}
+	return r.ContributingExchanges
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitConfig configures per-client token buckets. Requests carrying a
+// known API key in the X-API-Key header are limited per key; all others are
+// limited per client IP.
+type RateLimitConfig struct {
+	RPS         float64
+	Burst       int
+	APIKeyRPS   float64
+	APIKeyBurst int
+	APIKeys     []string
+	IdleTTL     time.Duration // buckets unused for this long are evicted
+}
+
+// DefaultRateLimitConfig returns limits suitable for anonymous public traffic
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		RPS:         10,
+		Burst:       20,
+		APIKeyRPS:   50,
+		APIKeyBurst: 100,
+		IdleTTL:     10 * time.Minute,
+	}
+}
+
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	rps      float64
+	capacity float64
+}
+
+// RateLimiter is an in-memory token-bucket limiter keyed by client
+type RateLimiter struct {
+	cfg     RateLimitConfig
+	apiKeys map[string]bool
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewRateLimiter creates a new rate limiter
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	keys := make(map[string]bool, len(cfg.APIKeys))
+	for _, k := range cfg.APIKeys {
+		if k != "" {
+			keys[k] = true
+		}
+	}
+	return &RateLimiter{
+		cfg:     cfg,
+		apiKeys: keys,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Middleware rejects clients that exceed their bucket with 429 and a
+// Retry-After header
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, rps, burst := "ip:"+c.ClientIP(), l.cfg.RPS, l.cfg.Burst
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" && l.apiKeys[apiKey] {
+			key, rps, burst = "key:"+apiKey, l.cfg.APIKeyRPS, l.cfg.APIKeyBurst
+		}
+
+		ok, remaining, retryAfter := l.allow(key, rps, burst)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			RespondError(c, http.StatusTooManyRequests, "rate_limited", "Too many requests, slow down")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// allow takes a token from the client's bucket, refilling it for the time
+// elapsed since the last request
+func (l *RateLimiter) allow(key string, rps float64, burst int) (bool, int, time.Duration) {
+	if rps <= 0 || burst <= 0 {
+		return true, burst, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: float64(burst), last: now, rps: rps, capacity: float64(burst)}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rps)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / b.rps * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// RunCleanup evicts idle buckets until ctx is cancelled
+func (l *RateLimiter) RunCleanup(ctx context.Context) {
+	ttl := l.cfg.IdleTTL
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	ticker := time.NewTicker(ttl / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cutoff := l.now().Add(-ttl)
+			l.mu.Lock()
+			for key, b := range l.buckets {
+				if b.last.Before(cutoff) {
+					delete(l.buckets, key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"database/sql"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/reconcile"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReconciliationHandler serves the nightly mapping reconciliation reports
+type ReconciliationHandler struct {
+	postgresDB *sql.DB
+	reconciler *reconcile.Service
+	logger     *zap.Logger
+}
+
+// NewReconciliationHandler creates a new reconciliation handler
+func NewReconciliationHandler(postgresDB *sql.DB, reconciler *reconcile.Service, logger *zap.Logger) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		postgresDB: postgresDB,
+		reconciler: reconciler,
+		logger:     logger,
+	}
+}
+
+// ListReconciliationReports lists recent reconciliation reports
+// @Summary List reconciliation reports
+// @Description The most recent mapping reconciliation reports, newest first. A report is made every night at RECONCILE_AT and lists the pairs exchanges quoted in the last day that have an unmapped base or quote, active mappings no exchange has quoted for RECONCILE_STALE_AFTER, and exchange symbols mapped more than once under different letter cases. Each list holds at most 1000 entries.
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Number of reports (1-30)" default(7)
+// @Success 200 {object} models.APIResponse{data=[]models.ReconciliationReport} "Reports"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/reconciliation-reports [get]
+func (h *ReconciliationHandler) ListReconciliationReports(c *gin.Context) {
+	v := NewRequestValidator(c)
+	limit := v.IntRange("limit", 7, 1, 30)
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	reports, err := db.ListReconciliationReports(c.Request.Context(), h.postgresDB, limit)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load reconciliation reports", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve reconciliation reports")
+		return
+	}
+
+	resp := make([]models.ReconciliationReport, 0, len(reports))
+	for _, r := range reports {
+		resp = append(resp, reconciliationResponse(r))
+	}
+	RespondOK(c, resp)
+}
+
+// RunReconciliation makes a reconciliation report now
+// @Summary Run reconciliation
+// @Description Make a mapping reconciliation report now instead of waiting for the nightly run. The report is stored and, when RECONCILE_WEBHOOK_URL is set, delivered like the nightly one; a failed delivery is shown in delivery_error.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.ReconciliationReport} "Report"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/reconciliation-reports [post]
+func (h *ReconciliationHandler) RunReconciliation(c *gin.Context) {
+	report, err := h.reconciler.Reconcile(c.Request.Context())
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to reconcile mappings", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to make reconciliation report")
+		return
+	}
+	RespondOKWithMessage(c, reconciliationResponse(report), "Reconciliation report created successfully")
+}
+
+func reconciliationResponse(r db.ReconciliationReport) models.ReconciliationReport {
+	resp := models.ReconciliationReport{
+		ID:             r.ID,
+		UnmappedCount:  r.UnmappedCount,
+		StaleCount:     r.StaleCount,
+		DuplicateCount: r.DuplicateCount,
+		Unmapped:       make([]models.UnmappedSymbol, 0, len(r.Findings.Unmapped)),
+		Stale:          make([]models.StaleMapping, 0, len(r.Findings.Stale)),
+		Duplicates:     make([]models.DuplicateMapping, 0, len(r.Findings.Duplicates)),
+		DeliveredAt:    r.DeliveredAt,
+		DeliveryError:  r.DeliveryError,
+		CreatedAt:      r.CreatedAt,
+	}
+	for _, u := range r.Findings.Unmapped {
+		resp.Unmapped = append(resp.Unmapped, models.UnmappedSymbol(u))
+	}
+	for _, s := range r.Findings.Stale {
+		resp.Stale = append(resp.Stale, models.StaleMapping(s))
+	}
+	for _, d := range r.Findings.Duplicates {
+		resp.Duplicates = append(resp.Duplicates, models.DuplicateMapping(d))
+	}
+	return resp
+}
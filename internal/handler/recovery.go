@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Recovery is middleware that turns a panicking handler into a 500, logging
+// the panic through logger, which records the stack of error entries, rather
+// than to gin's default writer. http.ErrAbortHandler is passed on to the
+// server, which drops the connection: handlers streaming a response use it
+// so a failure part way shows as a broken response, not a short one.
+func Recovery(logger *zap.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+		requestLogger(c, logger).Error("Recovered from handler panic",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("panic", fmt.Sprint(recovered)))
+		RespondInternalError(c, ErrCodeInternal, "Internal server error")
+		c.Abort()
+	})
+}
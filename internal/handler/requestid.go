@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/ashmitsharp/trading/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequestID is middleware that gives every request an ID: the X-Request-ID
+// header when the client or a proxy sent a valid one, a new one otherwise.
+// The ID is echoed in the response header, included in error responses and
+// carried in the request context for logging.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// requestLogger returns logger with the ID of the request being served
+func requestLogger(c *gin.Context, logger *zap.Logger) *zap.Logger {
+	return requestid.Logger(c.Request.Context(), logger)
+}
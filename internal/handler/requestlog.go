@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute is the route logged for requests no route matched, so
+// arbitrary paths do not each become a route in the usage rollups
+const unmatchedRoute = "unmatched"
+
+// LogRequests is middleware recording every request, by route rather than
+// path, in the request log
+func LogRequests(log *storage.RequestLog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		log.Record(storage.RequestLogEntry{
+			Timestamp:     start,
+			Method:        c.Request.Method,
+			Route:         route,
+			Status:        c.Writer.Status(),
+			Duration:      time.Since(start),
+			ResponseBytes: c.Writer.Size(),
+			APIKey:        APIKeyOwner(c),
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/symbol"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ResolverHandler serves the symbol resolver admin endpoints
+type ResolverHandler struct {
+	resolver *symbol.Resolver
+	logger   *zap.Logger
+}
+
+// NewResolverHandler creates a new resolver handler
+func NewResolverHandler(resolver *symbol.Resolver, logger *zap.Logger) *ResolverHandler {
+	return &ResolverHandler{
+		resolver: resolver,
+		logger:   logger,
+	}
+}
+
+// GetStats reports the resolver cache counters and sizes
+// @Summary Resolver cache stats
+// @Description Cache hits, remembered misses, database lookups and cache sizes of this process's symbol resolver
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.ResolverStatsResponse} "Cache stats"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Router /api/v1/admin/resolver [get]
+func (h *ResolverHandler) GetStats(c *gin.Context) {
+	RespondOK(c, resolverStats(h.resolver))
+}
+
+// Refresh reloads the resolver cache from PostgreSQL
+// @Summary Refresh resolver cache
+// @Description Reload symbol and pair mappings now instead of waiting for the 5 minute background refresh, and forget remembered misses. Only the process serving the request is refreshed.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.ResolverStatsResponse} "Cache stats after the refresh"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/resolver/refresh [post]
+func (h *ResolverHandler) Refresh(c *gin.Context) {
+	if err := h.resolver.RefreshCache(c.Request.Context()); err != nil {
+		requestLogger(c, h.logger).Error("Failed to refresh resolver cache", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to refresh resolver cache")
+		return
+	}
+	requestLogger(c, h.logger).Info("Resolver cache refreshed on request")
+	RespondOK(c, resolverStats(h.resolver))
+}
+
+func resolverStats(resolver *symbol.Resolver) models.ResolverStatsResponse {
+	stats := resolver.Stats()
+	resp := models.ResolverStatsResponse{
+		Hits:            stats.Hits,
+		NegativeHits:    stats.NegativeHits,
+		Misses:          stats.Misses,
+		Symbols:         stats.Symbols,
+		Pairs:           stats.Pairs,
+		NegativeEntries: stats.NegativeEntries,
+		PendingWrites:   stats.PendingWrites,
+		DroppedWrites:   stats.DroppedWrites,
+		Timestamp:       time.Now().Unix(),
+	}
+	if total := stats.Hits + stats.NegativeHits + stats.Misses; total > 0 {
+		resp.HitRatio = float64(stats.Hits+stats.NegativeHits) / float64(total)
+	}
+	if !stats.LastRefresh.IsZero() {
+		resp.LastRefresh = stats.LastRefresh.Unix()
+	}
+	return resp
+}
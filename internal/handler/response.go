@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes returned in models.ErrorResponse.Error. Handlers may use more
+// specific codes (e.g. "invalid_symbol"), but shared situations use these.
+const (
+	ErrCodeBadRequest       = "bad_request"
+	ErrCodeInvalidParameter = "invalid_parameter"
+	ErrCodeValidationFailed = "validation_failed"
+	ErrCodeNotFound         = "not_found"
+	ErrCodeDatabase         = "database_error"
+	ErrCodeInternal         = "internal_error"
+)
+
+// RespondOK writes a successful models.APIResponse envelope
+func RespondOK(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success:   true,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// RespondOKWithMessage writes a successful envelope with an informational message
+func RespondOKWithMessage(c *gin.Context, data interface{}, message string) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success:   true,
+		Data:      data,
+		Message:   message,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// RespondOKWithSource writes a successful envelope saying where the data
+// comes from. A nil source is left out.
+func RespondOKWithSource(c *gin.Context, data interface{}, source *models.DataSource) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success:   true,
+		Data:      data,
+		Source:    source,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// RespondError writes a models.ErrorResponse with the given status and code
+func RespondError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, models.ErrorResponse{
+		Error:     code,
+		Message:   message,
+		Code:      status,
+		RequestID: requestid.FromContext(c.Request.Context()),
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// RespondBadRequest reports a request that could not be parsed (400)
+func RespondBadRequest(c *gin.Context, code, message string) {
+	RespondError(c, http.StatusBadRequest, code, message)
+}
+
+// RespondNotFound reports a missing resource (404)
+func RespondNotFound(c *gin.Context, code, message string) {
+	RespondError(c, http.StatusNotFound, code, message)
+}
+
+// RespondUnprocessable reports a well-formed request with invalid values (422)
+func RespondUnprocessable(c *gin.Context, code, message string) {
+	RespondError(c, http.StatusUnprocessableEntity, code, message)
+}
+
+// RespondInternalError reports a server-side failure (500)
+func RespondInternalError(c *gin.Context, code, message string) {
+	RespondError(c, http.StatusInternalServerError, code, message)
+}
+
+// RespondBindingError maps a gin binding error to the right status: malformed
+// or missing JSON is a 400, a body that parses but fails validation is a 422
+func RespondBindingError(c *gin.Context, err error) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.Is(err, io.EOF) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		RespondBadRequest(c, ErrCodeBadRequest, "Request body is not valid JSON")
+		return
+	}
+	if details, ok := bindingFieldErrors(err); ok {
+		RespondValidationErrors(c, details)
+		return
+	}
+	RespondUnprocessable(c, ErrCodeValidationFailed, err.Error())
+}
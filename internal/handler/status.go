@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/ingester"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/polling"
+	"github.com/ashmitsharp/trading/internal/scheduler"
+	"github.com/ashmitsharp/trading/internal/supervisor"
+	"github.com/ashmitsharp/trading/internal/symbol"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// StatusHandler serves the admin status document of one process
+type StatusHandler struct {
+	serviceMode string
+	pollStatus  *polling.Status
+	ingester    *ingester.BinanceIngester
+	scheduler   *scheduler.Scheduler
+	resolver    *symbol.Resolver
+	tasks       *supervisor.Group
+	logger      *zap.Logger
+}
+
+// NewStatusHandler creates a new status handler. pollStatus, ingester and
+// scheduler are nil when that component does not run in this process.
+func NewStatusHandler(serviceMode string, pollStatus *polling.Status, ingester *ingester.BinanceIngester, scheduler *scheduler.Scheduler,
+	resolver *symbol.Resolver, tasks *supervisor.Group, logger *zap.Logger) *StatusHandler {
+	return &StatusHandler{
+		serviceMode: serviceMode,
+		pollStatus:  pollStatus,
+		ingester:    ingester,
+		scheduler:   scheduler,
+		resolver:    resolver,
+		tasks:       tasks,
+		logger:      logger,
+	}
+}
+
+// GetStatus reports the poller, ingester, scheduler, resolver and supervised
+// jobs running in this process
+// @Summary Process status
+// @Description Ticker poller freshness and tickers dropped by each exchange's symbol patterns or as leveraged tokens and derivatives, WebSocket ingester connections and trade queue, scheduled jobs, resolver cache counters and supervised background jobs of the process serving the request. Components not running in it are omitted.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.AdminStatusResponse} "Process status"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Router /api/v1/admin/status [get]
+func (h *StatusHandler) GetStatus(c *gin.Context) {
+	resp := models.AdminStatusResponse{
+		ServiceMode: h.serviceMode,
+		Resolver:    resolverStats(h.resolver),
+		Tasks:       taskStatuses(h.tasks),
+		Timestamp:   time.Now().Unix(),
+	}
+	if h.pollStatus != nil {
+		resp.Poller = pollerStatus(h.pollStatus.Snapshot())
+	}
+	if h.ingester != nil {
+		resp.Ingester = ingesterStatus(h.ingester)
+	}
+	if h.scheduler != nil {
+		resp.Scheduler = schedulerStatus(h.scheduler.Jobs())
+	}
+	if resp.Tasks == nil {
+		resp.Tasks = []models.TaskStatus{}
+	}
+	RespondOK(c, resp)
+}
+
+func pollerStatus(snap polling.StatusSnapshot) *models.PollerStatusResponse {
+	filtered := make([]models.FilteredSymbolsResponse, 0, len(snap.Filtered))
+	for _, f := range snap.Filtered {
+		filtered = append(filtered, models.FilteredSymbolsResponse{
+			Exchange:        f.ExchangeID,
+			LastPoll:        f.LastPoll.Filtered,
+			Total:           f.Filtered,
+			NonSpotLastPoll: f.LastPoll.NonSpot,
+			NonSpotTotal:    f.NonSpot,
+		})
+	}
+	return &models.PollerStatusResponse{
+		StartedAt:          unixOrZero(snap.StartedAt),
+		LastPollAt:         unixOrZero(snap.LastPollAt),
+		LastSuccessAt:      unixOrZero(snap.LastSuccessAt),
+		LastError:          snap.LastError,
+		ExchangesPolled:    snap.ExchangesPolled,
+		ExchangesSucceeded: snap.ExchangesSucceeded,
+		FilteredSymbols:    filtered,
+	}
+}
+
+func ingesterStatus(bi *ingester.BinanceIngester) *models.IngesterStatusResponse {
+	pipeline := bi.PipelineStats()
+	resp := &models.IngesterStatusResponse{
+		Running:           bi.IsRunning(),
+		QueueDepth:        pipeline.QueueDepth,
+		QueueCapacity:     pipeline.QueueCapacity,
+		TradesWritten:     pipeline.Written,
+		TradesDropped:     pipeline.Dropped,
+		TradesParked:      pipeline.Parked,
+		TradesFailed:      pipeline.Failed,
+		FailedBatches:     pipeline.FailedBatches,
+		DuplicatesSkipped: bi.DuplicatesSkipped(),
+		LastFlushAt:       unixOrZero(pipeline.LastFlush),
+		LastFlushMs:       float64(pipeline.LastFlushDuration.Microseconds()) / 1000,
+	}
+	for _, shard := range bi.ShardStats() {
+		resp.Connections++
+		if shard.Connected {
+			resp.Connected++
+		}
+		resp.Messages += shard.Messages
+		resp.Shards = append(resp.Shards, models.IngesterShardResponse{
+			Index:             shard.Index,
+			Symbols:           shard.Symbols,
+			Connected:         shard.Connected,
+			GivenUp:           shard.GivenUp,
+			ConnectedAt:       unixOrZero(shard.ConnectedAt),
+			ReconnectAttempts: shard.ReconnectAttempts,
+			LastError:         shard.LastError,
+			Messages:          shard.Messages,
+		})
+	}
+	return resp
+}
+
+func schedulerStatus(jobs []scheduler.JobStatus) *models.SchedulerStatusResponse {
+	resp := &models.SchedulerStatusResponse{Jobs: make([]models.SchedulerJobResponse, 0, len(jobs))}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, models.SchedulerJobResponse{
+			LastRun: unixOrZero(job.Prev),
+			NextRun: unixOrZero(job.Next),
+		})
+	}
+	return resp
+}
+
+// unixOrZero is t in Unix seconds, or 0 for the zero time
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/stream"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxStreamSymbols is the most symbols one stream may filter on
+const maxStreamSymbols = 100
+
+// StreamHandler serves Server-Sent Events streams
+type StreamHandler struct {
+	postgresDB *sql.DB
+	hub        *stream.TickerHub
+	heartbeat  time.Duration
+	logger     *zap.Logger
+}
+
+// NewStreamHandler creates a new stream handler that writes a heartbeat
+// comment after every heartbeat without events
+func NewStreamHandler(postgresDB *sql.DB, hub *stream.TickerHub, heartbeat time.Duration, logger *zap.Logger) *StreamHandler {
+	return &StreamHandler{
+		postgresDB: postgresDB,
+		hub:        hub,
+		heartbeat:  heartbeat,
+		logger:     logger,
+	}
+}
+
+// StreamTicker streams USD VWAP updates as Server-Sent Events
+// @Summary Stream ticker updates
+// @Description Server-Sent Events stream of USD VWAP updates, a lighter alternative to WebSocket for browsers behind proxies. Each "ticker" event carries a models.TickerEvent as JSON. The last known price of each token is sent on connect, then every new VWAP as it is stored. A ": heartbeat" comment is written whenever the stream has been idle for STREAM_HEARTBEAT.
+// @Tags stream
+// @Produce text/event-stream
+// @Param symbols query string false "Comma-separated token symbols to stream (at most 100); every token when omitted"
+// @Success 200 {object} models.TickerEvent "Stream of ticker events"
+// @Failure 404 {object} models.ErrorResponse "Unknown symbols"
+// @Failure 422 {object} models.ErrorResponse "Malformed symbols"
+// @Failure 503 {object} models.ErrorResponse "Too many open streams"
+// @Router /api/v1/stream/ticker [get]
+func (h *StreamHandler) StreamTicker(c *gin.Context) {
+	var symbols []string
+	v := NewRequestValidator(c)
+	if raw := c.Query("symbols"); raw != "" {
+		seen := make(map[string]bool)
+		for _, s := range strings.Split(raw, ",") {
+			s = strings.ToUpper(strings.TrimSpace(s))
+			if s == "" || seen[s] {
+				continue
+			}
+			seen[s] = true
+			if !symbolPattern.MatchString(s) {
+				v.Add("symbols", "Invalid symbol: "+s)
+				continue
+			}
+			symbols = append(symbols, s)
+		}
+		if len(seen) > maxStreamSymbols {
+			v.Add("symbols", fmt.Sprintf("At most %d symbols may be streamed", maxStreamSymbols))
+		}
+	}
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	var tokenIDs []int
+	if len(symbols) > 0 {
+		ids, err := db.GetTokenIDsBySymbol(c.Request.Context(), h.postgresDB, symbols)
+		if err != nil {
+			requestLogger(c, h.logger).Error("Failed to resolve stream symbols", zap.Error(err))
+			RespondInternalError(c, ErrCodeDatabase, "Failed to resolve tokens")
+			return
+		}
+		var unknown []string
+		for _, s := range symbols {
+			id, ok := ids[s]
+			if !ok {
+				unknown = append(unknown, s)
+				continue
+			}
+			tokenIDs = append(tokenIDs, id)
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			RespondNotFound(c, "token_not_found", "Unknown symbols: "+strings.Join(unknown, ", "))
+			return
+		}
+	}
+
+	sub, err := h.hub.Subscribe(tokenIDs)
+	if errors.Is(err, stream.ErrTooManySubscribers) {
+		RespondError(c, http.StatusServiceUnavailable, "too_many_streams", "Too many open streams, retry later")
+		return
+	}
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to open ticker stream", zap.Error(err))
+		RespondInternalError(c, ErrCodeInternal, "Failed to open stream")
+		return
+	}
+	defer sub.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // nginx would otherwise buffer the stream
+	c.Status(http.StatusOK)
+	io.WriteString(c.Writer, "retry: 3000\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTimer(h.heartbeat)
+	defer heartbeat.Stop()
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case tick, open := <-sub.C:
+			if !open {
+				return
+			}
+			c.SSEvent("ticker", tick.Event())
+		}
+		c.Writer.Flush()
+		if !heartbeat.Stop() {
+			select {
+			case <-heartbeat.C:
+			default:
+			}
+		}
+		heartbeat.Reset(h.heartbeat)
+	}
+}
@@ -1,242 +1,29 @@
-// package handler
-
-// import (
-// 	"database/sql"
-// 	"net/http"
-// 	"strings"
-// 	"time"
-
-// 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
-// 	"github.com/ashmitsharp/trading/internal/db"
-// 	"github.com/ashmitsharp/trading/internal/models"
-// 	"github.com/gin-gonic/gin"
-// 	"go.uber.org/zap"
-// )
-
-// type TickerHandler struct {
-// 	clickhouseConn driver.Conn
-// 	postgresDB     *sql.DB
-// 	logger         *zap.Logger
-// }
-
-// func NewTickerHandler(clickhouseConn driver.Conn, postgresDB *sql.DB, logger *zap.Logger) *TickerHandler {
-// 	return &TickerHandler{
-// 		clickhouseConn: clickhouseConn,
-// 		postgresDB:     postgresDB,
-// 		logger:         logger,
-// 	}
-// }
-
-// func (h *TickerHandler) GetTicker(c *gin.Context) {
-// 	prices, err := db.GetLatestPrices(h.clickhouseConn)
-// 	if err != nil {
-// 		h.logger.Error("Failed to get latest prices", zap.Error(err))
-// 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-// 			Error:     "database_error",
-// 			Message:   "Failed to retrieve ticker data",
-// 			Code:      http.StatusInternalServerError,
-// 			Timestamp: time.Now().Unix(),
-// 		})
-// 		return
-// 	}
-
-// 	tokens, err := db.GetAllTokens(h.postgresDB)
-// 	if err != nil {
-// 		h.logger.Error("Failed to get token metadata", zap.Error(err))
-// 	}
-
-// 	tokenMap := make(map[string]db.Token)
-// 	for _, token := range tokens {
-// 		tokenMap[token.Symbol] = token
-// 	}
-
-// 	var tickers []models.TickerResponse
-// 	for symbol, price := range prices {
-// 		ticker := models.TickerResponse{
-// 			Symbol:    symbol,
-// 			Price:     price.Price.InexactFloat64(),
-// 			Timestamp: price.Timestamp,
-// 		}
-
-// 		if token, exists := tokenMap[symbol]; exists {
-// 			ticker.Name = token.Name
-// 			ticker.Category = token.Category
-// 		}
-
-// 		stats, err := h.get24hStats(symbol)
-// 		if err == nil {
-// 			ticker.PriceChange24h = stats.PriceChange
-// 			ticker.PriceChangePercent24h = stats.PriceChangePercent
-// 			ticker.Volume24h = stats.Volume
-// 			ticker.High24h = stats.High
-// 			ticker.Low24h = stats.Low
-// 		}
-
-// 		tickers = append(tickers, ticker)
-// 	}
-
-// 	c.JSON(http.StatusOK, models.APIResponse{
-// 		Success:   true,
-// 		Data:      tickers,
-// 		Timestamp: time.Now().Unix(),
-// 	})
-// }
-
-// // GetTickerBySymbol returns the latest price for a specific symbol
-// func (h *TickerHandler) GetTickerBySymbol(c *gin.Context) {
-// 	symbol := strings.ToUpper(c.Param("symbol"))
-// 	if symbol == "" {
-// 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-// 			Error:     "invalid_symbol",
-// 			Message:   "Symbol parameter is required",
-// 			Code:      http.StatusBadRequest,
-// 			Timestamp: time.Now().Unix(),
-// 		})
-// 		return
-// 	}
-
-// 	// Get latest prices from ClickHouse
-// 	prices, err := db.GetLatestPrices(h.clickhouseConn)
-// 	if err != nil {
-// 		h.logger.Error("Failed to get latest prices", zap.Error(err))
-// 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-// 			Error:     "database_error",
-// 			Message:   "Failed to retrieve ticker data",
-// 			Code:      http.StatusInternalServerError,
-// 			Timestamp: time.Now().Unix(),
-// 		})
-// 		return
-// 	}
-
-// 	// Check if symbol exists
-// 	price, exists := prices[symbol]
-// 	if !exists {
-// 		c.JSON(http.StatusNotFound, models.ErrorResponse{
-// 			Error:     "symbol_not_found",
-// 			Message:   "Trading pair not found",
-// 			Code:      http.StatusNotFound,
-// 			Timestamp: time.Now().Unix(),
-// 		})
-// 		return
-// 	}
-
-// 	// Build ticker response
-// 	ticker := models.TickerResponse{
-// 		Symbol:    symbol,
-// 		Price:     price.Price.InexactFloat64(),
-// 		Timestamp: price.Timestamp,
-// 	}
-
-// 	// Get token metadata
-// 	token, err := db.GetTokenBySymbol(h.postgresDB, symbol)
-// 	if err == nil {
-// 		ticker.Name = token.Name
-// 		ticker.Category = token.Category
-// 	}
-
-// 	// Calculate 24h stats
-// 	stats, err := h.get24hStats(symbol)
-// 	if err == nil {
-// 		ticker.PriceChange24h = stats.PriceChange
-// 		ticker.PriceChangePercent24h = stats.PriceChangePercent
-// 		ticker.Volume24h = stats.Volume
-// 		ticker.High24h = stats.High
-// 		ticker.Low24h = stats.Low
-// 	}
-
-// 	c.JSON(http.StatusOK, models.APIResponse{
-// 		Success:   true,
-// 		Data:      ticker,
-// 		Timestamp: time.Now().Unix(),
-// 	})
-// }
-
-// type Stats struct {
-// 	PriceChange        float64
-// 	PriceChangePercent float64
-// 	Volume             float64
-// 	High               float64
-// 	Low                float64
-// }
-
-// // get24hStats calculates 24-hour statistics for a symbol
-// func (h *TickerHandler) get24hStats(symbol string) (*Stats, error) {
-// 	now := time.Now()
-// 	yesterday := now.Add(-24 * time.Hour)
-
-// 	// Get 24h data from ClickHouse
-// 	ohlcvData, err := db.GetOHLCVData(
-// 		h.clickhouseConn,
-// 		symbol,
-// 		yesterday.Unix()*1000, // Convert to milliseconds
-// 		now.Unix()*1000,
-// 		"1h", // 1-hour intervals for better granularity
-// 	)
-// 	if err != nil || len(ohlcvData) == 0 {
-// 		return nil, err
-// 	}
-
-// 	// Calculate stats from OHLCV data
-// 	var high, low, volume float64
-// 	var open, close float64
-
-// 	first := true
-// 	for _, data := range ohlcvData {
-// 		if first {
-// 			high = data.High.InexactFloat64()
-// 			low = data.Low.InexactFloat64()
-// 			open = data.Open.InexactFloat64()
-// 			first = false
-// 		}
-
-// 		if data.High.InexactFloat64() > high {
-// 			high = data.High.InexactFloat64()
-// 		}
-// 		if data.Low.InexactFloat64() < low {
-// 			low = data.Low.InexactFloat64()
-// 		}
-
-// 		volume += data.Volume.InexactFloat64()
-// 		close = data.Close.InexactFloat64() // Last close price
-// 	}
-
-// 	// Calculate price change and percentage
-// 	priceChange := close - open
-// 	priceChangePercent := 0.0
-// 	if open > 0 {
-// 		priceChangePercent = (priceChange / open) * 100
-// 	}
-
-// 	return &Stats{
-// 		PriceChange:        priceChange,
-// 		PriceChangePercent: priceChangePercent,
-// 		Volume:             volume,
-// 		High:               high,
-// 		Low:                low,
-// 	}, nil
-// }
-
 package handler
 
 import (
 	"database/sql"
-	"net/http"
-	"strings"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/ashmitsharp/trading/internal/db"
 	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/timeutil"
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+// TickerHandler serves the latest trade price of each symbol with its 24h
+// stats and token metadata
 type TickerHandler struct {
 	clickhouseConn driver.Conn
 	postgresDB     *sql.DB
 	logger         *zap.Logger
 }
 
+// NewTickerHandler creates a new ticker handler
 func NewTickerHandler(clickhouseConn driver.Conn, postgresDB *sql.DB, logger *zap.Logger) *TickerHandler {
 	if clickhouseConn == nil {
 		panic("clickhouseConn cannot be nil")
@@ -251,28 +38,44 @@ func NewTickerHandler(clickhouseConn driver.Conn, postgresDB *sql.DB, logger *za
 	}
 }
 
+// GetTicker returns the tickers of all symbols with a latest price
+// @Summary List tickers
+// @Description List the latest trade price of every symbol with its 24h change, volume, high and low and its token's name and category. The 24h fields are omitted for symbols without trades in the last 24 hours. The source object gives the venues the price came from and when it was last updated.
+// @Tags tickers
+// @Produce json
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} models.APIResponse{data=[]models.TickerResponse} "Tickers"
+// @Success 304 "Not modified since the ETag/Last-Modified given"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/tickers [get]
 func (h *TickerHandler) GetTicker(c *gin.Context) {
 	prices, err := db.GetLatestPrices(h.clickhouseConn)
 	if err != nil {
-		h.logger.Error("Failed to get latest prices", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:     "database_error",
-			Message:   "Failed to retrieve ticker data",
-			Code:      http.StatusInternalServerError,
-			Timestamp: time.Now().Unix(),
-		})
+		requestLogger(c, h.logger).Error("Failed to get latest prices", zap.Error(err))
+		RespondInternalError(c, "database_error", "Failed to retrieve ticker data")
 		return
 	}
 	if prices == nil {
-		h.logger.Error("GetLatestPrices returned nil map")
+		requestLogger(c, h.logger).Error("GetLatestPrices returned nil map")
 		prices = make(map[string]db.LatestPrice)
 	}
 
+	// Skip the token and 24h stats lookups when the client is already current
+	var latest timeutil.Millis
+	for _, price := range prices {
+		if price.Timestamp > latest {
+			latest = price.Timestamp
+		}
+	}
+	if CheckNotModified(c, latest.Time(), fmt.Sprint(len(prices))) {
+		return
+	}
+
 	tokenMap := make(map[string]db.Token)
 	if h.postgresDB != nil {
 		tokens, err := db.GetAllTokens(h.postgresDB)
 		if err != nil {
-			h.logger.Error("Failed to get token metadata", zap.Error(err))
+			requestLogger(c, h.logger).Error("Failed to get token metadata", zap.Error(err))
 		} else {
 			for _, token := range tokens {
 				tokenMap[token.Symbol] = token
@@ -284,8 +87,9 @@ func (h *TickerHandler) GetTicker(c *gin.Context) {
 	for symbol, price := range prices {
 		ticker := models.TickerResponse{
 			Symbol:    symbol,
-			Price:     price.Price.InexactFloat64(),
-			Timestamp: price.Timestamp,
+			Price:     price.Price,
+			Timestamp: int64(price.Timestamp),
+			Source:    tradeSource(price),
 		}
 		if token, exists := tokenMap[symbol]; exists {
 			ticker.Name = token.Name
@@ -293,69 +97,68 @@ func (h *TickerHandler) GetTicker(c *gin.Context) {
 		}
 		stats, err := h.get24hStats(symbol)
 		if err == nil && stats != nil {
-			ticker.PriceChange24h = stats.PriceChange
+			ticker.PriceChange24h = &stats.PriceChange
 			ticker.PriceChangePercent24h = stats.PriceChangePercent
-			ticker.Volume24h = stats.Volume
-			ticker.High24h = stats.High
-			ticker.Low24h = stats.Low
+			ticker.Volume24h = &stats.Volume
+			ticker.High24h = &stats.High
+			ticker.Low24h = &stats.Low
 		} else if err != nil {
-			h.logger.Debug("Failed to get 24h stats for symbol",
+			requestLogger(c, h.logger).Debug("Failed to get 24h stats for symbol",
 				zap.String("symbol", symbol),
 				zap.Error(err))
 		}
 		tickers = append(tickers, ticker)
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success:   true,
-		Data:      tickers,
-		Timestamp: time.Now().Unix(),
-	})
+	RespondOK(c, tickers)
 }
 
 // GetTickerBySymbol returns the latest price for a specific symbol
+// @Summary Get ticker
+// @Description Get the latest trade price of a trading pair with its 24h change, volume, high and low and its token's name and category
+// @Tags tickers
+// @Produce json
+// @Param symbol path string true "Trading pair symbol (e.g., BTCUSDT)"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} models.APIResponse{data=models.TickerResponse} "Ticker"
+// @Success 304 "Not modified since the ETag/Last-Modified given"
+// @Failure 404 {object} models.ErrorResponse "Trading pair not found"
+// @Failure 422 {object} models.ErrorResponse "Malformed symbol"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/tickers/{symbol} [get]
 func (h *TickerHandler) GetTickerBySymbol(c *gin.Context) {
-	symbol := strings.ToUpper(c.Param("symbol"))
-	if symbol == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:     "invalid_symbol",
-			Message:   "Symbol parameter is required",
-			Code:      http.StatusBadRequest,
-			Timestamp: time.Now().Unix(),
-		})
+	v := NewRequestValidator(c)
+	symbol := v.Symbol("symbol")
+	if !v.Valid() {
+		v.Respond()
 		return
 	}
 
 	// Get latest prices from ClickHouse
 	prices, err := db.GetLatestPrices(h.clickhouseConn)
 	if err != nil {
-		h.logger.Error("Failed to get latest prices", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:     "database_error",
-			Message:   "Failed to retrieve ticker data",
-			Code:      http.StatusInternalServerError,
-			Timestamp: time.Now().Unix(),
-		})
+		requestLogger(c, h.logger).Error("Failed to get latest prices", zap.Error(err))
+		RespondInternalError(c, "database_error", "Failed to retrieve ticker data")
 		return
 	}
 
 	// Check if symbol exists
 	price, exists := prices[symbol]
 	if !exists {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:     "symbol_not_found",
-			Message:   "Trading pair not found",
-			Code:      http.StatusNotFound,
-			Timestamp: time.Now().Unix(),
-		})
+		RespondNotFound(c, "symbol_not_found", "Trading pair not found")
+		return
+	}
+
+	if CheckNotModified(c, price.Timestamp.Time()) {
 		return
 	}
 
 	// Build ticker response
 	ticker := models.TickerResponse{
 		Symbol:    symbol,
-		Price:     price.Price.InexactFloat64(),
-		Timestamp: price.Timestamp,
+		Price:     price.Price,
+		Timestamp: int64(price.Timestamp),
+		Source:    tradeSource(price),
 	}
 
 	// Get token metadata with nil check
@@ -365,7 +168,7 @@ func (h *TickerHandler) GetTickerBySymbol(c *gin.Context) {
 			ticker.Name = token.Name
 			ticker.Category = token.Category
 		} else {
-			h.logger.Debug("Failed to get token metadata",
+			requestLogger(c, h.logger).Debug("Failed to get token metadata",
 				zap.String("symbol", symbol),
 				zap.Error(err))
 		}
@@ -374,30 +177,40 @@ func (h *TickerHandler) GetTickerBySymbol(c *gin.Context) {
 	// Calculate 24h stats with error handling
 	stats, err := h.get24hStats(symbol)
 	if err == nil && stats != nil {
-		ticker.PriceChange24h = stats.PriceChange
+		ticker.PriceChange24h = &stats.PriceChange
 		ticker.PriceChangePercent24h = stats.PriceChangePercent
-		ticker.Volume24h = stats.Volume
-		ticker.High24h = stats.High
-		ticker.Low24h = stats.Low
+		ticker.Volume24h = &stats.Volume
+		ticker.High24h = &stats.High
+		ticker.Low24h = &stats.Low
 	} else if err != nil {
-		h.logger.Debug("Failed to get 24h stats for symbol",
+		requestLogger(c, h.logger).Debug("Failed to get 24h stats for symbol",
 			zap.String("symbol", symbol),
 			zap.Error(err))
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success:   true,
-		Data:      ticker,
-		Timestamp: time.Now().Unix(),
-	})
+	RespondOK(c, ticker)
+}
+
+// tradeSource describes a ticker priced from the latest trade
+func tradeSource(price db.LatestPrice) *models.DataSource {
+	source := &models.DataSource{Type: models.SourceTrades, Venues: price.Venues}
+	if source.Venues == nil {
+		source.Venues = []string{}
+	}
+	if price.Timestamp > 0 {
+		updated := price.Timestamp.Time()
+		source.LastUpdated = &updated
+	}
+	return source
 }
 
+// Stats are the 24h statistics of a symbol
 type Stats struct {
-	PriceChange        float64
+	PriceChange        decimal.Decimal
 	PriceChangePercent float64
-	Volume             float64
-	High               float64
-	Low                float64
+	Volume             decimal.Decimal
+	High               decimal.Decimal
+	Low                decimal.Decimal
 }
 
 // get24hStats calculates 24-hour statistics for a symbol
@@ -409,43 +222,46 @@ func (h *TickerHandler) get24hStats(symbol string) (*Stats, error) {
 	ohlcvData, err := db.GetOHLCVData(
 		h.clickhouseConn,
 		symbol,
-		yesterday.Unix()*1000, // Convert to milliseconds
-		now.Unix()*1000,
+		timeutil.SecondsOf(yesterday),
+		timeutil.SecondsOf(now),
 		"1h", // 1-hour intervals for better granularity
 	)
-	if err != nil || len(ohlcvData) == 0 {
+	if errors.Is(err, db.ErrNoData) {
+		return nil, nil
+	}
+	if err != nil {
 		return nil, err
 	}
 
 	// Calculate stats from OHLCV data
-	var high, low, volume float64
-	var open, close float64
+	var high, low, volume decimal.Decimal
+	var open, close decimal.Decimal
 
 	first := true
 	for _, data := range ohlcvData {
 		if first {
-			high = data.High.InexactFloat64()
-			low = data.Low.InexactFloat64()
-			open = data.Open.InexactFloat64()
+			high = data.High
+			low = data.Low
+			open = data.Open
 			first = false
 		}
 
-		if data.High.InexactFloat64() > high {
-			high = data.High.InexactFloat64()
+		if data.High.GreaterThan(high) {
+			high = data.High
 		}
-		if data.Low.InexactFloat64() < low {
-			low = data.Low.InexactFloat64()
+		if data.Low.LessThan(low) {
+			low = data.Low
 		}
 
-		volume += data.Volume.InexactFloat64()
-		close = data.Close.InexactFloat64() // Last close price
+		volume = volume.Add(data.Volume)
+		close = data.Close // Last close price
 	}
 
 	// Calculate price change and percentage
-	priceChange := close - open
+	priceChange := close.Sub(open)
 	priceChangePercent := 0.0
-	if open > 0 {
-		priceChangePercent = (priceChange / open) * 100
+	if open.IsPositive() {
+		priceChangePercent = priceChange.Div(open).Mul(decimal.NewFromInt(100)).InexactFloat64()
 	}
 
 	return &Stats{
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+)
+
+func TestTradeSource(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		price db.LatestPrice
+		want  string
+	}{
+		{"traded", db.LatestPrice{Timestamp: timeutil.MillisOf(at), Venues: []string{"binance", "okx"}},
+			`{"type":"trades","venues":["binance","okx"],"last_updated":"2024-03-01T12:00:00Z"}`},
+		// Clients always get a venues array, even when no exchange is known
+		{"no trades", db.LatestPrice{}, `{"type":"trades","venues":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tradeSource(tt.price))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("source = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
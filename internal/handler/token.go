@@ -0,0 +1,589 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/requestid"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// TokenHandler handles token detail endpoints
+type TokenHandler struct {
+	postgresDB   *sql.DB
+	vwapStorage  *storage.VWAPStorage
+	priceStorage *storage.PriceStorage
+	logger       *zap.Logger
+}
+
+// NewTokenHandler creates a new token handler
+func NewTokenHandler(postgresDB *sql.DB, vwapStorage *storage.VWAPStorage, priceStorage *storage.PriceStorage, logger *zap.Logger) *TokenHandler {
+	return &TokenHandler{
+		postgresDB:   postgresDB,
+		vwapStorage:  vwapStorage,
+		priceStorage: priceStorage,
+		logger:       logger,
+	}
+}
+
+// ListTokens lists the top active tokens
+// @Summary List tokens
+// @Description List active tokens. sort=market_cap orders by market cap computed from our own VWAP and circulating supply; sort=rank (default) uses the imported market cap rank. category keeps the tokens in a category or tag, by its slug from /api/v1/categories.
+// @Tags tokens
+// @Produce json
+// @Param sort query string false "Sort order" Enums(rank, market_cap) default(rank)
+// @Param category query string false "Category or tag slug, e.g. defi"
+// @Param limit query int false "Maximum number of tokens" default(100) minimum(1) maximum(500)
+// @Param offset query int false "Number of tokens to skip" default(0) minimum(0)
+// @Success 200 {object} models.APIResponse{data=[]models.TokenResponse} "Tokens"
+// @Failure 422 {object} models.ErrorResponse "Invalid sort or pagination parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/tokens [get]
+func (h *TokenHandler) ListTokens(c *gin.Context) {
+	v := NewRequestValidator(c)
+	limit, offset := v.Pagination(100, 500)
+	category := db.CategorySlug(c.Query("category"))
+
+	// Both orderings return the same columns so the scan below is shared
+	const inCategory = `
+		AND ($3 = '' OR EXISTS (
+			SELECT 1 FROM token_categories tc
+			JOIN categories cat ON cat.id = tc.category_id
+			WHERE tc.token_id = tokens.id AND cat.slug = $3
+		))`
+	var query string
+	switch sortBy := c.DefaultQuery("sort", "rank"); sortBy {
+	case "rank":
+		query = `
+			SELECT id, symbol, name, current_price, market_cap, market_cap_rank
+			FROM tokens
+			WHERE is_active = true` + inCategory + `
+			ORDER BY market_cap_rank ASC NULLS LAST
+			LIMIT $1 OFFSET $2
+		`
+	case "market_cap":
+		query = `
+			SELECT id, symbol, name, computed_price, computed_market_cap, computed_rank
+			FROM tokens
+			WHERE is_active = true` + inCategory + `
+			ORDER BY computed_rank ASC NULLS LAST, id
+			LIMIT $1 OFFSET $2
+		`
+	default:
+		v.Add("sort", "Sort must be one of: rank, market_cap")
+	}
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	rows, err := h.postgresDB.Query(query, limit, offset, category)
+	if err != nil {
+		RespondInternalError(c, ErrCodeDatabase, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	tokens := []models.TokenResponse{}
+	for rows.Next() {
+		var token models.TokenResponse
+		var price decimal.NullDecimal
+		var marketCap sql.NullFloat64
+		var rank sql.NullInt64
+
+		if err := rows.Scan(&token.ID, &token.Symbol, &token.Name, &price, &marketCap, &rank); err != nil {
+			continue
+		}
+
+		if price.Valid {
+			token.Price = &price.Decimal
+		}
+		if marketCap.Valid {
+			token.MarketCap = &marketCap.Float64
+		}
+		if rank.Valid {
+			token.Rank = &rank.Int64
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	ids := make([]int, len(tokens))
+	for i, token := range tokens {
+		ids[i] = token.ID
+	}
+	categories, err := db.GetTokenCategories(c.Request.Context(), h.postgresDB, ids)
+	if err != nil {
+		RespondInternalError(c, ErrCodeDatabase, err.Error())
+		return
+	}
+	for i := range tokens {
+		tokens[i].Categories = categories[tokens[i].ID]
+		if tokens[i].Categories == nil {
+			tokens[i].Categories = []string{}
+		}
+	}
+
+	RespondOK(c, tokens)
+}
+
+// GetToken returns a single token
+// @Summary Get token
+// @Description Get a token by its numeric ID
+// @Tags tokens
+// @Produce json
+// @Param id path int true "Token ID"
+// @Success 200 {object} models.APIResponse{data=models.TokenResponse} "Token"
+// @Failure 400 {object} models.ErrorResponse "Invalid token ID"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/tokens/{id} [get]
+func (h *TokenHandler) GetToken(c *gin.Context) {
+	tokenID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		RespondBadRequest(c, ErrCodeInvalidParameter, "Invalid token ID")
+		return
+	}
+
+	var token models.TokenResponse
+	var price decimal.NullDecimal
+
+	query := `
+		SELECT id, symbol, name, current_price
+		FROM tokens
+		WHERE id = $1
+	`
+
+	err = h.postgresDB.QueryRow(query, tokenID).Scan(&token.ID, &token.Symbol, &token.Name, &price)
+	if err == sql.ErrNoRows {
+		RespondNotFound(c, "token_not_found", "Token not found")
+		return
+	}
+	if err != nil {
+		RespondInternalError(c, ErrCodeDatabase, err.Error())
+		return
+	}
+
+	if price.Valid {
+		token.Price = &price.Decimal
+	}
+	categories, err := db.GetTokenCategories(c.Request.Context(), h.postgresDB, []int{token.ID})
+	if err != nil {
+		RespondInternalError(c, ErrCodeDatabase, err.Error())
+		return
+	}
+	token.Categories = categories[token.ID]
+	if token.Categories == nil {
+		token.Categories = []string{}
+	}
+
+	RespondOK(c, token)
+}
+
+// tokenMetadata is the shape of tokens.metadata written by tokenctl seed
+type tokenMetadata struct {
+	URLs      map[string][]string `json:"urls"`
+	Contracts []struct {
+		ContractAddress string   `json:"contract_address"`
+		Platform        string   `json:"platform"`
+		RPCURLs         []string `json:"rpc_urls"`
+	} `json:"contracts"`
+}
+
+// GetTokenFull returns token metadata, contracts, markets and latest VWAP
+// @Summary Get full token detail
+// @Description Combine token metadata (URLs, contracts), the exchange pairs it trades on and the latest USD VWAP
+// @Tags tokens
+// @Produce json
+// @Param id path string true "Token symbol (e.g., BTC) or numeric ID"
+// @Success 200 {object} models.APIResponse{data=models.TokenDetailResponse} "Token detail"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 422 {object} models.ErrorResponse "Malformed symbol"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/tokens/{id}/full [get]
+func (h *TokenHandler) GetTokenFull(c *gin.Context) {
+	token, ok := h.tokenFromParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var err error
+	if token.Markets, err = h.loadMarkets(ctx, token.ID); err != nil {
+		requestLogger(c, h.logger).Error("Failed to load token markets", zap.Error(err), zap.Int("token_id", token.ID))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve token markets")
+		return
+	}
+
+	// A missing price should not hide the rest of the detail
+	if token.VWAP, err = h.latestUSDVWAP(ctx, token); err != nil {
+		requestLogger(c, h.logger).Warn("Failed to load token VWAP", zap.Error(err), zap.Int("token_id", token.ID))
+	}
+
+	RespondOK(c, token)
+}
+
+// GetTokenCoverage reports which exchanges list a token, with the last price
+// and time each pair was seen by the poller
+// @Summary Get token exchange coverage
+// @Description List exchanges and pair symbols for a token from trading_pairs, with last price and last seen time from price_tickers
+// @Tags tokens
+// @Produce json
+// @Param id path string true "Token ID or symbol"
+// @Success 200 {object} models.APIResponse{data=models.TokenCoverageResponse} "Coverage report"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 422 {object} models.ErrorResponse "Malformed token ID"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/tokens/{id}/coverage [get]
+func (h *TokenHandler) GetTokenCoverage(c *gin.Context) {
+	token, ok := h.tokenFromParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	markets, err := h.loadMarkets(ctx, token.ID)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load token markets", zap.Error(err), zap.Int("token_id", token.ID))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve token markets")
+		return
+	}
+
+	snapshots, err := h.priceStorage.GetLatestPairsForBase(ctx, token.ID)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load pair snapshots", zap.Error(err), zap.Int("token_id", token.ID))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve latest prices")
+		return
+	}
+
+	RespondOK(c, buildCoverage(token, markets, snapshots))
+}
+
+// GetSupplyHistory returns daily supply snapshots for a token
+// @Summary Get token supply history
+// @Description Daily circulating, total and max supply snapshots for dilution analysis, oldest first
+// @Tags tokens
+// @Produce json
+// @Param id path string true "Token ID or symbol"
+// @Param days query int false "Number of days of history" default(90) minimum(1) maximum(3650)
+// @Success 200 {object} models.APIResponse{data=models.SupplyHistoryResponse} "Supply history"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 422 {object} models.ErrorResponse "Malformed token ID or days"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/tokens/{id}/supply-history [get]
+func (h *TokenHandler) GetSupplyHistory(c *gin.Context) {
+	v := NewRequestValidator(c)
+	days := v.IntRange("days", 90, 1, 3650)
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	token, ok := h.tokenFromParam(c)
+	if !ok {
+		return
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days+1).Truncate(24 * time.Hour)
+	history, err := db.GetSupplyHistory(c.Request.Context(), h.postgresDB, token.ID, since)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load supply history", zap.Error(err), zap.Int("token_id", token.ID))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve supply history")
+		return
+	}
+
+	RespondOK(c, buildSupplyHistory(token, days, history))
+}
+
+// ListCategories lists the token taxonomy
+// @Summary List token categories
+// @Description Categories (e.g. defi, layer-1) and tags of the token taxonomy with how many active tokens each has. Their slugs filter /api/v1/tokens?category=.
+// @Tags tokens
+// @Produce json
+// @Param kind query string false "Only categories or only tags" Enums(category, tag)
+// @Success 200 {object} models.APIResponse{data=[]models.CategoryResponse} "Categories"
+// @Failure 422 {object} models.ErrorResponse "Invalid kind"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/categories [get]
+func (h *TokenHandler) ListCategories(c *gin.Context) {
+	v := NewRequestValidator(c)
+	kind := c.Query("kind")
+	if kind != "" && kind != db.CategoryKindCategory && kind != db.CategoryKindTag {
+		v.Add("kind", "Kind must be one of: category, tag")
+	}
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	categories, err := db.ListCategories(c.Request.Context(), h.postgresDB, kind)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load categories", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve categories")
+		return
+	}
+
+	resp := make([]models.CategoryResponse, 0, len(categories))
+	for _, cat := range categories {
+		resp = append(resp, models.CategoryResponse{
+			Slug:       cat.Slug,
+			Name:       cat.Name,
+			Kind:       cat.Kind,
+			Source:     cat.Source,
+			TokenCount: cat.Tokens,
+		})
+	}
+	RespondOK(c, resp)
+}
+
+func buildSupplyHistory(token *models.TokenDetailResponse, days int, history []db.SupplySnapshot) models.SupplyHistoryResponse {
+	resp := models.SupplyHistoryResponse{
+		TokenID:   token.ID,
+		Symbol:    token.Symbol,
+		Days:      days,
+		Snapshots: make([]models.SupplySnapshot, 0, len(history)),
+	}
+	for _, s := range history {
+		resp.Snapshots = append(resp.Snapshots, models.SupplySnapshot{
+			Date:              s.Date.Format("2006-01-02"),
+			CirculatingSupply: nullFloat(s.CirculatingSupply),
+			TotalSupply:       nullFloat(s.TotalSupply),
+			MaxSupply:         nullFloat(s.MaxSupply),
+		})
+	}
+
+	if len(history) > 1 {
+		first, last := history[0].CirculatingSupply, history[len(history)-1].CirculatingSupply
+		if first.Valid && last.Valid && first.Float64 > 0 {
+			change := (last.Float64 - first.Float64) / first.Float64 * 100
+			resp.CirculatingChangePct = &change
+		}
+	}
+	return resp
+}
+
+// buildCoverage merges configured pairs with observed tickers. Pairs the
+// poller has seen but trading_pairs does not know yet are included too.
+func buildCoverage(token *models.TokenDetailResponse, markets []models.TokenMarket, snapshots []storage.PairSnapshot) models.TokenCoverageResponse {
+	type pairKey struct{ exchange, symbol string }
+	pairs := make(map[pairKey]*models.PairCoverage)
+	for _, m := range markets {
+		pairs[pairKey{m.ExchangeID, m.PairSymbol}] = &models.PairCoverage{
+			PairSymbol:     m.PairSymbol,
+			QuoteSymbol:    m.QuoteSymbol,
+			InstrumentType: m.InstrumentType,
+		}
+	}
+	for _, snap := range snapshots {
+		key := pairKey{snap.ExchangeID, snap.Symbol}
+		p, ok := pairs[key]
+		if !ok {
+			p = &models.PairCoverage{PairSymbol: snap.Symbol, QuoteSymbol: snap.QuoteSymbol, InstrumentType: snap.InstrumentType}
+			pairs[key] = p
+		}
+		price := snap.Price
+		lastSeen := snap.LastSeen
+		p.LastPrice = &price
+		p.LastSeen = &lastSeen
+	}
+
+	byExchange := make(map[string][]models.PairCoverage)
+	for key, p := range pairs {
+		byExchange[key.exchange] = append(byExchange[key.exchange], *p)
+	}
+
+	resp := models.TokenCoverageResponse{
+		TokenID:   token.ID,
+		Symbol:    token.Symbol,
+		Name:      token.Name,
+		PairCount: len(pairs),
+		Exchanges: make([]models.ExchangeCoverage, 0, len(byExchange)),
+	}
+	for exchangeID, list := range byExchange {
+		sort.Slice(list, func(i, j int) bool { return list[i].PairSymbol < list[j].PairSymbol })
+		resp.Exchanges = append(resp.Exchanges, models.ExchangeCoverage{ExchangeID: exchangeID, Pairs: list})
+	}
+	sort.Slice(resp.Exchanges, func(i, j int) bool { return resp.Exchanges[i].ExchangeID < resp.Exchanges[j].ExchangeID })
+	resp.ExchangeCount = len(resp.Exchanges)
+	return resp
+}
+
+// tokenFromParam loads the token named by the :id path parameter, which may be
+// a numeric ID or a symbol. It writes the error response and returns false if
+// the parameter is malformed or the token cannot be loaded.
+func (h *TokenHandler) tokenFromParam(c *gin.Context) (*models.TokenDetailResponse, bool) {
+	ident := strings.ToUpper(strings.TrimSpace(c.Param("id")))
+	if !symbolPattern.MatchString(ident) {
+		RespondValidationErrors(c, []models.FieldError{{Field: "id", Message: "Must be a token symbol or numeric ID"}})
+		return nil, false
+	}
+
+	token, err := h.loadToken(c.Request.Context(), ident)
+	if errors.Is(err, db.ErrSymbolNotFound) {
+		RespondNotFound(c, "token_not_found", "Token not found")
+		return nil, false
+	}
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load token", zap.Error(err), zap.String("token", ident))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve token")
+		return nil, false
+	}
+	return token, true
+}
+
+// loadToken looks a token up by numeric ID, or by symbol preferring the
+// chain-agnostic row and then the highest-ranked active token when several
+// share it
+func (h *TokenHandler) loadToken(ctx context.Context, ident string) (*models.TokenDetailResponse, error) {
+	where := "UPPER(symbol) = $1 AND is_active = true"
+	var arg interface{} = ident
+	if id, err := strconv.Atoi(ident); err == nil {
+		where, arg = "id = $1", id
+	}
+
+	query := `
+		SELECT id, symbol, name, COALESCE(slug, ''), COALESCE(chain, ''), COALESCE(contract_address, ''), market_cap_rank,
+		       market_cap, circulating_supply, total_supply, max_supply,
+		       COALESCE(metadata, '{}'), is_archived, archived_at
+		FROM tokens
+		WHERE ` + where + `
+		ORDER BY chain IS NULL DESC, market_cap_rank ASC NULLS LAST, id ASC
+		LIMIT 1
+	`
+
+	var t models.TokenDetailResponse
+	var rank sql.NullInt64
+	var marketCap, circulating, total, max sql.NullFloat64
+	var rawMetadata []byte
+	var archivedAt sql.NullTime
+
+	err := h.postgresDB.QueryRowContext(ctx, query, arg).Scan(
+		&t.ID, &t.Symbol, &t.Name, &t.Slug, &t.Chain, &t.ContractAddress, &rank,
+		&marketCap, &circulating, &total, &max,
+		&rawMetadata, &t.Archived, &archivedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", db.ErrSymbolNotFound, ident)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query token: %w", err)
+	}
+
+	if rank.Valid {
+		t.Rank = &rank.Int64
+	}
+	if archivedAt.Valid {
+		t.ArchivedAt = &archivedAt.Time
+	}
+	categories, err := db.GetTokenCategories(ctx, h.postgresDB, []int{t.ID})
+	if err != nil {
+		return nil, err
+	}
+	t.Categories = categories[t.ID]
+	if t.Categories == nil {
+		t.Categories = []string{}
+	}
+	t.MarketCap = nullFloat(marketCap)
+	t.CirculatingSupply = nullFloat(circulating)
+	t.TotalSupply = nullFloat(total)
+	t.MaxSupply = nullFloat(max)
+
+	var meta tokenMetadata
+	if err := json.Unmarshal(rawMetadata, &meta); err != nil {
+		requestid.Logger(ctx, h.logger).Warn("Malformed token metadata", zap.Int("token_id", t.ID), zap.Error(err))
+	}
+	t.URLs = meta.URLs
+	if t.URLs == nil {
+		t.URLs = map[string][]string{}
+	}
+	t.Contracts = make([]models.TokenContract, 0, len(meta.Contracts))
+	for _, ct := range meta.Contracts {
+		t.Contracts = append(t.Contracts, models.TokenContract{
+			Platform:        ct.Platform,
+			ContractAddress: ct.ContractAddress,
+			RPCURLs:         ct.RPCURLs,
+		})
+	}
+
+	return &t, nil
+}
+
+// loadMarkets lists the active exchange pairs where the token is the base
+func (h *TokenHandler) loadMarkets(ctx context.Context, tokenID int) ([]models.TokenMarket, error) {
+	rows, err := h.postgresDB.QueryContext(ctx, `
+		SELECT tp.exchange_id, tp.exchange_pair_symbol, q.symbol, tp.instrument_type, COALESCE(tp.last_volume_24h, 0)
+		FROM trading_pairs tp
+		JOIN tokens q ON q.id = tp.quote_token_id
+		WHERE tp.base_token_id = $1 AND tp.is_active = true
+		ORDER BY tp.last_volume_24h DESC NULLS LAST, tp.exchange_id, tp.exchange_pair_symbol
+	`, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trading pairs: %w", err)
+	}
+	defer rows.Close()
+
+	markets := []models.TokenMarket{}
+	for rows.Next() {
+		var m models.TokenMarket
+		if err := rows.Scan(&m.ExchangeID, &m.PairSymbol, &m.QuoteSymbol, &m.InstrumentType, &m.Volume24h); err != nil {
+			return nil, fmt.Errorf("failed to scan trading pair: %w", err)
+		}
+		markets = append(markets, m)
+	}
+	return markets, rows.Err()
+}
+
+// latestUSDVWAP returns the latest VWAP against the first USD quote that has
+// one, or nil if none do
+func (h *TokenHandler) latestUSDVWAP(ctx context.Context, token *models.TokenDetailResponse) (*models.VWAPResponse, error) {
+	quoteIDs, err := db.GetUSDQuoteTokens(ctx, h.postgresDB)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, quote := range db.USDQuoteSymbols {
+		quoteID, ok := quoteIDs[quote]
+		if !ok {
+			continue
+		}
+		result, err := h.vwapStorage.GetLatestVWAP(ctx, token.ID, quoteID)
+		if errors.Is(err, db.ErrNoData) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &models.VWAPResponse{
+			Symbol:         token.Symbol,
+			Quote:          quote,
+			Price:          result.VWAPPrice,
+			Volume:         result.TotalVolume,
+			ExchangeCount:  result.ExchangeCount,
+			Exchanges:      result.ContributingExchanges,
+			SpreadBps:      result.SpreadBps,
+			LiquidityScore: result.LiquidityScore,
+			Indicative:     result.Indicative,
+			Timestamp:      result.Timestamp.Unix(),
+		}, nil
+	}
+	return nil, nil
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
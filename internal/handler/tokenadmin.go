@@ -0,0 +1,468 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/symbol"
+	"github.com/ashmitsharp/trading/internal/tokenops"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TokenAdminHandler serves the token admin endpoints: creating and editing
+// tokens, merging, splitting and archiving them
+type TokenAdminHandler struct {
+	service  *tokenops.Service
+	resolver *symbol.Resolver
+	logger   *zap.Logger
+}
+
+// NewTokenAdminHandler creates a new token admin handler. The resolver cache is
+// refreshed after each change so this process stops resolving to the old IDs.
+func NewTokenAdminHandler(service *tokenops.Service, resolver *symbol.Resolver, logger *zap.Logger) *TokenAdminHandler {
+	return &TokenAdminHandler{
+		service:  service,
+		resolver: resolver,
+		logger:   logger,
+	}
+}
+
+// MergeTokenRequest is the body of a token merge
+type MergeTokenRequest struct {
+	TargetTokenID int    `json:"target_token_id" binding:"required,min=1"`
+	PerformedBy   string `json:"performed_by" binding:"required"`
+	Reason        string `json:"reason"`
+}
+
+// SplitTokenRequest is the body of a token split: the new token and the
+// exchanges whose listings move to it
+type SplitTokenRequest struct {
+	Exchanges       []string `json:"exchanges" binding:"required,min=1,dive,required"`
+	Symbol          string   `json:"symbol" binding:"required,max=20"`
+	Name            string   `json:"name" binding:"required,max=100"`
+	Slug            string   `json:"slug" binding:"max=100"`
+	Chain           string   `json:"chain" binding:"max=50"`
+	ContractAddress string   `json:"contract_address"`
+	PerformedBy     string   `json:"performed_by" binding:"required"`
+	Reason          string   `json:"reason"`
+}
+
+// TokenRequest is the body of creating or updating a token
+type TokenRequest struct {
+	Symbol          string                 `json:"symbol" binding:"required,max=20"`
+	Name            string                 `json:"name" binding:"required,max=100"`
+	Slug            string                 `json:"slug" binding:"max=100"`
+	Chain           string                 `json:"chain" binding:"max=50"` // empty for the chain-agnostic row exchanges quote
+	ContractAddress string                 `json:"contract_address" binding:"max=255"`
+	Decimals        *int                   `json:"decimals" binding:"omitempty,min=0,max=36"`  // default 18
+	Metadata        map[string]interface{} `json:"metadata"`                                   // merged into the stored metadata, e.g. urls
+	Contracts       []TokenContractRequest `json:"contracts" binding:"omitempty,max=100,dive"` // replace the token's contracts when set
+	IsActive        *bool                  `json:"is_active"`                                  // default true on create, unchanged on update
+}
+
+// TokenContractRequest is one contract of a token
+type TokenContractRequest struct {
+	Platform        string   `json:"platform" binding:"required,max=100"`
+	ContractAddress string   `json:"contract_address" binding:"required"`
+	RPCURLs         []string `json:"rpc_urls" binding:"dive,url"`
+}
+
+func (r TokenRequest) input() tokenops.TokenInput {
+	in := tokenops.TokenInput{
+		Symbol:          r.Symbol,
+		Name:            r.Name,
+		Slug:            r.Slug,
+		Chain:           r.Chain,
+		ContractAddress: r.ContractAddress,
+		Decimals:        18,
+		Metadata:        r.Metadata,
+		Active:          r.IsActive,
+	}
+	if r.Decimals != nil {
+		in.Decimals = *r.Decimals
+	}
+	if r.Contracts != nil {
+		in.Contracts = make([]tokenops.Contract, 0, len(r.Contracts))
+		for _, c := range r.Contracts {
+			in.Contracts = append(in.Contracts, tokenops.Contract{
+				Platform: c.Platform,
+				Address:  c.ContractAddress,
+				RPCURLs:  c.RPCURLs,
+			})
+		}
+	}
+	return in
+}
+
+// ArchiveTokenRequest is the body of a token archival or restore
+type ArchiveTokenRequest struct {
+	PerformedBy string `json:"performed_by" binding:"required"`
+	Reason      string `json:"reason"`
+}
+
+// CreateToken adds a token
+// @Summary Create token
+// @Description Add a token. Tokens without a chain are the row exchanges quote and are unique by symbol; tokens on a chain are unique by chain and contract address. EVM addresses are lowercased. contracts are stored in order, the first as primary.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body TokenRequest true "Token"
+// @Success 200 {object} models.APIResponse{data=models.AdminTokenResponse} "Created"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 409 {object} models.ErrorResponse "Token already exists"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/tokens [post]
+func (h *TokenAdminHandler) CreateToken(c *gin.Context) {
+	var req TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	token, err := h.service.CreateToken(c.Request.Context(), req.input())
+	if err != nil {
+		h.respondError(c, err, "Failed to create token")
+		return
+	}
+	h.refreshResolver(c)
+	RespondOKWithMessage(c, adminTokenResponse(token), "Token created successfully")
+}
+
+// UpdateToken corrects a token
+// @Summary Update token
+// @Description Replace the symbol, name, slug, chain, contract address and decimals of token {id}. metadata keys are merged into the stored metadata, contracts replace the token's contracts when set, and market data is left alone. An archived token cannot be activated here; restore it instead.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Token ID"
+// @Param request body TokenRequest true "Token"
+// @Success 200 {object} models.APIResponse{data=models.AdminTokenResponse} "Updated"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 409 {object} models.ErrorResponse "Another token has the symbol or contract, or the token is archived"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/tokens/{id} [put]
+func (h *TokenAdminHandler) UpdateToken(c *gin.Context) {
+	tokenID, ok := idParam(c, "Invalid token ID")
+	if !ok {
+		return
+	}
+	var req TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	token, err := h.service.UpdateToken(c.Request.Context(), tokenID, req.input())
+	if err != nil {
+		h.respondError(c, err, "Failed to update token")
+		return
+	}
+	h.refreshResolver(c)
+	RespondOK(c, adminTokenResponse(token))
+}
+
+// DeactivateToken deactivates a token
+// @Summary Deactivate token
+// @Description Deactivate token {id}, taking it out of token listings and symbol lookups. Tokens are never deleted; its symbol mappings and trading pairs stay active until it is archived.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Token ID"
+// @Success 200 {object} models.APIResponse{data=models.AdminTokenResponse} "Deactivated"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/tokens/{id} [delete]
+func (h *TokenAdminHandler) DeactivateToken(c *gin.Context) {
+	tokenID, ok := idParam(c, "Invalid token ID")
+	if !ok {
+		return
+	}
+
+	token, err := h.service.DeactivateToken(c.Request.Context(), tokenID)
+	if err != nil {
+		h.respondError(c, err, "Failed to deactivate token")
+		return
+	}
+	h.refreshResolver(c)
+	RespondOKWithMessage(c, adminTokenResponse(token), "Token deactivated successfully")
+}
+
+// MergeToken merges a token into another
+// @Summary Merge tokens
+// @Description Re-point symbol mappings, trading pairs, outliers, supply snapshots, index constituents and ClickHouse rows of token {id} at target_token_id, then deactivate token {id}. Repeating a merge moves rows written under the old ID since.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Token ID to merge away"
+// @Param request body MergeTokenRequest true "Target token and audit details"
+// @Success 200 {object} models.APIResponse{data=models.TokenMergeResponse} "Merged"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/tokens/{id}/merge [post]
+func (h *TokenAdminHandler) MergeToken(c *gin.Context) {
+	tokenID, ok := idParam(c, "Invalid token ID")
+	if !ok {
+		return
+	}
+	var req MergeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	result, err := h.service.Merge(c.Request.Context(), tokenops.MergeRequest{
+		SourceTokenID: tokenID,
+		TargetTokenID: req.TargetTokenID,
+		PerformedBy:   req.PerformedBy,
+		Reason:        req.Reason,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to merge tokens")
+		return
+	}
+	h.respond(c, result)
+}
+
+// SplitToken moves some of a token's listings to a new token
+// @Summary Split token
+// @Description Create a new token and move the symbol mappings, trading pairs and outliers of token {id} on the given exchanges to it, with their per-exchange trades, tickers and VWAP contributions in ClickHouse. Composite VWAPs and candles stay with token {id}.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Token ID to split"
+// @Param request body SplitTokenRequest true "New token, exchanges to move and audit details"
+// @Success 200 {object} models.APIResponse{data=models.TokenMergeResponse} "Split"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 409 {object} models.ErrorResponse "New token already exists"
+// @Failure 422 {object} models.ErrorResponse "Validation failed or nothing to move"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/tokens/{id}/split [post]
+func (h *TokenAdminHandler) SplitToken(c *gin.Context) {
+	tokenID, ok := idParam(c, "Invalid token ID")
+	if !ok {
+		return
+	}
+	var req SplitTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	result, err := h.service.Split(c.Request.Context(), tokenops.SplitRequest{
+		SourceTokenID:   tokenID,
+		Exchanges:       req.Exchanges,
+		Symbol:          req.Symbol,
+		Name:            req.Name,
+		Slug:            req.Slug,
+		Chain:           req.Chain,
+		ContractAddress: req.ContractAddress,
+		PerformedBy:     req.PerformedBy,
+		Reason:          req.Reason,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to split token")
+		return
+	}
+	h.respond(c, result)
+}
+
+// ArchiveToken archives a token
+// @Summary Archive token
+// @Description Deactivate token {id}, mark it archived and tombstone its symbol mappings and trading pairs (deactivated, with archived_at set) so nothing resolves to it any more. Its rows are kept, and its ClickHouse prices, VWAPs and candles stay queryable under its ID; tokens are archived rather than deleted. Archiving again tombstones mappings and pairs written since.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Token ID"
+// @Param request body ArchiveTokenRequest true "Audit details"
+// @Success 200 {object} models.APIResponse{data=models.TokenMergeResponse} "Archived; moved counts the rows tombstoned"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/tokens/{id}/archive [post]
+func (h *TokenAdminHandler) ArchiveToken(c *gin.Context) {
+	tokenID, ok := idParam(c, "Invalid token ID")
+	if !ok {
+		return
+	}
+	var req ArchiveTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	result, err := h.service.Archive(c.Request.Context(), tokenops.ArchiveRequest{
+		TokenID:     tokenID,
+		PerformedBy: req.PerformedBy,
+		Reason:      req.Reason,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to archive token")
+		return
+	}
+	h.respond(c, result)
+}
+
+// RestoreToken restores an archived token
+// @Summary Restore token
+// @Description Reactivate archived token {id} with its tombstoned symbol mappings, and its tombstoned trading pairs whose other token is not archived.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Token ID"
+// @Param request body ArchiveTokenRequest true "Audit details"
+// @Success 200 {object} models.APIResponse{data=models.TokenMergeResponse} "Restored; moved counts the rows reactivated"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 409 {object} models.ErrorResponse "Token is not archived"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/tokens/{id}/restore [post]
+func (h *TokenAdminHandler) RestoreToken(c *gin.Context) {
+	tokenID, ok := idParam(c, "Invalid token ID")
+	if !ok {
+		return
+	}
+	var req ArchiveTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+
+	result, err := h.service.Restore(c.Request.Context(), tokenops.RestoreRequest{
+		TokenID:     tokenID,
+		PerformedBy: req.PerformedBy,
+		Reason:      req.Reason,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to restore token")
+		return
+	}
+	h.respond(c, result)
+}
+
+// ResumeClickHouse re-runs the ClickHouse step of a merge or split
+// @Summary Resume token merge
+// @Description Re-point the ClickHouse rows of a logged merge or split again, for when the step failed. PostgreSQL is not touched.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Token merge log ID"
+// @Success 200 {object} models.APIResponse{data=models.TokenMergeResponse} "ClickHouse step re-run"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Log entry not found"
+// @Failure 422 {object} models.ErrorResponse "Log entry has no ClickHouse step"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/token-merges/{id}/resume [post]
+func (h *TokenAdminHandler) ResumeClickHouse(c *gin.Context) {
+	logID, ok := idParam(c, "Invalid log ID")
+	if !ok {
+		return
+	}
+
+	result, err := h.service.ResumeClickHouse(c.Request.Context(), logID)
+	if err != nil {
+		h.respondError(c, err, "Failed to resume token merge")
+		return
+	}
+	h.respond(c, result)
+}
+
+func (h *TokenAdminHandler) respond(c *gin.Context, result *tokenops.Result) {
+	h.refreshResolver(c)
+
+	resp := mergeResponse(result)
+	if result.ClickHouseError != nil {
+		RespondOKWithMessage(c, resp, fmt.Sprintf("PostgreSQL updated but ClickHouse rows were not all moved; resume token merge %d to finish", result.LogID))
+		return
+	}
+	RespondOK(c, resp)
+}
+
+func (h *TokenAdminHandler) refreshResolver(c *gin.Context) {
+	if err := h.resolver.RefreshCache(c.Request.Context()); err != nil {
+		requestLogger(c, h.logger).Warn("Failed to refresh resolver cache after token change", zap.Error(err))
+	}
+}
+
+// respondError shows the error to the client when it is one of the
+// service's validation errors and a generic message otherwise
+func (h *TokenAdminHandler) respondError(c *gin.Context, err error, message string) {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c, h.logger).Error(message, zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, message)
+		return
+	}
+	RespondError(c, status, code, err.Error())
+}
+
+func mergeResponse(result *tokenops.Result) models.TokenMergeResponse {
+	resp := models.TokenMergeResponse{
+		LogID:            result.LogID,
+		Action:           result.Action,
+		SourceTokenID:    result.SourceTokenID,
+		TargetTokenID:    result.TargetTokenID,
+		Moved:            result.Moved,
+		ClickHouseStatus: result.ClickHouseStatus,
+		ClickHouseTables: result.ClickHouseTables,
+	}
+	if result.ClickHouseError != nil {
+		resp.ClickHouseError = result.ClickHouseError.Error()
+	}
+	return resp
+}
+
+func adminTokenResponse(t *tokenops.Token) models.AdminTokenResponse {
+	resp := models.AdminTokenResponse{
+		ID:              t.ID,
+		Symbol:          t.Symbol,
+		Name:            t.Name,
+		Slug:            t.Slug,
+		Chain:           t.Chain,
+		ContractAddress: t.ContractAddress,
+		Decimals:        t.Decimals,
+		Metadata:        map[string]interface{}{},
+		Contracts:       make([]models.TokenContract, 0, len(t.Contracts)),
+		Active:          t.Active,
+		Archived:        t.Archived,
+		CreatedAt:       t.CreatedAt,
+		UpdatedAt:       t.UpdatedAt,
+	}
+	// Stored metadata is always an object; anything else is shown as empty
+	_ = json.Unmarshal(t.Metadata, &resp.Metadata)
+	for _, ct := range t.Contracts {
+		resp.Contracts = append(resp.Contracts, models.TokenContract{
+			Platform:        ct.Platform,
+			ContractAddress: ct.Address,
+			RPCURLs:         ct.RPCURLs,
+		})
+	}
+	return resp
+}
+
+func idParam(c *gin.Context, message string) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		RespondBadRequest(c, ErrCodeInvalidParameter, message)
+		return 0, false
+	}
+	return id, true
+}
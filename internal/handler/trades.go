@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxTradeExportRange is the longest from/to range of a trade export;
+	// pages within it are fetched with the cursor
+	maxTradeExportRange = 366 * 24 * time.Hour
+	// tradeExportFlushEvery is how many trades are written between flushes,
+	// so a client sees a large page arrive as it is read
+	tradeExportFlushEvery = 1000
+)
+
+// csvTradeHeader is the first line of a CSV trade export
+var csvTradeHeader = []string{"timestamp", "trade_id", "exchange", "symbol", "price", "quantity", "is_buyer_maker"}
+
+// TradeHandler serves raw trades
+type TradeHandler struct {
+	clickhouseConn driver.Conn
+	logger         *zap.Logger
+}
+
+// NewTradeHandler creates a new trade export handler
+func NewTradeHandler(clickhouseConn driver.Conn, logger *zap.Logger) *TradeHandler {
+	return &TradeHandler{
+		clickhouseConn: clickhouseConn,
+		logger:         logger,
+	}
+}
+
+// ExportTrades streams one page of a symbol's trades
+// @Summary Export trades
+// @Description Stream the stored trades of a pair on one exchange, oldest first, as newline-delimited JSON (one trade object per line) or CSV with a header line. Trades are ordered by time, then trade ID. A full page of limit trades has the X-Next-Cursor header, the cursor of the next page, which may be empty; pass it back with the same symbol, exchange, from and to. A page without the header is the last. A response cut off part way was not complete and should be fetched again with the same cursor.
+// @Tags trades
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Param symbol path string true "Trading pair symbol as the exchange writes it (e.g., BTCUSDT)"
+// @Param exchange query string false "Exchange whose trades to export" default(binance)
+// @Param from query int false "Start time (Unix timestamp in seconds), included; default 24 hours ago"
+// @Param to query int false "End time (Unix timestamp in seconds), excluded; default now"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param limit query int false "Trades per page" default(10000) maximum(100000)
+// @Param format query string false "Output format" Enums(ndjson, csv) default(ndjson)
+// @Success 200 {file} file "Trades; X-Next-Cursor is set on a full page"
+// @Failure 422 {object} models.ErrorResponse "Invalid parameters, with per-field details"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/trades/{symbol} [get]
+func (h *TradeHandler) ExportTrades(c *gin.Context) {
+	v := NewRequestValidator(c)
+	symbol := v.Symbol("symbol")
+	limit := v.IntRange("limit", 10000, 1, 100000)
+	exchangeID := strings.ToLower(strings.TrimSpace(c.DefaultQuery("exchange", "binance")))
+	if exchangeID == "" || len(exchangeID) > 50 {
+		v.Add("exchange", "Exchange must be an exchange ID such as binance")
+	}
+	format := c.DefaultQuery("format", "ndjson")
+	if format != "ndjson" && format != "csv" {
+		v.Add("format", "Format must be one of: ndjson, csv")
+	}
+	now := timeutil.SecondsOf(time.Now())
+	from := v.Timestamp("from", now-24*3600)
+	to := v.Timestamp("to", now)
+	var after *db.TradeKey
+	if raw := c.Query("cursor"); raw != "" {
+		key, err := decodeTradeCursor(raw)
+		if err != nil {
+			v.Add("cursor", "Cursor must be the X-Next-Cursor of a previous page")
+		}
+		after = &key
+	}
+	if v.Valid() {
+		v.TimeRange(from, to, maxTradeExportRange)
+	}
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	ctx := c.Request.Context()
+	r := db.TradeRange{ExchangeID: exchangeID, Symbol: symbol, From: from, To: to, After: after}
+
+	// Fix where the page ends before streaming it, so the cursor can go in a
+	// header and trades arriving meanwhile do not shift the page
+	end, more, err := db.GetTradePageEnd(ctx, h.clickhouseConn, r, limit)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to get trade page", zap.Error(err), zap.String("symbol", symbol))
+		RespondServiceError(c, err, "Failed to retrieve trades")
+		return
+	}
+	if more {
+		r.Through = &end
+		c.Header("X-Next-Cursor", encodeTradeCursor(end))
+	}
+
+	contentType := "application/x-ndjson"
+	if format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+
+	out := bufio.NewWriter(c.Writer)
+	write := ndjsonTradeWriter(out)
+	if format == "csv" {
+		write = csvTradeWriter(out)
+	}
+	written := 0
+	err = db.StreamTrades(ctx, h.clickhouseConn, r, limit, func(trade db.TradeData) error {
+		if err := write(trade); err != nil {
+			return err
+		}
+		if written++; written%tradeExportFlushEvery == 0 {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		// The status is sent, so the client can only tell from the dropped
+		// connection that the page is incomplete
+		requestLogger(c, h.logger).Error("Trade export failed part way",
+			zap.Error(err),
+			zap.String("symbol", symbol),
+			zap.Int("written", written))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// GetTradeStats aggregates a symbol's trades over a window
+// @Summary Trade statistics
+// @Description Count, total volume, mean price, lowest and highest price and the times of the first and last trade of a pair's trades on one exchange over a window, computed in one ClickHouse query. A window without trades returns zeros.
+// @Tags trades
+// @Produce json
+// @Param symbol path string true "Trading pair symbol as the exchange writes it (e.g., BTCUSDT)"
+// @Param exchange query string false "Exchange whose trades to aggregate" default(binance)
+// @Param minutes query int false "Lookback window in minutes; overrides from/to"
+// @Param from query int false "Start time (Unix timestamp in seconds), included; default 24 hours ago"
+// @Param to query int false "End time (Unix timestamp in seconds), excluded; default now"
+// @Success 200 {object} models.APIResponse{data=models.TradeStats} "Trade statistics; trade times in Unix milliseconds"
+// @Failure 422 {object} models.ErrorResponse "Invalid parameters, with per-field details"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/trades/{symbol}/stats [get]
+func (h *TradeHandler) GetTradeStats(c *gin.Context) {
+	v := NewRequestValidator(c)
+	symbol := v.Symbol("symbol")
+	exchangeID := strings.ToLower(strings.TrimSpace(c.DefaultQuery("exchange", "binance")))
+	if exchangeID == "" || len(exchangeID) > 50 {
+		v.Add("exchange", "Exchange must be an exchange ID such as binance")
+	}
+	now := timeutil.SecondsOf(time.Now())
+	var from, to timeutil.Seconds
+	if minutes := v.IntRange("minutes", 0, 1, int(maxTradeExportRange/time.Minute)); minutes > 0 {
+		from = now - timeutil.Seconds(minutes*60)
+		to = now
+	} else {
+		from = v.Timestamp("from", now-24*3600)
+		to = v.Timestamp("to", now)
+	}
+	if v.Valid() {
+		v.TimeRange(from, to, maxTradeExportRange)
+	}
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	r := db.TradeRange{ExchangeID: exchangeID, Symbol: symbol, From: from, To: to}
+	stats, err := db.GetTradeStats(c.Request.Context(), h.clickhouseConn, r)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to get trade stats", zap.Error(err), zap.String("symbol", symbol))
+		RespondServiceError(c, err, "Failed to retrieve trade statistics")
+		return
+	}
+
+	resp := models.TradeStats{
+		Symbol:      symbol,
+		TotalTrades: int64(stats.Count),
+		TotalVolume: stats.Volume,
+		AvgPrice:    stats.AvgPrice,
+		MinPrice:    stats.MinPrice,
+		MaxPrice:    stats.MaxPrice,
+	}
+	if stats.Count == 0 {
+		RespondOKWithMessage(c, resp, "No trades found in the specified time range")
+		return
+	}
+	resp.FirstTradeTime = int64(timeutil.MillisOf(stats.FirstTrade))
+	resp.LastTradeTime = int64(timeutil.MillisOf(stats.LastTrade))
+	RespondOK(c, resp)
+}
+
+// ndjsonTradeWriter writes each trade as a JSON object on a line of its own
+func ndjsonTradeWriter(out *bufio.Writer) func(db.TradeData) error {
+	enc := json.NewEncoder(out)
+	return func(trade db.TradeData) error {
+		return enc.Encode(models.TradeResponse{
+			Timestamp:    int64(trade.Timestamp),
+			TradeID:      trade.TradeID,
+			Exchange:     trade.ExchangeID,
+			Symbol:       trade.Symbol,
+			Price:        trade.Price,
+			Quantity:     trade.Quantity,
+			IsBuyerMaker: trade.IsBuyerMaker == 1,
+		})
+	}
+}
+
+// csvTradeWriter writes the CSV header, so an empty page has one too, then
+// each trade as a row
+func csvTradeWriter(out *bufio.Writer) func(db.TradeData) error {
+	w := csv.NewWriter(out)
+	w.Write(csvTradeHeader)
+	w.Flush()
+	return func(trade db.TradeData) error {
+		w.Write([]string{
+			strconv.FormatInt(int64(trade.Timestamp), 10),
+			strconv.FormatUint(trade.TradeID, 10),
+			trade.ExchangeID,
+			trade.Symbol,
+			trade.Price.String(),
+			trade.Quantity.String(),
+			strconv.FormatBool(trade.IsBuyerMaker == 1),
+		})
+		// The CSV writer buffers into out, which the caller flushes
+		w.Flush()
+		return w.Error()
+	}
+}
+
+// encodeTradeCursor makes an opaque cursor of a trade's position
+func encodeTradeCursor(key db.TradeKey) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d:%d", key.Timestamp, key.TradeID))
+}
+
+// decodeTradeCursor reads a cursor made by encodeTradeCursor
+func decodeTradeCursor(cursor string) (db.TradeKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return db.TradeKey{}, err
+	}
+	ts, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return db.TradeKey{}, fmt.Errorf("malformed trade cursor")
+	}
+	millis, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return db.TradeKey{}, err
+	}
+	tradeID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return db.TradeKey{}, err
+	}
+	return db.TradeKey{Timestamp: timeutil.Millis(millis), TradeID: tradeID}, nil
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/requestid"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// symbolPattern matches exchange pair symbols (BTCUSDT) and token symbols (BTC)
+var symbolPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9._-]{0,31}$`)
+
+// supportedIntervals lists the candlestick intervals accepted by the API
+var supportedIntervals = []string{"1m", "5m", "15m", "1h", "4h", "1d"}
+
+func init() {
+	// Report JSON field names rather than Go struct field names in binding errors
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+			name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+			if name == "" || name == "-" {
+				return fld.Name
+			}
+			return name
+		})
+	}
+}
+
+// RequestValidator collects field-level errors while reading path and query
+// parameters, so a request with several bad parameters reports all of them
+type RequestValidator struct {
+	c      *gin.Context
+	errors []models.FieldError
+}
+
+// NewRequestValidator creates a validator for the given request
+func NewRequestValidator(c *gin.Context) *RequestValidator {
+	return &RequestValidator{c: c}
+}
+
+// Add records a field error
+func (v *RequestValidator) Add(field, message string) {
+	v.errors = append(v.errors, models.FieldError{Field: field, Message: message})
+}
+
+// Valid reports whether no errors have been recorded
+func (v *RequestValidator) Valid() bool {
+	return len(v.errors) == 0
+}
+
+// Errors returns the recorded field errors
+func (v *RequestValidator) Errors() []models.FieldError {
+	return v.errors
+}
+
+// Respond writes a 422 with all recorded field errors
+func (v *RequestValidator) Respond() {
+	RespondValidationErrors(v.c, v.errors)
+}
+
+// Symbol reads and normalizes a required symbol path parameter
+func (v *RequestValidator) Symbol(param string) string {
+	symbol := strings.ToUpper(strings.TrimSpace(v.c.Param(param)))
+	if symbol == "" {
+		v.Add(param, "Symbol is required")
+		return ""
+	}
+	if !symbolPattern.MatchString(symbol) {
+		v.Add(param, "Symbol must be 1-32 letters, digits, '.', '_' or '-'")
+		return ""
+	}
+	return symbol
+}
+
+// Interval reads a candlestick interval query parameter
+func (v *RequestValidator) Interval(name, def string) string {
+	interval := v.c.DefaultQuery(name, def)
+	for _, supported := range supportedIntervals {
+		if interval == supported {
+			return interval
+		}
+	}
+	v.Add(name, "Invalid interval. Supported: "+strings.Join(supportedIntervals, ", "))
+	return def
+}
+
+// Timestamp reads a Unix timestamp (seconds) query parameter, returning def
+// when it is absent
+func (v *RequestValidator) Timestamp(name string, def timeutil.Seconds) timeutil.Seconds {
+	raw := v.c.Query(name)
+	if raw == "" {
+		return def
+	}
+	ts, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || ts < 0 {
+		v.Add(name, "Must be a Unix timestamp in seconds")
+		return def
+	}
+	return timeutil.Seconds(ts)
+}
+
+// IntRange reads an integer query parameter bounded by [min, max], returning
+// def when it is absent
+func (v *RequestValidator) IntRange(name string, def, min, max int) int {
+	raw := v.c.Query(name)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min || n > max {
+		v.Add(name, fmt.Sprintf("Must be an integer between %d and %d", min, max))
+		return def
+	}
+	return n
+}
+
+// Bool reads a boolean query parameter, returning def when it is absent
+func (v *RequestValidator) Bool(name string, def bool) bool {
+	raw := v.c.Query(name)
+	if raw == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		v.Add(name, "Must be true or false")
+		return def
+	}
+	return b
+}
+
+// Pagination reads limit and offset query parameters
+func (v *RequestValidator) Pagination(defLimit, maxLimit int) (limit, offset int) {
+	limit = v.IntRange("limit", defLimit, 1, maxLimit)
+	offset = v.IntRange("offset", 0, 0, 1<<31-1)
+	return limit, offset
+}
+
+// TimeRange checks that from precedes to and spans at most maxRange
+func (v *RequestValidator) TimeRange(from, to timeutil.Seconds, maxRange time.Duration) {
+	if to <= from {
+		v.Add("to", "End time must be after start time")
+		return
+	}
+	if maxRange > 0 && int64(to-from) > int64(maxRange.Seconds()) {
+		v.Add("from", "Time range exceeds maximum allowed for this interval")
+	}
+}
+
+// RespondValidationErrors writes a 422 listing each invalid field
+func RespondValidationErrors(c *gin.Context, details []models.FieldError) {
+	c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+		Error:     ErrCodeValidationFailed,
+		Message:   "One or more parameters are invalid",
+		Code:      http.StatusUnprocessableEntity,
+		Details:   details,
+		RequestID: requestid.FromContext(c.Request.Context()),
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// ValidateSymbolParam is middleware that rejects malformed :symbol path
+// parameters and normalizes valid ones to upper case
+func ValidateSymbolParam() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := NewRequestValidator(c)
+		symbol := v.Symbol("symbol")
+		if !v.Valid() {
+			v.Respond()
+			c.Abort()
+			return
+		}
+		for i := range c.Params {
+			if c.Params[i].Key == "symbol" {
+				c.Params[i].Value = symbol
+			}
+		}
+		c.Next()
+	}
+}
+
+// bindingFieldErrors converts validator errors into field-level details
+func bindingFieldErrors(err error) ([]models.FieldError, bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+	details := make([]models.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		details = append(details, models.FieldError{
+			Field:   fe.Field(),
+			Message: fieldErrorMessage(fe),
+		})
+	}
+	return details, true
+}
+
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "This field is required"
+	case "min":
+		return "Must be at least " + fe.Param()
+	case "max":
+		return "Must be at most " + fe.Param()
+	case "oneof":
+		return "Must be one of: " + fe.Param()
+	default:
+		return "Failed '" + fe.Tag() + "' validation"
+	}
+}
@@ -2,9 +2,10 @@ package handler
 
 import (
 	"database/sql"
-	"net/http"
+	"errors"
 	"strconv"
 
+	"github.com/ashmitsharp/trading/internal/models"
 	"github.com/ashmitsharp/trading/internal/outlier"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -40,6 +41,14 @@ type UnverifiedMapping struct {
 }
 
 // GetUnverifiedMappings returns all unverified symbol-based mappings
+// @Summary List unverified mappings
+// @Description List symbol-based mappings that still need manual verification
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse "Mappings and total"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings/unverified [get]
 func (h *VerificationHandler) GetUnverifiedMappings(c *gin.Context) {
 	query := `
 		SELECT 
@@ -60,15 +69,15 @@ func (h *VerificationHandler) GetUnverifiedMappings(c *gin.Context) {
 		FROM token_exchange_symbols tes
 		JOIN tokens t ON tes.token_id = t.id
 		WHERE tes.needs_verification = true
-			AND tes.mapping_method = 'symbol'
+			AND tes.mapping_method IN ('symbol', 'auto')
 		ORDER BY tes.confidence_score ASC, tes.created_at DESC
 		LIMIT 100
 	`
 	
 	rows, err := h.db.Query(query)
 	if err != nil {
-		h.logger.Error("Failed to fetch unverified mappings", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mappings"})
+		requestLogger(c, h.logger).Error("Failed to fetch unverified mappings", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to fetch mappings")
 		return
 	}
 	defer rows.Close()
@@ -93,17 +102,30 @@ func (h *VerificationHandler) GetUnverifiedMappings(c *gin.Context) {
 		mappings = append(mappings, m)
 	}
 	
-	c.JSON(http.StatusOK, gin.H{
+	RespondOK(c, gin.H{
 		"mappings": mappings,
 		"total":    len(mappings),
 	})
 }
 
 // VerifyMapping marks a mapping as verified
+// @Summary Verify mapping
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Mapping ID"
+// @Param request body object true "verified_by (required) and notes"
+// @Success 200 {object} models.APIResponse "Verified"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Mapping not found"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings/{id}/verify [post]
 func (h *VerificationHandler) VerifyMapping(c *gin.Context) {
 	mappingID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping ID"})
+		RespondBadRequest(c, ErrCodeInvalidParameter, "Invalid mapping ID")
 		return
 	}
 	
@@ -113,7 +135,7 @@ func (h *VerificationHandler) VerifyMapping(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondBindingError(c, err)
 		return
 	}
 	
@@ -127,10 +149,14 @@ func (h *VerificationHandler) VerifyMapping(c *gin.Context) {
 		WHERE id = $1
 	`
 	
-	_, err = h.db.Exec(query, mappingID, req.VerifiedBy)
+	result, err := h.db.Exec(query, mappingID, req.VerifiedBy)
 	if err != nil {
-		h.logger.Error("Failed to verify mapping", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify mapping"})
+		requestLogger(c, h.logger).Error("Failed to verify mapping", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to verify mapping")
+		return
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		RespondNotFound(c, "mapping_not_found", "Mapping not found")
 		return
 	}
 	
@@ -151,17 +177,27 @@ func (h *VerificationHandler) VerifyMapping(c *gin.Context) {
 	
 	h.db.Exec(auditQuery, mappingID, req.VerifiedBy, req.Notes)
 	
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Mapping verified successfully",
-		"id":      mappingID,
-	})
+	RespondOKWithMessage(c, gin.H{"id": mappingID}, "Mapping verified successfully")
 }
 
 // FlagMapping marks a mapping as incorrect
+// @Summary Flag mapping
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Mapping ID"
+// @Param request body object true "flagged_by and reason (required), optional new_token_id"
+// @Success 200 {object} models.APIResponse "Flagged"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Mapping not found"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/mappings/{id}/flag [post]
 func (h *VerificationHandler) FlagMapping(c *gin.Context) {
 	mappingID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping ID"})
+		RespondBadRequest(c, ErrCodeInvalidParameter, "Invalid mapping ID")
 		return
 	}
 	
@@ -172,18 +208,19 @@ func (h *VerificationHandler) FlagMapping(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondBindingError(c, err)
 		return
 	}
 	
 	tx, err := h.db.Begin()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		RespondInternalError(c, ErrCodeDatabase, "Failed to start transaction")
 		return
 	}
 	defer tx.Rollback()
 	
 	// If a new token ID is provided, update the mapping
+	var result sql.Result
 	if req.NewTokenID > 0 {
 		updateQuery := `
 			UPDATE token_exchange_symbols
@@ -195,7 +232,7 @@ func (h *VerificationHandler) FlagMapping(c *gin.Context) {
 			    verified_at = NOW()
 			WHERE id = $1
 		`
-		_, err = tx.Exec(updateQuery, mappingID, req.NewTokenID, req.FlaggedBy)
+		result, err = tx.Exec(updateQuery, mappingID, req.NewTokenID, req.FlaggedBy)
 	} else {
 		// Otherwise, just mark it as needing more verification
 		updateQuery := `
@@ -204,12 +241,16 @@ func (h *VerificationHandler) FlagMapping(c *gin.Context) {
 			    needs_verification = true
 			WHERE id = $1
 		`
-		_, err = tx.Exec(updateQuery, mappingID)
+		result, err = tx.Exec(updateQuery, mappingID)
 	}
 	
 	if err != nil {
-		h.logger.Error("Failed to update mapping", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mapping"})
+		requestLogger(c, h.logger).Error("Failed to update mapping", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to update mapping")
+		return
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		RespondNotFound(c, "mapping_not_found", "Mapping not found")
 		return
 	}
 	
@@ -231,22 +272,27 @@ func (h *VerificationHandler) FlagMapping(c *gin.Context) {
 	tx.Exec(auditQuery, mappingID, req.FlaggedBy, req.Reason)
 	
 	if err := tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		RespondInternalError(c, ErrCodeDatabase, "Failed to commit transaction")
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Mapping flagged successfully",
-		"id":      mappingID,
-	})
+	RespondOKWithMessage(c, gin.H{"id": mappingID}, "Mapping flagged successfully")
 }
 
 // GetOutliers returns unresolved price outliers
+// @Summary List outliers
+// @Description Unresolved price outliers, largest deviation first, each with suggested fixes: remapping the exchange symbol to a token with the same symbol whose price on other exchanges matches, closest first, then disabling the pair. Apply one with POST /api/v1/admin/outliers/{id}/apply.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse "Outliers and total"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/outliers [get]
 func (h *VerificationHandler) GetOutliers(c *gin.Context) {
 	outliers, err := h.detector.GetUnresolvedOutliers()
 	if err != nil {
-		h.logger.Error("Failed to fetch outliers", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch outliers"})
+		requestLogger(c, h.logger).Error("Failed to fetch outliers", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to fetch outliers")
 		return
 	}
 	
@@ -257,6 +303,7 @@ func (h *VerificationHandler) GetOutliers(c *gin.Context) {
 		QuoteTokenSymbol string `json:"quote_token_symbol"`
 		BaseTokenName    string `json:"base_token_name"`
 		QuoteTokenName   string `json:"quote_token_name"`
+		Suggestions      []models.OutlierSuggestion `json:"suggestions"`
 	}
 	
 	var enrichedOutliers []EnrichedOutlier
@@ -275,20 +322,34 @@ func (h *VerificationHandler) GetOutliers(c *gin.Context) {
 			QuoteTokenSymbol: quoteSymbol,
 			BaseTokenName:    baseName,
 			QuoteTokenName:   quoteName,
+			Suggestions:      suggestionResponses(h.detector.Suggest(c.Request.Context(), o)),
 		})
 	}
 	
-	c.JSON(http.StatusOK, gin.H{
+	RespondOK(c, gin.H{
 		"outliers": enrichedOutliers,
 		"total":    len(enrichedOutliers),
 	})
 }
 
 // ResolveOutlier marks an outlier as resolved
+// @Summary Resolve outlier
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Outlier ID"
+// @Param request body object true "resolved_by and notes (required)"
+// @Success 200 {object} models.APIResponse "Resolved"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown admin API key"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Outlier not found"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/admin/outliers/{id}/resolve [post]
 func (h *VerificationHandler) ResolveOutlier(c *gin.Context) {
 	outlierID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid outlier ID"})
+		RespondBadRequest(c, ErrCodeInvalidParameter, "Invalid outlier ID")
 		return
 	}
 	
@@ -298,18 +359,19 @@ func (h *VerificationHandler) ResolveOutlier(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondBindingError(c, err)
 		return
 	}
 	
 	if err := h.detector.ResolveOutlier(outlierID, req.ResolvedBy, req.Notes); err != nil {
-		h.logger.Error("Failed to resolve outlier", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve outlier"})
+		if errors.Is(err, outlier.ErrOutlierNotFound) {
+			RespondNotFound(c, "outlier_not_found", "Outlier not found")
+			return
+		}
+		requestLogger(c, h.logger).Error("Failed to resolve outlier", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to resolve outlier")
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Outlier resolved successfully",
-		"id":      outlierID,
-	})
+	RespondOKWithMessage(c, gin.H{"id": outlierID}, "Outlier resolved successfully")
 }
\ No newline at end of file
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/volumeshare"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// VolumeShareHandler serves each exchange's daily share of observed volume
+type VolumeShareHandler struct {
+	postgresDB *sql.DB
+	shares     *volumeshare.Service
+	logger     *zap.Logger
+}
+
+// NewVolumeShareHandler creates a new volume share handler
+func NewVolumeShareHandler(postgresDB *sql.DB, shares *volumeshare.Service, logger *zap.Logger) *VolumeShareHandler {
+	return &VolumeShareHandler{
+		postgresDB: postgresDB,
+		shares:     shares,
+		logger:     logger,
+	}
+}
+
+// GetVolumeShare returns each exchange's share of a day's volume
+// @Summary Exchange volume share
+// @Description Each exchange's share of the 24h volume observed on a day, across all pairs valued in USD or, with base and quote, of one pair in the base token, next to its VWAP weight and its share of the VWAP (volume times weight). A share of the VWAP well above the share of volume means the weight overstates the exchange. Computed once a day at VOLUME_SHARE_AT UTC.
+// @Tags exchanges
+// @Produce json
+// @Param date query string false "UTC day (YYYY-MM-DD); defaults to the last day computed"
+// @Param base query string false "Base token symbol (e.g., BTC); requires quote"
+// @Param quote query string false "Quote token symbol (e.g., USDT); requires base"
+// @Success 200 {object} models.APIResponse{data=models.VolumeShareResponse} "Volume share per exchange"
+// @Failure 404 {object} models.ErrorResponse "Token not found or no volume share for the day"
+// @Failure 422 {object} models.ErrorResponse "Validation failed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/exchanges/volume-share [get]
+func (h *VolumeShareHandler) GetVolumeShare(c *gin.Context) {
+	v := NewRequestValidator(c)
+	var day time.Time
+	if raw := c.Query("date"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			v.Add("date", "Must be a date as YYYY-MM-DD")
+		}
+		day = parsed
+	}
+	base := strings.ToUpper(strings.TrimSpace(c.Query("base")))
+	quote := strings.ToUpper(strings.TrimSpace(c.Query("quote")))
+	if base != "" && !symbolPattern.MatchString(base) {
+		v.Add("base", "Base must be a token symbol")
+	}
+	if quote != "" && !symbolPattern.MatchString(quote) {
+		v.Add("quote", "Quote must be a token symbol")
+	}
+	if (base == "") != (quote == "") {
+		v.Add("quote", "base and quote must be given together")
+	}
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	ctx := c.Request.Context()
+	var baseID, quoteID int
+	if base != "" {
+		ids, err := db.GetTokenIDsBySymbol(ctx, h.postgresDB, []string{base, quote})
+		if err != nil {
+			requestLogger(c, h.logger).Error("Failed to resolve volume share tokens", zap.Error(err))
+			RespondInternalError(c, ErrCodeDatabase, "Failed to resolve token")
+			return
+		}
+		var okBase, okQuote bool
+		baseID, okBase = ids[base]
+		quoteID, okQuote = ids[quote]
+		if !okBase || !okQuote {
+			RespondNotFound(c, "token_not_found", "Token not found")
+			return
+		}
+	}
+
+	shares, day, err := h.shares.Shares(ctx, day, baseID, quoteID)
+	if errors.Is(err, db.ErrNoData) {
+		RespondNotFound(c, "volume_share_not_found", "No volume share computed for this day")
+		return
+	}
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load volume shares", zap.Error(err))
+		RespondServiceError(c, err, "Failed to retrieve volume share")
+		return
+	}
+
+	resp := models.VolumeShareResponse{
+		Date:      day.Format("2006-01-02"),
+		Base:      base,
+		Quote:     quote,
+		Exchanges: make([]models.ExchangeVolumeShare, 0, len(shares)),
+	}
+	for _, s := range shares {
+		resp.TotalVolumeUSD += s.VolumeUSD
+		e := models.ExchangeVolumeShare{
+			ExchangeID:   s.ExchangeID,
+			VolumeUSD:    s.VolumeUSD,
+			SharePct:     s.SharePct,
+			Weight:       s.Weight,
+			VWAPSharePct: s.VWAPSharePct,
+		}
+		if !s.Global() {
+			volume := s.Volume
+			e.Volume = &volume
+		}
+		resp.Exchanges = append(resp.Exchanges, e)
+	}
+	RespondOK(c, resp)
+}
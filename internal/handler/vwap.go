@@ -0,0 +1,472 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/arbitrage"
+	"github.com/ashmitsharp/trading/internal/calculator"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// VWAPHandler handles VWAP price endpoints
+type VWAPHandler struct {
+	postgresDB  *sql.DB
+	vwapStorage *storage.VWAPStorage
+	logger      *zap.Logger
+}
+
+// NewVWAPHandler creates a new VWAP handler
+func NewVWAPHandler(postgresDB *sql.DB, vwapStorage *storage.VWAPStorage, logger *zap.Logger) *VWAPHandler {
+	return &VWAPHandler{
+		postgresDB:  postgresDB,
+		vwapStorage: vwapStorage,
+		logger:      logger,
+	}
+}
+
+// GetVWAP returns the latest VWAP for a token against a quote token
+// @Summary Get latest VWAP
+// @Description Get the latest volume-weighted average price for a token across exchanges
+// @Tags vwap
+// @Produce json
+// @Param symbol path string true "Base token symbol (e.g., BTC)"
+// @Param quote query string false "Quote token symbol" default(USDT)
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} models.APIResponse{data=models.VWAPResponse} "Latest VWAP"
+// @Success 304 "Not modified since the ETag/Last-Modified given"
+// @Failure 404 {object} models.ErrorResponse "Token or VWAP not found"
+// @Failure 422 {object} models.ErrorResponse "Malformed symbol"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/vwap/{symbol} [get]
+func (h *VWAPHandler) GetVWAP(c *gin.Context) {
+	v := NewRequestValidator(c)
+	symbol := v.Symbol("symbol")
+	quote := strings.ToUpper(c.DefaultQuery("quote", "USDT"))
+	if !symbolPattern.MatchString(quote) {
+		v.Add("quote", "Quote must be a token symbol")
+	}
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	baseID, quoteID, ok := h.pairIDs(c, symbol, quote)
+	if !ok {
+		return
+	}
+
+	result, err := h.vwapStorage.GetLatestVWAP(ctx, baseID, quoteID)
+	if errors.Is(err, db.ErrNoData) {
+		RespondNotFound(c, "vwap_not_found", "No VWAP recorded for this pair")
+		return
+	}
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to get latest VWAP", zap.Error(err), zap.String("symbol", symbol))
+		RespondServiceError(c, err, "Failed to retrieve VWAP")
+		return
+	}
+
+	if CheckNotModified(c, result.Timestamp) {
+		return
+	}
+
+	RespondOK(c, models.VWAPResponse{
+		Symbol:         symbol,
+		Quote:          quote,
+		Price:          result.VWAPPrice,
+		Volume:         result.TotalVolume,
+		ExchangeCount:  result.ExchangeCount,
+		Exchanges:      result.ContributingExchanges,
+		SpreadBps:      result.SpreadBps,
+		LiquidityScore: result.LiquidityScore,
+		Indicative:     result.Indicative,
+		Timestamp:      result.Timestamp.Unix(),
+	})
+}
+
+// GetVWAPComposition returns which exchanges contributed to the latest VWAP
+// @Summary Get latest VWAP composition
+// @Description Get each exchange's price, volume and weight in the latest VWAP of a pair, with its share of the total weight. With fees=true each exchange whose taker fee is known also gets its effective buy and sell price after the fee, and the response gets the widest cross-exchange spread net of both legs' fees, since a raw spread of a few basis points is usually less than the fees.
+// @Tags vwap
+// @Produce json
+// @Param symbol path string true "Base token symbol (e.g., BTC)"
+// @Param quote query string false "Quote token symbol" default(USDT)
+// @Param fees query bool false "Add fee-adjusted prices and spread" default(false)
+// @Success 200 {object} models.APIResponse{data=models.VWAPCompositionResponse} "Latest VWAP composition"
+// @Success 304 "Not modified since the ETag/Last-Modified given"
+// @Failure 404 {object} models.ErrorResponse "Token or VWAP not found"
+// @Failure 422 {object} models.ErrorResponse "Malformed symbol"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/vwap/{symbol}/composition [get]
+func (h *VWAPHandler) GetVWAPComposition(c *gin.Context) {
+	v := NewRequestValidator(c)
+	symbol := v.Symbol("symbol")
+	quote := strings.ToUpper(c.DefaultQuery("quote", "USDT"))
+	if !symbolPattern.MatchString(quote) {
+		v.Add("quote", "Quote must be a token symbol")
+	}
+	withFees := v.Bool("fees", false)
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	baseID, quoteID, ok := h.pairIDs(c, symbol, quote)
+	if !ok {
+		return
+	}
+
+	result, err := h.vwapStorage.GetLatestVWAP(ctx, baseID, quoteID)
+	if errors.Is(err, db.ErrNoData) {
+		RespondNotFound(c, "vwap_not_found", "No VWAP recorded for this pair")
+		return
+	}
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to get latest VWAP", zap.Error(err), zap.String("symbol", symbol))
+		RespondServiceError(c, err, "Failed to retrieve VWAP")
+		return
+	}
+
+	if CheckNotModified(c, result.Timestamp) {
+		return
+	}
+
+	var fees map[string]decimal.Decimal
+	if withFees {
+		fees, err = db.GetPairTakerFees(ctx, h.postgresDB, baseID, quoteID)
+		if err != nil {
+			requestLogger(c, h.logger).Error("Failed to load pair fees", zap.Error(err), zap.String("symbol", symbol))
+			RespondInternalError(c, ErrCodeDatabase, "Failed to retrieve fees")
+			return
+		}
+	}
+
+	sources, err := h.vwapStorage.GetVWAPComposition(ctx, baseID, quoteID, result.Timestamp)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to get VWAP composition", zap.Error(err), zap.String("symbol", symbol))
+		RespondServiceError(c, err, "Failed to retrieve VWAP composition")
+		return
+	}
+
+	totalWeight := decimal.Zero
+	for _, src := range sources {
+		totalWeight = totalWeight.Add(src.Volume.Mul(src.Weight))
+	}
+
+	venues := make([]models.VWAPSource, 0, len(sources))
+	var quotes []arbitrage.Quote
+	for _, src := range sources {
+		share := 0.0
+		if totalWeight.IsPositive() {
+			share = src.Volume.Mul(src.Weight).Div(totalWeight).Mul(decimal.NewFromInt(100)).Round(4).InexactFloat64()
+		}
+		venue := models.VWAPSource{
+			Exchange: src.Exchange,
+			Price:    src.Price,
+			Volume:   src.Volume,
+			Weight:   src.Weight,
+			SharePct: share,
+		}
+		if fee, ok := fees[src.Exchange]; ok {
+			buy, sell := arbitrage.EffectiveBuy(src.Price, fee), arbitrage.EffectiveSell(src.Price, fee)
+			venue.TakerFee, venue.EffectiveBuyPrice, venue.EffectiveSellPrice = &fee, &buy, &sell
+			quotes = append(quotes, arbitrage.Quote{Exchange: src.Exchange, Price: src.Price, TakerFee: fee})
+		}
+		venues = append(venues, venue)
+	}
+
+	resp := models.VWAPCompositionResponse{
+		Symbol:     symbol,
+		Quote:      quote,
+		Price:      result.VWAPPrice,
+		Indicative: result.Indicative,
+		Sources:    venues,
+		Timestamp:  result.Timestamp.Unix(),
+	}
+	if best, ok := arbitrage.Best(quotes); ok {
+		resp.Arbitrage = &models.ArbitrageSpread{
+			BuyExchange:    best.BuyExchange,
+			SellExchange:   best.SellExchange,
+			BuyPrice:       best.BuyPrice,
+			SellPrice:      best.SellPrice,
+			GrossSpreadBps: best.GrossBps,
+			NetSpreadBps:   best.NetBps,
+		}
+	}
+	RespondOK(c, resp)
+}
+
+// GetVWAPAt returns a pair's VWAP at a point in time
+// @Summary Get VWAP at a timestamp
+// @Description Get the VWAP of a pair as of Unix time ts, for point-in-time valuations. method=nearest (default) takes the sample closest to ts, previous the last one at or before it, and linear interpolates between the samples either side of it. Only samples within tolerance seconds of ts are used; linear falls back to nearest when there is a sample on one side only, and method in the response says which was applied. Raw VWAP samples are kept for 30 days.
+// @Tags vwap
+// @Produce json
+// @Param symbol path string true "Base token symbol (e.g., BTC)"
+// @Param ts query int true "Unix timestamp in seconds"
+// @Param quote query string false "Quote token symbol" default(USDT)
+// @Param method query string false "How to pick the price" Enums(nearest, previous, linear) default(nearest)
+// @Param tolerance query int false "Furthest a sample may be from ts, in seconds" default(3600) minimum(1) maximum(86400)
+// @Success 200 {object} models.APIResponse{data=models.VWAPAtResponse} "VWAP at ts"
+// @Failure 404 {object} models.ErrorResponse "Token not found or no VWAP within tolerance"
+// @Failure 422 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/vwap/{symbol}/at [get]
+func (h *VWAPHandler) GetVWAPAt(c *gin.Context) {
+	v := NewRequestValidator(c)
+	symbol := v.Symbol("symbol")
+	quote := strings.ToUpper(c.DefaultQuery("quote", "USDT"))
+	if !symbolPattern.MatchString(quote) {
+		v.Add("quote", "Quote must be a token symbol")
+	}
+	if c.Query("ts") == "" {
+		v.Add("ts", "Timestamp is required")
+	}
+	ts := v.Timestamp("ts", 0).Time()
+	if ts.After(time.Now()) {
+		v.Add("ts", "Timestamp must not be in the future")
+	}
+	method := c.DefaultQuery("method", "nearest")
+	if method != "nearest" && method != "previous" && method != "linear" {
+		v.Add("method", "Method must be one of: nearest, previous, linear")
+	}
+	tolerance := time.Duration(v.IntRange("tolerance", 3600, 1, 86400)) * time.Second
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	baseID, quoteID, ok := h.pairIDs(c, symbol, quote)
+	if !ok {
+		return
+	}
+
+	before, after, err := h.vwapStorage.GetVWAPAround(ctx, baseID, quoteID, ts, tolerance)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to get VWAP at timestamp", zap.Error(err), zap.String("symbol", symbol))
+		RespondServiceError(c, err, "Failed to retrieve VWAP")
+		return
+	}
+	if method == "previous" {
+		after = nil
+	}
+	if before == nil && after == nil {
+		RespondNotFound(c, "vwap_not_found", "No VWAP recorded within tolerance of this timestamp")
+		return
+	}
+
+	price, nearest, used, samples := vwapAt(before, after, ts, method)
+	resp := models.VWAPAtResponse{
+		Symbol:             symbol,
+		Quote:              quote,
+		Price:              price,
+		Method:             used,
+		RequestedTimestamp: ts.Unix(),
+		Timestamp:          nearest.Timestamp.Unix(),
+		ExchangeCount:      nearest.ExchangeCount,
+		Exchanges:          nearest.ContributingExchanges,
+		Samples:            make([]models.VWAPPoint, 0, len(samples)),
+	}
+	if used == "linear" {
+		resp.Timestamp = ts.Unix()
+	}
+	if resp.Exchanges == nil {
+		resp.Exchanges = []string{}
+	}
+	for _, s := range samples {
+		resp.Indicative = resp.Indicative || s.Indicative
+		resp.Samples = append(resp.Samples, models.VWAPPoint{Price: s.VWAPPrice, Timestamp: s.Timestamp.Unix()})
+	}
+	RespondOK(c, resp)
+}
+
+// vwapAt prices ts from the samples either side of it, at least one of which
+// is set. It returns the price, the sample nearest ts, the method applied and
+// the samples the price came from.
+func vwapAt(before, after *calculator.VWAPResult, ts time.Time, method string) (decimal.Decimal, *calculator.VWAPResult, string, []*calculator.VWAPResult) {
+	nearest := before
+	if before == nil || (after != nil && after.Timestamp.Sub(ts) < ts.Sub(before.Timestamp)) {
+		nearest = after
+	}
+
+	if method != "linear" || before == nil || after == nil || before.Timestamp.Equal(ts) {
+		if method == "linear" {
+			method = "nearest"
+		}
+		return nearest.VWAPPrice, nearest, method, []*calculator.VWAPResult{nearest}
+	}
+
+	span := decimal.NewFromInt(after.Timestamp.Sub(before.Timestamp).Milliseconds())
+	elapsed := decimal.NewFromInt(ts.Sub(before.Timestamp).Milliseconds())
+	price := before.VWAPPrice.Add(after.VWAPPrice.Sub(before.VWAPPrice).Mul(elapsed).Div(span)).Round(8)
+	return price, nearest, method, []*calculator.VWAPResult{before, after}
+}
+
+// pairIDs resolves the base and quote symbols to token IDs, responding with an
+// error and returning false if either cannot be resolved
+func (h *VWAPHandler) pairIDs(c *gin.Context, symbol, quote string) (int, int, bool) {
+	ctx := c.Request.Context()
+
+	var baseID, quoteID int
+	err := h.postgresDB.QueryRowContext(ctx, `SELECT id FROM tokens WHERE symbol = $1`, symbol).Scan(&baseID)
+	if err == nil {
+		err = h.postgresDB.QueryRowContext(ctx, `SELECT id FROM tokens WHERE symbol = $1`, quote).Scan(&quoteID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondNotFound(c, "token_not_found", "Token not found")
+		return 0, 0, false
+	}
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to resolve VWAP tokens", zap.Error(err), zap.String("symbol", symbol))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to resolve token")
+		return 0, 0, false
+	}
+	return baseID, quoteID, true
+}
+
+// ListVWAP lists the latest VWAP of every pair, most liquid first
+// @Summary List latest VWAP by pair
+// @Description List the latest VWAP of every pair against the given quotes, ordered by liquidity score. Use min_liquidity to exclude illiquid pairs. Pairs below the exchange/volume quorum are flagged indicative; set include_indicative=false to exclude them.
+// @Tags vwap
+// @Produce json
+// @Param quote query string false "Quote token symbol; defaults to all USD quotes"
+// @Param min_liquidity query int false "Minimum liquidity score" default(0) minimum(0) maximum(100)
+// @Param include_indicative query bool false "Include pairs below the VWAP quorum" default(true)
+// @Param limit query int false "Maximum number of pairs" default(100) minimum(1) maximum(1000)
+// @Success 200 {object} models.APIResponse{data=[]models.VWAPPairResponse} "Latest VWAP per pair"
+// @Failure 404 {object} models.ErrorResponse "Quote token not found"
+// @Failure 422 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/vwap [get]
+func (h *VWAPHandler) ListVWAP(c *gin.Context) {
+	v := NewRequestValidator(c)
+	minLiquidity := v.IntRange("min_liquidity", 0, 0, 100)
+	limit := v.IntRange("limit", 100, 1, 1000)
+	includeIndicative := v.Bool("include_indicative", true)
+	quote := strings.ToUpper(c.Query("quote"))
+	if quote != "" && !symbolPattern.MatchString(quote) {
+		v.Add("quote", "Quote must be a token symbol")
+	}
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	var quoteIDs []int
+	var err error
+	if quote == "" {
+		quoteIDs, err = db.GetUSDQuoteTokenIDs(ctx, h.postgresDB)
+	} else {
+		var quoteID int
+		err = h.postgresDB.QueryRowContext(ctx, `SELECT id FROM tokens WHERE symbol = $1`, quote).Scan(&quoteID)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondNotFound(c, "token_not_found", "Quote token not found")
+			return
+		}
+		quoteIDs = []int{quoteID}
+	}
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to resolve quote tokens", zap.Error(err), zap.String("quote", quote))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to resolve quote token")
+		return
+	}
+
+	summaries, err := h.vwapStorage.GetLatestPairVWAPs(ctx, quoteIDs, float64(minLiquidity))
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to list latest VWAP", zap.Error(err))
+		RespondServiceError(c, err, "Failed to retrieve VWAP")
+		return
+	}
+
+	if !includeIndicative {
+		composite := summaries[:0]
+		for _, s := range summaries {
+			if !s.Indicative {
+				composite = append(composite, s)
+			}
+		}
+		summaries = composite
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].LiquidityScore != summaries[j].LiquidityScore {
+			return summaries[i].LiquidityScore > summaries[j].LiquidityScore
+		}
+		return summaries[i].Volume.GreaterThan(summaries[j].Volume)
+	})
+	if len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+
+	symbols, err := h.tokenSymbols(ctx, summaries)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load token symbols", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to resolve token symbols")
+		return
+	}
+
+	pairs := make([]models.VWAPPairResponse, 0, len(summaries))
+	for _, s := range summaries {
+		pairs = append(pairs, models.VWAPPairResponse{
+			Symbol:         symbols[s.BaseTokenID],
+			Quote:          symbols[s.QuoteTokenID],
+			Price:          s.Price,
+			Volume:         s.Volume,
+			ExchangeCount:  s.ExchangeCount,
+			SpreadBps:      s.SpreadBps,
+			LiquidityScore: s.LiquidityScore,
+			Indicative:     s.Indicative,
+			Timestamp:      s.LastUpdate.Unix(),
+		})
+	}
+
+	RespondOK(c, pairs)
+}
+
+// tokenSymbols maps the base and quote token IDs of summaries to symbols
+func (h *VWAPHandler) tokenSymbols(ctx context.Context, summaries []*storage.VWAPSummary) (map[int]string, error) {
+	ids := make([]int64, 0, len(summaries)*2)
+	for _, s := range summaries {
+		ids = append(ids, int64(s.BaseTokenID), int64(s.QuoteTokenID))
+	}
+
+	symbols := make(map[int]string, len(ids))
+	if len(ids) == 0 {
+		return symbols, nil
+	}
+
+	rows, err := h.postgresDB.QueryContext(ctx, `SELECT id, symbol FROM tokens WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query token symbols: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var symbol string
+		if err := rows.Scan(&id, &symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan token symbol: %w", err)
+		}
+		symbols[id] = symbol
+	}
+	return symbols, rows.Err()
+}
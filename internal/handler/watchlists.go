@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WatchlistHandler serves the watchlists of API clients. Routes must sit
+// behind RequireAPIKey, which identifies the caller.
+type WatchlistHandler struct {
+	postgresDB  *sql.DB
+	vwapStorage *storage.VWAPStorage
+	logger      *zap.Logger
+}
+
+// NewWatchlistHandler creates a new watchlist handler
+func NewWatchlistHandler(postgresDB *sql.DB, vwapStorage *storage.VWAPStorage, logger *zap.Logger) *WatchlistHandler {
+	return &WatchlistHandler{
+		postgresDB:  postgresDB,
+		vwapStorage: vwapStorage,
+		logger:      logger,
+	}
+}
+
+// WatchlistRequest is the body of creating or replacing a watchlist
+type WatchlistRequest struct {
+	Name    string   `json:"name" binding:"required,max=100"`
+	Symbols []string `json:"symbols" binding:"max=200,dive,required,max=32"`
+}
+
+// ListWatchlists lists the caller's watchlists
+// @Summary List watchlists
+// @Description List the watchlists of the API key in X-API-Key, by name
+// @Tags watchlists
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Success 200 {object} models.APIResponse{data=[]models.WatchlistResponse} "Watchlists"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown API key"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/watchlists [get]
+func (h *WatchlistHandler) ListWatchlists(c *gin.Context) {
+	lists, err := db.ListWatchlists(c.Request.Context(), h.postgresDB, APIKeyOwner(c))
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve watchlists")
+		return
+	}
+	resp := make([]models.WatchlistResponse, 0, len(lists))
+	for _, w := range lists {
+		resp = append(resp, watchlistResponse(w))
+	}
+	RespondOK(c, resp)
+}
+
+// GetWatchlist returns one of the caller's watchlists
+// @Summary Get watchlist
+// @Tags watchlists
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Param id path int true "Watchlist ID"
+// @Success 200 {object} models.APIResponse{data=models.WatchlistResponse} "Watchlist"
+// @Failure 400 {object} models.ErrorResponse "Invalid watchlist ID"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown API key"
+// @Failure 404 {object} models.ErrorResponse "Watchlist not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/watchlists/{id} [get]
+func (h *WatchlistHandler) GetWatchlist(c *gin.Context) {
+	id, ok := idParam(c, "Invalid watchlist ID")
+	if !ok {
+		return
+	}
+	w, err := db.GetWatchlist(c.Request.Context(), h.postgresDB, APIKeyOwner(c), id)
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve watchlist")
+		return
+	}
+	RespondOK(c, watchlistResponse(w))
+}
+
+// CreateWatchlist creates a watchlist
+// @Summary Create watchlist
+// @Description Create a watchlist of up to 200 tokens, given by symbol, for the API key in X-API-Key. Names are unique per key.
+// @Tags watchlists
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Param request body WatchlistRequest true "Watchlist"
+// @Success 200 {object} models.APIResponse{data=models.WatchlistResponse} "Created"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown API key"
+// @Failure 409 {object} models.ErrorResponse "A watchlist of that name exists"
+// @Failure 422 {object} models.ErrorResponse "Validation failed or unknown symbols"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/watchlists [post]
+func (h *WatchlistHandler) CreateWatchlist(c *gin.Context) {
+	var req WatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+	tokenIDs, ok := resolveSymbols(c, h.postgresDB, h.logger, req.Symbols)
+	if !ok {
+		return
+	}
+
+	w, err := db.CreateWatchlist(c.Request.Context(), h.postgresDB, APIKeyOwner(c), strings.TrimSpace(req.Name), tokenIDs)
+	if err != nil {
+		h.respondError(c, err, "Failed to create watchlist")
+		return
+	}
+	RespondOKWithMessage(c, watchlistResponse(w), "Watchlist created successfully")
+}
+
+// UpdateWatchlist replaces one of the caller's watchlists
+// @Summary Update watchlist
+// @Description Rename watchlist {id} and replace its tokens
+// @Tags watchlists
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Param id path int true "Watchlist ID"
+// @Param request body WatchlistRequest true "Watchlist"
+// @Success 200 {object} models.APIResponse{data=models.WatchlistResponse} "Updated"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown API key"
+// @Failure 404 {object} models.ErrorResponse "Watchlist not found"
+// @Failure 409 {object} models.ErrorResponse "A watchlist of that name exists"
+// @Failure 422 {object} models.ErrorResponse "Validation failed or unknown symbols"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/watchlists/{id} [put]
+func (h *WatchlistHandler) UpdateWatchlist(c *gin.Context) {
+	id, ok := idParam(c, "Invalid watchlist ID")
+	if !ok {
+		return
+	}
+	var req WatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+	tokenIDs, ok := resolveSymbols(c, h.postgresDB, h.logger, req.Symbols)
+	if !ok {
+		return
+	}
+
+	w, err := db.UpdateWatchlist(c.Request.Context(), h.postgresDB, APIKeyOwner(c), id, strings.TrimSpace(req.Name), tokenIDs)
+	if err != nil {
+		h.respondError(c, err, "Failed to update watchlist")
+		return
+	}
+	RespondOK(c, watchlistResponse(w))
+}
+
+// DeleteWatchlist deletes one of the caller's watchlists
+// @Summary Delete watchlist
+// @Tags watchlists
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Param id path int true "Watchlist ID"
+// @Success 200 {object} models.APIResponse "Deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid watchlist ID"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown API key"
+// @Failure 404 {object} models.ErrorResponse "Watchlist not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/watchlists/{id} [delete]
+func (h *WatchlistHandler) DeleteWatchlist(c *gin.Context) {
+	id, ok := idParam(c, "Invalid watchlist ID")
+	if !ok {
+		return
+	}
+	if err := db.DeleteWatchlist(c.Request.Context(), h.postgresDB, APIKeyOwner(c), id); err != nil {
+		h.respondError(c, err, "Failed to delete watchlist")
+		return
+	}
+	RespondOKWithMessage(c, gin.H{"id": id}, "Watchlist deleted successfully")
+}
+
+// GetWatchlistQuotes returns the latest prices of a watchlist's tokens
+// @Summary Get watchlist quotes
+// @Description Get the latest VWAP of every token in watchlist {id}, in list order, each against its highest-volume USD pair (or quote) with a VWAP in the last 24h. Tokens without one carry an error instead of a price, as in POST /api/v1/prices/batch.
+// @Tags watchlists
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Param id path int true "Watchlist ID"
+// @Param quote query string false "Quote token symbol; defaults to the USD quotes"
+// @Success 200 {object} models.APIResponse{data=models.WatchlistQuotesResponse} "Quotes"
+// @Failure 400 {object} models.ErrorResponse "Invalid watchlist ID"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown API key"
+// @Failure 404 {object} models.ErrorResponse "Watchlist or quote token not found"
+// @Failure 422 {object} models.ErrorResponse "Malformed quote"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/watchlists/{id}/quotes [get]
+func (h *WatchlistHandler) GetWatchlistQuotes(c *gin.Context) {
+	id, ok := idParam(c, "Invalid watchlist ID")
+	if !ok {
+		return
+	}
+	v := NewRequestValidator(c)
+	quote := strings.ToUpper(c.Query("quote"))
+	if quote != "" && !symbolPattern.MatchString(quote) {
+		v.Add("quote", "Quote must be a token symbol")
+	}
+	if !v.Valid() {
+		v.Respond()
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	w, err := db.GetWatchlist(ctx, h.postgresDB, APIKeyOwner(c), id)
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve watchlist")
+		return
+	}
+
+	quoteSymbols, err := quoteTokens(ctx, h.postgresDB, quote)
+	if err != nil {
+		h.respondError(c, err, "Failed to resolve quote token")
+		return
+	}
+	if len(quoteSymbols) == 0 {
+		RespondNotFound(c, "token_not_found", "Quote token not found")
+		return
+	}
+
+	latest, err := h.latestPrices(ctx, quoteSymbols)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to load watchlist prices", zap.Error(err), zap.Int("watchlist_id", id))
+		RespondServiceError(c, err, "Failed to retrieve prices")
+		return
+	}
+
+	resp := models.WatchlistQuotesResponse{ID: w.ID, Name: w.Name, Quotes: make([]models.BatchPrice, 0, len(w.Tokens))}
+	for _, t := range w.Tokens {
+		if summary := latest[t.ID]; summary != nil {
+			resp.Quotes = append(resp.Quotes, summaryPrice(t.Symbol, t.ID, summary, quoteSymbols))
+			continue
+		}
+		resp.Quotes = append(resp.Quotes, models.BatchPrice{
+			Symbol:  t.Symbol,
+			TokenID: t.ID,
+			Error:   &models.BatchPriceError{Code: "price_not_found", Message: "No VWAP recorded in the last 24h"},
+		})
+	}
+	RespondOK(c, resp)
+}
+
+func (h *WatchlistHandler) latestPrices(ctx context.Context, quoteSymbols map[int]string) (map[int]*storage.VWAPSummary, error) {
+	quoteIDs := make([]int, 0, len(quoteSymbols))
+	for id := range quoteSymbols {
+		quoteIDs = append(quoteIDs, id)
+	}
+	return h.vwapStorage.GetLatestVWAPByQuote(ctx, quoteIDs)
+}
+
+// resolveSymbols maps symbols to token IDs in order, responding with 422 and
+// returning false if any is unknown
+func resolveSymbols(c *gin.Context, postgresDB *sql.DB, logger *zap.Logger, symbols []string) ([]int, bool) {
+	normalized := make([]string, len(symbols))
+	for i, s := range symbols {
+		normalized[i] = strings.ToUpper(strings.TrimSpace(s))
+	}
+	ids, err := db.GetTokenIDsBySymbol(c.Request.Context(), postgresDB, normalized)
+	if err != nil {
+		requestLogger(c, logger).Error("Failed to resolve tokens", zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, "Failed to resolve tokens")
+		return nil, false
+	}
+
+	tokenIDs := make([]int, 0, len(normalized))
+	var unknown []models.FieldError
+	for _, s := range normalized {
+		id, ok := ids[s]
+		if !ok {
+			unknown = append(unknown, models.FieldError{Field: "symbols", Message: "Unknown token: " + s})
+			continue
+		}
+		tokenIDs = append(tokenIDs, id)
+	}
+	if len(unknown) > 0 {
+		RespondValidationErrors(c, unknown)
+		return nil, false
+	}
+	return tokenIDs, true
+}
+
+// respondError shows not-found and conflict errors to the client and a
+// generic message otherwise
+func (h *WatchlistHandler) respondError(c *gin.Context, err error, message string) {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c, h.logger).Error(message, zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, message)
+		return
+	}
+	RespondError(c, status, code, err.Error())
+}
+
+func watchlistResponse(w db.Watchlist) models.WatchlistResponse {
+	r := models.WatchlistResponse{
+		ID:        w.ID,
+		Name:      w.Name,
+		Tokens:    make([]models.WatchlistToken, 0, len(w.Tokens)),
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+	for _, t := range w.Tokens {
+		r.Tokens = append(r.Tokens, models.WatchlistToken{ID: t.ID, Symbol: t.Symbol})
+	}
+	return r
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/webhooks"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultWebhookInterval is the minimum interval of a webhook that does not
+// set one
+const defaultWebhookInterval = 60
+
+// WebhookHandler serves the webhook subscriptions of API clients. Routes must
+// sit behind RequireAPIKey, which identifies the caller.
+type WebhookHandler struct {
+	postgresDB *sql.DB
+	dispatcher *webhooks.Dispatcher
+	logger     *zap.Logger
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(postgresDB *sql.DB, dispatcher *webhooks.Dispatcher, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		postgresDB: postgresDB,
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+// WebhookRequest is the body of creating a webhook
+type WebhookRequest struct {
+	URL                string   `json:"url" binding:"required,max=2048"`
+	Symbols            []string `json:"symbols" binding:"required,min=1,max=100,dive,required,max=32"`
+	MinIntervalSeconds int      `json:"min_interval_seconds" binding:"omitempty,min=10,max=86400"`
+}
+
+// ListWebhooks lists the caller's webhooks
+// @Summary List webhooks
+// @Description List the webhooks of the API key in X-API-Key, oldest first. Secrets are not returned.
+// @Tags webhooks
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Success 200 {object} models.APIResponse{data=[]models.WebhookResponse} "Webhooks"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown API key"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	hooks, err := db.ListWebhooks(c.Request.Context(), h.postgresDB, APIKeyOwner(c))
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve webhooks")
+		return
+	}
+	resp := make([]models.WebhookResponse, 0, len(hooks))
+	for _, w := range hooks {
+		resp = append(resp, webhookResponse(w))
+	}
+	RespondOK(c, resp)
+}
+
+// GetWebhook returns one of the caller's webhooks
+// @Summary Get webhook
+// @Tags webhooks
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.APIResponse{data=models.WebhookResponse} "Webhook"
+// @Failure 400 {object} models.ErrorResponse "Invalid webhook ID"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown API key"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := idParam(c, "Invalid webhook ID")
+	if !ok {
+		return
+	}
+	w, err := db.GetWebhook(c.Request.Context(), h.postgresDB, APIKeyOwner(c), id)
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve webhook")
+		return
+	}
+	RespondOK(c, webhookResponse(w))
+}
+
+// CreateWebhook subscribes a callback URL to price updates
+// @Summary Create webhook
+// @Description Register a URL to be POSTed the latest USD VWAPs of up to 100 tokens, at most every min_interval_seconds (10-86400, default 60) and only when a price is new. Each POST carries a webhooks.Payload signed in the X-Webhook-Signature header as "sha256=" + hex HMAC-SHA256 of X-Webhook-Timestamp + "." + body, keyed by the returned secret, which is not shown again. Non-2xx responses are retried with backoff; a webhook that keeps failing is deactivated. URLs must not point at private addresses.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Param request body WebhookRequest true "Webhook"
+// @Success 200 {object} models.APIResponse{data=models.WebhookResponse} "Created, with its secret"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown API key"
+// @Failure 422 {object} models.ErrorResponse "Validation failed, disallowed URL or unknown symbols"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBindingError(c, err)
+		return
+	}
+	if err := h.dispatcher.ValidateURL(req.URL); err != nil {
+		RespondValidationErrors(c, []models.FieldError{{Field: "url", Message: err.Error()}})
+		return
+	}
+	tokenIDs, ok := resolveSymbols(c, h.postgresDB, h.logger, req.Symbols)
+	if !ok {
+		return
+	}
+	interval := req.MinIntervalSeconds
+	if interval == 0 {
+		interval = defaultWebhookInterval
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to create webhook secret", zap.Error(err))
+		RespondInternalError(c, ErrCodeInternal, "Failed to create webhook")
+		return
+	}
+	w, err := db.CreateWebhook(c.Request.Context(), h.postgresDB, db.Webhook{
+		Owner:       APIKeyOwner(c),
+		URL:         req.URL,
+		Secret:      secret,
+		MinInterval: time.Duration(interval) * time.Second,
+	}, tokenIDs)
+	if err != nil {
+		h.respondError(c, err, "Failed to create webhook")
+		return
+	}
+	h.dispatcher.Reload()
+
+	resp := webhookResponse(w)
+	resp.Secret = w.Secret
+	RespondOKWithMessage(c, resp, "Webhook created successfully")
+}
+
+// DeleteWebhook deletes one of the caller's webhooks
+// @Summary Delete webhook
+// @Tags webhooks
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.APIResponse "Deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid webhook ID"
+// @Failure 401 {object} models.ErrorResponse "Missing or unknown API key"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := idParam(c, "Invalid webhook ID")
+	if !ok {
+		return
+	}
+	if err := db.DeleteWebhook(c.Request.Context(), h.postgresDB, APIKeyOwner(c), id); err != nil {
+		h.respondError(c, err, "Failed to delete webhook")
+		return
+	}
+	h.dispatcher.Reload()
+	RespondOKWithMessage(c, gin.H{"id": id}, "Webhook deleted successfully")
+}
+
+// respondError shows not-found errors to the client and a generic message
+// otherwise
+func (h *WebhookHandler) respondError(c *gin.Context, err error, message string) {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c, h.logger).Error(message, zap.Error(err))
+		RespondInternalError(c, ErrCodeDatabase, message)
+		return
+	}
+	RespondError(c, status, code, err.Error())
+}
+
+func webhookResponse(w db.Webhook) models.WebhookResponse {
+	r := models.WebhookResponse{
+		ID:                  w.ID,
+		URL:                 w.URL,
+		Symbols:             make([]string, 0, len(w.Tokens)),
+		MinIntervalSeconds:  int(w.MinInterval / time.Second),
+		Active:              w.Active,
+		ConsecutiveFailures: w.ConsecutiveFailures,
+		LastError:           w.LastError,
+		LastDeliveredAt:     w.LastDeliveredAt,
+		CreatedAt:           w.CreatedAt,
+	}
+	for _, t := range w.Tokens {
+		r.Symbols = append(r.Symbols, t.Symbol)
+	}
+	return r
+}
@@ -0,0 +1,161 @@
+package indices
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// Weighting schemes an index can use
+const (
+	WeightingMarketCap = "market_cap"
+	WeightingEqual     = "equal"
+	WeightingFixed     = "fixed"
+)
+
+// ErrIndexNotFound is returned when no index matches an ID or slug
+var ErrIndexNotFound = errors.New("index not found")
+
+// Index is a basket definition together with its current constituents
+type Index struct {
+	ID               int
+	Slug             string
+	Name             string
+	Description      string
+	Weighting        string
+	TopN             int // 0 when constituents are configured explicitly
+	RebalanceDays    int
+	BaseLevel        decimal.Decimal
+	LastRebalancedAt sql.NullTime
+	IsActive         bool
+	Constituents     []Constituent
+}
+
+// Constituent is a token held by an index. Units are set at each rebalance and
+// are invalid until the first one.
+type Constituent struct {
+	TokenID int
+	Symbol  string
+	Weight  decimal.NullDecimal
+	Units   decimal.NullDecimal
+}
+
+// LoadIndices loads every index, or only active ones, with constituents
+func LoadIndices(ctx context.Context, db *sql.DB, activeOnly bool) ([]*Index, error) {
+	query := `
+		SELECT id, slug, name, COALESCE(description, ''), weighting, COALESCE(top_n, 0),
+		       rebalance_days, base_level, last_rebalanced_at, is_active
+		FROM indices
+	`
+	if activeOnly {
+		query += ` WHERE is_active = true`
+	}
+	query += ` ORDER BY id`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indices: %w", err)
+	}
+	defer rows.Close()
+
+	var indices []*Index
+	byID := make(map[int]*Index)
+	for rows.Next() {
+		idx := &Index{}
+		if err := rows.Scan(&idx.ID, &idx.Slug, &idx.Name, &idx.Description, &idx.Weighting, &idx.TopN,
+			&idx.RebalanceDays, &idx.BaseLevel, &idx.LastRebalancedAt, &idx.IsActive); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		indices = append(indices, idx)
+		byID[idx.ID] = idx
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadConstituents(ctx, db, byID); err != nil {
+		return nil, err
+	}
+	return indices, nil
+}
+
+// LoadIndex loads a single index by numeric ID or slug
+func LoadIndex(ctx context.Context, db *sql.DB, ident string) (*Index, error) {
+	indices, err := LoadIndices(ctx, db, false)
+	if err != nil {
+		return nil, err
+	}
+	id, idErr := strconv.Atoi(ident)
+	for _, idx := range indices {
+		if (idErr == nil && idx.ID == id) || idx.Slug == ident {
+			return idx, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrIndexNotFound, ident)
+}
+
+func loadConstituents(ctx context.Context, db *sql.DB, byID map[int]*Index) error {
+	if len(byID) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, int64(id))
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT ic.index_id, ic.token_id, t.symbol, ic.weight, ic.units
+		FROM index_constituents ic
+		JOIN tokens t ON t.id = ic.token_id
+		WHERE ic.index_id = ANY($1)
+		ORDER BY ic.index_id, ic.weight DESC NULLS LAST, t.symbol
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query index constituents: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var indexID int
+		var c Constituent
+		if err := rows.Scan(&indexID, &c.TokenID, &c.Symbol, &c.Weight, &c.Units); err != nil {
+			return fmt.Errorf("failed to scan index constituent: %w", err)
+		}
+		byID[indexID].Constituents = append(byID[indexID].Constituents, c)
+	}
+	return rows.Err()
+}
+
+// saveRebalance replaces an index's constituents and marks it rebalanced
+func saveRebalance(ctx context.Context, db *sql.DB, indexID int, constituents []Constituent, at time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM index_constituents WHERE index_id = $1`, indexID); err != nil {
+		return fmt.Errorf("failed to clear index constituents: %w", err)
+	}
+	for _, c := range constituents {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO index_constituents (index_id, token_id, weight, units)
+			VALUES ($1, $2, $3, $4)
+		`, indexID, c.TokenID, c.Weight, c.Units); err != nil {
+			return fmt.Errorf("failed to insert index constituent: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE indices SET last_rebalanced_at = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1
+	`, indexID, at); err != nil {
+		return fmt.Errorf("failed to mark index rebalanced: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,282 @@
+package indices
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Service computes index levels from the latest USD VWAPs and rebalances
+// indices when their rules call for it
+type Service struct {
+	postgresDB   *sql.DB
+	vwapStorage  *storage.VWAPStorage
+	indexStorage *storage.IndexStorage
+	logger       *zap.Logger
+}
+
+// NewService creates a new index service
+func NewService(postgresDB *sql.DB, vwapStorage *storage.VWAPStorage, indexStorage *storage.IndexStorage, logger *zap.Logger) *Service {
+	return &Service{
+		postgresDB:   postgresDB,
+		vwapStorage:  vwapStorage,
+		indexStorage: indexStorage,
+		logger:       logger,
+	}
+}
+
+// ComputeAndStore rebalances any index that is due, then computes and stores
+// the level of every active index. An index whose constituents are not all
+// priced is skipped for this cycle rather than stored with a gap.
+func (s *Service) ComputeAndStore(ctx context.Context) error {
+	indices, err := LoadIndices(ctx, s.postgresDB, true)
+	if err != nil {
+		return err
+	}
+	if len(indices) == 0 {
+		return nil
+	}
+
+	quoteIDs, err := db.GetUSDQuoteTokenIDs(ctx, s.postgresDB)
+	if err != nil {
+		return err
+	}
+	summaries, err := s.vwapStorage.GetLatestVWAPByQuote(ctx, quoteIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get USD VWAP prices: %w", err)
+	}
+	prices := make(map[int]decimal.Decimal, len(summaries))
+	for id, summary := range summaries {
+		prices[id] = summary.Price
+	}
+
+	now := time.Now()
+	levels := make([]storage.IndexLevel, 0, len(indices))
+	for _, idx := range indices {
+		if NeedsRebalance(idx, now) {
+			if err := s.rebalance(ctx, idx, prices, now); err != nil {
+				s.logger.Warn("Failed to rebalance index", zap.String("index", idx.Slug), zap.Error(err))
+				continue
+			}
+		}
+
+		level, ok := Level(idx.Constituents, prices)
+		if !ok {
+			s.logger.Warn("Skipping index level, constituent prices missing", zap.String("index", idx.Slug))
+			continue
+		}
+		levels = append(levels, storage.IndexLevel{
+			IndexID:          idx.ID,
+			Level:            level.Round(8),
+			ConstituentCount: len(idx.Constituents),
+			Timestamp:        now,
+		})
+	}
+
+	if err := s.indexStorage.StoreIndexLevels(ctx, levels); err != nil {
+		return fmt.Errorf("failed to store index levels: %w", err)
+	}
+	return nil
+}
+
+// NeedsRebalance reports whether an index has never been rebalanced, has
+// constituents without units, or is past its rebalance interval
+func NeedsRebalance(idx *Index, now time.Time) bool {
+	if !idx.LastRebalancedAt.Valid || len(idx.Constituents) == 0 {
+		return true
+	}
+	for _, c := range idx.Constituents {
+		if !c.Units.Valid {
+			return true
+		}
+	}
+	return now.Sub(idx.LastRebalancedAt.Time) >= time.Duration(idx.RebalanceDays)*24*time.Hour
+}
+
+// Level returns sum(units * price) over the constituents, or false if any
+// constituent lacks units or a price
+func Level(constituents []Constituent, prices map[int]decimal.Decimal) (decimal.Decimal, bool) {
+	if len(constituents) == 0 {
+		return decimal.Zero, false
+	}
+	level := decimal.Zero
+	for _, c := range constituents {
+		price, ok := prices[c.TokenID]
+		if !ok || !c.Units.Valid {
+			return decimal.Zero, false
+		}
+		level = level.Add(c.Units.Decimal.Mul(price))
+	}
+	return level, true
+}
+
+// Weights returns the target weight of each candidate under the weighting
+// scheme. caps are used for market_cap weighting and fixed for fixed
+// weighting; fixed weights are normalized to sum to 1.
+func Weights(weighting string, caps, fixed []decimal.Decimal) ([]decimal.Decimal, error) {
+	var raw []decimal.Decimal
+	switch weighting {
+	case WeightingMarketCap:
+		raw = caps
+	case WeightingFixed:
+		raw = fixed
+	case WeightingEqual:
+		raw = make([]decimal.Decimal, len(caps))
+		for i := range raw {
+			raw[i] = decimal.NewFromInt(1)
+		}
+	default:
+		return nil, fmt.Errorf("unknown weighting %q", weighting)
+	}
+
+	total := decimal.Zero
+	for _, w := range raw {
+		if w.IsNegative() {
+			return nil, fmt.Errorf("negative weight")
+		}
+		total = total.Add(w)
+	}
+	if !total.IsPositive() {
+		return nil, fmt.Errorf("weights sum to zero")
+	}
+
+	weights := make([]decimal.Decimal, len(raw))
+	for i, w := range raw {
+		weights[i] = w.Div(total)
+	}
+	return weights, nil
+}
+
+// rebalance picks constituents and weights per the index rules and converts
+// them to units at current prices. The level is carried over from the old
+// units so rebalancing does not move the index.
+func (s *Service) rebalance(ctx context.Context, idx *Index, prices map[int]decimal.Decimal, now time.Time) error {
+	level, ok := Level(idx.Constituents, prices)
+	if !ok {
+		if idx.LastRebalancedAt.Valid {
+			return fmt.Errorf("cannot carry level over, constituent prices missing")
+		}
+		level = idx.BaseLevel
+	}
+
+	candidates, err := s.candidates(ctx, idx, prices)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("no priced constituents")
+	}
+
+	caps := make([]decimal.Decimal, len(candidates))
+	fixed := make([]decimal.Decimal, len(candidates))
+	for i, c := range candidates {
+		caps[i] = c.marketCap
+		fixed[i] = c.Weight.Decimal
+	}
+	weights, err := Weights(idx.Weighting, caps, fixed)
+	if err != nil {
+		return err
+	}
+
+	constituents := make([]Constituent, len(candidates))
+	for i, c := range candidates {
+		constituents[i] = Constituent{
+			TokenID: c.TokenID,
+			Symbol:  c.Symbol,
+			Weight:  decimal.NewNullDecimal(weights[i].Round(8)),
+			Units:   decimal.NewNullDecimal(weights[i].Mul(level).Div(prices[c.TokenID]).Round(18)),
+		}
+	}
+
+	if err := saveRebalance(ctx, s.postgresDB, idx.ID, constituents, now); err != nil {
+		return err
+	}
+
+	s.logger.Info("Rebalanced index",
+		zap.String("index", idx.Slug),
+		zap.Int("constituents", len(constituents)),
+		zap.String("level", level.Round(8).String()))
+
+	idx.Constituents = constituents
+	idx.LastRebalancedAt = sql.NullTime{Time: now, Valid: true}
+	return nil
+}
+
+type candidate struct {
+	Constituent
+	marketCap decimal.Decimal
+}
+
+// candidates returns the tokens an index should hold after rebalancing: the
+// top_n priced tokens by computed market cap, or the configured constituents.
+// Configured constituents without a price fail the rebalance, since dropping
+// them would silently change the basket.
+func (s *Service) candidates(ctx context.Context, idx *Index, prices map[int]decimal.Decimal) ([]candidate, error) {
+	if idx.TopN > 0 {
+		rows, err := s.postgresDB.QueryContext(ctx, `
+			SELECT id, symbol, computed_market_cap
+			FROM tokens
+			WHERE is_active = true AND computed_market_cap > 0
+			  AND NOT (symbol = ANY($1))
+			ORDER BY computed_market_cap DESC, id
+		`, pq.Array(db.USDQuoteSymbols))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query index candidates: %w", err)
+		}
+		defer rows.Close()
+
+		var out []candidate
+		for rows.Next() && len(out) < idx.TopN {
+			var c candidate
+			if err := rows.Scan(&c.TokenID, &c.Symbol, &c.marketCap); err != nil {
+				return nil, fmt.Errorf("failed to scan index candidate: %w", err)
+			}
+			if _, ok := prices[c.TokenID]; ok {
+				out = append(out, c)
+			}
+		}
+		return out, rows.Err()
+	}
+
+	ids := make([]int64, len(idx.Constituents))
+	for i, c := range idx.Constituents {
+		if _, ok := prices[c.TokenID]; !ok {
+			return nil, fmt.Errorf("constituent %s has no price", c.Symbol)
+		}
+		ids[i] = int64(c.TokenID)
+	}
+
+	caps := make(map[int]decimal.Decimal)
+	if idx.Weighting == WeightingMarketCap {
+		rows, err := s.postgresDB.QueryContext(ctx,
+			`SELECT id, COALESCE(computed_market_cap, 0) FROM tokens WHERE id = ANY($1)`, pq.Array(ids))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query constituent market caps: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id int
+			var mc decimal.Decimal
+			if err := rows.Scan(&id, &mc); err != nil {
+				return nil, fmt.Errorf("failed to scan constituent market cap: %w", err)
+			}
+			caps[id] = mc
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]candidate, len(idx.Constituents))
+	for i, c := range idx.Constituents {
+		out[i] = candidate{Constituent: c, marketCap: caps[c.TokenID]}
+	}
+	return out, nil
+}
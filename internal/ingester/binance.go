@@ -4,16 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/ashmitsharp/trading/internal/config"
 	"github.com/ashmitsharp/trading/internal/db"
 	"github.com/ashmitsharp/trading/internal/models"
-	"github.com/gorilla/websocket"
+	"github.com/ashmitsharp/trading/internal/timeutil"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
@@ -24,42 +24,100 @@ const (
 	writeWait      = 10 * time.Second
 	maxMessageSize = 4096
 
-	// batch settings
+	// default batch settings, see DefaultPipelineConfig
 	batchSize    = 1000
 	batchTimeout = 5 * time.Second
 
-	// reconnection
+	// default reconnection policy, see DefaultReconnectPolicy
 	maxReconnectAttempts = 10
 	baseReconnectDelay   = 2 * time.Second
 	maxReconnectDelay    = 5 * time.Second
+	healthyConnection    = time.Minute
 )
 
+// ReconnectPolicy is how a dropped WebSocket connection is retried
+type ReconnectPolicy struct {
+	MaxAttempts  int           // failures in a row before the connection is given up; 0 retries forever
+	BaseDelay    time.Duration // before the first retry, doubling with each failure
+	MaxDelay     time.Duration // longest wait between retries
+	HealthyAfter time.Duration // a connection up this long starts the failure count again when it drops
+}
+
+// DefaultReconnectPolicy returns ten retries, two seconds apart at first and
+// at most five
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		MaxAttempts:  maxReconnectAttempts,
+		BaseDelay:    baseReconnectDelay,
+		MaxDelay:     maxReconnectDelay,
+		HealthyAfter: healthyConnection,
+	}
+}
+
+// delay is the wait before the retry following attempts failures in a row
+func (p ReconnectPolicy) delay(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay > p.MaxDelay {
+			delay = p.MaxDelay
+			break
+		}
+	}
+	return delay
+}
+
 type BinanceIngester struct {
-	conn   driver.Conn
-	logger *zap.Logger
-	config config.BinanceConfig
-	wsConn *websocket.Conn
-	ctx    context.Context
-	cancel context.CancelFunc
-
-	tradeBatch        []db.TradeData
-	batchMutex        sync.Mutex
-	reconnectAttempts int
-	isRunning         bool
-	mu                sync.RWMutex
+	conn      driver.Conn
+	logger    *zap.Logger
+	config    config.BinanceConfig
+	reconnect ReconnectPolicy
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	// One connection per share of the symbols, see shardSymbols
+	shards   []*wsShard
+	pipeline *tradePipeline
+
+	// Highest trade ID taken per symbol. A reconnected stream can repeat
+	// trades from before the drop, which would count their volume twice.
+	cursors    map[string]uint64
+	cursorsMu  sync.Mutex
+	duplicates atomic.Uint64
+
+	isRunning bool
+	mu        sync.RWMutex
 }
 
-// create a new binance data ingester
-func NewBinanceIngester(conn driver.Conn, logger *zap.Logger, config config.BinanceConfig) *BinanceIngester {
+// create a new binance data ingester, queueing trades for ClickHouse as
+// pipeline sets out and retrying dropped connections as reconnect does
+func NewBinanceIngester(conn driver.Conn, logger *zap.Logger, config config.BinanceConfig, pipeline PipelineConfig, reconnect ReconnectPolicy) *BinanceIngester {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &BinanceIngester{
-		conn:   conn,
-		logger: logger,
-		config: config,
-		ctx:    ctx,
-		cancel: cancel,
+	defaults := DefaultReconnectPolicy()
+	if reconnect.BaseDelay <= 0 {
+		reconnect.BaseDelay = defaults.BaseDelay
 	}
+	if reconnect.MaxDelay < reconnect.BaseDelay {
+		reconnect.MaxDelay = reconnect.BaseDelay
+	}
+
+	bi := &BinanceIngester{
+		conn:      conn,
+		logger:    logger,
+		config:    config,
+		reconnect: reconnect,
+		ctx:       ctx,
+		cancel:    cancel,
+		cursors:   make(map[string]uint64),
+	}
+	for i, symbols := range shardSymbols(config.Symbols, config.StreamsPerConnection) {
+		bi.shards = append(bi.shards, newWSShard(bi, i, symbols))
+	}
+	bi.pipeline = newTradePipeline(pipeline, func(batch []db.TradeData) error {
+		return db.InsertTrades(bi.conn, batch)
+	}, logger)
+	return bi
 }
 
 func (bi *BinanceIngester) Start() {
@@ -72,13 +130,18 @@ func (bi *BinanceIngester) Start() {
 	bi.isRunning = true
 	bi.mu.Unlock()
 
-	bi.logger.Info("Starting Binance ingester")
+	bi.logger.Info("Starting Binance ingester",
+		zap.Int("symbols", len(bi.config.Symbols)),
+		zap.Int("connections", len(bi.shards)))
+	bi.loadCursors()
 
-	// start the batch processor
-	go bi.processBatches()
+	// start the ClickHouse writer
+	bi.pipeline.start()
 
-	// websocket conn with retry logic
-	go bi.connectWithRetry()
+	// a websocket conn with retry logic per shard
+	for _, shard := range bi.shards {
+		go shard.connectWithRetry()
+	}
 }
 
 func (bi *BinanceIngester) Stop() {
@@ -93,151 +156,74 @@ func (bi *BinanceIngester) Stop() {
 	bi.isRunning = false
 	bi.cancel()
 
-	if bi.wsConn != nil {
-		bi.wsConn.Close()
+	for _, shard := range bi.shards {
+		shard.close()
 	}
 
-	// process remaining batch
-	bi.flushBatch()
-}
-
-func (bi *BinanceIngester) connectWithRetry() {
-	for {
-		select {
-		case <-bi.ctx.Done():
-			return
-		default:
-		}
-		if err := bi.connect(); err != nil {
-			bi.reconnectAttempts++
-			if bi.reconnectAttempts > maxReconnectAttempts {
-				bi.logger.Error("Max reconnection attempts reached", zap.Error(err))
-				return
-			}
-
-			delay := bi.calculateBackoffDelay()
-			bi.logger.Warn("Websocket connection failed, retrying",
-				zap.Error(err),
-				zap.Int("attempt", bi.reconnectAttempts),
-				zap.Duration("retry_in", delay),
-			)
-
-			select {
-			case <-time.After(delay):
-				continue
-			case <-bi.ctx.Done():
-				return
-			}
-		} else {
-			bi.reconnectAttempts = 0
-		}
-	}
+	// write the trades still queued
+	bi.pipeline.close()
 }
 
-// connect establishes WebSocket connection and starts listening
-func (bi *BinanceIngester) connect() error {
-	// Build combined stream URL
-	streamURL := bi.buildStreamURL()
-
-	bi.logger.Info("Connecting to Binance WebSocket", zap.String("url", streamURL))
-
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 45 * time.Second,
+// processMessage processes incoming trade messages
+func (bi *BinanceIngester) processMessage(message []byte) error {
+	var streamEvent models.BinanceCombinedStreamEvent
+	if err := json.Unmarshal(message, &streamEvent); err != nil {
+		return fmt.Errorf("failed to unmarshal stream event: %w", err)
 	}
 
-	conn, _, err := dialer.Dial(streamURL, nil)
+	// Parse trade data
+	trade, err := bi.parseTradeEvent(streamEvent.Data)
 	if err != nil {
-		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+		return fmt.Errorf("failed to parse trade event: %w", err)
 	}
 
-	bi.wsConn = conn
-
-	// Configure connection
-	bi.wsConn.SetReadLimit(maxMessageSize)
-	bi.wsConn.SetReadDeadline(time.Now().Add(pongWait))
-	bi.wsConn.SetPongHandler(func(string) error {
-		bi.wsConn.SetReadDeadline(time.Now().Add(pongWait))
+	if !bi.advanceCursor(trade) {
 		return nil
-	})
-
-	// Start ping routine
-	go bi.pingRoutine()
-
-	// Start reading messages
-	return bi.readMessages()
-}
-
-func (bi *BinanceIngester) buildStreamURL() string {
-	streams := make([]string, len(bi.config.Symbols))
-	for i, symbol := range bi.config.Symbols {
-		streams[i] = fmt.Sprintf("%s@trade", strings.ToLower(symbol))
 	}
 
-	u, _ := url.Parse(bi.config.WSBaseURL)
-	u.Path = "/stream"
-	q := u.Query()
-	q.Set("streams", strings.Join(streams, "/"))
-	u.RawQuery = q.Encode()
-
-	return u.String()
-}
+	// Add to batch
+	bi.addToBatch(trade)
 
-func (bi *BinanceIngester) pingRoutine() {
-	ticker := time.NewTicker(pingPeriod)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			if err := bi.wsConn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(writeWait)); err != nil {
-				bi.logger.Error("Failed to send ping", zap.Error(err))
-				return
-			}
-		case <-bi.ctx.Done():
-			return
-		}
-	}
+	return nil
 }
 
-func (bi *BinanceIngester) readMessages() error {
-	for {
-		select {
-		case <-bi.ctx.Done():
-			return nil
-		default:
-		}
+// loadCursors starts each symbol's cursor at its highest stored trade ID, so
+// trades already stored before a restart are not written again. Without
+// them the cursors start empty and still catch repeats within this run.
+func (bi *BinanceIngester) loadCursors() {
+	ctx, cancel := context.WithTimeout(bi.ctx, 10*time.Second)
+	defer cancel()
 
-		_, message, err := bi.wsConn.ReadMessage()
-		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				return fmt.Errorf("WebSocket connection closed unexpectedly: %w", err)
-			}
-			return err
-		}
+	// InsertTrades leaves exchange_id empty
+	cursors, err := db.GetTradeCursors(ctx, bi.conn, "")
+	if err != nil {
+		bi.logger.Warn("Failed to load trade cursors", zap.Error(err))
+		return
+	}
 
-		if err := bi.processMessage(message); err != nil {
-			bi.logger.Error("Failed to process message", zap.Error(err), zap.String("message", string(message)))
+	bi.cursorsMu.Lock()
+	for symbol, id := range cursors {
+		if id > bi.cursors[symbol] {
+			bi.cursors[symbol] = id
 		}
 	}
+	bi.cursorsMu.Unlock()
+	bi.logger.Info("Loaded trade cursors", zap.Int("symbols", len(cursors)))
 }
 
-// processMessage processes incoming trade messages
-func (bi *BinanceIngester) processMessage(message []byte) error {
-	var streamEvent models.BinanceCombinedStreamEvent
-	if err := json.Unmarshal(message, &streamEvent); err != nil {
-		return fmt.Errorf("failed to unmarshal stream event: %w", err)
-	}
+// advanceCursor reports whether a trade is new, moving its symbol's cursor
+// past it. Binance numbers each symbol's trades in order, so a trade at or
+// below the cursor has been taken already.
+func (bi *BinanceIngester) advanceCursor(trade db.TradeData) bool {
+	bi.cursorsMu.Lock()
+	defer bi.cursorsMu.Unlock()
 
-	// Parse trade data
-	trade, err := bi.parseTradeEvent(streamEvent.Data)
-	if err != nil {
-		return fmt.Errorf("failed to parse trade event: %w", err)
+	if trade.TradeID <= bi.cursors[trade.Symbol] {
+		bi.duplicates.Add(1)
+		return false
 	}
-
-	// Add to batch
-	bi.addToBatch(trade)
-
-	return nil
+	bi.cursors[trade.Symbol] = trade.TradeID
+	return true
 }
 
 // parseTradeEvent converts Binance trade event to internal trade data
@@ -262,93 +248,77 @@ func (bi *BinanceIngester) parseTradeEvent(event models.BinanceTradeEvent) (db.T
 		Price:        price,
 		Quantity:     quantity,
 		TradeID:      uint64(event.TradeID),
-		Timestamp:    event.TradeTime,
+		Timestamp:    timeutil.Millis(event.TradeTime),
 		IsBuyerMaker: isBuyerMaker,
 	}, nil
 }
 
-// addToBatch adds a trade to the current batch
+// addToBatch queues a trade for the ClickHouse writer
 func (bi *BinanceIngester) addToBatch(trade db.TradeData) {
-	bi.batchMutex.Lock()
-	defer bi.batchMutex.Unlock()
-
-	bi.tradeBatch = append(bi.tradeBatch, trade)
-
-	// Flush if batch is full
-	if len(bi.tradeBatch) >= batchSize {
-		go bi.flushBatch()
-	}
+	bi.pipeline.add(trade)
 }
 
-// processBatches periodically flushes batches
-func (bi *BinanceIngester) processBatches() {
-	ticker := time.NewTicker(batchTimeout)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			bi.flushBatch()
-		case <-bi.ctx.Done():
-			return
-		}
-	}
+// IsRunning returns whether the ingester is currently running
+func (bi *BinanceIngester) IsRunning() bool {
+	bi.mu.RLock()
+	defer bi.mu.RUnlock()
+	return bi.isRunning
 }
 
-// flushBatch writes the current batch to ClickHouse
-func (bi *BinanceIngester) flushBatch() {
-	bi.batchMutex.Lock()
-	if len(bi.tradeBatch) == 0 {
-		bi.batchMutex.Unlock()
-		return
-	}
-
-	batch := make([]db.TradeData, len(bi.tradeBatch))
-	copy(batch, bi.tradeBatch)
-	bi.tradeBatch = bi.tradeBatch[:0] // Reset slice
-	bi.batchMutex.Unlock()
-
-	if err := db.InsertTrades(bi.conn, batch); err != nil {
-		bi.logger.Error("Failed to insert batch",
-			zap.Error(err),
-			zap.Int("batch_size", len(batch)))
-		return
-	}
-
-	bi.logger.Debug("Batch inserted successfully",
-		zap.Int("trades_count", len(batch)))
+// PipelineStats returns the counters of the queue in front of ClickHouse
+func (bi *BinanceIngester) PipelineStats() PipelineStats {
+	return bi.pipeline.stats()
 }
 
-// calculateBackoffDelay calculates exponential backoff delay
-func (bi *BinanceIngester) calculateBackoffDelay() time.Duration {
-	delay := baseReconnectDelay
-	for i := 1; i < bi.reconnectAttempts; i++ {
-		delay *= 2
-		if delay > maxReconnectDelay {
-			delay = maxReconnectDelay
-			break
-		}
-	}
-	return delay
+// DuplicatesSkipped returns how many repeated trades the cursors have caught
+func (bi *BinanceIngester) DuplicatesSkipped() uint64 {
+	return bi.duplicates.Load()
 }
 
-// IsRunning returns whether the ingester is currently running
-func (bi *BinanceIngester) IsRunning() bool {
-	bi.mu.RLock()
-	defer bi.mu.RUnlock()
-	return bi.isRunning
+// ShardStats returns the state of each WebSocket connection
+func (bi *BinanceIngester) ShardStats() []ShardStats {
+	stats := make([]ShardStats, 0, len(bi.shards))
+	for _, shard := range bi.shards {
+		stats = append(stats, shard.stats())
+	}
+	return stats
 }
 
-// GetStats returns ingestion statistics
+// GetStats returns ingestion statistics, totalled over the connections
 func (bi *BinanceIngester) GetStats() map[string]interface{} {
-	bi.batchMutex.Lock()
-	batchSize := len(bi.tradeBatch)
-	bi.batchMutex.Unlock()
+	stats := bi.pipeline.stats()
+	shards := bi.ShardStats()
+
+	var connected, givenUp, reconnectAttempts int
+	var messages uint64
+	for _, shard := range shards {
+		if shard.Connected {
+			connected++
+		}
+		if shard.GivenUp {
+			givenUp++
+		}
+		reconnectAttempts += shard.ReconnectAttempts
+		messages += shard.Messages
+	}
 
 	return map[string]interface{}{
-		"is_running":         bi.IsRunning(),
-		"current_batch_size": batchSize,
-		"reconnect_attempts": bi.reconnectAttempts,
-		"symbols":            bi.config.Symbols,
+		"is_running":          bi.IsRunning(),
+		"queue_depth":         stats.QueueDepth,
+		"queue_capacity":      stats.QueueCapacity,
+		"trades_written":      stats.Written,
+		"trades_dropped":      stats.Dropped,
+		"trades_parked":       stats.Parked,
+		"trades_failed":       stats.Failed,
+		"failed_batches":      stats.FailedBatches,
+		"duplicates_skipped":  bi.DuplicatesSkipped(),
+		"last_flush_duration": stats.LastFlushDuration.String(),
+		"connections":         len(shards),
+		"connected":           connected,
+		"given_up":            givenUp,
+		"reconnect_attempts":  reconnectAttempts,
+		"messages":            messages,
+		"shards":              shards,
+		"symbols":             bi.config.Symbols,
 	}
 }
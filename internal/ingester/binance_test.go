@@ -0,0 +1,46 @@
+package ingester
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/config"
+	"go.uber.org/zap"
+)
+
+func tradeMessage(symbol string, id int64) []byte {
+	return []byte(fmt.Sprintf(`{"stream":"%s@trade","data":{"e":"trade","s":"%s","t":%d,"p":"100.5","q":"0.1","T":1700000000000,"m":true}}`,
+		symbol, symbol, id))
+}
+
+func TestProcessMessageSkipsRepeatedTrades(t *testing.T) {
+	bi := NewBinanceIngester(nil, zap.NewNop(), config.BinanceConfig{}, DefaultPipelineConfig(), DefaultReconnectPolicy())
+
+	// The stream before and after a reconnect, overlapping on 3 and 4
+	for _, id := range []int64{1, 2, 3, 4, 3, 4, 5} {
+		if err := bi.processMessage(tradeMessage("BTCUSDT", id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Other symbols have cursors of their own
+	if err := bi.processMessage(tradeMessage("ETHUSDT", 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(bi.pipeline.queue); got != 6 {
+		t.Fatalf("queued %d trades, want 6", got)
+	}
+	if got := bi.duplicates.Load(); got != 2 {
+		t.Fatalf("skipped %d duplicates, want 2", got)
+	}
+	var ids []uint64
+	for len(bi.pipeline.queue) > 0 {
+		trade := <-bi.pipeline.queue
+		if trade.Symbol == "BTCUSDT" {
+			ids = append(ids, trade.TradeID)
+		}
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4 5]" {
+		t.Fatalf("queued BTCUSDT trades %v, want [1 2 3 4 5]", ids)
+	}
+}
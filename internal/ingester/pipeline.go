@@ -0,0 +1,274 @@
+package ingester
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"go.uber.org/zap"
+)
+
+// OverflowPolicy is what happens to a trade that arrives while the queue in
+// front of the ClickHouse writer is full
+type OverflowPolicy string
+
+const (
+	// OverflowDrop discards the trade, so the websocket keeps up with the
+	// exchange at the cost of gaps in the stored trades
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowPark holds the websocket reader until the queue has room or
+	// the park timeout passes, then drops the trade. Parking too long makes
+	// Binance close the connection as a slow consumer.
+	OverflowPark OverflowPolicy = "park"
+)
+
+// ParseOverflowPolicy reads an OverflowPolicy from configuration
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch p := OverflowPolicy(s); p {
+	case OverflowDrop, OverflowPark:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown overflow policy %q, want drop or park", s)
+}
+
+// PipelineConfig sizes the queue between the websocket reader and the
+// ClickHouse writer
+type PipelineConfig struct {
+	QueueSize     int            // trades held while the writer is busy
+	BatchSize     int            // trades per insert
+	FlushInterval time.Duration  // longest a trade waits for a batch to fill
+	Overflow      OverflowPolicy // applied once QueueSize trades are waiting
+	ParkTimeout   time.Duration  // longest OverflowPark holds the reader; 0 until there is room
+}
+
+// DefaultPipelineConfig returns a queue of ten batches, which rides out a
+// ClickHouse stall of several seconds at Binance's busiest trade rates
+func DefaultPipelineConfig() PipelineConfig {
+	return PipelineConfig{
+		QueueSize:     10 * batchSize,
+		BatchSize:     batchSize,
+		FlushInterval: batchTimeout,
+		Overflow:      OverflowDrop,
+		ParkTimeout:   time.Second,
+	}
+}
+
+// PipelineStats is a snapshot of the pipeline. Dropped counts trades
+// discarded on overflow, Parked the trades that had to wait for room, and
+// Failed the trades in batches ClickHouse refused.
+type PipelineStats struct {
+	QueueDepth        int
+	QueueCapacity     int
+	Enqueued          uint64
+	Written           uint64
+	Dropped           uint64
+	Parked            uint64
+	Failed            uint64
+	FailedBatches     uint64
+	LastFlush         time.Time
+	LastFlushDuration time.Duration
+}
+
+// tradePipeline queues trades for a single writer goroutine, which batches
+// them into ClickHouse. A slow ClickHouse fills the bounded queue and the
+// overflow policy decides what gives, instead of flushes piling up.
+type tradePipeline struct {
+	config PipelineConfig
+	write  func([]db.TradeData) error
+	logger *zap.Logger
+
+	queue    chan db.TradeData
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	enqueued      atomic.Uint64
+	written       atomic.Uint64
+	dropped       atomic.Uint64
+	parked        atomic.Uint64
+	failed        atomic.Uint64
+	failedBatches atomic.Uint64
+
+	mu                sync.Mutex
+	lastFlush         time.Time
+	lastFlushDuration time.Duration
+}
+
+// newTradePipeline returns a pipeline writing batches with write, which must
+// not keep the slice it is given: the writer reuses it for the next batch
+func newTradePipeline(config PipelineConfig, write func([]db.TradeData) error, logger *zap.Logger) *tradePipeline {
+	defaults := DefaultPipelineConfig()
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+	if config.Overflow == "" {
+		config.Overflow = defaults.Overflow
+	}
+
+	return &tradePipeline{
+		config: config,
+		write:  write,
+		logger: logger,
+		queue:  make(chan db.TradeData, config.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// start runs the writer until close
+func (p *tradePipeline) start() {
+	go p.run()
+}
+
+// add queues a trade, applying the overflow policy when the queue is full.
+// It reports whether the trade was queued.
+func (p *tradePipeline) add(trade db.TradeData) bool {
+	select {
+	case <-p.stop:
+		return false
+	default:
+	}
+
+	select {
+	case p.queue <- trade:
+		p.enqueued.Add(1)
+		return true
+	default:
+	}
+
+	if p.config.Overflow == OverflowPark {
+		p.parked.Add(1)
+		var timeout <-chan time.Time
+		if p.config.ParkTimeout > 0 {
+			timer := time.NewTimer(p.config.ParkTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case p.queue <- trade:
+			p.enqueued.Add(1)
+			return true
+		case <-timeout:
+		case <-p.stop:
+			return false
+		}
+	}
+
+	p.dropped.Add(1)
+	return false
+}
+
+// close stops taking trades, writes those already queued and waits for the
+// writer to finish
+func (p *tradePipeline) close() {
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.done
+}
+
+func (p *tradePipeline) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]db.TradeData, 0, p.config.BatchSize)
+	var droppedBefore uint64
+	flush := func() {
+		if dropped := p.dropped.Load(); dropped > droppedBefore {
+			p.logger.Warn("Trade queue overflowed, trades dropped",
+				zap.Uint64("dropped", dropped-droppedBefore),
+				zap.Int("queue_capacity", p.config.QueueSize),
+				zap.String("policy", string(p.config.Overflow)))
+			droppedBefore = dropped
+		}
+		if len(batch) == 0 {
+			return
+		}
+		p.flush(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case trade := <-p.queue:
+			batch = append(batch, trade)
+			if len(batch) >= p.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.stop:
+		drain:
+			for {
+				select {
+				case trade := <-p.queue:
+					batch = append(batch, trade)
+					if len(batch) >= p.config.BatchSize {
+						flush()
+					}
+				default:
+					break drain
+				}
+			}
+			flush()
+			return
+		}
+	}
+}
+
+// flush writes one batch. The writer is the only caller, so batches never
+// overlap and a slow insert backs trades up into the queue.
+func (p *tradePipeline) flush(batch []db.TradeData) {
+	started := time.Now()
+	err := p.write(batch)
+	elapsed := time.Since(started)
+
+	p.mu.Lock()
+	p.lastFlush = started
+	p.lastFlushDuration = elapsed
+	p.mu.Unlock()
+
+	if err != nil {
+		p.failed.Add(uint64(len(batch)))
+		p.failedBatches.Add(1)
+		p.logger.Error("Failed to insert batch",
+			zap.Error(err),
+			zap.Int("batch_size", len(batch)),
+			zap.Duration("elapsed", elapsed))
+		return
+	}
+
+	p.written.Add(uint64(len(batch)))
+	p.logger.Debug("Batch inserted successfully",
+		zap.Int("trades_count", len(batch)),
+		zap.Int("queue_depth", len(p.queue)),
+		zap.Duration("elapsed", elapsed))
+}
+
+// stats returns the pipeline counters and current queue depth
+func (p *tradePipeline) stats() PipelineStats {
+	p.mu.Lock()
+	lastFlush, lastFlushDuration := p.lastFlush, p.lastFlushDuration
+	p.mu.Unlock()
+
+	return PipelineStats{
+		QueueDepth:        len(p.queue),
+		QueueCapacity:     cap(p.queue),
+		Enqueued:          p.enqueued.Load(),
+		Written:           p.written.Load(),
+		Dropped:           p.dropped.Load(),
+		Parked:            p.parked.Load(),
+		Failed:            p.failed.Load(),
+		FailedBatches:     p.failedBatches.Load(),
+		LastFlush:         lastFlush,
+		LastFlushDuration: lastFlushDuration,
+	}
+}
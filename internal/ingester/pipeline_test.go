@@ -0,0 +1,148 @@
+package ingester
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"go.uber.org/zap"
+)
+
+// blockingWriter records batches and holds each write until released
+type blockingWriter struct {
+	mu      sync.Mutex
+	batches [][]db.TradeData
+	release chan struct{}
+}
+
+func (w *blockingWriter) write(batch []db.TradeData) error {
+	<-w.release
+	w.mu.Lock()
+	w.batches = append(w.batches, append([]db.TradeData(nil), batch...))
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *blockingWriter) trades() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := 0
+	for _, b := range w.batches {
+		n += len(b)
+	}
+	return n
+}
+
+func TestPipelineDropsWhenFull(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	p := newTradePipeline(PipelineConfig{QueueSize: 4, BatchSize: 2, FlushInterval: time.Hour, Overflow: OverflowDrop}, w.write, zap.NewNop())
+	p.start()
+
+	// The writer takes two trades and blocks on them; four more fill the
+	// queue and the rest are dropped
+	for i := 0; i < 10; i++ {
+		p.add(db.TradeData{TradeID: uint64(i)})
+		if i == 1 {
+			waitFor(t, func() bool { return len(p.queue) == 0 })
+		}
+	}
+	stats := p.stats()
+	if stats.Dropped != 4 || stats.Enqueued != 6 {
+		t.Fatalf("dropped %d, enqueued %d; want 4 and 6", stats.Dropped, stats.Enqueued)
+	}
+	if stats.QueueDepth != 4 {
+		t.Fatalf("queue depth %d, want 4", stats.QueueDepth)
+	}
+
+	close(w.release)
+	p.close()
+	if got := w.trades(); got != 6 {
+		t.Fatalf("wrote %d trades, want the 6 queued", got)
+	}
+	if stats := p.stats(); stats.Written != 6 {
+		t.Fatalf("stats count %d written, want 6", stats.Written)
+	}
+}
+
+func TestPipelineParksUntilRoom(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	p := newTradePipeline(PipelineConfig{QueueSize: 1, BatchSize: 1, FlushInterval: time.Hour, Overflow: OverflowPark}, w.write, zap.NewNop())
+	p.start()
+
+	p.add(db.TradeData{TradeID: 1})
+	waitFor(t, func() bool { return len(p.queue) == 0 })
+	p.add(db.TradeData{TradeID: 2})
+
+	queued := make(chan bool)
+	go func() { queued <- p.add(db.TradeData{TradeID: 3}) }()
+	select {
+	case <-queued:
+		t.Fatal("add returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(w.release)
+	if !<-queued {
+		t.Fatal("parked trade was dropped once there was room")
+	}
+	p.close()
+	if got := w.trades(); got != 3 {
+		t.Fatalf("wrote %d trades, want 3", got)
+	}
+	if stats := p.stats(); stats.Parked != 1 || stats.Dropped != 0 {
+		t.Fatalf("parked %d, dropped %d; want 1 and 0", stats.Parked, stats.Dropped)
+	}
+}
+
+func TestPipelineParkTimeout(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	p := newTradePipeline(PipelineConfig{QueueSize: 1, BatchSize: 1, FlushInterval: time.Hour, Overflow: OverflowPark, ParkTimeout: 10 * time.Millisecond}, w.write, zap.NewNop())
+	p.start()
+
+	p.add(db.TradeData{TradeID: 1})
+	waitFor(t, func() bool { return len(p.queue) == 0 })
+	p.add(db.TradeData{TradeID: 2})
+	if p.add(db.TradeData{TradeID: 3}) {
+		t.Fatal("trade queued while the writer was stuck")
+	}
+	if stats := p.stats(); stats.Dropped != 1 {
+		t.Fatalf("dropped %d, want 1", stats.Dropped)
+	}
+
+	close(w.release)
+	p.close()
+}
+
+func TestPipelineFlushesOnInterval(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	close(w.release)
+	p := newTradePipeline(PipelineConfig{QueueSize: 10, BatchSize: 100, FlushInterval: 10 * time.Millisecond}, w.write, zap.NewNop())
+	p.start()
+	defer p.close()
+
+	p.add(db.TradeData{TradeID: 1})
+	waitFor(t, func() bool { return w.trades() == 1 })
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	for _, s := range []string{"drop", "park"} {
+		if p, err := ParseOverflowPolicy(s); err != nil || string(p) != s {
+			t.Errorf("ParseOverflowPolicy(%q) = %q, %v", s, p, err)
+		}
+	}
+	if _, err := ParseOverflowPolicy("block"); err == nil {
+		t.Error("ParseOverflowPolicy accepted block")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
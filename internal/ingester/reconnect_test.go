@@ -0,0 +1,91 @@
+package ingester
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/config"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// droppingServer accepts WebSocket connections and closes each after hold
+func droppingServer(t *testing.T, hold time.Duration) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var accepted atomic.Int64
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		accepted.Add(1)
+		time.Sleep(hold)
+		conn.Close()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &accepted
+}
+
+func newTestIngester(url string, policy ReconnectPolicy) *BinanceIngester {
+	return NewBinanceIngester(nil, zap.NewNop(), config.BinanceConfig{
+		WSBaseURL: "ws" + strings.TrimPrefix(url, "http"),
+		Symbols:   []string{"BTCUSDT"},
+	}, DefaultPipelineConfig(), policy)
+}
+
+func TestShardGivesUpAfterMaxAttempts(t *testing.T) {
+	srv, accepted := droppingServer(t, 0)
+	bi := newTestIngester(srv.URL, ReconnectPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, HealthyAfter: time.Hour})
+	defer bi.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		bi.shards[0].connectWithRetry()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shard kept retrying past MaxAttempts")
+	}
+
+	stats := bi.shards[0].stats()
+	if !stats.GivenUp {
+		t.Fatal("shard that stopped retrying is not marked given up")
+	}
+	if got := accepted.Load(); got != 4 {
+		t.Fatalf("server saw %d connections, want the first and 3 retries", got)
+	}
+}
+
+func TestShardResetsAttemptsAfterHealthyConnection(t *testing.T) {
+	srv, accepted := droppingServer(t, 20*time.Millisecond)
+	bi := newTestIngester(srv.URL, ReconnectPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, HealthyAfter: 10 * time.Millisecond})
+
+	done := make(chan struct{})
+	go func() {
+		bi.shards[0].connectWithRetry()
+		close(done)
+	}()
+	waitFor(t, func() bool { return accepted.Load() >= 5 })
+	bi.cancel()
+	<-done
+
+	if bi.shards[0].stats().GivenUp {
+		t.Fatal("connections up past HealthyAfter still counted towards MaxAttempts")
+	}
+}
+
+func TestReconnectPolicyDelay(t *testing.T) {
+	p := ReconnectPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := p.delay(attempts); got != want {
+			t.Errorf("delay(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
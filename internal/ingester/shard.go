@@ -0,0 +1,266 @@
+package ingester
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxStreamsPerConnection is Binance's limit on the streams one
+	// WebSocket connection may subscribe to
+	maxStreamsPerConnection = 1024
+	// defaultStreamsPerConnection is used when the config sets none. It is
+	// well under the limit so a combined stream URL stays a sensible length.
+	defaultStreamsPerConnection = 200
+)
+
+// wsShard is one WebSocket connection carrying the trade streams of a share
+// of the ingester's symbols. Each shard connects and reconnects on its own,
+// so one dropped connection leaves the others streaming.
+type wsShard struct {
+	ingester *BinanceIngester
+	index    int
+	symbols  []string
+	logger   *zap.Logger
+
+	mu                sync.Mutex
+	wsConn            *websocket.Conn
+	connected         bool
+	connectedAt       time.Time
+	reconnectAttempts int
+	lastError         string
+	givenUp           bool
+
+	messages atomic.Uint64
+}
+
+// ShardStats is a snapshot of one WebSocket connection. GivenUp is set once
+// the connection has failed the reconnect policy's MaxAttempts times in a
+// row; its symbols are no longer streamed.
+type ShardStats struct {
+	Index             int
+	Symbols           int
+	Connected         bool
+	GivenUp           bool
+	ConnectedAt       time.Time
+	ReconnectAttempts int
+	LastError         string
+	Messages          uint64
+}
+
+// shardSymbols splits symbols into groups of at most perConnection, capped
+// at Binance's limit
+func shardSymbols(symbols []string, perConnection int) [][]string {
+	if perConnection <= 0 {
+		perConnection = defaultStreamsPerConnection
+	}
+	if perConnection > maxStreamsPerConnection {
+		perConnection = maxStreamsPerConnection
+	}
+
+	var shards [][]string
+	for start := 0; start < len(symbols); start += perConnection {
+		end := start + perConnection
+		if end > len(symbols) {
+			end = len(symbols)
+		}
+		shards = append(shards, symbols[start:end])
+	}
+	return shards
+}
+
+func newWSShard(ingester *BinanceIngester, index int, symbols []string) *wsShard {
+	return &wsShard{
+		ingester: ingester,
+		index:    index,
+		symbols:  symbols,
+		logger:   ingester.logger.With(zap.Int("shard", index), zap.Int("symbols", len(symbols))),
+	}
+}
+
+func (s *wsShard) connectWithRetry() {
+	ctx := s.ingester.ctx
+	policy := s.ingester.reconnect
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		started := time.Now()
+		if err := s.connect(); err != nil {
+			s.mu.Lock()
+			// A connection that stayed up a while was healthy, so its drop
+			// is the first failure rather than one more in a run
+			if s.connectedAt.After(started) && time.Since(s.connectedAt) >= policy.HealthyAfter {
+				s.reconnectAttempts = 0
+			}
+			s.reconnectAttempts++
+			attempts := s.reconnectAttempts
+			s.lastError = err.Error()
+			givenUp := policy.MaxAttempts > 0 && attempts > policy.MaxAttempts
+			s.givenUp = givenUp
+			s.mu.Unlock()
+
+			if givenUp {
+				s.logger.Error("Max reconnection attempts reached, no longer streaming these symbols",
+					zap.Error(err),
+					zap.Int("attempts", attempts-1),
+					zap.Strings("symbols", s.symbols))
+				return
+			}
+
+			delay := policy.delay(attempts)
+			s.logger.Warn("Websocket connection failed, retrying",
+				zap.Error(err),
+				zap.Int("attempt", attempts),
+				zap.Duration("retry_in", delay),
+			)
+
+			select {
+			case <-time.After(delay):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		} else {
+			s.mu.Lock()
+			s.reconnectAttempts = 0
+			s.mu.Unlock()
+		}
+	}
+}
+
+// connect establishes the shard's WebSocket connection and listens on it
+// until it drops
+func (s *wsShard) connect() error {
+	// Build combined stream URL
+	streamURL := s.buildStreamURL()
+
+	s.logger.Info("Connecting to Binance WebSocket", zap.String("url", streamURL))
+
+	dialer := websocket.Dialer{
+		HandshakeTimeout: 45 * time.Second,
+	}
+
+	conn, _, err := dialer.Dial(streamURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+	}
+
+	s.mu.Lock()
+	s.wsConn = conn
+	s.connected = true
+	s.connectedAt = time.Now()
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.connected = false
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	// Configure connection
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	// Start ping routine, stopped with the connection
+	done := make(chan struct{})
+	defer close(done)
+	go s.pingRoutine(conn, done)
+
+	// Start reading messages
+	return s.readMessages(conn)
+}
+
+func (s *wsShard) buildStreamURL() string {
+	streams := make([]string, len(s.symbols))
+	for i, symbol := range s.symbols {
+		streams[i] = fmt.Sprintf("%s@trade", strings.ToLower(symbol))
+	}
+
+	u, _ := url.Parse(s.ingester.config.WSBaseURL)
+	u.Path = "/stream"
+	q := u.Query()
+	q.Set("streams", strings.Join(streams, "/"))
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func (s *wsShard) pingRoutine(conn *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(writeWait)); err != nil {
+				s.logger.Error("Failed to send ping", zap.Error(err))
+				return
+			}
+		case <-done:
+			return
+		case <-s.ingester.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *wsShard) readMessages(conn *websocket.Conn) error {
+	for {
+		select {
+		case <-s.ingester.ctx.Done():
+			return nil
+		default:
+		}
+
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				return fmt.Errorf("WebSocket connection closed unexpectedly: %w", err)
+			}
+			return err
+		}
+
+		s.messages.Add(1)
+		if err := s.ingester.processMessage(message); err != nil {
+			s.logger.Error("Failed to process message", zap.Error(err), zap.String("message", string(message)))
+		}
+	}
+}
+
+// close closes the shard's connection, ending its read loop
+func (s *wsShard) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wsConn != nil {
+		s.wsConn.Close()
+	}
+}
+
+func (s *wsShard) stats() ShardStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ShardStats{
+		Index:             s.index,
+		Symbols:           len(s.symbols),
+		Connected:         s.connected,
+		GivenUp:           s.givenUp,
+		ConnectedAt:       s.connectedAt,
+		ReconnectAttempts: s.reconnectAttempts,
+		LastError:         s.lastError,
+		Messages:          s.messages.Load(),
+	}
+}
@@ -0,0 +1,84 @@
+package ingester
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestShardSymbols(t *testing.T) {
+	symbols := make([]string, 450)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("SYM%dUSDT", i)
+	}
+
+	tests := []struct {
+		perConnection int
+		want          []int
+	}{
+		{200, []int{200, 200, 50}},
+		{450, []int{450}},
+		{0, []int{200, 200, 50}}, // the default
+		{5000, []int{450}},       // capped at Binance's limit
+		{1, make([]int, 450)},    // one connection per symbol
+	}
+	for _, tt := range tests {
+		shards := shardSymbols(symbols, tt.perConnection)
+		if len(shards) != len(tt.want) {
+			t.Errorf("perConnection %d: got %d shards, want %d", tt.perConnection, len(shards), len(tt.want))
+			continue
+		}
+		total := 0
+		for i, shard := range shards {
+			if tt.want[i] != 0 && len(shard) != tt.want[i] {
+				t.Errorf("perConnection %d: shard %d has %d symbols, want %d", tt.perConnection, i, len(shard), tt.want[i])
+			}
+			total += len(shard)
+		}
+		if total != len(symbols) {
+			t.Errorf("perConnection %d: shards hold %d symbols, want %d", tt.perConnection, total, len(symbols))
+		}
+	}
+
+	big := make([]string, 3000)
+	for _, shard := range shardSymbols(big, 5000) {
+		if len(shard) > maxStreamsPerConnection {
+			t.Fatalf("shard of %d streams is over Binance's limit", len(shard))
+		}
+	}
+}
+
+func TestShardStreamURLs(t *testing.T) {
+	bi := NewBinanceIngester(nil, zap.NewNop(), config.BinanceConfig{
+		WSBaseURL:            "wss://stream.binance.com:9443",
+		Symbols:              []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"},
+		StreamsPerConnection: 2,
+	}, DefaultPipelineConfig(), DefaultReconnectPolicy())
+
+	if len(bi.shards) != 2 {
+		t.Fatalf("got %d shards, want 2", len(bi.shards))
+	}
+	var streams []string
+	for _, shard := range bi.shards {
+		u, err := url.Parse(shard.buildStreamURL())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Path != "/stream" {
+			t.Errorf("path %q, want /stream", u.Path)
+		}
+		streams = append(streams, u.Query().Get("streams"))
+	}
+	if got := strings.Join(streams, " "); got != "btcusdt@trade/ethusdt@trade solusdt@trade" {
+		t.Fatalf("streams %q", got)
+	}
+
+	stats := bi.GetStats()
+	if stats["connections"] != 2 || stats["connected"] != 0 {
+		t.Fatalf("stats report %v connections, %v connected", stats["connections"], stats["connected"])
+	}
+}
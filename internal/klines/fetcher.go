@@ -0,0 +1,212 @@
+// Package klines fetches candles computed by exchanges themselves from their
+// kline endpoints and stores them in exchange_ohlcv, as an OHLCV source next
+// to the candles built from trades and from the VWAP.
+package klines
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"go.uber.org/zap"
+)
+
+const (
+	// backfillLimit is how many candles are fetched the first time a pair
+	// and interval are fetched
+	backfillLimit = 500
+	// refreshLimit is how many are fetched after that: the open candle and
+	// the ones that closed since the last fetch
+	refreshLimit = 5
+)
+
+// Pair is an exchange symbol whose klines are fetched, written as the
+// exchange writes it, e.g. okx:BTC-USDT. Symbols are kept in upper case, as
+// the OHLCV API looks them up.
+type Pair struct {
+	ExchangeID string
+	Symbol     string
+}
+
+// ParsePairs parses a comma-separated list of exchange:symbol pairs
+func ParsePairs(spec string) ([]Pair, error) {
+	var pairs []Pair
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		exchangeID, symbol, ok := strings.Cut(part, ":")
+		exchangeID = strings.ToLower(strings.TrimSpace(exchangeID))
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if !ok || exchangeID == "" || symbol == "" {
+			return nil, fmt.Errorf("invalid kline pair %q: want exchange:symbol", part)
+		}
+		if !Supported(exchangeID) {
+			return nil, fmt.Errorf("klines are not supported for %s", exchangeID)
+		}
+		pairs = append(pairs, Pair{ExchangeID: exchangeID, Symbol: symbol})
+	}
+	return pairs, nil
+}
+
+// intervals are the candle intervals that can be fetched, as the OHLCV API
+// names them
+var intervals = map[string]bool{"1m": true, "5m": true, "15m": true, "1h": true, "4h": true, "1d": true}
+
+// ParseIntervals parses a comma-separated list of intervals such as "1m,1h"
+func ParseIntervals(spec string) ([]string, error) {
+	var out []string
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !intervals[part] {
+			return nil, fmt.Errorf("invalid kline interval %q: want one of 1m, 5m, 15m, 1h, 4h, 1d", part)
+		}
+		out = append(out, part)
+	}
+	return out, nil
+}
+
+// Config controls which klines are fetched and how often
+type Config struct {
+	Pairs     []Pair
+	Intervals []string      // API interval names, e.g. 1m and 1h
+	Every     time.Duration // between fetches of every pair and interval
+}
+
+// Fetcher fetches klines for the configured pairs and stores them
+type Fetcher struct {
+	clickhouseConn driver.Conn
+	configs        map[string]exchanges.ExchangeConfig
+	config         Config
+	client         *http.Client
+	logger         *zap.Logger
+
+	fetched map[string]bool // pair and interval keys backfilled already
+}
+
+// NewFetcher creates a kline fetcher. Requests go to the base URL of each
+// exchange's config, through transport when it is not nil, and are spaced by
+// its rate limit.
+func NewFetcher(clickhouseConn driver.Conn, configs []exchanges.ExchangeConfig, transport http.RoundTripper, config Config, logger *zap.Logger) *Fetcher {
+	byID := make(map[string]exchanges.ExchangeConfig, len(configs))
+	for _, c := range configs {
+		byID[c.ID] = c
+	}
+	if config.Every <= 0 {
+		config.Every = time.Minute
+	}
+	return &Fetcher{
+		clickhouseConn: clickhouseConn,
+		configs:        byID,
+		config:         config,
+		client:         &http.Client{Timeout: 15 * time.Second, Transport: transport},
+		logger:         logger,
+		fetched:        make(map[string]bool),
+	}
+}
+
+// Run fetches on start and then every Config.Every until ctx is done
+func (f *Fetcher) Run(ctx context.Context) error {
+	f.logger.Info("Starting kline fetcher",
+		zap.Int("pairs", len(f.config.Pairs)),
+		zap.Strings("intervals", f.config.Intervals),
+		zap.Duration("every", f.config.Every))
+
+	ticker := time.NewTicker(f.config.Every)
+	defer ticker.Stop()
+
+	for {
+		f.FetchAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// FetchAll fetches and stores every configured pair and interval once.
+// Failures are logged and the rest carry on.
+func (f *Fetcher) FetchAll(ctx context.Context) {
+	for _, pair := range f.config.Pairs {
+		config, ok := f.configs[pair.ExchangeID]
+		if !ok {
+			f.logger.Warn("No exchange config for kline pair",
+				zap.String("exchange", pair.ExchangeID), zap.String("symbol", pair.Symbol))
+			continue
+		}
+		for _, interval := range f.config.Intervals {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := f.fetch(ctx, config, pair, interval); err != nil {
+				f.logger.Warn("Failed to fetch klines",
+					zap.String("exchange", pair.ExchangeID),
+					zap.String("symbol", pair.Symbol),
+					zap.String("interval", interval),
+					zap.Error(err))
+			}
+			if config.RateLimitPerMinute > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Minute / time.Duration(config.RateLimitPerMinute)):
+				}
+			}
+		}
+	}
+}
+
+func (f *Fetcher) fetch(ctx context.Context, config exchanges.ExchangeConfig, pair Pair, interval string) error {
+	src := sources[pair.ExchangeID]
+	key := pair.ExchangeID + "/" + pair.Symbol + "/" + interval
+	limit := refreshLimit
+	if !f.fetched[key] {
+		limit = backfillLimit
+	}
+	path, ok := src.url(pair.Symbol, interval, limit)
+	if !ok {
+		return fmt.Errorf("interval %s is not available", interval)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "CryptoPlatform/1.0")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(io.LimitReader(resp.Body, 8<<20)); err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &exchanges.StatusError{ExchangeID: pair.ExchangeID, StatusCode: resp.StatusCode, Body: body.String()}
+	}
+
+	candles, err := src.parse(body.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := db.InsertExchangeCandles(ctx, f.clickhouseConn, pair.ExchangeID, pair.Symbol, interval, candles); err != nil {
+		return err
+	}
+	f.fetched[key] = true
+	return nil
+}
@@ -0,0 +1,171 @@
+package klines
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/shopspring/decimal"
+)
+
+// source describes an exchange's kline endpoint. Every source returns rows
+// of values with the open time first; the other columns' positions differ.
+type source struct {
+	// path has {symbol}, {interval} and {limit} filled in
+	path string
+	// intervals maps the API's interval names to the exchange's
+	intervals map[string]string
+	// column positions in each row; trades is -1 when not given
+	open, high, low, close, volume, trades int
+}
+
+// sources are the exchanges whose klines can be fetched
+var sources = map[string]source{
+	"binance": {
+		path:      "/api/v3/klines?symbol={symbol}&interval={interval}&limit={limit}",
+		intervals: map[string]string{"1m": "1m", "5m": "5m", "15m": "15m", "1h": "1h", "4h": "4h", "1d": "1d"},
+		open:      1, high: 2, low: 3, close: 4, volume: 5, trades: 8,
+	},
+	"okx": {
+		path:      "/api/v5/market/candles?instId={symbol}&bar={interval}&limit={limit}",
+		intervals: map[string]string{"1m": "1m", "5m": "5m", "15m": "15m", "1h": "1H", "4h": "4H", "1d": "1Dutc"},
+		open:      1, high: 2, low: 3, close: 4, volume: 5, trades: -1,
+	},
+	"bybit": {
+		path:      "/v5/market/kline?category=spot&symbol={symbol}&interval={interval}&limit={limit}",
+		intervals: map[string]string{"1m": "1", "5m": "5", "15m": "15", "1h": "60", "4h": "240", "1d": "D"},
+		open:      1, high: 2, low: 3, close: 4, volume: 5, trades: -1,
+	},
+	"gateio": {
+		path:      "/api/v4/spot/candlesticks?currency_pair={symbol}&interval={interval}&limit={limit}",
+		intervals: map[string]string{"1m": "1m", "5m": "5m", "15m": "15m", "1h": "1h", "4h": "4h", "1d": "1d"},
+		open:      5, high: 3, low: 4, close: 2, volume: 6, trades: -1,
+	},
+	"kucoin": {
+		// KuCoin has no limit parameter and returns up to 1500 candles
+		path:      "/api/v1/market/candles?symbol={symbol}&type={interval}",
+		intervals: map[string]string{"1m": "1min", "5m": "5min", "15m": "15min", "1h": "1hour", "4h": "4hour", "1d": "1day"},
+		open:      1, high: 3, low: 4, close: 2, volume: 5, trades: -1,
+	},
+}
+
+// Supported reports whether klines can be fetched from an exchange
+func Supported(exchangeID string) bool {
+	_, ok := sources[exchangeID]
+	return ok
+}
+
+// url returns the path fetching the latest limit candles, or false when the
+// exchange does not have the interval
+func (s source) url(symbol, interval string, limit int) (string, bool) {
+	name, ok := s.intervals[interval]
+	if !ok {
+		return "", false
+	}
+	return strings.NewReplacer(
+		"{symbol}", url.QueryEscape(symbol),
+		"{interval}", name,
+		"{limit}", strconv.Itoa(limit),
+	).Replace(s.path), true
+}
+
+// parse reads a kline response, oldest candle first. Rows that are too
+// short or do not parse are skipped.
+func (s source) parse(data []byte) ([]db.ExchangeCandle, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var body interface{}
+	if err := dec.Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding klines: %w", err)
+	}
+	rows, ok := rowList(body)
+	if !ok {
+		return nil, fmt.Errorf("unable to parse klines response")
+	}
+
+	width := max(s.open, s.high, s.low, s.close, s.volume, s.trades) + 1
+	candles := make([]db.ExchangeCandle, 0, len(rows))
+	for _, r := range rows {
+		row, ok := r.([]interface{})
+		if !ok || len(row) < width {
+			continue
+		}
+		openTime, ok := parseTime(row[0])
+		if !ok {
+			continue
+		}
+		c := db.ExchangeCandle{
+			OpenTime: openTime,
+			Open:     parseDecimal(row[s.open]),
+			High:     parseDecimal(row[s.high]),
+			Low:      parseDecimal(row[s.low]),
+			Close:    parseDecimal(row[s.close]),
+			Volume:   parseDecimal(row[s.volume]),
+		}
+		if c.Open.Sign() <= 0 || c.Close.Sign() <= 0 {
+			continue
+		}
+		if s.trades >= 0 {
+			c.TradesCount = uint64(parseDecimal(row[s.trades]).IntPart())
+		}
+		candles = append(candles, c)
+	}
+
+	// OKX, Bybit and KuCoin give the newest candle first
+	if len(candles) > 1 && candles[0].OpenTime.After(candles[len(candles)-1].OpenTime) {
+		for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+			candles[i], candles[j] = candles[j], candles[i]
+		}
+	}
+	return candles, nil
+}
+
+func rowList(body interface{}) ([]interface{}, bool) {
+	switch v := body.(type) {
+	case []interface{}:
+		return v, true
+	case map[string]interface{}:
+		for _, field := range []string{"data", "result", "list"} {
+			if inner, ok := v[field]; ok {
+				if list, ok := rowList(inner); ok {
+					return list, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+func parseDecimal(v interface{}) decimal.Decimal {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		return decimal.Zero
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
+
+// parseTime reads a Unix open time in seconds or milliseconds
+func parseTime(v interface{}) (time.Time, bool) {
+	d := parseDecimal(v)
+	if d.Sign() <= 0 {
+		return time.Time{}, false
+	}
+	if d.GreaterThanOrEqual(decimal.NewFromInt(1e11)) {
+		return time.UnixMilli(d.IntPart()).UTC(), true
+	}
+	return time.Unix(d.IntPart(), 0).UTC(), true
+}
@@ -0,0 +1,56 @@
+package klines
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		exchange string
+		body     string
+		trades   uint64
+	}{
+		{"binance", `[[1700000000000,"100","110","95","105","12.5",1700000059999,"1300",42,"6","600","0"],
+			[1700000060000,"105","106","104","106","1",1700000119999,"106",3,"0","0","0"]]`, 42},
+		{"okx", `{"code":"0","data":[["1700000060000","105","106","104","106","1","106","106","0"],
+			["1700000000000","100","110","95","105","12.5","1300","1300","1"]]}`, 0},
+		{"bybit", `{"retCode":0,"result":{"category":"spot","list":[["1700000060000","105","106","104","106","1","106"],
+			["1700000000000","100","110","95","105","12.5","1300"]]}}`, 0},
+		{"gateio", `[["1700000000","1300","105","110","95","100","12.5","true"],["1700000060","106","106","106","104","105","1","false"]]`, 0},
+		{"kucoin", `{"code":"200000","data":[["1700000060","105","106","106","104","1","106"],
+			["1700000000","100","105","110","95","12.5","1300"]]}`, 0},
+	}
+	for _, tt := range tests {
+		candles, err := sources[tt.exchange].parse([]byte(tt.body))
+		if err != nil || len(candles) != 2 {
+			t.Errorf("%s: parse = %+v, %v", tt.exchange, candles, err)
+			continue
+		}
+		first := candles[0]
+		want := time.Unix(1700000000, 0)
+		if !first.OpenTime.Equal(want) || candles[1].OpenTime.Sub(want) != time.Minute {
+			t.Errorf("%s: open times %v, %v; want oldest first from %v", tt.exchange, first.OpenTime, candles[1].OpenTime, want)
+		}
+		d := decimal.RequireFromString
+		if !first.Open.Equal(d("100")) || !first.High.Equal(d("110")) || !first.Low.Equal(d("95")) ||
+			!first.Close.Equal(d("105")) || !first.Volume.Equal(d("12.5")) || first.TradesCount != tt.trades {
+			t.Errorf("%s: first candle = %+v", tt.exchange, first)
+		}
+	}
+}
+
+func TestParsePairs(t *testing.T) {
+	pairs, err := ParsePairs("binance:BTCUSDT, OKX:BTC-USDT")
+	if err != nil || len(pairs) != 2 || pairs[1] != (Pair{ExchangeID: "okx", Symbol: "BTC-USDT"}) {
+		t.Errorf("ParsePairs = %+v, %v", pairs, err)
+	}
+	if _, err := ParsePairs("kraken:XBTUSD"); err == nil {
+		t.Error("an exchange without klines should be rejected")
+	}
+	if _, err := ParsePairs("BTCUSDT"); err == nil {
+		t.Error("a pair without an exchange should be rejected")
+	}
+}
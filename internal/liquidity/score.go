@@ -0,0 +1,78 @@
+package liquidity
+
+import (
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+// Component weights; they sum to the maximum score of 100
+const (
+	volumeWeight   = 40.0
+	exchangeWeight = 30.0
+	spreadWeight   = 30.0
+)
+
+const (
+	// minVolume is the quote volume below which the volume component is 0
+	minVolume = 1e4
+	// fullVolume is the quote volume at which the volume component is maxed
+	fullVolume = 1e9
+	// fullExchangeCount is the number of exchanges that earns the full
+	// exchange component
+	fullExchangeCount = 10
+	// maxSpreadBps is the cross-exchange spread at which the spread
+	// component drops to 0
+	maxSpreadBps = 100.0
+)
+
+// Score rates how liquid a pair is on a 0-100 scale from its 24h volume in
+// the quote currency, the number of exchanges contributing to its VWAP and
+// the spread between the highest and lowest exchange price in basis points.
+//
+// Volume is scored on a log scale since it spans many orders of magnitude.
+// A single exchange has no cross-exchange spread to measure, so it earns
+// half of the spread component rather than a perfect one.
+func Score(quoteVolume decimal.Decimal, exchangeCount int, spreadBps float64) float64 {
+	score := volumeScore(quoteVolume.InexactFloat64()) +
+		exchangeScore(exchangeCount) +
+		spreadScore(exchangeCount, spreadBps)
+	return math.Round(score*100) / 100
+}
+
+// SpreadBps returns the spread between the highest and lowest price relative
+// to ref, in basis points. It returns 0 for fewer than two prices.
+func SpreadBps(prices []decimal.Decimal, ref decimal.Decimal) float64 {
+	if len(prices) < 2 || !ref.IsPositive() {
+		return 0
+	}
+	lo, hi := prices[0], prices[0]
+	for _, p := range prices[1:] {
+		lo = decimal.Min(lo, p)
+		hi = decimal.Max(hi, p)
+	}
+	return hi.Sub(lo).Div(ref).Mul(decimal.NewFromInt(10000)).InexactFloat64()
+}
+
+func volumeScore(volume float64) float64 {
+	if volume <= minVolume {
+		return 0
+	}
+	f := (math.Log10(volume) - math.Log10(minVolume)) / (math.Log10(fullVolume) - math.Log10(minVolume))
+	return volumeWeight * clamp(f)
+}
+
+func exchangeScore(count int) float64 {
+	return exchangeWeight * clamp(float64(count)/fullExchangeCount)
+}
+
+func spreadScore(exchangeCount int, spreadBps float64) float64 {
+	if exchangeCount < 2 {
+		return spreadWeight / 2
+	}
+	return spreadWeight * clamp(1-spreadBps/maxSpreadBps)
+}
+
+func clamp(f float64) float64 {
+	return math.Max(0, math.Min(1, f))
+}
@@ -0,0 +1,251 @@
+// Package listings detects base symbols appearing on an exchange for the first
+// time and serves them as a new-listing feed.
+package listings
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Listing is the first sighting of a base symbol on an exchange
+type Listing struct {
+	ID          int
+	ExchangeID  string
+	BaseSymbol  string
+	QuoteSymbol string
+	PairSymbol  string
+	TokenID     int // 0 when the base symbol could not be mapped
+	FirstPrice  decimal.Decimal
+	// MappingMethod is how the base symbol was mapped ("manual", "symbol",
+	// "slug", ...), empty when it was not
+	MappingMethod string
+	AutoMapped    bool
+	FirstSeenAt   time.Time
+}
+
+// Detector records the base symbols each exchange lists and emits a Listing
+// for each one it has not seen on that exchange before. The first poll of an
+// exchange only records a baseline, so deploying the detector, or adding an
+// exchange, does not announce everything it already lists.
+type Detector struct {
+	db     *sql.DB
+	logger *zap.Logger
+
+	mu          sync.Mutex
+	loaded      bool
+	known       map[string]map[string]bool // exchangeID -> base symbol
+	subscribers []func(Listing)
+}
+
+// NewDetector creates a new listing detector
+func NewDetector(db *sql.DB, logger *zap.Logger) *Detector {
+	return &Detector{
+		db:     db,
+		logger: logger,
+		known:  make(map[string]map[string]bool),
+	}
+}
+
+// Subscribe registers fn to be called with each new listing. fn runs on the
+// polling goroutine and should not block.
+func (d *Detector) Subscribe(fn func(Listing)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscribers = append(d.subscribers, fn)
+}
+
+// Observe checks a poll cycle's tickers, after token ID resolution, for base
+// symbols new to their exchange. When a symbol trades against several quotes
+// its first price is taken from a USD quote if there is one.
+func (d *Detector) Observe(ctx context.Context, tickers []exchanges.TickerData) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.loaded {
+		if err := d.load(ctx); err != nil {
+			return err
+		}
+		d.loaded = true
+	}
+
+	candidates := make(map[string]map[string]*exchanges.TickerData)
+	for i := range tickers {
+		t := &tickers[i]
+		base := strings.ToUpper(t.BaseSymbol)
+		if base == "" || d.known[t.ExchangeID][base] {
+			continue
+		}
+		if candidates[t.ExchangeID] == nil {
+			candidates[t.ExchangeID] = make(map[string]*exchanges.TickerData)
+		}
+		if prev, ok := candidates[t.ExchangeID][base]; !ok || (isUSDQuote(t.QuoteSymbol) && !isUSDQuote(prev.QuoteSymbol)) {
+			candidates[t.ExchangeID][base] = t
+		}
+	}
+
+	for exchangeID, bases := range candidates {
+		initial := len(d.known[exchangeID]) == 0
+		added, err := d.record(ctx, exchangeID, bases, initial)
+		if err != nil {
+			return err
+		}
+		if d.known[exchangeID] == nil {
+			d.known[exchangeID] = make(map[string]bool)
+		}
+		for base := range bases {
+			d.known[exchangeID][base] = true
+		}
+
+		if initial {
+			d.logger.Info("Recorded exchange listing baseline",
+				zap.String("exchange", exchangeID),
+				zap.Int("base_symbols", len(added)))
+			continue
+		}
+		for _, l := range added {
+			d.logger.Info("New listing detected",
+				zap.String("exchange", l.ExchangeID),
+				zap.String("base", l.BaseSymbol),
+				zap.String("pair", l.PairSymbol),
+				zap.String("first_price", l.FirstPrice.String()),
+				zap.Int("token_id", l.TokenID),
+				zap.Bool("auto_mapped", l.AutoMapped))
+			for _, fn := range d.subscribers {
+				fn(l)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Detector) load(ctx context.Context) error {
+	rows, err := d.db.QueryContext(ctx, `SELECT exchange_id, base_symbol FROM exchange_listings`)
+	if err != nil {
+		return fmt.Errorf("failed to query exchange listings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var exchangeID, base string
+		if err := rows.Scan(&exchangeID, &base); err != nil {
+			return fmt.Errorf("failed to scan exchange listing: %w", err)
+		}
+		if d.known[exchangeID] == nil {
+			d.known[exchangeID] = make(map[string]bool)
+		}
+		d.known[exchangeID][base] = true
+	}
+	return rows.Err()
+}
+
+// record inserts the first sightings of an exchange's new base symbols and
+// returns those this call inserted. Another process may have recorded some
+// already, in which case they are skipped.
+func (d *Detector) record(ctx context.Context, exchangeID string, bases map[string]*exchanges.TickerData, initial bool) ([]Listing, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		WITH m AS (
+			SELECT CASE WHEN $5::int = 0 THEN NULL ELSE COALESCE(
+				(SELECT mapping_method FROM token_exchange_symbols WHERE exchange_id = $1 AND exchange_symbol = $2),
+				(SELECT mapping_method FROM trading_pairs WHERE exchange_id = $1 AND exchange_pair_symbol = $4)
+			) END AS method
+		)
+		INSERT INTO exchange_listings (exchange_id, base_symbol, quote_symbol, pair_symbol, token_id,
+		                               first_price, mapping_method, auto_mapped, is_initial)
+		SELECT $1::text, $2::text, $3::text, $4::text, NULLIF($5::int, 0), $6::numeric,
+		       m.method, COALESCE(m.method, 'manual') <> 'manual', $7::boolean
+		FROM m
+		ON CONFLICT (exchange_id, base_symbol) DO NOTHING
+		RETURNING id, token_id, COALESCE(mapping_method, ''), auto_mapped, first_seen_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare listing insert: %w", err)
+	}
+	defer stmt.Close()
+
+	var added []Listing
+	for base, t := range bases {
+		l := Listing{
+			ExchangeID:  exchangeID,
+			BaseSymbol:  base,
+			QuoteSymbol: strings.ToUpper(t.QuoteSymbol),
+			PairSymbol:  t.Symbol,
+			FirstPrice:  t.Price,
+		}
+		var tokenID sql.NullInt64
+		err := stmt.QueryRowContext(ctx, exchangeID, base, l.QuoteSymbol, l.PairSymbol, t.BaseTokenID, t.Price, initial).
+			Scan(&l.ID, &tokenID, &l.MappingMethod, &l.AutoMapped, &l.FirstSeenAt)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to record listing %s on %s: %w", base, exchangeID, err)
+		}
+		l.TokenID = int(tokenID.Int64)
+		added = append(added, l)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit listings: %w", err)
+	}
+	return added, nil
+}
+
+// Filter narrows Recent
+type Filter struct {
+	ExchangeID string    // empty for all exchanges
+	Since      time.Time // zero for no lower bound
+	Limit      int
+}
+
+// Recent returns new listings, newest first. Baseline rows are left out.
+func Recent(ctx context.Context, conn *sql.DB, f Filter) ([]Listing, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT id, exchange_id, base_symbol, quote_symbol, pair_symbol, COALESCE(token_id, 0),
+		       COALESCE(first_price, 0), COALESCE(mapping_method, ''), auto_mapped, first_seen_at
+		FROM exchange_listings
+		WHERE is_initial = false
+		  AND ($1 = '' OR exchange_id = $1)
+		  AND first_seen_at >= $2
+		ORDER BY first_seen_at DESC, id DESC
+		LIMIT $3
+	`, f.ExchangeID, f.Since, f.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query new listings: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Listing
+	for rows.Next() {
+		var l Listing
+		if err := rows.Scan(&l.ID, &l.ExchangeID, &l.BaseSymbol, &l.QuoteSymbol, &l.PairSymbol, &l.TokenID,
+			&l.FirstPrice, &l.MappingMethod, &l.AutoMapped, &l.FirstSeenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan listing: %w", err)
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+func isUSDQuote(symbol string) bool {
+	symbol = strings.ToUpper(symbol)
+	for _, q := range db.USDQuoteSymbols {
+		if q == symbol {
+			return true
+		}
+	}
+	return false
+}
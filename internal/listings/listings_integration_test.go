@@ -0,0 +1,85 @@
+//go:build integration
+
+package listings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/testutil"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+func TestDetector(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ids := testutil.SeedTokens(t, pg, "BTC", "PEPE", "USDT")
+	testutil.SeedSymbolMapping(t, pg, ids["PEPE"], "binance", "PEPE", "PEPE")
+	ctx := context.Background()
+
+	ticker := func(base, quote string, price int64, baseID int) exchanges.TickerData {
+		return exchanges.TickerData{
+			ExchangeID:  "binance",
+			Symbol:      base + quote,
+			BaseSymbol:  base,
+			QuoteSymbol: quote,
+			BaseTokenID: baseID,
+			Price:       decimal.NewFromInt(price),
+		}
+	}
+
+	d := NewDetector(pg, zap.NewNop())
+	var emitted []Listing
+	d.Subscribe(func(l Listing) { emitted = append(emitted, l) })
+
+	// The first poll is the baseline
+	if err := d.Observe(ctx, []exchanges.TickerData{ticker("BTC", "USDT", 60000, ids["BTC"])}); err != nil {
+		t.Fatalf("Observe: %v", err)
+	}
+	if len(emitted) != 0 {
+		t.Fatalf("baseline emitted %d listings", len(emitted))
+	}
+
+	err := d.Observe(ctx, []exchanges.TickerData{
+		ticker("BTC", "USDT", 60100, ids["BTC"]),
+		ticker("PEPE", "BTC", 1, ids["PEPE"]),
+		ticker("PEPE", "USDT", 2, ids["PEPE"]),
+		ticker("NEWCOIN", "USDT", 3, 0),
+	})
+	if err != nil {
+		t.Fatalf("Observe: %v", err)
+	}
+	if len(emitted) != 2 {
+		t.Fatalf("emitted %d listings, want PEPE and NEWCOIN", len(emitted))
+	}
+
+	// A new detector picks up what is already recorded
+	again := NewDetector(pg, zap.NewNop())
+	again.Subscribe(func(l Listing) { t.Errorf("re-emitted %s", l.BaseSymbol) })
+	if err := again.Observe(ctx, []exchanges.TickerData{ticker("PEPE", "USDT", 2, ids["PEPE"])}); err != nil {
+		t.Fatalf("Observe: %v", err)
+	}
+
+	recent, err := Recent(ctx, pg, Filter{ExchangeID: "binance", Limit: 10})
+	if err != nil {
+		t.Fatalf("Recent: %v", err)
+	}
+	bySymbol := make(map[string]Listing)
+	for _, l := range recent {
+		bySymbol[l.BaseSymbol] = l
+	}
+	if len(recent) != 2 || bySymbol["BTC"].ID != 0 {
+		t.Fatalf("Recent = %+v, want PEPE and NEWCOIN only", recent)
+	}
+	pepe := bySymbol["PEPE"]
+	if pepe.PairSymbol != "PEPEUSDT" || !pepe.FirstPrice.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("PEPE first seen on %s at %s, want the USD quote PEPEUSDT at 2", pepe.PairSymbol, pepe.FirstPrice)
+	}
+	if pepe.TokenID != ids["PEPE"] || pepe.MappingMethod != "manual" || pepe.AutoMapped {
+		t.Errorf("PEPE token %d, method %q, auto %v", pepe.TokenID, pepe.MappingMethod, pepe.AutoMapped)
+	}
+	if n := bySymbol["NEWCOIN"]; n.TokenID != 0 || n.AutoMapped {
+		t.Errorf("unmapped NEWCOIN = %+v", n)
+	}
+}
@@ -0,0 +1,99 @@
+// Package maintenance keeps the exchanges' scheduled maintenance windows at
+// hand for the pollers, which leave an exchange alone during its windows so
+// the downtime is neither polled nor counted against its health.
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"go.uber.org/zap"
+)
+
+// Schedule is the cached maintenance windows that have not ended, reloaded
+// periodically so windows scheduled by other processes are picked up. It is
+// safe for concurrent use; a nil Schedule has no windows.
+type Schedule struct {
+	postgresDB *sql.DB
+	logger     *zap.Logger
+
+	mu      sync.RWMutex
+	windows map[string][]exchanges.MaintenanceWindow
+}
+
+// NewSchedule creates an empty schedule, filled by Reload
+func NewSchedule(postgresDB *sql.DB, logger *zap.Logger) *Schedule {
+	return &Schedule{
+		postgresDB: postgresDB,
+		logger:     logger,
+		windows:    make(map[string][]exchanges.MaintenanceWindow),
+	}
+}
+
+// Active returns the window an exchange is in at t, and false when it is in
+// none
+func (s *Schedule) Active(exchangeID string, t time.Time) (exchanges.MaintenanceWindow, bool) {
+	if s == nil {
+		return exchanges.MaintenanceWindow{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, w := range s.windows[exchangeID] {
+		if w.Contains(t) {
+			return w, true
+		}
+	}
+	return exchanges.MaintenanceWindow{}, false
+}
+
+// InMaintenance reports whether an exchange is in a maintenance window at t
+func (s *Schedule) InMaintenance(exchangeID string, t time.Time) bool {
+	_, ok := s.Active(exchangeID, t)
+	return ok
+}
+
+// Set replaces the cached windows
+func (s *Schedule) Set(windows []db.MaintenanceWindow) {
+	byExchange := make(map[string][]exchanges.MaintenanceWindow)
+	for _, w := range windows {
+		byExchange[w.ExchangeID] = append(byExchange[w.ExchangeID], w.MaintenanceWindow)
+	}
+	s.mu.Lock()
+	s.windows = byExchange
+	s.mu.Unlock()
+}
+
+// Reload replaces the cached windows with those in the database that have
+// not ended
+func (s *Schedule) Reload(ctx context.Context) error {
+	windows, err := db.ListMaintenanceWindows(ctx, s.postgresDB, "", time.Now())
+	if err != nil {
+		return fmt.Errorf("loading maintenance windows: %w", err)
+	}
+	s.Set(windows)
+	return nil
+}
+
+// Run reloads the windows on start and then every interval until ctx is
+// done
+func (s *Schedule) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reload(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to reload maintenance windows", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
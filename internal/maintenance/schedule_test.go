@@ -0,0 +1,42 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+)
+
+func TestScheduleActive(t *testing.T) {
+	start := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	s := NewSchedule(nil, nil)
+	s.Set([]db.MaintenanceWindow{
+		{ExchangeID: "kraken", MaintenanceWindow: exchanges.MaintenanceWindow{Start: start, End: start.Add(time.Hour), Reason: "upgrade"}},
+	})
+
+	tests := []struct {
+		exchange string
+		at       time.Time
+		want     bool
+	}{
+		{"kraken", start, true},
+		{"kraken", start.Add(59 * time.Minute), true},
+		{"kraken", start.Add(time.Hour), false},
+		{"kraken", start.Add(-time.Second), false},
+		{"binance", start, false},
+	}
+	for _, tt := range tests {
+		if got := s.InMaintenance(tt.exchange, tt.at); got != tt.want {
+			t.Errorf("InMaintenance(%s, %v) = %v, want %v", tt.exchange, tt.at, got, tt.want)
+		}
+	}
+	if w, _ := s.Active("kraken", start); w.Reason != "upgrade" {
+		t.Errorf("active window = %+v", w)
+	}
+
+	var none *Schedule
+	if none.InMaintenance("kraken", start) {
+		t.Error("nil schedule reports maintenance")
+	}
+}
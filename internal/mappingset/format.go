@@ -0,0 +1,252 @@
+package mappingset
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Formats a bundle is written in. A CSV bundle is a zip of manifest.json,
+// with the format version, export time and checksum, and one CSV file per
+// table.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+var (
+	tokenHeader  = []string{"id", "symbol", "name", "slug", "chain", "contract_address", "is_active"}
+	symbolHeader = []string{"token_id", "exchange_id", "exchange_symbol", "normalized_symbol", "chain", "is_active",
+		"mapping_method", "confidence_score", "needs_verification", "verified_by", "verified_at"}
+	pairHeader = []string{"base_token_id", "quote_token_id", "exchange_id", "symbol", "is_active",
+		"mapping_method", "confidence_score", "needs_verification", "verified_by", "verified_at"}
+)
+
+// manifest is manifest.json in a CSV bundle
+type manifest struct {
+	FormatVersion int       `json:"format_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	Checksum      string    `json:"checksum"`
+}
+
+// Write writes the bundle in a format, FormatJSON or FormatCSV
+func (b *Bundle) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(b)
+	case FormatCSV:
+		return b.writeCSV(w)
+	}
+	return fmt.Errorf("unknown bundle format %q: want json or csv", format)
+}
+
+func (b *Bundle) writeCSV(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	f, err := zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(manifest{b.FormatVersion, b.ExportedAt, b.Checksum}); err != nil {
+		return err
+	}
+
+	tokens := [][]string{tokenHeader}
+	for _, t := range b.Tokens {
+		tokens = append(tokens, []string{strconv.Itoa(t.ID), t.Symbol, t.Name, t.Slug, t.Chain, t.ContractAddress,
+			strconv.FormatBool(t.IsActive)})
+	}
+	symbols := [][]string{symbolHeader}
+	for _, s := range b.Symbols {
+		symbols = append(symbols, []string{strconv.Itoa(s.TokenID), s.ExchangeID, s.ExchangeSymbol, s.NormalizedSymbol,
+			s.Chain, strconv.FormatBool(s.IsActive), s.MappingMethod, formatScore(s.ConfidenceScore),
+			strconv.FormatBool(s.NeedsVerification), s.VerifiedBy, formatTime(s.VerifiedAt)})
+	}
+	pairs := [][]string{pairHeader}
+	for _, p := range b.Pairs {
+		pairs = append(pairs, []string{strconv.Itoa(p.BaseTokenID), strconv.Itoa(p.QuoteTokenID), p.ExchangeID, p.Symbol,
+			strconv.FormatBool(p.IsActive), p.MappingMethod, formatScore(p.ConfidenceScore),
+			strconv.FormatBool(p.NeedsVerification), p.VerifiedBy, formatTime(p.VerifiedAt)})
+	}
+
+	for _, file := range []struct {
+		name    string
+		records [][]string
+	}{
+		{"tokens.csv", tokens},
+		{"exchange_symbols.csv", symbols},
+		{"pairs.csv", pairs},
+	} {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if err := csv.NewWriter(f).WriteAll(file.records); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+	return zw.Close()
+}
+
+func formatScore(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// Read reads a bundle in either format, telling them apart by the zip
+// signature a CSV bundle starts with
+func Read(data []byte) (*Bundle, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return readCSV(data)
+	}
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	return &b, nil
+}
+
+func readCSV(data []byte) (*Bundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is missing", ErrInvalidBundle, name)
+		}
+		return f.Open()
+	}
+
+	r, err := open("manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	err = json.NewDecoder(r).Decode(&m)
+	r.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%w: manifest.json: %v", ErrInvalidBundle, err)
+	}
+	b := &Bundle{FormatVersion: m.FormatVersion, ExportedAt: m.ExportedAt, Checksum: m.Checksum,
+		Tokens: []Token{}, Symbols: []Symbol{}, Pairs: []Pair{}}
+
+	err = readRecords(open, "tokens.csv", tokenHeader, func(rec *record) {
+		b.Tokens = append(b.Tokens, Token{ID: rec.int(0), Symbol: rec.str(1), Name: rec.str(2), Slug: rec.str(3),
+			Chain: rec.str(4), ContractAddress: rec.str(5), IsActive: rec.bool(6)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readRecords(open, "exchange_symbols.csv", symbolHeader, func(rec *record) {
+		b.Symbols = append(b.Symbols, Symbol{TokenID: rec.int(0), ExchangeID: rec.str(1), ExchangeSymbol: rec.str(2),
+			NormalizedSymbol: rec.str(3), Chain: rec.str(4), IsActive: rec.bool(5), MappingMethod: rec.str(6),
+			ConfidenceScore: rec.float(7), NeedsVerification: rec.bool(8), VerifiedBy: rec.str(9), VerifiedAt: rec.time(10)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readRecords(open, "pairs.csv", pairHeader, func(rec *record) {
+		b.Pairs = append(b.Pairs, Pair{BaseTokenID: rec.int(0), QuoteTokenID: rec.int(1), ExchangeID: rec.str(2),
+			Symbol: rec.str(3), IsActive: rec.bool(4), MappingMethod: rec.str(5), ConfidenceScore: rec.float(6),
+			NeedsVerification: rec.bool(7), VerifiedBy: rec.str(8), VerifiedAt: rec.time(9)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// record is a CSV row. Its parsers keep the first value that does not
+// parse in err, so a row is checked once after it is read.
+type record struct {
+	values []string
+	err    error
+}
+
+func (r *record) str(i int) string { return r.values[i] }
+
+func (r *record) int(i int) int {
+	n, err := strconv.Atoi(r.values[i])
+	r.fail(err)
+	return n
+}
+
+func (r *record) bool(i int) bool {
+	v, err := strconv.ParseBool(r.values[i])
+	r.fail(err)
+	return v
+}
+
+func (r *record) float(i int) float64 {
+	f, err := strconv.ParseFloat(r.values[i], 64)
+	r.fail(err)
+	return f
+}
+
+func (r *record) time(i int) *time.Time {
+	if r.values[i] == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, r.values[i])
+	r.fail(err)
+	return &t
+}
+
+func (r *record) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// readRecords reads a CSV file of a bundle, checking its header, and calls
+// fn for each row
+func readRecords(open func(string) (io.ReadCloser, error), name string, header []string, fn func(*record)) error {
+	r, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(header)
+	records, err := cr.ReadAll()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidBundle, name, err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("%w: %s has no header", ErrInvalidBundle, name)
+	}
+	for i, col := range header {
+		if records[0][i] != col {
+			return fmt.Errorf("%w: %s column %d is %q, want %q", ErrInvalidBundle, name, i+1, records[0][i], col)
+		}
+	}
+
+	for i, values := range records[1:] {
+		rec := &record{values: values}
+		fn(rec)
+		if rec.err != nil {
+			return fmt.Errorf("%w: %s line %d: %v", ErrInvalidBundle, name, i+2, rec.err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package mappingset
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testBundle() *Bundle {
+	verified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := &Bundle{
+		FormatVersion: FormatVersion,
+		ExportedAt:    time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Tokens: []Token{
+			{ID: 1, Symbol: "BTC", Name: "Bitcoin", Slug: "bitcoin", IsActive: true},
+			{ID: 7, Symbol: "USDC", Name: "USD Coin, bridged", Chain: "base", ContractAddress: "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913", IsActive: true},
+		},
+		Symbols: []Symbol{
+			{TokenID: 1, ExchangeID: "kraken", ExchangeSymbol: "XBT", NormalizedSymbol: "BTC", IsActive: true,
+				MappingMethod: "manual", ConfidenceScore: 1, VerifiedBy: "ops", VerifiedAt: &verified},
+			{TokenID: 7, ExchangeID: "coinbase", ExchangeSymbol: "USDC", NormalizedSymbol: "USDC", Chain: "base",
+				MappingMethod: "symbol", ConfidenceScore: 0.75, NeedsVerification: true},
+		},
+		Pairs: []Pair{
+			{BaseTokenID: 1, QuoteTokenID: 7, ExchangeID: "coinbase", Symbol: "BTC-USDC", IsActive: true,
+				MappingMethod: "symbol", ConfidenceScore: 0.62},
+		},
+	}
+	b.Checksum = b.checksum()
+	return b
+}
+
+func TestWriteRead(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatCSV} {
+		want := testBundle()
+		var buf bytes.Buffer
+		if err := want.Write(&buf, format); err != nil {
+			t.Fatalf("%s: Write: %v", format, err)
+		}
+		got, err := Read(buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s: Read: %v", format, err)
+		}
+		if err := got.Validate(); err != nil {
+			t.Errorf("%s: Validate: %v", format, err)
+		}
+		if got.checksum() != want.Checksum || !got.ExportedAt.Equal(want.ExportedAt) {
+			t.Errorf("%s: read %+v, want %+v", format, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Bundle)
+	}{
+		{"later format", func(b *Bundle) { b.FormatVersion = FormatVersion + 1 }},
+		{"edited rows", func(b *Bundle) { b.Pairs[0].ConfidenceScore = 1 }},
+		{"unknown token", func(b *Bundle) {
+			b.Symbols[0].TokenID = 99
+			b.Checksum = ""
+		}},
+		{"duplicate token", func(b *Bundle) {
+			b.Tokens[1].ID = 1
+			b.Checksum = ""
+		}},
+	}
+	for _, tt := range tests {
+		b := testBundle()
+		tt.change(b)
+		if err := b.Validate(); !errors.Is(err, ErrInvalidBundle) {
+			t.Errorf("%s: Validate = %v, want ErrInvalidBundle", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+// Package mappingset exports the mapping dataset (tokens, exchange symbol
+// mappings and trading pairs with their confidence) as a versioned bundle,
+// and imports such a bundle into another database.
+//
+// Token IDs differ between databases, so a bundle links symbols and pairs to
+// its tokens by the IDs they had where it was exported, and Import matches
+// each token to the target's by its identity: chain and contract when it has
+// a contract, else symbol and chain.
+package mappingset
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+)
+
+// FormatVersion is the bundle format Export writes. Import refuses bundles
+// of a later format.
+const FormatVersion = 1
+
+// ErrInvalidBundle is returned by Import for a bundle that cannot be
+// imported, such as one of an unknown format or with a symbol or pair that
+// refers to a token it does not have
+var ErrInvalidBundle = errors.New("invalid mapping bundle")
+
+// Bundle is a snapshot of the mapping dataset
+type Bundle struct {
+	FormatVersion int       `json:"format_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	// Checksum is the SHA-256 of the tokens, symbols and pairs, so two
+	// bundles with the same checksum hold the same rows
+	Checksum string   `json:"checksum"`
+	Tokens   []Token  `json:"tokens"`
+	Symbols  []Symbol `json:"exchange_symbols"`
+	Pairs    []Pair   `json:"pairs"`
+}
+
+// Token is a tokens row. ID is its ID where the bundle was exported and
+// only links symbols and pairs to it.
+type Token struct {
+	ID              int    `json:"id"`
+	Symbol          string `json:"symbol"`
+	Name            string `json:"name"`
+	Slug            string `json:"slug,omitempty"`
+	Chain           string `json:"chain,omitempty"`
+	ContractAddress string `json:"contract_address,omitempty"`
+	IsActive        bool   `json:"is_active"`
+}
+
+// Symbol is a token_exchange_symbols row
+type Symbol struct {
+	TokenID           int        `json:"token_id"`
+	ExchangeID        string     `json:"exchange_id"`
+	ExchangeSymbol    string     `json:"exchange_symbol"`
+	NormalizedSymbol  string     `json:"normalized_symbol"`
+	Chain             string     `json:"chain,omitempty"`
+	IsActive          bool       `json:"is_active"`
+	MappingMethod     string     `json:"mapping_method"`
+	ConfidenceScore   float64    `json:"confidence_score"`
+	NeedsVerification bool       `json:"needs_verification"`
+	VerifiedBy        string     `json:"verified_by,omitempty"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+}
+
+// Pair is a trading_pairs row
+type Pair struct {
+	BaseTokenID       int        `json:"base_token_id"`
+	QuoteTokenID      int        `json:"quote_token_id"`
+	ExchangeID        string     `json:"exchange_id"`
+	Symbol            string     `json:"symbol"`
+	IsActive          bool       `json:"is_active"`
+	MappingMethod     string     `json:"mapping_method"`
+	ConfidenceScore   float64    `json:"confidence_score"`
+	NeedsVerification bool       `json:"needs_verification"`
+	VerifiedBy        string     `json:"verified_by,omitempty"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+}
+
+// Querier runs the export queries; *sql.DB and *sql.Tx are both one
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Export reads the whole mapping dataset, inactive rows included
+func Export(ctx context.Context, q Querier) (*Bundle, error) {
+	b := &Bundle{
+		FormatVersion: FormatVersion,
+		ExportedAt:    time.Now().UTC().Truncate(time.Second),
+		Tokens:        []Token{},
+		Symbols:       []Symbol{},
+		Pairs:         []Pair{},
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, symbol, name, COALESCE(slug, ''), COALESCE(chain, ''),
+			COALESCE(contract_address, ''), COALESCE(is_active, true)
+		FROM tokens
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tokens: %w", err)
+	}
+	for rows.Next() {
+		var t Token
+		if err := rows.Scan(&t.ID, &t.Symbol, &t.Name, &t.Slug, &t.Chain, &t.ContractAddress, &t.IsActive); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		b.Tokens = append(b.Tokens, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tokens: %w", err)
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT token_id, exchange_id, exchange_symbol, normalized_symbol, COALESCE(chain, ''),
+			COALESCE(is_active, true), COALESCE(mapping_method, 'manual'), COALESCE(confidence_score, 1),
+			COALESCE(needs_verification, false), COALESCE(verified_by, ''), verified_at
+		FROM token_exchange_symbols
+		ORDER BY exchange_id, exchange_symbol
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange symbols: %w", err)
+	}
+	for rows.Next() {
+		var s Symbol
+		var verifiedAt sql.NullTime
+		if err := rows.Scan(&s.TokenID, &s.ExchangeID, &s.ExchangeSymbol, &s.NormalizedSymbol, &s.Chain,
+			&s.IsActive, &s.MappingMethod, &s.ConfidenceScore,
+			&s.NeedsVerification, &s.VerifiedBy, &verifiedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan exchange symbol: %w", err)
+		}
+		s.VerifiedAt = timePtr(verifiedAt)
+		b.Symbols = append(b.Symbols, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read exchange symbols: %w", err)
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT base_token_id, quote_token_id, exchange_id, exchange_pair_symbol,
+			COALESCE(is_active, true), COALESCE(mapping_method, 'manual'), COALESCE(confidence_score, 1),
+			COALESCE(needs_verification, false), COALESCE(verified_by, ''), verified_at
+		FROM trading_pairs
+		ORDER BY exchange_id, exchange_pair_symbol
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trading pairs: %w", err)
+	}
+	for rows.Next() {
+		var p Pair
+		var verifiedAt sql.NullTime
+		if err := rows.Scan(&p.BaseTokenID, &p.QuoteTokenID, &p.ExchangeID, &p.Symbol,
+			&p.IsActive, &p.MappingMethod, &p.ConfidenceScore,
+			&p.NeedsVerification, &p.VerifiedBy, &verifiedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan trading pair: %w", err)
+		}
+		p.VerifiedAt = timePtr(verifiedAt)
+		b.Pairs = append(b.Pairs, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trading pairs: %w", err)
+	}
+
+	b.Checksum = b.checksum()
+	return b, nil
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+// checksum hashes the rows. The JSON of the same rows in the same order is
+// always the same, whichever format the bundle was read from.
+func (b *Bundle) checksum() string {
+	data, _ := json.Marshal(struct {
+		Tokens  []Token  `json:"tokens"`
+		Symbols []Symbol `json:"exchange_symbols"`
+		Pairs   []Pair   `json:"pairs"`
+	}{b.Tokens, b.Symbols, b.Pairs})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Validate checks that a bundle can be imported: its format is known, its
+// checksum matches its rows when it has one, and every symbol and pair
+// refers to one of its tokens
+func (b *Bundle) Validate() error {
+	if b.FormatVersion < 1 || b.FormatVersion > FormatVersion {
+		return fmt.Errorf("%w: format version %d, want 1 to %d", ErrInvalidBundle, b.FormatVersion, FormatVersion)
+	}
+	if b.Checksum != "" && b.Checksum != b.checksum() {
+		return fmt.Errorf("%w: checksum does not match its rows", ErrInvalidBundle)
+	}
+
+	tokens := make(map[int]bool, len(b.Tokens))
+	for _, t := range b.Tokens {
+		if t.Symbol == "" || t.Name == "" {
+			return fmt.Errorf("%w: token %d needs a symbol and a name", ErrInvalidBundle, t.ID)
+		}
+		if tokens[t.ID] {
+			return fmt.Errorf("%w: token ID %d appears twice", ErrInvalidBundle, t.ID)
+		}
+		tokens[t.ID] = true
+	}
+	for _, s := range b.Symbols {
+		if !tokens[s.TokenID] {
+			return fmt.Errorf("%w: %s symbol %s refers to token %d, which it does not have",
+				ErrInvalidBundle, s.ExchangeID, s.ExchangeSymbol, s.TokenID)
+		}
+		if s.ExchangeID == "" || s.ExchangeSymbol == "" || s.NormalizedSymbol == "" {
+			return fmt.Errorf("%w: exchange symbols need an exchange, a symbol and a normalized symbol", ErrInvalidBundle)
+		}
+	}
+	for _, p := range b.Pairs {
+		if !tokens[p.BaseTokenID] || !tokens[p.QuoteTokenID] {
+			return fmt.Errorf("%w: %s pair %s refers to a token it does not have", ErrInvalidBundle, p.ExchangeID, p.Symbol)
+		}
+		if p.ExchangeID == "" || p.Symbol == "" {
+			return fmt.Errorf("%w: trading pairs need an exchange and a symbol", ErrInvalidBundle)
+		}
+	}
+	return nil
+}
+
+// Counts counts what importing one kind of row did
+type Counts struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+}
+
+// ImportSummary is what Import did
+type ImportSummary struct {
+	Tokens  Counts `json:"tokens"`
+	Symbols Counts `json:"exchange_symbols"`
+	Pairs   Counts `json:"pairs"`
+}
+
+// Import writes a bundle in tx. Tokens are matched to existing ones by
+// identity and added when missing; symbols and pairs are upserted by
+// exchange and symbol and take the bundle's token, status and confidence,
+// verified or not. Rows the bundle does not have are left alone.
+func Import(ctx context.Context, tx *sql.Tx, b *Bundle) (ImportSummary, error) {
+	var summary ImportSummary
+	if err := b.Validate(); err != nil {
+		return summary, err
+	}
+
+	ids, err := importTokens(ctx, tx, b.Tokens, &summary.Tokens)
+	if err != nil {
+		return summary, err
+	}
+	if err := importSymbols(ctx, tx, b.Symbols, ids, &summary.Symbols); err != nil {
+		return summary, err
+	}
+	if err := importPairs(ctx, tx, b.Pairs, ids, &summary.Pairs); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// importTokens returns the target's ID for each bundle token ID
+func importTokens(ctx context.Context, tx *sql.Tx, tokens []Token, counts *Counts) (map[int]int, error) {
+	find, err := tx.PrepareContext(ctx, `
+		SELECT id FROM tokens
+		WHERE CASE
+			WHEN $3 <> '' THEN chain = $2 AND contract_address = $3
+			WHEN $2 = '' THEN symbol = $1 AND chain IS NULL
+			ELSE symbol = $1 AND chain = $2 AND contract_address IS NULL
+		END
+		ORDER BY id
+		LIMIT 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer find.Close()
+
+	update, err := tx.PrepareContext(ctx, `
+		UPDATE tokens SET name = $2, slug = NULLIF($3, ''), is_active = $4, updated_at = NOW()
+		WHERE id = $1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer update.Close()
+
+	insert, err := tx.PrepareContext(ctx, `
+		INSERT INTO tokens (symbol, name, slug, chain, contract_address, is_active)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6)
+		RETURNING id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer insert.Close()
+
+	ids := make(map[int]int, len(tokens))
+	for _, t := range tokens {
+		var id int
+		err := find.QueryRowContext(ctx, t.Symbol, t.Chain, t.ContractAddress).Scan(&id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			if err := insert.QueryRowContext(ctx, t.Symbol, t.Name, t.Slug, t.Chain, t.ContractAddress, t.IsActive).Scan(&id); err != nil {
+				return nil, fmt.Errorf("failed to add token %s: %w", t.Symbol, err)
+			}
+			counts.Inserted++
+		case err != nil:
+			return nil, fmt.Errorf("failed to look up token %s: %w", t.Symbol, err)
+		default:
+			if _, err := update.ExecContext(ctx, id, t.Name, t.Slug, t.IsActive); err != nil {
+				return nil, fmt.Errorf("failed to update token %s: %w", t.Symbol, err)
+			}
+			counts.Updated++
+		}
+		ids[t.ID] = id
+	}
+	return ids, nil
+}
+
+func importSymbols(ctx context.Context, tx *sql.Tx, symbols []Symbol, ids map[int]int, counts *Counts) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO token_exchange_symbols (
+			token_id, exchange_id, exchange_symbol, normalized_symbol, chain, is_active,
+			mapping_method, confidence_score, needs_verification, verified_by, verified_at
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, NULLIF($10, ''), $11)
+		ON CONFLICT (exchange_id, exchange_symbol) DO UPDATE SET
+			token_id = EXCLUDED.token_id,
+			normalized_symbol = EXCLUDED.normalized_symbol,
+			chain = EXCLUDED.chain,
+			is_active = EXCLUDED.is_active,
+			mapping_method = EXCLUDED.mapping_method,
+			confidence_score = EXCLUDED.confidence_score,
+			needs_verification = EXCLUDED.needs_verification,
+			verified_by = EXCLUDED.verified_by,
+			verified_at = EXCLUDED.verified_at,
+			updated_at = NOW()
+		RETURNING xmax = 0
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, s := range symbols {
+		var inserted bool
+		if err := stmt.QueryRowContext(ctx, ids[s.TokenID], s.ExchangeID, s.ExchangeSymbol, s.NormalizedSymbol,
+			s.Chain, s.IsActive, s.MappingMethod, s.ConfidenceScore,
+			s.NeedsVerification, s.VerifiedBy, s.VerifiedAt).Scan(&inserted); err != nil {
+			return fmt.Errorf("failed to write %s symbol %s: %w", s.ExchangeID, s.ExchangeSymbol, err)
+		}
+		count(counts, inserted)
+	}
+	return nil
+}
+
+func importPairs(ctx context.Context, tx *sql.Tx, pairs []Pair, ids map[int]int, counts *Counts) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO trading_pairs (
+			base_token_id, quote_token_id, exchange_id, exchange_pair_symbol, is_active,
+			mapping_method, confidence_score, needs_verification, verified_by, verified_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10)
+		ON CONFLICT (exchange_id, exchange_pair_symbol) DO UPDATE SET
+			base_token_id = EXCLUDED.base_token_id,
+			quote_token_id = EXCLUDED.quote_token_id,
+			is_active = EXCLUDED.is_active,
+			mapping_method = EXCLUDED.mapping_method,
+			confidence_score = EXCLUDED.confidence_score,
+			needs_verification = EXCLUDED.needs_verification,
+			verified_by = EXCLUDED.verified_by,
+			verified_at = EXCLUDED.verified_at,
+			updated_at = NOW()
+		RETURNING xmax = 0
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, p := range pairs {
+		var inserted bool
+		if err := stmt.QueryRowContext(ctx, ids[p.BaseTokenID], ids[p.QuoteTokenID], p.ExchangeID, p.Symbol,
+			p.IsActive, p.MappingMethod, p.ConfidenceScore,
+			p.NeedsVerification, p.VerifiedBy, p.VerifiedAt).Scan(&inserted); err != nil {
+			if db.IsUnregisteredExchange(err) {
+				return fmt.Errorf("failed to write %s pair %s: %w in the target registry", p.ExchangeID, p.Symbol, db.ErrExchangeNotFound)
+			}
+			return fmt.Errorf("failed to write %s pair %s: %w", p.ExchangeID, p.Symbol, err)
+		}
+		count(counts, inserted)
+	}
+	return nil
+}
+
+func count(c *Counts, inserted bool) {
+	if inserted {
+		c.Inserted++
+	} else {
+		c.Updated++
+	}
+}
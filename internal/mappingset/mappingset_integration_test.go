@@ -0,0 +1,75 @@
+//go:build integration
+
+package mappingset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	source := testutil.Postgres(t)
+	ids := testutil.SeedTokens(t, source, "ZZA", "ZZB")
+	testutil.SeedSymbolMapping(t, source, ids["ZZA"], "kraken", "XZZA", "ZZA")
+	testutil.SeedTradingPair(t, source, ids["ZZA"], ids["ZZB"], "kraken", "XZZAZZB")
+	if _, err := source.Exec(`UPDATE token_exchange_symbols SET confidence_score = 0.6 WHERE exchange_symbol = 'XZZA'`); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := Export(ctx, source)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if err := bundle.Validate(); err != nil {
+		t.Fatalf("exported bundle does not validate: %v", err)
+	}
+
+	// The target gets its own token IDs; ZZB already exists there
+	target := testutil.Postgres(t)
+	testutil.SeedTokens(t, target, "ZZB")
+	testutil.SeedExchange(t, target, "kraken")
+	importBundle := func() ImportSummary {
+		tx, err := target.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+		summary, err := Import(ctx, tx, bundle)
+		if err != nil {
+			t.Fatalf("Import: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		return summary
+	}
+
+	summary := importBundle()
+	if summary.Symbols.Inserted == 0 || summary.Pairs.Inserted == 0 || summary.Tokens.Updated == 0 {
+		t.Errorf("first import = %+v, want symbols and pairs inserted and existing tokens updated", summary)
+	}
+
+	var tokenID int
+	var score float64
+	if err := target.QueryRow(`
+		SELECT t.id, s.confidence_score FROM token_exchange_symbols s JOIN tokens t ON t.id = s.token_id
+		WHERE s.exchange_id = 'kraken' AND s.exchange_symbol = 'XZZA' AND t.symbol = 'ZZA'
+	`).Scan(&tokenID, &score); err != nil {
+		t.Fatalf("imported mapping: %v", err)
+	}
+	if score != 0.6 {
+		t.Errorf("imported confidence = %v, want 0.6", score)
+	}
+
+	// Importing again changes nothing, so the target exports the same rows
+	if summary := importBundle(); summary.Tokens.Inserted+summary.Symbols.Inserted+summary.Pairs.Inserted != 0 {
+		t.Errorf("second import inserted rows: %+v", summary)
+	}
+	var pairBase int
+	if err := target.QueryRow(`SELECT base_token_id FROM trading_pairs WHERE exchange_pair_symbol = 'XZZAZZB'`).Scan(&pairBase); err != nil || pairBase != tokenID {
+		t.Errorf("imported pair base = %d, %v; want token %d", pairBase, err, tokenID)
+	}
+}
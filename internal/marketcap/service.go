@@ -0,0 +1,171 @@
+package marketcap
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Ranking is the computed market cap of a single token
+type Ranking struct {
+	TokenID   int
+	Price     decimal.Decimal
+	MarketCap decimal.Decimal
+	Rank      int
+}
+
+// Service computes market cap and rank from circulating supply and our own
+// USD VWAP, so the listing does not depend on third-party rank fields
+type Service struct {
+	postgresDB  *sql.DB
+	vwapStorage *storage.VWAPStorage
+	logger      *zap.Logger
+}
+
+// NewService creates a new market cap service
+func NewService(postgresDB *sql.DB, vwapStorage *storage.VWAPStorage, logger *zap.Logger) *Service {
+	return &Service{
+		postgresDB:  postgresDB,
+		vwapStorage: vwapStorage,
+		logger:      logger,
+	}
+}
+
+// ComputeAndStore recomputes market cap and rank for every active token with a
+// known circulating supply and a USD VWAP from the last 24h, and stores them
+// on tokens. Tokens that no longer qualify have their computed values cleared.
+func (s *Service) ComputeAndStore(ctx context.Context) error {
+	quoteIDs, err := db.GetUSDQuoteTokenIDs(ctx, s.postgresDB)
+	if err != nil {
+		return err
+	}
+
+	prices, err := s.vwapStorage.GetLatestVWAPByQuote(ctx, quoteIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get USD VWAP prices: %w", err)
+	}
+
+	supplies, err := s.circulatingSupplies(ctx)
+	if err != nil {
+		return err
+	}
+
+	usdPrices := make(map[int]decimal.Decimal, len(prices))
+	for id, summary := range prices {
+		usdPrices[id] = summary.Price
+	}
+	rankings := Rank(supplies, usdPrices)
+
+	if err := s.store(ctx, rankings); err != nil {
+		return fmt.Errorf("failed to store market caps: %w", err)
+	}
+
+	s.logger.Info("Market cap computation completed",
+		zap.Int("ranked", len(rankings)),
+		zap.Int("with_supply", len(supplies)))
+
+	return nil
+}
+
+// Rank multiplies each token's supply by its price and ranks the results by
+// descending market cap. Tokens missing either value, or with a non-positive
+// price, are left out. Ties are broken by token ID so ranks are stable.
+func Rank(supplies, prices map[int]decimal.Decimal) []Ranking {
+	rankings := make([]Ranking, 0, len(supplies))
+	for id, supply := range supplies {
+		price, ok := prices[id]
+		if !ok || !price.IsPositive() || !supply.IsPositive() {
+			continue
+		}
+		rankings = append(rankings, Ranking{
+			TokenID:   id,
+			Price:     price,
+			MarketCap: supply.Mul(price).Round(2),
+		})
+	}
+
+	sort.Slice(rankings, func(i, j int) bool {
+		if c := rankings[i].MarketCap.Cmp(rankings[j].MarketCap); c != 0 {
+			return c > 0
+		}
+		return rankings[i].TokenID < rankings[j].TokenID
+	})
+	for i := range rankings {
+		rankings[i].Rank = i + 1
+	}
+	return rankings
+}
+
+func (s *Service) circulatingSupplies(ctx context.Context) (map[int]decimal.Decimal, error) {
+	rows, err := s.postgresDB.QueryContext(ctx, `
+		SELECT id, circulating_supply
+		FROM tokens
+		WHERE is_active = true AND circulating_supply > 0
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query circulating supply: %w", err)
+	}
+	defer rows.Close()
+
+	supplies := make(map[int]decimal.Decimal)
+	for rows.Next() {
+		var id int
+		var supply decimal.Decimal
+		if err := rows.Scan(&id, &supply); err != nil {
+			return nil, fmt.Errorf("failed to scan circulating supply: %w", err)
+		}
+		supplies[id] = supply
+	}
+	return supplies, rows.Err()
+}
+
+// store writes all rankings in one transaction so readers never see a
+// half-updated ranking with duplicate ranks
+func (s *Service) store(ctx context.Context, rankings []Ranking) error {
+	ids := make([]int64, len(rankings))
+	caps := make([]string, len(rankings))
+	prices := make([]string, len(rankings))
+	ranks := make([]int64, len(rankings))
+	for i, r := range rankings {
+		ids[i] = int64(r.TokenID)
+		caps[i] = r.MarketCap.String()
+		prices[i] = r.Price.String()
+		ranks[i] = int64(r.Rank)
+	}
+
+	tx, err := s.postgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE tokens
+		SET computed_market_cap = NULL, computed_rank = NULL, computed_price = NULL,
+			market_cap_updated_at = NOW()
+		WHERE computed_rank IS NOT NULL AND NOT (id = ANY($1))
+	`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to clear stale market caps: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE tokens t
+		SET computed_market_cap = v.market_cap,
+			computed_price = v.price,
+			computed_rank = v.rank,
+			market_cap_updated_at = NOW()
+		FROM unnest($1::int[], $2::numeric[], $3::numeric[], $4::int[]) AS v(id, market_cap, price, rank)
+		WHERE t.id = v.id
+	`, pq.Array(ids), pq.Array(caps), pq.Array(prices), pq.Array(ranks)); err != nil {
+		return fmt.Errorf("failed to update market caps: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -1,14 +1,18 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Trade struct {
-	Symbol       string    `json:"symbol" db:"symbol"`
-	Price        float64   `json:"price" db:"price"`
-	Quantity     float64   `json:"quantity" db:"quantity"`
-	TradeID      uint64    `json:"trade_id" db:"trade_id"`
-	Timestamp    time.Time `json:"timestamp" db:"timestamp"`
-	IsBuyerMaker bool      `json:"is_buyer_maker" db:"is_buyer_maker"`
+	Symbol       string          `json:"symbol" db:"symbol"`
+	Price        decimal.Decimal `json:"price" db:"price" swaggertype:"string"`
+	Quantity     decimal.Decimal `json:"quantity" db:"quantity" swaggertype:"string"`
+	TradeID      uint64          `json:"trade_id" db:"trade_id"`
+	Timestamp    time.Time       `json:"timestamp" db:"timestamp"`
+	IsBuyerMaker bool            `json:"is_buyer_maker" db:"is_buyer_maker"`
 }
 
 type BinanceTradeEvent struct {
@@ -30,29 +34,44 @@ type BinanceCombinedStreamEvent struct {
 	Data   BinanceTradeEvent `json:"data"`
 }
 
+// Prices and volumes are decimal strings so small-cap prices keep every
+// digit; the 24h fields are omitted when there were no trades to derive them
 type TickerResponse struct {
-	Symbol                string  `json:"symbol"`
-	Price                 float64 `json:"price"`
-	PriceChange24h        float64 `json:"price_change_24h,omitempty"`
-	PriceChangePercent24h float64 `json:"price_change_percent_24h,omitempty"`
-	Volume24h             float64 `json:"volume_24h,omitempty"`
-	High24h               float64 `json:"high_24h,omitempty"`
-	Low24h                float64 `json:"low_24h,omitempty"`
-	Timestamp             int64   `json:"timestamp"`
-	Name                  string  `json:"name,omitempty"`
-	Category              string  `json:"category,omitempty"`
+	Symbol                string           `json:"symbol"`
+	Price                 decimal.Decimal  `json:"price" swaggertype:"string"`
+	PriceChange24h        *decimal.Decimal `json:"price_change_24h,omitempty" swaggertype:"string"`
+	PriceChangePercent24h float64          `json:"price_change_percent_24h,omitempty"`
+	Volume24h             *decimal.Decimal `json:"volume_24h,omitempty" swaggertype:"string"`
+	High24h               *decimal.Decimal `json:"high_24h,omitempty" swaggertype:"string"`
+	Low24h                *decimal.Decimal `json:"low_24h,omitempty" swaggertype:"string"`
+	Timestamp             int64            `json:"timestamp"`
+	Name                  string           `json:"name,omitempty"`
+	Category              string           `json:"category,omitempty"`
+	Source                *DataSource      `json:"source,omitempty"`
 }
 
 type OHLCVResponse struct {
-	Symbol      string  `json:"symbol"`
-	Interval    string  `json:"interval"`
-	Timestamp   int64   `json:"timestamp"`
-	Open        float64 `json:"open"`
-	High        float64 `json:"high"`
-	Low         float64 `json:"low"`
-	Close       float64 `json:"close"`
-	Volume      float64 `json:"volume"`
-	TradesCount int64   `json:"trades_count"`
+	Symbol      string          `json:"symbol"`
+	Interval    string          `json:"interval"`
+	Timestamp   int64           `json:"timestamp"`
+	Open        decimal.Decimal `json:"open" swaggertype:"string"`
+	High        decimal.Decimal `json:"high" swaggertype:"string"`
+	Low         decimal.Decimal `json:"low" swaggertype:"string"`
+	Close       decimal.Decimal `json:"close" swaggertype:"string"`
+	Volume      decimal.Decimal `json:"volume" swaggertype:"string"`
+	TradesCount int64           `json:"trades_count"`
+}
+
+// TradeResponse is one trade of a trade export. Timestamp is in Unix
+// milliseconds; is_buyer_maker means the taker sold.
+type TradeResponse struct {
+	Timestamp    int64           `json:"timestamp"`
+	TradeID      uint64          `json:"trade_id"`
+	Exchange     string          `json:"exchange"`
+	Symbol       string          `json:"symbol"`
+	Price        decimal.Decimal `json:"price" swaggertype:"string"`
+	Quantity     decimal.Decimal `json:"quantity" swaggertype:"string"`
+	IsBuyerMaker bool            `json:"is_buyer_maker"`
 }
 
 type APIResponse struct {
@@ -60,14 +79,38 @@ type APIResponse struct {
 	Data      interface{} `json:"data,omitempty"`
 	Error     string      `json:"error,omitempty"`
 	Message   string      `json:"message,omitempty"`
+	Source    *DataSource `json:"source,omitempty"` // set by endpoints serving one symbol's prices
 	Timestamp int64       `json:"timestamp"`
 }
 
+// Data source types in DataSource.Type
+const (
+	SourceTrades         = "trades"          // built from exchange trades
+	SourceExchangeKlines = "exchange_klines" // candles as an exchange computed them
+	SourceCompositeVWAP  = "composite_vwap"  // built from the multi-exchange VWAP
+)
+
+// DataSource says what a symbol's prices are built from: the kind of data,
+// the exchanges it came from and when it was last updated. LastUpdated is
+// omitted when nothing is stored.
+type DataSource struct {
+	Type        string     `json:"type" enums:"trades,exchange_klines,composite_vwap"`
+	Venues      []string   `json:"venues"`
+	LastUpdated *time.Time `json:"last_updated,omitempty"`
+}
+
 type ErrorResponse struct {
-	Error     string `json:"error"`
-	Message   string `json:"message"`
-	Code      int    `json:"code"`
-	Timestamp int64  `json:"timestamp"`
+	Error     string       `json:"error"`
+	Message   string       `json:"message"`
+	Code      int          `json:"code"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"` // quote it when reporting a problem
+	Timestamp int64        `json:"timestamp"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type HealthResponse struct {
@@ -77,21 +120,884 @@ type HealthResponse struct {
 	Uptime    int64  `json:"uptime,omitempty"`
 }
 
+// TradeStats aggregates a pair's trades over a window. The trade times are
+// in Unix milliseconds and 0 when the window has no trades.
 type TradeStats struct {
-	Symbol         string  `json:"symbol"`
-	TotalTrades    int64   `json:"total_trades"`
-	TotalVolume    float64 `json:"total_volume"`
-	AvgPrice       float64 `json:"avg_price"`
-	MinPrice       float64 `json:"min_price"`
-	MaxPrice       float64 `json:"max_price"`
-	FirstTradeTime int64   `json:"first_trade_time"`
-	LastTradeTime  int64   `json:"last_trade_time"`
+	Symbol         string          `json:"symbol"`
+	TotalTrades    int64           `json:"total_trades"`
+	TotalVolume    decimal.Decimal `json:"total_volume" swaggertype:"string"`
+	AvgPrice       decimal.Decimal `json:"avg_price" swaggertype:"string"`
+	MinPrice       decimal.Decimal `json:"min_price" swaggertype:"string"`
+	MaxPrice       decimal.Decimal `json:"max_price" swaggertype:"string"`
+	FirstTradeTime int64           `json:"first_trade_time"`
+	LastTradeTime  int64           `json:"last_trade_time"`
 }
 
 type MarketSummary struct {
-	TotalSymbols   int     `json:"total_symbols"`
-	TotalTrades24h int64   `json:"total_trades_24h"`
-	TotalVolume24h float64 `json:"total_volume_24h"`
-	ActiveSymbols  int     `json:"active_symbols"`
-	LastUpdateTime int64   `json:"last_update_time"`
+	TotalSymbols   int             `json:"total_symbols"`
+	TotalTrades24h int64           `json:"total_trades_24h"`
+	TotalVolume24h decimal.Decimal `json:"total_volume_24h" swaggertype:"string"`
+	ActiveSymbols  int             `json:"active_symbols"`
+	LastUpdateTime int64           `json:"last_update_time"`
+}
+
+// ExchangeResponse is a registered exchange: the config its client is built
+// from and its poll health. LastSuccessfulPoll is omitted until the first
+// successful poll.
+type ExchangeResponse struct {
+	ID                  string                 `json:"id"`
+	Name                string                 `json:"name"`
+	IsActive            bool                   `json:"is_active"`
+	Weight              float64                `json:"weight"`
+	DynamicWeight       *float64               `json:"dynamic_weight,omitempty"` // set once computed; used while dynamic_exchange_weights is on
+	DynamicWeightAt     *time.Time             `json:"dynamic_weight_updated_at,omitempty"`
+	Trust               *ExchangeTrustResponse `json:"trust,omitempty"` // set once scored
+	BaseURL             string                 `json:"base_url"`
+	TickerEndpoint      string                 `json:"ticker_endpoint"`
+	SymbolsEndpoint     string                 `json:"symbols_endpoint"`
+	TradesEndpoint      string                 `json:"trades_endpoint,omitempty"` // recent trades are polled when set
+	RateLimitPerMinute  int                    `json:"rate_limit_per_minute"`
+	RequestTimeoutMs    int                    `json:"request_timeout_ms"`
+	RetryAttempts       int                    `json:"retry_attempts"`
+	SymbolFormat        string                 `json:"symbol_format"`
+	QuoteCurrencies     []string               `json:"quote_currencies"`
+	TakerFee            float64                `json:"taker_fee"` // base fee tier as a fraction, 0 when unknown
+	MakerFee            float64                `json:"maker_fee"`
+	AllowSymbols        []string               `json:"allow_symbols"` // symbol patterns kept when parsing; empty keeps every symbol not denied
+	DenySymbols         []string               `json:"deny_symbols"`  // symbol patterns dropped when parsing
+	ConsecutiveFailures int                    `json:"consecutive_failures"`
+	LastSuccessfulPoll  *time.Time             `json:"last_successful_poll,omitempty"`
+	CreatedAt           time.Time              `json:"created_at"`
+	UpdatedAt           time.Time              `json:"updated_at"`
+}
+
+// PairResponse is a trading pair with the order rules and fees its exchange
+// lists, for placing orders. Rules the exchange does not give are omitted.
+// The precisions are the decimal places of the tick and step sizes, and fees
+// are fractions of the traded amount.
+type PairResponse struct {
+	ID                int              `json:"id"`
+	Exchange          string           `json:"exchange"`
+	Symbol            string           `json:"symbol"`
+	Base              string           `json:"base"`
+	Quote             string           `json:"quote"`
+	BaseTokenID       int              `json:"base_token_id"`
+	QuoteTokenID      int              `json:"quote_token_id"`
+	InstrumentType    string           `json:"instrument_type" example:"spot"`
+	IsActive          bool             `json:"is_active"`
+	MappingMethod     string           `json:"mapping_method,omitempty"`
+	NeedsVerification bool             `json:"needs_verification"`
+	TickSize          *decimal.Decimal `json:"tick_size,omitempty" swaggertype:"string"`
+	PricePrecision    *int             `json:"price_precision,omitempty"`
+	StepSize          *decimal.Decimal `json:"step_size,omitempty" swaggertype:"string"`
+	QuantityPrecision *int             `json:"quantity_precision,omitempty"`
+	MinQuantity       *decimal.Decimal `json:"min_quantity,omitempty" swaggertype:"string"`
+	MinNotional       *decimal.Decimal `json:"min_notional,omitempty" swaggertype:"string"`
+	TakerFee          *decimal.Decimal `json:"taker_fee,omitempty" swaggertype:"string"`
+	MakerFee          *decimal.Decimal `json:"maker_fee,omitempty" swaggertype:"string"`
+	MetadataUpdatedAt *time.Time       `json:"metadata_updated_at,omitempty"`
+}
+
+type TokenResponse struct {
+	ID         int              `json:"id"`
+	Symbol     string           `json:"symbol"`
+	Name       string           `json:"name"`
+	Price      *decimal.Decimal `json:"price,omitempty" swaggertype:"string"`
+	MarketCap  *float64         `json:"market_cap,omitempty"`
+	Rank       *int64           `json:"rank,omitempty"`
+	Categories []string         `json:"categories"`
+}
+
+// CategoryResponse is a category or tag of the token taxonomy
+type CategoryResponse struct {
+	Slug       string `json:"slug"`
+	Name       string `json:"name"`
+	Kind       string `json:"kind" enums:"category,tag"`
+	Source     string `json:"source" enums:"manual,seed,coingecko"`
+	TokenCount int    `json:"token_count"`
+}
+
+type ServiceHealthResponse struct {
+	Status    string          `json:"status"`
+	Services  map[string]bool `json:"services"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+type VWAPResponse struct {
+	Symbol         string          `json:"symbol"`
+	Quote          string          `json:"quote"`
+	Price          decimal.Decimal `json:"price" swaggertype:"string"`
+	Volume         decimal.Decimal `json:"volume" swaggertype:"string"`
+	ExchangeCount  int             `json:"exchange_count"`
+	Exchanges      []string        `json:"exchanges"`
+	SpreadBps      float64         `json:"spread_bps"`
+	LiquidityScore float64         `json:"liquidity_score"`
+	Indicative     bool            `json:"indicative"`
+	Timestamp      int64           `json:"timestamp"`
+}
+
+type VWAPPairResponse struct {
+	Symbol         string          `json:"symbol"`
+	Quote          string          `json:"quote"`
+	Price          decimal.Decimal `json:"price" swaggertype:"string"`
+	Volume         decimal.Decimal `json:"volume" swaggertype:"string"`
+	ExchangeCount  int             `json:"exchange_count"`
+	SpreadBps      float64         `json:"spread_bps"`
+	LiquidityScore float64         `json:"liquidity_score"`
+	Indicative     bool            `json:"indicative"`
+	Timestamp      int64           `json:"timestamp"`
+}
+
+type VWAPCompositionResponse struct {
+	Symbol     string           `json:"symbol"`
+	Quote      string           `json:"quote"`
+	Price      decimal.Decimal  `json:"price" swaggertype:"string"`
+	Indicative bool             `json:"indicative"`
+	Sources    []VWAPSource     `json:"sources"`
+	Arbitrage  *ArbitrageSpread `json:"arbitrage,omitempty"` // with fees=true and two or more exchanges with known fees
+	Timestamp  int64            `json:"timestamp"`
+}
+
+// VWAPAtResponse is a pair's VWAP at a point in time. Timestamp is that of
+// the sample the price was taken from, or the requested time when it was
+// interpolated between Samples.
+type VWAPAtResponse struct {
+	Symbol             string          `json:"symbol"`
+	Quote              string          `json:"quote"`
+	Price              decimal.Decimal `json:"price" swaggertype:"string"`
+	Method             string          `json:"method" enums:"nearest,previous,linear"`
+	RequestedTimestamp int64           `json:"requested_timestamp"`
+	Timestamp          int64           `json:"timestamp"`
+	ExchangeCount      int             `json:"exchange_count"`
+	Exchanges          []string        `json:"exchanges"`
+	Indicative         bool            `json:"indicative"`
+	Samples            []VWAPPoint     `json:"samples"`
+}
+
+// VWAPPoint is one recorded VWAP sample
+type VWAPPoint struct {
+	Price     decimal.Decimal `json:"price" swaggertype:"string"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// PriceSnapshotResponse is a token's latest price in several currencies.
+// Prices is keyed by currency and leaves out currencies with no VWAP to
+// derive a price from.
+type PriceSnapshotResponse struct {
+	Symbol    string                `json:"symbol"`
+	TokenID   int                   `json:"token_id"`
+	Prices    map[string]PriceQuote `json:"prices"`
+	Timestamp int64                 `json:"timestamp"` // newest of the prices
+}
+
+// PriceQuote is a token's price in one currency. A direct price is one
+// pair's VWAP, a cross price the product or quotient of two, and identity a
+// currency priced in itself. Exchanges lists the exchanges behind the first
+// pair.
+type PriceQuote struct {
+	Price      decimal.Decimal `json:"price" swaggertype:"string"`
+	Method     string          `json:"method" enums:"direct,cross,identity"`
+	Pairs      []string        `json:"pairs"`
+	Exchanges  []string        `json:"exchanges"`
+	Indicative bool            `json:"indicative"`
+	Timestamp  int64           `json:"timestamp"` // oldest of the pairs
+}
+
+// BatchPriceResponse holds one result per distinct symbol requested, in
+// request order. A symbol that cannot be priced gets an error instead of
+// failing the batch.
+type BatchPriceResponse struct {
+	Prices []BatchPrice `json:"prices"`
+	Found  int          `json:"found"`
+	Failed int          `json:"failed"`
+}
+
+// BatchPrice is the latest VWAP of one requested symbol, or why there is none
+type BatchPrice struct {
+	Symbol         string           `json:"symbol"`
+	TokenID        int              `json:"token_id,omitempty"`
+	Quote          string           `json:"quote,omitempty"`
+	Price          *decimal.Decimal `json:"price,omitempty" swaggertype:"string"`
+	Change24h      *decimal.Decimal `json:"change_24h,omitempty" swaggertype:"string"` // percent
+	Volume         *decimal.Decimal `json:"volume,omitempty" swaggertype:"string"`
+	ExchangeCount  int              `json:"exchange_count,omitempty"`
+	LiquidityScore float64          `json:"liquidity_score,omitempty"`
+	Indicative     bool             `json:"indicative,omitempty"`
+	Timestamp      int64            `json:"timestamp,omitempty"`
+	Error          *BatchPriceError `json:"error,omitempty"`
+}
+
+// BatchPriceError says why a symbol in a batch has no price
+type BatchPriceError struct {
+	Code    string `json:"code" enums:"invalid_symbol,token_not_found,price_not_found"`
+	Message string `json:"message"`
+}
+
+// WatchlistResponse is one of the caller's watchlists
+type WatchlistResponse struct {
+	ID        int              `json:"id"`
+	Name      string           `json:"name"`
+	Tokens    []WatchlistToken `json:"tokens"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// WatchlistToken is a member of a watchlist
+type WatchlistToken struct {
+	ID     int    `json:"id"`
+	Symbol string `json:"symbol"`
+}
+
+// WatchlistQuotesResponse is the latest price of every member of a
+// watchlist, in list order
+type WatchlistQuotesResponse struct {
+	ID     int          `json:"id"`
+	Name   string       `json:"name"`
+	Quotes []BatchPrice `json:"quotes"`
+}
+
+// WebhookResponse is a webhook subscription. Secret, the key deliveries are
+// signed with, is only returned when the webhook is created.
+type WebhookResponse struct {
+	ID                  int        `json:"id"`
+	URL                 string     `json:"url"`
+	Symbols             []string   `json:"symbols"`
+	MinIntervalSeconds  int        `json:"min_interval_seconds"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastDeliveredAt     *time.Time `json:"last_delivered_at,omitempty"`
+	Secret              string     `json:"secret,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// OutlierThresholdValues are the thresholds a pair's prices are judged by:
+// a price is an outlier when it is more than max_deviation (a fraction, 0.05
+// = 5%) and max_std_devs standard deviations from the median of at least
+// min_samples prices
+type OutlierThresholdValues struct {
+	MaxDeviation float64 `json:"max_deviation"`
+	MaxStdDevs   float64 `json:"max_std_devs"`
+	MinSamples   int     `json:"min_samples"`
+}
+
+// OutlierThresholdOverride is a pair's override of the default thresholds.
+// Fields left out keep the default; Effective is the result.
+type OutlierThresholdOverride struct {
+	BaseSymbol   string                 `json:"base_symbol"`
+	QuoteSymbol  string                 `json:"quote_symbol"`
+	BaseTokenID  int                    `json:"base_token_id"`
+	QuoteTokenID int                    `json:"quote_token_id"`
+	MaxDeviation *float64               `json:"max_deviation,omitempty"`
+	MaxStdDevs   *float64               `json:"max_std_devs,omitempty"`
+	MinSamples   *int                   `json:"min_samples,omitempty"`
+	Effective    OutlierThresholdValues `json:"effective"`
+	Notes        string                 `json:"notes,omitempty"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+}
+
+// OutlierThresholdsResponse lists the default thresholds and every pair
+// override
+type OutlierThresholdsResponse struct {
+	Defaults  OutlierThresholdValues     `json:"defaults"`
+	Overrides []OutlierThresholdOverride `json:"overrides"`
+}
+
+// FeatureFlagResponse is a feature flag's value and where it comes from:
+// its override (source database), FEATURE_FLAGS (env) or its default
+type FeatureFlagResponse struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Enabled     bool       `json:"enabled"`
+	Default     bool       `json:"default"`
+	Source      string     `json:"source" enums:"default,env,database"`
+	Notes       string     `json:"notes,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// OutlierSuggestion is a fix proposed for a price outlier, applied with
+// POST /api/v1/admin/outliers/{id}/apply. The token fields are set on remap
+// suggestions only: Deviation is how far the exchange price is from the
+// token's CandidatePrice on other exchanges, as a fraction.
+type OutlierSuggestion struct {
+	Action         string           `json:"action" enums:"remap,disable_pair"`
+	Description    string           `json:"description"`
+	TokenID        int              `json:"token_id,omitempty"`
+	TokenSymbol    string           `json:"token_symbol,omitempty"`
+	TokenName      string           `json:"token_name,omitempty"`
+	CandidatePrice *decimal.Decimal `json:"candidate_price,omitempty" swaggertype:"string"`
+	Deviation      *float64         `json:"deviation,omitempty"`
+}
+
+// TickerEvent is the data of a "ticker" event on /api/v1/stream/ticker: a
+// token's new USD VWAP
+type TickerEvent struct {
+	Symbol        string          `json:"symbol"`
+	TokenID       int             `json:"token_id"`
+	Quote         string          `json:"quote"`
+	Price         decimal.Decimal `json:"price" swaggertype:"string"`
+	Change24h     decimal.Decimal `json:"change_24h" swaggertype:"string"` // percent
+	Volume        decimal.Decimal `json:"volume" swaggertype:"string"`
+	ExchangeCount int             `json:"exchange_count"`
+	Indicative    bool            `json:"indicative,omitempty"`
+	Timestamp     int64           `json:"timestamp"`
+}
+
+// VWAPSource is one exchange's contribution to a VWAP. SharePct is its
+// volume times weight as a share of the total. The fee fields are set with
+// fees=true for exchanges whose taker fee is known.
+type VWAPSource struct {
+	Exchange           string           `json:"exchange"`
+	Price              decimal.Decimal  `json:"price" swaggertype:"string"`
+	Volume             decimal.Decimal  `json:"volume" swaggertype:"string"`
+	Weight             decimal.Decimal  `json:"weight" swaggertype:"string"`
+	SharePct           float64          `json:"share_pct"`
+	TakerFee           *decimal.Decimal `json:"taker_fee,omitempty" swaggertype:"string"`
+	EffectiveBuyPrice  *decimal.Decimal `json:"effective_buy_price,omitempty" swaggertype:"string"`
+	EffectiveSellPrice *decimal.Decimal `json:"effective_sell_price,omitempty" swaggertype:"string"`
+}
+
+// ArbitrageSpread is the widest cross-exchange spread after taker fees:
+// buying on BuyExchange and selling on SellExchange. GrossSpreadBps is the raw
+// gap between the two prices and NetSpreadBps what is left after both fees,
+// negative when the fees cost more than the gap.
+type ArbitrageSpread struct {
+	BuyExchange    string          `json:"buy_exchange"`
+	SellExchange   string          `json:"sell_exchange"`
+	BuyPrice       decimal.Decimal `json:"buy_price" swaggertype:"string"`
+	SellPrice      decimal.Decimal `json:"sell_price" swaggertype:"string"`
+	GrossSpreadBps float64         `json:"gross_spread_bps"`
+	NetSpreadBps   float64         `json:"net_spread_bps"`
+}
+
+type LivenessResponse struct {
+	Status    string `json:"status"`
+	Uptime    int64  `json:"uptime"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type ReadinessResponse struct {
+	Status    string                     `json:"status"`
+	Checks    map[string]DependencyCheck `json:"checks"`
+	Tasks     []TaskStatus               `json:"tasks,omitempty"`
+	Timestamp int64                      `json:"timestamp"`
+}
+
+// TaskStatus reports a supervised background job. A job that crashed is
+// restarted with backoff and is not running until then.
+type TaskStatus struct {
+	Name      string `json:"name"`
+	Running   bool   `json:"running"`
+	Restarts  int    `json:"restarts"`
+	LastCrash string `json:"last_crash,omitempty"`
+	CrashedAt *int64 `json:"crashed_at,omitempty"`
+}
+
+type DependencyCheck struct {
+	Status                string   `json:"status"`
+	LatencyMs             float64  `json:"latency_ms"`
+	Error                 string   `json:"error,omitempty"`
+	LastSuccessAgeSeconds *float64 `json:"last_success_age_seconds,omitempty"`
+	Detail                string   `json:"detail,omitempty"`
+}
+
+type TokenDetailResponse struct {
+	ID                int                 `json:"id"`
+	Symbol            string              `json:"symbol"`
+	Name              string              `json:"name"`
+	Slug              string              `json:"slug,omitempty"`
+	Chain             string              `json:"chain,omitempty"`
+	ContractAddress   string              `json:"contract_address,omitempty"`
+	Rank              *int64              `json:"rank,omitempty"`
+	Categories        []string            `json:"categories"`
+	MarketCap         *float64            `json:"market_cap,omitempty"`
+	CirculatingSupply *float64            `json:"circulating_supply,omitempty"`
+	TotalSupply       *float64            `json:"total_supply,omitempty"`
+	MaxSupply         *float64            `json:"max_supply,omitempty"`
+	URLs              map[string][]string `json:"urls"`
+	Contracts         []TokenContract     `json:"contracts"`
+	Markets           []TokenMarket       `json:"markets"`
+	VWAP              *VWAPResponse       `json:"vwap,omitempty"`
+	// Archived tokens are only found by ID; their markets are tombstoned
+	Archived   bool       `json:"archived"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+type TokenContract struct {
+	Platform        string   `json:"platform"`
+	ContractAddress string   `json:"contract_address"`
+	RPCURLs         []string `json:"rpc_urls,omitempty"`
+}
+
+type TokenMarket struct {
+	ExchangeID  string `json:"exchange_id"`
+	PairSymbol  string `json:"pair_symbol"`
+	QuoteSymbol string `json:"quote_symbol"`
+	// spot, perp, futures or leveraged_token
+	InstrumentType string          `json:"instrument_type" example:"spot"`
+	Volume24h      decimal.Decimal `json:"volume_24h" swaggertype:"string"`
+}
+
+type TokenCoverageResponse struct {
+	TokenID       int                `json:"token_id"`
+	Symbol        string             `json:"symbol"`
+	Name          string             `json:"name"`
+	ExchangeCount int                `json:"exchange_count"`
+	PairCount     int                `json:"pair_count"`
+	Exchanges     []ExchangeCoverage `json:"exchanges"`
+}
+
+type ExchangeCoverage struct {
+	ExchangeID string         `json:"exchange_id"`
+	Pairs      []PairCoverage `json:"pairs"`
+}
+
+type PairCoverage struct {
+	PairSymbol     string           `json:"pair_symbol"`
+	QuoteSymbol    string           `json:"quote_symbol"`
+	InstrumentType string           `json:"instrument_type" example:"spot"`
+	LastPrice      *decimal.Decimal `json:"last_price,omitempty" swaggertype:"string"`
+	LastSeen       *time.Time       `json:"last_seen,omitempty"`
+}
+
+type SupplyHistoryResponse struct {
+	TokenID   int              `json:"token_id"`
+	Symbol    string           `json:"symbol"`
+	Days      int              `json:"days"`
+	Snapshots []SupplySnapshot `json:"snapshots"`
+	// Percentage growth of circulating supply between the first and last snapshot
+	CirculatingChangePct *float64 `json:"circulating_change_pct,omitempty"`
+}
+
+type SupplySnapshot struct {
+	Date              string   `json:"date"`
+	CirculatingSupply *float64 `json:"circulating_supply,omitempty"`
+	TotalSupply       *float64 `json:"total_supply,omitempty"`
+	MaxSupply         *float64 `json:"max_supply,omitempty"`
+}
+
+type AnalyticsResponse struct {
+	Symbol    string            `json:"symbol"`
+	Interval  string            `json:"interval"`
+	Candles   int               `json:"candles"`
+	From      int64             `json:"from"`
+	To        int64             `json:"to"`
+	Windows   []AnalyticsWindow `json:"windows"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+// AnalyticsWindow holds statistics over one trailing window. Return and
+// MaxDrawdown are fractions (0.05 = 5%); Volatility is annualized.
+type AnalyticsWindow struct {
+	Window      string   `json:"window"`
+	Candles     int      `json:"candles"`
+	Return      *float64 `json:"return,omitempty"`
+	Volatility  *float64 `json:"volatility,omitempty"`
+	MaxDrawdown *float64 `json:"max_drawdown,omitempty"`
+}
+
+// CorrelationMatrixResponse is a symmetric matrix indexed like Symbols; a
+// null cell means the two tokens had too little overlapping history
+type CorrelationMatrixResponse struct {
+	Window     string       `json:"window"`
+	TokenIDs   []int        `json:"token_ids"`
+	Symbols    []string     `json:"symbols"`
+	Matrix     [][]*float64 `json:"matrix"`
+	ComputedAt int64        `json:"computed_at"`
+}
+
+type IndexResponse struct {
+	ID               int                `json:"id"`
+	Slug             string             `json:"slug"`
+	Name             string             `json:"name"`
+	Description      string             `json:"description,omitempty"`
+	Weighting        string             `json:"weighting"`
+	TopN             *int               `json:"top_n,omitempty"`
+	RebalanceDays    int                `json:"rebalance_days"`
+	LastRebalancedAt *int64             `json:"last_rebalanced_at,omitempty"`
+	BaseLevel        float64            `json:"base_level"`
+	Level            *float64           `json:"level,omitempty"`
+	Change24h        *float64           `json:"change_24h,omitempty"`
+	LevelTimestamp   *int64             `json:"level_timestamp,omitempty"`
+	Constituents     []IndexConstituent `json:"constituents"`
+}
+
+type IndexConstituent struct {
+	TokenID int      `json:"token_id"`
+	Symbol  string   `json:"symbol"`
+	Weight  *float64 `json:"weight,omitempty"`
+}
+
+// Hits were answered from the resolver cache, negative hits from a
+// remembered miss and misses went to the database. Pending writes are
+// discovered mappings and pairs waiting to be written, and dropped writes
+// were discarded because too many were waiting.
+type ResolverStatsResponse struct {
+	Hits            uint64  `json:"hits"`
+	NegativeHits    uint64  `json:"negative_hits"`
+	Misses          uint64  `json:"misses"`
+	HitRatio        float64 `json:"hit_ratio"`
+	Symbols         int     `json:"symbols"`
+	Pairs           int     `json:"pairs"`
+	NegativeEntries int     `json:"negative_entries"`
+	PendingWrites   int     `json:"pending_writes"`
+	DroppedWrites   uint64  `json:"dropped_writes"`
+	LastRefresh     int64   `json:"last_refresh"`
+	Timestamp       int64   `json:"timestamp"`
+}
+
+// AdminStatusResponse gathers the state of this process's background work.
+// Components that do not run in the process are left out.
+type AdminStatusResponse struct {
+	ServiceMode string                   `json:"service_mode"`
+	Poller      *PollerStatusResponse    `json:"poller,omitempty"`
+	Ingester    *IngesterStatusResponse  `json:"ingester,omitempty"`
+	Scheduler   *SchedulerStatusResponse `json:"scheduler,omitempty"`
+	Resolver    ResolverStatsResponse    `json:"resolver"`
+	Tasks       []TaskStatus             `json:"tasks"`
+	Timestamp   int64                    `json:"timestamp"`
+}
+
+// PollerStatusResponse reports the ticker poller's last cycle. The times are
+// Unix seconds, 0 before the first cycle.
+type PollerStatusResponse struct {
+	StartedAt          int64  `json:"started_at"`
+	LastPollAt         int64  `json:"last_poll_at"`
+	LastSuccessAt      int64  `json:"last_success_at"`
+	LastError          string `json:"last_error,omitempty"`
+	ExchangesPolled    int    `json:"exchanges_polled"`
+	ExchangesSucceeded int    `json:"exchanges_succeeded"`
+	// FilteredSymbols lists the exchanges tickers were dropped from when
+	// parsing
+	FilteredSymbols []FilteredSymbolsResponse `json:"filtered_symbols"`
+}
+
+// FilteredSymbolsResponse counts the tickers dropped from an exchange's
+// polls when parsing, in its last poll and since the poller started:
+// filtered by its allow and deny symbol patterns, and non_spot as leveraged
+// tokens or derivatives while the ingest_non_spot flag is off
+type FilteredSymbolsResponse struct {
+	Exchange        string `json:"exchange"`
+	LastPoll        int    `json:"last_poll"`
+	Total           int64  `json:"total"`
+	NonSpotLastPoll int    `json:"non_spot_last_poll"`
+	NonSpotTotal    int64  `json:"non_spot_total"`
+}
+
+// IngesterStatusResponse reports the Binance WebSocket ingester: its
+// connections and the queue of trades in front of ClickHouse. Dropped trades
+// overflowed the queue; failed ones were in batches ClickHouse refused.
+type IngesterStatusResponse struct {
+	Running           bool                    `json:"running"`
+	Connections       int                     `json:"connections"`
+	Connected         int                     `json:"connected"`
+	Messages          uint64                  `json:"messages"`
+	QueueDepth        int                     `json:"queue_depth"`
+	QueueCapacity     int                     `json:"queue_capacity"`
+	TradesWritten     uint64                  `json:"trades_written"`
+	TradesDropped     uint64                  `json:"trades_dropped"`
+	TradesParked      uint64                  `json:"trades_parked"`
+	TradesFailed      uint64                  `json:"trades_failed"`
+	FailedBatches     uint64                  `json:"failed_batches"`
+	DuplicatesSkipped uint64                  `json:"duplicates_skipped"`
+	LastFlushAt       int64                   `json:"last_flush_at"`
+	LastFlushMs       float64                 `json:"last_flush_ms"`
+	Shards            []IngesterShardResponse `json:"shards"`
+}
+
+type IngesterShardResponse struct {
+	Index             int    `json:"index"`
+	Symbols           int    `json:"symbols"`
+	Connected         bool   `json:"connected"`
+	GivenUp           bool   `json:"given_up"`
+	ConnectedAt       int64  `json:"connected_at"`
+	ReconnectAttempts int    `json:"reconnect_attempts"`
+	LastError         string `json:"last_error,omitempty"`
+	Messages          uint64 `json:"messages"`
+}
+
+// SchedulerStatusResponse lists the cron jobs with their last and next runs
+// in Unix seconds; last_run is 0 until a job has run
+type SchedulerStatusResponse struct {
+	Jobs []SchedulerJobResponse `json:"jobs"`
+}
+
+type SchedulerJobResponse struct {
+	LastRun int64 `json:"last_run"`
+	NextRun int64 `json:"next_run"`
+}
+
+// ExchangeUptimeResponse is an exchange's poll health over a window.
+// last_poll_at is in Unix seconds.
+type ExchangeUptimeResponse struct {
+	ExchangeID      string  `json:"exchange_id"`
+	Polls           uint64  `json:"polls"`
+	SuccessfulPolls uint64  `json:"successful_polls"`
+	UptimePercent   float64 `json:"uptime_percent"`
+	AvgResponseMs   float64 `json:"avg_response_ms"`
+	P95ResponseMs   float64 `json:"p95_response_ms"`
+	LastPollAt      int64   `json:"last_poll_at"`
+}
+
+// ExchangeTrustResponse is the trust score of an exchange's volume, 0 to 1,
+// and the wash trading signals behind it: the share of its trades undone by
+// a trade of the same size on the other side within seconds, and of its
+// pairs where it reported far more volume than other venues while its price
+// barely moved
+type ExchangeTrustResponse struct {
+	Score        float64   `json:"score"`
+	Trades       int64     `json:"trades"`
+	RoundTripPct float64   `json:"round_trip_pct"`
+	Pairs        int       `json:"pairs"`
+	MismatchPct  float64   `json:"mismatch_pct"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ExchangeWeightChangeResponse is one daily computation of an exchange's
+// dynamic weight. target_weight is before smoothing with previous_weight,
+// which is omitted on the first computation.
+type ExchangeWeightChangeResponse struct {
+	ExchangeID     string    `json:"exchange_id"`
+	StaticWeight   float64   `json:"static_weight"`
+	PreviousWeight *float64  `json:"previous_weight,omitempty"`
+	TargetWeight   float64   `json:"target_weight"`
+	Weight         float64   `json:"weight"`
+	VolumeSharePct float64   `json:"volume_share_pct"`
+	UptimePct      float64   `json:"uptime_pct"`
+	ComputedAt     time.Time `json:"computed_at"`
+}
+
+// MaintenanceWindowResponse is a scheduled downtime of an exchange, from
+// starts_at up to ends_at, during which it is not polled
+type MaintenanceWindowResponse struct {
+	ID         int64     `json:"id"`
+	ExchangeID string    `json:"exchange_id"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Reason     string    `json:"reason,omitempty"`
+	Active     bool      `json:"active"` // the window has started
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// VolumeShareResponse is each exchange's share of a day's volume, of the
+// pair Base/Quote or, when they are empty, of all pairs
+type VolumeShareResponse struct {
+	Date           string                `json:"date"`
+	Base           string                `json:"base,omitempty"`
+	Quote          string                `json:"quote,omitempty"`
+	TotalVolumeUSD float64               `json:"total_volume_usd"`
+	Exchanges      []ExchangeVolumeShare `json:"exchanges"`
+}
+
+// ExchangeVolumeShare is one exchange's share of volume. Volume, in the base
+// token, is only set for a pair. VWAPSharePct is its volume times weight as a
+// share of the total.
+type ExchangeVolumeShare struct {
+	ExchangeID   string           `json:"exchange_id"`
+	Volume       *decimal.Decimal `json:"volume,omitempty" swaggertype:"string"`
+	VolumeUSD    float64          `json:"volume_usd"`
+	SharePct     float64          `json:"share_pct"`
+	Weight       float64          `json:"weight"`
+	VWAPSharePct float64          `json:"vwap_share_pct"`
+}
+
+// RouteUsageResponse is the requests to one API route and method over a
+// window. Client errors are 4xx responses, server errors 5xx.
+type RouteUsageResponse struct {
+	Route         string  `json:"route"`
+	Method        string  `json:"method"`
+	Requests      uint64  `json:"requests"`
+	ClientErrors  uint64  `json:"client_errors"`
+	ServerErrors  uint64  `json:"server_errors"`
+	AvgDurationMs float64 `json:"avg_duration_ms"`
+	P95DurationMs float64 `json:"p95_duration_ms"`
+	ResponseBytes uint64  `json:"response_bytes"`
+}
+
+// AdminTokenResponse is a token as the admin token endpoints write it,
+// whether active, inactive or archived
+type AdminTokenResponse struct {
+	ID              int                    `json:"id"`
+	Symbol          string                 `json:"symbol"`
+	Name            string                 `json:"name"`
+	Slug            string                 `json:"slug,omitempty"`
+	Chain           string                 `json:"chain,omitempty"`
+	ContractAddress string                 `json:"contract_address,omitempty"`
+	Decimals        int                    `json:"decimals"`
+	Metadata        map[string]interface{} `json:"metadata"`
+	Contracts       []TokenContract        `json:"contracts"`
+	Active          bool                   `json:"is_active"`
+	Archived        bool                   `json:"archived"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+}
+
+// TokenMergeResponse reports a token merge or split. Moved counts PostgreSQL
+// rows re-pointed per table. ClickHouseStatus is "failed" when the PostgreSQL
+// change committed but ClickHouse rows were not all moved; resuming the log
+// entry finishes it.
+type TokenMergeResponse struct {
+	LogID            int              `json:"log_id"`
+	Action           string           `json:"action"`
+	SourceTokenID    int              `json:"source_token_id"`
+	TargetTokenID    int              `json:"target_token_id"`
+	Moved            map[string]int64 `json:"moved"`
+	ClickHouseStatus string           `json:"clickhouse_status"`
+	ClickHouseTables []string         `json:"clickhouse_tables"`
+	ClickHouseError  string           `json:"clickhouse_error,omitempty"`
+}
+
+// ListingResponse is a base symbol seen on an exchange for the first time.
+// TokenID is omitted when the symbol could not be mapped to a token.
+type ListingResponse struct {
+	ID            int             `json:"id"`
+	ExchangeID    string          `json:"exchange_id"`
+	BaseSymbol    string          `json:"base_symbol"`
+	QuoteSymbol   string          `json:"quote_symbol"`
+	PairSymbol    string          `json:"pair_symbol"`
+	TokenID       *int            `json:"token_id,omitempty"`
+	FirstPrice    decimal.Decimal `json:"first_price" swaggertype:"string"`
+	MappingMethod string          `json:"mapping_method,omitempty"`
+	AutoMapped    bool            `json:"auto_mapped"`
+	FirstSeenAt   time.Time       `json:"first_seen_at"`
+}
+
+// SymbolMappingResponse is an exchange symbol mapped to a token
+type SymbolMappingResponse struct {
+	ID                int        `json:"id"`
+	TokenID           int        `json:"token_id"`
+	TokenSymbol       string     `json:"token_symbol"`
+	ExchangeID        string     `json:"exchange_id"`
+	ExchangeSymbol    string     `json:"exchange_symbol"`
+	NormalizedSymbol  string     `json:"normalized_symbol"`
+	Chain             string     `json:"chain,omitempty"`
+	MappingMethod     string     `json:"mapping_method"`
+	ConfidenceScore   float64    `json:"confidence_score"`
+	NeedsVerification bool       `json:"needs_verification"`
+	IsActive          bool       `json:"is_active"`
+	VerifiedBy        string     `json:"verified_by,omitempty"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// SymbolMappingListResponse is a page of symbol mappings. Pass NextAfterID as
+// after_id to get the next page; it is omitted on the last.
+type SymbolMappingListResponse struct {
+	Mappings    []SymbolMappingResponse `json:"mappings"`
+	NextAfterID *int                    `json:"next_after_id,omitempty"`
+}
+
+// PairListResponse is a page of trading pairs. Pass NextAfterID as after_id
+// to get the next page; it is omitted on the last.
+type PairListResponse struct {
+	Pairs       []PairResponse `json:"pairs"`
+	NextAfterID *int           `json:"next_after_id,omitempty"`
+}
+
+// PendingMappingResponse is an exchange symbol the mapper could not map to a
+// token, with the tokens it thought likely, best first. The resolved fields
+// are omitted while the entry is pending.
+type PendingMappingResponse struct {
+	ID              int                       `json:"id"`
+	ExchangeID      string                    `json:"exchange_id"`
+	ExchangeSymbol  string                    `json:"exchange_symbol"`
+	Slug            string                    `json:"slug,omitempty"`
+	Name            string                    `json:"name,omitempty"`
+	MarketPair      string                    `json:"market_pair,omitempty"`
+	Candidates      []PendingMappingCandidate `json:"candidates"`
+	Status          string                    `json:"status"`
+	ResolvedTokenID *int                      `json:"resolved_token_id,omitempty"`
+	ResolvedBy      string                    `json:"resolved_by,omitempty"`
+	ResolvedAt      *time.Time                `json:"resolved_at,omitempty"`
+	Notes           string                    `json:"notes,omitempty"`
+	SeenCount       int                       `json:"seen_count"`
+	FirstSeenAt     time.Time                 `json:"first_seen_at"`
+	LastSeenAt      time.Time                 `json:"last_seen_at"`
+}
+
+// PendingMappingCandidate is a token suggested for a pending mapping. Score
+// adds 0.5 for a matching symbol, 0.3 for a matching name and 0.2 for a
+// matching slug; Reason lists which matched.
+type PendingMappingCandidate struct {
+	TokenID int     `json:"token_id"`
+	Symbol  string  `json:"symbol"`
+	Name    string  `json:"name"`
+	Score   float64 `json:"score"`
+	Reason  string  `json:"reason"`
+}
+
+// PendingMappingListResponse is a page of the pending mapping queue. Pass
+// NextAfterID as after_id to get the next page; it is omitted on the last.
+type PendingMappingListResponse struct {
+	Mappings    []PendingMappingResponse `json:"mappings"`
+	NextAfterID *int                     `json:"next_after_id,omitempty"`
+}
+
+// ReconciliationReport is a nightly comparison of the symbols exchanges
+// quoted with the stored mappings. The counts are of the full findings; each
+// list holds at most 1000 entries.
+type ReconciliationReport struct {
+	ID             int                `json:"id"`
+	UnmappedCount  int                `json:"unmapped_count"`
+	StaleCount     int                `json:"stale_count"`
+	DuplicateCount int                `json:"duplicate_count"`
+	Unmapped       []UnmappedSymbol   `json:"unmapped"`
+	Stale          []StaleMapping     `json:"stale"`
+	Duplicates     []DuplicateMapping `json:"duplicates"`
+	DeliveredAt    *time.Time         `json:"delivered_at,omitempty"`
+	DeliveryError  string             `json:"delivery_error,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// UnmappedSymbol is a pair an exchange quoted in the last day whose base or
+// quote symbol has no mapping
+type UnmappedSymbol struct {
+	ExchangeID   string    `json:"exchange_id"`
+	Symbol       string    `json:"symbol"`
+	BaseSymbol   string    `json:"base_symbol"`
+	QuoteSymbol  string    `json:"quote_symbol"`
+	MissingBase  bool      `json:"missing_base"`
+	MissingQuote bool      `json:"missing_quote"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+}
+
+// StaleMapping is an active mapping no exchange has quoted within
+// RECONCILE_STALE_AFTER. LastSeenAt is omitted if it has never been quoted
+// since tracking began.
+type StaleMapping struct {
+	ID             int        `json:"id"`
+	ExchangeID     string     `json:"exchange_id"`
+	ExchangeSymbol string     `json:"exchange_symbol"`
+	TokenID        int        `json:"token_id"`
+	TokenSymbol    string     `json:"token_symbol"`
+	MappingMethod  string     `json:"mapping_method"`
+	LastSeenAt     *time.Time `json:"last_seen_at"`
+}
+
+// DuplicateMapping is an exchange symbol mapped more than once on an
+// exchange under different letter cases, e.g. "PEPE" and "pepe"
+type DuplicateMapping struct {
+	ExchangeID      string   `json:"exchange_id"`
+	ExchangeSymbol  string   `json:"exchange_symbol"`
+	MappingIDs      []int    `json:"mapping_ids"`
+	ExchangeSymbols []string `json:"exchange_symbols"`
+	TokenIDs        []int    `json:"token_ids"`
+}
+
+// MappingImportCounts counts the rows a mapping import added and updated
+type MappingImportCounts struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+}
+
+// MappingImportResult is what importing a mapping bundle did. Nothing is
+// written when DryRun is set.
+type MappingImportResult struct {
+	Checksum        string              `json:"checksum"`
+	ExportedAt      time.Time           `json:"exported_at"`
+	DryRun          bool                `json:"dry_run"`
+	Tokens          MappingImportCounts `json:"tokens"`
+	ExchangeSymbols MappingImportCounts `json:"exchange_symbols"`
+	Pairs           MappingImportCounts `json:"pairs"`
 }
@@ -4,33 +4,36 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/db"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+// ErrOutlierNotFound is returned when an outlier ID does not exist
+var ErrOutlierNotFound = db.ErrOutlierNotFound
+
 // Detector identifies price outliers that may indicate mapping issues
 type Detector struct {
 	postgresDB     *sql.DB
 	clickhouseConn driver.Conn
 	logger         *zap.Logger
 	
-	// Configurable thresholds
-	deviationThreshold float64 // Percentage deviation to flag (default 5%)
-	stdDevMultiplier   float64 // Number of standard deviations (default 2.0)
+	// Shared with the VWAP calculator so both agree on what an outlier is
+	thresholds *Overrides
 }
 
-// NewDetector creates a new outlier detector
-func NewDetector(postgresDB *sql.DB, clickhouseConn driver.Conn, logger *zap.Logger) *Detector {
+// NewDetector creates a new outlier detector. thresholds should be the
+// overrides the VWAP calculator uses; nil applies DefaultThresholds to every
+// pair.
+func NewDetector(postgresDB *sql.DB, clickhouseConn driver.Conn, thresholds *Overrides, logger *zap.Logger) *Detector {
 	return &Detector{
-		postgresDB:         postgresDB,
-		clickhouseConn:     clickhouseConn,
-		logger:             logger,
-		deviationThreshold: 0.05, // 5% deviation
-		stdDevMultiplier:   2.0,   // 2 standard deviations
+		postgresDB:     postgresDB,
+		clickhouseConn: clickhouseConn,
+		logger:         logger,
+		thresholds:     thresholds,
 	}
 }
 
@@ -45,6 +48,7 @@ type PricePoint struct {
 
 // Outlier represents a detected price outlier
 type Outlier struct {
+	ID              int
 	ExchangeID      string
 	BaseTokenID     int
 	QuoteTokenID    int
@@ -70,10 +74,6 @@ func (d *Detector) DetectOutliers(ctx context.Context, window time.Duration) ([]
 	// Detect outliers for each pair
 	var outliers []Outlier
 	for _, prices := range pricesByPair {
-		if len(prices) < 2 {
-			continue // Need at least 2 exchanges for comparison
-		}
-		
 		pairOutliers := d.detectPairOutliers(prices)
 		outliers = append(outliers, pairOutliers...)
 	}
@@ -96,6 +96,7 @@ func (d *Detector) fetchRecentPrices(ctx context.Context, window time.Duration)
 			max(timestamp) as latest_timestamp
 		FROM price_tickers
 		WHERE timestamp >= now() - INTERVAL ? SECOND
+			AND instrument_type = 'spot'
 			AND base_token_id > 0
 			AND quote_token_id > 0
 			AND price > 0
@@ -138,55 +139,44 @@ func (d *Detector) groupByPair(prices []PricePoint) map[string][]PricePoint {
 	return grouped
 }
 
+// detectPairOutliers applies the pair's thresholds to its prices and
+// returns the outliers on symbol-based mappings. AveragePrice holds the
+// median the deviation was measured from.
 func (d *Detector) detectPairOutliers(prices []PricePoint) []Outlier {
-	if len(prices) < 2 {
-		return nil
-	}
-	
-	// Calculate statistics
-	var sum, sumSquares decimal.Decimal
-	for _, p := range prices {
-		sum = sum.Add(p.Price)
-		sumSquares = sumSquares.Add(p.Price.Mul(p.Price))
+	values := make([]decimal.Decimal, len(prices))
+	for i, p := range prices {
+		values[i] = p.Price
 	}
-	
-	n := decimal.NewFromInt(int64(len(prices)))
-	mean := sum.Div(n)
-	
-	// Calculate standard deviation
-	variance := sumSquares.Div(n).Sub(mean.Mul(mean))
-	stdDev, _ := variance.Float64()
-	stdDev = math.Sqrt(stdDev)
-	
-	// Detect outliers
+	analysis := d.thresholds.For(prices[0].BaseTokenID, prices[0].QuoteTokenID).Analyze(values)
+
 	var outliers []Outlier
-	for _, price := range prices {
-		deviation, _ := price.Price.Sub(mean).Abs().Float64()
-		deviationPercent := deviation / mean.InexactFloat64() * 100
-		stdDeviations := deviation / stdDev
-		
-		// Check if this is an outlier
-		if deviationPercent > d.deviationThreshold*100 || stdDeviations > d.stdDevMultiplier {
-			// Get mapping method for this exchange/token combination
-			mappingMethod := d.getMappingMethod(price.ExchangeID, price.BaseTokenID)
-			
-			// Only flag if it's a symbol-based mapping
-			if mappingMethod == "symbol" {
-				outliers = append(outliers, Outlier{
-					ExchangeID:       price.ExchangeID,
-					BaseTokenID:      price.BaseTokenID,
-					QuoteTokenID:     price.QuoteTokenID,
-					ExchangePrice:    price.Price,
-					AveragePrice:     mean,
-					DeviationPercent: deviationPercent,
-					StdDeviations:    stdDeviations,
-					MappingMethod:    mappingMethod,
-					Timestamp:        price.Timestamp,
-				})
-			}
+	for i, price := range prices {
+		point := analysis.Points[i]
+		if !point.Outlier {
+			continue
+		}
+
+		// Only flag if it's a symbol-based mapping
+		mappingMethod := d.getMappingMethod(price.ExchangeID, price.BaseTokenID)
+		if mappingMethod != "symbol" {
+			continue
 		}
+
+		LogOutlier(d.logger, "Flagged outlier price", price.ExchangeID,
+			price.BaseTokenID, price.QuoteTokenID, point, analysis.Median)
+		outliers = append(outliers, Outlier{
+			ExchangeID:       price.ExchangeID,
+			BaseTokenID:      price.BaseTokenID,
+			QuoteTokenID:     price.QuoteTokenID,
+			ExchangePrice:    price.Price,
+			AveragePrice:     analysis.Median,
+			DeviationPercent: point.Deviation * 100,
+			StdDeviations:    point.StdDevs,
+			MappingMethod:    mappingMethod,
+			Timestamp:        price.Timestamp,
+		})
 	}
-	
+
 	return outliers
 }
 
@@ -253,6 +243,7 @@ func (d *Detector) storeOutliers(outliers []Outlier) error {
 func (d *Detector) GetUnresolvedOutliers() ([]Outlier, error) {
 	query := `
 		SELECT 
+			po.id,
 			po.exchange_id,
 			po.base_token_id,
 			po.quote_token_id,
@@ -280,6 +271,7 @@ func (d *Detector) GetUnresolvedOutliers() ([]Outlier, error) {
 		var exchangePrice, avgPrice string
 		
 		err := rows.Scan(
+			&o.ID,
 			&o.ExchangeID,
 			&o.BaseTokenID,
 			&o.QuoteTokenID,
@@ -313,6 +305,12 @@ func (d *Detector) ResolveOutlier(outlierID int, resolvedBy, notes string) error
 		WHERE id = $1
 	`
 	
-	_, err := d.postgresDB.Exec(query, outlierID, resolvedBy, notes)
-	return err
+	result, err := d.postgresDB.Exec(query, outlierID, resolvedBy, notes)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrOutlierNotFound
+	}
+	return nil
 }
\ No newline at end of file
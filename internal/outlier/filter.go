@@ -0,0 +1,143 @@
+package outlier
+
+import (
+	"math"
+	"sort"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Thresholds decide when a venue's price is an outlier among the prices of
+// the same pair on other venues. The VWAP calculator and the Detector both
+// use them, so a price dropped from VWAP is also the one flagged for review.
+type Thresholds struct {
+	// MaxDeviation is the largest allowed distance from the median, as a
+	// fraction of the median (0.05 = 5%)
+	MaxDeviation float64
+	// MaxStdDevs is the largest allowed distance from the median in standard
+	// deviations. A price must exceed both limits to be an outlier, so tight
+	// clusters don't flag ordinary noise. Zero disables the check.
+	MaxStdDevs float64
+	// MinSamples is the fewest prices needed to judge outliers. With fewer
+	// there is no majority to compare against and nothing is flagged.
+	MinSamples int
+}
+
+// DefaultThresholds returns the thresholds used when none are configured
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		MaxDeviation: 0.05,
+		MaxStdDevs:   2.0,
+		MinSamples:   3,
+	}
+}
+
+// Point is the verdict on a single price
+type Point struct {
+	Price     decimal.Decimal
+	Deviation float64 // Distance from the median as a fraction of it
+	StdDevs   float64 // Distance from the median in standard deviations
+	Outlier   bool
+}
+
+// Analysis is the verdict on a set of prices for one pair. Points are in the
+// order the prices were given.
+type Analysis struct {
+	Median decimal.Decimal
+	StdDev float64
+	Points []Point
+	// Inconclusive is set when too many prices disagreed to tell which side
+	// is wrong, in which case no point is flagged
+	Inconclusive bool
+}
+
+// Analyze measures every price against the median of all of them. The result
+// depends only on the set of prices, not their order. If more than half of
+// the prices would be flagged there is no trustworthy majority, and none are.
+func (t Thresholds) Analyze(prices []decimal.Decimal) Analysis {
+	analysis := Analysis{Points: make([]Point, len(prices))}
+	for i, p := range prices {
+		analysis.Points[i].Price = p
+	}
+	if len(prices) == 0 {
+		return analysis
+	}
+
+	analysis.Median = Median(prices)
+	analysis.StdDev = stdDev(prices)
+	if len(prices) < t.MinSamples || !analysis.Median.IsPositive() {
+		return analysis
+	}
+
+	median := analysis.Median.InexactFloat64()
+	flagged := 0
+	for i := range analysis.Points {
+		p := &analysis.Points[i]
+		distance := math.Abs(p.Price.InexactFloat64() - median)
+		p.Deviation = distance / median
+		if analysis.StdDev > 0 {
+			p.StdDevs = distance / analysis.StdDev
+		}
+
+		p.Outlier = p.Deviation > t.MaxDeviation &&
+			(t.MaxStdDevs <= 0 || p.StdDevs > t.MaxStdDevs)
+		if p.Outlier {
+			flagged++
+		}
+	}
+
+	if flagged*2 > len(prices) {
+		analysis.Inconclusive = true
+		for i := range analysis.Points {
+			analysis.Points[i].Outlier = false
+		}
+	}
+	return analysis
+}
+
+// Median returns the median of prices, averaging the middle two for an even
+// count
+func Median(prices []decimal.Decimal) decimal.Decimal {
+	if len(prices) == 0 {
+		return decimal.Zero
+	}
+	sorted := make([]decimal.Decimal, len(prices))
+	copy(sorted, prices)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].LessThan(sorted[j]) })
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return sorted[mid-1].Add(sorted[mid]).Div(decimal.NewFromInt(2))
+}
+
+// stdDev returns the population standard deviation of prices
+func stdDev(prices []decimal.Decimal) float64 {
+	var sum float64
+	for _, p := range prices {
+		sum += p.InexactFloat64()
+	}
+	mean := sum / float64(len(prices))
+
+	var squares float64
+	for _, p := range prices {
+		d := p.InexactFloat64() - mean
+		squares += d * d
+	}
+	return math.Sqrt(squares / float64(len(prices)))
+}
+
+// LogOutlier logs a flagged price with the same fields wherever outliers are
+// handled
+func LogOutlier(logger *zap.Logger, msg, exchangeID string, baseTokenID, quoteTokenID int, p Point, median decimal.Decimal) {
+	logger.Warn(msg,
+		zap.String("exchange", exchangeID),
+		zap.Int("base_token_id", baseTokenID),
+		zap.Int("quote_token_id", quoteTokenID),
+		zap.String("price", p.Price.String()),
+		zap.String("median", median.String()),
+		zap.Float64("deviation_pct", p.Deviation*100),
+		zap.Float64("std_devs", p.StdDevs))
+}
@@ -0,0 +1,113 @@
+package outlier
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func prices(values ...float64) []decimal.Decimal {
+	out := make([]decimal.Decimal, len(values))
+	for i, v := range values {
+		out[i] = decimal.NewFromFloat(v)
+	}
+	return out
+}
+
+func flagged(a Analysis) []int {
+	var idx []int
+	for i, p := range a.Points {
+		if p.Outlier {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name         string
+		prices       []decimal.Decimal
+		want         []int
+		inconclusive bool
+	}{
+		{"empty", nil, nil, false},
+		{"too few samples", prices(100, 200), nil, false},
+		{"tight cluster", prices(100, 100.1, 99.9, 100.2, 99.8), nil, false},
+		{"one venue far off", prices(100, 101, 99, 100, 150), []int{4}, false},
+		{"far off but within deviation", prices(100, 100, 100, 100, 104), nil, false},
+		{"three samples", prices(100, 100, 200), []int{2}, false},
+		{"wrong mapping on low side", prices(0.5, 100, 101, 99, 100), []int{0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := DefaultThresholds().Analyze(tt.prices)
+			got := flagged(a)
+			if len(got) != len(tt.want) {
+				t.Fatalf("flagged %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("flagged %v, want %v", got, tt.want)
+				}
+			}
+			if a.Inconclusive != tt.inconclusive {
+				t.Errorf("inconclusive = %v, want %v", a.Inconclusive, tt.inconclusive)
+			}
+		})
+	}
+}
+
+func TestAnalyzeInconclusive(t *testing.T) {
+	th := Thresholds{MaxDeviation: 0.05, MinSamples: 3}
+	a := th.Analyze(prices(100, 200, 300, 400))
+	if !a.Inconclusive {
+		t.Fatal("expected inconclusive analysis")
+	}
+	if got := flagged(a); len(got) != 0 {
+		t.Errorf("flagged %v in inconclusive analysis", got)
+	}
+}
+
+func TestAnalyzeOrderIndependent(t *testing.T) {
+	a := DefaultThresholds().Analyze(prices(100, 150, 101, 99, 100))
+	b := DefaultThresholds().Analyze(prices(150, 100, 100, 99, 101))
+	if !a.Median.Equal(b.Median) {
+		t.Fatalf("median %s != %s", a.Median, b.Median)
+	}
+	if !a.Points[1].Outlier || !b.Points[0].Outlier {
+		t.Error("the 150 price should be flagged regardless of order")
+	}
+}
+
+func TestAnalyzeStdDev(t *testing.T) {
+	// 107 is 7% off the median but under 2 standard deviations of a spread
+	// out set, so it only counts once the std dev check is disabled
+	values := prices(96, 100, 100, 104, 107)
+	if got := flagged(DefaultThresholds().Analyze(values)); len(got) != 0 {
+		t.Errorf("flagged %v with std dev check, want none", got)
+	}
+
+	th := Thresholds{MaxDeviation: 0.05, MinSamples: 3}
+	if got := flagged(th.Analyze(values)); len(got) != 1 || got[0] != 4 {
+		t.Errorf("flagged %v without std dev check, want [4]", got)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		prices []decimal.Decimal
+		want   string
+	}{
+		{nil, "0"},
+		{prices(3), "3"},
+		{prices(3, 1, 2), "2"},
+		{prices(4, 1, 3, 2), "2.5"},
+	}
+	for _, tt := range tests {
+		if got := Median(tt.prices); got.String() != tt.want {
+			t.Errorf("Median(%v) = %s, want %s", tt.prices, got, tt.want)
+		}
+	}
+}
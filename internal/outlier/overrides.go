@@ -0,0 +1,131 @@
+package outlier
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"go.uber.org/zap"
+)
+
+// Pair identifies a token pair
+type Pair struct {
+	BaseTokenID  int
+	QuoteTokenID int
+}
+
+// Override replaces some of the default thresholds for one pair. Nil fields
+// keep the default.
+type Override struct {
+	MaxDeviation *float64
+	MaxStdDevs   *float64
+	MinSamples   *int
+}
+
+// Apply returns defaults with the fields set in o replaced
+func (o Override) Apply(defaults Thresholds) Thresholds {
+	t := defaults
+	if o.MaxDeviation != nil {
+		t.MaxDeviation = *o.MaxDeviation
+	}
+	if o.MaxStdDevs != nil {
+		t.MaxStdDevs = *o.MaxStdDevs
+	}
+	if o.MinSamples != nil {
+		t.MinSamples = *o.MinSamples
+	}
+	return t
+}
+
+// Overrides holds the configured default thresholds together with per-pair
+// overrides loaded from the outlier_thresholds table, so the VWAP calculator
+// and the Detector apply the same bands to every pair. It is safe for
+// concurrent use.
+type Overrides struct {
+	postgresDB *sql.DB
+	defaults   Thresholds
+	logger     *zap.Logger
+
+	mu    sync.RWMutex
+	pairs map[Pair]Override
+}
+
+// NewOverrides creates overrides on top of defaults. postgresDB may be nil,
+// in which case there are no per-pair overrides.
+func NewOverrides(postgresDB *sql.DB, defaults Thresholds, logger *zap.Logger) *Overrides {
+	return &Overrides{
+		postgresDB: postgresDB,
+		defaults:   defaults,
+		logger:     logger,
+		pairs:      make(map[Pair]Override),
+	}
+}
+
+// Defaults returns the thresholds of pairs without an override
+func (o *Overrides) Defaults() Thresholds {
+	return o.defaults
+}
+
+// For returns the thresholds of a pair
+func (o *Overrides) For(baseTokenID, quoteTokenID int) Thresholds {
+	if o == nil {
+		return DefaultThresholds()
+	}
+	o.mu.RLock()
+	override, ok := o.pairs[Pair{BaseTokenID: baseTokenID, QuoteTokenID: quoteTokenID}]
+	o.mu.RUnlock()
+	if !ok {
+		return o.defaults
+	}
+	return override.Apply(o.defaults)
+}
+
+// Set replaces all per-pair overrides
+func (o *Overrides) Set(pairs map[Pair]Override) {
+	o.mu.Lock()
+	o.pairs = pairs
+	o.mu.Unlock()
+}
+
+// Reload replaces the per-pair overrides with those in the database
+func (o *Overrides) Reload(ctx context.Context) error {
+	if o.postgresDB == nil {
+		return nil
+	}
+	rows, err := db.ListOutlierThresholds(ctx, o.postgresDB)
+	if err != nil {
+		return fmt.Errorf("loading outlier thresholds: %w", err)
+	}
+	pairs := make(map[Pair]Override, len(rows))
+	for _, r := range rows {
+		pairs[Pair{BaseTokenID: r.BaseTokenID, QuoteTokenID: r.QuoteTokenID}] = Override{
+			MaxDeviation: r.MaxDeviation,
+			MaxStdDevs:   r.MaxStdDevs,
+			MinSamples:   r.MinSamples,
+		}
+	}
+	o.Set(pairs)
+	return nil
+}
+
+// Run reloads the overrides on start and then every interval until ctx is
+// done, so edits made by another process are picked up
+func (o *Overrides) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := o.Reload(ctx); err != nil && ctx.Err() == nil {
+			o.logger.Error("Failed to reload outlier thresholds", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package outlier
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestOverridesFor(t *testing.T) {
+	defaults := DefaultThresholds()
+	o := NewOverrides(nil, defaults, zap.NewNop())
+
+	wide := 0.3
+	o.Set(map[Pair]Override{
+		{BaseTokenID: 7, QuoteTokenID: 2}: {MaxDeviation: &wide},
+	})
+
+	got := o.For(7, 2)
+	want := defaults
+	want.MaxDeviation = wide
+	if got != want {
+		t.Errorf("For(7, 2) = %+v, want %+v", got, want)
+	}
+	if got := o.For(2, 7); got != defaults {
+		t.Errorf("For(2, 7) = %+v, want defaults", got)
+	}
+
+	var none *Overrides
+	if got := none.For(7, 2); got != DefaultThresholds() {
+		t.Errorf("nil Overrides = %+v, want DefaultThresholds", got)
+	}
+}
@@ -0,0 +1,142 @@
+package outlier
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Actions a Suggestion can propose
+const (
+	ActionRemap       = "remap"
+	ActionDisablePair = "disable_pair"
+)
+
+// suggestionWindow is how far back candidate tokens' prices are looked up
+const suggestionWindow = time.Hour
+
+// Suggestion is a fix proposed for an outlier. A remap moves the exchange's
+// symbol to TokenID, whose price on other exchanges, CandidatePrice, agrees
+// with the outlier's exchange price to within Deviation. disable_pair
+// deactivates the pair on the exchange and is always offered last.
+type Suggestion struct {
+	Action         string
+	Description    string
+	TokenID        int
+	TokenSymbol    string
+	TokenName      string
+	CandidatePrice decimal.Decimal
+	Deviation      float64
+}
+
+// Suggest proposes fixes for an outlier: remapping its exchange symbol to
+// each token with the same symbol whose price matches the exchange price,
+// closest first, followed by disabling the pair. If candidate lookups fail
+// only disable_pair is offered.
+func (d *Detector) Suggest(ctx context.Context, o Outlier) []Suggestion {
+	disable := Suggestion{
+		Action:      ActionDisablePair,
+		Description: fmt.Sprintf("Disable the pair on %s", o.ExchangeID),
+	}
+
+	candidates, err := db.GetRemapCandidates(ctx, d.postgresDB, o.ExchangeID, o.BaseTokenID)
+	if err != nil {
+		d.logger.Warn("Failed to look up remap candidates",
+			zap.Int("outlier_id", o.ID), zap.Error(err))
+		return []Suggestion{disable}
+	}
+	if len(candidates) == 0 {
+		return []Suggestion{disable}
+	}
+
+	medians, err := d.candidateMedians(ctx, o, candidates)
+	if err != nil {
+		d.logger.Warn("Failed to look up remap candidate prices",
+			zap.Int("outlier_id", o.ID), zap.Error(err))
+		return []Suggestion{disable}
+	}
+
+	return append(remapSuggestions(o, candidates, medians, d.thresholds), disable)
+}
+
+// candidateMedians returns the median recent price of each candidate against
+// the outlier's quote token on exchanges other than the outlier's. Candidates
+// without prices are left out.
+func (d *Detector) candidateMedians(ctx context.Context, o Outlier, candidates []db.RemapCandidate) (map[int]decimal.Decimal, error) {
+	ids := make([]uint32, len(candidates))
+	for i, c := range candidates {
+		ids[i] = uint32(c.TokenID)
+	}
+
+	rows, err := d.clickhouseConn.Query(ctx, `
+		SELECT base_token_id, argMax(price, timestamp) AS latest_price
+		FROM price_tickers
+		WHERE timestamp >= now() - INTERVAL ? SECOND
+			AND instrument_type = 'spot'
+			AND base_token_id IN (?)
+			AND quote_token_id = ?
+			AND exchange_id != ?
+			AND price > 0
+		GROUP BY base_token_id, exchange_id
+	`, int(suggestionWindow.Seconds()), ids, uint32(o.QuoteTokenID), o.ExchangeID)
+	if err != nil {
+		return nil, fmt.Errorf("querying candidate prices: %w", err)
+	}
+	defer rows.Close()
+
+	prices := make(map[int][]decimal.Decimal)
+	for rows.Next() {
+		var tokenID uint32
+		var price decimal.Decimal
+		if err := rows.Scan(&tokenID, &price); err != nil {
+			return nil, fmt.Errorf("scanning candidate price: %w", err)
+		}
+		prices[int(tokenID)] = append(prices[int(tokenID)], price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	medians := make(map[int]decimal.Decimal, len(prices))
+	for id, p := range prices {
+		medians[id] = Median(p)
+	}
+	return medians, nil
+}
+
+// remapSuggestions returns a remap to each candidate whose median price is
+// within its pair's max deviation of the outlier's exchange price, closest
+// first
+func remapSuggestions(o Outlier, candidates []db.RemapCandidate, medians map[int]decimal.Decimal, thresholds *Overrides) []Suggestion {
+	price := o.ExchangePrice.InexactFloat64()
+
+	var out []Suggestion
+	for _, c := range candidates {
+		median, ok := medians[c.TokenID]
+		if !ok || !median.IsPositive() {
+			continue
+		}
+		deviation := math.Abs(price-median.InexactFloat64()) / median.InexactFloat64()
+		if deviation > thresholds.For(c.TokenID, o.QuoteTokenID).MaxDeviation {
+			continue
+		}
+		out = append(out, Suggestion{
+			Action: ActionRemap,
+			Description: fmt.Sprintf("Remap the symbol on %s to %s (%s, token %d), which trades at %s on other exchanges",
+				o.ExchangeID, c.Symbol, c.Name, c.TokenID, median.String()),
+			TokenID:        c.TokenID,
+			TokenSymbol:    c.Symbol,
+			TokenName:      c.Name,
+			CandidatePrice: median,
+			Deviation:      deviation,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Deviation < out[j].Deviation })
+	return out
+}
@@ -0,0 +1,41 @@
+package outlier
+
+import (
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/shopspring/decimal"
+)
+
+func TestRemapSuggestions(t *testing.T) {
+	o := Outlier{ExchangeID: "gate", BaseTokenID: 1, QuoteTokenID: 2, ExchangePrice: decimal.NewFromInt(100)}
+	candidates := []db.RemapCandidate{
+		{TokenID: 10, Symbol: "ABC", Name: "Far"},
+		{TokenID: 11, Symbol: "ABC", Name: "Close"},
+		{TokenID: 12, Symbol: "ABC", Name: "Closest"},
+		{TokenID: 13, Symbol: "ABC", Name: "No prices"},
+	}
+	medians := map[int]decimal.Decimal{
+		10: decimal.NewFromInt(300),
+		11: decimal.NewFromInt(105),
+		12: decimal.NewFromInt(99),
+	}
+
+	got := remapSuggestions(o, candidates, medians, nil)
+	if len(got) != 2 {
+		t.Fatalf("remapSuggestions returned %d suggestions, want 2: %+v", len(got), got)
+	}
+	if got[0].TokenID != 12 || got[1].TokenID != 11 {
+		t.Errorf("suggested tokens %d, %d, want 12, 11", got[0].TokenID, got[1].TokenID)
+	}
+	if got[0].Action != ActionRemap || !got[0].CandidatePrice.Equal(decimal.NewFromInt(99)) {
+		t.Errorf("first suggestion = %+v", got[0])
+	}
+
+	wide := 5.0
+	overrides := NewOverrides(nil, DefaultThresholds(), nil)
+	overrides.Set(map[Pair]Override{{BaseTokenID: 10, QuoteTokenID: 2}: {MaxDeviation: &wide}})
+	if got := remapSuggestions(o, candidates, medians, overrides); len(got) != 3 || got[2].TokenID != 10 {
+		t.Errorf("with a wide override for token 10: %+v", got)
+	}
+}
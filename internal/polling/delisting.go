@@ -0,0 +1,210 @@
+package polling
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// DefaultDelistAfterPolls is how many consecutive polls a pair may be missing
+// from its exchange's tickers before it is deactivated
+const DefaultDelistAfterPolls = 20
+
+// partialCheckMinPairs is the number of active pairs an exchange needs before
+// a poll missing most of them is taken as a partial response
+const partialCheckMinPairs = 10
+
+// DelistingTracker deactivates trading pairs an exchange has stopped returning
+// and re-activates them when they come back. Only successful polls count. A
+// poll returning none of an exchange's active pairs, or under half of them
+// once it has partialCheckMinPairs, is treated as a partial response rather
+// than a mass delisting.
+//
+// Miss counts are kept in memory, so a restart starts them again.
+type DelistingTracker struct {
+	db        *sql.DB
+	threshold int
+	logger    *zap.Logger
+
+	mu     sync.Mutex
+	missed map[string]map[string]int // exchangeID -> pair symbol -> consecutive misses
+}
+
+// pairState is what the tracker needs to know about a trading_pairs row
+type pairState struct {
+	active   bool
+	delisted bool // deactivated by the tracker rather than by hand
+}
+
+// seenSets reuses the per-poll sets of returned symbols, which hold thousands of
+// entries on large exchanges
+var seenSets = sync.Pool{
+	New: func() any { return make(map[string]bool) },
+}
+
+// NewDelistingTracker creates a tracker that deactivates pairs after
+// threshold consecutive missed polls
+func NewDelistingTracker(db *sql.DB, threshold int, logger *zap.Logger) *DelistingTracker {
+	if threshold <= 0 {
+		threshold = DefaultDelistAfterPolls
+	}
+	return &DelistingTracker{
+		db:        db,
+		threshold: threshold,
+		logger:    logger,
+		missed:    make(map[string]map[string]int),
+	}
+}
+
+// Observe records a successful poll of an exchange. It is safe to call for
+// several exchanges concurrently.
+func (t *DelistingTracker) Observe(ctx context.Context, exchangeID string, tickers []exchanges.TickerData) error {
+	pairs, err := t.loadPairs(ctx, exchangeID)
+	if err != nil {
+		return err
+	}
+
+	seen := seenSets.Get().(map[string]bool)
+	for _, ticker := range tickers {
+		seen[ticker.Symbol] = true
+	}
+	delist, relist, partial := t.update(exchangeID, pairs, seen)
+	clear(seen)
+	seenSets.Put(seen)
+
+	if partial {
+		t.logger.Warn("Exchange returned too few of its active pairs, not counting misses",
+			zap.String("exchange", exchangeID),
+			zap.Int("tickers", len(tickers)))
+	}
+
+	if len(delist) > 0 {
+		if err := t.setDelisted(ctx, exchangeID, delist); err != nil {
+			return err
+		}
+		t.logger.Warn("Deactivated delisted trading pairs",
+			zap.String("exchange", exchangeID),
+			zap.Strings("pairs", delist),
+			zap.Int("missed_polls", t.threshold))
+	}
+	if len(relist) > 0 {
+		if err := t.setRelisted(ctx, exchangeID, relist); err != nil {
+			return err
+		}
+		t.logger.Info("Re-activated relisted trading pairs",
+			zap.String("exchange", exchangeID),
+			zap.Strings("pairs", relist))
+	}
+	return nil
+}
+
+// update advances the miss counts of an exchange with one poll and returns
+// the pairs to deactivate and re-activate
+func (t *DelistingTracker) update(exchangeID string, pairs map[string]pairState, seen map[string]bool) (delist, relist []string, partial bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	active, activeSeen := 0, 0
+	for symbol, p := range pairs {
+		if p.active {
+			active++
+			if seen[symbol] {
+				activeSeen++
+			}
+		}
+	}
+	partial = active > 0 && (activeSeen == 0 || (active >= partialCheckMinPairs && activeSeen*2 < active))
+
+	prev := t.missed[exchangeID]
+	missed := make(map[string]int)
+	for symbol, p := range pairs {
+		switch {
+		case p.active && !seen[symbol]:
+			if partial {
+				if n, ok := prev[symbol]; ok {
+					missed[symbol] = n
+				}
+				continue
+			}
+			n := prev[symbol] + 1
+			if n >= t.threshold {
+				delist = append(delist, symbol)
+				continue
+			}
+			missed[symbol] = n
+		case !p.active && p.delisted && seen[symbol]:
+			relist = append(relist, symbol)
+		}
+	}
+	t.missed[exchangeID] = missed
+	sort.Strings(delist)
+	sort.Strings(relist)
+	return delist, relist, partial
+}
+
+func (t *DelistingTracker) loadPairs(ctx context.Context, exchangeID string) (map[string]pairState, error) {
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT exchange_pair_symbol, COALESCE(is_active, true), delisted_at IS NOT NULL
+		FROM trading_pairs
+		WHERE exchange_id = $1
+	`, exchangeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trading pairs for %s: %w", exchangeID, err)
+	}
+	defer rows.Close()
+
+	pairs := make(map[string]pairState)
+	for rows.Next() {
+		var symbol string
+		var p pairState
+		if err := rows.Scan(&symbol, &p.active, &p.delisted); err != nil {
+			return nil, fmt.Errorf("failed to scan trading pair: %w", err)
+		}
+		pairs[symbol] = p
+	}
+	return pairs, rows.Err()
+}
+
+func (t *DelistingTracker) setDelisted(ctx context.Context, exchangeID string, symbols []string) error {
+	_, err := t.db.ExecContext(ctx, `
+		WITH delisted AS (
+			UPDATE trading_pairs
+			SET is_active = false, delisted_at = NOW()
+			WHERE exchange_id = $1 AND exchange_pair_symbol = ANY($2) AND is_active = true
+			RETURNING base_token_id, exchange_id, exchange_pair_symbol, mapping_method, confidence_score
+		)
+		INSERT INTO mapping_audit_log (token_id, exchange_id, exchange_symbol, mapping_method, confidence_score, action, performed_by, notes)
+		SELECT base_token_id, exchange_id, LEFT(exchange_pair_symbol, 50), COALESCE(mapping_method, 'manual'), confidence_score,
+		       'delisted', 'poller', $3
+		FROM delisted
+	`, exchangeID, pq.Array(symbols), fmt.Sprintf("missing from %d consecutive polls", t.threshold))
+	if err != nil {
+		return fmt.Errorf("failed to deactivate delisted pairs on %s: %w", exchangeID, err)
+	}
+	return nil
+}
+
+func (t *DelistingTracker) setRelisted(ctx context.Context, exchangeID string, symbols []string) error {
+	_, err := t.db.ExecContext(ctx, `
+		WITH relisted AS (
+			UPDATE trading_pairs
+			SET is_active = true, delisted_at = NULL
+			WHERE exchange_id = $1 AND exchange_pair_symbol = ANY($2) AND delisted_at IS NOT NULL
+			RETURNING base_token_id, exchange_id, exchange_pair_symbol, mapping_method, confidence_score
+		)
+		INSERT INTO mapping_audit_log (token_id, exchange_id, exchange_symbol, mapping_method, confidence_score, action, performed_by, notes)
+		SELECT base_token_id, exchange_id, LEFT(exchange_pair_symbol, 50), COALESCE(mapping_method, 'manual'), confidence_score,
+		       'relisted', 'poller', 'returned by the exchange again'
+		FROM relisted
+	`, exchangeID, pq.Array(symbols))
+	if err != nil {
+		return fmt.Errorf("failed to re-activate relisted pairs on %s: %w", exchangeID, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+//go:build integration
+
+package polling
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/testutil"
+	"go.uber.org/zap"
+)
+
+func TestDelistingTrackerObserve(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ids := testutil.SeedTokens(t, pg, "BTC", "LUNA", "USDT")
+	testutil.SeedTradingPair(t, pg, ids["BTC"], ids["USDT"], "binance", "BTCUSDT")
+	testutil.SeedTradingPair(t, pg, ids["LUNA"], ids["USDT"], "binance", "LUNAUSDT")
+
+	ctx := context.Background()
+	tracker := NewDelistingTracker(pg, 2, zap.NewNop())
+	btcOnly := []exchanges.TickerData{{ExchangeID: "binance", Symbol: "BTCUSDT"}}
+	for i := 0; i < 2; i++ {
+		if err := tracker.Observe(ctx, "binance", btcOnly); err != nil {
+			t.Fatalf("Observe: %v", err)
+		}
+	}
+
+	state := func() (active bool, delisted bool) {
+		t.Helper()
+		if err := pg.QueryRow(`
+			SELECT is_active, delisted_at IS NOT NULL FROM trading_pairs
+			WHERE exchange_id = 'binance' AND exchange_pair_symbol = 'LUNAUSDT'
+		`).Scan(&active, &delisted); err != nil {
+			t.Fatalf("reading pair: %v", err)
+		}
+		return active, delisted
+	}
+	if active, delisted := state(); active || !delisted {
+		t.Fatalf("after 2 missed polls: active = %v, delisted = %v", active, delisted)
+	}
+	var audits int
+	pg.QueryRow(`SELECT count(*) FROM mapping_audit_log WHERE exchange_symbol = 'LUNAUSDT' AND action = 'delisted'`).Scan(&audits)
+	if audits != 1 {
+		t.Errorf("%d delisting audit rows, want 1", audits)
+	}
+
+	untrusted, err := db.GetUntrustedMappings(ctx, pg, 0.5, 0)
+	if err != nil {
+		t.Fatalf("GetUntrustedMappings: %v", err)
+	}
+	if !untrusted.Excludes("binance", "LUNAUSDT", "LUNA", "USDT") {
+		t.Error("delisted pair still feeds VWAP")
+	}
+
+	both := append(btcOnly, exchanges.TickerData{ExchangeID: "binance", Symbol: "LUNAUSDT"})
+	if err := tracker.Observe(ctx, "binance", both); err != nil {
+		t.Fatalf("Observe: %v", err)
+	}
+	if active, delisted := state(); !active || delisted {
+		t.Errorf("after reappearing: active = %v, delisted = %v", active, delisted)
+	}
+}
@@ -0,0 +1,70 @@
+package polling
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestDelistingTrackerUpdate(t *testing.T) {
+	tracker := NewDelistingTracker(nil, 3, zap.NewNop())
+	pairs := map[string]pairState{
+		"BTCUSDT": {active: true},
+		"ETHUSDT": {active: true},
+		"OLDUSDT": {active: true},
+		"BACKUSD": {delisted: true},
+		"HANDUSD": {}, // deactivated by hand
+	}
+	seen := map[string]bool{"BTCUSDT": true, "ETHUSDT": true}
+
+	for poll := 1; poll < 3; poll++ {
+		if delist, _, _ := tracker.update("binance", pairs, seen); delist != nil {
+			t.Fatalf("poll %d delisted %v before the threshold", poll, delist)
+		}
+	}
+	delist, _, _ := tracker.update("binance", pairs, seen)
+	if !reflect.DeepEqual(delist, []string{"OLDUSDT"}) {
+		t.Errorf("third missed poll delisted %v, want [OLDUSDT]", delist)
+	}
+
+	// Coming back resets the count
+	pairs["ETHUSDT"] = pairState{active: true}
+	tracker.update("binance", pairs, map[string]bool{"BTCUSDT": true})
+	tracker.update("binance", pairs, map[string]bool{"BTCUSDT": true, "ETHUSDT": true})
+	tracker.update("binance", pairs, map[string]bool{"BTCUSDT": true})
+	if delist, _, _ := tracker.update("binance", pairs, map[string]bool{"BTCUSDT": true}); delist != nil {
+		t.Errorf("pair missed 3 polls with a gap was delisted: %v", delist)
+	}
+
+	// Only pairs the tracker deactivated come back on their own
+	_, relist, _ := tracker.update("binance", pairs, map[string]bool{"BTCUSDT": true, "BACKUSD": true, "HANDUSD": true})
+	if !reflect.DeepEqual(relist, []string{"BACKUSD"}) {
+		t.Errorf("relisted %v, want [BACKUSD]", relist)
+	}
+}
+
+func TestDelistingTrackerPartialResponse(t *testing.T) {
+	tracker := NewDelistingTracker(nil, 1, zap.NewNop())
+	pairs := make(map[string]pairState)
+	for i := 0; i < partialCheckMinPairs*2; i++ {
+		pairs[fmt.Sprintf("T%dUSDT", i)] = pairState{active: true}
+	}
+
+	if delist, _, partial := tracker.update("okx", pairs, map[string]bool{"T0USDT": true}); !partial || delist != nil {
+		t.Errorf("poll with 1 of %d pairs: partial = %v, delisted %d", len(pairs), partial, len(delist))
+	}
+	if delist, _, partial := tracker.update("okx", pairs, nil); !partial || delist != nil {
+		t.Errorf("empty poll: partial = %v, delisted %d", partial, len(delist))
+	}
+
+	seen := make(map[string]bool)
+	for symbol := range pairs {
+		seen[symbol] = true
+	}
+	delete(seen, "T0USDT")
+	if delist, _, partial := tracker.update("okx", pairs, seen); partial || !reflect.DeepEqual(delist, []string{"T0USDT"}) {
+		t.Errorf("poll missing one pair: partial = %v, delisted %v", partial, delist)
+	}
+}
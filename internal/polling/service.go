@@ -54,9 +54,17 @@ func (s *Service) Start() error {
 		zap.Int("exchanges", len(s.exchangeClients)),
 		zap.Duration("interval", s.pollingInterval))
 	
-	// Start polling loop
-	s.wg.Add(1)
+	// Start polling loop and symbol cache refresh
+	s.wg.Add(3)
 	go s.pollLoop()
+	go func() {
+		defer s.wg.Done()
+		s.symbolResolver.Run(s.ctx)
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.symbolResolver.RunWriteBack(s.ctx)
+	}()
 	
 	return nil
 }
@@ -114,7 +122,15 @@ func (s *Service) pollExchanges() {
 			
 			client.UpdateHealth(true, time.Since(start))
 			
-			// Resolve token IDs for each ticker
+			// Resolve token IDs for each ticker, after loading the ones the
+			// cache lacks in one go
+			lookups := make([]symbol.Lookup, len(tickers))
+			for i, t := range tickers {
+				lookups[i] = symbol.Lookup{ExchangeID: t.ExchangeID, PairSymbol: t.Symbol, Base: t.BaseSymbol, Quote: t.QuoteSymbol}
+			}
+			if err := s.symbolResolver.Prefetch(ctx, lookups); err != nil {
+				s.logger.Error("Failed to prefetch symbols", zap.Error(err))
+			}
 			for i := range tickers {
 				s.resolveTickerTokenIDs(&tickers[i])
 			}
@@ -163,14 +179,14 @@ func (s *Service) resolveTickerTokenIDs(ticker *exchanges.TickerData) {
 		ticker.QuoteTokenID = quoteID
 		
 		// Add this pair to the database for future use
-		s.symbolResolver.AddTradingPair(baseID, quoteID, ticker.ExchangeID, ticker.Symbol)
+		s.symbolResolver.QueueTradingPair(baseID, quoteID, ticker.ExchangeID, ticker.Symbol, string(ticker.Instrument()))
 	} else {
 		// Try normalized symbols as last resort
 		if err1 != nil {
 			if id, err := s.symbolResolver.GetTokenByNormalizedSymbol(ticker.BaseSymbol); err == nil {
 				ticker.BaseTokenID = id
 				// Add mapping for future use
-				s.symbolResolver.AddSymbolMapping(id, ticker.ExchangeID, ticker.BaseSymbol, ticker.BaseSymbol)
+				s.symbolResolver.QueueSymbolMapping(id, ticker.ExchangeID, ticker.BaseSymbol, ticker.BaseSymbol, 0.75)
 			}
 		}
 		
@@ -178,7 +194,7 @@ func (s *Service) resolveTickerTokenIDs(ticker *exchanges.TickerData) {
 			if id, err := s.symbolResolver.GetTokenByNormalizedSymbol(ticker.QuoteSymbol); err == nil {
 				ticker.QuoteTokenID = id
 				// Add mapping for future use
-				s.symbolResolver.AddSymbolMapping(id, ticker.ExchangeID, ticker.QuoteSymbol, ticker.QuoteSymbol)
+				s.symbolResolver.QueueSymbolMapping(id, ticker.ExchangeID, ticker.QuoteSymbol, ticker.QuoteSymbol, 0.75)
 			}
 		}
 	}
@@ -203,7 +219,7 @@ func (s *Service) storeTickers(tickers []exchanges.TickerData) error {
 	// Store in price_tickers table
 	batch, err := s.clickhouseConn.PrepareBatch(ctx, `
 		INSERT INTO price_tickers (
-			timestamp, exchange_id, base_token_id, quote_token_id,
+			timestamp, exchange_id, base_token_id, quote_token_id, instrument_type,
 			price, volume_24h, quote_volume_24h, high_24h, low_24h, price_change_24h
 		)`)
 	if err != nil {
@@ -224,6 +240,7 @@ func (s *Service) storeTickers(tickers []exchanges.TickerData) error {
 			ticker.ExchangeID,
 			uint32(ticker.BaseTokenID),
 			uint32(ticker.QuoteTokenID),
+			string(ticker.Instrument()),
 			ticker.Price,
 			ticker.Volume24h,
 			ticker.QuoteVolume24h,
@@ -0,0 +1,117 @@
+package polling
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status tracks poll cycle progress so health checks can report how fresh
+// the collected data is. It is safe for concurrent use.
+type Status struct {
+	mu sync.RWMutex
+
+	startedAt          time.Time
+	lastPollAt         time.Time
+	lastSuccessAt      time.Time
+	lastError          string
+	exchangesPolled    int
+	exchangesSucceeded int
+	filtered           map[string]FilteredSymbols
+}
+
+// StatusSnapshot is a point-in-time copy of a Status
+type StatusSnapshot struct {
+	StartedAt          time.Time
+	LastPollAt         time.Time
+	LastSuccessAt      time.Time
+	LastError          string
+	ExchangesPolled    int
+	ExchangesSucceeded int
+	Filtered           []FilteredSymbols
+}
+
+// Dropped is how many tickers a poll of an exchange dropped by its symbol
+// patterns and as leveraged tokens or derivatives, which are only dropped
+// while non-spot ingest is off
+type Dropped struct {
+	Filtered int
+	NonSpot  int
+}
+
+// FilteredSymbols counts the tickers dropped from an exchange's polls, in
+// the last cycle it was polled and since the poller started
+type FilteredSymbols struct {
+	ExchangeID string
+	LastPoll   Dropped
+	Filtered   int64
+	NonSpot    int64
+}
+
+// NewStatus creates a status tracker for a poller starting now
+func NewStatus() *Status {
+	return &Status{
+		startedAt: time.Now(),
+		filtered:  make(map[string]FilteredSymbols),
+	}
+}
+
+// RecordFiltered records how many tickers were dropped from each polled
+// exchange in a cycle. Exchanges are left out until one is dropped.
+func (s *Status) RecordFiltered(dropped map[string]Dropped) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, d := range dropped {
+		f, seen := s.filtered[id]
+		if !seen && d == (Dropped{}) {
+			continue
+		}
+		f.ExchangeID, f.LastPoll = id, d
+		f.Filtered += int64(d.Filtered)
+		f.NonSpot += int64(d.NonSpot)
+		s.filtered[id] = f
+	}
+}
+
+// RecordPoll records the outcome of a poll cycle. A cycle counts as
+// successful when at least one exchange returned data.
+func (s *Status) RecordPoll(polled, succeeded int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.lastPollAt = now
+	s.exchangesPolled = polled
+	s.exchangesSucceeded = succeeded
+	if err != nil {
+		s.lastError = err.Error()
+	} else {
+		s.lastError = ""
+	}
+	if succeeded > 0 && err == nil {
+		s.lastSuccessAt = now
+	}
+}
+
+// Snapshot returns a copy of the current status
+func (s *Status) Snapshot() StatusSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	filtered := make([]FilteredSymbols, 0, len(s.filtered))
+	for _, f := range s.filtered {
+		filtered = append(filtered, f)
+	}
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].ExchangeID < filtered[j].ExchangeID })
+
+	return StatusSnapshot{
+		StartedAt:          s.startedAt,
+		LastPollAt:         s.lastPollAt,
+		LastSuccessAt:      s.lastSuccessAt,
+		LastError:          s.lastError,
+		ExchangesPolled:    s.exchangesPolled,
+		ExchangesSucceeded: s.exchangesSucceeded,
+		Filtered:           filtered,
+	}
+}
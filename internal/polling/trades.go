@@ -0,0 +1,260 @@
+package polling
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"go.uber.org/zap"
+)
+
+// streamedExchanges get their trades from a WebSocket ingester, so the trade
+// poller leaves them alone even if they have a trades endpoint
+var streamedExchanges = map[string]bool{
+	"binance": true,
+}
+
+// TradeConfig controls the recent-trades poller
+type TradeConfig struct {
+	Interval         time.Duration // between polls of an exchange's pairs
+	PairsPerExchange int           // most traded pairs polled on each exchange
+	PairsRefresh     time.Duration // how often the polled pairs are chosen again
+}
+
+// DefaultTradeConfig returns the trade poller defaults
+func DefaultTradeConfig() TradeConfig {
+	return TradeConfig{
+		Interval:         10 * time.Second,
+		PairsPerExchange: 20,
+		PairsRefresh:     time.Hour,
+	}
+}
+
+// MaintenanceSchedule tells whether an exchange is in a scheduled
+// maintenance window
+type MaintenanceSchedule interface {
+	InMaintenance(exchangeID string, at time.Time) bool
+}
+
+// TradePoller fetches recent trades over REST from exchanges with a trades
+// endpoint and stores them in the trades table, so OHLCV can be built from
+// more than the streamed exchanges. Each pair keeps a cursor at the highest
+// trade ID stored, and only trades past it are written; cursors are loaded
+// from the table on start. Exchanges in a maintenance window are not polled.
+//
+// A pair trading faster than its endpoint returns between polls loses the
+// trades in between.
+type TradePoller struct {
+	clickhouseConn driver.Conn
+	clients        map[string]exchanges.ExchangeClient
+	maintenance    MaintenanceSchedule
+	config         TradeConfig
+	logger         *zap.Logger
+}
+
+// NewTradePoller creates a trade poller for the clients that support trades.
+// maintenance may be nil, in which case exchanges are always polled.
+func NewTradePoller(clickhouseConn driver.Conn, clients map[string]exchanges.ExchangeClient, maintenance MaintenanceSchedule,
+	config TradeConfig, logger *zap.Logger) *TradePoller {
+	if config.Interval <= 0 {
+		config.Interval = DefaultTradeConfig().Interval
+	}
+	if config.PairsPerExchange <= 0 {
+		config.PairsPerExchange = DefaultTradeConfig().PairsPerExchange
+	}
+	if config.PairsRefresh <= 0 {
+		config.PairsRefresh = DefaultTradeConfig().PairsRefresh
+	}
+	return &TradePoller{
+		clickhouseConn: clickhouseConn,
+		clients:        clients,
+		maintenance:    maintenance,
+		config:         config,
+		logger:         logger,
+	}
+}
+
+// tradedPair is a pair whose trades are polled
+type tradedPair struct {
+	symbol       string
+	baseTokenID  int
+	quoteTokenID int
+}
+
+// Run polls every supporting exchange until ctx is done
+func (p *TradePoller) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for id, client := range p.clients {
+		tc, ok := client.(exchanges.TradesClient)
+		if !ok || !tc.SupportsTrades() || streamedExchanges[id] {
+			continue
+		}
+		wg.Add(1)
+		go func(client exchanges.ExchangeClient, tc exchanges.TradesClient) {
+			defer wg.Done()
+			p.runExchange(ctx, client, tc)
+		}(client, tc)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (p *TradePoller) runExchange(ctx context.Context, client exchanges.ExchangeClient, tc exchanges.TradesClient) {
+	exchangeID := client.GetID()
+	logger := p.logger.With(zap.String("exchange", exchangeID))
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	// Without the stored cursors every recent trade would be written again,
+	// so keep trying until they load
+	var cursors map[string]uint64
+	for cursors == nil {
+		var err error
+		if cursors, err = db.GetTradeCursors(ctx, p.clickhouseConn, exchangeID); err != nil {
+			logger.Error("Failed to load trade cursors", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+	logger.Info("Starting trade polling", zap.Int("known_pairs", len(cursors)))
+
+	var pairs []tradedPair
+	var pairsAt time.Time
+	for {
+		if len(pairs) == 0 || time.Since(pairsAt) >= p.config.PairsRefresh {
+			found, err := p.tradedPairs(ctx, exchangeID)
+			if err != nil {
+				logger.Error("Failed to choose pairs for trade polling", zap.Error(err))
+			} else {
+				pairs, pairsAt = found, time.Now()
+			}
+		}
+
+		if p.maintenance == nil || !p.maintenance.InMaintenance(exchangeID, time.Now()) {
+			p.pollTrades(ctx, client, tc, pairs, cursors, logger)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollTrades fetches the trades of each pair, pausing between requests for
+// the exchange's rate limit, and stores those past the pair's cursor.
+// Cursors only move once the trades are stored, so a failed insert is
+// retried on the next poll.
+func (p *TradePoller) pollTrades(ctx context.Context, client exchanges.ExchangeClient, tc exchanges.TradesClient,
+	pairs []tradedPair, cursors map[string]uint64, logger *zap.Logger) {
+	var batch []db.TradeData
+	next := make(map[string]uint64)
+	for i, pair := range pairs {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(client.GetRateLimit()):
+			}
+		}
+
+		trades, err := tc.GetRecentTrades(ctx, pair.symbol)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("Failed to fetch recent trades", zap.String("symbol", pair.symbol), zap.Error(err))
+			}
+			continue
+		}
+		fresh := newTrades(trades, cursors[pair.symbol])
+		for _, t := range fresh {
+			var maker uint8
+			if t.IsBuyerMaker {
+				maker = 1
+			}
+			batch = append(batch, db.TradeData{
+				Symbol:       pair.symbol,
+				Price:        t.Price,
+				Quantity:     t.Quantity,
+				TradeID:      t.ID,
+				Timestamp:    timeutil.MillisOf(t.Timestamp),
+				IsBuyerMaker: maker,
+				ExchangeID:   client.GetID(),
+				BaseTokenID:  pair.baseTokenID,
+				QuoteTokenID: pair.quoteTokenID,
+			})
+		}
+		if len(fresh) > 0 {
+			next[pair.symbol] = fresh[len(fresh)-1].ID
+		}
+	}
+
+	if err := db.InsertExchangeTrades(ctx, p.clickhouseConn, batch); err != nil {
+		logger.Error("Failed to store polled trades", zap.Int("trades", len(batch)), zap.Error(err))
+		return
+	}
+	for symbol, id := range next {
+		cursors[symbol] = id
+	}
+	if len(batch) > 0 {
+		logger.Debug("Stored polled trades", zap.Int("trades", len(batch)), zap.Int("pairs", len(next)))
+	}
+}
+
+// newTrades returns the trades after the cursor, given trades sorted by ID
+func newTrades(trades []exchanges.Trade, cursor uint64) []exchanges.Trade {
+	for i, t := range trades {
+		if t.ID > cursor {
+			return trades[i:]
+		}
+	}
+	return nil
+}
+
+// tradedPairs returns the exchange's mapped pairs with the most quote
+// volume in its last hour of tickers. Volumes in different quote currencies
+// are compared as they are, which favours USD-quoted pairs.
+func (p *TradePoller) tradedPairs(ctx context.Context, exchangeID string) ([]tradedPair, error) {
+	rows, err := p.clickhouseConn.Query(ctx, `
+		SELECT symbol, base, quote
+		FROM (
+			SELECT
+				symbol,
+				argMax(base_token_id, timestamp) AS base,
+				argMax(quote_token_id, timestamp) AS quote,
+				argMax(quote_volume_24h, timestamp) AS quote_volume
+			FROM price_tickers
+			WHERE exchange_id = ? AND timestamp >= now() - INTERVAL 1 HOUR
+				AND instrument_type = 'spot'
+			GROUP BY symbol
+		)
+		WHERE base > 0 AND quote > 0
+		ORDER BY quote_volume DESC
+		LIMIT ?
+	`, exchangeID, p.config.PairsPerExchange)
+	if err != nil {
+		return nil, fmt.Errorf("querying traded pairs: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs []tradedPair
+	for rows.Next() {
+		var pair tradedPair
+		var base, quote uint32
+		if err := rows.Scan(&pair.symbol, &base, &quote); err != nil {
+			return nil, fmt.Errorf("scanning traded pair: %w", err)
+		}
+		pair.baseTokenID, pair.quoteTokenID = int(base), int(quote)
+		pairs = append(pairs, pair)
+	}
+	return pairs, rows.Err()
+}
@@ -0,0 +1,21 @@
+package polling
+
+import (
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+)
+
+func TestNewTrades(t *testing.T) {
+	trades := []exchanges.Trade{{ID: 5}, {ID: 7}, {ID: 9}}
+
+	if got := newTrades(trades, 0); len(got) != 3 {
+		t.Errorf("no cursor: got %d trades, want all 3", len(got))
+	}
+	if got := newTrades(trades, 7); len(got) != 1 || got[0].ID != 9 {
+		t.Errorf("cursor 7: got %+v, want only 9", got)
+	}
+	if got := newTrades(trades, 9); len(got) != 0 {
+		t.Errorf("cursor at the newest: got %+v, want none", got)
+	}
+}
@@ -0,0 +1,289 @@
+// Package reconcile compares the symbols exchanges quote with the mappings
+// in token_exchange_symbols every night and reports the differences: quoted
+// symbols without a mapping, mappings no exchange has quoted for a while, and
+// symbols mapped more than once.
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/ashmitsharp/trading/internal/webhooks"
+	"go.uber.org/zap"
+)
+
+// tickerWindow is how far back tickers are read. price_tickers keeps a day.
+const tickerWindow = 24 * time.Hour
+
+// maxFindings caps each list in a report
+const maxFindings = 1000
+
+// Config controls when reports are made and where they are sent
+type Config struct {
+	At            time.Duration // time past midnight UTC to run at
+	StaleAfter    time.Duration // mappings unseen for longer are reported
+	WebhookURL    string        // reports are POSTed here when set
+	WebhookSecret string        // signs webhook deliveries when set
+	Timeout       time.Duration // per webhook delivery
+}
+
+// DefaultConfig returns the reconciliation defaults
+func DefaultConfig() Config {
+	return Config{
+		At:         4 * time.Hour,
+		StaleAfter: 7 * 24 * time.Hour,
+		Timeout:    10 * time.Second,
+	}
+}
+
+// Service makes reconciliation reports
+type Service struct {
+	postgresDB     *sql.DB
+	clickhouseConn driver.Conn
+	config         Config
+	client         *http.Client
+	logger         *zap.Logger
+}
+
+// NewService creates a new reconciliation service
+func NewService(postgresDB *sql.DB, clickhouseConn driver.Conn, config Config, logger *zap.Logger) *Service {
+	return &Service{
+		postgresDB:     postgresDB,
+		clickhouseConn: clickhouseConn,
+		config:         config,
+		client:         &http.Client{Timeout: config.Timeout},
+		logger:         logger,
+	}
+}
+
+// quotedPair is an exchange pair's latest ticker
+type quotedPair struct {
+	exchangeID   string
+	symbol       string
+	baseSymbol   string
+	quoteSymbol  string
+	baseTokenID  uint32
+	quoteTokenID uint32
+	lastSeen     time.Time
+}
+
+// Reconcile records which mapped symbols were quoted in the last day,
+// stores a report of the differences and sends it to the webhook if one is
+// configured. A failed delivery is recorded on the report, not returned.
+func (s *Service) Reconcile(ctx context.Context) (db.ReconciliationReport, error) {
+	pairs, err := s.quotedPairs(ctx)
+	if err != nil {
+		return db.ReconciliationReport{}, err
+	}
+
+	seen, unmapped := summarize(pairs)
+	if _, err := db.MarkSymbolsSeen(ctx, s.postgresDB, seen); err != nil {
+		return db.ReconciliationReport{}, err
+	}
+
+	stale, err := db.GetStaleMappings(ctx, s.postgresDB, time.Now().Add(-s.config.StaleAfter), maxFindings)
+	if err != nil {
+		return db.ReconciliationReport{}, err
+	}
+	duplicates, err := db.GetDuplicateMappings(ctx, s.postgresDB)
+	if err != nil {
+		return db.ReconciliationReport{}, err
+	}
+	if len(duplicates) > maxFindings {
+		duplicates = duplicates[:maxFindings]
+	}
+
+	report, err := db.InsertReconciliationReport(ctx, s.postgresDB, db.ReconciliationFindings{
+		Unmapped:   unmapped,
+		Stale:      stale,
+		Duplicates: duplicates,
+	})
+	if err != nil {
+		return db.ReconciliationReport{}, err
+	}
+
+	if s.config.WebhookURL != "" {
+		deliveryErr := s.deliver(ctx, report)
+		if deliveryErr != nil {
+			s.logger.Warn("Failed to deliver reconciliation report",
+				zap.Int("report_id", report.ID), zap.Error(deliveryErr))
+			report.DeliveryError = deliveryErr.Error()
+		} else {
+			now := time.Now()
+			report.DeliveredAt = &now
+		}
+		if err := db.SetReconciliationDelivery(ctx, s.postgresDB, report.ID, deliveryErr); err != nil {
+			s.logger.Error("Failed to record reconciliation delivery", zap.Error(err))
+		}
+	}
+	return report, nil
+}
+
+func (s *Service) quotedPairs(ctx context.Context) ([]quotedPair, error) {
+	rows, err := s.clickhouseConn.Query(ctx, `
+		SELECT
+			exchange_id,
+			symbol,
+			argMax(base_symbol, timestamp),
+			argMax(quote_symbol, timestamp),
+			argMax(base_token_id, timestamp),
+			argMax(quote_token_id, timestamp),
+			max(timestamp)
+		FROM price_tickers
+		WHERE timestamp >= now() - INTERVAL ? SECOND
+		GROUP BY exchange_id, symbol
+	`, int(tickerWindow.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("querying quoted pairs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []quotedPair
+	for rows.Next() {
+		var p quotedPair
+		if err := rows.Scan(&p.exchangeID, &p.symbol, &p.baseSymbol, &p.quoteSymbol,
+			&p.baseTokenID, &p.quoteTokenID, &p.lastSeen); err != nil {
+			return nil, fmt.Errorf("scanning quoted pair: %w", err)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// summarize returns when each exchange symbol was last quoted and the pairs
+// whose latest ticker could not be mapped, sorted by exchange and symbol
+func summarize(pairs []quotedPair) ([]db.SeenSymbol, []db.UnmappedSymbol) {
+	type key struct{ exchangeID, symbol string }
+	latest := make(map[key]time.Time)
+	see := func(exchangeID, symbol string, at time.Time) {
+		if symbol == "" {
+			return
+		}
+		k := key{exchangeID, symbol}
+		if at.After(latest[k]) {
+			latest[k] = at
+		}
+	}
+
+	var unmapped []db.UnmappedSymbol
+	for _, p := range pairs {
+		see(p.exchangeID, p.baseSymbol, p.lastSeen)
+		see(p.exchangeID, p.quoteSymbol, p.lastSeen)
+		if p.baseTokenID == 0 || p.quoteTokenID == 0 {
+			unmapped = append(unmapped, db.UnmappedSymbol{
+				ExchangeID:   p.exchangeID,
+				Symbol:       p.symbol,
+				BaseSymbol:   p.baseSymbol,
+				QuoteSymbol:  p.quoteSymbol,
+				MissingBase:  p.baseTokenID == 0,
+				MissingQuote: p.quoteTokenID == 0,
+				LastSeenAt:   p.lastSeen,
+			})
+		}
+	}
+	sort.Slice(unmapped, func(i, j int) bool {
+		if unmapped[i].ExchangeID != unmapped[j].ExchangeID {
+			return unmapped[i].ExchangeID < unmapped[j].ExchangeID
+		}
+		return unmapped[i].Symbol < unmapped[j].Symbol
+	})
+	if len(unmapped) > maxFindings {
+		unmapped = unmapped[:maxFindings]
+	}
+
+	seen := make([]db.SeenSymbol, 0, len(latest))
+	for k, at := range latest {
+		seen = append(seen, db.SeenSymbol{ExchangeID: k.exchangeID, Symbol: k.symbol, At: at})
+	}
+	return seen, unmapped
+}
+
+// webhookPayload is the body POSTed to the reconciliation webhook
+type webhookPayload struct {
+	ID             int                       `json:"id"`
+	CreatedAt      time.Time                 `json:"created_at"`
+	UnmappedCount  int                       `json:"unmapped_count"`
+	StaleCount     int                       `json:"stale_count"`
+	DuplicateCount int                       `json:"duplicate_count"`
+	Findings       db.ReconciliationFindings `json:"findings"`
+}
+
+// deliver POSTs a report to the webhook, signed like price webhooks when a
+// secret is configured
+func (s *Service) deliver(ctx context.Context, r db.ReconciliationReport) error {
+	body, err := json.Marshal(webhookPayload{
+		ID:             r.ID,
+		CreatedAt:      r.CreatedAt,
+		UnmappedCount:  r.UnmappedCount,
+		StaleCount:     r.StaleCount,
+		DuplicateCount: r.DuplicateCount,
+		Findings:       r.Findings,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "trading-reconciliation/1")
+	if s.config.WebhookSecret != "" {
+		timestamp := time.Now().Unix()
+		req.Header.Set(webhooks.TimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(webhooks.SignatureHeader, webhooks.Sign(s.config.WebhookSecret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Run makes a report every day at Config.At past midnight UTC until ctx is
+// done
+func (s *Service) Run(ctx context.Context) error {
+	for {
+		next := timeutil.NextDaily(time.Now(), s.config.At)
+		s.logger.Info("Next mapping reconciliation scheduled", zap.Time("at", next))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		report, err := s.Reconcile(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to reconcile mappings", zap.Error(err))
+			}
+			continue
+		}
+		s.logger.Info("Mapping reconciliation report stored",
+			zap.Int("report_id", report.ID),
+			zap.Int("unmapped", report.UnmappedCount),
+			zap.Int("stale", report.StaleCount),
+			zap.Int("duplicates", report.DuplicateCount))
+	}
+}
@@ -0,0 +1,36 @@
+package reconcile
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	earlier := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+	pairs := []quotedPair{
+		{exchangeID: "gate", symbol: "BTC_USDT", baseSymbol: "BTC", quoteSymbol: "USDT", baseTokenID: 1, quoteTokenID: 2, lastSeen: earlier},
+		{exchangeID: "gate", symbol: "NEW_USDT", baseSymbol: "NEW", quoteSymbol: "USDT", quoteTokenID: 2, lastSeen: later},
+		{exchangeID: "binance", symbol: "BTCXYZ", baseSymbol: "BTC", quoteSymbol: "XYZ", baseTokenID: 1, lastSeen: earlier},
+	}
+
+	seen, unmapped := summarize(pairs)
+
+	if len(unmapped) != 2 || unmapped[0].ExchangeID != "binance" || unmapped[1].Symbol != "NEW_USDT" {
+		t.Fatalf("unmapped = %+v, want binance BTCXYZ then gate NEW_USDT", unmapped)
+	}
+	if !unmapped[0].MissingQuote || unmapped[0].MissingBase || !unmapped[1].MissingBase {
+		t.Errorf("missing sides wrong: %+v", unmapped)
+	}
+
+	got := make(map[string]time.Time)
+	for _, s := range seen {
+		got[s.ExchangeID+"/"+s.Symbol] = s.At
+	}
+	if len(got) != 5 {
+		t.Errorf("seen %d symbols, want 5: %v", len(got), got)
+	}
+	if !got["gate/USDT"].Equal(later) {
+		t.Errorf("gate/USDT last seen %v, want the later ticker %v", got["gate/USDT"], later)
+	}
+}
@@ -0,0 +1,60 @@
+// Package requestid carries the ID of the API request being served through
+// contexts, so that everything logged on its behalf can be correlated.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.uber.org/zap"
+)
+
+// Header is the request and response header carrying the ID
+const Header = "X-Request-ID"
+
+// maxLength bounds IDs accepted from clients
+const maxLength = 128
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID in ctx, or "" if there is none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Logger returns logger with the request ID in ctx as a field, or logger
+// itself outside a request
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if id := FromContext(ctx); id != "" {
+		return logger.With(zap.String("request_id", id))
+	}
+	return logger
+}
+
+// New returns a random request ID
+func New() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether id, taken from a client or an upstream proxy, is
+// safe to echo and log: 1-128 visible ASCII characters
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	cases := map[string]bool{
+		"":                       false,
+		"abc-123":                true,
+		"b7f3c2d1e0a94f6b":       true,
+		"has space":              false,
+		"line\nbreak":            false,
+		"café":                   false,
+		strings.Repeat("a", 128): true,
+		strings.Repeat("a", 129): false,
+	}
+	for id, want := range cases {
+		if got := Valid(id); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	a, b := New(), New()
+	if !Valid(a) || len(a) != 32 {
+		t.Fatalf("New() = %q, want 32 hex characters", a)
+	}
+	if a == b {
+		t.Fatalf("New() returned %q twice", a)
+	}
+}
+
+func TestContext(t *testing.T) {
+	if id := FromContext(context.Background()); id != "" {
+		t.Fatalf("FromContext(empty) = %q, want empty", id)
+	}
+	ctx := NewContext(context.Background(), "req-1")
+	if id := FromContext(ctx); id != "req-1" {
+		t.Fatalf("FromContext = %q, want req-1", id)
+	}
+}
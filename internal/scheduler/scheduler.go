@@ -1,8 +1,10 @@
 package scheduler
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -83,6 +85,12 @@ func (s *Scheduler) registerJobs() {
 func (s *Scheduler) updateTokenMetadata() error {
 	s.logger.Info("Starting token metadata update")
 
+	// Keep today's supply before it is overwritten below. The first run of
+	// the day records the snapshot; later runs leave it alone.
+	if err := s.snapshotTokenSupply(); err != nil {
+		s.logger.Warn("Failed to snapshot token supply", zap.Error(err))
+	}
+
 	// Get all tokens from database
 	tokens, err := db.GetAllTokens(s.db)
 	if err != nil {
@@ -102,7 +110,10 @@ func (s *Scheduler) updateTokenMetadata() error {
 		}
 
 		// Update token in database
-		if err := db.UpdateTokenMarketData(s.db, token.Symbol, marketData.MarketCap, marketData.CirculatingSupply); err != nil {
+		if err := db.UpdateTokenMarketData(s.db, token.Symbol, marketData.MarketCap, marketData.CirculatingSupply); errors.Is(err, db.ErrSymbolNotFound) {
+			s.logger.Warn("Token disappeared before market data update", zap.String("symbol", token.Symbol))
+			continue
+		} else if err != nil {
 			s.logger.Error("Failed to update token market data",
 				zap.String("symbol", token.Symbol),
 				zap.Error(err))
@@ -122,6 +133,19 @@ func (s *Scheduler) updateTokenMetadata() error {
 	return nil
 }
 
+// snapshotTokenSupply records today's supply for every token into
+// token_supply_snapshots; repeated calls on the same day are no-ops
+func (s *Scheduler) snapshotTokenSupply() error {
+	written, err := db.SnapshotTokenSupply(context.Background(), s.db)
+	if err != nil {
+		return err
+	}
+	if written > 0 {
+		s.logger.Info("Recorded token supply snapshots", zap.Int64("tokens", written))
+	}
+	return nil
+}
+
 // TokenMarketData represents market data from external API
 type TokenMarketData struct {
 	MarketCap         float64
@@ -235,22 +259,39 @@ func (s *Scheduler) cleanupOldData() error {
 	return nil
 }
 
+// JobStatus is when a scheduled job last ran and runs next. Prev is zero
+// until the job has run once.
+type JobStatus struct {
+	Next time.Time
+	Prev time.Time
+}
+
+// Jobs returns the scheduled jobs in the order they were registered
+func (s *Scheduler) Jobs() []JobStatus {
+	entries := s.cron.Entries()
+	jobs := make([]JobStatus, 0, len(entries))
+	for _, entry := range entries {
+		jobs = append(jobs, JobStatus{Next: entry.Next, Prev: entry.Prev})
+	}
+	return jobs
+}
+
 // GetJobStats returns statistics about scheduled jobs
 func (s *Scheduler) GetJobStats() map[string]interface{} {
-	entries := s.cron.Entries()
+	jobs := s.Jobs()
 
-	var jobs []map[string]interface{}
-	for _, entry := range entries {
-		jobs = append(jobs, map[string]interface{}{
-			"next_run": entry.Next.Unix(),
-			"prev_run": entry.Prev.Unix(),
+	var stats []map[string]interface{}
+	for _, job := range jobs {
+		stats = append(stats, map[string]interface{}{
+			"next_run": job.Next.Unix(),
+			"prev_run": job.Prev.Unix(),
 		})
 	}
 
 	return map[string]interface{}{
-		"total_jobs":   len(entries),
-		"jobs":         jobs,
-		"is_running":   len(entries) > 0,
+		"total_jobs":   len(jobs),
+		"jobs":         stats,
+		"is_running":   len(jobs) > 0,
 		"last_updated": time.Now().Unix(),
 	}
 }
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// IndexLevel is the computed level of an index at a point in time
+type IndexLevel struct {
+	IndexID          int
+	Level            decimal.Decimal
+	ConstituentCount int
+	Timestamp        time.Time
+}
+
+// IndexStorage handles storage of index levels
+type IndexStorage struct {
+	conn   driver.Conn
+	logger *zap.Logger
+}
+
+// NewIndexStorage creates a new index storage service
+func NewIndexStorage(conn driver.Conn, logger *zap.Logger) *IndexStorage {
+	return &IndexStorage{
+		conn:   conn,
+		logger: logger,
+	}
+}
+
+// StoreIndexLevels stores index levels in ClickHouse
+func (s *IndexStorage) StoreIndexLevels(ctx context.Context, levels []IndexLevel) error {
+	if len(levels) == 0 {
+		return nil
+	}
+
+	batch, err := s.conn.PrepareBatch(ctx, `
+		INSERT INTO index_levels (
+			timestamp, index_id, level, constituent_count
+		)`)
+	if err != nil {
+		return fmt.Errorf("preparing index level batch: %w", err)
+	}
+
+	for _, l := range levels {
+		if err := batch.Append(
+			l.Timestamp,
+			uint32(l.IndexID),
+			l.Level,
+			uint16(l.ConstituentCount),
+		); err != nil {
+			return fmt.Errorf("appending index level: %w", err)
+		}
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("sending index level batch: %w", err)
+	}
+	return nil
+}
+
+// GetLatestIndexLevel retrieves the latest level of an index together with
+// its level 24h earlier, returning db.ErrNoData if none has been recorded
+func (s *IndexStorage) GetLatestIndexLevel(ctx context.Context, indexID int) (*IndexLevel, decimal.Decimal, error) {
+	query := `
+		SELECT
+			argMax(level, timestamp) as latest_level,
+			argMax(constituent_count, timestamp) as constituents,
+			max(timestamp) as last_update,
+			argMinIf(level, timestamp, timestamp >= now() - INTERVAL 24 HOUR) as open_level,
+			count() as points
+		FROM index_levels
+		WHERE index_id = ?
+	`
+
+	level := &IndexLevel{IndexID: indexID}
+	var constituents uint16
+	var open decimal.Decimal
+	var points uint64
+
+	err := s.conn.QueryRow(ctx, query, uint32(indexID)).Scan(
+		&level.Level,
+		&constituents,
+		&level.Timestamp,
+		&open,
+		&points,
+	)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && points == 0) {
+		return nil, decimal.Zero, fmt.Errorf("latest level for index %d: %w", indexID, db.ErrNoData)
+	}
+	if err != nil {
+		return nil, decimal.Zero, fmt.Errorf("querying latest index level: %w", err)
+	}
+
+	level.ConstituentCount = int(constituents)
+	return level, open, nil
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.uber.org/zap"
+)
+
+// hourlyRollupRetention is how long the hourly rollups are kept; longer
+// windows are read from the daily ones
+const hourlyRollupRetention = 90 * 24 * time.Hour
+
+// ExchangeHealthSample is the outcome of polling one exchange once
+type ExchangeHealthSample struct {
+	Timestamp      time.Time
+	ExchangeID     string
+	ResponseTime   time.Duration
+	Success        bool
+	ErrorMessage   string
+	SymbolsFetched int
+}
+
+// ExchangeUptime summarizes an exchange's polls over a window
+type ExchangeUptime struct {
+	ExchangeID      string
+	Polls           uint64
+	SuccessfulPolls uint64
+	AvgResponseMs   float64
+	P95ResponseMs   float64
+	LastPoll        time.Time
+}
+
+// RouteUsage summarizes the requests to one API route over a window
+type RouteUsage struct {
+	Route         string
+	Method        string
+	Requests      uint64
+	ClientErrors  uint64
+	ServerErrors  uint64
+	AvgDurationMs float64
+	P95DurationMs float64
+	ResponseBytes uint64
+}
+
+// OpsStorage stores and reads the operational tables: exchange poll health
+// and API requests, through their hourly and daily rollups
+type OpsStorage struct {
+	conn   driver.Conn
+	logger *zap.Logger
+}
+
+// NewOpsStorage creates a new operational storage service
+func NewOpsStorage(conn driver.Conn, logger *zap.Logger) *OpsStorage {
+	return &OpsStorage{
+		conn:   conn,
+		logger: logger,
+	}
+}
+
+// RecordExchangeHealth stores the outcomes of one poll cycle
+func (s *OpsStorage) RecordExchangeHealth(ctx context.Context, samples []ExchangeHealthSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	batch, err := s.conn.PrepareBatch(ctx, `
+		INSERT INTO exchange_health (
+			timestamp, exchange_id, response_time_ms, success, error_message, symbols_fetched
+		)`)
+	if err != nil {
+		return fmt.Errorf("preparing exchange health batch: %w", err)
+	}
+	for _, sample := range samples {
+		if err := batch.Append(
+			sample.Timestamp,
+			sample.ExchangeID,
+			uint32(sample.ResponseTime.Milliseconds()),
+			sample.Success,
+			sample.ErrorMessage,
+			uint32(sample.SymbolsFetched),
+		); err != nil {
+			return fmt.Errorf("appending exchange health: %w", err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("storing exchange health: %w", err)
+	}
+	return nil
+}
+
+// rollup picks the hourly or daily rollup of a table for a window starting
+// at since, returning the view and the condition selecting the window from
+// the start of since's hour or day
+func rollup(table string, since time.Time) (string, string) {
+	if time.Since(since) <= hourlyRollupRetention {
+		return table + "_hourly", "hour >= toStartOfHour(?)"
+	}
+	return table + "_daily", "day >= toDate(?)"
+}
+
+// GetExchangeUptime summarizes each exchange's polls since a time from the
+// rollups, counting whole hours, or whole days for windows reaching past
+// the hourly retention
+func (s *OpsStorage) GetExchangeUptime(ctx context.Context, since time.Time) ([]ExchangeUptime, error) {
+	view, window := rollup("exchange_health", since)
+	rows, err := s.conn.Query(ctx, `
+		SELECT
+			exchange_id,
+			countMerge(polls),
+			countIfMerge(successful_polls),
+			avgMerge(avg_response_time),
+			quantileMerge(0.95)(p95_response_time),
+			maxMerge(last_poll_time)
+		FROM `+view+`
+		WHERE `+window+`
+		GROUP BY exchange_id
+		ORDER BY exchange_id
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("querying exchange uptime: %w", err)
+	}
+	defer rows.Close()
+
+	var uptimes []ExchangeUptime
+	for rows.Next() {
+		var u ExchangeUptime
+		if err := rows.Scan(&u.ExchangeID, &u.Polls, &u.SuccessfulPolls, &u.AvgResponseMs, &u.P95ResponseMs, &u.LastPoll); err != nil {
+			return nil, fmt.Errorf("scanning exchange uptime: %w", err)
+		}
+		uptimes = append(uptimes, u)
+	}
+	return uptimes, rows.Err()
+}
+
+// GetAPIUsage summarizes requests per route since a time from the rollups,
+// busiest route first
+func (s *OpsStorage) GetAPIUsage(ctx context.Context, since time.Time) ([]RouteUsage, error) {
+	view, window := rollup("api_requests", since)
+	rows, err := s.conn.Query(ctx, `
+		SELECT
+			route,
+			method,
+			countMerge(requests) as total,
+			countIfMerge(client_errors),
+			countIfMerge(server_errors),
+			avgMerge(avg_duration),
+			toFloat64(quantileMerge(0.95)(p95_duration)),
+			sumMerge(response_bytes)
+		FROM `+view+`
+		WHERE `+window+`
+		GROUP BY route, method
+		ORDER BY total DESC, route, method
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("querying API usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []RouteUsage
+	for rows.Next() {
+		var u RouteUsage
+		if err := rows.Scan(&u.Route, &u.Method, &u.Requests, &u.ClientErrors, &u.ServerErrors,
+			&u.AvgDurationMs, &u.P95DurationMs, &u.ResponseBytes); err != nil {
+			return nil, fmt.Errorf("scanning API usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/requestid"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
@@ -33,7 +35,7 @@ func (s *PriceStorage) StorePriceTickers(ctx context.Context, tickers []exchange
 	batch, err := s.conn.PrepareBatch(ctx, `
 		INSERT INTO price_tickers (
 			timestamp, exchange_id, symbol, base_symbol, quote_symbol,
-			base_token_id, quote_token_id, price, volume_24h, quote_volume_24h,
+			base_token_id, quote_token_id, instrument_type, price, volume_24h, quote_volume_24h,
 			price_change_24h, high_24h, low_24h
 		)`)
 	if err != nil {
@@ -55,6 +57,7 @@ func (s *PriceStorage) StorePriceTickers(ctx context.Context, tickers []exchange
 			ticker.QuoteSymbol,
 			uint32(ticker.BaseTokenID),   // Will be 0 if not mapped yet
 			uint32(ticker.QuoteTokenID),  // Will be 0 if not mapped yet
+			string(ticker.Instrument()),
 			ticker.Price,
 			ticker.Volume24h,
 			ticker.QuoteVolume24h,
@@ -98,6 +101,7 @@ func (s *PriceStorage) GetLatestPrices(ctx context.Context, window time.Duration
 			max(timestamp) as latest_timestamp
 		FROM price_tickers
 		WHERE timestamp >= now() - INTERVAL ? SECOND
+			AND instrument_type = 'spot'
 		GROUP BY exchange_id, symbol, base_symbol, quote_symbol, base_token_id, quote_token_id
 		HAVING latest_price > 0
 	`
@@ -111,50 +115,70 @@ func (s *PriceStorage) GetLatestPrices(ctx context.Context, window time.Duration
 	var tickers []exchanges.TickerData
 	for rows.Next() {
 		var ticker exchanges.TickerData
+		var baseTokenID, quoteTokenID uint32
 		if err := rows.Scan(
 			&ticker.ExchangeID,
 			&ticker.Symbol,
 			&ticker.BaseSymbol,
 			&ticker.QuoteSymbol,
-			&ticker.BaseTokenID,
-			&ticker.QuoteTokenID,
+			&baseTokenID,
+			&quoteTokenID,
 			&ticker.Price,
 			&ticker.Volume24h,
 			&ticker.Timestamp,
 		); err != nil {
-			s.logger.Error("Failed to scan ticker", zap.Error(err))
+			requestid.Logger(ctx, s.logger).Error("Failed to scan ticker", zap.Error(err))
 			continue
 		}
+		ticker.BaseTokenID = int(baseTokenID)
+		ticker.QuoteTokenID = int(quoteTokenID)
 		tickers = append(tickers, ticker)
 	}
 
 	return tickers, nil
 }
 
-// UpdateExchangeHealth stores exchange health metrics
-func (s *PriceStorage) UpdateExchangeHealth(ctx context.Context, exchangeID string, isHealthy bool, responseTime time.Duration) error {
+// PairSnapshot is the most recent ticker seen for an exchange pair
+type PairSnapshot struct {
+	ExchangeID  string
+	Symbol      string
+	QuoteSymbol string
+	// InstrumentType is spot, perp, futures or leveraged_token
+	InstrumentType string
+	Price          decimal.Decimal
+	LastSeen       time.Time
+}
+
+// GetLatestPairsForBase returns the last price and time seen for every
+// exchange pair quoting the given base token, within the table's retention
+func (s *PriceStorage) GetLatestPairsForBase(ctx context.Context, baseTokenID int) ([]PairSnapshot, error) {
 	query := `
-		INSERT INTO exchange_health (
-			timestamp, exchange_id, is_healthy, response_time_ms, error_count
-		) VALUES (?, ?, ?, ?, ?)
+		SELECT
+			exchange_id,
+			symbol,
+			any(quote_symbol) as quote_symbol,
+			any(instrument_type) as instrument_type,
+			argMax(price, timestamp) as latest_price,
+			max(timestamp) as last_seen
+		FROM price_tickers
+		WHERE base_token_id = ?
+		GROUP BY exchange_id, symbol
 	`
 
-	errorCount := 0
-	if !isHealthy {
-		errorCount = 1
+	rows, err := s.conn.Query(ctx, query, baseTokenID)
+	if err != nil {
+		return nil, fmt.Errorf("querying pair snapshots: %w", err)
 	}
+	defer rows.Close()
 
-	err := s.conn.Exec(ctx, query,
-		time.Now(),
-		exchangeID,
-		isHealthy,
-		responseTime.Milliseconds(),
-		errorCount,
-	)
-
-	if err != nil {
-		return fmt.Errorf("updating exchange health: %w", err)
+	var snapshots []PairSnapshot
+	for rows.Next() {
+		var p PairSnapshot
+		if err := rows.Scan(&p.ExchangeID, &p.Symbol, &p.QuoteSymbol, &p.InstrumentType, &p.Price, &p.LastSeen); err != nil {
+			return nil, fmt.Errorf("scanning pair snapshot: %w", err)
+		}
+		snapshots = append(snapshots, p)
 	}
 
-	return nil
-}
\ No newline at end of file
+	return snapshots, rows.Err()
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.uber.org/zap"
+)
+
+// RequestLogEntry is one API request
+type RequestLogEntry struct {
+	Timestamp     time.Time
+	Method        string
+	Route         string
+	Status        int
+	Duration      time.Duration
+	ResponseBytes int
+	APIKey        string // hash of the caller's key on authenticated routes
+}
+
+// RequestLog writes API requests to ClickHouse in batches. Record never
+// blocks a request: entries arriving while the buffer is full are dropped.
+type RequestLog struct {
+	conn      driver.Conn
+	entries   chan RequestLogEntry
+	batchSize int
+	logger    *zap.Logger
+
+	dropped atomic.Uint64
+}
+
+// NewRequestLog creates a request log buffering up to bufferSize entries
+// between writes
+func NewRequestLog(conn driver.Conn, bufferSize int, logger *zap.Logger) *RequestLog {
+	if bufferSize <= 0 {
+		bufferSize = 10000
+	}
+	return &RequestLog{
+		conn:      conn,
+		entries:   make(chan RequestLogEntry, bufferSize),
+		batchSize: bufferSize / 2,
+		logger:    logger,
+	}
+}
+
+// Record queues an entry
+func (l *RequestLog) Record(entry RequestLogEntry) {
+	select {
+	case l.entries <- entry:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// Run writes queued entries every interval, or sooner once half the buffer
+// is waiting, until ctx is done, then writes what is left
+func (l *RequestLog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	batch := make([]RequestLogEntry, 0, l.batchSize)
+	var droppedBefore uint64
+	flush := func(ctx context.Context) {
+		if dropped := l.dropped.Load(); dropped > droppedBefore {
+			l.logger.Warn("Request log buffer full, requests not logged", zap.Uint64("dropped", dropped-droppedBefore))
+			droppedBefore = dropped
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := l.write(ctx, batch); err != nil {
+			l.logger.Error("Failed to write request log", zap.Error(err), zap.Int("requests", len(batch)))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry := <-l.entries:
+			batch = append(batch, entry)
+			if len(batch) >= l.batchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			for len(l.entries) > 0 {
+				batch = append(batch, <-l.entries)
+			}
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			flush(shutdownCtx)
+			cancel()
+			return
+		}
+	}
+}
+
+func (l *RequestLog) write(ctx context.Context, entries []RequestLogEntry) error {
+	batch, err := l.conn.PrepareBatch(ctx, `
+		INSERT INTO api_requests (timestamp, method, route, status, duration_ms, response_bytes, api_key)
+	`)
+	if err != nil {
+		return fmt.Errorf("preparing request log batch: %w", err)
+	}
+	for _, e := range entries {
+		bytes := e.ResponseBytes
+		if bytes < 0 {
+			bytes = 0
+		}
+		if err := batch.Append(
+			e.Timestamp,
+			e.Method,
+			e.Route,
+			uint16(e.Status),
+			float32(e.Duration.Microseconds())/1000,
+			uint64(bytes),
+			e.APIKey,
+		); err != nil {
+			return fmt.Errorf("appending request log entry: %w", err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("storing request log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+//go:build integration
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/testutil"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// One polling cycle at production size: 30 exchanges reporting 2k pairs each
+const (
+	benchExchanges = 30
+	benchPairs     = 2000
+)
+
+func benchTickers(now time.Time) []exchanges.TickerData {
+	tickers := make([]exchanges.TickerData, 0, benchExchanges*benchPairs)
+	for ex := 0; ex < benchExchanges; ex++ {
+		for pair := 1; pair <= benchPairs; pair++ {
+			price := decimal.NewFromFloat(float64(pair) * 1.0001)
+			tickers = append(tickers, exchanges.TickerData{
+				ExchangeID:     fmt.Sprintf("exchange%02d", ex),
+				Symbol:         fmt.Sprintf("TOKEN%dUSDT", pair),
+				BaseSymbol:     fmt.Sprintf("TOKEN%d", pair),
+				QuoteSymbol:    "USDT",
+				BaseTokenID:    pair,
+				QuoteTokenID:   benchPairs + 1,
+				Price:          price,
+				Volume24h:      decimal.NewFromInt(int64(pair * 100)),
+				QuoteVolume24h: price.Mul(decimal.NewFromInt(int64(pair * 100))),
+				PriceChange24h: decimal.RequireFromString("0.0123"),
+				High24h:        price.Mul(decimal.RequireFromString("1.01")),
+				Low24h:         price.Mul(decimal.RequireFromString("0.99")),
+				Timestamp:      now,
+			})
+		}
+	}
+	return tickers
+}
+
+func BenchmarkStorePriceTickers(b *testing.B) {
+	s := NewPriceStorage(testutil.ClickHouse(b), zap.NewNop())
+	ctx := context.Background()
+	tickers := benchTickers(time.Now())
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := s.StorePriceTickers(ctx, tickers); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(tickers)*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
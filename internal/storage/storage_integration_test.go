@@ -0,0 +1,186 @@
+//go:build integration
+
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/calculator"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/testutil"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+func TestPriceStorageRoundTrip(t *testing.T) {
+	conn := testutil.ClickHouse(t)
+	s := NewPriceStorage(conn, zap.NewNop())
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	tickers := []exchanges.TickerData{
+		{ExchangeID: "binance", Symbol: "BTCUSDT", BaseSymbol: "BTC", QuoteSymbol: "USDT",
+			BaseTokenID: 1, QuoteTokenID: 2, Price: decimal.RequireFromString("64000.5"),
+			Volume24h: decimal.NewFromInt(1200), Timestamp: now.Add(-time.Second)},
+		{ExchangeID: "binance", Symbol: "BTCUSDT", BaseSymbol: "BTC", QuoteSymbol: "USDT",
+			BaseTokenID: 1, QuoteTokenID: 2, Price: decimal.RequireFromString("64010.25"),
+			Volume24h: decimal.NewFromInt(1210), Timestamp: now},
+		// Unparsed symbols are dropped
+		{ExchangeID: "kraken", Symbol: "XBTUSD", Price: decimal.NewFromInt(64000), Timestamp: now},
+	}
+	if err := s.StorePriceTickers(ctx, tickers); err != nil {
+		t.Fatalf("StorePriceTickers: %v", err)
+	}
+
+	latest, err := s.GetLatestPrices(ctx, time.Minute)
+	if err != nil {
+		t.Fatalf("GetLatestPrices: %v", err)
+	}
+	if len(latest) != 1 {
+		t.Fatalf("got %d tickers, want 1: %+v", len(latest), latest)
+	}
+	got := latest[0]
+	if got.ExchangeID != "binance" || got.BaseTokenID != 1 || got.QuoteTokenID != 2 {
+		t.Errorf("unexpected ticker identity: %+v", got)
+	}
+	if !got.Price.Equal(decimal.RequireFromString("64010.25")) {
+		t.Errorf("price = %s, want the latest 64010.25", got.Price)
+	}
+	if !got.Timestamp.Equal(now) {
+		t.Errorf("timestamp = %s, want %s", got.Timestamp, now)
+	}
+}
+
+func TestVWAPStorageRoundTrip(t *testing.T) {
+	conn := testutil.ClickHouse(t)
+	s := NewVWAPStorage(conn, zap.NewNop())
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	btc := calculator.PairKey{BaseTokenID: 1, QuoteTokenID: 2}
+	eth := calculator.PairKey{BaseTokenID: 3, QuoteTokenID: 2}
+	results := map[calculator.PairKey]*calculator.VWAPResult{
+		btc: {
+			BaseTokenID: 1, QuoteTokenID: 2,
+			VWAPPrice:             decimal.RequireFromString("64005"),
+			TotalVolume:           decimal.NewFromInt(300),
+			ExchangeCount:         2,
+			ContributingExchanges: []string{"binance", "coinbase"},
+			PriceSources: []calculator.PriceSource{
+				{Exchange: "binance", Price: decimal.NewFromInt(64000), Volume: decimal.NewFromInt(200), Weight: decimal.RequireFromString("0.6")},
+				{Exchange: "coinbase", Price: decimal.NewFromInt(64015), Volume: decimal.NewFromInt(100), Weight: decimal.RequireFromString("0.4")},
+			},
+			SpreadBps:      2.3,
+			LiquidityScore: 80,
+			Timestamp:      now,
+		},
+		eth: {
+			BaseTokenID: 3, QuoteTokenID: 2,
+			VWAPPrice:             decimal.NewFromInt(3100),
+			TotalVolume:           decimal.NewFromInt(50),
+			ExchangeCount:         1,
+			ContributingExchanges: []string{"binance"},
+			PriceSources: []calculator.PriceSource{
+				{Exchange: "binance", Price: decimal.NewFromInt(3100), Volume: decimal.NewFromInt(50), Weight: decimal.NewFromInt(1)},
+			},
+			LiquidityScore: 10,
+			Indicative:     true,
+			Timestamp:      now,
+		},
+		// Unresolved pairs are skipped
+		{}: {VWAPPrice: decimal.NewFromInt(1), Timestamp: now},
+	}
+	if err := s.StoreVWAPResults(ctx, results); err != nil {
+		t.Fatalf("StoreVWAPResults: %v", err)
+	}
+
+	latest, err := s.GetLatestVWAP(ctx, 1, 2)
+	if err != nil {
+		t.Fatalf("GetLatestVWAP: %v", err)
+	}
+	if !latest.VWAPPrice.Equal(decimal.NewFromInt(64005)) || latest.ExchangeCount != 2 || latest.Indicative {
+		t.Errorf("unexpected latest VWAP: %+v", latest)
+	}
+	if !latest.Timestamp.Equal(now) {
+		t.Errorf("timestamp = %s, want %s", latest.Timestamp, now)
+	}
+
+	sources, err := s.GetVWAPComposition(ctx, 1, 2, latest.Timestamp)
+	if err != nil {
+		t.Fatalf("GetVWAPComposition: %v", err)
+	}
+	if len(sources) != 2 || sources[0].Exchange != "binance" || sources[1].Exchange != "coinbase" {
+		t.Fatalf("unexpected composition: %+v", sources)
+	}
+	if !sources[0].Weight.Equal(decimal.RequireFromString("0.6")) {
+		t.Errorf("binance weight = %s, want 0.6", sources[0].Weight)
+	}
+
+	summaries, err := s.GetLatestPairVWAPs(ctx, []int{2}, 50)
+	if err != nil {
+		t.Fatalf("GetLatestPairVWAPs: %v", err)
+	}
+	if len(summaries) != 1 || summaries[0].BaseTokenID != 1 {
+		t.Fatalf("liquidity filter kept %+v, want only the BTC pair", summaries)
+	}
+
+	summaries, err = s.GetLatestPairVWAPs(ctx, []int{2}, 0)
+	if err != nil {
+		t.Fatalf("GetLatestPairVWAPs: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("got %d summaries, want 2", len(summaries))
+	}
+	for _, summary := range summaries {
+		if summary.BaseTokenID == 3 && !summary.Indicative {
+			t.Error("ETH summary should be indicative")
+		}
+	}
+}
+
+func TestOpsStorageRollups(t *testing.T) {
+	conn := testutil.ClickHouse(t)
+	s := NewOpsStorage(conn, zap.NewNop())
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	samples := []ExchangeHealthSample{
+		{Timestamp: now.Add(-2 * time.Hour), ExchangeID: "binance", ResponseTime: 100 * time.Millisecond, Success: true, SymbolsFetched: 10},
+		{Timestamp: now.Add(-time.Hour), ExchangeID: "binance", ResponseTime: 300 * time.Millisecond, ErrorMessage: "timeout"},
+		{Timestamp: now, ExchangeID: "okx", ResponseTime: 50 * time.Millisecond, Success: true, SymbolsFetched: 5},
+	}
+	if err := s.RecordExchangeHealth(ctx, samples); err != nil {
+		t.Fatalf("RecordExchangeHealth: %v", err)
+	}
+	uptimes, err := s.GetExchangeUptime(ctx, now.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("GetExchangeUptime: %v", err)
+	}
+	if len(uptimes) != 2 || uptimes[0].ExchangeID != "binance" || uptimes[0].Polls != 2 ||
+		uptimes[0].SuccessfulPolls != 1 || uptimes[0].AvgResponseMs != 200 {
+		t.Fatalf("unexpected uptime: %+v", uptimes)
+	}
+	// Windows past the hourly retention read the daily rollup
+	if long, err := s.GetExchangeUptime(ctx, now.AddDate(0, 0, -365)); err != nil || len(long) != 2 {
+		t.Fatalf("yearly uptime = %+v, %v", long, err)
+	}
+
+	log := NewRequestLog(conn, 10, zap.NewNop())
+	log.Record(RequestLogEntry{Timestamp: now, Method: "GET", Route: "/api/v1/tickers", Status: 200, Duration: 2 * time.Millisecond, ResponseBytes: 512})
+	log.Record(RequestLogEntry{Timestamp: now, Method: "GET", Route: "/api/v1/tickers", Status: 503, Duration: 4 * time.Millisecond})
+	log.Record(RequestLogEntry{Timestamp: now, Method: "GET", Route: "unmatched", Status: 404})
+	runCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	log.Run(runCtx, time.Hour)
+
+	usage, err := s.GetAPIUsage(ctx, now.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("GetAPIUsage: %v", err)
+	}
+	if len(usage) != 2 || usage[0].Route != "/api/v1/tickers" || usage[0].Requests != 2 ||
+		usage[0].ServerErrors != 1 || usage[0].ResponseBytes != 512 || usage[1].ClientErrors != 1 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+}
@@ -2,10 +2,16 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/ashmitsharp/trading/internal/calculator"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/requestid"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
@@ -24,7 +30,7 @@ func NewVWAPStorage(conn driver.Conn, logger *zap.Logger) *VWAPStorage {
 }
 
 // StoreVWAPResults stores VWAP calculation results in ClickHouse
-func (s *VWAPStorage) StoreVWAPResults(ctx context.Context, results map[string]*calculator.VWAPResult) error {
+func (s *VWAPStorage) StoreVWAPResults(ctx context.Context, results map[calculator.PairKey]*calculator.VWAPResult) error {
 	if len(results) == 0 {
 		return nil
 	}
@@ -32,7 +38,8 @@ func (s *VWAPStorage) StoreVWAPResults(ctx context.Context, results map[string]*
 	batch, err := s.conn.PrepareBatch(ctx, `
 		INSERT INTO vwap_prices (
 			timestamp, base_token_id, quote_token_id,
-			vwap_price, total_volume, exchange_count, contributing_exchanges
+			vwap_price, total_volume, exchange_count, contributing_exchanges,
+			spread_bps, liquidity_score, indicative
 		)`)
 	if err != nil {
 		return fmt.Errorf("preparing VWAP batch: %w", err)
@@ -41,10 +48,13 @@ func (s *VWAPStorage) StoreVWAPResults(ctx context.Context, results map[string]*
 	count := 0
 	skipped := 0
 	for pair, result := range results {
-		// Skip if token IDs are not set (0 means unmapped)
-		// For now, we'll store with 0 IDs and map them later
-		// This allows us to see the data even without token mapping
-		
+		// Results keyed by an unresolved pair cannot be queried by token
+		// ID later, so they are not stored
+		if !pair.Resolved() {
+			skipped++
+			continue
+		}
+
 		exchangeList := make([]string, len(result.ContributingExchanges))
 		copy(exchangeList, result.ContributingExchanges)
 
@@ -56,9 +66,12 @@ func (s *VWAPStorage) StoreVWAPResults(ctx context.Context, results map[string]*
 			result.TotalVolume,
 			uint8(result.ExchangeCount),
 			exchangeList,
+			result.SpreadBps,
+			result.LiquidityScore,
+			result.Indicative,
 		); err != nil {
 			s.logger.Debug("Failed to append VWAP result",
-				zap.String("pair", pair),
+				zap.Stringer("pair", pair),
 				zap.Error(err))
 			skipped++
 			continue
@@ -70,48 +83,189 @@ func (s *VWAPStorage) StoreVWAPResults(ctx context.Context, results map[string]*
 		if err := batch.Send(); err != nil {
 			return fmt.Errorf("sending VWAP batch: %w", err)
 		}
-		
+
 		s.logger.Info("Stored VWAP prices",
 			zap.Int("stored", count),
 			zap.Int("skipped", skipped),
 			zap.Int("total", len(results)))
 	}
 
+	if err := s.storeComposition(ctx, results); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// GetLatestVWAP retrieves the latest VWAP for a token pair
-func (s *VWAPStorage) GetLatestVWAP(ctx context.Context, baseTokenID, quoteTokenID int) (*calculator.VWAPResult, error) {
+// storeComposition stores each exchange's contribution to the results under
+// the same timestamp as the VWAP itself
+func (s *VWAPStorage) storeComposition(ctx context.Context, results map[calculator.PairKey]*calculator.VWAPResult) error {
+	batch, err := s.conn.PrepareBatch(ctx, `
+		INSERT INTO vwap_composition (
+			timestamp, base_token_id, quote_token_id,
+			exchange_id, price, volume, weight
+		)`)
+	if err != nil {
+		return fmt.Errorf("preparing VWAP composition batch: %w", err)
+	}
+
+	rows := 0
+	for pair, result := range results {
+		if !pair.Resolved() {
+			continue
+		}
+		for _, src := range result.PriceSources {
+			if err := batch.Append(
+				result.Timestamp,
+				uint32(result.BaseTokenID),
+				uint32(result.QuoteTokenID),
+				src.Exchange,
+				src.Price,
+				src.Volume,
+				src.Weight.InexactFloat64(),
+			); err != nil {
+				s.logger.Debug("Failed to append VWAP source",
+					zap.Stringer("pair", pair),
+					zap.String("exchange", src.Exchange),
+					zap.Error(err))
+				continue
+			}
+			rows++
+		}
+	}
+
+	if rows == 0 {
+		return batch.Abort()
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("sending VWAP composition batch: %w", err)
+	}
+	return nil
+}
+
+// GetVWAPComposition retrieves the per-exchange contributions to the VWAP a
+// pair recorded at timestamp
+func (s *VWAPStorage) GetVWAPComposition(ctx context.Context, baseTokenID, quoteTokenID int, timestamp time.Time) ([]calculator.PriceSource, error) {
 	query := `
-		SELECT 
+		SELECT exchange_id, price, volume, weight
+		FROM vwap_composition
+		WHERE base_token_id = ? AND quote_token_id = ? AND timestamp = ?
+		ORDER BY volume DESC, exchange_id
+	`
+
+	rows, err := s.conn.Query(ctx, query, uint32(baseTokenID), uint32(quoteTokenID), timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("querying VWAP composition: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []calculator.PriceSource
+	for rows.Next() {
+		var src calculator.PriceSource
+		var weight float64
+		if err := rows.Scan(&src.Exchange, &src.Price, &src.Volume, &weight); err != nil {
+			return nil, fmt.Errorf("scanning VWAP source: %w", err)
+		}
+		src.Weight = decimal.NewFromFloat(weight)
+		sources = append(sources, src)
+	}
+	return sources, rows.Err()
+}
+
+// vwapColumns are the vwap_prices columns scanVWAP reads
+const vwapColumns = `
 			timestamp,
 			vwap_price,
 			total_volume,
 			exchange_count,
-			contributing_exchanges
-		FROM vwap_prices
-		WHERE base_token_id = ? AND quote_token_id = ?
-		ORDER BY timestamp DESC
-		LIMIT 1
-	`
+			contributing_exchanges,
+			spread_bps,
+			liquidity_score,
+			indicative`
 
+// scanVWAP reads a row of vwapColumns
+func scanVWAP(row driver.Row, baseTokenID, quoteTokenID int) (*calculator.VWAPResult, error) {
 	var result calculator.VWAPResult
+	var exchangeCount uint8
 	result.BaseTokenID = baseTokenID
 	result.QuoteTokenID = quoteTokenID
 
-	err := s.conn.QueryRow(ctx, query, baseTokenID, quoteTokenID).Scan(
+	err := row.Scan(
 		&result.Timestamp,
 		&result.VWAPPrice,
 		&result.TotalVolume,
-		&result.ExchangeCount,
+		&exchangeCount,
 		&result.ContributingExchanges,
+		&result.SpreadBps,
+		&result.LiquidityScore,
+		&result.Indicative,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	result.ExchangeCount = int(exchangeCount)
+	return &result, nil
+}
 
+// GetLatestVWAP retrieves the latest VWAP for a token pair, returning
+// db.ErrNoData if none has been recorded
+func (s *VWAPStorage) GetLatestVWAP(ctx context.Context, baseTokenID, quoteTokenID int) (*calculator.VWAPResult, error) {
+	query := `
+		SELECT ` + vwapColumns + `
+		FROM vwap_prices
+		WHERE base_token_id = ? AND quote_token_id = ?
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`
+
+	result, err := scanVWAP(s.conn.QueryRow(ctx, query, baseTokenID, quoteTokenID), baseTokenID, quoteTokenID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("latest VWAP for %d/%d: %w", baseTokenID, quoteTokenID, db.ErrNoData)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("querying latest VWAP: %w", err)
 	}
+	return result, nil
+}
 
-	return &result, nil
+// GetVWAPAround retrieves the last VWAP of a token pair at or before ts and
+// the first one after it. Either is nil when none was recorded within window
+// of ts.
+func (s *VWAPStorage) GetVWAPAround(ctx context.Context, baseTokenID, quoteTokenID int, ts time.Time, window time.Duration) (before, after *calculator.VWAPResult, err error) {
+	beforeQuery := `
+		SELECT ` + vwapColumns + `
+		FROM vwap_prices
+		WHERE base_token_id = ? AND quote_token_id = ?
+		  AND timestamp <= ? AND timestamp >= ?
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`
+	afterQuery := `
+		SELECT ` + vwapColumns + `
+		FROM vwap_prices
+		WHERE base_token_id = ? AND quote_token_id = ?
+		  AND timestamp > ? AND timestamp <= ?
+		ORDER BY timestamp ASC
+		LIMIT 1
+	`
+
+	before, err = scanVWAP(s.conn.QueryRow(ctx, beforeQuery, baseTokenID, quoteTokenID, ts, ts.Add(-window)), baseTokenID, quoteTokenID)
+	if errors.Is(err, sql.ErrNoRows) {
+		before, err = nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying VWAP before %s: %w", ts.Format(time.RFC3339), err)
+	}
+
+	after, err = scanVWAP(s.conn.QueryRow(ctx, afterQuery, baseTokenID, quoteTokenID, ts, ts.Add(window)), baseTokenID, quoteTokenID)
+	if errors.Is(err, sql.ErrNoRows) {
+		after, err = nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying VWAP after %s: %w", ts.Format(time.RFC3339), err)
+	}
+	return before, after, nil
 }
 
 // GetVWAPHistory retrieves VWAP history for a token pair
@@ -141,20 +295,180 @@ func (s *VWAPStorage) GetVWAPHistory(ctx context.Context, baseTokenID, quoteToke
 			BaseTokenID:  baseTokenID,
 			QuoteTokenID: quoteTokenID,
 		}
-		
+		var exchangeCount uint8
+
 		if err := rows.Scan(
 			&result.Timestamp,
 			&result.VWAPPrice,
 			&result.TotalVolume,
-			&result.ExchangeCount,
+			&exchangeCount,
 			&result.ContributingExchanges,
 		); err != nil {
-			s.logger.Error("Failed to scan VWAP result", zap.Error(err))
+			requestid.Logger(ctx, s.logger).Error("Failed to scan VWAP result", zap.Error(err))
 			continue
 		}
-		
+		result.ExchangeCount = int(exchangeCount)
+
 		results = append(results, result)
 	}
 
 	return results, nil
-}
\ No newline at end of file
+}
+
+// VWAPSummary is the latest VWAP of a token pair together with its 24h open
+type VWAPSummary struct {
+	BaseTokenID    int
+	QuoteTokenID   int
+	Price          decimal.Decimal
+	Open24h        decimal.Decimal
+	Volume         decimal.Decimal
+	ExchangeCount  int
+	SpreadBps      float64
+	LiquidityScore float64
+	Indicative     bool
+	LastUpdate     time.Time
+}
+
+// Change24h returns the percentage change between the 24h open and the latest price
+func (s *VWAPSummary) Change24h() decimal.Decimal {
+	if s.Open24h.IsZero() {
+		return decimal.Zero
+	}
+	return s.Price.Sub(s.Open24h).Div(s.Open24h).Mul(decimal.NewFromInt(100)).Round(4)
+}
+
+// GetLatestVWAPByQuote retrieves the latest VWAP of every base token quoted in one
+// of quoteTokenIDs during the last 24h. When a base token trades against several of
+// the quotes, the highest-volume pair wins. The result is keyed by base token ID.
+func (s *VWAPStorage) GetLatestVWAPByQuote(ctx context.Context, quoteTokenIDs []int) (map[int]*VWAPSummary, error) {
+	summaries, err := s.GetLatestPairVWAPs(ctx, quoteTokenIDs, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[int]*VWAPSummary, len(summaries))
+	for _, summary := range summaries {
+		if existing, ok := results[summary.BaseTokenID]; ok && existing.Volume.GreaterThan(summary.Volume) {
+			continue
+		}
+		results[summary.BaseTokenID] = summary
+	}
+	return results, nil
+}
+
+// GetLatestPairVWAPs retrieves the latest VWAP of every pair quoted in one of
+// quoteTokenIDs during the last 24h whose latest liquidity score is at least
+// minLiquidity. Pass 0 to include every pair. Pairs whose latest VWAP is
+// indicative are included and flagged; callers decide whether to serve them.
+func (s *VWAPStorage) GetLatestPairVWAPs(ctx context.Context, quoteTokenIDs []int, minLiquidity float64) ([]*VWAPSummary, error) {
+	if len(quoteTokenIDs) == 0 {
+		return nil, nil
+	}
+
+	quotes := make([]uint32, len(quoteTokenIDs))
+	for i, id := range quoteTokenIDs {
+		quotes[i] = uint32(id)
+	}
+
+	query := `
+		SELECT
+			base_token_id,
+			quote_token_id,
+			argMax(vwap_price, timestamp) as latest_price,
+			argMin(vwap_price, timestamp) as open_price,
+			argMax(total_volume, timestamp) as latest_volume,
+			argMax(exchange_count, timestamp) as exchange_count,
+			argMax(spread_bps, timestamp) as latest_spread_bps,
+			argMax(liquidity_score, timestamp) as latest_liquidity_score,
+			argMax(indicative, timestamp) as latest_indicative,
+			max(timestamp) as last_update
+		FROM vwap_prices
+		WHERE quote_token_id IN (?) AND timestamp >= now() - INTERVAL 24 HOUR
+		GROUP BY base_token_id, quote_token_id
+		HAVING latest_liquidity_score >= ?
+	`
+
+	rows, err := s.conn.Query(ctx, query, quotes, minLiquidity)
+	if err != nil {
+		return nil, fmt.Errorf("querying latest VWAP by quote: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*VWAPSummary
+	for rows.Next() {
+		var baseID, quoteID uint32
+		var exchangeCount uint8
+		summary := &VWAPSummary{}
+
+		if err := rows.Scan(
+			&baseID,
+			&quoteID,
+			&summary.Price,
+			&summary.Open24h,
+			&summary.Volume,
+			&exchangeCount,
+			&summary.SpreadBps,
+			&summary.LiquidityScore,
+			&summary.Indicative,
+			&summary.LastUpdate,
+		); err != nil {
+			return nil, fmt.Errorf("scanning VWAP summary: %w", err)
+		}
+
+		summary.BaseTokenID = int(baseID)
+		summary.QuoteTokenID = int(quoteID)
+		summary.ExchangeCount = int(exchangeCount)
+		results = append(results, summary)
+	}
+
+	return results, rows.Err()
+}
+
+// GetHourlyVWAPCloses retrieves the last VWAP of each hour since the given time
+// for every base token in baseTokenIDs quoted in quoteTokenID. The result is
+// keyed by base token ID, then by the unix start of the hour.
+func (s *VWAPStorage) GetHourlyVWAPCloses(ctx context.Context, baseTokenIDs []int, quoteTokenID int, since time.Time) (map[int]map[int64]decimal.Decimal, error) {
+	results := make(map[int]map[int64]decimal.Decimal)
+	if len(baseTokenIDs) == 0 {
+		return results, nil
+	}
+
+	bases := make([]uint32, len(baseTokenIDs))
+	for i, id := range baseTokenIDs {
+		bases[i] = uint32(id)
+	}
+
+	query := `
+		SELECT
+			base_token_id,
+			toStartOfHour(timestamp) as hour,
+			argMax(vwap_price, timestamp) as close
+		FROM vwap_prices
+		WHERE base_token_id IN (?) AND quote_token_id = ? AND timestamp >= ?
+		GROUP BY base_token_id, hour
+		ORDER BY base_token_id, hour
+	`
+
+	rows, err := s.conn.Query(ctx, query, bases, uint32(quoteTokenID), since)
+	if err != nil {
+		return nil, fmt.Errorf("querying hourly VWAP: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var baseID uint32
+		var hour time.Time
+		var price decimal.Decimal
+		if err := rows.Scan(&baseID, &hour, &price); err != nil {
+			return nil, fmt.Errorf("scanning hourly VWAP: %w", err)
+		}
+		series, ok := results[int(baseID)]
+		if !ok {
+			series = make(map[int64]decimal.Decimal)
+			results[int(baseID)] = series
+		}
+		series[hour.Unix()] = price
+	}
+
+	return results, rows.Err()
+}
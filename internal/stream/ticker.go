@@ -0,0 +1,285 @@
+// Package stream fans the latest USD VWAPs out to long-lived client
+// connections, polling ClickHouse once for all of them.
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// ErrTooManySubscribers is returned by Subscribe when the hub is full
+var ErrTooManySubscribers = errors.New("too many stream subscribers")
+
+// subscriberBuffer is how many ticks a subscriber may fall behind before
+// further ticks are dropped for it
+const subscriberBuffer = 256
+
+// Tick is a new USD VWAP of a token
+type Tick struct {
+	TokenID       int
+	Symbol        string
+	Quote         string
+	Price         decimal.Decimal
+	Change24h     decimal.Decimal
+	Volume        decimal.Decimal
+	ExchangeCount int
+	Indicative    bool
+	Timestamp     time.Time
+}
+
+// Event returns the tick as served to clients
+func (t Tick) Event() models.TickerEvent {
+	return models.TickerEvent{
+		Symbol:        t.Symbol,
+		TokenID:       t.TokenID,
+		Quote:         t.Quote,
+		Price:         t.Price,
+		Change24h:     t.Change24h,
+		Volume:        t.Volume,
+		ExchangeCount: t.ExchangeCount,
+		Indicative:    t.Indicative,
+		Timestamp:     t.Timestamp.Unix(),
+	}
+}
+
+// Subscription receives the ticks of the tokens it was opened for. C is
+// closed when the hub stops.
+type Subscription struct {
+	C <-chan Tick
+
+	ch     chan Tick
+	tokens map[int]bool // nil for every token
+	hub    *TickerHub
+}
+
+// Close unsubscribes. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+func (s *Subscription) wants(tokenID int) bool {
+	return s.tokens == nil || s.tokens[tokenID]
+}
+
+// TickerHub polls the latest USD VWAP of every token while it has
+// subscribers and sends each one changed since the previous poll to the
+// subscribers that want it. A subscriber that falls behind misses ticks
+// rather than slowing the others.
+type TickerHub struct {
+	postgresDB     *sql.DB
+	vwapStorage    *storage.VWAPStorage
+	maxSubscribers int
+	logger         *zap.Logger
+
+	wake chan struct{}
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]bool
+	latest      map[int]Tick
+	symbols     map[int]string
+	stopped     bool
+}
+
+// NewTickerHub creates a hub admitting up to maxSubscribers subscribers at a
+// time; 0 means no limit
+func NewTickerHub(postgresDB *sql.DB, vwapStorage *storage.VWAPStorage, maxSubscribers int, logger *zap.Logger) *TickerHub {
+	return &TickerHub{
+		postgresDB:     postgresDB,
+		vwapStorage:    vwapStorage,
+		maxSubscribers: maxSubscribers,
+		logger:         logger,
+		wake:           make(chan struct{}, 1),
+		subscribers:    make(map[*Subscription]bool),
+		latest:         make(map[int]Tick),
+		symbols:        make(map[int]string),
+	}
+}
+
+// Subscribe opens a subscription to the ticks of tokenIDs, or of every token
+// when tokenIDs is empty. The last known tick of each is sent first.
+func (h *TickerHub) Subscribe(tokenIDs []int) (*Subscription, error) {
+	ch := make(chan Tick, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, hub: h}
+	if len(tokenIDs) > 0 {
+		sub.tokens = make(map[int]bool, len(tokenIDs))
+		for _, id := range tokenIDs {
+			sub.tokens[id] = true
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopped {
+		close(ch)
+		return sub, nil
+	}
+	if h.maxSubscribers > 0 && len(h.subscribers) >= h.maxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+	h.subscribers[sub] = true
+	for id, tick := range h.latest {
+		if sub.wants(id) {
+			h.send(sub, tick)
+		}
+	}
+
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+	return sub, nil
+}
+
+// Subscribers returns the number of open subscriptions
+func (h *TickerHub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+func (h *TickerHub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[sub] {
+		delete(h.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// Run polls every interval until ctx is done, then closes every
+// subscription. A poll is also made as soon as the first subscriber joins.
+func (h *TickerHub) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		case <-h.wake:
+		}
+		if ctx.Err() != nil {
+			h.stop()
+			return
+		}
+		if h.Subscribers() == 0 {
+			continue
+		}
+		if err := h.poll(ctx); err != nil && ctx.Err() == nil {
+			h.logger.Warn("Failed to poll stream prices", zap.Error(err))
+		}
+	}
+}
+
+func (h *TickerHub) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopped = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// poll loads the latest USD VWAPs and publishes those that changed
+func (h *TickerHub) poll(ctx context.Context) error {
+	quotes, err := db.GetUSDQuoteTokens(ctx, h.postgresDB)
+	if err != nil {
+		return err
+	}
+	quoteSymbols := make(map[int]string, len(quotes))
+	quoteIDs := make([]int, 0, len(quotes))
+	for symbol, id := range quotes {
+		quoteSymbols[id] = symbol
+		quoteIDs = append(quoteIDs, id)
+	}
+
+	summaries, err := h.vwapStorage.GetLatestVWAPByQuote(ctx, quoteIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get USD VWAP prices: %w", err)
+	}
+	if err := h.loadSymbols(ctx, summaries); err != nil {
+		return err
+	}
+
+	ticks := make([]Tick, 0, len(summaries))
+	for id, summary := range summaries {
+		ticks = append(ticks, Tick{
+			TokenID:       id,
+			Symbol:        h.symbols[id],
+			Quote:         quoteSymbols[summary.QuoteTokenID],
+			Price:         summary.Price,
+			Change24h:     summary.Change24h(),
+			Volume:        summary.Volume,
+			ExchangeCount: summary.ExchangeCount,
+			Indicative:    summary.Indicative,
+			Timestamp:     summary.LastUpdate,
+		})
+	}
+	h.publish(ticks)
+	return nil
+}
+
+// loadSymbols looks up the symbols of tokens the hub has not priced before
+func (h *TickerHub) loadSymbols(ctx context.Context, summaries map[int]*storage.VWAPSummary) error {
+	h.mu.Lock()
+	var missing []int
+	for id := range summaries {
+		if _, ok := h.symbols[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	h.mu.Unlock()
+	if len(missing) == 0 {
+		return nil
+	}
+
+	symbols, err := db.GetTokenSymbols(ctx, h.postgresDB, missing)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, symbol := range symbols {
+		h.symbols[id] = symbol
+	}
+	return nil
+}
+
+// publish sends each tick newer than the last one of its token to the
+// subscribers that want it
+func (h *TickerHub) publish(ticks []Tick) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, tick := range ticks {
+		if prev, ok := h.latest[tick.TokenID]; ok && !tick.Timestamp.After(prev.Timestamp) {
+			continue
+		}
+		h.latest[tick.TokenID] = tick
+		for sub := range h.subscribers {
+			if sub.wants(tick.TokenID) {
+				h.send(sub, tick)
+			}
+		}
+	}
+}
+
+// send delivers tick without blocking, dropping it for a full subscriber.
+// h.mu must be held.
+func (h *TickerHub) send(sub *Subscription, tick Tick) {
+	select {
+	case sub.ch <- tick:
+	default:
+	}
+}
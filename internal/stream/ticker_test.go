@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+func tick(tokenID int, price string, ts time.Time) Tick {
+	return Tick{TokenID: tokenID, Price: decimal.RequireFromString(price), Timestamp: ts}
+}
+
+func drain(sub *Subscription) []Tick {
+	var ticks []Tick
+	for {
+		select {
+		case t := <-sub.C:
+			ticks = append(ticks, t)
+		default:
+			return ticks
+		}
+	}
+}
+
+func TestTickerHubPublish(t *testing.T) {
+	h := NewTickerHub(nil, nil, 2, zap.NewNop())
+	now := time.Now()
+
+	btc, err := h.Subscribe([]int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := h.Subscribe(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Subscribe(nil); !errors.Is(err, ErrTooManySubscribers) {
+		t.Errorf("third subscriber: err = %v, want ErrTooManySubscribers", err)
+	}
+
+	h.publish([]Tick{tick(1, "100", now), tick(2, "10", now)})
+	if got := drain(btc); len(got) != 1 || got[0].TokenID != 1 {
+		t.Errorf("filtered subscriber got %+v, want token 1 only", got)
+	}
+	if got := drain(all); len(got) != 2 {
+		t.Errorf("unfiltered subscriber got %+v, want both tokens", got)
+	}
+
+	// Unchanged prices are not sent again
+	h.publish([]Tick{tick(1, "100", now), tick(2, "11", now.Add(time.Second))})
+	if got := drain(btc); len(got) != 0 {
+		t.Errorf("unchanged token resent: %+v", got)
+	}
+	if got := drain(all); len(got) != 1 || got[0].TokenID != 2 {
+		t.Errorf("unfiltered subscriber got %+v, want token 2 only", got)
+	}
+
+	// A new subscriber starts from the last known ticks
+	btc.Close()
+	btc.Close()
+	late, err := h.Subscribe([]int{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(late); len(got) != 1 || !got[0].Price.Equal(decimal.NewFromInt(11)) {
+		t.Errorf("late subscriber got %+v, want the latest token 2 tick", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h.Run(ctx, time.Hour)
+	if _, open := <-all.C; open {
+		t.Error("subscription still open after the hub stopped")
+	}
+	if h.Subscribers() != 0 {
+		t.Errorf("%d subscribers after the hub stopped", h.Subscribers())
+	}
+}
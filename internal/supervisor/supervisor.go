@@ -0,0 +1,209 @@
+// Package supervisor runs long-lived background goroutines so that a panic
+// or an unexpected error is logged and the goroutine restarted with backoff
+// instead of it dying silently.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Policy controls how crashed tasks are restarted. The delay before a
+// restart doubles from MinBackoff up to MaxBackoff with every consecutive
+// crash; a task that ran for ResetAfter before crashing starts over at
+// MinBackoff.
+type Policy struct {
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	ResetAfter time.Duration
+}
+
+// DefaultPolicy restarts after 1s, backing off to 1m, and forgets crashes
+// after 5m of healthy running
+var DefaultPolicy = Policy{
+	MinBackoff: time.Second,
+	MaxBackoff: time.Minute,
+	ResetAfter: 5 * time.Minute,
+}
+
+// Func is a supervised task. It should run until ctx is done and then
+// return nil; returning an error or panicking before that gets it restarted.
+type Func func(ctx context.Context) error
+
+// TaskStatus is a point-in-time copy of a task's state
+type TaskStatus struct {
+	Name      string
+	Running   bool
+	Restarts  int
+	StartedAt time.Time
+	LastCrash string
+	CrashedAt time.Time
+}
+
+type task struct {
+	status TaskStatus
+}
+
+// Group supervises a set of named tasks sharing one context. It is safe for
+// concurrent use.
+type Group struct {
+	ctx    context.Context
+	policy Policy
+	logger *zap.Logger
+	wg     sync.WaitGroup
+
+	mu    sync.RWMutex
+	tasks map[string]*task
+}
+
+// NewGroup creates a group whose tasks stop when ctx is done
+func NewGroup(ctx context.Context, policy Policy, logger *zap.Logger) *Group {
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = DefaultPolicy.MinBackoff
+	}
+	if policy.MaxBackoff < policy.MinBackoff {
+		policy.MaxBackoff = policy.MinBackoff
+	}
+	return &Group{
+		ctx:    ctx,
+		policy: policy,
+		logger: logger,
+		tasks:  make(map[string]*task),
+	}
+}
+
+// Go starts fn under supervision. Names identify tasks in logs and health
+// output and must be unique within the group.
+func (g *Group) Go(name string, fn Func) {
+	g.mu.Lock()
+	if _, ok := g.tasks[name]; ok {
+		g.mu.Unlock()
+		panic(fmt.Sprintf("supervisor: duplicate task %q", name))
+	}
+	t := &task{status: TaskStatus{Name: name}}
+	g.tasks[name] = t
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go g.supervise(t, fn)
+}
+
+// Wait blocks until every task has returned
+func (g *Group) Wait() {
+	g.wg.Wait()
+}
+
+// Snapshot returns the status of every task, ordered by name
+func (g *Group) Snapshot() []TaskStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	statuses := make([]TaskStatus, 0, len(g.tasks))
+	for _, t := range g.tasks {
+		statuses = append(statuses, t.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Restarts returns the total number of restarts across all tasks
+func (g *Group) Restarts() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	total := 0
+	for _, t := range g.tasks {
+		total += t.status.Restarts
+	}
+	return total
+}
+
+func (g *Group) supervise(t *task, fn Func) {
+	defer g.wg.Done()
+
+	logger := g.logger.With(zap.String("task", t.status.Name))
+	backoff := g.policy.MinBackoff
+	for {
+		started := time.Now()
+		g.update(t, func(s *TaskStatus) {
+			s.Running = true
+			s.StartedAt = started
+		})
+
+		err := Call(g.ctx, fn)
+		g.update(t, func(s *TaskStatus) { s.Running = false })
+		if g.ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			logger.Info("Background task finished")
+			return
+		}
+
+		if g.policy.ResetAfter > 0 && time.Since(started) >= g.policy.ResetAfter {
+			backoff = g.policy.MinBackoff
+		}
+		g.update(t, func(s *TaskStatus) {
+			s.Restarts++
+			s.LastCrash = err.Error()
+			s.CrashedAt = time.Now()
+		})
+		var p *panicError
+		if errors.As(err, &p) {
+			logger.Error("Background task panicked, restarting",
+				zap.Any("panic", p.value),
+				zap.String("stack", p.stack),
+				zap.Duration("backoff", backoff))
+		} else {
+			logger.Error("Background task failed, restarting",
+				zap.Error(err),
+				zap.Duration("backoff", backoff))
+		}
+
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > g.policy.MaxBackoff {
+			backoff = g.policy.MaxBackoff
+		}
+	}
+}
+
+func (g *Group) update(t *task, fn func(*TaskStatus)) {
+	g.mu.Lock()
+	fn(&t.status)
+	g.mu.Unlock()
+}
+
+// panicError carries a recovered panic and the stack it was raised on
+type panicError struct {
+	value any
+	stack string
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// Call runs fn on the calling goroutine, returning a panic as an error that
+// includes the panic value. Use it for short-lived goroutines, such as one
+// per exchange in a poll cycle, whose panic would otherwise take down the
+// process.
+func Call(ctx context.Context, fn Func) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &panicError{value: r, stack: string(debug.Stack())}
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,91 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var testPolicy = Policy{MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+func TestGroupRestartsPanickingTask(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g := NewGroup(ctx, testPolicy, zap.NewNop())
+
+	var calls atomic.Int32
+	running := make(chan struct{})
+	g.Go("flaky", func(ctx context.Context) error {
+		switch calls.Add(1) {
+		case 1:
+			panic("boom")
+		case 2:
+			return errors.New("failed")
+		}
+		close(running)
+		<-ctx.Done()
+		return nil
+	})
+
+	select {
+	case <-running:
+	case <-time.After(time.Second):
+		t.Fatal("task was not restarted")
+	}
+
+	snap := g.Snapshot()
+	if len(snap) != 1 {
+		t.Fatalf("Snapshot() returned %d tasks, want 1", len(snap))
+	}
+	s := snap[0]
+	if s.Name != "flaky" || !s.Running || s.Restarts != 2 {
+		t.Fatalf("status = %+v, want flaky running with 2 restarts", s)
+	}
+	if s.LastCrash != "failed" || s.CrashedAt.IsZero() {
+		t.Fatalf("last crash = %q at %v, want \"failed\"", s.LastCrash, s.CrashedAt)
+	}
+
+	cancel()
+	g.Wait()
+	if calls.Load() != 3 {
+		t.Fatalf("task ran %d times, want 3", calls.Load())
+	}
+}
+
+func TestGroupDoesNotRestartFinishedTask(t *testing.T) {
+	g := NewGroup(context.Background(), testPolicy, zap.NewNop())
+
+	var calls atomic.Int32
+	g.Go("once", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+	g.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("task ran %d times, want 1", calls.Load())
+	}
+	if g.Restarts() != 0 {
+		t.Fatalf("Restarts() = %d, want 0", g.Restarts())
+	}
+}
+
+func TestCall(t *testing.T) {
+	err := Call(context.Background(), func(ctx context.Context) error {
+		var m map[string]int
+		m["x"] = 1
+		return nil
+	})
+	var p *panicError
+	if !errors.As(err, &p) || !strings.Contains(err.Error(), "nil map") {
+		t.Fatalf("Call() = %v, want recovered panic", err)
+	}
+	if !strings.Contains(p.stack, "TestCall") {
+		t.Fatal("panic stack does not include the panicking function")
+	}
+}
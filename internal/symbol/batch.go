@@ -0,0 +1,266 @@
+package symbol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Lookup is a pair an exchange quotes, with the base and quote symbols it
+// was split into
+type Lookup struct {
+	ExchangeID string
+	PairSymbol string
+	Base       string
+	Quote      string
+}
+
+// Prefetch loads what resolving lookups needs and the cache lacks with one
+// query per table: the trading pairs, then the symbol mappings of pairs not
+// found, then the tokens of symbols not mapped. Whatever is not found is
+// remembered as a miss, so resolving the same tickers afterwards is answered
+// from the cache instead of costing round trips per ticker.
+func (r *Resolver) Prefetch(ctx context.Context, lookups []Lookup) error {
+	pairs := make(map[[2]string]Lookup)
+	r.mu.RLock()
+	for _, l := range lookups {
+		if _, ok := r.pairCache[l.ExchangeID][l.PairSymbol]; !ok {
+			pairs[[2]string{l.ExchangeID, l.PairSymbol}] = l
+		}
+	}
+	r.mu.RUnlock()
+	for key := range pairs {
+		if r.knownMiss(lookupKey("pair", key[0], key[1])) {
+			delete(pairs, key)
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	if err := r.prefetchPairs(ctx, pairs); err != nil {
+		return err
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	// The pairs left are resolved from their symbols, both as the ticker
+	// splits them and as ResolveTradingPair does
+	symbols := make(map[[2]string]bool)
+	for _, l := range pairs {
+		base, quote := r.parsePairSymbol(l.PairSymbol, l.ExchangeID)
+		for _, s := range []string{l.Base, l.Quote, base, quote} {
+			if s != "" {
+				symbols[[2]string{l.ExchangeID, s}] = true
+			}
+		}
+	}
+	r.mu.RLock()
+	for key := range symbols {
+		if _, ok := r.symbolCache[key[0]][key[1]]; ok {
+			delete(symbols, key)
+		}
+	}
+	r.mu.RUnlock()
+	for key := range symbols {
+		if r.knownMiss(lookupKey("symbol", key[0], key[1])) {
+			delete(symbols, key)
+		}
+	}
+	if err := r.prefetchMappings(ctx, symbols); err != nil {
+		return err
+	}
+
+	// Symbols without a mapping fall back to the token with their normalized
+	// symbol
+	normalized := make(map[string]bool)
+	r.mu.RLock()
+	for key := range symbols {
+		n := r.normalizeSymbol(key[1])
+		if _, ok := r.normalizedCache[chainKey(n, "")]; ok {
+			continue
+		}
+		if _, ok := r.tokenCache[n]; !ok {
+			normalized[n] = true
+		}
+	}
+	r.mu.RUnlock()
+	for n := range normalized {
+		if r.knownMiss(lookupKey("token", "", n)) {
+			delete(normalized, n)
+		}
+	}
+	if err := r.prefetchTokens(ctx, normalized); err != nil {
+		return err
+	}
+
+	// Pairs whose symbols now resolve from the cache are cached as
+	// ResolveTradingPair would; the rest are misses
+	for key, l := range pairs {
+		base, quote := r.parsePairSymbol(l.PairSymbol, l.ExchangeID)
+		baseID, err1 := r.ResolveSymbol(l.ExchangeID, base)
+		quoteID, err2 := r.ResolveSymbol(l.ExchangeID, quote)
+		if err1 != nil || err2 != nil {
+			r.recordMiss(lookupKey("pair", key[0], key[1]))
+			continue
+		}
+		r.cachePair(l.ExchangeID, l.PairSymbol, TokenPair{BaseTokenID: baseID, QuoteTokenID: quoteID})
+	}
+	return nil
+}
+
+// prefetchPairs loads the active trading pairs of keys, removing those found
+func (r *Resolver) prefetchPairs(ctx context.Context, keys map[[2]string]Lookup) error {
+	exchangeIDs, symbols := splitKeys(keys)
+	r.misses.Add(uint64(len(keys)))
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tp.exchange_id, tp.exchange_pair_symbol, tp.base_token_id, tp.quote_token_id
+		FROM trading_pairs tp
+		JOIN unnest($1::text[], $2::text[]) AS l(exchange_id, symbol)
+		  ON tp.exchange_id = l.exchange_id AND tp.exchange_pair_symbol = l.symbol
+		WHERE tp.is_active = true
+	`, pq.Array(exchangeIDs), pq.Array(symbols))
+	if err != nil {
+		return fmt.Errorf("failed to prefetch trading pairs: %w", err)
+	}
+	defer rows.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for rows.Next() {
+		var exchangeID, symbol string
+		var pair TokenPair
+		if err := rows.Scan(&exchangeID, &symbol, &pair.BaseTokenID, &pair.QuoteTokenID); err != nil {
+			return fmt.Errorf("failed to scan trading pair: %w", err)
+		}
+		if r.pairCache[exchangeID] == nil {
+			r.pairCache[exchangeID] = make(map[string]TokenPair)
+		}
+		r.pairCache[exchangeID][symbol] = pair
+		delete(keys, [2]string{exchangeID, symbol})
+	}
+	return rows.Err()
+}
+
+// prefetchMappings loads the active symbol mappings of keys, remembering the
+// symbols without one as misses
+func (r *Resolver) prefetchMappings(ctx context.Context, keys map[[2]string]bool) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	exchangeIDs, symbols := splitKeys(keys)
+	r.misses.Add(uint64(len(keys)))
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tes.exchange_id, tes.exchange_symbol, tes.token_id
+		FROM token_exchange_symbols tes
+		JOIN unnest($1::text[], $2::text[]) AS l(exchange_id, symbol)
+		  ON tes.exchange_id = l.exchange_id AND tes.exchange_symbol = l.symbol
+		WHERE tes.is_active = true
+	`, pq.Array(exchangeIDs), pq.Array(symbols))
+	if err != nil {
+		return fmt.Errorf("failed to prefetch symbol mappings: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[[2]string]bool)
+	r.mu.Lock()
+	for rows.Next() {
+		var exchangeID, symbol string
+		var tokenID int
+		if err := rows.Scan(&exchangeID, &symbol, &tokenID); err != nil {
+			r.mu.Unlock()
+			return fmt.Errorf("failed to scan symbol mapping: %w", err)
+		}
+		if r.symbolCache[exchangeID] == nil {
+			r.symbolCache[exchangeID] = make(map[string]int)
+		}
+		r.symbolCache[exchangeID][symbol] = tokenID
+		found[[2]string{exchangeID, symbol}] = true
+	}
+	r.mu.Unlock()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to prefetch symbol mappings: %w", err)
+	}
+
+	for key := range keys {
+		if !found[key] {
+			r.recordMiss(lookupKey("symbol", key[0], key[1]))
+		}
+	}
+	return nil
+}
+
+// prefetchTokens loads the tokens of normalized symbols, picked as
+// GetTokenByNormalizedSymbol does, remembering symbols without one as misses
+func (r *Resolver) prefetchTokens(ctx context.Context, normalized map[string]bool) error {
+	if len(normalized) == 0 {
+		return nil
+	}
+	list := make([]string, 0, len(normalized))
+	for n := range normalized {
+		list = append(list, n)
+	}
+	r.misses.Add(uint64(len(list)))
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT UPPER(symbol), id, chain IS NULL FROM tokens
+		WHERE UPPER(symbol) = ANY($1) AND is_active = true
+		ORDER BY UPPER(symbol), chain IS NULL DESC, id
+	`, pq.Array(list))
+	if err != nil {
+		return fmt.Errorf("failed to prefetch tokens: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := make(map[string][]int)
+	native := make(map[string]bool)
+	for rows.Next() {
+		var symbol string
+		var id int
+		var isNative bool
+		if err := rows.Scan(&symbol, &id, &isNative); err != nil {
+			return fmt.Errorf("failed to scan token: %w", err)
+		}
+		if len(candidates[symbol]) == 0 {
+			native[symbol] = isNative
+		}
+		candidates[symbol] = append(candidates[symbol], id)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to prefetch tokens: %w", err)
+	}
+
+	r.mu.Lock()
+	for symbol, ids := range candidates {
+		r.tokenCache[symbol] = pickToken(ids, native[symbol])
+	}
+	r.mu.Unlock()
+	for n := range normalized {
+		if _, ok := candidates[n]; !ok {
+			r.recordMiss(lookupKey("token", "", n))
+		}
+	}
+	return nil
+}
+
+// splitKeys returns the exchange IDs and symbols of keys as parallel arrays
+func splitKeys[V any](keys map[[2]string]V) (exchangeIDs, symbols []string) {
+	exchangeIDs = make([]string, 0, len(keys))
+	symbols = make([]string, 0, len(keys))
+	for key := range keys {
+		exchangeIDs = append(exchangeIDs, key[0])
+		symbols = append(symbols, key[1])
+	}
+	return exchangeIDs, symbols
+}
+
+// pickToken chooses between the tokens sharing a symbol, ordered with the
+// chain-agnostic one first: it wins, as does a lone token; otherwise the
+// symbol is ambiguous, which is cached as 0
+func pickToken(ids []int, firstIsNative bool) int {
+	if firstIsNative || len(ids) == 1 {
+		return ids[0]
+	}
+	return 0
+}
@@ -0,0 +1,11 @@
+package symbol
+
+import "errors"
+
+// ErrSymbolNotFound is returned when an exchange symbol or trading pair cannot
+// be resolved to token IDs by any method
+var ErrSymbolNotFound = errors.New("symbol not found")
+
+// ErrAmbiguousSymbol is returned when a symbol matches tokens on several
+// chains and nothing says which one is meant
+var ErrAmbiguousSymbol = errors.New("symbol is ambiguous")
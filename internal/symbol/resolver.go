@@ -3,12 +3,15 @@ package symbol
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 )
 
 // TokenPair represents a base/quote token pair
@@ -35,6 +38,10 @@ type TradingPair struct {
 	IsActive            bool
 }
 
+// defaultNegativeTTL is how long an unmapped symbol or pair is answered from
+// the cache before the database is asked again
+const defaultNegativeTTL = time.Minute
+
 // Resolver handles symbol to token ID resolution
 type Resolver struct {
 	db                *sql.DB
@@ -44,12 +51,43 @@ type Resolver struct {
 	symbolCache       map[string]map[string]int    // exchangeID -> symbol -> tokenID
 	pairCache         map[string]map[string]TokenPair // exchangeID -> pairSymbol -> TokenPair
 	normalizedCache   map[string]int               // normalizedSymbol -> tokenID
+	tokenCache        map[string]int               // normalizedSymbol -> token picked by GetTokenByNormalizedSymbol, 0 if ambiguous
+	negativeCache     map[string]time.Time         // lookup key -> when the miss expires
+	
+	// Concurrent database lookups of the same symbol share one query
+	lookups           singleflight.Group
+	negativeTTL       time.Duration
+	
+	// Discovered mappings and pairs waiting for RunWriteBack
+	writeBack         writeBackQueue
+	
+	// Counters reported by Stats
+	hits              atomic.Uint64
+	negativeHits      atomic.Uint64
+	misses            atomic.Uint64
 	
 	mu                sync.RWMutex
 	lastRefresh       time.Time
 	refreshInterval   time.Duration
 }
 
+// CacheStats is a snapshot of the resolver cache. Hits were answered from
+// the cache, NegativeHits from a remembered miss, and Misses went to the
+// database.
+type CacheStats struct {
+	Hits            uint64
+	NegativeHits    uint64
+	Misses          uint64
+	Symbols         int
+	Pairs           int
+	NegativeEntries int
+	LastRefresh     time.Time
+	// PendingWrites are discovered mappings and pairs not written yet, and
+	// DroppedWrites those dropped because the queue was full
+	PendingWrites int
+	DroppedWrites uint64
+}
+
 // NewResolver creates a new symbol resolver
 func NewResolver(db *sql.DB, logger *zap.Logger) *Resolver {
 	r := &Resolver{
@@ -58,41 +96,72 @@ func NewResolver(db *sql.DB, logger *zap.Logger) *Resolver {
 		symbolCache:     make(map[string]map[string]int),
 		pairCache:       make(map[string]map[string]TokenPair),
 		normalizedCache: make(map[string]int),
+		tokenCache:      make(map[string]int),
+		negativeCache:   make(map[string]time.Time),
+		negativeTTL:     defaultNegativeTTL,
 		refreshInterval: 5 * time.Minute,
 	}
 	
-	// Load initial cache
+	// Load initial cache; Run keeps it fresh
 	if err := r.RefreshCache(context.Background()); err != nil {
 		logger.Error("Failed to load initial symbol cache", zap.Error(err))
 	}
 	
-	// Start background refresh
-	go r.startBackgroundRefresh()
-	
 	return r
 }
 
 // ResolveSymbol resolves an exchange symbol to a token ID
 func (r *Resolver) ResolveSymbol(exchangeID, symbol string) (int, error) {
+	if tokenID, ok := r.lookupMapping(exchangeID, symbol); ok {
+		return tokenID, nil
+	}
+	return r.resolveNormalized(exchangeID, symbol)
+}
+
+// ResolveSymbolOnChain resolves an exchange symbol that the exchange lists on
+// a specific chain. An existing mapping wins; otherwise the token row for
+// that chain is preferred over the chain-agnostic one.
+func (r *Resolver) ResolveSymbolOnChain(exchangeID, symbol, chain string) (int, error) {
+	if chain == "" {
+		return r.ResolveSymbol(exchangeID, symbol)
+	}
+	if tokenID, ok := r.lookupMapping(exchangeID, symbol); ok {
+		return tokenID, nil
+	}
+	return r.GetTokenOnChain(symbol, chain)
+}
+
+// lookupMapping finds the token an exchange symbol is mapped to, from the
+// cache or the database
+func (r *Resolver) lookupMapping(exchangeID, symbol string) (int, bool) {
 	r.mu.RLock()
 	if exchangeSymbols, ok := r.symbolCache[exchangeID]; ok {
 		if tokenID, ok := exchangeSymbols[symbol]; ok {
 			r.mu.RUnlock()
-			return tokenID, nil
+			r.hits.Add(1)
+			return tokenID, true
 		}
 	}
 	r.mu.RUnlock()
 	
+	key := lookupKey("symbol", exchangeID, symbol)
+	if r.knownMiss(key) {
+		r.negativeHits.Add(1)
+		return 0, false
+	}
+	r.misses.Add(1)
+	
 	// Not in cache, try to fetch from database
-	tokenID, err := r.fetchSymbolFromDB(exchangeID, symbol)
+	v, err, _ := r.lookups.Do(key, func() (interface{}, error) {
+		return r.fetchSymbolFromDB(exchangeID, symbol)
+	})
 	if err != nil {
-		// Try normalized lookup as fallback
-		normalized := r.normalizeSymbol(symbol)
-		if id, ok := r.normalizedCache[normalized]; ok {
-			return id, nil
+		if errors.Is(err, sql.ErrNoRows) {
+			r.recordMiss(key)
 		}
-		return 0, fmt.Errorf("symbol %s not found for exchange %s", symbol, exchangeID)
+		return 0, false
 	}
+	tokenID := v.(int)
 	
 	// Update cache
 	r.mu.Lock()
@@ -102,7 +171,7 @@ func (r *Resolver) ResolveSymbol(exchangeID, symbol string) (int, error) {
 	r.symbolCache[exchangeID][symbol] = tokenID
 	r.mu.Unlock()
 	
-	return tokenID, nil
+	return tokenID, true
 }
 
 // ResolveTradingPair resolves a trading pair symbol to base and quote token IDs
@@ -111,34 +180,46 @@ func (r *Resolver) ResolveTradingPair(exchangeID, pairSymbol string) (*TokenPair
 	if pairs, ok := r.pairCache[exchangeID]; ok {
 		if pair, ok := pairs[pairSymbol]; ok {
 			r.mu.RUnlock()
+			r.hits.Add(1)
 			return &pair, nil
 		}
 	}
 	r.mu.RUnlock()
 	
+	key := lookupKey("pair", exchangeID, pairSymbol)
+	notFound := fmt.Errorf("%w: pair %s on %s", ErrSymbolNotFound, pairSymbol, exchangeID)
+	if r.knownMiss(key) {
+		r.negativeHits.Add(1)
+		return nil, notFound
+	}
+	r.misses.Add(1)
+	
 	// Not in cache, try to fetch from database
-	pair, err := r.fetchPairFromDB(exchangeID, pairSymbol)
-	if err != nil {
+	v, err, _ := r.lookups.Do(key, func() (interface{}, error) {
+		return r.fetchPairFromDB(exchangeID, pairSymbol)
+	})
+	var pair *TokenPair
+	if err == nil {
+		// Callers waiting on the same lookup each get their own copy
+		found := *v.(*TokenPair)
+		pair = &found
+	} else {
 		// Try to parse and resolve individually
 		base, quote := r.parsePairSymbol(pairSymbol, exchangeID)
 		baseID, err1 := r.ResolveSymbol(exchangeID, base)
 		quoteID, err2 := r.ResolveSymbol(exchangeID, quote)
 		
 		if err1 != nil || err2 != nil {
-			return nil, fmt.Errorf("pair %s not found for exchange %s", pairSymbol, exchangeID)
+			if errors.Is(err, sql.ErrNoRows) {
+				r.recordMiss(key)
+			}
+			return nil, notFound
 		}
 		
 		pair = &TokenPair{BaseTokenID: baseID, QuoteTokenID: quoteID}
 	}
 	
-	// Update cache
-	r.mu.Lock()
-	if r.pairCache[exchangeID] == nil {
-		r.pairCache[exchangeID] = make(map[string]TokenPair)
-	}
-	r.pairCache[exchangeID][pairSymbol] = *pair
-	r.mu.Unlock()
-	
+	r.cachePair(exchangeID, pairSymbol, *pair)
 	return pair, nil
 }
 
@@ -155,9 +236,9 @@ func (r *Resolver) AddSymbolMappingWithMethod(tokenID int, exchangeID, exchangeS
 	query := `
 		INSERT INTO token_exchange_symbols (
 			token_id, exchange_id, exchange_symbol, normalized_symbol,
-			mapping_method, confidence_score, needs_verification
+			mapping_method, confidence_score, needs_verification, chain
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, (SELECT chain FROM tokens WHERE id = $1))
 		ON CONFLICT (exchange_id, exchange_symbol) 
 		DO UPDATE SET 
 			token_id = $1, 
@@ -165,11 +246,14 @@ func (r *Resolver) AddSymbolMappingWithMethod(tokenID int, exchangeID, exchangeS
 			mapping_method = $5,
 			confidence_score = $6,
 			needs_verification = $7,
+			chain = EXCLUDED.chain,
 			updated_at = NOW()
+		RETURNING COALESCE(chain, '')
 	`
 	
-	_, err := r.db.Exec(query, tokenID, exchangeID, exchangeSymbol, normalizedSymbol, 
-		method, confidence, needsVerification)
+	var chain string
+	err := r.db.QueryRow(query, tokenID, exchangeID, exchangeSymbol, normalizedSymbol, 
+		method, confidence, needsVerification).Scan(&chain)
 	if err != nil {
 		return fmt.Errorf("failed to add symbol mapping: %w", err)
 	}
@@ -183,45 +267,51 @@ func (r *Resolver) AddSymbolMappingWithMethod(tokenID int, exchangeID, exchangeS
 		r.symbolCache[exchangeID] = make(map[string]int)
 	}
 	r.symbolCache[exchangeID][exchangeSymbol] = tokenID
-	r.normalizedCache[normalizedSymbol] = tokenID
+	r.normalizedCache[chainKey(normalizedSymbol, chain)] = tokenID
+	delete(r.negativeCache, lookupKey("symbol", exchangeID, exchangeSymbol))
 	r.mu.Unlock()
 	
 	return nil
 }
 
-// AddTradingPair adds a new trading pair mapping
-func (r *Resolver) AddTradingPair(baseTokenID, quoteTokenID int, exchangeID, pairSymbol string) error {
+// AddTradingPair adds a new trading pair mapping. instrumentType is the
+// pair's market, such as spot or perp; empty means spot.
+func (r *Resolver) AddTradingPair(baseTokenID, quoteTokenID int, exchangeID, pairSymbol, instrumentType string) error {
+	if instrumentType == "" {
+		instrumentType = "spot"
+	}
 	query := `
-		INSERT INTO trading_pairs (base_token_id, quote_token_id, exchange_id, exchange_pair_symbol)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO trading_pairs (base_token_id, quote_token_id, exchange_id, exchange_pair_symbol, instrument_type)
+		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (exchange_id, exchange_pair_symbol)
-		DO UPDATE SET base_token_id = $1, quote_token_id = $2, updated_at = NOW()
+		DO UPDATE SET base_token_id = $1, quote_token_id = $2, instrument_type = $5, updated_at = NOW()
 	`
 	
-	_, err := r.db.Exec(query, baseTokenID, quoteTokenID, exchangeID, pairSymbol)
+	_, err := r.db.Exec(query, baseTokenID, quoteTokenID, exchangeID, pairSymbol, instrumentType)
 	if err != nil {
 		return fmt.Errorf("failed to add trading pair: %w", err)
 	}
 	
-	// Update cache
+	r.cachePair(exchangeID, pairSymbol, TokenPair{BaseTokenID: baseTokenID, QuoteTokenID: quoteTokenID})
+	return nil
+}
+
+// cachePair caches a pair's tokens and forgets any remembered miss
+func (r *Resolver) cachePair(exchangeID, pairSymbol string, pair TokenPair) {
 	r.mu.Lock()
 	if r.pairCache[exchangeID] == nil {
 		r.pairCache[exchangeID] = make(map[string]TokenPair)
 	}
-	r.pairCache[exchangeID][pairSymbol] = TokenPair{
-		BaseTokenID:  baseTokenID,
-		QuoteTokenID: quoteTokenID,
-	}
+	r.pairCache[exchangeID][pairSymbol] = pair
+	delete(r.negativeCache, lookupKey("pair", exchangeID, pairSymbol))
 	r.mu.Unlock()
-	
-	return nil
 }
 
 // RefreshCache refreshes the symbol cache from the database
 func (r *Resolver) RefreshCache(ctx context.Context) error {
 	// Load symbol mappings
 	symbolQuery := `
-		SELECT token_id, exchange_id, exchange_symbol, normalized_symbol
+		SELECT token_id, exchange_id, exchange_symbol, normalized_symbol, COALESCE(chain, '')
 		FROM token_exchange_symbols
 		WHERE is_active = true
 	`
@@ -237,9 +327,9 @@ func (r *Resolver) RefreshCache(ctx context.Context) error {
 	
 	for rows.Next() {
 		var tokenID int
-		var exchangeID, exchangeSymbol, normalizedSymbol string
+		var exchangeID, exchangeSymbol, normalizedSymbol, chain string
 		
-		if err := rows.Scan(&tokenID, &exchangeID, &exchangeSymbol, &normalizedSymbol); err != nil {
+		if err := rows.Scan(&tokenID, &exchangeID, &exchangeSymbol, &normalizedSymbol, &chain); err != nil {
 			r.logger.Error("Failed to scan symbol mapping", zap.Error(err))
 			continue
 		}
@@ -248,7 +338,7 @@ func (r *Resolver) RefreshCache(ctx context.Context) error {
 			newSymbolCache[exchangeID] = make(map[string]int)
 		}
 		newSymbolCache[exchangeID][exchangeSymbol] = tokenID
-		newNormalizedCache[normalizedSymbol] = tokenID
+		newNormalizedCache[chainKey(normalizedSymbol, chain)] = tokenID
 	}
 	
 	// Load trading pairs
@@ -289,6 +379,10 @@ func (r *Resolver) RefreshCache(ctx context.Context) error {
 	r.symbolCache = newSymbolCache
 	r.pairCache = newPairCache
 	r.normalizedCache = newNormalizedCache
+	r.tokenCache = make(map[string]int)
+	// Mappings may have been added behind the resolver's back, so every
+	// remembered miss gets another look
+	r.negativeCache = make(map[string]time.Time)
 	r.lastRefresh = time.Now()
 	r.mu.Unlock()
 	
@@ -299,8 +393,79 @@ func (r *Resolver) RefreshCache(ctx context.Context) error {
 	return nil
 }
 
+// Stats returns the cache counters and current cache sizes
+func (r *Resolver) Stats() CacheStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	stats := CacheStats{
+		Hits:          r.hits.Load(),
+		NegativeHits:  r.negativeHits.Load(),
+		Misses:        r.misses.Load(),
+		LastRefresh:   r.lastRefresh,
+		PendingWrites: r.writeBack.len(),
+		DroppedWrites: r.writeBack.dropped.Load(),
+	}
+	for _, symbols := range r.symbolCache {
+		stats.Symbols += len(symbols)
+	}
+	for _, pairs := range r.pairCache {
+		stats.Pairs += len(pairs)
+	}
+	now := time.Now()
+	for _, expires := range r.negativeCache {
+		if now.Before(expires) {
+			stats.NegativeEntries++
+		}
+	}
+	return stats
+}
+
 // Helper methods
 
+// chainKey qualifies a normalized symbol with its chain for normalizedCache;
+// chain-agnostic tokens are keyed by the bare symbol
+func chainKey(normalized, chain string) string {
+	if chain == "" {
+		return normalized
+	}
+	return normalized + "@" + strings.ToLower(chain)
+}
+
+// lookupKey identifies a symbol, pair or token lookup in the negative cache
+// and the flight group. Token lookups on a chain pass the chain as exchangeID.
+func lookupKey(kind, exchangeID, symbol string) string {
+	return kind + ":" + exchangeID + ":" + symbol
+}
+
+// knownMiss reports whether key was looked up and not found within the
+// negative cache TTL
+func (r *Resolver) knownMiss(key string) bool {
+	r.mu.RLock()
+	expires, ok := r.negativeCache[key]
+	r.mu.RUnlock()
+	return ok && time.Now().Before(expires)
+}
+
+// recordMiss remembers that key has no mapping for the negative cache TTL
+func (r *Resolver) recordMiss(key string) {
+	r.mu.Lock()
+	r.negativeCache[key] = time.Now().Add(r.negativeTTL)
+	r.mu.Unlock()
+}
+
+// resolveNormalized is the fallback for symbols without an exchange mapping
+func (r *Resolver) resolveNormalized(exchangeID, symbol string) (int, error) {
+	normalized := r.normalizeSymbol(symbol)
+	r.mu.RLock()
+	id, ok := r.normalizedCache[chainKey(normalized, "")]
+	r.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("%w: %s on %s", ErrSymbolNotFound, symbol, exchangeID)
+}
+
 func (r *Resolver) fetchSymbolFromDB(exchangeID, symbol string) (int, error) {
 	var tokenID int
 	query := `
@@ -390,42 +555,146 @@ func (r *Resolver) parsePairSymbol(pairSymbol, exchangeID string) (base, quote s
 	return pairSymbol, ""
 }
 
-func (r *Resolver) startBackgroundRefresh() {
+// Run refreshes the cache every refresh interval until ctx is done
+func (r *Resolver) Run(ctx context.Context) error {
 	ticker := time.NewTicker(r.refreshInterval)
 	defer ticker.Stop()
 	
-	for range ticker.C {
-		if err := r.RefreshCache(context.Background()); err != nil {
-			r.logger.Error("Failed to refresh symbol cache", zap.Error(err))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.RefreshCache(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Error("Failed to refresh symbol cache", zap.Error(err))
+			}
 		}
 	}
 }
 
-// GetTokenByNormalizedSymbol gets token ID by normalized symbol
+// GetTokenByNormalizedSymbol gets token ID by normalized symbol. The
+// chain-agnostic token wins when the symbol also has chain-specific rows; a
+// symbol that only exists on several chains is ambiguous.
 func (r *Resolver) GetTokenByNormalizedSymbol(symbol string) (int, error) {
 	normalized := r.normalizeSymbol(symbol)
 	
 	r.mu.RLock()
-	if tokenID, ok := r.normalizedCache[normalized]; ok {
+	if tokenID, ok := r.normalizedCache[chainKey(normalized, "")]; ok {
 		r.mu.RUnlock()
 		return tokenID, nil
 	}
+	tokenID, cached := r.tokenCache[normalized]
 	r.mu.RUnlock()
+	if cached {
+		if tokenID == 0 {
+			return 0, fmt.Errorf("%w: %s exists on several chains", ErrAmbiguousSymbol, symbol)
+		}
+		return tokenID, nil
+	}
+	key := lookupKey("token", "", normalized)
+	if r.knownMiss(key) {
+		return 0, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+	}
 	
 	// Try to fetch from database
-	var tokenID int
+	v, err, _ := r.lookups.Do(key, func() (interface{}, error) {
+		return r.fetchTokenFromDB(normalized)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		r.recordMiss(key)
+		return 0, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+	}
+	if err != nil {
+		return 0, err
+	}
+	tokenID = v.(int)
+	r.mu.Lock()
+	r.tokenCache[normalized] = tokenID
+	r.mu.Unlock()
+	if tokenID == 0 {
+		return 0, fmt.Errorf("%w: %s exists on several chains", ErrAmbiguousSymbol, symbol)
+	}
+	return tokenID, nil
+}
+
+// fetchTokenFromDB picks the token of a normalized symbol as
+// GetTokenByNormalizedSymbol does, returning 0 when it is ambiguous and
+// sql.ErrNoRows when there is none
+func (r *Resolver) fetchTokenFromDB(normalized string) (int, error) {
 	query := `
-		SELECT id FROM tokens 
+		SELECT id, chain IS NULL FROM tokens 
 		WHERE UPPER(symbol) = $1 AND is_active = true
-		LIMIT 1
+		ORDER BY chain IS NULL DESC, id
+		LIMIT 2
 	`
 	
-	err := r.db.QueryRow(query, normalized).Scan(&tokenID)
+	rows, err := r.db.Query(query, normalized)
 	if err != nil {
-		return 0, fmt.Errorf("token not found for symbol %s", symbol)
+		return 0, fmt.Errorf("failed to look up token %s: %w", normalized, err)
 	}
+	defer rows.Close()
 	
-	return tokenID, nil
+	var ids []int
+	var firstIsNative bool
+	for rows.Next() {
+		var id int
+		var native bool
+		if err := rows.Scan(&id, &native); err != nil {
+			return 0, fmt.Errorf("failed to scan token %s: %w", normalized, err)
+		}
+		if len(ids) == 0 {
+			firstIsNative = native
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to look up token %s: %w", normalized, err)
+	}
+	
+	if len(ids) == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return pickToken(ids, firstIsNative), nil
+}
+
+// GetTokenOnChain gets the token for a symbol on a chain, falling back to the
+// chain-agnostic token when the chain has no row of its own
+func (r *Resolver) GetTokenOnChain(symbol, chain string) (int, error) {
+	normalized := r.normalizeSymbol(symbol)
+	
+	r.mu.RLock()
+	if tokenID, ok := r.normalizedCache[chainKey(normalized, chain)]; ok {
+		r.mu.RUnlock()
+		return tokenID, nil
+	}
+	r.mu.RUnlock()
+	
+	key := lookupKey("chain", strings.ToLower(chain), normalized)
+	if r.knownMiss(key) {
+		return 0, fmt.Errorf("%w: %s on chain %s", ErrSymbolNotFound, symbol, chain)
+	}
+	
+	v, err, _ := r.lookups.Do(key, func() (interface{}, error) {
+		var tokenID int
+		query := `
+			SELECT id FROM tokens
+			WHERE UPPER(symbol) = $1 AND is_active = true
+			  AND (chain = $2 OR chain IS NULL)
+			ORDER BY chain IS NULL, id
+			LIMIT 1
+		`
+		err := r.db.QueryRow(query, normalized, strings.ToLower(chain)).Scan(&tokenID)
+		return tokenID, err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		r.recordMiss(key)
+		return 0, fmt.Errorf("%w: %s on chain %s", ErrSymbolNotFound, symbol, chain)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up token %s on %s: %w", symbol, chain, err)
+	}
+	
+	return v.(int), nil
 }
 
 // logMappingAudit logs mapping changes to audit table
@@ -475,5 +744,5 @@ func (r *Resolver) ResolveWithSlug(exchangeID, exchangeSymbol, slug string) (int
 		return id, "symbol", nil
 	}
 	
-	return 0, "", fmt.Errorf("unable to resolve %s on %s", exchangeSymbol, exchangeID)
+	return 0, "", fmt.Errorf("%w: %s on %s", ErrSymbolNotFound, exchangeSymbol, exchangeID)
 }
\ No newline at end of file
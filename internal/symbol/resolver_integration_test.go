@@ -0,0 +1,220 @@
+//go:build integration
+
+package symbol
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+	"go.uber.org/zap"
+)
+
+func TestResolverCache(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ids := testutil.SeedTokens(t, pg, "BTC", "USDT", "ETH")
+	testutil.SeedSymbolMapping(t, pg, ids["BTC"], "kraken", "XBT", "BTC")
+	testutil.SeedTradingPair(t, pg, ids["BTC"], ids["USDT"], "binance", "BTCUSDT")
+
+	r := NewResolver(pg, zap.NewNop())
+
+	tokenID, err := r.ResolveSymbol("kraken", "XBT")
+	if err != nil || tokenID != ids["BTC"] {
+		t.Fatalf("ResolveSymbol(kraken, XBT) = %d, %v; want %d", tokenID, err, ids["BTC"])
+	}
+
+	pair, err := r.ResolveTradingPair("binance", "BTCUSDT")
+	if err != nil {
+		t.Fatalf("ResolveTradingPair: %v", err)
+	}
+	if pair.BaseTokenID != ids["BTC"] || pair.QuoteTokenID != ids["USDT"] {
+		t.Errorf("BTCUSDT resolved to %+v", pair)
+	}
+
+	// Mappings added through the resolver are served from the cache, and
+	// stay there after a refresh since they were also written to the database
+	if err := r.AddTradingPair(ids["ETH"], ids["USDT"], "binance", "ETHUSDT", "spot"); err != nil {
+		t.Fatalf("AddTradingPair: %v", err)
+	}
+	if _, err := pg.Exec(`UPDATE trading_pairs SET is_active = false WHERE exchange_pair_symbol = 'BTCUSDT'`); err != nil {
+		t.Fatalf("deactivating pair: %v", err)
+	}
+	if err := r.RefreshCache(context.Background()); err != nil {
+		t.Fatalf("RefreshCache: %v", err)
+	}
+
+	pair, err = r.ResolveTradingPair("binance", "ETHUSDT")
+	if err != nil || pair.BaseTokenID != ids["ETH"] {
+		t.Errorf("ETHUSDT after refresh = %+v, %v", pair, err)
+	}
+	if pair, err := r.ResolveTradingPair("binance", "BTCUSDT"); err == nil {
+		t.Errorf("deactivated BTCUSDT still resolves to %+v", pair)
+	}
+}
+
+func TestResolverNegativeCache(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ids := testutil.SeedTokens(t, pg, "FOO", "USDT")
+	r := NewResolver(pg, zap.NewNop())
+
+	if _, err := r.ResolveSymbol("binance", "FOO"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Fatalf("unmapped FOO: %v, want ErrSymbolNotFound", err)
+	}
+
+	// A mapping written straight to the database stays hidden behind the
+	// remembered miss until the next refresh
+	testutil.SeedSymbolMapping(t, pg, ids["FOO"], "binance", "FOO", "FOO")
+	if _, err := r.ResolveSymbol("binance", "FOO"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("FOO within the negative TTL = %v, want ErrSymbolNotFound", err)
+	}
+	if stats := r.Stats(); stats.NegativeHits != 1 || stats.Misses != 1 || stats.NegativeEntries != 1 {
+		t.Errorf("stats after a repeated miss = %+v", stats)
+	}
+	if err := r.RefreshCache(context.Background()); err != nil {
+		t.Fatalf("RefreshCache: %v", err)
+	}
+	if id, err := r.ResolveSymbol("binance", "FOO"); err != nil || id != ids["FOO"] {
+		t.Errorf("FOO after refresh = %d, %v; want %d", id, err, ids["FOO"])
+	}
+
+	// Misses expire on their own too
+	r.negativeTTL = time.Millisecond
+	if _, err := r.ResolveTradingPair("binance", "FOO-BAR"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Fatalf("unmapped FOO-BAR: %v, want ErrSymbolNotFound", err)
+	}
+	testutil.SeedTradingPair(t, pg, ids["FOO"], ids["USDT"], "binance", "FOO-BAR")
+	time.Sleep(5 * time.Millisecond)
+	if pair, err := r.ResolveTradingPair("binance", "FOO-BAR"); err != nil || pair.BaseTokenID != ids["FOO"] {
+		t.Errorf("FOO-BAR after the miss expired = %+v, %v", pair, err)
+	}
+
+	// Token lookups by symbol and by chain remember their misses separately
+	r.negativeTTL = time.Hour
+	if _, err := r.GetTokenOnChain("BAZ", "solana"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Fatalf("unknown BAZ on solana: %v, want ErrSymbolNotFound", err)
+	}
+	baz := testutil.SeedTokens(t, pg, "BAZ")["BAZ"]
+	if _, err := r.GetTokenOnChain("BAZ", "solana"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("BAZ on solana within the negative TTL = %v, want ErrSymbolNotFound", err)
+	}
+	if id, err := r.GetTokenByNormalizedSymbol("BAZ"); err != nil || id != baz {
+		t.Errorf("BAZ without a chain = %d, %v; want %d", id, err, baz)
+	}
+}
+
+func TestResolverChainIdentity(t *testing.T) {
+	pg := testutil.Postgres(t)
+	native := testutil.SeedTokens(t, pg, "USDC")["USDC"]
+	onChain := func(symbol, chain, contract string) int {
+		t.Helper()
+		var id int
+		err := pg.QueryRow(`
+			INSERT INTO tokens (symbol, name, chain, contract_address)
+			VALUES ($1, $1, $2, $3) RETURNING id
+		`, symbol, chain, contract).Scan(&id)
+		if err != nil {
+			t.Fatalf("seeding %s on %s: %v", symbol, chain, err)
+		}
+		return id
+	}
+	solana := onChain("USDC", "solana", "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	onChain("USDC", "base", "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913")
+	onChain("WIF", "solana", "EKpQGSJtjMFqKZ9KQanSqYXRcF8fBopzLHYxdM65zcjm")
+	onChain("WIF", "base", "0x0000000000000000000000000000000000000001")
+
+	r := NewResolver(pg, zap.NewNop())
+
+	if id, err := r.GetTokenByNormalizedSymbol("USDC"); err != nil || id != native {
+		t.Errorf("USDC without a chain = %d, %v; want the chain-agnostic %d", id, err, native)
+	}
+	if id, err := r.GetTokenOnChain("USDC", "Solana"); err != nil || id != solana {
+		t.Errorf("USDC on solana = %d, %v; want %d", id, err, solana)
+	}
+	if id, err := r.GetTokenOnChain("USDC", "arbitrum"); err != nil || id != native {
+		t.Errorf("USDC on a chain without its own row = %d, %v; want %d", id, err, native)
+	}
+	if _, err := r.GetTokenByNormalizedSymbol("WIF"); !errors.Is(err, ErrAmbiguousSymbol) {
+		t.Errorf("WIF on two chains: %v, want ErrAmbiguousSymbol", err)
+	}
+
+	// Mappings record the chain of their token and resolve before any
+	// chain preference
+	if err := r.AddSymbolMapping(solana, "bybit", "USDC", "USDC"); err != nil {
+		t.Fatalf("AddSymbolMapping: %v", err)
+	}
+	var chain string
+	if err := pg.QueryRow(`SELECT chain FROM token_exchange_symbols WHERE exchange_id = 'bybit'`).Scan(&chain); err != nil || chain != "solana" {
+		t.Errorf("mapping chain = %q, %v; want solana", chain, err)
+	}
+	if id, err := r.ResolveSymbolOnChain("bybit", "USDC", "base"); err != nil || id != solana {
+		t.Errorf("mapped bybit USDC = %d, %v; want %d", id, err, solana)
+	}
+}
+
+func TestResolverPrefetch(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ids := testutil.SeedTokens(t, pg, "BTC", "ETH", "SOL", "USDT")
+	testutil.SeedTradingPair(t, pg, ids["BTC"], ids["USDT"], "binance", "BTCUSDT")
+	testutil.SeedSymbolMapping(t, pg, ids["ETH"], "binance", "ETH", "ETH")
+	testutil.SeedSymbolMapping(t, pg, ids["USDT"], "binance", "USDT", "USDT")
+	r := NewResolver(pg, zap.NewNop())
+	r.pairCache = make(map[string]map[string]TokenPair)
+	r.symbolCache = make(map[string]map[string]int)
+	r.normalizedCache = make(map[string]int)
+
+	err := r.Prefetch(context.Background(), []Lookup{
+		{ExchangeID: "binance", PairSymbol: "BTCUSDT", Base: "BTC", Quote: "USDT"},
+		{ExchangeID: "binance", PairSymbol: "ETHUSDT", Base: "ETH", Quote: "USDT"},
+		{ExchangeID: "binance", PairSymbol: "SOLUSDT", Base: "SOL", Quote: "USDT"},
+		{ExchangeID: "binance", PairSymbol: "FOOUSDT", Base: "FOO", Quote: "USDT"},
+	})
+	if err != nil {
+		t.Fatalf("Prefetch: %v", err)
+	}
+	misses := r.Stats().Misses
+
+	// Everything is answered from the cache now, found or not
+	if pair, err := r.ResolveTradingPair("binance", "BTCUSDT"); err != nil || pair.BaseTokenID != ids["BTC"] {
+		t.Errorf("BTCUSDT = %+v, %v", pair, err)
+	}
+	if pair, err := r.ResolveTradingPair("binance", "ETHUSDT"); err != nil || pair.BaseTokenID != ids["ETH"] {
+		t.Errorf("ETHUSDT from its mappings = %+v, %v", pair, err)
+	}
+	if _, err := r.ResolveTradingPair("binance", "SOLUSDT"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("unmapped SOLUSDT: %v, want ErrSymbolNotFound", err)
+	}
+	if id, err := r.GetTokenByNormalizedSymbol("SOL"); err != nil || id != ids["SOL"] {
+		t.Errorf("SOL token = %d, %v; want %d", id, err, ids["SOL"])
+	}
+	if _, err := r.GetTokenByNormalizedSymbol("FOO"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("FOO token: %v, want ErrSymbolNotFound", err)
+	}
+	if got := r.Stats().Misses; got != misses {
+		t.Errorf("%d lookups went to the database after the prefetch", got-misses)
+	}
+
+	// Discoveries are cached at once and written in the background
+	r.QueueTradingPair(ids["SOL"], ids["USDT"], "binance", "SOLUSDT", "spot")
+	if pair, err := r.ResolveTradingPair("binance", "SOLUSDT"); err != nil || pair.BaseTokenID != ids["SOL"] {
+		t.Errorf("queued SOLUSDT = %+v, %v", pair, err)
+	}
+	if stats := r.Stats(); stats.PendingWrites != 1 {
+		t.Errorf("pending writes = %d, want 1", stats.PendingWrites)
+	}
+	r.QueueSymbolMapping(ids["SOL"], "binance", "SOL", "SOL", 0.75)
+	r.QueueSymbolMapping(ids["ETH"], "binance", "ETH", "ETH", 0.75) // mapped by hand, kept
+	r.flushWriteBack(context.Background())
+	var method string
+	var review bool
+	if err := pg.QueryRow(`SELECT mapping_method, needs_verification FROM trading_pairs WHERE exchange_pair_symbol = 'SOLUSDT'`).Scan(&method, &review); err != nil || method != "auto" || !review {
+		t.Errorf("written SOLUSDT = %s, review %v, %v", method, review, err)
+	}
+	if err := pg.QueryRow(`SELECT mapping_method, needs_verification FROM token_exchange_symbols WHERE exchange_symbol = 'SOL'`).Scan(&method, &review); err != nil || method != "auto" || !review {
+		t.Errorf("written SOL mapping = %s, review %v, %v", method, review, err)
+	}
+	if err := pg.QueryRow(`SELECT mapping_method FROM token_exchange_symbols WHERE exchange_symbol = 'ETH'`).Scan(&method); err != nil || method == "auto" {
+		t.Errorf("hand-made ETH mapping became %s, %v", method, err)
+	}
+}
@@ -0,0 +1,323 @@
+package symbol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxPendingWrites bounds the discoveries waiting to be written; more
+	// are dropped and found again once the cache is refreshed
+	maxPendingWrites = 10000
+	// writeBackInterval is how often RunWriteBack writes discoveries
+	writeBackInterval = time.Second
+	// writeBackBatchSize is how many rows go into one INSERT
+	writeBackBatchSize = 500
+
+	// discoveredMethod is the mapping_method of written back discoveries,
+	// which are flagged for review
+	discoveredMethod = "auto"
+)
+
+// pendingMapping is a symbol mapping queued by QueueSymbolMapping
+type pendingMapping struct {
+	tokenID          int
+	exchangeID       string
+	exchangeSymbol   string
+	normalizedSymbol string
+	confidence       float64
+}
+
+// pendingPair is a trading pair queued by QueueTradingPair
+type pendingPair struct {
+	baseTokenID    int
+	quoteTokenID   int
+	exchangeID     string
+	pairSymbol     string
+	instrumentType string
+}
+
+// writeBackQueue holds discovered mappings and pairs until they are written
+type writeBackQueue struct {
+	mu       sync.Mutex
+	mappings []pendingMapping
+	pairs    []pendingPair
+	dropped  atomic.Uint64
+}
+
+func (q *writeBackQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.mappings) + len(q.pairs)
+}
+
+// add runs push unless the queue is full
+func (q *writeBackQueue) add(push func()) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.mappings)+len(q.pairs) >= maxPendingWrites {
+		q.dropped.Add(1)
+		return false
+	}
+	push()
+	return true
+}
+
+// take empties the queue, returning what was in it
+func (q *writeBackQueue) take() ([]pendingMapping, []pendingPair) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	mappings, pairs := q.mappings, q.pairs
+	q.mappings, q.pairs = nil, nil
+	return mappings, pairs
+}
+
+// QueueSymbolMapping caches a symbol mapping discovered while polling and
+// queues it for RunWriteBack, keeping the database write out of the poll
+// path. It is written as an auto mapping needing verification.
+func (r *Resolver) QueueSymbolMapping(tokenID int, exchangeID, exchangeSymbol, normalizedSymbol string, confidence float64) {
+	r.mu.Lock()
+	if r.symbolCache[exchangeID] == nil {
+		r.symbolCache[exchangeID] = make(map[string]int)
+	}
+	r.symbolCache[exchangeID][exchangeSymbol] = tokenID
+	delete(r.negativeCache, lookupKey("symbol", exchangeID, exchangeSymbol))
+	r.mu.Unlock()
+
+	m := pendingMapping{tokenID, exchangeID, exchangeSymbol, normalizedSymbol, confidence}
+	if !r.writeBack.add(func() { r.writeBack.mappings = append(r.writeBack.mappings, m) }) {
+		r.logger.Warn("Write-back queue full, dropping symbol mapping",
+			zap.String("exchange", exchangeID),
+			zap.String("symbol", exchangeSymbol))
+	}
+}
+
+// QueueTradingPair caches a trading pair discovered while polling and queues
+// it for RunWriteBack, as an auto pair needing verification
+func (r *Resolver) QueueTradingPair(baseTokenID, quoteTokenID int, exchangeID, pairSymbol, instrumentType string) {
+	r.cachePair(exchangeID, pairSymbol, TokenPair{BaseTokenID: baseTokenID, QuoteTokenID: quoteTokenID})
+
+	if instrumentType == "" {
+		instrumentType = "spot"
+	}
+	p := pendingPair{baseTokenID, quoteTokenID, exchangeID, pairSymbol, instrumentType}
+	if !r.writeBack.add(func() { r.writeBack.pairs = append(r.writeBack.pairs, p) }) {
+		r.logger.Warn("Write-back queue full, dropping trading pair",
+			zap.String("exchange", exchangeID),
+			zap.String("pair", pairSymbol))
+	}
+}
+
+// RunWriteBack writes the queued mappings and pairs every second until ctx
+// is done, then writes what is left
+func (r *Resolver) RunWriteBack(ctx context.Context) error {
+	ticker := time.NewTicker(writeBackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The queue is drained with a context of its own on the way out
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			r.flushWriteBack(flushCtx)
+			cancel()
+			return nil
+		case <-ticker.C:
+			r.flushWriteBack(ctx)
+		}
+	}
+}
+
+// flushWriteBack writes the queued mappings, then the queued pairs, in
+// batches. A failed batch is logged and not retried; its symbols are
+// discovered again after the next cache refresh.
+func (r *Resolver) flushWriteBack(ctx context.Context) {
+	mappings, pairs := r.writeBack.take()
+	mappings, pairs = dedupeWrites(mappings, pairs)
+	for len(mappings) > 0 {
+		n := min(len(mappings), writeBackBatchSize)
+		if err := r.writeMappings(ctx, mappings[:n]); err != nil {
+			r.logger.Error("Failed to write symbol mappings", zap.Int("mappings", n), zap.Error(err))
+		}
+		mappings = mappings[n:]
+	}
+	for len(pairs) > 0 {
+		n := min(len(pairs), writeBackBatchSize)
+		if err := r.writePairs(ctx, pairs[:n]); err != nil {
+			r.logger.Error("Failed to write trading pairs", zap.Int("pairs", n), zap.Error(err))
+		}
+		pairs = pairs[n:]
+	}
+}
+
+// dedupeWrites keeps the last queued write of each exchange symbol, since
+// one INSERT cannot upsert the same row twice
+func dedupeWrites(mappings []pendingMapping, pairs []pendingPair) ([]pendingMapping, []pendingPair) {
+	seen := make(map[[2]string]int)
+	var keptMappings []pendingMapping
+	for _, m := range mappings {
+		key := [2]string{m.exchangeID, m.exchangeSymbol}
+		if i, ok := seen[key]; ok {
+			keptMappings[i] = m
+			continue
+		}
+		seen[key] = len(keptMappings)
+		keptMappings = append(keptMappings, m)
+	}
+	clear(seen)
+	var keptPairs []pendingPair
+	for _, p := range pairs {
+		key := [2]string{p.exchangeID, p.pairSymbol}
+		if i, ok := seen[key]; ok {
+			keptPairs[i] = p
+			continue
+		}
+		seen[key] = len(keptPairs)
+		keptPairs = append(keptPairs, p)
+	}
+	return keptMappings, keptPairs
+}
+
+// writeMappings upserts a batch of discovered mappings with their audit log
+// entries. Verified mappings and ones set by hand are left alone.
+func (r *Resolver) writeMappings(ctx context.Context, batch []pendingMapping) error {
+	tokenIDs := make([]int64, len(batch))
+	exchangeIDs := make([]string, len(batch))
+	symbols := make([]string, len(batch))
+	normalized := make([]string, len(batch))
+	confidences := make([]float64, len(batch))
+	for i, m := range batch {
+		tokenIDs[i] = int64(m.tokenID)
+		exchangeIDs[i] = m.exchangeID
+		symbols[i] = m.exchangeSymbol
+		normalized[i] = m.normalizedSymbol
+		confidences[i] = m.confidence
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		INSERT INTO token_exchange_symbols (
+			token_id, exchange_id, exchange_symbol, normalized_symbol,
+			mapping_method, confidence_score, needs_verification, chain
+		)
+		SELECT d.token_id, d.exchange_id, d.exchange_symbol, d.normalized_symbol, $6, d.confidence, true, t.chain
+		FROM unnest($1::int[], $2::text[], $3::text[], $4::text[], $5::numeric[])
+		     AS d(token_id, exchange_id, exchange_symbol, normalized_symbol, confidence)
+		JOIN tokens t ON t.id = d.token_id
+		ON CONFLICT (exchange_id, exchange_symbol) DO UPDATE SET
+			token_id = EXCLUDED.token_id,
+			normalized_symbol = EXCLUDED.normalized_symbol,
+			mapping_method = EXCLUDED.mapping_method,
+			confidence_score = EXCLUDED.confidence_score,
+			needs_verification = true,
+			chain = EXCLUDED.chain,
+			updated_at = NOW()
+		WHERE token_exchange_symbols.verified_at IS NULL
+		  AND token_exchange_symbols.mapping_method IN ('symbol', 'auto')
+		RETURNING token_id, exchange_id, exchange_symbol, normalized_symbol, COALESCE(chain, ''), confidence_score
+	`, pq.Array(tokenIDs), pq.Array(exchangeIDs), pq.Array(symbols), pq.Array(normalized), pq.Array(confidences), discoveredMethod)
+	if err != nil {
+		return fmt.Errorf("failed to insert symbol mappings: %w", err)
+	}
+	var written []pendingMapping
+	var chains []string
+	for rows.Next() {
+		var m pendingMapping
+		var chain string
+		if err := rows.Scan(&m.tokenID, &m.exchangeID, &m.exchangeSymbol, &m.normalizedSymbol, &chain, &m.confidence); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan symbol mapping: %w", err)
+		}
+		written = append(written, m)
+		chains = append(chains, chain)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to insert symbol mappings: %w", err)
+	}
+	if len(written) == 0 {
+		return nil
+	}
+
+	tokenIDs, exchangeIDs, symbols, confidences = tokenIDs[:0], exchangeIDs[:0], symbols[:0], confidences[:0]
+	for _, m := range written {
+		tokenIDs = append(tokenIDs, int64(m.tokenID))
+		exchangeIDs = append(exchangeIDs, m.exchangeID)
+		symbols = append(symbols, m.exchangeSymbol)
+		confidences = append(confidences, m.confidence)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO mapping_audit_log (
+			token_id, exchange_id, exchange_symbol,
+			mapping_method, confidence_score, action
+		)
+		SELECT d.token_id, d.exchange_id, d.exchange_symbol, $5, d.confidence, 'created'
+		FROM unnest($1::int[], $2::text[], $3::text[], $4::numeric[])
+		     AS d(token_id, exchange_id, exchange_symbol, confidence)
+	`, pq.Array(tokenIDs), pq.Array(exchangeIDs), pq.Array(symbols), pq.Array(confidences), discoveredMethod); err != nil {
+		return fmt.Errorf("failed to log mapping audit: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit symbol mappings: %w", err)
+	}
+
+	r.mu.Lock()
+	for i, m := range written {
+		r.normalizedCache[chainKey(m.normalizedSymbol, chains[i])] = m.tokenID
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// writePairs upserts a batch of discovered trading pairs. Verified pairs and
+// ones set by hand are left alone, as are pairs of exchanges not in the
+// registry, which would fail the whole batch.
+func (r *Resolver) writePairs(ctx context.Context, batch []pendingPair) error {
+	baseIDs := make([]int64, len(batch))
+	quoteIDs := make([]int64, len(batch))
+	exchangeIDs := make([]string, len(batch))
+	symbols := make([]string, len(batch))
+	instruments := make([]string, len(batch))
+	for i, p := range batch {
+		baseIDs[i] = int64(p.baseTokenID)
+		quoteIDs[i] = int64(p.quoteTokenID)
+		exchangeIDs[i] = p.exchangeID
+		symbols[i] = p.pairSymbol
+		instruments[i] = p.instrumentType
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO trading_pairs (
+			base_token_id, quote_token_id, exchange_id, exchange_pair_symbol,
+			instrument_type, mapping_method, needs_verification
+		)
+		SELECT d.base_token_id, d.quote_token_id, d.exchange_id, d.exchange_pair_symbol, d.instrument_type, $6, true
+		FROM unnest($1::int[], $2::int[], $3::text[], $4::text[], $5::text[])
+		     AS d(base_token_id, quote_token_id, exchange_id, exchange_pair_symbol, instrument_type)
+		WHERE EXISTS (SELECT 1 FROM exchanges e WHERE e.exchange_id = d.exchange_id)
+		ON CONFLICT (exchange_id, exchange_pair_symbol) DO UPDATE SET
+			base_token_id = EXCLUDED.base_token_id,
+			quote_token_id = EXCLUDED.quote_token_id,
+			instrument_type = EXCLUDED.instrument_type,
+			mapping_method = EXCLUDED.mapping_method,
+			needs_verification = true,
+			updated_at = NOW()
+		WHERE trading_pairs.verified_at IS NULL
+		  AND trading_pairs.mapping_method IN ('symbol', 'auto')
+	`, pq.Array(baseIDs), pq.Array(quoteIDs), pq.Array(exchangeIDs), pq.Array(symbols), pq.Array(instruments), discoveredMethod)
+	if err != nil {
+		return fmt.Errorf("failed to insert trading pairs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+)
+
+// Images started when no TEST_* server is given, matching docker-compose.yml
+const (
+	postgresImage   = "postgres"
+	postgresTag     = "15-alpine"
+	clickHouseImage = "clickhouse/clickhouse-server"
+	clickHouseTag   = "latest"
+
+	postgresUser     = "crypto_user"
+	postgresPassword = "crypto_password"
+
+	// Containers are shared by the tests of one package and removed by
+	// Docker this long after they start, as no test knows it is the last
+	containerLifetime = 15 * time.Minute
+	// How long a container may take to accept connections
+	containerStartTimeout = 2 * time.Minute
+)
+
+var (
+	poolOnce sync.Once
+	pool     *dockertest.Pool
+	poolErr  error
+
+	postgresOnce sync.Once
+	postgresAddr string
+	postgresErr  error
+
+	clickHouseOnce sync.Once
+	clickHouseAddr string
+	clickHouseErr  error
+)
+
+// dockerPool connects to the Docker daemon, skipping the test when there is
+// none to run the databases in
+func dockerPool(t testing.TB) *dockertest.Pool {
+	t.Helper()
+	poolOnce.Do(func() {
+		pool, poolErr = dockertest.NewPool("")
+		if poolErr == nil {
+			poolErr = pool.Client.Ping()
+		}
+		if poolErr == nil {
+			pool.MaxWait = containerStartTimeout
+		}
+	})
+	if poolErr != nil {
+		t.Skipf("Docker is not available and %s/%s are not set: %v", envPostgresURL, envClickHouseAddr, poolErr)
+	}
+	return pool
+}
+
+// runContainer starts a container removed when it stops or its lifetime ends
+func runContainer(p *dockertest.Pool, opts *dockertest.RunOptions) (*dockertest.Resource, error) {
+	resource, err := p.RunWithOptions(opts, func(hc *docker.HostConfig) {
+		hc.AutoRemove = true
+		hc.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("starting %s: %w", opts.Repository, err)
+	}
+	if err := resource.Expire(uint(containerLifetime.Seconds())); err != nil {
+		resource.Close()
+		return nil, fmt.Errorf("setting %s lifetime: %w", opts.Repository, err)
+	}
+	return resource, nil
+}
+
+// dockerPostgresURL returns the admin URL of a PostgreSQL container started
+// for this test binary
+func dockerPostgresURL(t testing.TB) string {
+	t.Helper()
+	p := dockerPool(t)
+	postgresOnce.Do(func() {
+		resource, err := runContainer(p, &dockertest.RunOptions{
+			Repository: postgresImage,
+			Tag:        postgresTag,
+			Env: []string{
+				"POSTGRES_USER=" + postgresUser,
+				"POSTGRES_PASSWORD=" + postgresPassword,
+				"POSTGRES_DB=postgres",
+			},
+		})
+		if err != nil {
+			postgresErr = err
+			return
+		}
+		url := fmt.Sprintf("postgres://%s:%s@%s/postgres?sslmode=disable",
+			postgresUser, postgresPassword, resource.GetHostPort("5432/tcp"))
+		postgresErr = p.Retry(func() error {
+			db, err := sql.Open("postgres", url)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			return db.Ping()
+		})
+		if postgresErr != nil {
+			resource.Close()
+			postgresErr = fmt.Errorf("waiting for postgres: %w", postgresErr)
+			return
+		}
+		postgresAddr = url
+	})
+	if postgresErr != nil {
+		t.Fatalf("starting postgres in Docker: %v", postgresErr)
+	}
+	return postgresAddr
+}
+
+// dockerClickHouseAddr returns the native protocol address of a ClickHouse
+// container started for this test binary
+func dockerClickHouseAddr(t testing.TB) string {
+	t.Helper()
+	p := dockerPool(t)
+	clickHouseOnce.Do(func() {
+		resource, err := runContainer(p, &dockertest.RunOptions{
+			Repository: clickHouseImage,
+			Tag:        clickHouseTag,
+			Env: []string{
+				"CLICKHOUSE_USER=" + defaultClickHouseUser,
+				"CLICKHOUSE_PASSWORD=" + defaultClickHousePassword,
+				"CLICKHOUSE_DEFAULT_ACCESS_MANAGEMENT=1",
+			},
+		})
+		if err != nil {
+			clickHouseErr = err
+			return
+		}
+		addr := resource.GetHostPort("9000/tcp")
+		clickHouseErr = p.Retry(func() error {
+			conn, err := clickhouse.Open(&clickhouse.Options{
+				Addr: []string{addr},
+				Auth: clickhouse.Auth{Username: defaultClickHouseUser, Password: defaultClickHousePassword},
+			})
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			return conn.Ping(context.Background())
+		})
+		if clickHouseErr != nil {
+			resource.Close()
+			clickHouseErr = fmt.Errorf("waiting for clickhouse: %w", clickHouseErr)
+			return
+		}
+		clickHouseAddr = addr
+	})
+	if clickHouseErr != nil {
+		t.Fatalf("starting clickhouse in Docker: %v", clickHouseErr)
+	}
+	return clickHouseAddr
+}
@@ -0,0 +1,99 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/shopspring/decimal"
+)
+
+// SeedTokens ensures a token exists per symbol and returns their IDs by
+// symbol. Tokens the migrations already seed, such as USD, are reused.
+func SeedTokens(t testing.TB, db *sql.DB, symbols ...string) map[string]int {
+	t.Helper()
+	ids := make(map[string]int, len(symbols))
+	for _, symbol := range symbols {
+		var id int
+		err := db.QueryRow(`
+			INSERT INTO tokens (symbol, name)
+			VALUES ($1, $1)
+			ON CONFLICT (symbol) WHERE chain IS NULL DO UPDATE SET updated_at = NOW()
+			RETURNING id
+		`, symbol).Scan(&id)
+		if err != nil {
+			t.Fatalf("seeding token %s: %v", symbol, err)
+		}
+		ids[symbol] = id
+	}
+	return ids
+}
+
+// SeedSymbolMapping maps an exchange symbol to a token
+func SeedSymbolMapping(t testing.TB, db *sql.DB, tokenID int, exchangeID, exchangeSymbol, normalizedSymbol string) {
+	t.Helper()
+	_, err := db.Exec(`
+		INSERT INTO token_exchange_symbols (token_id, exchange_id, exchange_symbol, normalized_symbol)
+		VALUES ($1, $2, $3, $4)
+	`, tokenID, exchangeID, exchangeSymbol, normalizedSymbol)
+	if err != nil {
+		t.Fatalf("seeding symbol mapping %s/%s: %v", exchangeID, exchangeSymbol, err)
+	}
+}
+
+// SeedExchange registers an exchange with a bare config, unless it is already
+func SeedExchange(t testing.TB, db *sql.DB, exchangeID string) {
+	t.Helper()
+	_, err := db.Exec(`
+		INSERT INTO exchanges (exchange_id, name, base_url, ticker_endpoint)
+		VALUES ($1, $1, '', '')
+		ON CONFLICT (exchange_id) DO NOTHING
+	`, exchangeID)
+	if err != nil {
+		t.Fatalf("seeding exchange %s: %v", exchangeID, err)
+	}
+}
+
+// SeedTradingPair adds an exchange pair between two tokens, registering the
+// exchange first
+func SeedTradingPair(t testing.TB, db *sql.DB, baseTokenID, quoteTokenID int, exchangeID, pairSymbol string) {
+	t.Helper()
+	SeedExchange(t, db, exchangeID)
+	_, err := db.Exec(`
+		INSERT INTO trading_pairs (base_token_id, quote_token_id, exchange_id, exchange_pair_symbol)
+		VALUES ($1, $2, $3, $4)
+	`, baseTokenID, quoteTokenID, exchangeID, pairSymbol)
+	if err != nil {
+		t.Fatalf("seeding trading pair %s/%s: %v", exchangeID, pairSymbol, err)
+	}
+}
+
+// Trade is a row of the trades table
+type Trade struct {
+	Timestamp time.Time
+	Symbol    string
+	Price     decimal.Decimal
+	Quantity  decimal.Decimal
+}
+
+// SeedTrades inserts trades for a Binance symbol
+func SeedTrades(t testing.TB, conn driver.Conn, trades ...Trade) {
+	t.Helper()
+	ctx := context.Background()
+	batch, err := conn.PrepareBatch(ctx, `
+		INSERT INTO trades (timestamp, exchange_id, symbol, price, quantity, trade_id, is_buyer_maker)
+	`)
+	if err != nil {
+		t.Fatalf("preparing trades batch: %v", err)
+	}
+	for i, trade := range trades {
+		if err := batch.Append(trade.Timestamp, "binance", trade.Symbol, trade.Price, trade.Quantity, uint64(i+1), uint8(0)); err != nil {
+			t.Fatalf("appending trade: %v", err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		t.Fatalf("seeding trades: %v", err)
+	}
+}
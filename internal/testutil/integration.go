@@ -0,0 +1,223 @@
+// Package testutil provides the database harness for integration tests. Tests
+// using it are built with the integration tag and run against PostgreSQL and
+// ClickHouse containers started with dockertest, or against the servers in
+// TEST_POSTGRES_URL and TEST_CLICKHOUSE_ADDR when those are set. Without
+// either, or Docker, the tests are skipped.
+//
+// Each test gets its own freshly migrated database, dropped when the test
+// ends, so tests can run in parallel and in any order.
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	_ "github.com/lib/pq"
+)
+
+// Servers to test against instead of containers, e.g. in CI. The ClickHouse
+// credentials default to those of the container.
+const (
+	envPostgresURL        = "TEST_POSTGRES_URL"
+	envClickHouseAddr     = "TEST_CLICKHOUSE_ADDR"
+	envClickHouseUser     = "TEST_CLICKHOUSE_USER"
+	envClickHousePassword = "TEST_CLICKHOUSE_PASSWORD"
+
+	defaultClickHouseUser     = "default"
+	defaultClickHousePassword = "clickhouse123"
+)
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// databaseName returns a name unique to this test run
+func databaseName(t testing.TB) string {
+	name := strings.ToLower(t.Name())
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+	if len(name) > 30 {
+		name = name[:30]
+	}
+	return fmt.Sprintf("test_%s_%d", name, time.Now().UnixNano())
+}
+
+// Postgres creates a database migrated with migrations/postgres and returns a
+// connection to it
+func Postgres(t testing.TB) *sql.DB {
+	t.Helper()
+
+	adminURL := os.Getenv(envPostgresURL)
+	if adminURL == "" {
+		adminURL = dockerPostgresURL(t)
+	}
+	admin, err := sql.Open("postgres", adminURL)
+	if err != nil {
+		t.Fatalf("opening postgres: %v", err)
+	}
+	if err := admin.Ping(); err != nil {
+		admin.Close()
+		t.Fatalf("connecting to postgres at %s: %v", adminURL, err)
+	}
+
+	name := databaseName(t)
+	if _, err := admin.Exec(`CREATE DATABASE ` + name); err != nil {
+		admin.Close()
+		t.Fatalf("creating postgres database: %v", err)
+	}
+
+	u, err := url.Parse(adminURL)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", envPostgresURL, err)
+	}
+	u.Path = "/" + name
+	conn, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		if _, err := admin.Exec(`DROP DATABASE IF EXISTS ` + name + ` WITH (FORCE)`); err != nil {
+			t.Logf("dropping postgres database %s: %v", name, err)
+		}
+		admin.Close()
+	})
+
+	for _, file := range migrationFiles(t, "postgres") {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("reading %s: %v", file, err)
+		}
+		if _, err := conn.Exec(string(body)); err != nil {
+			t.Fatalf("applying %s: %v", filepath.Base(file), err)
+		}
+	}
+	return conn
+}
+
+// ClickHouse creates a database migrated with migrations/clickhouse and
+// returns a connection to it
+func ClickHouse(t testing.TB) driver.Conn {
+	t.Helper()
+
+	addr := os.Getenv(envClickHouseAddr)
+	if addr == "" {
+		addr = dockerClickHouseAddr(t)
+	}
+	auth := clickhouse.Auth{
+		Username: getEnv(envClickHouseUser, defaultClickHouseUser),
+		Password: getEnv(envClickHousePassword, defaultClickHousePassword),
+	}
+
+	admin, err := clickhouse.Open(&clickhouse.Options{Addr: []string{addr}, Auth: auth})
+	if err == nil {
+		err = admin.Ping(context.Background())
+	}
+	if err != nil {
+		t.Fatalf("connecting to clickhouse at %s: %v", addr, err)
+	}
+
+	ctx := context.Background()
+	name := databaseName(t)
+	if err := admin.Exec(ctx, `CREATE DATABASE `+name); err != nil {
+		admin.Close()
+		t.Fatalf("creating clickhouse database: %v", err)
+	}
+
+	auth.Database = name
+	conn, err := clickhouse.Open(&clickhouse.Options{Addr: []string{addr}, Auth: auth})
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		if err := admin.Exec(context.Background(), `DROP DATABASE IF EXISTS `+name); err != nil {
+			t.Logf("dropping clickhouse database %s: %v", name, err)
+		}
+		admin.Close()
+	})
+
+	for _, file := range migrationFiles(t, "clickhouse") {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("reading %s: %v", file, err)
+		}
+		// The native protocol runs one statement per query
+		for _, stmt := range splitStatements(string(body)) {
+			if err := conn.Exec(ctx, stmt); err != nil {
+				t.Fatalf("applying %s: %v", filepath.Base(file), err)
+			}
+		}
+	}
+	return conn
+}
+
+// migrationFiles returns the up migrations in migrations/<dir>, in order
+func migrationFiles(t testing.TB, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(repoRoot(t), "migrations", dir, "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no %s migrations found: %v", dir, err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// repoRoot walks up from the test's working directory to the directory
+// holding go.mod
+func repoRoot(t testing.TB) string {
+	t.Helper()
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getting working directory: %v", err)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			t.Fatal("go.mod not found above working directory")
+		}
+		dir = parent
+	}
+}
+
+// splitStatements splits a migration on semicolons, dropping empty and
+// comment-only statements
+func splitStatements(body string) []string {
+	var stmts []string
+	for _, part := range strings.Split(body, ";") {
+		hasSQL := false
+		for _, line := range strings.Split(part, "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "--") {
+				hasSQL = true
+				break
+			}
+		}
+		if hasSQL {
+			stmts = append(stmts, strings.TrimSpace(part))
+		}
+	}
+	return stmts
+}
@@ -0,0 +1,14 @@
+package timeutil
+
+import "time"
+
+// NextDaily returns the first time after now that is at past midnight UTC,
+// for jobs that run once a day at a fixed time such as 03:00 UTC
+func NextDaily(now time.Time, at time.Duration) time.Time {
+	now = now.UTC()
+	next := now.Truncate(24 * time.Hour).Add(at)
+	for !next.After(now) {
+		next = next.Add(24 * time.Hour)
+	}
+	return next
+}
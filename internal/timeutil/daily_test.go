@@ -0,0 +1,24 @@
+package timeutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextDaily(t *testing.T) {
+	at := 3 * time.Hour
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 3, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 3, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 1, 1, 0, 0, 0, time.FixedZone("UTC+5", 5*3600)), time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := NextDaily(tt.now, at); !got.Equal(tt.want) {
+			t.Errorf("NextDaily(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
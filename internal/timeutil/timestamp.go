@@ -0,0 +1,52 @@
+// Package timeutil gives the two Unix timestamp units used across the
+// platform their own types, so a value cannot cross from one to the other
+// without an explicit conversion.
+//
+// Trades and tickers are stored in ClickHouse as DateTime64(3) and read back
+// as milliseconds. API parameters, candle buckets and the bounds bound into
+// toDateTime64(?, 3), which reads integers as seconds, are in seconds.
+package timeutil
+
+import "time"
+
+// Millis is a Unix timestamp in milliseconds
+type Millis int64
+
+// Seconds is a Unix timestamp in seconds
+type Seconds int64
+
+// MillisOf returns t as a Unix timestamp in milliseconds
+func MillisOf(t time.Time) Millis {
+	return Millis(t.UnixMilli())
+}
+
+// SecondsOf returns t as a Unix timestamp in seconds
+func SecondsOf(t time.Time) Seconds {
+	return Seconds(t.Unix())
+}
+
+// Time returns m as a time.Time, or the zero time when m is not positive
+func (m Millis) Time() time.Time {
+	if m <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(m))
+}
+
+// Seconds truncates m to whole seconds
+func (m Millis) Seconds() Seconds {
+	return Seconds(int64(m) / 1000)
+}
+
+// Time returns s as a time.Time, or the zero time when s is not positive
+func (s Seconds) Time() time.Time {
+	if s <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(s), 0)
+}
+
+// Millis returns s in milliseconds
+func (s Seconds) Millis() Millis {
+	return Millis(int64(s) * 1000)
+}
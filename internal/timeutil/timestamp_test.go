@@ -0,0 +1,40 @@
+package timeutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConversions(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 15, 250*int(time.Millisecond), time.UTC)
+
+	ms := MillisOf(at)
+	if ms != 1709296215250 {
+		t.Fatalf("MillisOf = %d", ms)
+	}
+	if !ms.Time().Equal(at) {
+		t.Errorf("Millis.Time = %v, want %v", ms.Time(), at)
+	}
+
+	s := SecondsOf(at)
+	if s != 1709296215 {
+		t.Fatalf("SecondsOf = %d", s)
+	}
+	if ms.Seconds() != s {
+		t.Errorf("Millis.Seconds = %d, want %d", ms.Seconds(), s)
+	}
+	if s.Millis() != 1709296215000 {
+		t.Errorf("Seconds.Millis = %d", s.Millis())
+	}
+	if !s.Time().Equal(at.Truncate(time.Second)) {
+		t.Errorf("Seconds.Time = %v", s.Time())
+	}
+}
+
+func TestZeroTime(t *testing.T) {
+	for _, got := range []time.Time{Millis(0).Time(), Millis(-1).Time(), Seconds(0).Time()} {
+		if !got.IsZero() {
+			t.Errorf("got %v, want zero time", got)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package tokenctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	coinGeckoBaseURL = "https://api.coingecko.com/api/v3"
+	cmcBaseURL       = "https://pro-api.coinmarketcap.com/v1"
+
+	// coinGeckoPageSize is the largest page /coins/markets serves
+	coinGeckoPageSize = 250
+	// cmcPageSize is the largest page /cryptocurrency/listings/latest serves
+	cmcPageSize = 5000
+
+	// maxRateLimitRetries is how often a request answered with 429 is retried
+	maxRateLimitRetries = 3
+)
+
+var apiClient = &http.Client{Timeout: 30 * time.Second}
+
+// getJSON fetches rawURL into out, waiting out rate limits
+func getJSON(ctx context.Context, rawURL string, header http.Header, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return err
+		}
+		req.Header = header.Clone()
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := apiClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("error fetching %s: %w", req.URL.Path, err)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxRateLimitRetries {
+			resp.Body.Close()
+			wait := time.Minute
+			if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+				wait = time.Duration(s) * time.Second
+			}
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", req.URL.Path, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s returned %d: %s", req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("error parsing %s: %w", req.URL.Path, err)
+		}
+		return nil
+	}
+}
+
+// coinGeckoSource pulls the top tokens by market cap from CoinGecko's
+// /coins/markets. It carries no URLs or contracts, and the CoinGecko ID is
+// stored as coingecko_id rather than as the slug, which holds CoinMarketCap
+// slugs the mapper matches exchange dumps against.
+type coinGeckoSource struct {
+	baseURL string
+	apiKey  string // demo key, optional
+	limit   int
+}
+
+func newCoinGeckoSource(apiKey string, limit int) coinGeckoSource {
+	return coinGeckoSource{baseURL: coinGeckoBaseURL, apiKey: apiKey, limit: limit}
+}
+
+func (s coinGeckoSource) Name() string { return fmt.Sprintf("CoinGecko (top %d)", s.limit) }
+
+type coinGeckoMarket struct {
+	ID                string   `json:"id"`
+	Symbol            string   `json:"symbol"`
+	Name              string   `json:"name"`
+	CirculatingSupply float64  `json:"circulating_supply"`
+	TotalSupply       *float64 `json:"total_supply"`
+	MaxSupply         *float64 `json:"max_supply"`
+}
+
+func (s coinGeckoSource) Read(ctx context.Context, batchSize int, fn func([]TokenMetadata) error) error {
+	header := http.Header{}
+	if s.apiKey != "" {
+		header.Set("x-cg-demo-api-key", s.apiKey)
+	}
+
+	b := newBatcher(batchSize, fn)
+	for page, read := 1, 0; read < s.limit; page++ {
+		var markets []coinGeckoMarket
+		u := fmt.Sprintf("%s/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=%d&page=%d",
+			s.baseURL, coinGeckoPageSize, page)
+		if err := getJSON(ctx, u, header, &markets); err != nil {
+			return err
+		}
+		for _, m := range markets {
+			if read == s.limit {
+				break
+			}
+			read++
+			token := TokenMetadata{
+				Name:              m.Name,
+				Symbol:            strings.ToUpper(m.Symbol),
+				CoinGeckoID:       m.ID,
+				CirculatingSupply: m.CirculatingSupply,
+				MaxSupply:         m.MaxSupply,
+			}
+			if m.TotalSupply != nil {
+				token.TotalSupply = *m.TotalSupply
+			}
+			if err := b.add(token); err != nil {
+				return err
+			}
+		}
+		if len(markets) < coinGeckoPageSize {
+			break
+		}
+	}
+	return b.flush()
+}
+
+// cmcSource pulls the top tokens by market cap from CoinMarketCap's
+// /cryptocurrency/listings/latest. A token issued on another chain's platform
+// gets that contract.
+type cmcSource struct {
+	baseURL string
+	apiKey  string
+	limit   int
+}
+
+func newCMCSource(apiKey string, limit int) cmcSource {
+	return cmcSource{baseURL: cmcBaseURL, apiKey: apiKey, limit: limit}
+}
+
+func (s cmcSource) Name() string { return fmt.Sprintf("CoinMarketCap (top %d)", s.limit) }
+
+type cmcListings struct {
+	Status struct {
+		ErrorCode    int    `json:"error_code"`
+		ErrorMessage string `json:"error_message"`
+	} `json:"status"`
+	Data []struct {
+		Name              string   `json:"name"`
+		Symbol            string   `json:"symbol"`
+		Slug              string   `json:"slug"`
+		CirculatingSupply float64  `json:"circulating_supply"`
+		TotalSupply       float64  `json:"total_supply"`
+		MaxSupply         *float64 `json:"max_supply"`
+		InfiniteSupply    bool     `json:"infinite_supply"`
+		Platform          *struct {
+			Name         string `json:"name"`
+			TokenAddress string `json:"token_address"`
+		} `json:"platform"`
+	} `json:"data"`
+}
+
+func (s cmcSource) Read(ctx context.Context, batchSize int, fn func([]TokenMetadata) error) error {
+	header := http.Header{}
+	header.Set("X-CMC_PRO_API_KEY", s.apiKey)
+
+	b := newBatcher(batchSize, fn)
+	for start := 1; start <= s.limit; start += cmcPageSize {
+		size := s.limit - start + 1
+		if size > cmcPageSize {
+			size = cmcPageSize
+		}
+		query := url.Values{"start": {strconv.Itoa(start)}, "limit": {strconv.Itoa(size)}}
+
+		var listings cmcListings
+		if err := getJSON(ctx, s.baseURL+"/cryptocurrency/listings/latest?"+query.Encode(), header, &listings); err != nil {
+			return err
+		}
+		if listings.Status.ErrorCode != 0 {
+			return fmt.Errorf("CoinMarketCap error %d: %s", listings.Status.ErrorCode, listings.Status.ErrorMessage)
+		}
+
+		for _, d := range listings.Data {
+			token := TokenMetadata{
+				Name:              d.Name,
+				Symbol:            d.Symbol,
+				Slug:              d.Slug,
+				CirculatingSupply: d.CirculatingSupply,
+				TotalSupply:       d.TotalSupply,
+				MaxSupply:         d.MaxSupply,
+			}
+			if d.InfiniteSupply {
+				token.IsInfiniteMaxSupply = 1
+			}
+			if d.Platform != nil && d.Platform.TokenAddress != "" {
+				token.Contracts = []Contract{{No: 1, ContractAddress: d.Platform.TokenAddress, ContractPlatform: d.Platform.Name}}
+			}
+			if err := b.add(token); err != nil {
+				return err
+			}
+		}
+		if len(listings.Data) < size {
+			break
+		}
+	}
+	return b.flush()
+}
@@ -0,0 +1,252 @@
+package tokenctl
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// sourceCoinGecko marks the categories and links an import wrote, so the
+// next import replaces them and leaves manual ones alone
+const sourceCoinGecko = "coingecko"
+
+// categoriesCommand links the tokens in the database to their CoinGecko
+// categories
+func categoriesCommand(fs *flag.FlagSet) func(context.Context, *env) error {
+	only := fs.String("categories", "", "CoinGecko category IDs to import, optionally as slug=id to store them under another slug, e.g. defi=decentralized-finance-defi,layer-1 (default all)")
+	limit := fs.Int("limit", coinGeckoPageSize, "Tokens read per category, by market cap rank")
+	apiKey := fs.String("api-key", "", "CoinGecko demo API key (default COINGECKO_API_KEY)")
+
+	return func(ctx context.Context, e *env) error {
+		if *limit < 1 {
+			return usageError{fmt.Errorf("-limit must be at least 1")}
+		}
+		slugs, err := parseCategorySlugs(*only)
+		if err != nil {
+			return usageError{err}
+		}
+
+		source := coinGeckoCategories{baseURL: coinGeckoBaseURL, apiKey: firstNonEmpty(*apiKey, os.Getenv("COINGECKO_API_KEY"))}
+		categories, err := source.List(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list CoinGecko categories: %w", err)
+		}
+		categories, err = selectCategories(categories, slugs)
+		if err != nil {
+			return usageError{err}
+		}
+
+		// Read every category before writing, so the transaction is not
+		// held open over the API's rate limits
+		members := make([][]string, len(categories))
+		for i, cat := range categories {
+			if members[i], err = source.Coins(ctx, cat.ID, *limit); err != nil {
+				return fmt.Errorf("failed to read CoinGecko category %s: %w", cat.ID, err)
+			}
+			e.logger.Debug("Read category", zap.String("category", cat.ID), zap.Int("coins", len(members[i])))
+		}
+
+		if err := e.connect(); err != nil {
+			return err
+		}
+		var linked, unmatched int
+		err = e.inTx(ctx, func(tx *sql.Tx) error {
+			tokens, err := loadCoinGeckoIDs(ctx, tx)
+			if err != nil {
+				return err
+			}
+			for i, cat := range categories {
+				var ids []int
+				for _, coin := range members[i] {
+					if len(tokens[coin]) == 0 {
+						unmatched++
+					}
+					ids = append(ids, tokens[coin]...)
+				}
+				if err := writeCategory(ctx, tx, cat, ids); err != nil {
+					return err
+				}
+				linked += len(ids)
+				if e.verbose {
+					fmt.Fprintf(e.out, "✓ %s: %d tokens\n", cat.Slug, len(ids))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import categories: %w", err)
+		}
+
+		fmt.Fprintf(e.out, "✓ Categories: %d imported from CoinGecko\n", len(categories))
+		fmt.Fprintf(e.out, "✓ Links: %d tokens linked, %d category coins not in the database\n", linked, unmatched)
+		return nil
+	}
+}
+
+// coinGeckoCategory is a CoinGecko category and the slug it is stored under
+type coinGeckoCategory struct {
+	ID   string `json:"category_id"`
+	Name string `json:"name"`
+	Slug string `json:"-"`
+}
+
+// parseCategorySlugs parses -categories into CoinGecko ID -> slug
+func parseCategorySlugs(s string) (map[string]string, error) {
+	slugs := make(map[string]string)
+	for _, entry := range splitList(s) {
+		slug, id, ok := strings.Cut(entry, "=")
+		if !ok {
+			id = slug
+		}
+		slug, id = db.CategorySlug(slug), strings.TrimSpace(id)
+		if slug == "" || id == "" {
+			return nil, fmt.Errorf("invalid -categories entry %q, want id or slug=id", entry)
+		}
+		slugs[id] = slug
+	}
+	return slugs, nil
+}
+
+// selectCategories keeps the categories named in slugs, or all of them when
+// it is empty, giving each its slug
+func selectCategories(all []coinGeckoCategory, slugs map[string]string) ([]coinGeckoCategory, error) {
+	var selected []coinGeckoCategory
+	found := make(map[string]bool, len(slugs))
+	for _, cat := range all {
+		slug, ok := slugs[cat.ID]
+		switch {
+		case ok:
+			found[cat.ID] = true
+		case len(slugs) > 0:
+			continue
+		default:
+			slug = db.CategorySlug(cat.ID)
+		}
+		cat.Slug = slug
+		selected = append(selected, cat)
+	}
+	for id := range slugs {
+		if !found[id] {
+			return nil, fmt.Errorf("CoinGecko has no category %q", id)
+		}
+	}
+	return selected, nil
+}
+
+// coinGeckoCategories reads CoinGecko's categories and the coins in each
+type coinGeckoCategories struct {
+	baseURL string
+	apiKey  string // demo key, optional
+}
+
+func (s coinGeckoCategories) header() http.Header {
+	header := http.Header{}
+	if s.apiKey != "" {
+		header.Set("x-cg-demo-api-key", s.apiKey)
+	}
+	return header
+}
+
+// List returns every category
+func (s coinGeckoCategories) List(ctx context.Context) ([]coinGeckoCategory, error) {
+	var categories []coinGeckoCategory
+	if err := getJSON(ctx, s.baseURL+"/coins/categories/list", s.header(), &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// Coins returns the CoinGecko IDs of up to limit coins of a category, by
+// market cap rank
+func (s coinGeckoCategories) Coins(ctx context.Context, category string, limit int) ([]string, error) {
+	var ids []string
+	for page := 1; len(ids) < limit; page++ {
+		query := url.Values{
+			"vs_currency": {"usd"},
+			"category":    {category},
+			"order":       {"market_cap_desc"},
+			"per_page":    {strconv.Itoa(coinGeckoPageSize)},
+			"page":        {strconv.Itoa(page)},
+		}
+		var markets []coinGeckoMarket
+		if err := getJSON(ctx, s.baseURL+"/coins/markets?"+query.Encode(), s.header(), &markets); err != nil {
+			return nil, err
+		}
+		for _, m := range markets {
+			if len(ids) == limit {
+				break
+			}
+			ids = append(ids, m.ID)
+		}
+		if len(markets) < coinGeckoPageSize {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// loadCoinGeckoIDs maps the CoinGecko IDs tokenctl seed stored in
+// tokens.metadata to the tokens carrying them
+func loadCoinGeckoIDs(ctx context.Context, tx *sql.Tx) (map[string][]int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, metadata->>'coingecko_id'
+		FROM tokens
+		WHERE COALESCE(metadata->>'coingecko_id', '') <> ''
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CoinGecko IDs: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make(map[string][]int)
+	for rows.Next() {
+		var id int
+		var coin string
+		if err := rows.Scan(&id, &coin); err != nil {
+			return nil, fmt.Errorf("failed to scan CoinGecko ID: %w", err)
+		}
+		tokens[coin] = append(tokens[coin], id)
+	}
+	return tokens, rows.Err()
+}
+
+// writeCategory upserts a category and replaces its CoinGecko links with
+// tokenIDs. A category someone created by hand keeps its name.
+func writeCategory(ctx context.Context, tx *sql.Tx, cat coinGeckoCategory, tokenIDs []int) error {
+	var id int
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO categories (slug, name, kind, source)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (slug) DO UPDATE SET
+			name = CASE WHEN categories.source = $4 THEN EXCLUDED.name ELSE categories.name END
+		RETURNING id
+	`, cat.Slug, cat.Name, db.CategoryKindCategory, sourceCoinGecko).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to write category %s: %w", cat.Slug, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM token_categories WHERE category_id = $1 AND source = $2
+	`, id, sourceCoinGecko); err != nil {
+		return fmt.Errorf("failed to clear category %s: %w", cat.Slug, err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO token_categories (token_id, category_id, source)
+		SELECT DISTINCT unnest($1::int[]), $2, $3
+		ON CONFLICT DO NOTHING
+	`, pq.Array(tokenIDs), id, sourceCoinGecko); err != nil {
+		return fmt.Errorf("failed to link tokens to category %s: %w", cat.Slug, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+//go:build integration
+
+package tokenctl
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestWriteCategory(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ctx := context.Background()
+	ids := testutil.SeedTokens(t, pg, "UNI", "AAVE", "BTC")
+
+	defi := coinGeckoCategory{ID: "decentralized-finance-defi", Name: "DeFi", Slug: "defi"}
+	write := func(tokenIDs ...int) {
+		t.Helper()
+		err := testEnv(pg).inTx(ctx, func(tx *sql.Tx) error {
+			return writeCategory(ctx, tx, defi, tokenIDs)
+		})
+		if err != nil {
+			t.Fatalf("writeCategory: %v", err)
+		}
+	}
+
+	write(ids["UNI"], ids["AAVE"])
+	// A link made by hand survives the next import
+	if _, err := pg.Exec(`
+		INSERT INTO token_categories (token_id, category_id, source)
+		SELECT $1, id, 'manual' FROM categories WHERE slug = 'defi'
+	`, ids["BTC"]); err != nil {
+		t.Fatal(err)
+	}
+	write(ids["UNI"])
+
+	got, err := db.GetTokenCategories(ctx, pg, []int{ids["UNI"], ids["AAVE"], ids["BTC"]})
+	if err != nil {
+		t.Fatalf("GetTokenCategories: %v", err)
+	}
+	if len(got) != 2 || len(got[ids["UNI"]]) != 1 || got[ids["UNI"]][0] != "defi" || len(got[ids["BTC"]]) != 1 {
+		t.Errorf("token categories = %v, want defi for UNI and BTC only", got)
+	}
+
+	categories, err := db.ListCategories(ctx, pg, db.CategoryKindCategory)
+	if err != nil {
+		t.Fatalf("ListCategories: %v", err)
+	}
+	if len(categories) != 1 || categories[0].Slug != "defi" || categories[0].Tokens != 2 || categories[0].Source != sourceCoinGecko {
+		t.Errorf("categories = %+v", categories)
+	}
+}
@@ -0,0 +1,73 @@
+package tokenctl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSelectCategories(t *testing.T) {
+	all := []coinGeckoCategory{
+		{ID: "decentralized-finance-defi", Name: "Decentralized Finance (DeFi)"},
+		{ID: "layer-1", Name: "Layer 1 (L1)"},
+		{ID: "meme-token", Name: "Meme"},
+	}
+
+	selected, err := selectCategories(all, map[string]string{})
+	if err != nil || len(selected) != 3 || selected[0].Slug != "decentralized-finance-defi" {
+		t.Errorf("all categories = %+v, %v", selected, err)
+	}
+
+	slugs, err := parseCategorySlugs("DeFi = decentralized-finance-defi, layer-1")
+	if err != nil {
+		t.Fatalf("parseCategorySlugs: %v", err)
+	}
+	selected, err = selectCategories(all, slugs)
+	if err != nil || len(selected) != 2 || selected[0].Slug != "defi" || selected[1].Slug != "layer-1" {
+		t.Errorf("selected = %+v, %v", selected, err)
+	}
+
+	if _, err := selectCategories(all, map[string]string{"layer-3": "layer-3"}); err == nil {
+		t.Error("unknown category accepted")
+	}
+	if _, err := parseCategorySlugs("=layer-1"); err == nil {
+		t.Error("empty slug accepted")
+	}
+}
+
+func TestCoinGeckoCategoryCoins(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("category") != "layer-1" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		pages = append(pages, r.URL.Query().Get("page"))
+		// A full first page, then a short one
+		n := coinGeckoPageSize
+		if len(pages) > 1 {
+			n = 2
+		}
+		coins := make([]string, n)
+		for i := range coins {
+			coins[i] = `{"id": "coin"}`
+		}
+		w.Write([]byte("[" + strings.Join(coins, ",") + "]"))
+	}))
+	defer srv.Close()
+
+	source := coinGeckoCategories{baseURL: srv.URL}
+	ids, err := source.Coins(context.Background(), "layer-1", 1000)
+	if err != nil {
+		t.Fatalf("Coins: %v", err)
+	}
+	if len(ids) != coinGeckoPageSize+2 || len(pages) != 2 {
+		t.Errorf("%d coins after pages %v", len(ids), pages)
+	}
+
+	pages = nil
+	if ids, _ := source.Coins(context.Background(), "layer-1", 10); len(ids) != 10 || len(pages) != 1 {
+		t.Errorf("limit 10: %d coins after pages %v", len(ids), pages)
+	}
+}
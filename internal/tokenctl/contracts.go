@@ -0,0 +1,181 @@
+package tokenctl
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var (
+	nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+	evmAddress      = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+)
+
+// contractRow is one token_contracts row
+type contractRow struct {
+	Chain    string
+	Platform string
+	Address  string
+	RPCURLs  []string
+	Position int
+}
+
+// tokenContracts is a token's normalized contracts and the chain and contract
+// address its tokens row gets, empty when it is not on a single chain
+type tokenContracts struct {
+	rows    []contractRow
+	primary contractRow
+	chain   string
+	address string
+}
+
+// normalizeChain turns a platform name into a chain ID as token_contracts
+// stores it: "BNB Smart Chain (BEP20)" -> "bnb-smart-chain-bep20"
+func normalizeChain(platform string) string {
+	chain := strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(strings.TrimSpace(platform)), "-"), "-")
+	if len(chain) > 50 {
+		chain = strings.TrimRight(chain[:50], "-")
+	}
+	return chain
+}
+
+// normalizeAddress lowercases EVM addresses, which are case-insensitive hex,
+// and leaves other chains' addresses as they are
+func normalizeAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if evmAddress.MatchString(address) {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+// normalizeContracts orders a token's contracts by their number in the source
+// and picks the first as primary. The token takes the primary contract's
+// chain and address when all its contracts are on that chain, unless another
+// token of this run took the same contract first; claimed records who did.
+func normalizeContracts(token TokenMetadata, claimed map[string]string) tokenContracts {
+	contracts := make([]Contract, len(token.Contracts))
+	copy(contracts, token.Contracts)
+	sort.SliceStable(contracts, func(i, j int) bool {
+		// Unnumbered contracts keep their place after the numbered ones
+		if (contracts[i].No == 0) != (contracts[j].No == 0) {
+			return contracts[j].No == 0
+		}
+		return contracts[i].No < contracts[j].No
+	})
+
+	var tc tokenContracts
+	seen := make(map[string]bool)
+	chains := make(map[string]bool)
+	for _, c := range contracts {
+		row := contractRow{
+			Chain:    normalizeChain(c.ContractPlatform),
+			Platform: strings.TrimSpace(c.ContractPlatform),
+			Address:  normalizeAddress(c.ContractAddress),
+			RPCURLs:  c.ContractRpcURL,
+		}
+		key := row.Chain + "\x00" + row.Address
+		if row.Chain == "" || row.Address == "" || seen[key] {
+			continue
+		}
+		if len(row.Platform) > 100 {
+			row.Platform = row.Platform[:100]
+		}
+		if row.RPCURLs == nil {
+			row.RPCURLs = []string{}
+		}
+		seen[key] = true
+		chains[row.Chain] = true
+		row.Position = len(tc.rows) + 1
+		tc.rows = append(tc.rows, row)
+	}
+	if len(tc.rows) == 0 {
+		return tc
+	}
+
+	primary := tc.rows[0]
+	key := primary.Chain + "\x00" + primary.Address
+	if owner, ok := claimed[key]; ok && owner != token.Symbol {
+		return tc
+	}
+	claimed[key] = token.Symbol
+	tc.primary = primary
+	if len(chains) == 1 {
+		tc.chain, tc.address = primary.Chain, primary.Address
+	}
+	return tc
+}
+
+// replaceContracts sets the token_contracts rows of the batch's tokens that
+// list contracts to those contracts. Tokens the source lists no contracts
+// for keep the rows they have.
+func replaceContracts(tx *sql.Tx, batch []TokenMetadata, contracts []tokenContracts, results map[string]upsertResult) (int, error) {
+	var tokenIDs []int64
+	type idRow struct {
+		tokenID int
+		row     contractRow
+		primary bool
+	}
+	var rows []idRow
+	for i, token := range batch {
+		r, ok := results[token.Symbol]
+		if !ok || len(contracts[i].rows) == 0 {
+			continue
+		}
+		tokenIDs = append(tokenIDs, int64(r.id))
+		for _, row := range contracts[i].rows {
+			rows = append(rows, idRow{tokenID: r.id, row: row, primary: row.Position == 1})
+		}
+	}
+	if len(tokenIDs) == 0 {
+		return 0, nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM token_contracts WHERE token_id = ANY($1)`, pq.Array(tokenIDs)); err != nil {
+		return 0, fmt.Errorf("error clearing contracts: %w", err)
+	}
+
+	const columns = 7
+	written := 0
+	for start := 0; start < len(rows); start += maxContractRows {
+		end := start + maxContractRows
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		var values strings.Builder
+		args := make([]interface{}, 0, (end-start)*columns)
+		for i, r := range rows[start:end] {
+			if i > 0 {
+				values.WriteString(", ")
+			}
+			n := i * columns
+			fmt.Fprintf(&values, "($%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7)
+			args = append(args, r.tokenID, r.row.Chain, r.row.Platform, r.row.Address, pq.Array(r.row.RPCURLs), r.row.Position, r.primary)
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO token_contracts (token_id, chain, platform, contract_address, rpc_urls, position, is_primary)
+			VALUES `+values.String()+`
+			ON CONFLICT (token_id, chain, contract_address) DO NOTHING
+		`, args...)
+		if err != nil {
+			return written, fmt.Errorf("error inserting contracts: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		written += int(n)
+	}
+	return written, nil
+}
+
+// nullString passes an empty string to SQL as NULL
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
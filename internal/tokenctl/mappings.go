@@ -0,0 +1,308 @@
+package tokenctl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// defaultExchanges are the exchanges the all mapping set guesses symbols on
+var defaultExchanges = []string{"binance", "kraken", "okx", "coinbase"}
+
+// symbolMapping is how an exchange writes a token's symbol
+type symbolMapping struct {
+	Token    string
+	Exchange string
+	Symbol   string
+}
+
+// curatedSymbols are mappings checked by hand, including the exchanges'
+// aliases such as Kraken's XBT
+var curatedSymbols = []symbolMapping{
+	// Bitcoin variations
+	{"BTC", "binance", "BTC"},
+	{"BTC", "coinbase", "BTC"},
+	{"BTC", "kraken", "XBT"},
+	{"BTC", "kraken", "XXBT"},
+	{"BTC", "kraken", "BTC"},
+	{"BTC", "bitfinex", "BTC"},
+	{"BTC", "okx", "BTC"},
+
+	// Ethereum
+	{"ETH", "binance", "ETH"},
+	{"ETH", "coinbase", "ETH"},
+	{"ETH", "kraken", "ETH"},
+	{"ETH", "kraken", "XETH"},
+	{"ETH", "okx", "ETH"},
+
+	// Stablecoins
+	{"USDT", "binance", "USDT"},
+	{"USDT", "coinbase", "USDT"},
+	{"USDT", "kraken", "USDT"},
+	{"USDT", "okx", "USDT"},
+	{"USDC", "binance", "USDC"},
+	{"USDC", "coinbase", "USDC"},
+	{"USDC", "kraken", "USDC"},
+	{"USDC", "okx", "USDC"},
+
+	// USD representations
+	{"USD", "coinbase", "USD"},
+	{"USD", "kraken", "USD"},
+	{"USD", "kraken", "ZUSD"},
+	{"USD", "bitstamp", "USD"},
+	{"USD", "gemini", "USD"},
+
+	// Other major tokens
+	{"BNB", "binance", "BNB"},
+	{"SOL", "binance", "SOL"},
+	{"SOL", "coinbase", "SOL"},
+	{"SOL", "kraken", "SOL"},
+	{"SOL", "okx", "SOL"},
+	{"ADA", "binance", "ADA"},
+	{"ADA", "coinbase", "ADA"},
+	{"ADA", "kraken", "ADA"},
+	{"ADA", "okx", "ADA"},
+	{"DOT", "binance", "DOT"},
+	{"DOT", "coinbase", "DOT"},
+	{"DOT", "kraken", "DOT"},
+	{"MATIC", "binance", "MATIC"},
+	{"MATIC", "coinbase", "MATIC"},
+	{"MATIC", "kraken", "MATIC"},
+	{"AVAX", "binance", "AVAX"},
+	{"AVAX", "coinbase", "AVAX"},
+	{"AVAX", "kraken", "AVAX"},
+	{"AVAX", "okx", "AVAX"},
+	{"LINK", "binance", "LINK"},
+	{"LINK", "coinbase", "LINK"},
+	{"LINK", "kraken", "LINK"},
+	{"UNI", "binance", "UNI"},
+	{"UNI", "coinbase", "UNI"},
+	{"ATOM", "binance", "ATOM"},
+	{"ATOM", "coinbase", "ATOM"},
+	{"XRP", "binance", "XRP"},
+	{"XRP", "coinbase", "XRP"},
+	{"XRP", "kraken", "XRP"},
+	{"XRP", "kraken", "XXRP"},
+	{"XRP", "okx", "XRP"},
+	{"LTC", "binance", "LTC"},
+	{"LTC", "coinbase", "LTC"},
+	{"LTC", "kraken", "LTC"},
+	{"LTC", "kraken", "XLTC"},
+	{"DOGE", "binance", "DOGE"},
+	{"DOGE", "coinbase", "DOGE"},
+	{"DOGE", "kraken", "DOGE"},
+	{"DOGE", "kraken", "XDOGE"},
+	{"DOGE", "okx", "DOGE"},
+}
+
+// How a row was arrived at. Curated rows are written as manual mappings;
+// generated ones as symbol guesses, which stay out of VWAP until verified and
+// never replace a mapping made any other way.
+const (
+	methodManual = "manual"
+	methodSymbol = "symbol"
+
+	symbolConfidence = 0.75
+)
+
+// mappingRow is one token_exchange_symbols row to write
+type mappingRow struct {
+	TokenID  int
+	Exchange string
+	Symbol   string
+	Token    string
+	Method   string
+}
+
+// WriteCounts counts what writing mapping or pair rows did
+type WriteCounts struct {
+	Inserted int
+	Updated  int
+	Kept     int // rows left alone because they were verified or mapped another way
+
+	Deactivated int // pairs pruned because their exchange no longer lists them
+	Enriched    int // pairs whose order rules and fees were refreshed from a listing
+}
+
+// mapCommand writes symbol mappings for the chain-agnostic tokens in the
+// database
+func mapCommand(fs *flag.FlagSet) func(context.Context, *env) error {
+	set := fs.String("set", "curated", "Mappings to write: curated (checked by hand) or all (curated plus every token under its own symbol)")
+	exchangeList := fs.String("exchanges", "", "Comma-separated exchanges to write mappings for (default all in the set)")
+
+	return func(ctx context.Context, e *env) error {
+		if *set != "curated" && *set != "all" {
+			return usageError{fmt.Errorf("unknown -set %q, want curated or all", *set)}
+		}
+		if err := e.connect(); err != nil {
+			return err
+		}
+
+		var counts WriteCounts
+		err := e.inTx(ctx, func(tx *sql.Tx) error {
+			tokens, err := loadTokenIDs(ctx, tx)
+			if err != nil {
+				return err
+			}
+			rows, missing := planSymbolMappings(*set, tokens, splitList(*exchangeList))
+			for _, symbol := range missing {
+				e.logger.Warn("Token not found in database, skipping its mappings", zap.String("symbol", symbol))
+			}
+			counts, err = writeSymbolMappings(ctx, tx, rows, e)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write symbol mappings: %w", err)
+		}
+
+		fmt.Fprintf(e.out, "✓ Symbol mappings: %d inserted, %d updated, %d kept\n", counts.Inserted, counts.Updated, counts.Kept)
+		return nil
+	}
+}
+
+// loadTokenIDs maps the symbols of the active chain-agnostic tokens, the rows
+// exchanges' listings map to, to their IDs
+func loadTokenIDs(ctx context.Context, tx *sql.Tx) (map[string]int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, symbol FROM tokens WHERE is_active = true AND chain IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var symbol string
+		if err := rows.Scan(&id, &symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens[strings.ToUpper(symbol)] = id
+	}
+	return tokens, rows.Err()
+}
+
+// sortedSymbols returns the symbols of tokens in order, so generated rows are
+// written the same way every run
+func sortedSymbols(tokens map[string]int) []string {
+	symbols := make([]string, 0, len(tokens))
+	for symbol := range tokens {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// planSymbolMappings lists the mappings of set for the tokens there are,
+// limited to exchanges when any are given, and the curated symbols that have
+// no token
+func planSymbolMappings(set string, tokens map[string]int, exchanges []string) ([]mappingRow, []string) {
+	include := exchangeFilter(exchanges)
+	var rows []mappingRow
+	seen := make(map[string]bool)
+	missing := make(map[string]bool)
+	add := func(m symbolMapping, method string) {
+		key := m.Exchange + ":" + m.Symbol
+		if seen[key] || !include(m.Exchange) {
+			return
+		}
+		id, ok := tokens[m.Token]
+		if !ok {
+			missing[m.Token] = true
+			return
+		}
+		seen[key] = true
+		rows = append(rows, mappingRow{TokenID: id, Exchange: m.Exchange, Symbol: m.Symbol, Token: m.Token, Method: method})
+	}
+
+	for _, m := range curatedSymbols {
+		add(m, methodManual)
+	}
+	if set == "all" {
+		for _, symbol := range sortedSymbols(tokens) {
+			for _, exchange := range defaultExchanges {
+				add(symbolMapping{Token: symbol, Exchange: exchange, Symbol: symbol}, methodSymbol)
+			}
+		}
+	}
+
+	var missingSymbols []string
+	for symbol := range missing {
+		missingSymbols = append(missingSymbols, symbol)
+	}
+	sort.Strings(missingSymbols)
+	return rows, missingSymbols
+}
+
+// exchangeFilter reports whether an exchange is one of exchanges, or any
+// exchange when there are none
+func exchangeFilter(exchanges []string) func(string) bool {
+	if len(exchanges) == 0 {
+		return func(string) bool { return true }
+	}
+	set := make(map[string]bool, len(exchanges))
+	for _, exchange := range exchanges {
+		set[strings.ToLower(exchange)] = true
+	}
+	return func(exchange string) bool { return set[exchange] }
+}
+
+// writeSymbolMappings upserts rows into token_exchange_symbols. Verified
+// mappings are kept, and a symbol guess only fills a gap or replaces another
+// guess.
+func writeSymbolMappings(ctx context.Context, tx *sql.Tx, rows []mappingRow, e *env) (WriteCounts, error) {
+	var counts WriteCounts
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO token_exchange_symbols (
+			token_id, exchange_id, exchange_symbol, normalized_symbol,
+			mapping_method, confidence_score, needs_verification, is_active, chain
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, true, (SELECT chain FROM tokens WHERE id = $1))
+		ON CONFLICT (exchange_id, exchange_symbol) DO UPDATE SET
+			token_id = EXCLUDED.token_id,
+			normalized_symbol = EXCLUDED.normalized_symbol,
+			mapping_method = EXCLUDED.mapping_method,
+			confidence_score = EXCLUDED.confidence_score,
+			needs_verification = EXCLUDED.needs_verification,
+			chain = EXCLUDED.chain,
+			updated_at = NOW()
+		WHERE token_exchange_symbols.verified_at IS NULL
+		  AND (EXCLUDED.mapping_method = 'manual' OR token_exchange_symbols.mapping_method IN ('symbol', 'auto'))
+		RETURNING xmax = 0
+	`)
+	if err != nil {
+		return counts, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		confidence, needsVerification := 1.0, false
+		if r.Method == methodSymbol {
+			confidence, needsVerification = symbolConfidence, true
+		}
+
+		var inserted bool
+		err := stmt.QueryRowContext(ctx, r.TokenID, r.Exchange, r.Symbol, r.Token,
+			r.Method, confidence, needsVerification).Scan(&inserted)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			counts.Kept++
+			continue
+		case err != nil:
+			return counts, fmt.Errorf("failed to write mapping %s on %s: %w", r.Symbol, r.Exchange, err)
+		case inserted:
+			counts.Inserted++
+		default:
+			counts.Updated++
+		}
+		if e.verbose {
+			fmt.Fprintf(e.out, "✓ %s %s -> %s (%s)\n", r.Exchange, r.Symbol, r.Token, r.Method)
+		}
+	}
+	return counts, nil
+}
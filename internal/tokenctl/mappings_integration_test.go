@@ -0,0 +1,74 @@
+//go:build integration
+
+package tokenctl
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func writeMappings(t *testing.T, e *env, set string) WriteCounts {
+	t.Helper()
+	ctx := context.Background()
+	var counts WriteCounts
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		tokens, err := loadTokenIDs(ctx, tx)
+		if err != nil {
+			return err
+		}
+		rows, _ := planSymbolMappings(set, tokens, []string{"kraken"})
+		counts, err = writeSymbolMappings(ctx, tx, rows, e)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("writing %s mappings: %v", set, err)
+	}
+	return counts
+}
+
+func TestWriteSymbolMappings(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ids := testutil.SeedTokens(t, pg, "BTC", "ETH", "PEPE")
+	e := testEnv(pg)
+
+	// Kraken's curated symbols of BTC, ETH and the migrations' USD:
+	// XBT, XXBT, BTC, ETH, XETH, USD, ZUSD
+	if counts := writeMappings(t, e, "curated"); counts != (WriteCounts{Inserted: 7}) {
+		t.Errorf("curated counts = %+v", counts)
+	}
+
+	// A verified mapping is kept, and the other tokens get symbol guesses
+	if _, err := pg.Exec(`UPDATE token_exchange_symbols SET token_id = $1, verified_at = NOW() WHERE exchange_id = 'kraken' AND exchange_symbol = 'XBT'`, ids["PEPE"]); err != nil {
+		t.Fatal(err)
+	}
+	if counts := writeMappings(t, e, "all"); counts.Updated != 6 || counts.Kept != 1 || counts.Inserted == 0 {
+		t.Errorf("all counts = %+v", counts)
+	}
+
+	var tokenID int
+	var method string
+	err := pg.QueryRow(`SELECT token_id, mapping_method FROM token_exchange_symbols WHERE exchange_id = 'kraken' AND exchange_symbol = 'XBT'`).Scan(&tokenID, &method)
+	if err != nil || tokenID != ids["PEPE"] {
+		t.Errorf("verified XBT mapping = token %d, err %v", tokenID, err)
+	}
+	err = pg.QueryRow(`SELECT mapping_method FROM token_exchange_symbols WHERE exchange_id = 'kraken' AND exchange_symbol = 'PEPE'`).Scan(&method)
+	if err != nil || method != methodSymbol {
+		t.Errorf("PEPE mapping method = %q, err %v", method, err)
+	}
+
+	// A dry run writes nothing
+	e.dryRun = true
+	if _, err := pg.Exec(`DELETE FROM token_exchange_symbols`); err != nil {
+		t.Fatal(err)
+	}
+	if counts := writeMappings(t, e, "curated"); counts.Inserted != 7 {
+		t.Errorf("dry run counts = %+v", counts)
+	}
+	var n int
+	if err := pg.QueryRow(`SELECT COUNT(*) FROM token_exchange_symbols`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("%d mappings after a dry run, err %v", n, err)
+	}
+}
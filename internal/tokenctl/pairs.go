@@ -0,0 +1,567 @@
+package tokenctl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// tradingPair is a pair as an exchange lists it
+type tradingPair struct {
+	Base     string
+	Quote    string
+	Exchange string
+	Symbol   string
+}
+
+// curatedPairs are pairs checked by hand against the exchanges
+var curatedPairs = []tradingPair{
+	// BTC pairs
+	{"BTC", "USDT", "binance", "BTCUSDT"},
+	{"BTC", "USDC", "binance", "BTCUSDC"},
+	{"BTC", "USD", "coinbase", "BTC-USD"},
+	{"BTC", "USDT", "coinbase", "BTC-USDT"},
+	{"BTC", "USD", "kraken", "XXBTZUSD"},
+	{"BTC", "USDT", "kraken", "XBTUSDT"},
+	{"BTC", "EUR", "kraken", "XXBTZEUR"},
+	{"BTC", "USDT", "okx", "BTC-USDT"},
+	{"BTC", "USDC", "okx", "BTC-USDC"},
+
+	// ETH pairs
+	{"ETH", "USDT", "binance", "ETHUSDT"},
+	{"ETH", "USDC", "binance", "ETHUSDC"},
+	{"ETH", "BTC", "binance", "ETHBTC"},
+	{"ETH", "USD", "coinbase", "ETH-USD"},
+	{"ETH", "USDT", "coinbase", "ETH-USDT"},
+	{"ETH", "BTC", "coinbase", "ETH-BTC"},
+	{"ETH", "USD", "kraken", "ETHUSD"},
+	{"ETH", "USDT", "kraken", "ETHUSDT"},
+	{"ETH", "BTC", "kraken", "ETHXBT"},
+
+	// Other major pairs
+	{"SOL", "USDT", "binance", "SOLUSDT"},
+	{"SOL", "USD", "coinbase", "SOL-USD"},
+	{"ADA", "USDT", "binance", "ADAUSDT"},
+	{"ADA", "USD", "coinbase", "ADA-USD"},
+	{"DOT", "USDT", "binance", "DOTUSDT"},
+	{"DOT", "USD", "coinbase", "DOT-USD"},
+	{"MATIC", "USDT", "binance", "MATICUSDT"},
+	{"MATIC", "USD", "coinbase", "MATIC-USD"},
+	{"AVAX", "USDT", "binance", "AVAXUSDT"},
+	{"AVAX", "USD", "coinbase", "AVAX-USD"},
+	{"LINK", "USDT", "binance", "LINKUSDT"},
+	{"LINK", "USD", "coinbase", "LINK-USD"},
+	{"UNI", "USDT", "binance", "UNIUSDT"},
+	{"UNI", "USD", "coinbase", "UNI-USD"},
+	{"ATOM", "USDT", "binance", "ATOMUSDT"},
+	{"ATOM", "USD", "coinbase", "ATOM-USD"},
+	{"XRP", "USDT", "binance", "XRPUSDT"},
+	{"XRP", "USD", "coinbase", "XRP-USD"},
+	{"LTC", "USDT", "binance", "LTCUSDT"},
+	{"LTC", "USD", "coinbase", "LTC-USD"},
+	{"DOGE", "USDT", "binance", "DOGEUSDT"},
+	{"DOGE", "USD", "coinbase", "DOGE-USD"},
+}
+
+// commonBases and commonQuotes limit the common set to the major markets
+var (
+	commonBases  = []string{"BTC", "ETH", "SOL", "XRP", "ADA", "DOGE", "AVAX", "BNB"}
+	commonQuotes = []string{"USDT", "USDC", "USD", "BTC", "ETH", "BNB"}
+)
+
+// pairRow is one trading_pairs row to write
+type pairRow struct {
+	tradingPair
+	BaseID  int
+	QuoteID int
+	Method  string
+}
+
+// pairsCommand writes trading pairs between the chain-agnostic tokens in the
+// database. The common and all sets come from the pairs the exchanges' symbol
+// endpoints list, so only pairs that exist are written.
+func pairsCommand(fs *flag.FlagSet) func(context.Context, *env) error {
+	set := fs.String("set", "curated", "Pairs to write: curated (checked by hand), common (curated plus the listed pairs between major bases and quotes) or all (curated plus every listed pair between known tokens)")
+	exchangeList := fs.String("exchanges", "", "Comma-separated exchanges to write pairs for (default all enabled)")
+	exchangeConfig := fs.String("exchange-config", "configs/exchanges.json", "common, all: exchange client configuration")
+	fetchTimeout := fs.Duration("fetch-timeout", 30*time.Second, "common, all: timeout per exchange symbol list")
+	prune := fs.Bool("prune", false, "common, all: deactivate unverified symbol-guessed pairs the fetched exchanges do not list")
+
+	return func(ctx context.Context, e *env) error {
+		if *set != "curated" && *set != "common" && *set != "all" {
+			return usageError{fmt.Errorf("unknown -set %q, want curated, common or all", *set)}
+		}
+		exchangeIDs := splitList(*exchangeList)
+
+		var listings []exchangeListing
+		var configFees map[string]pairFees
+		if *set != "curated" {
+			factory, err := exchanges.NewExchangeFactory(*exchangeConfig, e.logger)
+			if err != nil {
+				return err
+			}
+			if listings, err = fetchListings(ctx, factory, exchangeIDs, *fetchTimeout, e.logger); err != nil {
+				return err
+			}
+			configFees = feesOf(factory.Configs())
+		}
+		if err := e.connect(); err != nil {
+			return err
+		}
+
+		var counts WriteCounts
+		err := e.inTx(ctx, func(tx *sql.Tx) error {
+			tokens, err := loadTokenIDs(ctx, tx)
+			if err != nil {
+				return err
+			}
+			aliases, err := loadExchangeSymbols(ctx, tx)
+			if err != nil {
+				return err
+			}
+			rows, missing := planPairs(*set, tokens, aliases, listings, exchangeIDs)
+			for _, pair := range missing {
+				e.logger.Warn("Tokens not found in database, skipping pair", zap.String("pair", pair))
+			}
+			registered, err := loadRegisteredExchanges(ctx, tx)
+			if err != nil {
+				return err
+			}
+			rows, unregistered := registeredPairs(rows, registered)
+			for exchange, n := range unregistered {
+				e.logger.Warn("Exchange not in the registry, skipping its pairs", zap.String("exchange", exchange), zap.Int("pairs", n))
+			}
+			if counts, err = writePairs(ctx, tx, rows, e); err != nil {
+				return err
+			}
+			if *prune {
+				if counts.Deactivated, err = pruneUnlistedPairs(ctx, tx, listings); err != nil {
+					return err
+				}
+			}
+			if len(listings) == 0 {
+				return nil
+			}
+			fees, err := loadRegistryFees(ctx, tx, configFees)
+			if err != nil {
+				return err
+			}
+			counts.Enriched, err = writePairMetadata(ctx, tx, listings, fees)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write trading pairs: %w", err)
+		}
+
+		fmt.Fprintf(e.out, "✓ Trading pairs: %d inserted, %d updated, %d kept\n", counts.Inserted, counts.Updated, counts.Kept)
+		if *prune {
+			fmt.Fprintf(e.out, "✓ Deactivated %d unlisted pairs\n", counts.Deactivated)
+		}
+		if len(listings) > 0 {
+			fmt.Fprintf(e.out, "✓ Pair metadata: %d pairs updated\n", counts.Enriched)
+		}
+		return nil
+	}
+}
+
+// exchangeListing is the pairs one exchange's symbol endpoint lists
+type exchangeListing struct {
+	exchange string
+	symbols  []exchanges.ExchangeSymbol
+}
+
+// fetchListings gets the symbol lists of the given exchanges, or every enabled
+// one, concurrently. An exchange whose list cannot be fetched is left out
+// with a warning, so one outage does not stop the others' pairs.
+func fetchListings(ctx context.Context, factory *exchanges.ExchangeFactory, exchangeIDs []string, timeout time.Duration, logger *zap.Logger) ([]exchangeListing, error) {
+	clients := factory.CreateAllClients()
+	if len(exchangeIDs) == 0 {
+		for id := range clients {
+			exchangeIDs = append(exchangeIDs, id)
+		}
+	}
+	sort.Strings(exchangeIDs)
+
+	selected := make([]exchanges.ExchangeClient, len(exchangeIDs))
+	for i, id := range exchangeIDs {
+		client, ok := clients[strings.ToLower(id)]
+		if !ok {
+			return nil, usageError{fmt.Errorf("%w: %s (or disabled)", exchanges.ErrUnknownExchange, id)}
+		}
+		selected[i] = client
+	}
+
+	results := make([]exchangeListing, len(selected))
+	var wg sync.WaitGroup
+	for i, client := range selected {
+		wg.Add(1)
+		go func(i int, client exchanges.ExchangeClient) {
+			defer wg.Done()
+			fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			symbols, err := client.GetSymbols(fetchCtx)
+			if err != nil {
+				logger.Warn("Failed to fetch exchange symbols, skipping exchange", zap.String("exchange", client.GetID()), zap.Error(err))
+				return
+			}
+			logger.Info("Fetched exchange symbols", zap.String("exchange", client.GetID()), zap.Int("symbols", len(symbols)))
+			results[i] = exchangeListing{exchange: client.GetID(), symbols: symbols}
+		}(i, client)
+	}
+	wg.Wait()
+
+	listings := results[:0]
+	for _, listing := range results {
+		if listing.exchange != "" {
+			listings = append(listings, listing)
+		}
+	}
+	if len(listings) == 0 {
+		return nil, errors.New("no exchange symbol list could be fetched")
+	}
+	return listings, nil
+}
+
+// tokenRef is the token an exchange symbol is mapped to
+type tokenRef struct {
+	ID     int
+	Symbol string
+}
+
+// loadExchangeSymbols loads the active symbol mappings by exchange and
+// exchange symbol, so listings written with an exchange's own aliases (XXBT,
+// ZUSD) resolve to their tokens
+func loadExchangeSymbols(ctx context.Context, tx *sql.Tx) (map[string]map[string]tokenRef, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT tes.exchange_id, tes.exchange_symbol, t.id, t.symbol
+		FROM token_exchange_symbols tes
+		JOIN tokens t ON t.id = tes.token_id
+		WHERE tes.is_active = true AND t.is_active = true AND t.chain IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query symbol mappings: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := make(map[string]map[string]tokenRef)
+	for rows.Next() {
+		var exchange, symbol string
+		var ref tokenRef
+		if err := rows.Scan(&exchange, &symbol, &ref.ID, &ref.Symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol mapping: %w", err)
+		}
+		if aliases[exchange] == nil {
+			aliases[exchange] = make(map[string]tokenRef)
+		}
+		aliases[exchange][strings.ToUpper(symbol)] = tokenRef{ID: ref.ID, Symbol: strings.ToUpper(ref.Symbol)}
+	}
+	return aliases, rows.Err()
+}
+
+// planPairs lists the curated pairs of the exchanges given, or all, and for
+// the common and all sets the listed pairs between the tokens there are. A
+// listed symbol resolves through the exchange's symbol mappings first and the
+// token of the same symbol second. It also returns the curated pairs missing
+// a token.
+func planPairs(set string, tokens map[string]int, aliases map[string]map[string]tokenRef, listings []exchangeListing, exchangeIDs []string) ([]pairRow, []string) {
+	include := exchangeFilter(exchangeIDs)
+	var rows []pairRow
+	seen := make(map[string]bool)
+	var missing []string
+
+	for _, p := range curatedPairs {
+		key := p.Exchange + ":" + p.Symbol
+		if seen[key] || !include(p.Exchange) {
+			continue
+		}
+		baseID, baseOK := tokens[p.Base]
+		quoteID, quoteOK := tokens[p.Quote]
+		if !baseOK || !quoteOK {
+			missing = append(missing, key)
+			continue
+		}
+		seen[key] = true
+		rows = append(rows, pairRow{tradingPair: p, BaseID: baseID, QuoteID: quoteID, Method: methodManual})
+	}
+	if set == "curated" {
+		sort.Strings(missing)
+		return rows, missing
+	}
+
+	resolve := func(exchange, symbol string) (tokenRef, bool) {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if ref, ok := aliases[exchange][symbol]; ok {
+			return ref, true
+		}
+		id, ok := tokens[symbol]
+		return tokenRef{ID: id, Symbol: symbol}, ok
+	}
+	commonBase, commonQuote := stringSet(commonBases), stringSet(commonQuotes)
+	for _, listing := range listings {
+		for _, s := range listing.symbols {
+			key := listing.exchange + ":" + s.Symbol
+			if seen[key] || !s.IsActive || s.Symbol == "" {
+				continue
+			}
+			base, baseOK := resolve(listing.exchange, s.BaseSymbol)
+			quote, quoteOK := resolve(listing.exchange, s.QuoteSymbol)
+			if !baseOK || !quoteOK || base.ID == quote.ID {
+				continue
+			}
+			if set == "common" && (!commonBase[base.Symbol] || !commonQuote[quote.Symbol]) {
+				continue
+			}
+			seen[key] = true
+			rows = append(rows, pairRow{
+				tradingPair: tradingPair{Base: base.Symbol, Quote: quote.Symbol, Exchange: listing.exchange, Symbol: s.Symbol},
+				BaseID:      base.ID,
+				QuoteID:     quote.ID,
+				Method:      methodSymbol,
+			})
+		}
+	}
+
+	sort.Strings(missing)
+	return rows, missing
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// pruneUnlistedPairs deactivates the unverified symbol-guessed pairs of the
+// listed exchanges that their listings no longer have, such as pairs earlier
+// versions of this tool made up. Pairs the mapper or a person wrote are kept.
+func pruneUnlistedPairs(ctx context.Context, tx *sql.Tx, listings []exchangeListing) (int, error) {
+	deactivated := 0
+	for _, listing := range listings {
+		symbols := make([]string, 0, len(listing.symbols))
+		for _, s := range listing.symbols {
+			if s.IsActive {
+				symbols = append(symbols, s.Symbol)
+			}
+		}
+		if len(symbols) == 0 {
+			// An empty list is more likely a parsing gap than a delisting
+			continue
+		}
+		result, err := tx.ExecContext(ctx, `
+			UPDATE trading_pairs
+			SET is_active = false, updated_at = NOW()
+			WHERE exchange_id = $1
+			  AND is_active = true
+			  AND mapping_method IN ('symbol', 'auto')
+			  AND verified_at IS NULL
+			  AND NOT (exchange_pair_symbol = ANY($2))
+		`, listing.exchange, pq.Array(symbols))
+		if err != nil {
+			return deactivated, fmt.Errorf("failed to prune %s pairs: %w", listing.exchange, err)
+		}
+		n, _ := result.RowsAffected()
+		deactivated += int(n)
+	}
+	return deactivated, nil
+}
+
+// loadRegisteredExchanges returns the IDs of the exchanges in the registry,
+// the only ones trading pairs may be on
+func loadRegisteredExchanges(ctx context.Context, tx *sql.Tx) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT exchange_id FROM exchanges`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchanges: %w", err)
+	}
+	defer rows.Close()
+	registered := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange: %w", err)
+		}
+		registered[id] = true
+	}
+	return registered, rows.Err()
+}
+
+// registeredPairs drops the rows of exchanges not in the registry and returns
+// the rest with how many were dropped per exchange
+func registeredPairs(rows []pairRow, registered map[string]bool) ([]pairRow, map[string]int) {
+	kept := rows[:0]
+	dropped := make(map[string]int)
+	for _, r := range rows {
+		if registered[r.Exchange] {
+			kept = append(kept, r)
+		} else {
+			dropped[r.Exchange]++
+		}
+	}
+	return kept, dropped
+}
+
+// writePairs upserts rows into trading_pairs, keeping verified pairs and
+// letting a generated pair replace only another generated one
+func writePairs(ctx context.Context, tx *sql.Tx, rows []pairRow, e *env) (WriteCounts, error) {
+	var counts WriteCounts
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO trading_pairs (
+			base_token_id, quote_token_id, exchange_id, exchange_pair_symbol,
+			mapping_method, confidence_score, needs_verification, is_active
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, true)
+		ON CONFLICT (exchange_id, exchange_pair_symbol) DO UPDATE SET
+			base_token_id = EXCLUDED.base_token_id,
+			quote_token_id = EXCLUDED.quote_token_id,
+			mapping_method = EXCLUDED.mapping_method,
+			confidence_score = EXCLUDED.confidence_score,
+			needs_verification = EXCLUDED.needs_verification,
+			updated_at = NOW()
+		WHERE trading_pairs.verified_at IS NULL
+		  AND (EXCLUDED.mapping_method = 'manual' OR trading_pairs.mapping_method IN ('symbol', 'auto'))
+		RETURNING xmax = 0
+	`)
+	if err != nil {
+		return counts, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		confidence, needsVerification := 1.0, false
+		if r.Method == methodSymbol {
+			confidence, needsVerification = symbolConfidence, true
+		}
+
+		var inserted bool
+		err := stmt.QueryRowContext(ctx, r.BaseID, r.QuoteID, r.Exchange, r.Symbol,
+			r.Method, confidence, needsVerification).Scan(&inserted)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			counts.Kept++
+			continue
+		case err != nil:
+			return counts, fmt.Errorf("failed to write pair %s on %s: %w", r.Symbol, r.Exchange, err)
+		case inserted:
+			counts.Inserted++
+		default:
+			counts.Updated++
+		}
+		if e.verbose {
+			fmt.Fprintf(e.out, "✓ %s %s -> %s/%s (%s)\n", r.Exchange, r.Symbol, r.Base, r.Quote, r.Method)
+		}
+	}
+	return counts, nil
+}
+
+// pairFees are an exchange's base tier fees as fractions, zero when unknown
+type pairFees struct {
+	Taker float64
+	Maker float64
+}
+
+func feesOf(configs []exchanges.ExchangeConfig) map[string]pairFees {
+	fees := make(map[string]pairFees, len(configs))
+	for _, c := range configs {
+		fees[c.ID] = pairFees{Taker: c.TakerFee, Maker: c.MakerFee}
+	}
+	return fees
+}
+
+// loadRegistryFees returns the fees of the exchange registry, which the REST
+// app fills from the same config file and which can be edited through its
+// API, over the fees of the config file
+func loadRegistryFees(ctx context.Context, tx *sql.Tx, configFees map[string]pairFees) (map[string]pairFees, error) {
+	fees := make(map[string]pairFees, len(configFees))
+	for id, f := range configFees {
+		fees[id] = f
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT exchange_id, COALESCE(taker_fee, 0), COALESCE(maker_fee, 0) FROM exchanges`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange fees: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var f pairFees
+		if err := rows.Scan(&id, &f.Taker, &f.Maker); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange fees: %w", err)
+		}
+		if f.Taker > 0 || f.Maker > 0 {
+			fees[id] = f
+		}
+	}
+	return fees, rows.Err()
+}
+
+// writePairMetadata stores the order rules and fees the listings give on the
+// pairs of the listed symbols, whoever wrote the pair, falling back to the
+// exchange's base fees for listings without fees. Rules a listing leaves out
+// are kept.
+func writePairMetadata(ctx context.Context, tx *sql.Tx, listings []exchangeListing, fees map[string]pairFees) (int, error) {
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE trading_pairs SET
+			tick_size = COALESCE(NULLIF($3, '')::numeric, tick_size),
+			step_size = COALESCE(NULLIF($4, '')::numeric, step_size),
+			min_quantity = COALESCE(NULLIF($5, '')::numeric, min_quantity),
+			min_notional = COALESCE(NULLIF($6, '')::numeric, min_notional),
+			taker_fee = COALESCE(NULLIF($7, '')::numeric, taker_fee),
+			maker_fee = COALESCE(NULLIF($8, '')::numeric, maker_fee),
+			metadata_updated_at = NOW()
+		WHERE exchange_id = $1 AND exchange_pair_symbol = $2
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare metadata statement: %w", err)
+	}
+	defer stmt.Close()
+
+	updated := 0
+	for _, listing := range listings {
+		base := fees[listing.exchange]
+		for _, s := range listing.symbols {
+			if !s.IsActive || s.Symbol == "" {
+				continue
+			}
+			taker, maker := s.TakerFee, s.MakerFee
+			if taker == "" && base.Taker > 0 {
+				taker = strconv.FormatFloat(base.Taker, 'f', -1, 64)
+			}
+			if maker == "" && base.Maker > 0 {
+				maker = strconv.FormatFloat(base.Maker, 'f', -1, 64)
+			}
+			result, err := stmt.ExecContext(ctx, listing.exchange, s.Symbol,
+				validDecimal(s.TickSize), validDecimal(s.StepSize), validDecimal(s.MinQuantity),
+				validDecimal(s.MinNotional), validDecimal(taker), validDecimal(maker))
+			if err != nil {
+				return updated, fmt.Errorf("failed to write metadata of %s on %s: %w", s.Symbol, listing.exchange, err)
+			}
+			n, _ := result.RowsAffected()
+			updated += int(n)
+		}
+	}
+	return updated, nil
+}
+
+// validDecimal returns s when it is a non-negative decimal and "" otherwise,
+// so one malformed field does not fail the whole run
+func validDecimal(s string) string {
+	d, err := decimal.NewFromString(strings.TrimSpace(s))
+	if err != nil || d.IsNegative() {
+		return ""
+	}
+	return d.String()
+}
@@ -0,0 +1,69 @@
+//go:build integration
+
+package tokenctl
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/exchanges"
+	"github.com/ashmitsharp/trading/internal/testutil"
+)
+
+func TestWritePairMetadata(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ctx := context.Background()
+	ids := testutil.SeedTokens(t, pg, "BTC", "USDT")
+	if _, err := pg.Exec(`
+		INSERT INTO exchanges (exchange_id, name, base_url, ticker_endpoint, taker_fee, maker_fee)
+		VALUES ('binance', 'Binance', 'https://api.binance.com', '/api/v3/ticker/24hr', 0.00075, 0.00075)
+	`); err != nil {
+		t.Fatal(err)
+	}
+	testutil.SeedTradingPair(t, pg, ids["BTC"], ids["USDT"], "binance", "BTCUSDT")
+	testutil.SeedTradingPair(t, pg, ids["BTC"], ids["USDT"], "kraken", "XBTUSDT")
+
+	listings := []exchangeListing{
+		{exchange: "binance", symbols: []exchanges.ExchangeSymbol{
+			{Symbol: "BTCUSDT", IsActive: true, TickSize: "0.01000000", StepSize: "0.00001000", MinQuantity: "0.00001000", MinNotional: "5"},
+			{Symbol: "ETHUSDT", IsActive: true, TickSize: "0.01"},
+		}},
+		{exchange: "kraken", symbols: []exchanges.ExchangeSymbol{
+			{Symbol: "XBTUSDT", IsActive: true, TickSize: "bad", MinQuantity: "0.0001", TakerFee: "0.0026", MakerFee: "0.0016"},
+		}},
+	}
+	e := testEnv(pg)
+	var updated int
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		fees, err := loadRegistryFees(ctx, tx, map[string]pairFees{"binance": {Taker: 0.001, Maker: 0.001}})
+		if err != nil {
+			return err
+		}
+		updated, err = writePairMetadata(ctx, tx, listings, fees)
+		return err
+	})
+	if err != nil || updated != 2 {
+		t.Fatalf("writePairMetadata: updated %d, err %v", updated, err)
+	}
+
+	// The registry's fees win over the config file's
+	binance, err := db.GetTradingPair(ctx, pg, "binance", "btcusdt")
+	if err != nil {
+		t.Fatalf("GetTradingPair: %v", err)
+	}
+	if binance.TickSize.Decimal.String() != "0.01" || binance.MinNotional.Decimal.String() != "5" ||
+		binance.TakerFee.Decimal.String() != "0.00075" || binance.MetadataUpdatedAt.IsZero() {
+		t.Errorf("binance BTCUSDT = %+v", binance)
+	}
+
+	// A malformed field is left unset rather than failing the run
+	kraken, err := db.GetTradingPair(ctx, pg, "kraken", "XBTUSDT")
+	if err != nil {
+		t.Fatalf("GetTradingPair: %v", err)
+	}
+	if kraken.TickSize.Valid || kraken.MakerFee.Decimal.String() != "0.0016" {
+		t.Errorf("kraken XBTUSDT = %+v", kraken)
+	}
+}
@@ -0,0 +1,108 @@
+package tokenctl
+
+import (
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/exchanges"
+)
+
+func TestPlanSymbolMappings(t *testing.T) {
+	tokens := map[string]int{"BTC": 1, "PEPE": 2}
+
+	rows, missing := planSymbolMappings("curated", tokens, []string{"Kraken"})
+	if len(rows) != 3 || rows[0].Symbol != "XBT" || rows[0].TokenID != 1 || rows[0].Method != methodManual {
+		t.Errorf("curated kraken rows = %+v", rows)
+	}
+	if len(missing) == 0 || missing[0] != "ADA" {
+		t.Errorf("missing = %v", missing)
+	}
+
+	// Every token gets a guess under its own symbol unless curated has it
+	rows, _ = planSymbolMappings("all", tokens, nil)
+	guesses := make(map[string]string)
+	for _, r := range rows {
+		if r.Method == methodSymbol {
+			guesses[r.Exchange+":"+r.Symbol] = r.Token
+		}
+	}
+	if len(guesses) != len(defaultExchanges) || guesses["kraken:PEPE"] != "PEPE" {
+		t.Errorf("guesses = %v", guesses)
+	}
+}
+
+func TestPlanPairs(t *testing.T) {
+	tokens := map[string]int{"BTC": 1, "ETH": 2, "USDT": 3, "PEPE": 4, "USD": 5}
+	aliases := map[string]map[string]tokenRef{
+		"kraken": {"XXBT": {ID: 1, Symbol: "BTC"}, "ZUSD": {ID: 5, Symbol: "USD"}},
+	}
+	listings := []exchangeListing{
+		{exchange: "kraken", symbols: []exchanges.ExchangeSymbol{
+			{Symbol: "XXBTZUSD", BaseSymbol: "XXBT", QuoteSymbol: "ZUSD", IsActive: true},
+			{Symbol: "PEPEUSD", BaseSymbol: "PEPE", QuoteSymbol: "USD", IsActive: true},
+			{Symbol: "FOOUSD", BaseSymbol: "FOO", QuoteSymbol: "USD", IsActive: true},
+		}},
+		{exchange: "okx", symbols: []exchanges.ExchangeSymbol{
+			{Symbol: "PEPE-USDT", BaseSymbol: "PEPE", QuoteSymbol: "USDT", IsActive: true},
+			{Symbol: "ETH-BTC", BaseSymbol: "ETH", QuoteSymbol: "BTC", IsActive: true},
+			{Symbol: "ETH-USDT", BaseSymbol: "ETH", QuoteSymbol: "USDT", IsActive: false},
+		}},
+	}
+
+	rows, missing := planPairs("curated", tokens, aliases, listings, []string{"kraken"})
+	if len(rows) != 5 || len(missing) != 1 || missing[0] != "kraken:XXBTZEUR" {
+		t.Errorf("curated kraken rows = %+v, missing %v", rows, missing)
+	}
+
+	bySymbol := func(rows []pairRow) map[string]pairRow {
+		m := make(map[string]pairRow)
+		for _, r := range rows {
+			m[r.Exchange+":"+r.Symbol] = r
+		}
+		return m
+	}
+
+	// Only listed pairs are added, and curated ones keep their method
+	all := bySymbol(func() []pairRow { rows, _ := planPairs("all", tokens, aliases, listings, nil); return rows }())
+	if r := all["kraken:XXBTZUSD"]; r.Method != methodManual {
+		t.Errorf("kraken XXBTZUSD = %+v, want the curated pair", r)
+	}
+	if r, ok := all["okx:PEPE-USDT"]; !ok || r.BaseID != 4 || r.QuoteID != 3 || r.Method != methodSymbol {
+		t.Errorf("okx PEPE-USDT = %+v", r)
+	}
+	if _, ok := all["kraken:FOOUSD"]; ok {
+		t.Error("pair of an unknown token planned")
+	}
+	if _, ok := all["okx:ETH-USDT"]; ok {
+		t.Error("inactive listing planned")
+	}
+	if _, ok := all["okx:BTC-ETH"]; ok {
+		t.Error("pair planned that no exchange lists")
+	}
+
+	common := bySymbol(func() []pairRow { rows, _ := planPairs("common", tokens, aliases, listings, nil); return rows }())
+	if _, ok := common["okx:ETH-BTC"]; !ok {
+		t.Error("common set is missing ETH-BTC")
+	}
+	if _, ok := common["okx:PEPE-USDT"]; ok {
+		t.Error("common set has PEPE")
+	}
+}
+
+func TestMainUsage(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"unknown"},
+		{"map", "-set", "everything"},
+		{"pairs", "-no-such-flag"},
+		{"seed", "-source", "json"},
+		{"export", "-format", "xml"},
+		{"import"},
+	} {
+		if code := Main(args); code != 2 {
+			t.Errorf("Main(%q) = %d, want 2", args, code)
+		}
+	}
+	if code := Main([]string{"verify", "-h"}); code != 0 {
+		t.Errorf("verify -h = %d, want 0", code)
+	}
+}
@@ -0,0 +1,390 @@
+package tokenctl
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// TokenMetadata represents the structure of your JSON data
+type TokenMetadata struct {
+	Name                string     `json:"name"`
+	Symbol              string     `json:"symbol"`
+	Slug                string     `json:"slug"`
+	CirculatingSupply   float64    `json:"circulatingSupply"`
+	TotalSupply         float64    `json:"totalSupply"`
+	MaxSupply           *float64   `json:"maxSupply"` // Pointer to handle null values
+	IsInfiniteMaxSupply int        `json:"isInfiniteMaxSupply"`
+	URLs                TokenURLs  `json:"urls"`
+	Contracts           []Contract `json:"contracts"`
+	CoinGeckoID         string     `json:"coingeckoId"`
+}
+
+type TokenURLs struct {
+	Website      []string `json:"website"`
+	TechnicalDoc []string `json:"technical_doc"`
+	Explorer     []string `json:"explorer"`
+	SourceCode   []string `json:"source_code"`
+	Reddit       []string `json:"reddit"`
+	Chat         []string `json:"chat"`
+	Announcement []string `json:"announcement"`
+	Twitter      []string `json:"twitter"`
+}
+
+type Contract struct {
+	No               int      `json:"no"`
+	ContractAddress  string   `json:"contractAddress"`
+	ContractPlatform string   `json:"contractPlatform"`
+	ContractRpcURL   []string `json:"contractRpcUrl"`
+}
+
+// seedCommand streams tokens from a file or an API into the tokens table
+func seedCommand(fs *flag.FlagSet) func(context.Context, *env) error {
+	batchSize := fs.Int("batch-size", 500, "Tokens upserted per statement")
+	kind := fs.String("source", "json", "Input: json or csv (file argument), coingecko or cmc (API pull)")
+	fieldMap := fs.String("map", "", "csv: field=column pairs for headers that differ from the field names, e.g. symbol=Ticker,name=Coin")
+	limit := fs.Int("limit", 1000, "coingecko, cmc: tokens to pull, by market cap rank")
+	apiKey := fs.String("api-key", "", "coingecko, cmc: API key (default COINGECKO_API_KEY or CMC_API_KEY)")
+
+	return func(ctx context.Context, e *env) error {
+		source, err := newTokenSource(*kind, fs.Arg(0), sourceOptions{
+			FieldMap: *fieldMap,
+			Limit:    *limit,
+			APIKey:   *apiKey,
+		})
+		if err != nil {
+			return usageError{err}
+		}
+		if err := e.connect(); err != nil {
+			return err
+		}
+
+		// Stream the source into the database
+		e.logger.Info("Seeding tokens", zap.String("source", source.Name()))
+		var summary SeedSummary
+		err = e.inTx(ctx, func(tx *sql.Tx) error {
+			summary, err = seedTokens(ctx, tx, source, *batchSize, e.verbose)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seed tokens: %w", err)
+		}
+
+		fmt.Fprintf(e.out, "✓ Inserted: %d new tokens, Updated: %d existing tokens\n", summary.Inserted, summary.Updated)
+		if summary.Duplicates > 0 {
+			fmt.Fprintf(e.out, "  %d tokens repeated a symbol listed earlier in the input and replaced it\n", summary.Duplicates)
+		}
+		fmt.Fprintf(e.out, "✓ Contracts: %d recorded in token_contracts\n", summary.Contracts)
+		fmt.Fprintf(e.out, "Processed %d tokens in %d batches\n", summary.Processed, summary.Batches)
+		return nil
+	}
+}
+
+// maxBatchSize keeps a batch's upsert under PostgreSQL's 65535 parameters
+const maxBatchSize = 5000
+
+// maxContractRows is how many token_contracts rows one insert writes
+const maxContractRows = 5000
+
+// SeedSummary counts what seeding a file did
+type SeedSummary struct {
+	Processed  int // tokens read from the file
+	Inserted   int
+	Updated    int
+	Duplicates int // tokens whose symbol an earlier token of the file had
+	Contracts  int // token_contracts rows written
+	Batches    int
+}
+
+// seedTokens upserts the tokens of a source within tx
+func seedTokens(ctx context.Context, tx *sql.Tx, source TokenSource, batchSize int, verbose bool) (SeedSummary, error) {
+	var summary SeedSummary
+	if batchSize < 1 || batchSize > maxBatchSize {
+		return summary, usageError{fmt.Errorf("batch size must be between 1 and %d", maxBatchSize)}
+	}
+
+	// Symbols written by earlier batches. A later token with the same symbol
+	// replaces the earlier one, as it would row by row.
+	written := make(map[string]bool)
+	// Primary contracts given to a token so far, so no two get the same one
+	claimed := make(map[string]string)
+	err := source.Read(ctx, batchSize, func(batch []TokenMetadata) error {
+		summary.Processed += len(batch)
+		batch, dropped := dedupeTokens(batch)
+		summary.Duplicates += dropped
+
+		contracts := make([]tokenContracts, len(batch))
+		for i, token := range batch {
+			contracts[i] = normalizeContracts(token, claimed)
+		}
+
+		results, err := upsertTokens(tx, batch, contracts, verbose)
+		if err != nil {
+			return fmt.Errorf("error upserting batch %d: %w", summary.Batches+1, err)
+		}
+		summary.Batches++
+		for symbol, r := range results {
+			switch {
+			case written[symbol]:
+				summary.Duplicates++
+			case r.inserted:
+				summary.Inserted++
+			default:
+				summary.Updated++
+			}
+			written[symbol] = true
+		}
+
+		n, err := replaceContracts(tx, batch, contracts, results)
+		if err != nil {
+			return fmt.Errorf("error writing contracts of batch %d: %w", summary.Batches, err)
+		}
+		summary.Contracts += n
+		return nil
+	})
+	if err != nil {
+		return SeedSummary{}, err
+	}
+	return summary, nil
+}
+
+// dedupeTokens keeps the last token of each symbol in a batch, since one
+// upsert statement cannot touch the same row twice
+func dedupeTokens(batch []TokenMetadata) ([]TokenMetadata, int) {
+	last := make(map[string]int, len(batch))
+	for i, token := range batch {
+		last[token.Symbol] = i
+	}
+	if len(last) == len(batch) {
+		return batch, 0
+	}
+	deduped := make([]TokenMetadata, 0, len(last))
+	for i, token := range batch {
+		if last[token.Symbol] == i {
+			deduped = append(deduped, token)
+		}
+	}
+	return deduped, len(batch) - len(deduped)
+}
+
+// upsertResult is the row a token was written to
+type upsertResult struct {
+	id       int
+	inserted bool
+}
+
+// upsertTokens writes a batch of tokens with one statement and reports, by
+// symbol, the row each was written to. A token is matched to its symbol's row
+// on its primary contract, or else its symbol's chain-agnostic row. A token
+// whose contracts are all on one chain takes that chain and its primary
+// contract address, unless another symbol's row has them; others keep the
+// chain and contract they have.
+func upsertTokens(tx *sql.Tx, batch []TokenMetadata, contracts []tokenContracts, verbose bool) (map[string]upsertResult, error) {
+	const columns = 10
+	var values strings.Builder
+	args := make([]interface{}, 0, len(batch)*columns)
+	for i, token := range batch {
+		// Create comprehensive metadata that includes ALL information
+		metadataJSON, err := json.Marshal(createTokenMetadata(token))
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling metadata for %s: %w", token.Symbol, err)
+		}
+
+		var maxSupply *float64
+		if token.MaxSupply != nil && token.IsInfiniteMaxSupply == 0 {
+			maxSupply = token.MaxSupply
+		}
+
+		if i > 0 {
+			values.WriteString(", ")
+		}
+		n := i * columns
+		fmt.Fprintf(&values, "($%d::text, $%d::text, $%d::text, $%d::text, $%d::text, $%d::text, $%d::numeric, $%d::numeric, $%d::numeric, $%d::jsonb)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10)
+		c := contracts[i]
+		args = append(args, token.Symbol, token.Name,
+			nullString(c.primary.Chain), nullString(c.primary.Address),
+			nullString(c.chain), nullString(c.address),
+			token.CirculatingSupply, token.TotalSupply, maxSupply, string(metadataJSON))
+	}
+
+	rows, err := tx.Query(`
+		WITH input (symbol, name, lookup_chain, lookup_address, chain, contract_address,
+		            circulating_supply, total_supply, max_supply, metadata) AS (
+			VALUES `+values.String()+`
+		),
+		matched AS (
+			SELECT i.symbol, i.name, i.circulating_supply, i.total_supply, i.max_supply, i.metadata,
+			       CASE WHEN taken THEN NULL ELSE i.chain END AS chain,
+			       CASE WHEN taken THEN NULL ELSE i.contract_address END AS contract_address,
+			       (
+			           SELECT t.id FROM tokens t
+			           WHERE t.symbol = i.symbol
+			             AND (t.chain IS NULL OR (t.chain = i.lookup_chain AND t.contract_address = i.lookup_address))
+			           ORDER BY t.chain IS NULL, t.id
+			           LIMIT 1
+			       ) AS id
+			FROM input i
+			-- A contract another symbol's row already has is left off this one
+			CROSS JOIN LATERAL (
+				SELECT EXISTS (
+					SELECT 1 FROM tokens o
+					WHERE o.chain = i.chain AND o.contract_address = i.contract_address AND o.symbol <> i.symbol
+				) AS taken
+			) c
+		),
+		updated AS (
+			UPDATE tokens t SET
+				name = m.name,
+				chain = COALESCE(m.chain, t.chain),
+				contract_address = COALESCE(m.contract_address, t.contract_address),
+				circulating_supply = m.circulating_supply,
+				total_supply = m.total_supply,
+				max_supply = m.max_supply,
+				metadata = COALESCE(t.metadata, '{}') || m.metadata,
+				updated_at = NOW()
+			FROM matched m
+			WHERE t.id = m.id
+			RETURNING m.symbol, t.id, false AS inserted
+		),
+		inserted AS (
+			INSERT INTO tokens (
+				symbol, name, contract_address, chain,
+				circulating_supply, total_supply, max_supply,
+				metadata, is_active
+			)
+			SELECT symbol, name, contract_address, chain,
+			       circulating_supply, total_supply, max_supply,
+			       metadata, true
+			FROM matched
+			WHERE id IS NULL
+			RETURNING symbol, id, true AS inserted
+		)
+		SELECT symbol, id, inserted FROM updated
+		UNION ALL
+		SELECT symbol, id, inserted FROM inserted
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing token upsert: %w", err)
+	}
+	defer rows.Close()
+
+	results := make(map[string]upsertResult, len(batch))
+	for rows.Next() {
+		var symbol string
+		var r upsertResult
+		if err := rows.Scan(&symbol, &r.id, &r.inserted); err != nil {
+			return nil, fmt.Errorf("error scanning upsert result: %w", err)
+		}
+		results[symbol] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error executing token upsert: %w", err)
+	}
+
+	if verbose {
+		for _, token := range batch {
+			printToken(token, results[token.Symbol].inserted)
+		}
+	}
+	return results, nil
+}
+
+func printToken(token TokenMetadata, inserted bool) {
+	fmt.Printf("✓ %s: %s (%s) - %d contracts, %d URLs\n",
+		map[bool]string{true: "Inserted", false: "Updated"}[inserted],
+		token.Name,
+		token.Symbol,
+		len(token.Contracts),
+		countURLs(token.URLs))
+
+	// Log contract summary
+	if len(token.Contracts) > 0 {
+		fmt.Printf("  Contracts: ")
+		for i, contract := range token.Contracts {
+			if i > 0 {
+				fmt.Printf(", ")
+			}
+			fmt.Printf("%s", contract.ContractPlatform)
+		}
+		fmt.Println()
+	}
+}
+
+func createTokenMetadata(token TokenMetadata) map[string]interface{} {
+	metadata := make(map[string]interface{})
+
+	// Add all URLs to metadata
+	urls := make(map[string]interface{})
+	if len(token.URLs.Website) > 0 {
+		urls["website"] = token.URLs.Website
+	}
+	if len(token.URLs.TechnicalDoc) > 0 {
+		urls["technical_doc"] = token.URLs.TechnicalDoc
+	}
+	if len(token.URLs.Explorer) > 0 {
+		urls["explorer"] = token.URLs.Explorer
+	}
+	if len(token.URLs.SourceCode) > 0 {
+		urls["source_code"] = token.URLs.SourceCode
+	}
+	if len(token.URLs.Reddit) > 0 {
+		urls["reddit"] = token.URLs.Reddit
+	}
+	if len(token.URLs.Chat) > 0 {
+		urls["chat"] = token.URLs.Chat
+	}
+	if len(token.URLs.Announcement) > 0 {
+		urls["announcement"] = token.URLs.Announcement
+	}
+	if len(token.URLs.Twitter) > 0 {
+		urls["twitter"] = token.URLs.Twitter
+	}
+
+	if len(urls) > 0 {
+		metadata["urls"] = urls
+	}
+
+	// Add all contracts to metadata
+	if len(token.Contracts) > 0 {
+		contracts := make([]map[string]interface{}, len(token.Contracts))
+		for i, contract := range token.Contracts {
+			contracts[i] = map[string]interface{}{
+				"contract_address": contract.ContractAddress,
+				"platform":         contract.ContractPlatform,
+				"rpc_urls":         contract.ContractRpcURL,
+				"number":           contract.No,
+			}
+		}
+		metadata["contracts"] = contracts
+	}
+
+	// Add other token metadata
+	if token.Slug != "" {
+		metadata["slug"] = token.Slug
+	}
+	if token.CoinGeckoID != "" {
+		metadata["coingecko_id"] = token.CoinGeckoID
+	}
+	metadata["is_infinite_max_supply"] = token.IsInfiniteMaxSupply == 1
+
+	return metadata
+}
+
+func countURLs(urls TokenURLs) int {
+	count := 0
+	count += len(urls.Website)
+	count += len(urls.TechnicalDoc)
+	count += len(urls.Explorer)
+	count += len(urls.SourceCode)
+	count += len(urls.Reddit)
+	count += len(urls.Chat)
+	count += len(urls.Announcement)
+	count += len(urls.Twitter)
+	return count
+}
@@ -0,0 +1,136 @@
+//go:build integration
+
+package tokenctl
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+	"go.uber.org/zap"
+)
+
+// testEnv is an env on pg that prints nothing
+func testEnv(pg *sql.DB) *env {
+	return &env{db: pg, logger: zap.NewNop(), out: io.Discard}
+}
+
+// seed seeds source into pg in a committed transaction
+func seed(pg *sql.DB, source TokenSource, batchSize int) (SeedSummary, error) {
+	var summary SeedSummary
+	err := testEnv(pg).inTx(context.Background(), func(tx *sql.Tx) error {
+		var err error
+		summary, err = seedTokens(context.Background(), tx, source, batchSize, false)
+		return err
+	})
+	return summary, err
+}
+
+func TestSeedTokens(t *testing.T) {
+	pg := testutil.Postgres(t)
+	testutil.SeedTokens(t, pg, "BTC")
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	err := os.WriteFile(path, []byte(`[
+		{"name": "Bitcoin", "symbol": "BTC", "slug": "bitcoin", "circulatingSupply": 19900000, "maxSupply": 21000000},
+		{"name": "Pepe", "symbol": "PEPE", "slug": "pepe"},
+		{"name": "Ethereum", "symbol": "ETH", "slug": "ethereum"},
+		{"name": "Pepe on Base", "symbol": "PEPE", "slug": "pepe-base"},
+		{"name": "Dogecoin", "symbol": "DOGE", "slug": "dogecoin"}
+	]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := seed(pg, jsonSource{path: path}, 2)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	want := SeedSummary{Processed: 5, Inserted: 3, Updated: 1, Duplicates: 1, Batches: 3}
+	if summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+
+	var name, slug string
+	var supply float64
+	err = pg.QueryRow(`SELECT name, metadata->>'slug', circulating_supply FROM tokens WHERE symbol = 'BTC'`).Scan(&name, &slug, &supply)
+	if err != nil || name != "Bitcoin" || slug != "bitcoin" || supply != 19900000 {
+		t.Errorf("BTC = %s %s %v, err %v", name, slug, supply, err)
+	}
+	if err := pg.QueryRow(`SELECT name FROM tokens WHERE symbol = 'PEPE'`).Scan(&name); err != nil || name != "Pepe on Base" {
+		t.Errorf("PEPE = %s, err %v", name, err)
+	}
+
+	// Seeding again updates every token
+	summary, err = seed(pg, jsonSource{path: path}, 500)
+	if err != nil {
+		t.Fatalf("second seed: %v", err)
+	}
+	if summary.Inserted != 0 || summary.Updated != 4 || summary.Duplicates != 1 {
+		t.Errorf("second summary = %+v", summary)
+	}
+}
+
+func TestSeedTokenContracts(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ids := testutil.SeedTokens(t, pg, "UNI")
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	err := os.WriteFile(path, []byte(`[
+		{"name": "Uniswap", "symbol": "UNI", "contracts": [
+			{"no": 1, "contractAddress": "0x1F9840a85d5aF5bf1D1762F925BDADdC4201F984", "contractPlatform": "Ethereum", "contractRpcUrl": ["https://eth.llamarpc.com"]}
+		]},
+		{"name": "Tether USDt", "symbol": "USDT", "contracts": [
+			{"no": 2, "contractAddress": "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", "contractPlatform": "Tron20"},
+			{"no": 1, "contractAddress": "0xdAC17F958D2ee523a2206206994597C13D831ec7", "contractPlatform": "Ethereum"},
+			{"no": 3, "contractAddress": "0x55d398326f99059fF775485246999027B3197955", "contractPlatform": "BNB Smart Chain (BEP20)"}
+		]}
+	]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for run := 1; run <= 2; run++ {
+		summary, err := seed(pg, jsonSource{path: path}, 500)
+		if err != nil {
+			t.Fatalf("run %d: seed: %v", run, err)
+		}
+		if summary.Contracts != 4 {
+			t.Errorf("run %d: %d contracts written, want 4", run, summary.Contracts)
+		}
+		if run == 2 && (summary.Inserted != 0 || summary.Updated != 2) {
+			t.Errorf("run 2: summary = %+v", summary)
+		}
+	}
+
+	// UNI is only on Ethereum, so its existing row takes that chain
+	var id int
+	var chain, address string
+	err = pg.QueryRow(`SELECT id, chain, contract_address FROM tokens WHERE symbol = 'UNI'`).Scan(&id, &chain, &address)
+	if err != nil || id != ids["UNI"] || chain != "ethereum" || address != "0x1f9840a85d5af5bf1d1762f925bdaddc4201f984" {
+		t.Errorf("UNI = %d %s %s, err %v", id, chain, address, err)
+	}
+
+	// USDT is on several chains and stays chain-agnostic
+	var usdtChain, usdtAddress *string
+	if err := pg.QueryRow(`SELECT chain, contract_address FROM tokens WHERE symbol = 'USDT'`).Scan(&usdtChain, &usdtAddress); err != nil || usdtChain != nil || usdtAddress != nil {
+		t.Errorf("USDT chain %v contract %v, err %v", usdtChain, usdtAddress, err)
+	}
+
+	var primary string
+	err = pg.QueryRow(`
+		SELECT tc.chain FROM token_contracts tc JOIN tokens t ON t.id = tc.token_id
+		WHERE t.symbol = 'USDT' AND tc.is_primary
+	`).Scan(&primary)
+	if err != nil || primary != "ethereum" {
+		t.Errorf("USDT primary chain = %s, err %v", primary, err)
+	}
+	var bsc int
+	if err := pg.QueryRow(`SELECT COUNT(*) FROM token_contracts WHERE chain = 'bnb-smart-chain-bep20' AND position = 3`).Scan(&bsc); err != nil || bsc != 1 {
+		t.Errorf("BSC contracts = %d, err %v", bsc, err)
+	}
+}
@@ -0,0 +1,77 @@
+package tokenctl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStreamTokens(t *testing.T) {
+	input := `[
+		{"name": "Bitcoin", "symbol": "BTC", "maxSupply": 21000000},
+		{"name": "Ethereum", "symbol": "ETH", "maxSupply": null},
+		{"name": "Dogecoin", "symbol": "DOGE"}
+	]`
+
+	var batches [][]string
+	err := streamTokens(strings.NewReader(input), 2, func(batch []TokenMetadata) error {
+		var symbols []string
+		for _, token := range batch {
+			symbols = append(symbols, token.Symbol)
+		}
+		batches = append(batches, symbols)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("streamTokens: %v", err)
+	}
+	if len(batches) != 2 || strings.Join(batches[0], ",") != "BTC,ETH" || strings.Join(batches[1], ",") != "DOGE" {
+		t.Errorf("batches = %v", batches)
+	}
+
+	for _, bad := range []string{`{"symbol": "BTC"}`, `[{"symbol": "BTC"}, {"symbol": 1}]`, `[{"symbol": "BTC"}`} {
+		if err := streamTokens(strings.NewReader(bad), 2, func([]TokenMetadata) error { return nil }); err == nil {
+			t.Errorf("streamTokens(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestDedupeTokens(t *testing.T) {
+	batch := []TokenMetadata{
+		{Symbol: "PEPE", Name: "Pepe"},
+		{Symbol: "BTC", Name: "Bitcoin"},
+		{Symbol: "PEPE", Name: "Pepe on Base"},
+	}
+	deduped, dropped := dedupeTokens(batch)
+	if dropped != 1 || len(deduped) != 2 || deduped[0].Symbol != "BTC" || deduped[1].Name != "Pepe on Base" {
+		t.Errorf("dedupeTokens = %+v, %d", deduped, dropped)
+	}
+}
+
+func TestNormalizeContracts(t *testing.T) {
+	claimed := make(map[string]string)
+	usdt := TokenMetadata{Symbol: "USDT", Contracts: []Contract{
+		{No: 2, ContractAddress: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", ContractPlatform: "Tron20"},
+		{No: 1, ContractAddress: " 0xdAC17F958D2ee523a2206206994597C13D831ec7 ", ContractPlatform: "Ethereum"},
+		{No: 3, ContractAddress: "0x55d398326f99059fF775485246999027B3197955", ContractPlatform: "BNB Smart Chain (BEP20)"},
+		{No: 4, ContractAddress: "", ContractPlatform: "Solana"},
+	}}
+	tc := normalizeContracts(usdt, claimed)
+	if len(tc.rows) != 3 || tc.rows[0].Chain != "ethereum" || tc.rows[0].Address != "0xdac17f958d2ee523a2206206994597c13d831ec7" ||
+		tc.rows[1].Address != "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t" || tc.rows[2].Chain != "bnb-smart-chain-bep20" || tc.rows[2].Position != 3 {
+		t.Errorf("USDT rows = %+v", tc.rows)
+	}
+	if tc.primary.Chain != "ethereum" || tc.chain != "" || tc.address != "" {
+		t.Errorf("USDT on several chains got chain %q address %q", tc.chain, tc.address)
+	}
+
+	uni := TokenMetadata{Symbol: "UNI", Contracts: []Contract{{ContractAddress: "0x1f9840a85d5af5bf1d1762f925bdaddc4201f984", ContractPlatform: "Ethereum"}}}
+	if tc := normalizeContracts(uni, claimed); tc.chain != "ethereum" || tc.address != "0x1f9840a85d5af5bf1d1762f925bdaddc4201f984" {
+		t.Errorf("UNI = %+v", tc)
+	}
+
+	// A second token with the same primary contract does not get it
+	clone := TokenMetadata{Symbol: "UNI2", Contracts: uni.Contracts}
+	if tc := normalizeContracts(clone, claimed); tc.chain != "" || tc.primary.Address != "" || len(tc.rows) != 1 {
+		t.Errorf("UNI2 = %+v", tc)
+	}
+}
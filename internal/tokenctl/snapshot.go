@@ -0,0 +1,95 @@
+package tokenctl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ashmitsharp/trading/internal/mappingset"
+	"go.uber.org/zap"
+)
+
+// exportCommand writes the mapping dataset as a bundle another database can
+// import
+func exportCommand(fs *flag.FlagSet) func(context.Context, *env) error {
+	format := fs.String("format", mappingset.FormatJSON, "Bundle format: json, or csv for a zip of one CSV file per table")
+	output := fs.String("o", "", "File to write the bundle to (default stdout)")
+
+	return func(ctx context.Context, e *env) error {
+		if *format != mappingset.FormatJSON && *format != mappingset.FormatCSV {
+			return usageError{fmt.Errorf("unknown format %q: want json or csv", *format)}
+		}
+		if err := e.connect(); err != nil {
+			return err
+		}
+
+		bundle, err := mappingset.Export(ctx, e.db)
+		if err != nil {
+			return fmt.Errorf("failed to export mappings: %w", err)
+		}
+
+		var w io.Writer = e.out
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := bundle.Write(w, *format); err != nil {
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+
+		e.logger.Info("Exported mappings",
+			zap.Int("tokens", len(bundle.Tokens)),
+			zap.Int("exchange_symbols", len(bundle.Symbols)),
+			zap.Int("pairs", len(bundle.Pairs)),
+			zap.String("checksum", bundle.Checksum))
+		return nil
+	}
+}
+
+// importCommand writes a bundle from tokenctl export into the database
+func importCommand(fs *flag.FlagSet) func(context.Context, *env) error {
+	return func(ctx context.Context, e *env) error {
+		if fs.NArg() != 1 {
+			return usageError{errors.New("a bundle file is required")}
+		}
+		data, err := os.ReadFile(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		bundle, err := mappingset.Read(data)
+		if err != nil {
+			return err
+		}
+		if err := bundle.Validate(); err != nil {
+			return err
+		}
+		if err := e.connect(); err != nil {
+			return err
+		}
+
+		e.logger.Info("Importing mappings",
+			zap.Time("exported_at", bundle.ExportedAt),
+			zap.String("checksum", bundle.Checksum))
+		var summary mappingset.ImportSummary
+		err = e.inTx(ctx, func(tx *sql.Tx) error {
+			summary, err = mappingset.Import(ctx, tx, bundle)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import mappings: %w", err)
+		}
+
+		fmt.Fprintf(e.out, "✓ Tokens: %d inserted, %d updated\n", summary.Tokens.Inserted, summary.Tokens.Updated)
+		fmt.Fprintf(e.out, "✓ Exchange symbols: %d inserted, %d updated\n", summary.Symbols.Inserted, summary.Symbols.Updated)
+		fmt.Fprintf(e.out, "✓ Pairs: %d inserted, %d updated\n", summary.Pairs.Inserted, summary.Pairs.Updated)
+		return nil
+	}
+}
@@ -0,0 +1,288 @@
+package tokenctl
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TokenSource reads tokens for the seeder and hands them over in batches
+type TokenSource interface {
+	// Name describes the source in logs
+	Name() string
+	// Read calls fn with up to batchSize tokens at a time until the source is
+	// exhausted, stopping at the first error fn returns
+	Read(ctx context.Context, batchSize int, fn func([]TokenMetadata) error) error
+}
+
+// sourceOptions configures the sources that need more than a path
+type sourceOptions struct {
+	FieldMap string // csv: field=column pairs
+	Limit    int    // API sources: how many tokens to pull
+	APIKey   string
+}
+
+// newTokenSource creates the source for the -source flag. File sources take
+// the file path as arg.
+func newTokenSource(kind, arg string, opts sourceOptions) (TokenSource, error) {
+	switch kind {
+	case "json", "csv":
+		if arg == "" {
+			return nil, fmt.Errorf("-source %s needs a file path", kind)
+		}
+		if kind == "json" {
+			return jsonSource{path: arg}, nil
+		}
+		columns, err := parseFieldMap(opts.FieldMap)
+		if err != nil {
+			return nil, err
+		}
+		return csvSource{path: arg, columns: columns}, nil
+	case "coingecko":
+		return newCoinGeckoSource(firstNonEmpty(opts.APIKey, os.Getenv("COINGECKO_API_KEY")), opts.Limit), nil
+	case "cmc":
+		key := firstNonEmpty(opts.APIKey, os.Getenv("CMC_API_KEY"))
+		if key == "" {
+			return nil, errors.New("-source cmc needs -api-key or CMC_API_KEY")
+		}
+		return newCMCSource(key, opts.Limit), nil
+	default:
+		return nil, fmt.Errorf("unknown -source %q, want json, csv, coingecko or cmc", kind)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// batcher collects tokens and hands them to fn batchSize at a time
+type batcher struct {
+	size  int
+	fn    func([]TokenMetadata) error
+	batch []TokenMetadata
+}
+
+func newBatcher(size int, fn func([]TokenMetadata) error) *batcher {
+	return &batcher{size: size, fn: fn, batch: make([]TokenMetadata, 0, size)}
+}
+
+func (b *batcher) add(token TokenMetadata) error {
+	b.batch = append(b.batch, token)
+	if len(b.batch) < b.size {
+		return nil
+	}
+	return b.flush()
+}
+
+func (b *batcher) flush() error {
+	if len(b.batch) == 0 {
+		return nil
+	}
+	err := b.fn(b.batch)
+	b.batch = b.batch[:0]
+	return err
+}
+
+// jsonSource reads a JSON array in the TokenMetadata schema
+type jsonSource struct {
+	path string
+}
+
+func (s jsonSource) Name() string { return s.path }
+
+func (s jsonSource) Read(ctx context.Context, batchSize int, fn func([]TokenMetadata) error) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	defer file.Close()
+	return streamTokens(file, batchSize, fn)
+}
+
+// streamTokens decodes a JSON array of tokens one element at a time and hands
+// them to fn in batches of batchSize, so the file never has to fit in memory
+func streamTokens(r io.Reader, batchSize int, fn func([]TokenMetadata) error) error {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("error parsing JSON: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("error parsing JSON: expected an array of tokens")
+	}
+
+	b := newBatcher(batchSize, fn)
+	for i := 0; dec.More(); i++ {
+		var token TokenMetadata
+		if err := dec.Decode(&token); err != nil {
+			return fmt.Errorf("error parsing token %d: %w", i, err)
+		}
+		if err := b.add(token); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("error parsing JSON: %w", err)
+	}
+	return b.flush()
+}
+
+// csvFields are the token fields a CSV column can fill. URL columns may hold
+// several URLs separated by ";". A contract column pair adds one contract.
+var csvFields = []string{
+	"name", "symbol", "slug", "coingecko_id",
+	"circulating_supply", "total_supply", "max_supply",
+	"website", "explorer", "twitter",
+	"contract_address", "contract_platform",
+}
+
+// parseFieldMap parses -map, "symbol=Ticker,name=Coin", into field -> column.
+// Fields not mapped are read from the column of the same name.
+func parseFieldMap(s string) (map[string]string, error) {
+	columns := make(map[string]string, len(csvFields))
+	for _, field := range csvFields {
+		columns[field] = field
+	}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if _, known := columns[field]; !ok || !known {
+			return nil, fmt.Errorf("invalid -map entry %q, want field=column with field one of %s", pair, strings.Join(csvFields, ", "))
+		}
+		columns[field] = strings.TrimSpace(column)
+	}
+	return columns, nil
+}
+
+// csvSource reads a CSV file with a header row
+type csvSource struct {
+	path    string
+	columns map[string]string // field -> header
+}
+
+func (s csvSource) Name() string { return s.path }
+
+func (s csvSource) Read(ctx context.Context, batchSize int, fn func([]TokenMetadata) error) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("error reading CSV header: %w", err)
+	}
+
+	// field -> column index, matching headers case-insensitively
+	index := make(map[string]int)
+	for i, name := range header {
+		for field, column := range s.columns {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				index[field] = i
+			}
+		}
+	}
+	for _, required := range []string{"symbol", "name"} {
+		if _, ok := index[required]; !ok {
+			return fmt.Errorf("CSV has no %q column for %s (use -map %s=<column>)", s.columns[required], required, required)
+		}
+	}
+
+	b := newBatcher(batchSize, fn)
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading CSV: %w", err)
+		}
+		token, err := csvToken(record, index)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if token.Symbol == "" {
+			continue
+		}
+		if err := b.add(token); err != nil {
+			return err
+		}
+	}
+	return b.flush()
+}
+
+func csvToken(record []string, index map[string]int) (TokenMetadata, error) {
+	get := func(field string) string {
+		if i, ok := index[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	urls := func(field string) []string {
+		var out []string
+		for _, u := range strings.Split(get(field), ";") {
+			if u = strings.TrimSpace(u); u != "" {
+				out = append(out, u)
+			}
+		}
+		return out
+	}
+
+	token := TokenMetadata{
+		Name:        get("name"),
+		Symbol:      strings.ToUpper(get("symbol")),
+		Slug:        get("slug"),
+		CoinGeckoID: get("coingecko_id"),
+		URLs: TokenURLs{
+			Website:  urls("website"),
+			Explorer: urls("explorer"),
+			Twitter:  urls("twitter"),
+		},
+	}
+
+	var err error
+	if token.CirculatingSupply, err = parseSupply(get("circulating_supply")); err != nil {
+		return token, fmt.Errorf("circulating_supply: %w", err)
+	}
+	if token.TotalSupply, err = parseSupply(get("total_supply")); err != nil {
+		return token, fmt.Errorf("total_supply: %w", err)
+	}
+	if raw := get("max_supply"); raw != "" {
+		maxSupply, err := parseSupply(raw)
+		if err != nil {
+			return token, fmt.Errorf("max_supply: %w", err)
+		}
+		token.MaxSupply = &maxSupply
+	}
+
+	if address := get("contract_address"); address != "" {
+		token.Contracts = []Contract{{No: 1, ContractAddress: address, ContractPlatform: get("contract_platform")}}
+	}
+	return token, nil
+}
+
+// parseSupply reads a supply figure, allowing thousands separators. Empty is 0.
+func parseSupply(s string) (float64, error) {
+	s = strings.ReplaceAll(s, ",", "")
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,124 @@
+package tokenctl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readAll(t *testing.T, source TokenSource) []TokenMetadata {
+	t.Helper()
+	var tokens []TokenMetadata
+	err := source.Read(context.Background(), 2, func(batch []TokenMetadata) error {
+		tokens = append(tokens, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	return tokens
+}
+
+func TestCSVSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.csv")
+	err := os.WriteFile(path, []byte(`Ticker,Coin,slug,circulating_supply,max_supply,website,contract_address,contract_platform
+btc,Bitcoin,bitcoin,"19,900,000",21000000,https://bitcoin.org,,
+UNI,Uniswap,uniswap,600000000,,https://uniswap.org;https://app.uniswap.org,0x1f98,Ethereum
+,Blank row,,,,,,
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newTokenSource("csv", path, sourceOptions{FieldMap: "ticker=Ticker"}); err == nil {
+		t.Error("unknown field in -map accepted")
+	}
+	source, err := newTokenSource("csv", path, sourceOptions{FieldMap: "symbol=Ticker, name=coin"})
+	if err != nil {
+		t.Fatalf("newTokenSource: %v", err)
+	}
+	tokens := readAll(t, source)
+	if len(tokens) != 2 {
+		t.Fatalf("tokens = %+v", tokens)
+	}
+
+	btc, uni := tokens[0], tokens[1]
+	if btc.Symbol != "BTC" || btc.Name != "Bitcoin" || btc.CirculatingSupply != 19900000 ||
+		btc.MaxSupply == nil || *btc.MaxSupply != 21000000 || len(btc.Contracts) != 0 {
+		t.Errorf("BTC = %+v", btc)
+	}
+	if uni.MaxSupply != nil || len(uni.URLs.Website) != 2 ||
+		len(uni.Contracts) != 1 || uni.Contracts[0].ContractAddress != "0x1f98" || uni.Contracts[0].ContractPlatform != "Ethereum" {
+		t.Errorf("UNI = %+v", uni)
+	}
+
+	// Without the mapping there is no symbol column
+	source, _ = newTokenSource("csv", path, sourceOptions{})
+	if err := source.Read(context.Background(), 2, func([]TokenMetadata) error { return nil }); err == nil {
+		t.Error("CSV without a symbol column accepted")
+	}
+}
+
+func TestCoinGeckoSource(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages = append(pages, r.URL.Query().Get("page"))
+		if r.Header.Get("x-cg-demo-api-key") != "key" {
+			t.Errorf("missing API key header")
+		}
+		w.Write([]byte(`[
+			{"id": "bitcoin", "symbol": "btc", "name": "Bitcoin", "circulating_supply": 19900000, "total_supply": 21000000, "max_supply": 21000000},
+			{"id": "ethereum", "symbol": "eth", "name": "Ethereum", "circulating_supply": 120000000, "total_supply": null, "max_supply": null}
+		]`))
+	}))
+	defer srv.Close()
+
+	source := coinGeckoSource{baseURL: srv.URL, apiKey: "key", limit: 1}
+	tokens := readAll(t, source)
+	if len(tokens) != 1 || len(pages) != 1 {
+		t.Fatalf("tokens = %+v after pages %v", tokens, pages)
+	}
+	if btc := tokens[0]; btc.Symbol != "BTC" || btc.CoinGeckoID != "bitcoin" || btc.Slug != "" || btc.TotalSupply != 21000000 {
+		t.Errorf("BTC = %+v", btc)
+	}
+
+	// A short page is the last one
+	pages = nil
+	tokens = readAll(t, coinGeckoSource{baseURL: srv.URL, apiKey: "key", limit: 1000})
+	if len(tokens) != 2 || len(pages) != 1 || tokens[1].MaxSupply != nil {
+		t.Errorf("tokens = %+v after pages %v", tokens, pages)
+	}
+}
+
+func TestCMCSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-CMC_PRO_API_KEY") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"status": {"error_code": 1002, "error_message": "API key missing."}}`))
+			return
+		}
+		if r.URL.Query().Get("start") != "1" || r.URL.Query().Get("limit") != "2" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"status": {"error_code": 0}, "data": [
+			{"name": "Ethereum", "symbol": "ETH", "slug": "ethereum", "circulating_supply": 120000000, "total_supply": 120000000, "max_supply": null, "infinite_supply": true, "platform": null},
+			{"name": "Uniswap", "symbol": "UNI", "slug": "uniswap", "circulating_supply": 600000000, "total_supply": 1000000000, "max_supply": 1000000000, "infinite_supply": false,
+			 "platform": {"name": "Ethereum", "token_address": "0x1f98"}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	tokens := readAll(t, cmcSource{baseURL: srv.URL, apiKey: "key", limit: 2})
+	if len(tokens) != 2 || tokens[0].IsInfiniteMaxSupply != 1 || tokens[0].Slug != "ethereum" ||
+		len(tokens[1].Contracts) != 1 || tokens[1].Contracts[0].ContractAddress != "0x1f98" {
+		t.Errorf("tokens = %+v", tokens)
+	}
+
+	err := cmcSource{baseURL: srv.URL, apiKey: "wrong", limit: 2}.Read(context.Background(), 2, func([]TokenMetadata) error { return nil })
+	if err == nil {
+		t.Error("rejected API key not reported")
+	}
+}
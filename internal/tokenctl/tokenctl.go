@@ -0,0 +1,235 @@
+// Package tokenctl implements the tokenctl command, which seeds tokens,
+// exchange symbol mappings and trading pairs into PostgreSQL and checks them.
+//
+// Usage:
+//
+//	tokenctl seed       [flags] configs/tokens.json
+//	tokenctl categories [flags]
+//	tokenctl map        [flags]
+//	tokenctl pairs      [flags]
+//	tokenctl verify     [flags]
+//	tokenctl export     [flags]
+//	tokenctl import     [flags] bundle
+//
+// Every subcommand takes -database-url, -dry-run and -verbose. Writes run in
+// one transaction, which -dry-run rolls back after reporting what it did.
+package tokenctl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/ashmitsharp/trading/internal/config"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// command is a tokenctl subcommand. setup registers its flags and returns
+// the function that runs it once they are parsed.
+type command struct {
+	name    string
+	args    string
+	summary string
+	setup   func(fs *flag.FlagSet) func(context.Context, *env) error
+}
+
+var commands = []command{
+	{"seed", "[flags] [file]", "Upsert tokens from a JSON or CSV file, CoinGecko or CoinMarketCap", seedCommand},
+	{"categories", "[flags]", "Link the tokens in the database to their CoinGecko categories", categoriesCommand},
+	{"map", "[flags]", "Write exchange symbol mappings for the tokens in the database", mapCommand},
+	{"pairs", "[flags]", "Write trading pairs for the tokens in the database", pairsCommand},
+	{"verify", "[flags]", "Report unmapped tokens and inconsistent mappings and pairs", verifyCommand},
+	{"export", "[flags]", "Write tokens, exchange symbols and pairs as a versioned JSON or CSV bundle", exportCommand},
+	{"import", "[flags] bundle", "Write a bundle from tokenctl export into the database", importCommand},
+}
+
+// env is what every subcommand shares
+type env struct {
+	databaseURL string
+	dryRun      bool
+	verbose     bool
+
+	db     *sql.DB
+	logger *zap.Logger
+	out    io.Writer
+}
+
+// usageError is an error in how tokenctl was invoked, reported with the
+// subcommand's usage
+type usageError struct{ err error }
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// Main runs tokenctl with args, the command line without the program name,
+// and returns the exit code: 0 on success, 1 when the command failed and 2
+// when it was invoked wrongly
+func Main(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage(os.Stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == args[0] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "tokenctl: unknown command %q\n\n", args[0])
+		usage(os.Stderr)
+		return 2
+	}
+
+	// A .env file is optional, and variables already set take precedence
+	_ = godotenv.Load()
+
+	e := &env{out: os.Stdout}
+	fs := flag.NewFlagSet("tokenctl "+cmd.name, flag.ContinueOnError)
+	fs.StringVar(&e.databaseURL, "database-url", os.Getenv("DATABASE_URL"), "PostgreSQL URL (default DATABASE_URL, else the POSTGRES_* variables)")
+	fs.BoolVar(&e.dryRun, "dry-run", false, "Do everything in a transaction and roll it back")
+	fs.BoolVar(&e.verbose, "verbose", false, "Log debug output and print every row written")
+	run := cmd.setup(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tokenctl %s %s\n\n%s.\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	e.logger = newLogger(e.verbose)
+	defer e.logger.Sync()
+	defer func() {
+		if e.db != nil {
+			e.db.Close()
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, e)
+	var uerr usageError
+	switch {
+	case errors.As(err, &uerr):
+		fmt.Fprintf(os.Stderr, "tokenctl %s: %v\n\n", cmd.name, err)
+		fs.Usage()
+		return 2
+	case err != nil:
+		e.logger.Error(cmd.name+" failed", zap.Error(err))
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: tokenctl <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run tokenctl <command> -h for the command's flags.")
+}
+
+// newLogger logs to stderr, keeping stdout for results
+func newLogger(verbose bool) *zap.Logger {
+	level := zapcore.InfoLevel
+	if verbose {
+		level = zapcore.DebugLevel
+	}
+	encoderConfig := zap.NewDevelopmentEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("15:04:05")
+	encoderConfig.EncodeCaller = nil
+	return zap.New(zapcore.NewCore(zapcore.NewConsoleEncoder(encoderConfig), zapcore.Lock(os.Stderr), level))
+}
+
+// connect opens the PostgreSQL connection the subcommand works on
+func (e *env) connect() error {
+	dsn := e.databaseURL
+	if dsn == "" {
+		dsn = postgresDSN()
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to ping PostgreSQL: %w", err)
+	}
+	e.db = db
+	e.logger.Debug("Connected to PostgreSQL")
+	return nil
+}
+
+// postgresDSN builds the connection string from the POSTGRES_* variables the
+// API server reads. The old seeder's POSTGRES_USERNAME, POSTGRES_DATABASE and
+// POSTGRES_SSLMODE are honored when the server's names are not set.
+func postgresDSN() string {
+	cfg, _ := config.Load()
+	pg := cfg.Postgres
+	for _, legacy := range []struct {
+		name, old string
+		value     *string
+	}{
+		{"POSTGRES_USER", "POSTGRES_USERNAME", &pg.Username},
+		{"POSTGRES_DB", "POSTGRES_DATABASE", &pg.Database},
+		{"POSTGRES_SSL_MODE", "POSTGRES_SSLMODE", &pg.SSLMode},
+	} {
+		if v := os.Getenv(legacy.old); v != "" && os.Getenv(legacy.name) == "" {
+			*legacy.value = v
+		}
+	}
+	return pg.ConnectionString()
+}
+
+// inTx runs fn in a transaction, which it commits unless this is a dry run
+func (e *env) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if e.dryRun {
+		fmt.Fprintln(e.out, "Dry run: rolled back, nothing was written")
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
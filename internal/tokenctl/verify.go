@@ -0,0 +1,163 @@
+package tokenctl
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+// check is one verify query. Its query selects a detail column, one row per
+// finding; problems fail verify, the others are reported.
+type check struct {
+	name    string
+	problem bool
+	query   string
+}
+
+var checks = []check{
+	{
+		name:    "active tokens without an active symbol mapping",
+		problem: false,
+		query: `
+			SELECT t.symbol || ' (id ' || t.id || ')'
+			FROM tokens t
+			WHERE t.is_active = true AND t.chain IS NULL
+			  AND NOT EXISTS (
+			    SELECT 1 FROM token_exchange_symbols tes
+			    WHERE tes.token_id = t.id AND tes.is_active = true
+			  )`,
+	},
+	{
+		name:    "symbol mappings whose normalized symbol is not their token's",
+		problem: true,
+		query: `
+			SELECT tes.exchange_id || ' ' || tes.exchange_symbol || ': ' || tes.normalized_symbol || ' but token ' || t.symbol
+			FROM token_exchange_symbols tes
+			JOIN tokens t ON t.id = tes.token_id
+			WHERE tes.is_active = true AND UPPER(tes.normalized_symbol) <> UPPER(t.symbol)`,
+	},
+	{
+		name:    "active symbol mappings of inactive tokens",
+		problem: true,
+		query: `
+			SELECT tes.exchange_id || ' ' || tes.exchange_symbol || ' -> ' || t.symbol
+			FROM token_exchange_symbols tes
+			JOIN tokens t ON t.id = tes.token_id
+			WHERE tes.is_active = true AND t.is_active = false`,
+	},
+	{
+		name:    "active trading pairs of inactive tokens",
+		problem: true,
+		query: `
+			SELECT tp.exchange_id || ' ' || tp.exchange_pair_symbol || ' -> ' || b.symbol || '/' || q.symbol
+			FROM trading_pairs tp
+			JOIN tokens b ON b.id = tp.base_token_id
+			JOIN tokens q ON q.id = tp.quote_token_id
+			WHERE tp.is_active = true AND (b.is_active = false OR q.is_active = false)`,
+	},
+	{
+		name:    "trading pairs quoting a token in itself",
+		problem: true,
+		query: `
+			SELECT tp.exchange_id || ' ' || tp.exchange_pair_symbol
+			FROM trading_pairs tp
+			WHERE tp.is_active = true AND tp.base_token_id = tp.quote_token_id`,
+	},
+	{
+		name:    "active trading pairs whose base has no symbol mapping on the exchange",
+		problem: false,
+		query: `
+			SELECT tp.exchange_id || ' ' || tp.exchange_pair_symbol || ' -> ' || b.symbol
+			FROM trading_pairs tp
+			JOIN tokens b ON b.id = tp.base_token_id
+			WHERE tp.is_active = true
+			  AND NOT EXISTS (
+			    SELECT 1 FROM token_exchange_symbols tes
+			    WHERE tes.token_id = tp.base_token_id AND tes.exchange_id = tp.exchange_id AND tes.is_active = true
+			  )`,
+	},
+	{
+		name:    "symbol mappings awaiting verification",
+		problem: false,
+		query: `
+			SELECT tes.exchange_id || ' ' || tes.exchange_symbol || ' (' || COALESCE(tes.mapping_method, 'manual') || ')'
+			FROM token_exchange_symbols tes
+			WHERE tes.is_active = true AND tes.needs_verification = true`,
+	},
+	{
+		name:    "exchange symbols pending review",
+		problem: false,
+		query: `
+			SELECT pm.exchange_id || ' ' || pm.exchange_symbol
+			FROM pending_mappings pm
+			WHERE pm.status = 'pending'`,
+	},
+}
+
+// errVerifyFailed is returned when a problem check finds rows
+var errVerifyFailed = errors.New("verification found problems")
+
+// verifyCommand runs the checks and fails when a problem check finds anything
+func verifyCommand(fs *flag.FlagSet) func(context.Context, *env) error {
+	examples := fs.Int("examples", 5, "Findings to list per check")
+
+	return func(ctx context.Context, e *env) error {
+		if err := e.connect(); err != nil {
+			return err
+		}
+
+		problems := 0
+		for _, c := range checks {
+			// The window count is taken before LIMIT, so it counts every finding.
+			// One row is always fetched to carry it.
+			rows, err := e.db.QueryContext(ctx, `
+				SELECT detail, COUNT(*) OVER ()
+				FROM (`+c.query+`) AS findings (detail)
+				ORDER BY detail
+				LIMIT GREATEST($1, 1)
+			`, *examples)
+			if err != nil {
+				return fmt.Errorf("failed to check %s: %w", c.name, err)
+			}
+
+			var details []string
+			total := 0
+			for rows.Next() {
+				var detail string
+				if err := rows.Scan(&detail, &total); err != nil {
+					rows.Close()
+					return fmt.Errorf("failed to scan %s: %w", c.name, err)
+				}
+				if len(details) < *examples {
+					details = append(details, detail)
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("failed to check %s: %w", c.name, err)
+			}
+
+			mark := "✓"
+			switch {
+			case total > 0 && c.problem:
+				mark = "✗"
+				problems++
+			case total > 0:
+				mark = "•"
+			}
+			fmt.Fprintf(e.out, "%s %-72s %d\n", mark, c.name, total)
+			for _, detail := range details {
+				fmt.Fprintf(e.out, "    %s\n", detail)
+			}
+			if total > len(details) && *examples > 0 {
+				fmt.Fprintf(e.out, "    ... and %d more\n", total-len(details))
+			}
+		}
+
+		if problems > 0 {
+			return fmt.Errorf("%w: %d checks failed", errVerifyFailed, problems)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,257 @@
+package tokenops
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Audit log actions of archival
+const (
+	ActionArchive = "archive"
+	ActionRestore = "restore"
+)
+
+// ErrTokenNotArchived is returned when restoring a token that is not archived
+var ErrTokenNotArchived = errors.New("token is not archived")
+
+// archivalPerformer is the performed_by of the archival job's log entries
+const archivalPerformer = "archival"
+
+// ArchiveRequest archives TokenID
+type ArchiveRequest struct {
+	TokenID     int
+	PerformedBy string
+	Reason      string
+}
+
+// RestoreRequest restores an archived TokenID
+type RestoreRequest struct {
+	TokenID     int
+	PerformedBy string
+	Reason      string
+}
+
+// Archive deactivates a token, marks it archived and tombstones its symbol
+// mappings and trading pairs: they are deactivated and stamped archived_at,
+// so the resolver and pollers no longer use them while the rows, and the
+// ClickHouse prices, VWAPs and candles stored under the token's ID, stay
+// queryable. Archiving an archived token again tombstones whatever has been
+// written for it since.
+func (s *Service) Archive(ctx context.Context, req ArchiveRequest) (*Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockToken(ctx, tx, req.TokenID, nil); err != nil {
+		return nil, err
+	}
+
+	id := req.TokenID
+	moved := make(map[string]int64)
+	steps := []struct {
+		name  string
+		query string
+		args  []interface{}
+	}{
+		{"token_exchange_symbols", `
+			UPDATE token_exchange_symbols SET is_active = false, archived_at = NOW()
+			WHERE token_id = $1 AND archived_at IS NULL`, []interface{}{id}},
+		{"trading_pairs", `
+			UPDATE trading_pairs SET is_active = false, archived_at = NOW()
+			WHERE (base_token_id = $1 OR quote_token_id = $1) AND archived_at IS NULL`, []interface{}{id}},
+		{"tokens", `
+			UPDATE tokens
+			SET is_active = false, is_archived = true,
+			    archived_at = COALESCE(archived_at, NOW()),
+			    archive_reason = COALESCE($2, archive_reason)
+			WHERE id = $1`, []interface{}{id, nullString(req.Reason)}},
+	}
+	for _, step := range steps {
+		n, err := execCount(ctx, tx, step.query, step.args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to archive %s: %w", step.name, err)
+		}
+		moved[step.name] = n
+	}
+
+	result, err := s.commitArchival(ctx, tx, ActionArchive, id, moved, req.PerformedBy, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Archived token",
+		zap.Int("log_id", result.LogID),
+		zap.Int("token_id", id),
+		zap.Any("tombstoned", moved),
+		zap.String("performed_by", req.PerformedBy))
+	return result, nil
+}
+
+// Restore reverses Archive: the token is active again, as are its tombstoned
+// mappings and the tombstoned pairs whose other token is not archived too
+func (s *Service) Restore(ctx context.Context, req RestoreRequest) (*Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockToken(ctx, tx, req.TokenID, nil); err != nil {
+		return nil, err
+	}
+	var archived bool
+	if err := tx.QueryRowContext(ctx, `SELECT is_archived FROM tokens WHERE id = $1`, req.TokenID).Scan(&archived); err != nil {
+		return nil, fmt.Errorf("failed to read token %d: %w", req.TokenID, err)
+	}
+	if !archived {
+		return nil, fmt.Errorf("%w: %d", ErrTokenNotArchived, req.TokenID)
+	}
+
+	id := req.TokenID
+	moved := make(map[string]int64)
+	steps := []struct {
+		name  string
+		query string
+	}{
+		{"token_exchange_symbols", `
+			UPDATE token_exchange_symbols SET is_active = true, archived_at = NULL
+			WHERE token_id = $1 AND archived_at IS NOT NULL`},
+		{"trading_pairs", `
+			UPDATE trading_pairs tp SET is_active = true, archived_at = NULL
+			WHERE (tp.base_token_id = $1 OR tp.quote_token_id = $1) AND tp.archived_at IS NOT NULL
+			  AND NOT EXISTS (
+			    SELECT 1 FROM tokens t
+			    WHERE t.id IN (tp.base_token_id, tp.quote_token_id) AND t.id <> $1 AND t.is_archived
+			  )`},
+		{"tokens", `
+			UPDATE tokens SET is_active = true, is_archived = false, archived_at = NULL, archive_reason = NULL
+			WHERE id = $1`},
+	}
+	for _, step := range steps {
+		n, err := execCount(ctx, tx, step.query, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", step.name, err)
+		}
+		moved[step.name] = n
+	}
+
+	result, err := s.commitArchival(ctx, tx, ActionRestore, id, moved, req.PerformedBy, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Restored token",
+		zap.Int("log_id", result.LogID),
+		zap.Int("token_id", id),
+		zap.Any("restored", moved),
+		zap.String("performed_by", req.PerformedBy))
+	return result, nil
+}
+
+// commitArchival logs an archival change and commits it. ClickHouse rows keep
+// the token's ID either way, so the entry has no ClickHouse step to wait for.
+func (s *Service) commitArchival(ctx context.Context, tx *sql.Tx, action string, id int, moved map[string]int64, performedBy, reason string) (*Result, error) {
+	logID, err := insertLog(ctx, tx, action, id, id, nil, moved, performedBy, reason)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE token_merge_log SET clickhouse_status = $2, completed_at = NOW() WHERE id = $1
+	`, logID, StatusCompleted); err != nil {
+		return nil, fmt.Errorf("failed to update token merge log: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit %s: %w", action, err)
+	}
+	return &Result{
+		LogID:            logID,
+		Action:           action,
+		SourceTokenID:    id,
+		TargetTokenID:    id,
+		Moved:            moved,
+		ClickHouseStatus: StatusCompleted,
+	}, nil
+}
+
+// ArchiveInactive archives the tokens that have been inactive, such as the
+// source of a merge, and untouched for inactiveFor, and tombstones mappings
+// and pairs written for archived tokens since they were archived. It returns
+// the IDs of the tokens it archived.
+func (s *Service) ArchiveInactive(ctx context.Context, inactiveFor time.Duration) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM tokens
+		WHERE is_active = false AND NOT is_archived
+		  AND updated_at < NOW() - make_interval(secs => $1)
+		ORDER BY id
+	`, inactiveFor.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inactive tokens: %w", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query inactive tokens: %w", err)
+	}
+
+	for _, id := range ids {
+		reason := fmt.Sprintf("inactive for over %s", inactiveFor)
+		if _, err := s.Archive(ctx, ArchiveRequest{TokenID: id, PerformedBy: archivalPerformer, Reason: reason}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Rows an import or a seed wrote for tokens that were already archived
+	for _, sweep := range []struct{ table, query string }{
+		{"token_exchange_symbols", `
+			UPDATE token_exchange_symbols tes SET is_active = false, archived_at = NOW()
+			FROM tokens t
+			WHERE t.id = tes.token_id AND t.is_archived AND tes.archived_at IS NULL`},
+		{"trading_pairs", `
+			UPDATE trading_pairs tp SET is_active = false, archived_at = NOW()
+			FROM tokens t
+			WHERE t.id IN (tp.base_token_id, tp.quote_token_id) AND t.is_archived AND tp.archived_at IS NULL`},
+	} {
+		res, err := s.db.ExecContext(ctx, sweep.query)
+		if err != nil {
+			return ids, fmt.Errorf("failed to tombstone %s of archived tokens: %w", sweep.table, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			s.logger.Info("Tombstoned rows of archived tokens", zap.String("table", sweep.table), zap.Int64("rows", n))
+		}
+	}
+	return ids, nil
+}
+
+// RunArchival archives the tokens inactive for inactiveFor every interval
+// until ctx is cancelled
+func (s *Service) RunArchival(ctx context.Context, interval, inactiveFor time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ids, err := s.ArchiveInactive(ctx, inactiveFor)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error("Token archival failed", zap.Error(err))
+		} else if len(ids) > 0 {
+			s.logger.Info("Archived inactive tokens", zap.Ints("token_ids", ids))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package tokenops
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// chTable is a ClickHouse table keyed by token IDs. Token IDs are part of
+// every sorting key, which ALTER ... UPDATE cannot change, so rows are copied
+// with the IDs replaced and the originals deleted.
+type chTable struct {
+	name        string
+	hasExchange bool   // rows record a single exchange and can be split by venue
+	view        string // materialized view fed by inserts into this table
+}
+
+var chTables = []chTable{
+	{name: "trades", hasExchange: true, view: "trades_ohlcv_1m"},
+	{name: "price_tickers", hasExchange: true},
+	{name: "vwap_prices", view: "vwap_ohlcv_1m"},
+	{name: "vwap_composition", hasExchange: true},
+	{name: "candlesticks_hot"},
+}
+
+// repointClickHouse moves rows referring to src over to dst, for all tables or,
+// when exchanges is set, only for rows recorded on those exchanges. It returns
+// the tables it finished before failing.
+//
+// Copying a table's rows feeds its materialized view as any insert would, so
+// view rows are only copied for minutes the table no longer holds. The minute
+// the table's oldest row falls in is rebuilt from that row onwards.
+func (s *Service) repointClickHouse(ctx context.Context, src, dst int, exchanges []string) ([]string, error) {
+	match := fmt.Sprintf("(base_token_id = %d OR quote_token_id = %d)", src, src)
+	var args []interface{}
+	if exchanges != nil {
+		match += " AND has(?, exchange_id)"
+		args = append(args, exchanges)
+	}
+	replace := fmt.Sprintf(
+		"REPLACE (if(base_token_id = %[1]d, %[2]d, base_token_id) AS base_token_id, if(quote_token_id = %[1]d, %[2]d, quote_token_id) AS quote_token_id)",
+		src, dst)
+
+	var done []string
+	for _, t := range chTables {
+		if exchanges != nil && !t.hasExchange {
+			continue
+		}
+
+		if t.view != "" {
+			var count uint64
+			var oldest time.Time
+			if err := s.ch.QueryRow(ctx, "SELECT count(), min(timestamp) FROM "+t.name+" WHERE "+match, args...).Scan(&count, &oldest); err != nil {
+				return done, fmt.Errorf("failed to find oldest %s row: %w", t.name, err)
+			}
+			viewMatch := match
+			viewArgs := args
+			if count > 0 {
+				viewMatch += " AND minute < toStartOfMinute(?)"
+				viewArgs = append(append([]interface{}{}, args...), oldest)
+			}
+			if err := s.moveRows(ctx, t.view, replace, viewMatch, viewArgs, match, args); err != nil {
+				return done, err
+			}
+			done = append(done, t.view)
+		}
+
+		if err := s.moveRows(ctx, t.name, replace, match, args, match, args); err != nil {
+			return done, err
+		}
+		done = append(done, t.name)
+	}
+	return done, nil
+}
+
+// moveRows copies the rows of table matching copyWhere with replaced token IDs,
+// then deletes every row matching deleteWhere. The delete waits for the
+// mutation so a failure is reported before the next table is touched.
+func (s *Service) moveRows(ctx context.Context, table, replace, copyWhere string, copyArgs []interface{}, deleteWhere string, deleteArgs []interface{}) error {
+	if err := s.ch.Exec(ctx, "INSERT INTO "+table+" SELECT * "+replace+" FROM "+table+" WHERE "+copyWhere, copyArgs...); err != nil {
+		return fmt.Errorf("failed to copy %s rows: %w", table, err)
+	}
+	if err := s.ch.Exec(ctx, "ALTER TABLE "+table+" DELETE WHERE "+deleteWhere+" SETTINGS mutations_sync = 1", deleteArgs...); err != nil {
+		return fmt.Errorf("failed to delete %s rows: %w", table, err)
+	}
+	return nil
+}
@@ -0,0 +1,461 @@
+// Package tokenops fixes token identity mistakes after the fact: merging two
+// token rows that turned out to be the same asset, or splitting the listings of
+// one row that covers two different assets onto a new token. Tokens are
+// also created, corrected and archived here, so those fixes do not need seed
+// binaries run against production.
+//
+// PostgreSQL changes are made in a single transaction. ClickHouse cannot take
+// part in it, so its rows are re-pointed once the transaction commits and the
+// outcome of that step is recorded on the token_merge_log entry.
+package tokenops
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Audit log actions
+const (
+	ActionMerge = "merge"
+	ActionSplit = "split"
+)
+
+// ClickHouse step outcomes recorded on the audit log
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+var (
+	// ErrTokenNotFound is returned when a source or target token does not exist
+	ErrTokenNotFound = errors.New("token not found")
+
+	// ErrSameToken is returned when a token is merged into itself
+	ErrSameToken = errors.New("source and target are the same token")
+
+	// ErrTokenExists is returned when a split's new token clashes with an
+	// existing row on symbol and chain, or on chain and contract address
+	ErrTokenExists = errors.New("token already exists")
+
+	// ErrLogNotFound is returned when a token merge log entry does not exist
+	ErrLogNotFound = errors.New("token merge log entry not found")
+
+	// ErrNothingToMove is returned when a split matches no listings of the
+	// source token on the given exchanges
+	ErrNothingToMove = errors.New("no listings to move")
+)
+
+// evmAddress matches contract addresses that are case-insensitive hex, as
+// normalized by the chain identity migration
+var evmAddress = regexp.MustCompile(`(?i)^0x[0-9a-f]{40}$`)
+
+// MergeRequest merges SourceTokenID into TargetTokenID. The source token is
+// kept, deactivated, so history that cannot be moved still has a row to refer
+// to.
+type MergeRequest struct {
+	SourceTokenID int
+	TargetTokenID int
+	PerformedBy   string
+	Reason        string
+}
+
+// SplitRequest moves the listings of SourceTokenID on Exchanges to a new token
+type SplitRequest struct {
+	SourceTokenID   int
+	Exchanges       []string
+	Symbol          string
+	Name            string
+	Slug            string
+	Chain           string
+	ContractAddress string
+	PerformedBy     string
+	Reason          string
+}
+
+// Result describes a completed merge or split
+type Result struct {
+	LogID            int
+	Action           string
+	SourceTokenID    int
+	TargetTokenID    int
+	Moved            map[string]int64 // PostgreSQL rows re-pointed per table
+	ClickHouseStatus string           // as recorded on the log entry
+	ClickHouseTables []string         // ClickHouse tables re-pointed
+	ClickHouseError  error            // set when the PostgreSQL change committed but ClickHouse did not follow
+}
+
+// Service merges and splits tokens
+type Service struct {
+	db     *sql.DB
+	ch     driver.Conn
+	logger *zap.Logger
+}
+
+// NewService creates a new token merge/split service. ch may be nil, in which
+// case only PostgreSQL is changed and the log entry stays pending.
+func NewService(db *sql.DB, ch driver.Conn, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		ch:     ch,
+		logger: logger,
+	}
+}
+
+// Merge re-points everything that refers to the source token at the target
+// and deactivates the source. Pairs that would quote the target against itself
+// are deactivated. Supply snapshots, index constituents and watchlist and
+// webhook memberships the target already has for the same date, index or list
+// stay with the source.
+//
+// Merging an already merged token again is safe: it moves whatever has been
+// written under the source ID since, such as tickers stored by a process whose
+// resolver cache had not refreshed yet.
+func (s *Service) Merge(ctx context.Context, req MergeRequest) (*Result, error) {
+	if req.SourceTokenID == req.TargetTokenID {
+		return nil, ErrSameToken
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var targetChain sql.NullString
+	if err := lockToken(ctx, tx, req.SourceTokenID, nil); err != nil {
+		return nil, err
+	}
+	if err := lockToken(ctx, tx, req.TargetTokenID, &targetChain); err != nil {
+		return nil, err
+	}
+
+	src, tgt := req.SourceTokenID, req.TargetTokenID
+	moved := make(map[string]int64)
+	steps := []struct {
+		name  string
+		query string
+		args  []interface{}
+	}{
+		{"token_exchange_symbols", `
+			UPDATE token_exchange_symbols SET token_id = $2, chain = $3
+			WHERE token_id = $1`, []interface{}{src, tgt, targetChain}},
+		{"trading_pairs", `
+			UPDATE trading_pairs
+			SET base_token_id = CASE WHEN base_token_id = $1 THEN $2 ELSE base_token_id END,
+			    quote_token_id = CASE WHEN quote_token_id = $1 THEN $2 ELSE quote_token_id END
+			WHERE base_token_id = $1 OR quote_token_id = $1`, []interface{}{src, tgt}},
+		{"trading_pairs_deactivated", `
+			UPDATE trading_pairs SET is_active = false
+			WHERE base_token_id = $1 AND quote_token_id = $1 AND is_active = true`, []interface{}{tgt}},
+		{"price_outliers", `
+			UPDATE price_outliers
+			SET base_token_id = CASE WHEN base_token_id = $1 THEN $2 ELSE base_token_id END,
+			    quote_token_id = CASE WHEN quote_token_id = $1 THEN $2 ELSE quote_token_id END
+			WHERE base_token_id = $1 OR quote_token_id = $1`, []interface{}{src, tgt}},
+		{"token_supply_snapshots", `
+			UPDATE token_supply_snapshots s SET token_id = $2
+			WHERE s.token_id = $1
+			  AND NOT EXISTS (
+			    SELECT 1 FROM token_supply_snapshots t
+			    WHERE t.token_id = $2 AND t.snapshot_date = s.snapshot_date
+			  )`, []interface{}{src, tgt}},
+		{"index_constituents", `
+			UPDATE index_constituents c SET token_id = $2
+			WHERE c.token_id = $1
+			  AND NOT EXISTS (
+			    SELECT 1 FROM index_constituents t
+			    WHERE t.token_id = $2 AND t.index_id = c.index_id
+			  )`, []interface{}{src, tgt}},
+		{"watchlist_items", `
+			UPDATE watchlist_items w SET token_id = $2
+			WHERE w.token_id = $1
+			  AND NOT EXISTS (
+			    SELECT 1 FROM watchlist_items t
+			    WHERE t.token_id = $2 AND t.watchlist_id = w.watchlist_id
+			  )`, []interface{}{src, tgt}},
+		{"webhook_tokens", `
+			UPDATE webhook_tokens w SET token_id = $2
+			WHERE w.token_id = $1
+			  AND NOT EXISTS (
+			    SELECT 1 FROM webhook_tokens t
+			    WHERE t.token_id = $2 AND t.webhook_id = w.webhook_id
+			  )`, []interface{}{src, tgt}},
+		{"token_categories", `
+			UPDATE token_categories c SET token_id = $2
+			WHERE c.token_id = $1
+			  AND NOT EXISTS (
+			    SELECT 1 FROM token_categories t
+			    WHERE t.token_id = $2 AND t.category_id = c.category_id
+			  )`, []interface{}{src, tgt}},
+		{"outlier_thresholds", `
+			UPDATE outlier_thresholds o
+			SET base_token_id = CASE WHEN base_token_id = $1 THEN $2 ELSE base_token_id END,
+			    quote_token_id = CASE WHEN quote_token_id = $1 THEN $2 ELSE quote_token_id END
+			WHERE (o.base_token_id = $1 OR o.quote_token_id = $1)
+			  AND NOT EXISTS (
+			    SELECT 1 FROM outlier_thresholds t
+			    WHERE t.base_token_id = CASE WHEN o.base_token_id = $1 THEN $2 ELSE o.base_token_id END
+			      AND t.quote_token_id = CASE WHEN o.quote_token_id = $1 THEN $2 ELSE o.quote_token_id END
+			  )`, []interface{}{src, tgt}},
+	}
+	for _, step := range steps {
+		n, err := execCount(ctx, tx, step.query, step.args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-point %s: %w", step.name, err)
+		}
+		moved[step.name] = n
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tokens SET is_active = false WHERE id = $1`, src); err != nil {
+		return nil, fmt.Errorf("failed to deactivate source token: %w", err)
+	}
+
+	logID, err := insertLog(ctx, tx, ActionMerge, src, tgt, nil, moved, req.PerformedBy, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", err)
+	}
+
+	s.logger.Info("Merged tokens",
+		zap.Int("log_id", logID),
+		zap.Int("source_token_id", src),
+		zap.Int("target_token_id", tgt),
+		zap.Any("moved", moved),
+		zap.String("performed_by", req.PerformedBy))
+
+	result := &Result{
+		LogID:            logID,
+		Action:           ActionMerge,
+		SourceTokenID:    src,
+		TargetTokenID:    tgt,
+		Moved:            moved,
+		ClickHouseStatus: StatusPending,
+	}
+	s.finishClickHouse(ctx, result, nil)
+	return result, nil
+}
+
+// Split creates a new token and moves the source token's symbol mappings,
+// trading pairs and outliers on the given exchanges to it. ClickHouse rows are
+// only moved from tables that record the exchange; composite VWAPs and candles
+// stay with the source since they cannot be divided by venue.
+func (s *Service) Split(ctx context.Context, req SplitRequest) (*Result, error) {
+	if len(req.Exchanges) == 0 {
+		return nil, fmt.Errorf("%w: no exchanges given", ErrNothingToMove)
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if symbol == "" || strings.TrimSpace(req.Name) == "" {
+		return nil, errors.New("new token needs a symbol and a name")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockToken(ctx, tx, req.SourceTokenID, nil); err != nil {
+		return nil, err
+	}
+
+	chain := nullString(strings.ToLower(strings.TrimSpace(req.Chain)))
+	contract := normalizeAddress(req.ContractAddress)
+
+	var newID int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO tokens (symbol, name, slug, chain, contract_address, is_active)
+		VALUES ($1, $2, $3, $4, $5, true)
+		RETURNING id
+	`, symbol, strings.TrimSpace(req.Name), nullString(strings.TrimSpace(req.Slug)), chain, nullString(contract)).Scan(&newID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, fmt.Errorf("%w: %s", ErrTokenExists, pqErr.Detail)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token %s: %w", symbol, err)
+	}
+
+	src := req.SourceTokenID
+	exchangeIDs := make([]string, len(req.Exchanges))
+	for i, ex := range req.Exchanges {
+		exchangeIDs[i] = strings.ToLower(strings.TrimSpace(ex))
+	}
+	exchanges := pq.Array(exchangeIDs)
+	moved := make(map[string]int64)
+
+	n, err := execCount(ctx, tx, `
+		UPDATE token_exchange_symbols SET token_id = $2, chain = $3
+		WHERE token_id = $1 AND exchange_id = ANY($4)
+	`, src, newID, chain, exchanges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move symbol mappings: %w", err)
+	}
+	moved["token_exchange_symbols"] = n
+
+	for _, table := range []string{"trading_pairs", "price_outliers"} {
+		n, err := execCount(ctx, tx, `
+			UPDATE `+table+`
+			SET base_token_id = CASE WHEN base_token_id = $1 THEN $2 ELSE base_token_id END,
+			    quote_token_id = CASE WHEN quote_token_id = $1 THEN $2 ELSE quote_token_id END
+			WHERE (base_token_id = $1 OR quote_token_id = $1) AND exchange_id = ANY($3)
+		`, src, newID, exchanges)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move %s: %w", table, err)
+		}
+		moved[table] = n
+	}
+	if moved["token_exchange_symbols"] == 0 && moved["trading_pairs"] == 0 {
+		return nil, ErrNothingToMove
+	}
+
+	logID, err := insertLog(ctx, tx, ActionSplit, src, newID, exchangeIDs, moved, req.PerformedBy, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit split: %w", err)
+	}
+
+	s.logger.Info("Split token",
+		zap.Int("log_id", logID),
+		zap.Int("source_token_id", src),
+		zap.Int("new_token_id", newID),
+		zap.Strings("exchanges", exchangeIDs),
+		zap.Any("moved", moved),
+		zap.String("performed_by", req.PerformedBy))
+
+	result := &Result{
+		LogID:            logID,
+		Action:           ActionSplit,
+		SourceTokenID:    src,
+		TargetTokenID:    newID,
+		Moved:            moved,
+		ClickHouseStatus: StatusPending,
+	}
+	s.finishClickHouse(ctx, result, exchangeIDs)
+	return result, nil
+}
+
+// ResumeClickHouse re-runs the ClickHouse step of a logged merge or split,
+// for when it failed or the service ran without ClickHouse. Tables already
+// finished have no rows left under the old ID and are passed over quickly.
+func (s *Service) ResumeClickHouse(ctx context.Context, logID int) (*Result, error) {
+	if s.ch == nil {
+		return nil, errors.New("no ClickHouse connection")
+	}
+
+	result := &Result{LogID: logID, Moved: map[string]int64{}}
+	var exchanges []string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT action, source_token_id, target_token_id, COALESCE(exchanges, '{}')
+		FROM token_merge_log
+		WHERE id = $1
+	`, logID).Scan(&result.Action, &result.SourceTokenID, &result.TargetTokenID, pq.Array(&exchanges))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %d", ErrLogNotFound, logID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token merge log %d: %w", logID, err)
+	}
+	switch result.Action {
+	case ActionArchive, ActionRestore:
+		return nil, fmt.Errorf("%w: token merge log %d (%s) has no ClickHouse step", ErrNothingToMove, logID, result.Action)
+	case ActionMerge:
+		exchanges = nil
+	}
+
+	s.finishClickHouse(ctx, result, exchanges)
+	return result, nil
+}
+
+// finishClickHouse re-points ClickHouse rows for a committed change and
+// records the outcome on its log entry. Failures are reported on the result
+// rather than returned, since the PostgreSQL change cannot be undone.
+func (s *Service) finishClickHouse(ctx context.Context, result *Result, exchanges []string) {
+	if s.ch == nil {
+		return
+	}
+
+	tables, err := s.repointClickHouse(ctx, result.SourceTokenID, result.TargetTokenID, exchanges)
+	result.ClickHouseTables = tables
+
+	status, errText := StatusCompleted, sql.NullString{}
+	if err != nil {
+		result.ClickHouseError = err
+		status = StatusFailed
+		errText = sql.NullString{String: err.Error(), Valid: true}
+		s.logger.Error("Failed to re-point ClickHouse rows",
+			zap.Int("log_id", result.LogID),
+			zap.Strings("completed_tables", tables),
+			zap.Error(err))
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE token_merge_log
+		SET clickhouse_status = $2, clickhouse_error = $3, completed_at = NOW()
+		WHERE id = $1
+	`, result.LogID, status, errText); err != nil {
+		s.logger.Error("Failed to update token merge log", zap.Int("log_id", result.LogID), zap.Error(err))
+	}
+	result.ClickHouseStatus = status
+}
+
+func lockToken(ctx context.Context, tx *sql.Tx, id int, chain *sql.NullString) error {
+	var c sql.NullString
+	err := tx.QueryRowContext(ctx, `SELECT chain FROM tokens WHERE id = $1 FOR UPDATE`, id).Scan(&c)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", ErrTokenNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock token %d: %w", id, err)
+	}
+	if chain != nil {
+		*chain = c
+	}
+	return nil
+}
+
+func insertLog(ctx context.Context, tx *sql.Tx, action string, src, tgt int, exchanges []string, moved map[string]int64, performedBy, reason string) (int, error) {
+	details, err := json.Marshal(map[string]interface{}{"moved": moved})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode log details: %w", err)
+	}
+	if exchanges == nil {
+		exchanges = []string{}
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO token_merge_log (action, source_token_id, target_token_id, exchanges, details, performed_by, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, action, src, tgt, pq.Array(exchanges), details, performedBy, nullString(reason)).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write token merge log: %w", err)
+	}
+	return id, nil
+}
+
+func execCount(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
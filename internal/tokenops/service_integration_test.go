@@ -0,0 +1,267 @@
+//go:build integration
+
+package tokenops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/testutil"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+func TestMerge(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ch := testutil.ClickHouse(t)
+	ctx := context.Background()
+
+	ids := testutil.SeedTokens(t, pg, "BTC", "XBT", "USDT")
+	btc, xbt, usdt := ids["BTC"], ids["XBT"], ids["USDT"]
+	testutil.SeedSymbolMapping(t, pg, xbt, "kraken", "XBT", "XBT")
+	testutil.SeedTradingPair(t, pg, xbt, usdt, "kraken", "XBTUSDT")
+	testutil.SeedTradingPair(t, pg, xbt, btc, "kraken", "XBTBTC")
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	old := now.Add(-time.Hour)
+	seedCH := func(query string, args ...interface{}) {
+		t.Helper()
+		if err := ch.Exec(ctx, query, args...); err != nil {
+			t.Fatalf("seeding clickhouse: %v", err)
+		}
+	}
+	seedCH(`INSERT INTO trades (timestamp, exchange_id, base_token_id, quote_token_id, symbol, price, quantity, trade_id, is_buyer_maker)
+		VALUES (?, 'kraken', ?, ?, 'XBTUSDT', ?, ?, 1, 0)`, now, uint32(xbt), uint32(usdt), decimal.NewFromInt(100), decimal.NewFromInt(2))
+	// A candle older than anything left in trades must be carried over as is
+	seedCH(`INSERT INTO trades_ohlcv_1m
+		SELECT ?, ?, 'kraken', toStartOfMinute(ts), argMinState(p, ts), maxState(p), minState(p), argMaxState(p, ts), sumState(p), countState()
+		FROM (SELECT toDateTime64(?, 3) AS ts, toDecimal64('100', 8) AS p)
+		GROUP BY ts`, uint32(xbt), uint32(usdt), old.Unix())
+
+	svc := NewService(pg, ch, zap.NewNop())
+	if _, err := svc.Merge(ctx, MergeRequest{SourceTokenID: xbt, TargetTokenID: xbt, PerformedBy: "test"}); !errors.Is(err, ErrSameToken) {
+		t.Errorf("merging into itself: %v, want ErrSameToken", err)
+	}
+
+	result, err := svc.Merge(ctx, MergeRequest{SourceTokenID: xbt, TargetTokenID: btc, PerformedBy: "test", Reason: "duplicate"})
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if result.ClickHouseError != nil || result.ClickHouseStatus != StatusCompleted {
+		t.Fatalf("ClickHouse step = %s, %v", result.ClickHouseStatus, result.ClickHouseError)
+	}
+	if result.Moved["token_exchange_symbols"] != 1 || result.Moved["trading_pairs"] != 2 || result.Moved["trading_pairs_deactivated"] != 1 {
+		t.Errorf("moved = %v", result.Moved)
+	}
+
+	var mapped int
+	pg.QueryRow(`SELECT token_id FROM token_exchange_symbols WHERE exchange_id = 'kraken' AND exchange_symbol = 'XBT'`).Scan(&mapped)
+	if mapped != btc {
+		t.Errorf("kraken XBT maps to %d, want %d", mapped, btc)
+	}
+	var selfPairActive, sourceActive bool
+	pg.QueryRow(`SELECT is_active FROM trading_pairs WHERE exchange_pair_symbol = 'XBTBTC'`).Scan(&selfPairActive)
+	pg.QueryRow(`SELECT is_active FROM tokens WHERE id = $1`, xbt).Scan(&sourceActive)
+	if selfPairActive || sourceActive {
+		t.Errorf("self pair active = %v, source token active = %v; want both deactivated", selfPairActive, sourceActive)
+	}
+	var status string
+	pg.QueryRow(`SELECT clickhouse_status FROM token_merge_log WHERE id = $1`, result.LogID).Scan(&status)
+	if status != StatusCompleted {
+		t.Errorf("log status = %q", status)
+	}
+
+	count := func(query string, tokenID int) uint64 {
+		t.Helper()
+		var n uint64
+		if err := ch.QueryRow(ctx, query, uint32(tokenID)).Scan(&n); err != nil {
+			t.Fatalf("counting: %v", err)
+		}
+		return n
+	}
+	if n := count(`SELECT count() FROM trades WHERE base_token_id = ?`, btc); n != 1 {
+		t.Errorf("%d trades under the target, want 1", n)
+	}
+	if n := count(`SELECT count() FROM trades WHERE base_token_id = ?`, xbt); n != 0 {
+		t.Errorf("%d trades left under the source", n)
+	}
+	// One candle rebuilt from the copied trade, one carried over
+	if n := count(`SELECT countMerge(trades_count) FROM trades_ohlcv_1m WHERE base_token_id = ?`, btc); n != 2 {
+		t.Errorf("target candles count %d trades, want 2", n)
+	}
+	if n := count(`SELECT count() FROM trades_ohlcv_1m WHERE base_token_id = ?`, xbt); n != 0 {
+		t.Errorf("%d candle rows left under the source", n)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ctx := context.Background()
+
+	ids := testutil.SeedTokens(t, pg, "GMT", "USDT")
+	gmt := ids["GMT"]
+	testutil.SeedSymbolMapping(t, pg, gmt, "binance", "GMT", "GMT")
+	testutil.SeedSymbolMapping(t, pg, gmt, "gate", "GMT", "GMT")
+	testutil.SeedTradingPair(t, pg, gmt, ids["USDT"], "gate", "GMT_USDT")
+
+	svc := NewService(pg, nil, zap.NewNop())
+	if _, err := svc.Split(ctx, SplitRequest{SourceTokenID: gmt, Exchanges: []string{"okx"}, Symbol: "GMT", Name: "GoMining", Chain: "ethereum", PerformedBy: "test"}); !errors.Is(err, ErrNothingToMove) {
+		t.Errorf("split with nothing on the exchange: %v, want ErrNothingToMove", err)
+	}
+	if _, err := svc.Split(ctx, SplitRequest{SourceTokenID: gmt, Exchanges: []string{"gate"}, Symbol: "GMT", Name: "GoMining", PerformedBy: "test"}); !errors.Is(err, ErrTokenExists) {
+		t.Errorf("split onto a second chain-agnostic GMT: %v, want ErrTokenExists", err)
+	}
+
+	result, err := svc.Split(ctx, SplitRequest{SourceTokenID: gmt, Exchanges: []string{"Gate"}, Symbol: "GMT", Name: "GoMining", Chain: "ethereum", PerformedBy: "test"})
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	if result.TargetTokenID == gmt || result.ClickHouseStatus != StatusPending {
+		t.Fatalf("result = %+v", result)
+	}
+
+	var gate, binance int
+	var gateChain string
+	pg.QueryRow(`SELECT token_id, chain FROM token_exchange_symbols WHERE exchange_id = 'gate'`).Scan(&gate, &gateChain)
+	pg.QueryRow(`SELECT token_id FROM token_exchange_symbols WHERE exchange_id = 'binance'`).Scan(&binance)
+	if gate != result.TargetTokenID || gateChain != "ethereum" || binance != gmt {
+		t.Errorf("gate -> %d on %q, binance -> %d; want gate on the new token %d, binance on %d", gate, gateChain, binance, result.TargetTokenID, gmt)
+	}
+	var pairBase int
+	pg.QueryRow(`SELECT base_token_id FROM trading_pairs WHERE exchange_pair_symbol = 'GMT_USDT'`).Scan(&pairBase)
+	if pairBase != result.TargetTokenID {
+		t.Errorf("gate pair base = %d, want %d", pairBase, result.TargetTokenID)
+	}
+}
+
+func TestArchiveRestore(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ctx := context.Background()
+
+	ids := testutil.SeedTokens(t, pg, "LUNA", "UST", "USDT")
+	luna, ust, usdt := ids["LUNA"], ids["UST"], ids["USDT"]
+	testutil.SeedSymbolMapping(t, pg, luna, "kraken", "LUNA", "LUNA")
+	testutil.SeedTradingPair(t, pg, luna, usdt, "kraken", "LUNAUSDT")
+	testutil.SeedTradingPair(t, pg, luna, ust, "kraken", "LUNAUST")
+
+	svc := NewService(pg, nil, zap.NewNop())
+	if _, err := svc.Restore(ctx, RestoreRequest{TokenID: luna, PerformedBy: "test"}); !errors.Is(err, ErrTokenNotArchived) {
+		t.Errorf("restoring an active token: %v, want ErrTokenNotArchived", err)
+	}
+
+	if _, err := svc.Archive(ctx, ArchiveRequest{TokenID: ust, PerformedBy: "test"}); err != nil {
+		t.Fatalf("Archive UST: %v", err)
+	}
+	result, err := svc.Archive(ctx, ArchiveRequest{TokenID: luna, PerformedBy: "test", Reason: "collapsed"})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	// LUNAUST was tombstoned with UST
+	if result.Moved["token_exchange_symbols"] != 1 || result.Moved["trading_pairs"] != 1 || result.ClickHouseStatus != StatusCompleted {
+		t.Errorf("archive result = %+v", result)
+	}
+	var active, archived int
+	pg.QueryRow(`SELECT COUNT(*) FILTER (WHERE is_active), COUNT(*) FILTER (WHERE archived_at IS NOT NULL)
+		FROM trading_pairs WHERE base_token_id = $1`, luna).Scan(&active, &archived)
+	if active != 0 || archived != 2 {
+		t.Errorf("pairs after archive: %d active, %d tombstoned", active, archived)
+	}
+	if _, err := pg.Exec(`DELETE FROM tokens WHERE id = $1`, luna); err == nil {
+		t.Error("deleting a token with mappings succeeded")
+	}
+
+	if _, err := svc.Restore(ctx, RestoreRequest{TokenID: luna, PerformedBy: "test"}); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	var lunaUSDT, lunaUST bool
+	pg.QueryRow(`SELECT is_active FROM trading_pairs WHERE exchange_pair_symbol = 'LUNAUSDT'`).Scan(&lunaUSDT)
+	pg.QueryRow(`SELECT is_active FROM trading_pairs WHERE exchange_pair_symbol = 'LUNAUST'`).Scan(&lunaUST)
+	if !lunaUSDT || lunaUST {
+		t.Errorf("after restore LUNAUSDT active = %v, LUNAUST active = %v (UST is still archived)", lunaUSDT, lunaUST)
+	}
+
+	// The job archives tokens left inactive; a trigger stamps updated_at, so
+	// the deactivation is only older than now
+	if _, err := pg.Exec(`UPDATE tokens SET is_active = false WHERE id = $1`, usdt); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := svc.ArchiveInactive(ctx, time.Hour); err != nil || len(ids) != 0 {
+		t.Errorf("ArchiveInactive(1h) = %v, %v", ids, err)
+	}
+	archivedIDs, err := svc.ArchiveInactive(ctx, 0)
+	if err != nil || len(archivedIDs) != 1 || archivedIDs[0] != usdt {
+		t.Errorf("ArchiveInactive = %v, %v", archivedIDs, err)
+	}
+}
+
+func TestTokenCRUD(t *testing.T) {
+	pg := testutil.Postgres(t)
+	ctx := context.Background()
+	svc := NewService(pg, nil, zap.NewNop())
+
+	token, err := svc.CreateToken(ctx, TokenInput{
+		Symbol:          " usdc ",
+		Name:            "USD Coin",
+		Chain:           "Ethereum",
+		ContractAddress: "0xA0B86991C6218B36C1D19D4A2E9EB0CE3606EB48",
+		Decimals:        6,
+		Metadata:        map[string]interface{}{"urls": map[string]interface{}{"website": []string{"https://circle.com"}}},
+		Contracts: []Contract{
+			{Platform: "Ethereum", Address: "0xA0B86991C6218B36C1D19D4A2E9EB0CE3606EB48"},
+			{Platform: "BNB Smart Chain (BEP20)", Address: "0x8ac76a51cc950d9822d68b83fe1ad97b32cd580d"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	if token.Symbol != "USDC" || token.Chain != "ethereum" || token.ContractAddress != "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48" ||
+		!token.Active || len(token.Contracts) != 2 {
+		t.Errorf("created token = %+v", token)
+	}
+	var chain string
+	pg.QueryRow(`SELECT chain FROM token_contracts WHERE token_id = $1 AND position = 2`, token.ID).Scan(&chain)
+	if chain != "bnb-smart-chain-bep20" {
+		t.Errorf("second contract chain = %q", chain)
+	}
+
+	if _, err := svc.CreateToken(ctx, TokenInput{Symbol: "USDC.e", Name: "Bridged", Chain: "ethereum",
+		ContractAddress: token.ContractAddress}); !errors.Is(err, ErrTokenExists) {
+		t.Errorf("creating a token with a taken contract: %v, want ErrTokenExists", err)
+	}
+
+	// Metadata is merged and contracts are only replaced when given
+	updated, err := svc.UpdateToken(ctx, token.ID, TokenInput{
+		Symbol:          "USDC",
+		Name:            "USDC",
+		Chain:           "ethereum",
+		ContractAddress: token.ContractAddress,
+		Decimals:        6,
+		Metadata:        map[string]interface{}{"coingecko_id": "usd-coin"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateToken: %v", err)
+	}
+	var meta map[string]interface{}
+	json.Unmarshal(updated.Metadata, &meta)
+	if updated.Name != "USDC" || meta["coingecko_id"] != "usd-coin" || meta["urls"] == nil || len(updated.Contracts) != 2 {
+		t.Errorf("updated token = %+v, metadata %v", updated, meta)
+	}
+
+	deactivated, err := svc.DeactivateToken(ctx, token.ID)
+	if err != nil || deactivated.Active {
+		t.Fatalf("DeactivateToken = %+v, %v", deactivated, err)
+	}
+	if _, err := svc.Archive(ctx, ArchiveRequest{TokenID: token.ID, PerformedBy: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	active := true
+	if _, err := svc.UpdateToken(ctx, token.ID, TokenInput{Symbol: "USDC", Name: "USDC", Active: &active}); !errors.Is(err, ErrTokenArchived) {
+		t.Errorf("activating an archived token: %v, want ErrTokenArchived", err)
+	}
+	if _, err := svc.DeactivateToken(ctx, 1<<30); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("deactivating a missing token: %v, want ErrTokenNotFound", err)
+	}
+}
@@ -0,0 +1,331 @@
+package tokenops
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrInvalidToken is returned when a token to write lacks a symbol or a
+	// name, or its metadata and contracts disagree
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrTokenArchived is returned when activating an archived token, which
+	// Restore does instead
+	ErrTokenArchived = errors.New("token is archived")
+)
+
+// nonAlphanumeric turns contract platforms into chain IDs as tokenctl seeds
+// store them in token_contracts
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Contract is one chain a token is deployed on
+type Contract struct {
+	Platform string // as the source names it, e.g. "BNB Smart Chain (BEP20)"
+	Address  string
+	RPCURLs  []string
+}
+
+// TokenInput is a token as the admin API writes it
+type TokenInput struct {
+	Symbol          string
+	Name            string
+	Slug            string
+	Chain           string // empty for the chain-agnostic row exchanges quote
+	ContractAddress string
+	Decimals        int
+	// Metadata keys are merged into the stored metadata, as seeds do
+	Metadata map[string]interface{}
+	// Contracts replace the token's contracts when not nil; the first is the
+	// primary one. Chain and ContractAddress are not derived from them.
+	Contracts []Contract
+	Active    *bool // nil keeps the current state, or creates an active token
+}
+
+// Token is a token row as the admin API edits it
+type Token struct {
+	ID              int
+	Symbol          string
+	Name            string
+	Slug            string
+	Chain           string
+	ContractAddress string
+	Decimals        int
+	Metadata        json.RawMessage
+	Contracts       []Contract
+	Active          bool
+	Archived        bool
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// GetToken returns a token by ID, active, inactive or archived
+func (s *Service) GetToken(ctx context.Context, id int) (*Token, error) {
+	var t Token
+	var createdAt, updatedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, symbol, name, COALESCE(slug, ''), COALESCE(chain, ''), COALESCE(contract_address, ''),
+		       COALESCE(decimals, 18), COALESCE(metadata, '{}'), COALESCE(is_active, false), is_archived,
+		       created_at, updated_at
+		FROM tokens WHERE id = $1
+	`, id).Scan(&t.ID, &t.Symbol, &t.Name, &t.Slug, &t.Chain, &t.ContractAddress,
+		&t.Decimals, &t.Metadata, &t.Active, &t.Archived, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %d", ErrTokenNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token %d: %w", id, err)
+	}
+	t.CreatedAt, t.UpdatedAt = createdAt.Time, updatedAt.Time
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT platform, contract_address, rpc_urls FROM token_contracts
+		WHERE token_id = $1 ORDER BY position, id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contracts of token %d: %w", id, err)
+	}
+	defer rows.Close()
+	t.Contracts = []Contract{}
+	for rows.Next() {
+		var c Contract
+		if err := rows.Scan(&c.Platform, &c.Address, pq.Array(&c.RPCURLs)); err != nil {
+			return nil, fmt.Errorf("failed to scan contract: %w", err)
+		}
+		t.Contracts = append(t.Contracts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query contracts of token %d: %w", id, err)
+	}
+	return &t, nil
+}
+
+// CreateToken adds a token. It returns ErrTokenExists when another token has
+// the symbol without a chain, or the chain and contract address.
+func (s *Service) CreateToken(ctx context.Context, in TokenInput) (*Token, error) {
+	row, metadata, err := normalizeToken(in)
+	if err != nil {
+		return nil, err
+	}
+	active := in.Active == nil || *in.Active
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO tokens (symbol, name, slug, chain, contract_address, decimals, metadata, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, row.Symbol, row.Name, nullString(row.Slug), nullString(row.Chain), nullString(row.ContractAddress),
+		row.Decimals, metadata, active).Scan(&id)
+	if err := tokenConflict(err); err != nil {
+		return nil, fmt.Errorf("failed to create token %s: %w", row.Symbol, err)
+	}
+	if in.Contracts != nil {
+		if err := replaceContracts(ctx, tx, id, row.Contracts); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit token %s: %w", row.Symbol, err)
+	}
+
+	s.logger.Info("Created token", zap.Int("token_id", id), zap.String("symbol", row.Symbol), zap.String("chain", row.Chain))
+	return s.GetToken(ctx, id)
+}
+
+// UpdateToken replaces the identity of a token: its symbol, name, slug,
+// chain, contract address and decimals. Metadata and contracts are changed as
+// TokenInput describes, market data is left alone. An archived token can be
+// corrected but not activated; ErrTokenArchived points at Restore instead.
+func (s *Service) UpdateToken(ctx context.Context, id int, in TokenInput) (*Token, error) {
+	row, metadata, err := normalizeToken(in)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockToken(ctx, tx, id, nil); err != nil {
+		return nil, err
+	}
+	var archived bool
+	if err := tx.QueryRowContext(ctx, `SELECT is_archived FROM tokens WHERE id = $1`, id).Scan(&archived); err != nil {
+		return nil, fmt.Errorf("failed to read token %d: %w", id, err)
+	}
+	if archived && in.Active != nil && *in.Active {
+		return nil, fmt.Errorf("%w: restore token %d to activate it", ErrTokenArchived, id)
+	}
+
+	var active sql.NullBool
+	if in.Active != nil {
+		active = sql.NullBool{Bool: *in.Active, Valid: true}
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tokens SET
+			symbol = $2, name = $3, slug = $4, chain = $5, contract_address = $6, decimals = $7,
+			metadata = COALESCE(metadata, '{}') || $8::jsonb,
+			is_active = COALESCE($9, is_active)
+		WHERE id = $1
+	`, id, row.Symbol, row.Name, nullString(row.Slug), nullString(row.Chain), nullString(row.ContractAddress),
+		row.Decimals, metadata, active)
+	if err := tokenConflict(err); err != nil {
+		return nil, fmt.Errorf("failed to update token %d: %w", id, err)
+	}
+	if in.Contracts != nil {
+		if err := replaceContracts(ctx, tx, id, row.Contracts); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit token %d: %w", id, err)
+	}
+
+	s.logger.Info("Updated token", zap.Int("token_id", id), zap.String("symbol", row.Symbol), zap.String("chain", row.Chain))
+	return s.GetToken(ctx, id)
+}
+
+// DeactivateToken takes a token out of token listings and symbol lookups.
+// Its mappings and pairs are kept active, so prices are still stored for it;
+// Archive tombstones them too, and with TOKEN_ARCHIVE_AFTER set the archival
+// job archives the token once it has stayed inactive that long.
+func (s *Service) DeactivateToken(ctx context.Context, id int) (*Token, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE tokens SET is_active = false WHERE id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deactivate token %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrTokenNotFound, id)
+	}
+
+	s.logger.Info("Deactivated token", zap.Int("token_id", id))
+	return s.GetToken(ctx, id)
+}
+
+// normalizeToken trims the input the way the chain identity migration
+// normalized existing rows and encodes the metadata to merge. Contracts are
+// keyed by chain and address, so later duplicates are dropped.
+func normalizeToken(in TokenInput) (TokenInput, []byte, error) {
+	in.Symbol = strings.ToUpper(strings.TrimSpace(in.Symbol))
+	in.Name = strings.TrimSpace(in.Name)
+	in.Slug = strings.TrimSpace(in.Slug)
+	in.Chain = strings.ToLower(strings.TrimSpace(in.Chain))
+	in.ContractAddress = normalizeAddress(in.ContractAddress)
+	if in.Symbol == "" || in.Name == "" {
+		return in, nil, fmt.Errorf("%w: symbol and name are required", ErrInvalidToken)
+	}
+	if in.ContractAddress != "" && in.Chain == "" {
+		return in, nil, fmt.Errorf("%w: a contract address needs a chain", ErrInvalidToken)
+	}
+
+	metadata := make(map[string]interface{}, len(in.Metadata)+1)
+	for k, v := range in.Metadata {
+		metadata[k] = v
+	}
+	if _, ok := metadata["contracts"]; ok {
+		return in, nil, fmt.Errorf("%w: set contracts instead of metadata.contracts", ErrInvalidToken)
+	}
+
+	if in.Contracts != nil {
+		seen := make(map[string]bool)
+		contracts := make([]Contract, 0, len(in.Contracts))
+		listed := make([]map[string]interface{}, 0, len(in.Contracts))
+		for _, c := range in.Contracts {
+			c.Platform = strings.TrimSpace(c.Platform)
+			c.Address = normalizeAddress(c.Address)
+			if c.RPCURLs == nil {
+				c.RPCURLs = []string{}
+			}
+			chain := contractChain(c.Platform)
+			if chain == "" || c.Address == "" {
+				return in, nil, fmt.Errorf("%w: contracts need a platform and an address", ErrInvalidToken)
+			}
+			if seen[chain+"\x00"+c.Address] {
+				continue
+			}
+			seen[chain+"\x00"+c.Address] = true
+			contracts = append(contracts, c)
+			// token detail reads contracts from metadata, in the seed's shape
+			listed = append(listed, map[string]interface{}{
+				"contract_address": c.Address,
+				"platform":         c.Platform,
+				"rpc_urls":         c.RPCURLs,
+				"number":           len(contracts),
+			})
+		}
+		in.Contracts = contracts
+		metadata["contracts"] = listed
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return in, nil, fmt.Errorf("%w: metadata: %v", ErrInvalidToken, err)
+	}
+	return in, encoded, nil
+}
+
+// replaceContracts sets the token_contracts rows of a token to contracts
+func replaceContracts(ctx context.Context, tx *sql.Tx, id int, contracts []Contract) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM token_contracts WHERE token_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to clear contracts of token %d: %w", id, err)
+	}
+	for i, c := range contracts {
+		platform := c.Platform
+		if len(platform) > 100 {
+			platform = platform[:100]
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO token_contracts (token_id, chain, platform, contract_address, rpc_urls, position, is_primary)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, id, contractChain(c.Platform), platform, c.Address, pq.Array(c.RPCURLs), i+1, i == 0); err != nil {
+			return fmt.Errorf("failed to insert contract of token %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// tokenConflict turns a unique violation into ErrTokenExists
+func tokenConflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("%w: %s", ErrTokenExists, pqErr.Detail)
+	}
+	return err
+}
+
+// contractChain turns a contract platform into the chain ID token_contracts
+// stores: "BNB Smart Chain (BEP20)" -> "bnb-smart-chain-bep20"
+func contractChain(platform string) string {
+	chain := strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(strings.TrimSpace(platform)), "-"), "-")
+	if len(chain) > 50 {
+		chain = strings.TrimRight(chain[:50], "-")
+	}
+	return chain
+}
+
+// normalizeAddress lowercases EVM addresses, which are case-insensitive hex,
+// and leaves other chains' addresses as they are
+func normalizeAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if evmAddress.MatchString(address) {
+		return strings.ToLower(address)
+	}
+	return address
+}
@@ -0,0 +1,138 @@
+// Package trust scores how far each exchange's reported volume can be
+// trusted, from heuristics that flag wash trading: trades undone at once by
+// a trade of the same size on the other side, and volume far above the
+// other venues' for a pair whose price barely moved there. Order book depth,
+// the other usual signal, is not collected, so it does not count.
+package trust
+
+import (
+	"math"
+
+	"github.com/ashmitsharp/trading/internal/outlier"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// minTrades is the fewest trades of an exchange whose round trips count
+	minTrades = 1000
+	// roundTripBaseline is the share of round-trip trades, in percent, that
+	// honest venues reach with round lot sizes; the penalty starts above it
+	roundTripBaseline = 5.0
+	// roundTripFull is the share at which the round-trip penalty is maxed
+	roundTripFull = 30.0
+
+	// minPairs is the fewest compared pairs of an exchange whose mismatches
+	// count
+	minPairs = 5
+	// mismatchBaseline and mismatchFull bound the mismatch penalty, in
+	// percent of the exchange's compared pairs
+	mismatchBaseline = 10.0
+	mismatchFull     = 50.0
+
+	// maxPenalty is what each heuristic can take off a score of 1
+	maxPenalty = 0.5
+
+	// minVenues is the fewest exchanges listing a pair to compare them
+	minVenues = 3
+	// mismatchVolumeRatio is how many times the pair's median volume a
+	// venue reports to be suspicious ...
+	mismatchVolumeRatio = 3.0
+	// mismatchRangeRatio ... when its 24h price range is at most this
+	// fraction of the median range
+	mismatchRangeRatio = 0.5
+)
+
+// Signals are the heuristics' findings for an exchange
+type Signals struct {
+	ExchangeID string
+	// Trades is the number of trades checked for round trips, and
+	// RoundTripPct the percentage of them that a trade of the same size on
+	// the other side undid within a few seconds
+	Trades       uint64
+	RoundTripPct float64
+	// Pairs is the number of pairs compared with other venues, and
+	// MismatchPct the percentage of them where the exchange reported far more
+	// volume than the others while its price barely moved
+	Pairs       int
+	MismatchPct float64
+}
+
+// Score turns the signals into a trust score from 0 to 1, 1 meaning nothing
+// suspicious. A heuristic with too little data to judge takes nothing off.
+func Score(s Signals) float64 {
+	score := 1.0
+	if s.Trades >= minTrades {
+		score -= maxPenalty * ramp(s.RoundTripPct, roundTripBaseline, roundTripFull)
+	}
+	if s.Pairs >= minPairs {
+		score -= maxPenalty * ramp(s.MismatchPct, mismatchBaseline, mismatchFull)
+	}
+	return math.Round(score*1000) / 1000
+}
+
+// ramp rises linearly from 0 at from to 1 at to
+func ramp(v, from, to float64) float64 {
+	return math.Max(0, math.Min(1, (v-from)/(to-from)))
+}
+
+// Listing is an exchange's latest 24h ticker for a pair
+type Listing struct {
+	ExchangeID   string
+	BaseTokenID  int
+	QuoteTokenID int
+	Price        decimal.Decimal
+	Volume       decimal.Decimal // in the base token
+	High         decimal.Decimal
+	Low          decimal.Decimal
+}
+
+// Mismatch is, per exchange, the pairs compared with other venues and
+// those where it reported far more volume with far less price movement
+type Mismatch struct {
+	Pairs      int
+	Mismatched int
+}
+
+type pairKey struct {
+	base, quote int
+}
+
+// Mismatches compares each exchange's volume and 24h price range on every
+// pair at least minVenues exchanges list with the pair's medians. Listings
+// without a price, volume or range are left out.
+func Mismatches(listings []Listing) map[string]Mismatch {
+	byPair := make(map[pairKey][]Listing)
+	for _, l := range listings {
+		if !l.Price.IsPositive() || !l.Volume.IsPositive() || !l.High.GreaterThan(l.Low) {
+			continue
+		}
+		k := pairKey{l.BaseTokenID, l.QuoteTokenID}
+		byPair[k] = append(byPair[k], l)
+	}
+
+	out := make(map[string]Mismatch)
+	for _, pair := range byPair {
+		if len(pair) < minVenues {
+			continue
+		}
+		volumes := make([]decimal.Decimal, len(pair))
+		ranges := make([]decimal.Decimal, len(pair))
+		for i, l := range pair {
+			volumes[i] = l.Volume
+			ranges[i] = l.High.Sub(l.Low).Div(l.Price)
+		}
+		medianVolume := outlier.Median(volumes).InexactFloat64()
+		medianRange := outlier.Median(ranges).InexactFloat64()
+
+		for i, l := range pair {
+			m := out[l.ExchangeID]
+			m.Pairs++
+			if volumes[i].InexactFloat64() >= mismatchVolumeRatio*medianVolume &&
+				ranges[i].InexactFloat64() <= mismatchRangeRatio*medianRange {
+				m.Mismatched++
+			}
+			out[l.ExchangeID] = m
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package trust
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name string
+		sig  Signals
+		want float64
+	}{
+		{"nothing to judge", Signals{}, 1},
+		{"round trips at baseline", Signals{Trades: 5000, RoundTripPct: 5}, 1},
+		{"few trades", Signals{Trades: 10, RoundTripPct: 90}, 1},
+		{"half-way round trips", Signals{Trades: 5000, RoundTripPct: 17.5}, 0.75},
+		{"both maxed", Signals{Trades: 5000, RoundTripPct: 60, Pairs: 10, MismatchPct: 80}, 0},
+		{"mismatches", Signals{Pairs: 10, MismatchPct: 30}, 0.75},
+	}
+	for _, tt := range tests {
+		if got := Score(tt.sig); got != tt.want {
+			t.Errorf("%s: Score = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMismatches(t *testing.T) {
+	d := decimal.NewFromInt
+	listing := func(exchange string, quote, volume, high, low int64) Listing {
+		return Listing{ExchangeID: exchange, BaseTokenID: 1, QuoteTokenID: int(quote),
+			Price: d(100), Volume: d(volume), High: d(high), Low: d(low)}
+	}
+	listings := []Listing{
+		// binance reports 10x the volume of the others with a flat price
+		listing("binance", 2, 1000, 101, 100),
+		listing("kraken", 2, 100, 110, 90),
+		listing("gate", 2, 100, 108, 92),
+		// heavy volume that moved the price is fine
+		listing("binance", 3, 1000, 120, 80),
+		listing("kraken", 3, 100, 110, 90),
+		listing("gate", 3, 100, 110, 90),
+		// two venues are too few to compare
+		listing("binance", 4, 1000, 101, 100),
+		listing("kraken", 4, 10, 120, 80),
+	}
+
+	got := Mismatches(listings)
+	want := map[string]Mismatch{
+		"binance": {Pairs: 2, Mismatched: 1},
+		"kraken":  {Pairs: 2},
+		"gate":    {Pairs: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for id, w := range want {
+		if got[id] != w {
+			t.Errorf("%s: got %+v, want %+v", id, got[id], w)
+		}
+	}
+}
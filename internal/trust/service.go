@@ -0,0 +1,192 @@
+package trust
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/db"
+	"go.uber.org/zap"
+)
+
+const (
+	// tradeWindow is how far back trades are checked for round trips
+	tradeWindow = 24 * time.Hour
+	// roundTripWindow is how soon a trade must be undone to count as a
+	// round trip
+	roundTripWindow = 5 * time.Second
+	// tickerWindow is how far back each exchange's latest ticker is read
+	tickerWindow = time.Hour
+)
+
+// Service scores the exchanges' trust from the stored trades and tickers
+type Service struct {
+	postgresDB     *sql.DB
+	clickhouseConn driver.Conn
+	logger         *zap.Logger
+}
+
+// NewService creates a new trust scoring service
+func NewService(postgresDB *sql.DB, clickhouseConn driver.Conn, logger *zap.Logger) *Service {
+	return &Service{
+		postgresDB:     postgresDB,
+		clickhouseConn: clickhouseConn,
+		logger:         logger,
+	}
+}
+
+// Rescore scores every active exchange and stores the scores. Exchanges
+// without trades or pairs to judge them by score 1.
+func (s *Service) Rescore(ctx context.Context) ([]db.ExchangeTrust, error) {
+	trades, err := s.roundTrips(ctx)
+	if err != nil {
+		return nil, err
+	}
+	listings, err := s.listings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := db.ListExchanges(ctx, s.postgresDB, false)
+	if err != nil {
+		return nil, err
+	}
+	mismatches := Mismatches(listings)
+
+	scores := make([]db.ExchangeTrust, 0, len(registry))
+	for _, e := range registry {
+		sig := Signals{ExchangeID: e.ID}
+		if t, ok := trades[e.ID]; ok && t.trades > 0 {
+			sig.Trades = t.trades
+			sig.RoundTripPct = 100 * float64(t.roundTrips) / float64(t.trades)
+		}
+		if m, ok := mismatches[e.ID]; ok && m.Pairs > 0 {
+			sig.Pairs = m.Pairs
+			sig.MismatchPct = 100 * float64(m.Mismatched) / float64(m.Pairs)
+		}
+		scores = append(scores, db.ExchangeTrust{
+			ExchangeID:   e.ID,
+			Score:        Score(sig),
+			Trades:       int64(sig.Trades),
+			RoundTripPct: sig.RoundTripPct,
+			Pairs:        sig.Pairs,
+			MismatchPct:  sig.MismatchPct,
+		})
+	}
+
+	if err := db.SaveExchangeTrust(ctx, s.postgresDB, scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+type tradeCount struct {
+	trades     uint64
+	roundTrips uint64
+}
+
+// roundTrips counts each exchange's trades from the last tradeWindow and
+// those in a round trip: trades of one size on a pair within the same
+// roundTripWindow that buyers and sellers both took
+func (s *Service) roundTrips(ctx context.Context) (map[string]tradeCount, error) {
+	rows, err := s.clickhouseConn.Query(ctx, `
+		SELECT exchange_id, sum(trades), sumIf(trades, sides = 2)
+		FROM (
+			SELECT
+				exchange_id,
+				count() AS trades,
+				uniqExact(is_buyer_maker) AS sides
+			FROM trades
+			WHERE timestamp >= now() - INTERVAL ? SECOND
+			GROUP BY exchange_id, base_token_id, quote_token_id, quantity,
+				toStartOfInterval(timestamp, INTERVAL ? SECOND)
+		)
+		GROUP BY exchange_id
+	`, int(tradeWindow.Seconds()), int(roundTripWindow.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("querying round trips: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]tradeCount)
+	for rows.Next() {
+		var id string
+		var c tradeCount
+		if err := rows.Scan(&id, &c.trades, &c.roundTrips); err != nil {
+			return nil, fmt.Errorf("scanning round trips: %w", err)
+		}
+		out[id] = c
+	}
+	return out, rows.Err()
+}
+
+// listings returns each exchange's latest ticker per pair from the last
+// tickerWindow
+func (s *Service) listings(ctx context.Context) ([]Listing, error) {
+	rows, err := s.clickhouseConn.Query(ctx, `
+		SELECT
+			exchange_id,
+			base_token_id,
+			quote_token_id,
+			argMax(price, timestamp),
+			argMax(volume_24h, timestamp),
+			argMax(high_24h, timestamp),
+			argMax(low_24h, timestamp)
+		FROM price_tickers
+		WHERE timestamp >= now() - INTERVAL ? SECOND
+			AND instrument_type = 'spot'
+			AND base_token_id > 0
+			AND quote_token_id > 0
+		GROUP BY exchange_id, base_token_id, quote_token_id
+	`, int(tickerWindow.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("querying tickers: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Listing
+	for rows.Next() {
+		var l Listing
+		var base, quote uint32
+		if err := rows.Scan(&l.ExchangeID, &base, &quote, &l.Price, &l.Volume, &l.High, &l.Low); err != nil {
+			return nil, fmt.Errorf("scanning ticker: %w", err)
+		}
+		l.BaseTokenID, l.QuoteTokenID = int(base), int(quote)
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// Run rescores the exchanges on start and then every interval until ctx is
+// done
+func (s *Service) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		scores, err := s.Rescore(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			s.logger.Error("Failed to score exchange trust", zap.Error(err))
+		case err == nil:
+			suspicious := 0
+			for _, t := range scores {
+				if t.Score < 1 {
+					suspicious++
+				}
+			}
+			s.logger.Info("Exchange trust scored",
+				zap.Int("exchanges", len(scores)),
+				zap.Int("below_full_trust", suspicious),
+				zap.Duration("took", time.Since(start)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
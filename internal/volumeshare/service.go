@@ -0,0 +1,232 @@
+package volumeshare
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// tickerWindow is how far back each exchange's latest ticker is read
+const tickerWindow = time.Hour
+
+// WeightSource gives each exchange's VWAP weight, and false for exchanges
+// without one
+type WeightSource interface {
+	Weight(exchangeID string) (float64, bool)
+}
+
+// Service computes the volume shares once a day into exchange_volume_share
+// and reads them back
+type Service struct {
+	postgresDB     *sql.DB
+	clickhouseConn driver.Conn
+	vwapStorage    *storage.VWAPStorage
+	weights        WeightSource
+	defaultWeight  float64
+	logger         *zap.Logger
+}
+
+// NewService creates a new volume share service. weights gives each
+// exchange's VWAP weight, defaultWeight the weight of the others, as in
+// the VWAP job.
+func NewService(postgresDB *sql.DB, clickhouseConn driver.Conn, vwapStorage *storage.VWAPStorage, weights WeightSource, defaultWeight float64, logger *zap.Logger) *Service {
+	return &Service{
+		postgresDB:     postgresDB,
+		clickhouseConn: clickhouseConn,
+		vwapStorage:    vwapStorage,
+		weights:        weights,
+		defaultWeight:  defaultWeight,
+		logger:         logger,
+	}
+}
+
+// ComputeAndStore computes the shares from each exchange's latest 24h
+// volume and stores them under day, replacing any computed for it before.
+// It returns the number of shares stored.
+func (s *Service) ComputeAndStore(ctx context.Context, day time.Time) (int, error) {
+	listings, err := s.listings(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	quoteIDs, err := db.GetUSDQuoteTokenIDs(ctx, s.postgresDB)
+	if err != nil {
+		return 0, err
+	}
+	summaries, err := s.vwapStorage.GetLatestVWAPByQuote(ctx, quoteIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get USD VWAP prices: %w", err)
+	}
+	usdPrices := make(map[int]decimal.Decimal, len(summaries)+len(quoteIDs))
+	for id, summary := range summaries {
+		usdPrices[id] = summary.Price
+	}
+	// Volume quoted in a USD quote without a VWAP of its own is valued at par
+	for _, id := range quoteIDs {
+		if _, ok := usdPrices[id]; !ok {
+			usdPrices[id] = decimal.NewFromInt(1)
+		}
+	}
+
+	weights := make(map[string]float64)
+	for _, l := range listings {
+		if w, ok := s.weights.Weight(l.ExchangeID); ok {
+			weights[l.ExchangeID] = w
+		}
+	}
+	shares := Compute(listings, usdPrices, weights, s.defaultWeight)
+	if err := s.store(ctx, day, shares); err != nil {
+		return 0, err
+	}
+	return len(shares), nil
+}
+
+// listings returns each exchange's latest volume per pair from the last
+// tickerWindow
+func (s *Service) listings(ctx context.Context) ([]Listing, error) {
+	rows, err := s.clickhouseConn.Query(ctx, `
+		SELECT
+			exchange_id,
+			base_token_id,
+			quote_token_id,
+			argMax(volume_24h, timestamp) AS latest_volume,
+			argMax(quote_volume_24h, timestamp) AS latest_quote_volume
+		FROM price_tickers
+		WHERE timestamp >= now() - INTERVAL ? SECOND
+			AND instrument_type = 'spot'
+			AND base_token_id > 0
+			AND quote_token_id > 0
+		GROUP BY exchange_id, base_token_id, quote_token_id
+	`, int(tickerWindow.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("querying tickers: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Listing
+	for rows.Next() {
+		var l Listing
+		var base, quote uint32
+		if err := rows.Scan(&l.ExchangeID, &base, &quote, &l.Volume, &l.QuoteVolume); err != nil {
+			return nil, fmt.Errorf("scanning ticker: %w", err)
+		}
+		l.BaseTokenID, l.QuoteTokenID = int(base), int(quote)
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+func (s *Service) store(ctx context.Context, day time.Time, shares []Share) error {
+	if len(shares) == 0 {
+		return nil
+	}
+
+	batch, err := s.clickhouseConn.PrepareBatch(ctx, `
+		INSERT INTO exchange_volume_share (
+			day, exchange_id, base_token_id, quote_token_id, volume, volume_usd, share_pct, weight, vwap_share_pct
+		)`)
+	if err != nil {
+		return fmt.Errorf("preparing volume share batch: %w", err)
+	}
+	for _, sh := range shares {
+		if err := batch.Append(
+			day,
+			sh.ExchangeID,
+			uint32(sh.BaseTokenID),
+			uint32(sh.QuoteTokenID),
+			sh.Volume,
+			sh.VolumeUSD,
+			sh.SharePct,
+			sh.Weight,
+			sh.VWAPSharePct,
+		); err != nil {
+			return fmt.Errorf("appending volume share: %w", err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("storing volume shares: %w", err)
+	}
+	return nil
+}
+
+// Shares returns the shares of a pair, or across all pairs when both token
+// IDs are 0, stored for day, or for the last day stored when day is zero,
+// by descending share. It returns the day read, and db.ErrNoData when
+// nothing is stored for it.
+func (s *Service) Shares(ctx context.Context, day time.Time, baseTokenID, quoteTokenID int) ([]Share, time.Time, error) {
+	if day.IsZero() {
+		if err := s.clickhouseConn.QueryRow(ctx, `
+			SELECT max(day) FROM exchange_volume_share WHERE base_token_id = 0 AND quote_token_id = 0
+		`).Scan(&day); err != nil {
+			return nil, time.Time{}, fmt.Errorf("querying latest volume share day: %w", err)
+		}
+		// max over no rows is the zero Date
+		if day.Unix() <= 0 {
+			return nil, time.Time{}, db.ErrNoData
+		}
+	}
+
+	rows, err := s.clickhouseConn.Query(ctx, `
+		SELECT exchange_id, volume, volume_usd, share_pct, weight, vwap_share_pct
+		FROM exchange_volume_share FINAL
+		WHERE day = ? AND base_token_id = ? AND quote_token_id = ?
+		ORDER BY share_pct DESC, exchange_id
+	`, day, uint32(baseTokenID), uint32(quoteTokenID))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("querying volume shares: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Share
+	for rows.Next() {
+		sh := Share{BaseTokenID: baseTokenID, QuoteTokenID: quoteTokenID}
+		if err := rows.Scan(&sh.ExchangeID, &sh.Volume, &sh.VolumeUSD, &sh.SharePct, &sh.Weight, &sh.VWAPSharePct); err != nil {
+			return nil, time.Time{}, fmt.Errorf("scanning volume share: %w", err)
+		}
+		out = append(out, sh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(out) == 0 {
+		return nil, time.Time{}, db.ErrNoData
+	}
+	return out, day, nil
+}
+
+// Run computes the shares every day at at past midnight UTC until ctx is
+// done, storing them under that UTC day
+func (s *Service) Run(ctx context.Context, at time.Duration) error {
+	for {
+		next := timeutil.NextDaily(time.Now(), at)
+		s.logger.Info("Next volume share run scheduled", zap.Time("at", next))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		stored, err := s.ComputeAndStore(ctx, start.UTC().Truncate(24*time.Hour))
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to compute volume shares", zap.Error(err))
+			}
+			continue
+		}
+		s.logger.Info("Volume shares computed",
+			zap.Int("shares", stored),
+			zap.Duration("took", time.Since(start)))
+	}
+}
@@ -0,0 +1,151 @@
+// Package volumeshare computes each exchange's share of the 24h volume we
+// observe, per pair and across all pairs, next to its share of the VWAP, so
+// the configured exchange weights can be checked against where trading
+// actually happens.
+package volumeshare
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// Listing is an exchange's latest 24h volume for a pair
+type Listing struct {
+	ExchangeID   string
+	BaseTokenID  int
+	QuoteTokenID int
+	Volume       decimal.Decimal // in the base token
+	QuoteVolume  decimal.Decimal // in the quote token
+}
+
+// Share is an exchange's share of the volume of a pair or, when
+// BaseTokenID and QuoteTokenID are 0, of all pairs
+type Share struct {
+	ExchangeID   string
+	BaseTokenID  int
+	QuoteTokenID int
+	Volume       decimal.Decimal // base volume, zero across all pairs
+	VolumeUSD    float64         // zero when neither token has a USD price
+	// SharePct is the exchange's share of the pair's base volume, or of
+	// the USD volume across all pairs
+	SharePct float64
+	Weight   float64
+	// VWAPSharePct is the exchange's volume times weight as a share of the
+	// total, its pull on the VWAP
+	VWAPSharePct float64
+}
+
+// Global reports whether the share is across all pairs
+func (s Share) Global() bool {
+	return s.BaseTokenID == 0 && s.QuoteTokenID == 0
+}
+
+type pairKey struct {
+	base, quote int
+}
+
+// Compute returns every exchange's share of each pair listed on it and of
+// all pairs. Listings without volume are left out. Volume is valued in USD
+// from the base token's price, or the quote token's when the base has none;
+// pairs with neither still get a share of their own volume but count
+// nothing towards the shares across all pairs. weights gives each
+// exchange's VWAP weight, defaultWeight being used for the others.
+// The shares across all pairs come first, then each pair's, each by
+// descending share.
+func Compute(listings []Listing, usdPrices map[int]decimal.Decimal, weights map[string]float64, defaultWeight float64) []Share {
+	weightOf := func(exchangeID string) float64 {
+		if w, ok := weights[exchangeID]; ok {
+			return w
+		}
+		return defaultWeight
+	}
+
+	byPair := make(map[pairKey][]Share)
+	for _, l := range listings {
+		if !l.Volume.IsPositive() {
+			continue
+		}
+		s := Share{
+			ExchangeID:   l.ExchangeID,
+			BaseTokenID:  l.BaseTokenID,
+			QuoteTokenID: l.QuoteTokenID,
+			Volume:       l.Volume,
+			Weight:       weightOf(l.ExchangeID),
+		}
+		if price, ok := usdPrices[l.BaseTokenID]; ok && price.IsPositive() {
+			s.VolumeUSD = l.Volume.Mul(price).InexactFloat64()
+		} else if price, ok := usdPrices[l.QuoteTokenID]; ok && price.IsPositive() {
+			s.VolumeUSD = l.QuoteVolume.Mul(price).InexactFloat64()
+		}
+		k := pairKey{l.BaseTokenID, l.QuoteTokenID}
+		byPair[k] = append(byPair[k], s)
+	}
+
+	pairs := make([]pairKey, 0, len(byPair))
+	for k := range byPair {
+		pairs = append(pairs, k)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].base != pairs[j].base {
+			return pairs[i].base < pairs[j].base
+		}
+		return pairs[i].quote < pairs[j].quote
+	})
+
+	var out []Share
+	global := make(map[string]*Share)
+	for _, k := range pairs {
+		shares := byPair[k]
+		volumes := make([]float64, len(shares))
+		for i, s := range shares {
+			volumes[i] = s.Volume.InexactFloat64()
+		}
+		shares = apportion(shares, volumes)
+		out = append(out, shares...)
+
+		for _, s := range shares {
+			g, ok := global[s.ExchangeID]
+			if !ok {
+				g = &Share{ExchangeID: s.ExchangeID, Weight: s.Weight}
+				global[s.ExchangeID] = g
+			}
+			g.VolumeUSD += s.VolumeUSD
+		}
+	}
+
+	totals := make([]Share, 0, len(global))
+	for _, g := range global {
+		totals = append(totals, *g)
+	}
+	volumes := make([]float64, len(totals))
+	for i, s := range totals {
+		volumes[i] = s.VolumeUSD
+	}
+	return append(apportion(totals, volumes), out...)
+}
+
+// apportion sets each share's SharePct from volumes and its VWAPSharePct
+// from volumes times weights, and orders the shares by descending share
+func apportion(shares []Share, volumes []float64) []Share {
+	var total, weighted float64
+	for i, s := range shares {
+		total += volumes[i]
+		weighted += volumes[i] * s.Weight
+	}
+	for i := range shares {
+		if total > 0 {
+			shares[i].SharePct = 100 * volumes[i] / total
+		}
+		if weighted > 0 {
+			shares[i].VWAPSharePct = 100 * volumes[i] * shares[i].Weight / weighted
+		}
+	}
+	sort.SliceStable(shares, func(i, j int) bool {
+		if shares[i].SharePct != shares[j].SharePct {
+			return shares[i].SharePct > shares[j].SharePct
+		}
+		return shares[i].ExchangeID < shares[j].ExchangeID
+	})
+	return shares
+}
@@ -0,0 +1,45 @@
+package volumeshare
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCompute(t *testing.T) {
+	d := decimal.NewFromInt
+	listings := []Listing{
+		{ExchangeID: "kraken", BaseTokenID: 1, QuoteTokenID: 2, Volume: d(1), QuoteVolume: d(100)},
+		{ExchangeID: "binance", BaseTokenID: 1, QuoteTokenID: 2, Volume: d(3), QuoteVolume: d(300)},
+		{ExchangeID: "mexc", BaseTokenID: 1, QuoteTokenID: 2, Volume: d(0)},
+		// Neither token has a USD price, so gate's volume counts only
+		// towards its own pair
+		{ExchangeID: "gate", BaseTokenID: 5, QuoteTokenID: 3, Volume: d(10), QuoteVolume: d(10)},
+	}
+	prices := map[int]decimal.Decimal{1: d(100), 2: d(1)}
+	weights := map[string]float64{"binance": 0.5, "kraken": 0.3}
+
+	got := Compute(listings, prices, weights, 0.1)
+
+	want := []Share{
+		{ExchangeID: "binance", VolumeUSD: 300, SharePct: 75, Weight: 0.5, VWAPSharePct: 83.333},
+		{ExchangeID: "kraken", VolumeUSD: 100, SharePct: 25, Weight: 0.3, VWAPSharePct: 16.667},
+		{ExchangeID: "gate", Weight: 0.1},
+		{ExchangeID: "binance", BaseTokenID: 1, QuoteTokenID: 2, Volume: d(3), VolumeUSD: 300, SharePct: 75, Weight: 0.5, VWAPSharePct: 83.333},
+		{ExchangeID: "kraken", BaseTokenID: 1, QuoteTokenID: 2, Volume: d(1), VolumeUSD: 100, SharePct: 25, Weight: 0.3, VWAPSharePct: 16.667},
+		{ExchangeID: "gate", BaseTokenID: 5, QuoteTokenID: 3, Volume: d(10), SharePct: 100, Weight: 0.1, VWAPSharePct: 100},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d shares, want %d: %+v", len(got), len(want), got)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 0.001 }
+	for i, w := range want {
+		g := got[i]
+		if g.ExchangeID != w.ExchangeID || g.BaseTokenID != w.BaseTokenID || g.QuoteTokenID != w.QuoteTokenID ||
+			!g.Volume.Equal(w.Volume) || !near(g.VolumeUSD, w.VolumeUSD) || !near(g.SharePct, w.SharePct) ||
+			g.Weight != w.Weight || !near(g.VWAPSharePct, w.VWAPSharePct) {
+			t.Errorf("share %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
@@ -2,231 +2,236 @@ package vwap
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/ashmitsharp/trading/internal/calculator"
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/features"
+	"github.com/ashmitsharp/trading/internal/storage"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
-// Service handles VWAP calculations and storage
+// defaultLookback bounds the tickers read when the calculator has no
+// staleness limit
+const defaultLookback = 5 * time.Minute
+
+// DefaultExchangeWeight is used for exchanges without a configured weight
+const DefaultExchangeWeight = 0.01
+
+// WeightSource gives each exchange's weight in the VWAP, and false for
+// exchanges without one
+type WeightSource interface {
+	Weight(exchangeID string) (float64, bool)
+}
+
+// Config holds the settings of the VWAP calculation loop
+type Config struct {
+	Calculator calculator.Config
+	// ExchangeWeights is each exchange's weight in the VWAP, static from the
+	// exchange registry or dynamic, see weighting.Weights
+	ExchangeWeights WeightSource
+	// MinMappingConfidence and FlaggedMappingWindow select the mappings whose
+	// tickers are left out of VWAP, see db.GetUntrustedMappings
+	MinMappingConfidence float64
+	FlaggedMappingWindow time.Duration
+	// FXMaxAge is how old a fiat rate can be and still convert pairs quoted in
+	// that currency into the pair's USD VWAP. Zero leaves fiat-quoted pairs
+	// in their own VWAP only.
+	FXMaxAge time.Duration
+	// Flags gates the parts of the calculation behind a feature flag. Nil
+	// leaves every flag at its default.
+	Flags *features.Flags
+}
+
+// Service calculates VWAP from the tickers the poller stored in ClickHouse,
+// independently of how often the poller runs
 type Service struct {
 	clickhouseConn driver.Conn
+	postgresDB     *sql.DB
+	vwapStorage    *storage.VWAPStorage
 	calculator     *calculator.VWAPCalculator
+	config         Config
 	logger         *zap.Logger
-	
-	mu sync.RWMutex
+
+	// Last successfully loaded sets, kept if a reload fails
+	untrusted  *db.UntrustedMappings
+	fiatRates  map[int]decimal.Decimal
+	usdTokenID int
 }
 
 // NewService creates a new VWAP service
-func NewService(clickhouseConn driver.Conn, logger *zap.Logger) *Service {
+func NewService(clickhouseConn driver.Conn, postgresDB *sql.DB, vwapStorage *storage.VWAPStorage, config Config, logger *zap.Logger) *Service {
 	return &Service{
 		clickhouseConn: clickhouseConn,
-		calculator:     calculator.NewVWAPCalculator(logger),
+		postgresDB:     postgresDB,
+		vwapStorage:    vwapStorage,
+		calculator:     calculator.NewVWAPCalculator(config.Calculator, logger),
+		config:         config,
 		logger:         logger,
 	}
 }
 
-// CalculateAndStore calculates VWAP for all token pairs and stores in ClickHouse
-func (s *Service) CalculateAndStore(ctx context.Context) error {
-	// Fetch recent prices from ClickHouse
+// CalculateAndStore calculates VWAP for every pair with recent tickers and
+// stores the results. It returns the number of pairs stored.
+func (s *Service) CalculateAndStore(ctx context.Context) (int, error) {
 	priceData, err := s.fetchRecentPrices(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to fetch recent prices: %w", err)
-	}
-	
-	// Group prices by token pair
-	pricesByPair := s.groupPricesByPair(priceData)
-	
-	// Calculate VWAP for each pair
-	vwapResults := s.calculator.CalculateBatch(pricesByPair)
-	
-	// Store VWAP results
-	if err := s.storeVWAPResults(ctx, vwapResults); err != nil {
-		return fmt.Errorf("failed to store VWAP results: %w", err)
-	}
-	
-	s.logger.Info("VWAP calculation completed",
+		return 0, fmt.Errorf("failed to fetch recent prices: %w", err)
+	}
+
+	vwapResults := s.calculator.CalculateBatch(calculator.GroupByPair(priceData))
+
+	if err := s.vwapStorage.StoreVWAPResults(ctx, vwapResults); err != nil {
+		return 0, fmt.Errorf("failed to store VWAP results: %w", err)
+	}
+
+	s.logger.Debug("VWAP calculation completed",
+		zap.Int("prices", len(priceData)),
 		zap.Int("pairs", len(vwapResults)))
-	
-	return nil
+
+	return len(vwapResults), nil
 }
 
+// lookback returns how far back tickers can still contribute to VWAP
+func (s *Service) lookback() time.Duration {
+	window := s.config.Calculator.MaxPriceAge
+	for _, age := range s.config.Calculator.ExchangeMaxPriceAge {
+		if age > window {
+			window = age
+		}
+	}
+	if window <= 0 {
+		return defaultLookback
+	}
+	return window
+}
+
+// fetchRecentPrices reads the latest ticker of every exchange pair within the
+// lookback, leaving out tickers whose mapping awaits verification. They stay
+// stored in price_tickers until approved.
 func (s *Service) fetchRecentPrices(ctx context.Context) ([]calculator.PriceData, error) {
+	if untrusted, err := db.GetUntrustedMappings(ctx, s.postgresDB, s.config.MinMappingConfidence, s.config.FlaggedMappingWindow); err != nil {
+		s.logger.Error("Failed to load untrusted mappings, using previous set", zap.Error(err))
+	} else {
+		s.untrusted = untrusted
+	}
+
 	query := `
-		SELECT 
+		SELECT
 			exchange_id,
+			symbol,
+			argMax(base_symbol, timestamp) as base,
+			argMax(quote_symbol, timestamp) as quote,
 			base_token_id,
 			quote_token_id,
 			argMax(price, timestamp) as latest_price,
-			sum(volume_24h) as total_volume,
+			argMax(volume_24h, timestamp) as latest_volume,
 			max(timestamp) as latest_timestamp
 		FROM price_tickers
-		WHERE timestamp >= now() - INTERVAL 1 MINUTE
+		WHERE timestamp >= now64(3) - INTERVAL ? SECOND
+			AND instrument_type = 'spot'
 			AND base_token_id > 0
 			AND quote_token_id > 0
-			AND price > 0
-			AND volume_24h > 0
-		GROUP BY exchange_id, base_token_id, quote_token_id
-		HAVING total_volume > 1000  -- Minimum volume threshold
+		GROUP BY exchange_id, symbol, base_token_id, quote_token_id
+		HAVING latest_price > 0 AND latest_volume > 0
 	`
-	
-	rows, err := s.clickhouseConn.Query(ctx, query)
+
+	rows, err := s.clickhouseConn.Query(ctx, query, int(s.lookback().Seconds()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query prices: %w", err)
 	}
 	defer rows.Close()
-	
+
 	var prices []calculator.PriceData
-	exchangeWeights := getExchangeWeights()
-	
+	excluded := 0
 	for rows.Next() {
 		var (
-			exchangeID   string
-			baseTokenID  uint32
-			quoteTokenID uint32
-			price        decimal.Decimal
-			volume       decimal.Decimal
-			timestamp    time.Time
+			exchangeID, symbol, base, quote string
+			baseTokenID, quoteTokenID       uint32
+			price, volume                   decimal.Decimal
+			timestamp                       time.Time
 		)
-		
-		if err := rows.Scan(&exchangeID, &baseTokenID, &quoteTokenID, &price, &volume, &timestamp); err != nil {
-			s.logger.Error("Failed to scan price row", zap.Error(err))
+
+		if err := rows.Scan(&exchangeID, &symbol, &base, &quote, &baseTokenID, &quoteTokenID, &price, &volume, &timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan price row: %w", err)
+		}
+
+		if s.untrusted.Excludes(exchangeID, symbol, base, quote) {
+			excluded++
 			continue
 		}
-		
-		weight := exchangeWeights[exchangeID]
-		if weight.IsZero() {
-			weight = decimal.NewFromFloat(0.01) // Default weight
+
+		weight, ok := s.config.ExchangeWeights.Weight(exchangeID)
+		if !ok {
+			weight = DefaultExchangeWeight
 		}
-		
+
 		prices = append(prices, calculator.PriceData{
 			ExchangeID:   exchangeID,
-			Symbol:       fmt.Sprintf("%d-%d", baseTokenID, quoteTokenID),
+			Symbol:       symbol,
 			BaseTokenID:  int(baseTokenID),
 			QuoteTokenID: int(quoteTokenID),
 			Price:        price,
 			Volume:       volume,
-			Weight:       weight,
+			Weight:       decimal.NewFromFloat(weight),
 			Timestamp:    timestamp,
 		})
 	}
-	
-	return prices, nil
-}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-func (s *Service) groupPricesByPair(prices []calculator.PriceData) map[string][]calculator.PriceData {
-	grouped := make(map[string][]calculator.PriceData)
-	
-	for _, price := range prices {
-		key := fmt.Sprintf("%d-%d", price.BaseTokenID, price.QuoteTokenID)
-		grouped[key] = append(grouped[key], price)
-	}
-	
-	// Filter out pairs with insufficient exchanges
-	filtered := make(map[string][]calculator.PriceData)
-	for key, prices := range grouped {
-		if len(prices) >= 2 { // Minimum 2 exchanges for VWAP
-			filtered[key] = prices
-		}
+	if excluded > 0 {
+		s.logger.Info("Excluded tickers with unverified mappings from VWAP",
+			zap.Int("excluded", excluded),
+			zap.Int("untrusted_mappings", s.untrusted.Count()))
 	}
-	
-	return filtered
+
+	return append(prices, s.fiatToUSD(ctx, prices)...), nil
 }
 
-func (s *Service) storeVWAPResults(ctx context.Context, results map[string]*calculator.VWAPResult) error {
-	if len(results) == 0 {
+// fiatToUSD converts the prices quoted in a fiat currency with a recent FX
+// rate to USD, so a BTC/EUR market also counts towards BTC/USD. The fiat
+// pair keeps its own VWAP. An exchange listing both is counted once in the
+// USD VWAP, by its larger market.
+func (s *Service) fiatToUSD(ctx context.Context, prices []calculator.PriceData) []calculator.PriceData {
+	if s.config.FXMaxAge <= 0 || !s.config.Flags.Enabled(features.FXConversion) {
 		return nil
 	}
-	
-	batch, err := s.clickhouseConn.PrepareBatch(ctx, `
-		INSERT INTO vwap_prices (
-			timestamp, base_token_id, quote_token_id,
-			vwap_price, total_volume, exchange_count, contributing_exchanges
-		)`)
-	if err != nil {
-		return fmt.Errorf("failed to prepare batch: %w", err)
-	}
-	
-	for _, result := range results {
-		if err := batch.Append(
-			result.Timestamp,
-			uint32(result.BaseTokenID),
-			uint32(result.QuoteTokenID),
-			result.VWAPPrice,
-			result.TotalVolume,
-			uint8(result.ExchangeCount),
-			result.ContributingExchanges,
-		); err != nil {
-			s.logger.Error("Failed to append VWAP result",
-				zap.Int("base_token_id", result.BaseTokenID),
-				zap.Int("quote_token_id", result.QuoteTokenID),
-				zap.Error(err))
+	if err := s.loadFiatRates(ctx); err != nil {
+		s.logger.Error("Failed to load fx rates, using previous set", zap.Error(err))
+	}
+	if s.usdTokenID == 0 || len(s.fiatRates) == 0 {
+		return nil
+	}
+
+	var converted []calculator.PriceData
+	for _, p := range prices {
+		rate, ok := s.fiatRates[p.QuoteTokenID]
+		if !ok || p.BaseTokenID == s.usdTokenID {
 			continue
 		}
+		p.QuoteTokenID = s.usdTokenID
+		p.Price = p.Price.Mul(rate)
+		converted = append(converted, p)
 	}
-	
-	if err := batch.Send(); err != nil {
-		return fmt.Errorf("failed to send batch: %w", err)
-	}
-	
-	return nil
+	return converted
 }
 
-// GetLatestVWAP retrieves the latest VWAP for a token pair
-func (s *Service) GetLatestVWAP(ctx context.Context, baseTokenID, quoteTokenID int) (*calculator.VWAPResult, error) {
-	query := `
-		SELECT 
-			timestamp,
-			vwap_price,
-			total_volume,
-			exchange_count,
-			contributing_exchanges
-		FROM vwap_prices
-		WHERE base_token_id = ? AND quote_token_id = ?
-		ORDER BY timestamp DESC
-		LIMIT 1
-	`
-	
-	var result calculator.VWAPResult
-	result.BaseTokenID = baseTokenID
-	result.QuoteTokenID = quoteTokenID
-	
-	err := s.clickhouseConn.QueryRow(ctx, query, baseTokenID, quoteTokenID).Scan(
-		&result.Timestamp,
-		&result.VWAPPrice,
-		&result.TotalVolume,
-		&result.ExchangeCount,
-		&result.ContributingExchanges,
-	)
-	
+// loadFiatRates reloads the USD token and the fiat rates
+func (s *Service) loadFiatRates(ctx context.Context) error {
+	quotes, err := db.GetUSDQuoteTokens(ctx, s.postgresDB)
+	if err != nil {
+		return err
+	}
+	rates, err := db.GetFiatUSDRates(ctx, s.postgresDB, s.config.FXMaxAge)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get latest VWAP: %w", err)
+		return err
 	}
-	
-	return &result, nil
+	s.usdTokenID, s.fiatRates = quotes["USD"], rates
+	return nil
 }
-
-// getExchangeWeights returns predefined exchange weights for VWAP calculation
-func getExchangeWeights() map[string]decimal.Decimal {
-	return map[string]decimal.Decimal{
-		"binance":   decimal.NewFromFloat(0.15),
-		"coinbase":  decimal.NewFromFloat(0.12),
-		"kraken":    decimal.NewFromFloat(0.10),
-		"okx":       decimal.NewFromFloat(0.08),
-		"bybit":     decimal.NewFromFloat(0.07),
-		"bitget":    decimal.NewFromFloat(0.06),
-		"gateio":    decimal.NewFromFloat(0.05),
-		"huobi":     decimal.NewFromFloat(0.04),
-		"kucoin":    decimal.NewFromFloat(0.05),
-		"cryptocom": decimal.NewFromFloat(0.03),
-		"mexc":      decimal.NewFromFloat(0.03),
-		"bitfinex":  decimal.NewFromFloat(0.03),
-		"gemini":    decimal.NewFromFloat(0.02),
-		"bitstamp":  decimal.NewFromFloat(0.02),
-		// Others default to 0.01
-	}
-}
\ No newline at end of file
@@ -0,0 +1,434 @@
+// Package webhooks POSTs price updates to the callback URLs API clients
+// subscribe, for integrations that cannot hold a stream open.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/models"
+	"github.com/ashmitsharp/trading/internal/stream"
+	"go.uber.org/zap"
+)
+
+// Request headers of a delivery. SignatureHeader is "sha256=" followed by the
+// hex HMAC-SHA256, keyed by the webhook's secret, of TimestampHeader's value,
+// a ".", and the body.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	IDHeader        = "X-Webhook-ID"
+)
+
+// Config controls delivery
+type Config struct {
+	ReloadInterval time.Duration // how often subscriptions are reloaded from PostgreSQL
+	Timeout        time.Duration // per attempt
+	MaxAttempts    int           // per delivery
+	Backoff        time.Duration // before the second attempt, doubling after each
+	MaxFailures    int           // consecutive failed deliveries before a webhook is deactivated; 0 never
+	Workers        int           // concurrent deliveries
+	AllowPrivate   bool          // allow URLs resolving to loopback and private addresses
+}
+
+// DefaultConfig returns the delivery defaults
+func DefaultConfig() Config {
+	return Config{
+		ReloadInterval: 30 * time.Second,
+		Timeout:        10 * time.Second,
+		MaxAttempts:    4,
+		Backoff:        2 * time.Second,
+		MaxFailures:    20,
+		Workers:        8,
+	}
+}
+
+// Payload is the body of a delivery
+type Payload struct {
+	WebhookID int                  `json:"webhook_id"`
+	Event     string               `json:"event"`
+	SentAt    int64                `json:"sent_at"`
+	Prices    []models.TickerEvent `json:"prices"`
+}
+
+// Sign returns the SignatureHeader value of body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random signing secret
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// errBlockedAddress is returned when dialing an address webhooks may not reach
+var errBlockedAddress = errors.New("webhook URL resolves to a private address")
+
+// ValidateURL checks that raw is an absolute http(s) URL and, unless
+// allowPrivate, that it does not name a loopback or private IP. Host names
+// are checked again when dialed.
+func ValidateURL(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("URL must be an absolute http or https URL")
+	}
+	if u.User != nil {
+		return errors.New("URL must not contain credentials")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !allowPrivate && blocked(ip) {
+		return errBlockedAddress
+	}
+	if !allowPrivate && u.Hostname() == "localhost" {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+func blocked(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast()
+}
+
+// newClient returns a client that does not follow redirects and, unless
+// allowPrivate, refuses to connect to loopback and private addresses
+func newClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blocked(ip) {
+				return errBlockedAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Dispatcher keeps the latest USD VWAPs from a stream.TickerHub and delivers
+// each webhook the new prices of its tokens, at most once per its
+// MinInterval. A failed delivery is retried with backoff; prices it carried
+// are sent again with the next delivery.
+type Dispatcher struct {
+	postgresDB *sql.DB
+	hub        *stream.TickerHub
+	client     *http.Client
+	config     Config
+	logger     *zap.Logger
+
+	reload chan struct{}
+	slots  chan struct{}
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	hooks    []db.Webhook
+	latest   map[int]stream.Tick
+	sent     map[int]map[int]time.Time // webhook -> token -> timestamp of the last price delivered
+	lastTry  map[int]time.Time
+	inFlight map[int]bool
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(postgresDB *sql.DB, hub *stream.TickerHub, config Config, logger *zap.Logger) *Dispatcher {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	return &Dispatcher{
+		postgresDB: postgresDB,
+		hub:        hub,
+		client:     newClient(config.Timeout, config.AllowPrivate),
+		config:     config,
+		logger:     logger,
+		reload:     make(chan struct{}, 1),
+		slots:      make(chan struct{}, config.Workers),
+		latest:     make(map[int]stream.Tick),
+		sent:       make(map[int]map[int]time.Time),
+		lastTry:    make(map[int]time.Time),
+		inFlight:   make(map[int]bool),
+	}
+}
+
+// ValidateURL checks a callback URL against the dispatcher's address policy
+func (d *Dispatcher) ValidateURL(raw string) error {
+	return ValidateURL(raw, d.config.AllowPrivate)
+}
+
+// Reload asks the dispatcher to reload subscriptions now rather than at the
+// next ReloadInterval
+func (d *Dispatcher) Reload() {
+	select {
+	case d.reload <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers until ctx is done, then waits for deliveries in flight. The
+// dispatcher subscribes to the hub only while there are active webhooks, so
+// it does not keep the hub polling for nothing.
+func (d *Dispatcher) Run(ctx context.Context) {
+	reload := time.NewTicker(d.config.ReloadInterval)
+	defer reload.Stop()
+	flush := time.NewTicker(time.Second)
+	defer flush.Stop()
+
+	var sub *stream.Subscription
+	var ticks <-chan stream.Tick
+	defer func() {
+		if sub != nil {
+			sub.Close()
+		}
+		d.wg.Wait()
+	}()
+
+	load := func() {
+		active, err := d.load(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				d.logger.Warn("Failed to load webhooks", zap.Error(err))
+			}
+			return
+		}
+		switch {
+		case active && sub == nil:
+			if sub, err = d.hub.Subscribe(nil); err != nil {
+				d.logger.Warn("Failed to subscribe webhooks to prices", zap.Error(err))
+				sub = nil
+				return
+			}
+			ticks = sub.C
+		case !active && sub != nil:
+			sub.Close()
+			sub, ticks = nil, nil
+		}
+	}
+	load()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload.C:
+			load()
+		case <-d.reload:
+			load()
+		case tick, open := <-ticks:
+			if !open {
+				// The hub stopped; it only does so on shutdown
+				sub, ticks = nil, nil
+				continue
+			}
+			d.mu.Lock()
+			d.latest[tick.TokenID] = tick
+			d.mu.Unlock()
+		case <-flush.C:
+			d.flush(ctx)
+		}
+	}
+}
+
+// load replaces the subscriptions and reports whether any are active
+func (d *Dispatcher) load(ctx context.Context) (bool, error) {
+	hooks, err := db.ListActiveWebhooks(ctx, d.postgresDB)
+	if err != nil {
+		return false, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hooks = hooks
+	keep := make(map[int]bool, len(hooks))
+	for _, w := range hooks {
+		keep[w.ID] = true
+		if _, ok := d.lastTry[w.ID]; !ok && w.LastDeliveredAt != nil {
+			d.lastTry[w.ID] = *w.LastDeliveredAt
+		}
+	}
+	for id := range d.lastTry {
+		if !keep[id] {
+			delete(d.lastTry, id)
+			delete(d.sent, id)
+		}
+	}
+	return len(hooks) > 0, nil
+}
+
+// flush starts a delivery to every webhook that is due and has new prices
+func (d *Dispatcher) flush(ctx context.Context) {
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, w := range d.hooks {
+		if d.inFlight[w.ID] || now.Sub(d.lastTry[w.ID]) < w.MinInterval {
+			continue
+		}
+		prices := d.pending(w)
+		if len(prices) == 0 {
+			continue
+		}
+		select {
+		case d.slots <- struct{}{}:
+		default:
+			return // every worker is busy; the rest wait for the next flush
+		}
+		d.inFlight[w.ID] = true
+		d.lastTry[w.ID] = now
+		d.wg.Add(1)
+		go func(w db.Webhook, prices []stream.Tick) {
+			defer d.wg.Done()
+			defer func() { <-d.slots }()
+			d.deliver(ctx, w, prices)
+		}(w, prices)
+	}
+}
+
+// pending returns the latest prices of w's tokens it has not been sent.
+// d.mu must be held.
+func (d *Dispatcher) pending(w db.Webhook) []stream.Tick {
+	var prices []stream.Tick
+	for _, t := range w.Tokens {
+		tick, ok := d.latest[t.ID]
+		if ok && tick.Timestamp.After(d.sent[w.ID][t.ID]) {
+			prices = append(prices, tick)
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Symbol < prices[j].Symbol })
+	return prices
+}
+
+// deliver POSTs prices to w, retrying with backoff, and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, w db.Webhook, prices []stream.Tick) {
+	payload := Payload{WebhookID: w.ID, Event: "price_update", SentAt: time.Now().Unix()}
+	for _, p := range prices {
+		payload.Prices = append(payload.Prices, p.Event())
+	}
+	body, err := json.Marshal(payload)
+	if err == nil {
+		err = d.post(ctx, w, body)
+	}
+
+	d.mu.Lock()
+	delete(d.inFlight, w.ID)
+	if err == nil {
+		if d.sent[w.ID] == nil {
+			d.sent[w.ID] = make(map[int]time.Time)
+		}
+		for _, p := range prices {
+			d.sent[w.ID][p.TokenID] = p.Timestamp
+		}
+	}
+	d.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		d.logger.Warn("Webhook delivery failed",
+			zap.Int("webhook_id", w.ID),
+			zap.String("url", w.URL),
+			zap.Error(err))
+	}
+	active, recErr := db.RecordWebhookDelivery(ctx, d.postgresDB, w.ID, time.Now(), err, d.config.MaxFailures)
+	if recErr != nil {
+		d.logger.Warn("Failed to record webhook delivery", zap.Int("webhook_id", w.ID), zap.Error(recErr))
+		return
+	}
+	if !active {
+		d.logger.Warn("Webhook deactivated after repeated failures", zap.Int("webhook_id", w.ID), zap.String("url", w.URL))
+		d.Reload()
+	}
+}
+
+// post sends body to w, making up to MaxAttempts attempts. Client errors
+// other than 429 are not retried.
+func (d *Dispatcher) post(ctx context.Context, w db.Webhook, body []byte) error {
+	backoff := d.config.Backoff
+	var err error
+	for attempt := 1; attempt <= d.config.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		var retry bool
+		retry, err = d.attempt(ctx, w, body)
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return fmt.Errorf("after %d attempts: %w", d.config.MaxAttempts, err)
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, w db.Webhook, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "trading-webhooks/1")
+	req.Header.Set(IDHeader, strconv.Itoa(w.ID))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(w.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, errBlockedAddress), err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+}
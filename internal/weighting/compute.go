@@ -0,0 +1,114 @@
+// Package weighting computes dynamic VWAP weights for the exchanges from
+// their share of observed volume and their poll uptime, as an alternative to
+// the static weights of the exchange registry. The dynamic_exchange_weights
+// feature flag picks which of the two VWAP uses.
+package weighting
+
+import (
+	"math"
+
+	"github.com/ashmitsharp/trading/internal/db"
+)
+
+// Config holds the settings of the dynamic weights
+type Config struct {
+	// Cap is the most weight one exchange gets, its excess going to the
+	// others in proportion. 0 disables it.
+	Cap float64
+	// Smoothing is the share of each day's target in the new weight, the
+	// rest being the previous weight, so a single bad day moves weights
+	// only so far. 1 takes the target as is.
+	Smoothing float64
+}
+
+// Input is what an exchange's dynamic weight is computed from
+type Input struct {
+	ExchangeID     string
+	StaticWeight   float64
+	PreviousWeight *float64 // nil before the first computation
+	VolumeSharePct float64  // of the USD volume across all pairs
+	UptimePct      float64
+}
+
+// Compute turns volume share times uptime into weights summing to 1,
+// capped, and smooths them with the previous weights. Exchanges whose
+// static weight is 0 are left out of the VWAP on purpose and stay at 0.
+// It returns nil when no exchange has volume, so weights are left alone
+// rather than zeroed.
+func Compute(inputs []Input, cfg Config) []db.ExchangeWeightChange {
+	raw := make([]float64, len(inputs))
+	var total float64
+	for i, in := range inputs {
+		if in.StaticWeight > 0 {
+			raw[i] = in.VolumeSharePct / 100 * in.UptimePct / 100
+		}
+		total += raw[i]
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	targets := make([]float64, len(inputs))
+	for i := range raw {
+		targets[i] = raw[i] / total
+	}
+	capWeights(targets, cfg.Cap)
+
+	smoothing := cfg.Smoothing
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 1
+	}
+	changes := make([]db.ExchangeWeightChange, len(inputs))
+	for i, in := range inputs {
+		weight := targets[i]
+		if in.PreviousWeight != nil {
+			weight = smoothing*targets[i] + (1-smoothing)*(*in.PreviousWeight)
+		}
+		if cfg.Cap > 0 && weight > cfg.Cap {
+			weight = cfg.Cap
+		}
+		changes[i] = db.ExchangeWeightChange{
+			ExchangeID:     in.ExchangeID,
+			StaticWeight:   in.StaticWeight,
+			PreviousWeight: in.PreviousWeight,
+			TargetWeight:   round(targets[i]),
+			Weight:         round(weight),
+			VolumeSharePct: in.VolumeSharePct,
+			UptimePct:      in.UptimePct,
+		}
+	}
+	return changes
+}
+
+// capWeights lowers the weights above limit to it and gives the excess to
+// the weights below it in proportion, until none is over or none can take
+// more
+func capWeights(weights []float64, limit float64) {
+	if limit <= 0 {
+		return
+	}
+	for range weights {
+		var excess, free float64
+		for i, w := range weights {
+			if w > limit {
+				excess += w - limit
+				weights[i] = limit
+			} else if w < limit {
+				free += w
+			}
+		}
+		if excess == 0 || free == 0 {
+			return
+		}
+		for i, w := range weights {
+			if w < limit {
+				weights[i] += excess * w / free
+			}
+		}
+	}
+}
+
+// round rounds a weight to the 4 decimals the exchanges table keeps
+func round(w float64) float64 {
+	return math.Round(w*1e4) / 1e4
+}
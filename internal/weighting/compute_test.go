@@ -0,0 +1,38 @@
+package weighting
+
+import "testing"
+
+func TestCompute(t *testing.T) {
+	previous := func(w float64) *float64 { return &w }
+	inputs := []Input{
+		{ExchangeID: "binance", StaticWeight: 0.1, PreviousWeight: previous(0.4), VolumeSharePct: 60, UptimePct: 100},
+		{ExchangeID: "kraken", StaticWeight: 0.1, VolumeSharePct: 30, UptimePct: 50},
+		{ExchangeID: "gate", StaticWeight: 0.1, PreviousWeight: previous(0.2), VolumeSharePct: 10, UptimePct: 100},
+		// Left out of the VWAP on purpose
+		{ExchangeID: "mexc", StaticWeight: 0, VolumeSharePct: 20, UptimePct: 100},
+	}
+
+	// Volume share times uptime gives 0.706/0.176/0.118; binance is capped
+	// at 0.5 and its excess split 0.3/0.2, then smoothed half-way
+	changes := Compute(inputs, Config{Cap: 0.5, Smoothing: 0.5})
+
+	want := map[string][2]float64{ // target, weight
+		"binance": {0.5, 0.45},
+		"kraken":  {0.3, 0.3},
+		"gate":    {0.2, 0.2},
+		"mexc":    {0, 0},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d", len(changes), len(want))
+	}
+	for _, c := range changes {
+		w := want[c.ExchangeID]
+		if c.TargetWeight != w[0] || c.Weight != w[1] {
+			t.Errorf("%s: target %v weight %v, want %v %v", c.ExchangeID, c.TargetWeight, c.Weight, w[0], w[1])
+		}
+	}
+
+	if got := Compute([]Input{{ExchangeID: "binance", StaticWeight: 0.1, UptimePct: 100}}, Config{}); got != nil {
+		t.Errorf("no volume: got %+v, want nil", got)
+	}
+}
@@ -0,0 +1,152 @@
+package weighting
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/storage"
+	"github.com/ashmitsharp/trading/internal/timeutil"
+	"github.com/ashmitsharp/trading/internal/volumeshare"
+	"go.uber.org/zap"
+)
+
+const (
+	// uptimeWindow is how far back poll uptime is read
+	uptimeWindow = 24 * time.Hour
+	// shareMaxAge is the oldest volume share weights are computed from
+	shareMaxAge = 48 * time.Hour
+)
+
+// ErrNoVolume is returned when there is no recent volume share to compute
+// weights from
+var ErrNoVolume = errors.New("no recent volume share")
+
+// Service recomputes the dynamic weights once a day, after the volume
+// shares they are computed from
+type Service struct {
+	postgresDB *sql.DB
+	shares     *volumeshare.Service
+	ops        *storage.OpsStorage
+	weights    *Weights
+	config     Config
+	logger     *zap.Logger
+}
+
+// NewService creates a new dynamic weight service. weights is reloaded
+// after every computation so it applies at once in this process.
+func NewService(postgresDB *sql.DB, shares *volumeshare.Service, ops *storage.OpsStorage, weights *Weights, config Config, logger *zap.Logger) *Service {
+	return &Service{
+		postgresDB: postgresDB,
+		shares:     shares,
+		ops:        ops,
+		weights:    weights,
+		config:     config,
+		logger:     logger,
+	}
+}
+
+// Recalculate computes every active exchange's dynamic weight from the
+// latest volume shares across all pairs and the last day's uptime outside
+// maintenance windows, and stores the weights with their history. It
+// returns ErrNoVolume when the latest volume share is missing or older than
+// two days.
+func (s *Service) Recalculate(ctx context.Context) ([]db.ExchangeWeightChange, error) {
+	shares, day, err := s.shares.Shares(ctx, time.Time{}, 0, 0)
+	if errors.Is(err, db.ErrNoData) || (err == nil && time.Since(day) > shareMaxAge) {
+		return nil, ErrNoVolume
+	}
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().Add(-uptimeWindow)
+	uptimes, err := s.ops.GetExchangeUptime(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	windows, err := db.ListMaintenanceWindows(ctx, s.postgresDB, "", since)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := db.ListExchanges(ctx, s.postgresDB, false)
+	if err != nil {
+		return nil, err
+	}
+
+	sharePct := make(map[string]float64, len(shares))
+	for _, sh := range shares {
+		sharePct[sh.ExchangeID] = sh.SharePct
+	}
+	uptimePct := make(map[string]float64, len(uptimes))
+	for _, u := range uptimes {
+		if u.Polls > 0 {
+			uptimePct[u.ExchangeID] = 100 * float64(u.SuccessfulPolls) / float64(u.Polls)
+		}
+	}
+	// Polls are skipped during maintenance, so an exchange in maintenance
+	// the whole window has no uptime to go by and is not penalized
+	for _, w := range windows {
+		if _, polled := uptimePct[w.ExchangeID]; !polled && w.Start.Before(time.Now()) {
+			uptimePct[w.ExchangeID] = 100
+		}
+	}
+
+	inputs := make([]Input, 0, len(registry))
+	for _, e := range registry {
+		in := Input{
+			ExchangeID:     e.ID,
+			StaticWeight:   e.Weight,
+			VolumeSharePct: sharePct[e.ID],
+			UptimePct:      uptimePct[e.ID],
+		}
+		if !e.DynamicWeightAt.IsZero() {
+			previous := e.DynamicWeight
+			in.PreviousWeight = &previous
+		}
+		inputs = append(inputs, in)
+	}
+
+	changes := Compute(inputs, s.config)
+	if changes == nil {
+		return nil, ErrNoVolume
+	}
+	if err := db.SaveDynamicWeights(ctx, s.postgresDB, changes); err != nil {
+		return nil, err
+	}
+	if err := s.weights.Reload(ctx); err != nil {
+		s.logger.Warn("Failed to reload dynamic weights", zap.Error(err))
+	}
+	return changes, nil
+}
+
+// Run recomputes the weights every day at at past midnight UTC until ctx
+// is done
+func (s *Service) Run(ctx context.Context, at time.Duration) error {
+	for {
+		next := timeutil.NextDaily(time.Now(), at)
+		s.logger.Info("Next dynamic weight run scheduled", zap.Time("at", next))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		changes, err := s.Recalculate(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to recompute dynamic weights", zap.Error(err))
+			}
+			continue
+		}
+		s.logger.Info("Dynamic weights recomputed",
+			zap.Int("exchanges", len(changes)),
+			zap.Bool("in_use", s.weights.Dynamic()),
+			zap.Duration("took", time.Since(start)))
+	}
+}
@@ -0,0 +1,105 @@
+package weighting
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ashmitsharp/trading/internal/db"
+	"github.com/ashmitsharp/trading/internal/features"
+	"go.uber.org/zap"
+)
+
+// Weights gives each exchange's VWAP weight: its dynamic weight while the
+// dynamic_exchange_weights flag is on and it has one, its static weight
+// otherwise, multiplied by its trust score while the vwap_trust_weighting
+// flag is on. Dynamic weights and trust scores are cached and reloaded
+// periodically. It is safe for concurrent use.
+type Weights struct {
+	postgresDB *sql.DB
+	static     map[string]float64
+	flags      *features.Flags
+	logger     *zap.Logger
+
+	mu      sync.RWMutex
+	dynamic map[string]float64
+	trust   map[string]float64
+}
+
+// NewWeights creates weights on top of the static ones. flags may be nil,
+// in which case the flag has its default.
+func NewWeights(postgresDB *sql.DB, static map[string]float64, flags *features.Flags, logger *zap.Logger) *Weights {
+	return &Weights{
+		postgresDB: postgresDB,
+		static:     static,
+		flags:      flags,
+		logger:     logger,
+		dynamic:    make(map[string]float64),
+		trust:      make(map[string]float64),
+	}
+}
+
+// Dynamic reports whether dynamic weights are in use
+func (w *Weights) Dynamic() bool {
+	return w.flags.Enabled(features.DynamicWeights)
+}
+
+// Weight returns an exchange's weight, and false when it has none
+func (w *Weights) Weight(exchangeID string) (float64, bool) {
+	w.mu.RLock()
+	dynamic, hasDynamic := w.dynamic[exchangeID]
+	trust, hasTrust := w.trust[exchangeID]
+	w.mu.RUnlock()
+
+	weight, ok := w.static[exchangeID]
+	if hasDynamic && w.Dynamic() {
+		weight, ok = dynamic, true
+	}
+	if ok && hasTrust && w.flags.Enabled(features.TrustWeighting) {
+		weight *= trust
+	}
+	return weight, ok
+}
+
+// Set replaces the dynamic weights and trust scores
+func (w *Weights) Set(dynamic, trust map[string]float64) {
+	w.mu.Lock()
+	w.dynamic, w.trust = dynamic, trust
+	w.mu.Unlock()
+}
+
+// Reload replaces the dynamic weights and trust scores with those in the
+// database
+func (w *Weights) Reload(ctx context.Context) error {
+	dynamic, err := db.GetDynamicWeights(ctx, w.postgresDB)
+	if err != nil {
+		return fmt.Errorf("loading dynamic weights: %w", err)
+	}
+	trust, err := db.GetTrustScores(ctx, w.postgresDB)
+	if err != nil {
+		return fmt.Errorf("loading trust scores: %w", err)
+	}
+	w.Set(dynamic, trust)
+	return nil
+}
+
+// Run reloads the dynamic weights and trust scores on start and then every
+// interval until ctx is done, so those computed since are picked up
+func (w *Weights) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Reload(ctx); err != nil && ctx.Err() == nil {
+			w.logger.Error("Failed to reload dynamic weights", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
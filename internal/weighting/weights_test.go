@@ -0,0 +1,39 @@
+package weighting
+
+import (
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/features"
+	"go.uber.org/zap"
+)
+
+func TestWeights(t *testing.T) {
+	static := map[string]float64{"binance": 0.1, "kraken": 0.08}
+	dynamic := map[string]float64{"binance": 0.4}
+	trust := map[string]float64{"binance": 0.5, "kraken": 0.25}
+
+	tests := []struct {
+		name    string
+		flags   map[string]bool
+		binance float64
+		kraken  float64
+	}{
+		{"static", nil, 0.1, 0.08},
+		{"dynamic", map[string]bool{features.DynamicWeights: true}, 0.4, 0.08},
+		{"trust", map[string]bool{features.TrustWeighting: true}, 0.05, 0.02},
+		{"dynamic and trust", map[string]bool{features.DynamicWeights: true, features.TrustWeighting: true}, 0.2, 0.02},
+	}
+	for _, tt := range tests {
+		w := NewWeights(nil, static, features.NewFlags(nil, tt.flags, zap.NewNop()), zap.NewNop())
+		w.Set(dynamic, trust)
+		if got, _ := w.Weight("binance"); got != tt.binance {
+			t.Errorf("%s: binance = %v, want %v", tt.name, got, tt.binance)
+		}
+		if got, _ := w.Weight("kraken"); got != tt.kraken {
+			t.Errorf("%s: kraken = %v, want %v", tt.name, got, tt.kraken)
+		}
+		if _, ok := w.Weight("mexc"); ok {
+			t.Errorf("%s: mexc has a weight", tt.name)
+		}
+	}
+}
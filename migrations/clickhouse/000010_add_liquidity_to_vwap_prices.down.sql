@@ -0,0 +1,6 @@
+-- Remove liquidity columns from vwap_prices table
+ALTER TABLE vwap_prices
+    DROP COLUMN IF EXISTS liquidity_score;
+
+ALTER TABLE vwap_prices
+    DROP COLUMN IF EXISTS spread_bps;
@@ -0,0 +1,6 @@
+-- Add liquidity score (0-100) and cross-exchange spread to VWAP prices
+ALTER TABLE vwap_prices
+    ADD COLUMN IF NOT EXISTS spread_bps Float64 DEFAULT 0 AFTER contributing_exchanges;
+
+ALTER TABLE vwap_prices
+    ADD COLUMN IF NOT EXISTS liquidity_score Float64 DEFAULT 0 AFTER spread_bps;
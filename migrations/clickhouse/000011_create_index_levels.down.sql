@@ -0,0 +1 @@
+DROP TABLE IF EXISTS index_levels
\ No newline at end of file
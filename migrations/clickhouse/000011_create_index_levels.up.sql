@@ -0,0 +1,12 @@
+-- 11. Index levels computed from constituent VWAPs
+CREATE TABLE IF NOT EXISTS index_levels (
+    timestamp DateTime64(3),
+    index_id UInt32,
+    level Decimal64(8),
+    constituent_count UInt16,
+    created_at DateTime64(3) DEFAULT now64()
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (index_id, timestamp)
+TTL timestamp + INTERVAL 365 DAY DELETE
+SETTINGS index_granularity = 8192
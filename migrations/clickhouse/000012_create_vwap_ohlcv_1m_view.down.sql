@@ -0,0 +1 @@
+DROP VIEW IF EXISTS vwap_ohlcv_1m
\ No newline at end of file
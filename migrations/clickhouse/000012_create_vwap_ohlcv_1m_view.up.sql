@@ -0,0 +1,20 @@
+-- Create materialized view for 1-minute candles of the composite VWAP.
+-- volume is the rolling 24h volume at the candle close, since vwap_prices
+-- does not carry per-interval volume; samples counts VWAP updates.
+CREATE MATERIALIZED VIEW IF NOT EXISTS vwap_ohlcv_1m
+ENGINE = AggregatingMergeTree()
+PARTITION BY toYYYYMM(minute)
+ORDER BY (base_token_id, quote_token_id, minute)
+TTL minute + INTERVAL 90 DAY DELETE
+AS SELECT
+    base_token_id,
+    quote_token_id,
+    toStartOfMinute(timestamp) as minute,
+    argMinState(vwap_price, timestamp) as open,
+    maxState(vwap_price) as high,
+    minState(vwap_price) as low,
+    argMaxState(vwap_price, timestamp) as close,
+    argMaxState(total_volume, timestamp) as volume,
+    countState() as samples
+FROM vwap_prices
+GROUP BY base_token_id, quote_token_id, minute
@@ -0,0 +1,3 @@
+-- Remove quorum flag from vwap_prices table
+ALTER TABLE vwap_prices
+    DROP COLUMN IF EXISTS indicative;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Flag VWAPs below the exchange/volume quorum as indicative rather than composite
+ALTER TABLE vwap_prices
+    ADD COLUMN IF NOT EXISTS indicative Bool DEFAULT false AFTER liquidity_score;
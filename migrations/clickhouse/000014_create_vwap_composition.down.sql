@@ -0,0 +1 @@
+DROP TABLE IF EXISTS vwap_composition
\ No newline at end of file
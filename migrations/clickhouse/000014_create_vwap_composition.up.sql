@@ -0,0 +1,15 @@
+-- 14. Per-exchange contributions to each stored VWAP, for auditing composites
+CREATE TABLE IF NOT EXISTS vwap_composition (
+    timestamp DateTime64(3),
+    base_token_id UInt32,
+    quote_token_id UInt32,
+    exchange_id LowCardinality(String),
+    price Decimal64(8),
+    volume Decimal64(8),
+    weight Float64,
+    created_at DateTime64(3) DEFAULT now64()
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMMDD(timestamp)
+ORDER BY (base_token_id, quote_token_id, timestamp, exchange_id)
+TTL timestamp + INTERVAL 30 DAY DELETE
+SETTINGS index_granularity = 8192
@@ -0,0 +1,19 @@
+-- 15. Candles as exchanges compute them, from their kline endpoints. The
+-- newest candle is still open when fetched, so later fetches replace it.
+CREATE TABLE IF NOT EXISTS exchange_ohlcv (
+    exchange_id LowCardinality(String),
+    symbol LowCardinality(String),
+    interval LowCardinality(String),
+    open_time DateTime,
+    open Decimal64(8),
+    high Decimal64(8),
+    low Decimal64(8),
+    close Decimal64(8),
+    volume Decimal64(8),
+    trades_count UInt64,
+    fetched_at DateTime64(3) DEFAULT now64()
+) ENGINE = ReplacingMergeTree(fetched_at)
+PARTITION BY (exchange_id, toYYYYMM(open_time))
+ORDER BY (exchange_id, symbol, interval, open_time)
+TTL open_time + INTERVAL 1 YEAR DELETE
+SETTINGS index_granularity = 8192
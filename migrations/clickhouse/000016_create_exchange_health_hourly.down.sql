@@ -0,0 +1 @@
+DROP VIEW IF EXISTS exchange_health_hourly
\ No newline at end of file
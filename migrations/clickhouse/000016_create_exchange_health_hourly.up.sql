@@ -0,0 +1,17 @@
+-- 16. Hourly rollup of exchange polls, so uptime and latency over weeks read
+-- a row per exchange and hour instead of every poll in exchange_health
+CREATE MATERIALIZED VIEW IF NOT EXISTS exchange_health_hourly
+ENGINE = AggregatingMergeTree()
+PARTITION BY toYYYYMM(hour)
+ORDER BY (exchange_id, hour)
+TTL hour + INTERVAL 90 DAY DELETE
+AS SELECT
+    exchange_id,
+    toStartOfHour(timestamp) as hour,
+    countState() as polls,
+    countIfState(success) as successful_polls,
+    avgState(response_time_ms) as avg_response_time,
+    quantileState(0.95)(response_time_ms) as p95_response_time,
+    maxState(timestamp) as last_poll_time
+FROM exchange_health
+GROUP BY exchange_id, hour
@@ -0,0 +1,17 @@
+-- 17. Daily rollup of exchange polls, kept for long-range uptime after the
+-- hourly rollup expires
+CREATE MATERIALIZED VIEW IF NOT EXISTS exchange_health_daily
+ENGINE = AggregatingMergeTree()
+PARTITION BY toYYYYMM(day)
+ORDER BY (exchange_id, day)
+TTL day + INTERVAL 2 YEAR DELETE
+AS SELECT
+    exchange_id,
+    toDate(timestamp) as day,
+    countState() as polls,
+    countIfState(success) as successful_polls,
+    avgState(response_time_ms) as avg_response_time,
+    quantileState(0.95)(response_time_ms) as p95_response_time,
+    maxState(timestamp) as last_poll_time
+FROM exchange_health
+GROUP BY exchange_id, day
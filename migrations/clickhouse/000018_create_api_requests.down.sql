@@ -0,0 +1 @@
+DROP TABLE IF EXISTS api_requests
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- 18. One row per API request. Only a week is kept; usage over longer
+-- ranges comes from the hourly and daily rollups.
+CREATE TABLE IF NOT EXISTS api_requests (
+    timestamp DateTime64(3),
+    method LowCardinality(String),
+    route LowCardinality(String),
+    status UInt16,
+    duration_ms Float32,
+    response_bytes UInt64,
+    api_key String
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMMDD(timestamp)
+ORDER BY (route, timestamp)
+TTL timestamp + INTERVAL 7 DAY DELETE
+SETTINGS index_granularity = 8192
@@ -0,0 +1 @@
+DROP VIEW IF EXISTS api_requests_hourly
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- 19. Hourly API usage per route
+CREATE MATERIALIZED VIEW IF NOT EXISTS api_requests_hourly
+ENGINE = AggregatingMergeTree()
+PARTITION BY toYYYYMM(hour)
+ORDER BY (route, method, hour)
+TTL hour + INTERVAL 90 DAY DELETE
+AS SELECT
+    route,
+    method,
+    toStartOfHour(timestamp) as hour,
+    countState() as requests,
+    countIfState(status >= 400 AND status < 500) as client_errors,
+    countIfState(status >= 500) as server_errors,
+    avgState(duration_ms) as avg_duration,
+    quantileState(0.95)(duration_ms) as p95_duration,
+    sumState(response_bytes) as response_bytes
+FROM api_requests
+GROUP BY route, method, hour
@@ -0,0 +1 @@
+DROP VIEW IF EXISTS api_requests_daily
\ No newline at end of file
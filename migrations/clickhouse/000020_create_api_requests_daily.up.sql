@@ -0,0 +1,18 @@
+-- 20. Daily API usage per route, kept after the hourly rollup expires
+CREATE MATERIALIZED VIEW IF NOT EXISTS api_requests_daily
+ENGINE = AggregatingMergeTree()
+PARTITION BY toYYYYMM(day)
+ORDER BY (route, method, day)
+TTL day + INTERVAL 2 YEAR DELETE
+AS SELECT
+    route,
+    method,
+    toDate(timestamp) as day,
+    countState() as requests,
+    countIfState(status >= 400 AND status < 500) as client_errors,
+    countIfState(status >= 500) as server_errors,
+    avgState(duration_ms) as avg_duration,
+    quantileState(0.95)(duration_ms) as p95_duration,
+    sumState(response_bytes) as response_bytes
+FROM api_requests
+GROUP BY route, method, day
@@ -0,0 +1,18 @@
+-- 21. Each exchange's daily share of observed 24h volume, per pair and, with
+-- base_token_id and quote_token_id 0, across all pairs
+CREATE TABLE IF NOT EXISTS exchange_volume_share (
+    day Date,
+    exchange_id LowCardinality(String),
+    base_token_id UInt32,
+    quote_token_id UInt32,
+    volume Decimal64(8),
+    volume_usd Float64,
+    share_pct Float64,
+    weight Float64,
+    vwap_share_pct Float64,
+    computed_at DateTime64(3) DEFAULT now64()
+) ENGINE = ReplacingMergeTree(computed_at)
+PARTITION BY toYYYYMM(day)
+ORDER BY (day, base_token_id, quote_token_id, exchange_id)
+TTL day + INTERVAL 2 YEAR DELETE
+SETTINGS index_granularity = 8192
@@ -0,0 +1,3 @@
+-- Remove the instrument type from price_tickers
+ALTER TABLE price_tickers
+    DROP COLUMN IF EXISTS instrument_type;
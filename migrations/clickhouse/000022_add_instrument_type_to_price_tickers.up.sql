@@ -0,0 +1,4 @@
+-- Kind of market each ticker is from, so leveraged tokens and derivatives can
+-- be stored next to spot tickers without counting towards spot composites
+ALTER TABLE price_tickers
+    ADD COLUMN IF NOT EXISTS instrument_type LowCardinality(String) DEFAULT 'spot' AFTER quote_token_id;
@@ -0,0 +1,8 @@
+-- Remove computed market cap columns
+DROP INDEX IF EXISTS idx_tokens_computed_rank;
+
+ALTER TABLE tokens
+DROP COLUMN IF EXISTS computed_market_cap,
+DROP COLUMN IF EXISTS computed_rank,
+DROP COLUMN IF EXISTS computed_price,
+DROP COLUMN IF EXISTS market_cap_updated_at;
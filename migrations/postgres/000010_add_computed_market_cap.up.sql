@@ -0,0 +1,9 @@
+-- Market cap and rank computed from our own VWAP prices and circulating supply,
+-- kept apart from the third-party market_cap / market_cap_rank columns
+ALTER TABLE tokens
+ADD COLUMN computed_market_cap DECIMAL(30,2),
+ADD COLUMN computed_rank INTEGER,
+ADD COLUMN computed_price DECIMAL(30,10),
+ADD COLUMN market_cap_updated_at TIMESTAMP;
+
+CREATE INDEX idx_tokens_computed_rank ON tokens(computed_rank) WHERE computed_rank IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Drop token supply snapshots table
+DROP TABLE IF EXISTS token_supply_snapshots CASCADE;
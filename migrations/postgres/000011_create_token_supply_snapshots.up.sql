@@ -0,0 +1,13 @@
+-- Daily supply history per token, so metadata refreshes that overwrite the
+-- supply columns on tokens do not lose past values
+CREATE TABLE token_supply_snapshots (
+    token_id INTEGER NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    snapshot_date DATE NOT NULL,
+    circulating_supply DECIMAL(30,0),
+    total_supply DECIMAL(30,0),
+    max_supply DECIMAL(30,0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (token_id, snapshot_date)
+);
+
+CREATE INDEX idx_supply_snapshots_date ON token_supply_snapshots(snapshot_date);
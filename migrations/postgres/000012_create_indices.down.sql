@@ -0,0 +1,3 @@
+-- Drop index tables
+DROP TABLE IF EXISTS index_constituents CASCADE;
+DROP TABLE IF EXISTS indices CASCADE;
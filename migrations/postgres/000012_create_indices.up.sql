@@ -0,0 +1,35 @@
+-- Index/basket definitions. An index either selects its constituents by
+-- computed rank (top_n) or uses the rows in index_constituents as given.
+CREATE TABLE indices (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    weighting VARCHAR(20) NOT NULL DEFAULT 'market_cap'
+        CHECK (weighting IN ('market_cap', 'equal', 'fixed')),
+    top_n INTEGER CHECK (top_n > 0),
+    rebalance_days INTEGER NOT NULL DEFAULT 30 CHECK (rebalance_days > 0),
+    base_level DECIMAL(30,10) NOT NULL DEFAULT 1000,
+    last_rebalanced_at TIMESTAMP,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (weighting <> 'fixed' OR top_n IS NULL)
+);
+
+-- weight is the target weight set at the last rebalance (or configured for
+-- fixed weighting); units is how much of the token the index holds until
+-- the next rebalance, so level = sum(units * price)
+CREATE TABLE index_constituents (
+    index_id INTEGER NOT NULL REFERENCES indices(id) ON DELETE CASCADE,
+    token_id INTEGER NOT NULL REFERENCES tokens(id),
+    weight DECIMAL(10,8),
+    units DECIMAL(40,18),
+    PRIMARY KEY (index_id, token_id)
+);
+
+INSERT INTO indices (slug, name, description, weighting, top_n, rebalance_days)
+VALUES ('top10', 'Top 10 Market Cap Index',
+        'The ten largest tokens by computed market cap, cap-weighted and rebalanced monthly',
+        'market_cap', 10, 30)
+ON CONFLICT (slug) DO NOTHING;
@@ -0,0 +1,8 @@
+-- Remove chain-aware token identity. Symbols were never unique before this
+-- migration, so no symbol index is put back.
+DROP INDEX IF EXISTS idx_token_exchange_symbols_normalized_chain;
+ALTER TABLE token_exchange_symbols DROP COLUMN IF EXISTS chain;
+
+DROP INDEX IF EXISTS idx_tokens_symbol_chain;
+DROP INDEX IF EXISTS idx_tokens_chain_contract;
+DROP INDEX IF EXISTS idx_tokens_symbol_native;
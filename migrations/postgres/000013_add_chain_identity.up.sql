@@ -0,0 +1,32 @@
+-- Token identity becomes symbol + chain + contract, so the same symbol can
+-- have one row per chain (USDC on Ethereum, Solana and Base). Rows without a
+-- chain stand for the asset as exchanges quote it and stay unique by symbol.
+
+-- Normalize what was entered by hand before the unique indexes go on
+UPDATE tokens
+SET chain = NULLIF(LOWER(TRIM(chain)), ''),
+    contract_address = NULLIF(TRIM(contract_address), '');
+
+-- EVM addresses are case-insensitive hex; other chains keep their casing
+UPDATE tokens
+SET contract_address = LOWER(contract_address)
+WHERE contract_address ~* '^0x[0-9a-f]{40}$';
+
+DROP INDEX IF EXISTS idx_tokens_symbol_unique;
+ALTER TABLE tokens DROP CONSTRAINT IF EXISTS tokens_symbol_key;
+
+CREATE UNIQUE INDEX idx_tokens_symbol_native ON tokens(symbol) WHERE chain IS NULL;
+CREATE UNIQUE INDEX idx_tokens_chain_contract ON tokens(chain, contract_address)
+    WHERE contract_address IS NOT NULL;
+CREATE INDEX idx_tokens_symbol_chain ON tokens(symbol, chain);
+
+-- The chain an exchange listing is on, when the exchange says so
+ALTER TABLE token_exchange_symbols
+ADD COLUMN chain VARCHAR(50);
+
+UPDATE token_exchange_symbols tes
+SET chain = t.chain
+FROM tokens t
+WHERE t.id = tes.token_id AND t.chain IS NOT NULL;
+
+CREATE INDEX idx_token_exchange_symbols_normalized_chain ON token_exchange_symbols(normalized_symbol, chain);
@@ -0,0 +1,2 @@
+-- Drop token merge audit table
+DROP TABLE IF EXISTS token_merge_log;
@@ -0,0 +1,19 @@
+-- Audit trail of token merges and splits. ClickHouse rows are re-pointed after
+-- the PostgreSQL change commits, so each entry records how that step went.
+CREATE TABLE token_merge_log (
+    id SERIAL PRIMARY KEY,
+    action VARCHAR(20) NOT NULL, -- 'merge', 'split'
+    source_token_id INTEGER NOT NULL REFERENCES tokens(id),
+    target_token_id INTEGER NOT NULL REFERENCES tokens(id),
+    exchanges TEXT[] DEFAULT '{}', -- split only: exchanges moved to the target
+    details JSONB DEFAULT '{}', -- rows moved per table
+    clickhouse_status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'completed', 'failed'
+    clickhouse_error TEXT,
+    performed_by VARCHAR(100) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_token_merge_log_source ON token_merge_log(source_token_id, created_at DESC);
+CREATE INDEX idx_token_merge_log_target ON token_merge_log(target_token_id, created_at DESC);
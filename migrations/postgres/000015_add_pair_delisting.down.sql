@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_trading_pairs_delisted;
+ALTER TABLE trading_pairs DROP COLUMN IF EXISTS delisted_at;
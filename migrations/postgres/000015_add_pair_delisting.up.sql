@@ -0,0 +1,7 @@
+-- Set when the poller deactivates a pair an exchange stopped returning, so
+-- it can tell those apart from pairs deactivated by hand and re-activate them
+-- when they reappear
+ALTER TABLE trading_pairs
+ADD COLUMN delisted_at TIMESTAMP;
+
+CREATE INDEX idx_trading_pairs_delisted ON trading_pairs(exchange_id) WHERE delisted_at IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Drop exchange listings table
+DROP TABLE IF EXISTS exchange_listings;
@@ -0,0 +1,20 @@
+-- First sighting of each base symbol on each exchange. The first poll of an
+-- exchange records what it already lists as the initial baseline; later
+-- rows are new listings.
+CREATE TABLE exchange_listings (
+    id SERIAL PRIMARY KEY,
+    exchange_id VARCHAR(50) NOT NULL,
+    base_symbol VARCHAR(50) NOT NULL,
+    quote_symbol VARCHAR(50) NOT NULL,
+    pair_symbol VARCHAR(100) NOT NULL, -- pair the first price came from
+    token_id INTEGER REFERENCES tokens(id),
+    first_price DECIMAL(30, 12),
+    mapping_method VARCHAR(20), -- how the base symbol was mapped, NULL when it was not
+    auto_mapped BOOLEAN NOT NULL DEFAULT FALSE,
+    is_initial BOOLEAN NOT NULL DEFAULT FALSE,
+    first_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(exchange_id, base_symbol)
+);
+
+CREATE INDEX idx_exchange_listings_new ON exchange_listings(first_seen_at DESC) WHERE is_initial = false;
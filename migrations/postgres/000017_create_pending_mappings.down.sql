@@ -0,0 +1,2 @@
+-- Drop pending mappings queue
+DROP TABLE IF EXISTS pending_mappings;
@@ -0,0 +1,24 @@
+-- Exchange symbols the mapper could not map to a token, queued for review.
+-- candidates holds the tokens the mapper thought likely, best first:
+-- [{"token_id": 1, "symbol": "BTC", "name": "Bitcoin", "score": 0.8, "reason": "symbol, name"}]
+CREATE TABLE pending_mappings (
+    id SERIAL PRIMARY KEY,
+    exchange_id VARCHAR(50) NOT NULL,
+    exchange_symbol VARCHAR(50) NOT NULL,
+    slug VARCHAR(100),
+    name VARCHAR(255),
+    market_pair VARCHAR(100), -- a pair the symbol was seen in
+    candidates JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'resolved', 'ignored'
+    resolved_token_id INTEGER REFERENCES tokens(id) ON DELETE SET NULL,
+    resolved_by VARCHAR(100),
+    resolved_at TIMESTAMP,
+    notes TEXT,
+    seen_count INTEGER NOT NULL DEFAULT 1, -- mapper runs that found it unmapped
+    first_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(exchange_id, exchange_symbol)
+);
+
+CREATE INDEX idx_pending_mappings_status ON pending_mappings(status, id);
@@ -0,0 +1,2 @@
+-- Drop token contracts table
+DROP TABLE IF EXISTS token_contracts;
@@ -0,0 +1,43 @@
+-- Every contract a token is deployed as, one row per chain and address. The
+-- seeder fills this from the source's contract list and replaces a token's
+-- rows each time the source lists contracts for it. chain is the platform
+-- name lowercased with runs of other characters turned into dashes
+-- ("BNB Smart Chain (BEP20)" -> bnb-smart-chain-bep20), platform is the name
+-- as the source gave it. position is the order the source listed the
+-- contract in, and the first is the primary contract.
+CREATE TABLE token_contracts (
+    id SERIAL PRIMARY KEY,
+    token_id INTEGER NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    chain VARCHAR(50) NOT NULL,
+    platform VARCHAR(100) NOT NULL,
+    contract_address TEXT NOT NULL,
+    rpc_urls TEXT[] NOT NULL DEFAULT '{}',
+    position INTEGER NOT NULL DEFAULT 1,
+    is_primary BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    UNIQUE(token_id, chain, contract_address)
+);
+
+CREATE INDEX idx_token_contracts_token_id ON token_contracts(token_id);
+CREATE INDEX idx_token_contracts_address ON token_contracts(chain, contract_address);
+
+-- Backfill from the contracts earlier seeds stored in tokens.metadata
+INSERT INTO token_contracts (token_id, chain, platform, contract_address, rpc_urls, position, is_primary)
+SELECT t.id,
+       LEFT(TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(TRIM(c.value->>'platform')), '[^a-z0-9]+', '-', 'g')), 50),
+       LEFT(TRIM(c.value->>'platform'), 100),
+       CASE WHEN TRIM(c.value->>'contract_address') ~* '^0x[0-9a-f]{40}$'
+            THEN LOWER(TRIM(c.value->>'contract_address'))
+            ELSE TRIM(c.value->>'contract_address') END,
+       COALESCE(ARRAY(SELECT jsonb_array_elements_text(
+           CASE WHEN jsonb_typeof(c.value->'rpc_urls') = 'array' THEN c.value->'rpc_urls' ELSE '[]'::jsonb END)), '{}'),
+       c.position,
+       c.position = 1
+FROM tokens t
+CROSS JOIN LATERAL jsonb_array_elements(
+    CASE WHEN jsonb_typeof(t.metadata->'contracts') = 'array' THEN t.metadata->'contracts' ELSE '[]'::jsonb END
+) WITH ORDINALITY AS c(value, position)
+WHERE COALESCE(TRIM(c.value->>'contract_address'), '') <> ''
+  AND COALESCE(TRIM(c.value->>'platform'), '') <> ''
+ON CONFLICT (token_id, chain, contract_address) DO NOTHING;
@@ -0,0 +1,2 @@
+-- Drop exchange registry
+DROP TABLE IF EXISTS exchanges;
@@ -0,0 +1,31 @@
+-- Registry of the exchanges we poll. configs/exchanges.json seeds exchanges
+-- that are not here yet at startup, after which this table is canonical:
+-- the poller builds its clients and VWAP weights from it.
+CREATE TABLE exchanges (
+    exchange_id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    base_url TEXT NOT NULL,
+    ticker_endpoint TEXT NOT NULL,
+    symbols_endpoint TEXT NOT NULL DEFAULT '',
+    rate_limit_per_minute INTEGER NOT NULL DEFAULT 60,
+    request_timeout_ms INTEGER NOT NULL DEFAULT 10000,
+    retry_attempts INTEGER NOT NULL DEFAULT 3,
+    weight DECIMAL(6, 4) NOT NULL DEFAULT 0.01, -- VWAP weight
+    symbol_format VARCHAR(20) NOT NULL DEFAULT '', -- e.g. BTCUSDT or BTC-USDT
+    quote_currencies TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+
+    -- Poll health, written by the poller
+    last_successful_poll TIMESTAMP,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (weight >= 0 AND weight <= 1)
+);
+
+CREATE INDEX idx_exchanges_active ON exchanges(weight DESC) WHERE is_active = true;
+
+CREATE TRIGGER update_exchanges_updated_at BEFORE UPDATE ON exchanges
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
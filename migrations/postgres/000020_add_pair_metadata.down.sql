@@ -0,0 +1,13 @@
+-- Drop pair metadata
+ALTER TABLE exchanges
+DROP COLUMN IF EXISTS maker_fee,
+DROP COLUMN IF EXISTS taker_fee;
+
+ALTER TABLE trading_pairs
+DROP COLUMN IF EXISTS metadata_updated_at,
+DROP COLUMN IF EXISTS maker_fee,
+DROP COLUMN IF EXISTS taker_fee,
+DROP COLUMN IF EXISTS min_notional,
+DROP COLUMN IF EXISTS min_quantity,
+DROP COLUMN IF EXISTS step_size,
+DROP COLUMN IF EXISTS tick_size;
@@ -0,0 +1,16 @@
+-- Order rules and fees of each pair as its exchange lists them, refreshed by
+-- tokenctl pairs. NULL where the exchange does not say. Fees are fractions of
+-- the traded amount (0.001 is 0.1%).
+ALTER TABLE trading_pairs
+ADD COLUMN tick_size DECIMAL(30, 18),
+ADD COLUMN step_size DECIMAL(30, 18),
+ADD COLUMN min_quantity DECIMAL(30, 18),
+ADD COLUMN min_notional DECIMAL(30, 12),
+ADD COLUMN taker_fee DECIMAL(8, 6),
+ADD COLUMN maker_fee DECIMAL(8, 6),
+ADD COLUMN metadata_updated_at TIMESTAMP;
+
+-- Base fee tier of each exchange, used for pairs whose listing has no fees
+ALTER TABLE exchanges
+ADD COLUMN taker_fee DECIMAL(8, 6),
+ADD COLUMN maker_fee DECIMAL(8, 6);
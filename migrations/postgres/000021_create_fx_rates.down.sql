@@ -0,0 +1,2 @@
+-- Drop fiat exchange rates
+DROP TABLE IF EXISTS fx_rates;
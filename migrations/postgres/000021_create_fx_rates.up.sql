@@ -0,0 +1,12 @@
+-- Daily fiat exchange rates, as US dollars per unit of the currency, used to
+-- fold pairs quoted in EUR, TRY, BRL and the like into USD composites
+CREATE TABLE IF NOT EXISTS fx_rates (
+    currency VARCHAR(10) NOT NULL,
+    rate_date DATE NOT NULL,
+    usd_rate DECIMAL(30, 12) NOT NULL CHECK (usd_rate > 0),
+    source VARCHAR(30) NOT NULL,
+    fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (currency, rate_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fx_rates_date ON fx_rates(rate_date DESC);
@@ -0,0 +1,3 @@
+-- Drop watchlists
+DROP TABLE IF EXISTS watchlist_items;
+DROP TABLE IF EXISTS watchlists;
@@ -0,0 +1,24 @@
+-- Watchlists of API clients. owner is a hash of the client's API key, so keys
+-- are never stored.
+CREATE TABLE IF NOT EXISTS watchlists (
+    id SERIAL PRIMARY KEY,
+    owner VARCHAR(64) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (owner, name)
+);
+
+-- Tokens of each watchlist, in the order the client gave them
+CREATE TABLE IF NOT EXISTS watchlist_items (
+    watchlist_id INTEGER NOT NULL REFERENCES watchlists(id) ON DELETE CASCADE,
+    token_id INTEGER NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (watchlist_id, token_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_watchlist_items_token ON watchlist_items(token_id);
+
+CREATE TRIGGER update_watchlists_updated_at BEFORE UPDATE ON watchlists
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
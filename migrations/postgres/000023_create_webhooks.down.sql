@@ -0,0 +1,3 @@
+-- Drop webhooks
+DROP TABLE IF EXISTS webhook_tokens;
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,30 @@
+-- Webhook subscriptions of API clients. Price updates for the subscribed
+-- tokens are POSTed to url at most every min_interval_seconds, signed with
+-- secret. owner is a hash of the client's API key, as in watchlists.
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    owner VARCHAR(64) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    min_interval_seconds INTEGER NOT NULL DEFAULT 60,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    last_delivered_at TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_owner ON webhooks(owner);
+
+-- Tokens each webhook is sent updates for
+CREATE TABLE IF NOT EXISTS webhook_tokens (
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    token_id INTEGER NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    PRIMARY KEY (webhook_id, token_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_tokens_token ON webhook_tokens(token_id);
+
+CREATE TRIGGER update_webhooks_updated_at BEFORE UPDATE ON webhooks
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Drop outlier threshold overrides
+DROP TABLE IF EXISTS outlier_thresholds;
@@ -0,0 +1,16 @@
+-- Per-pair overrides of the outlier thresholds, e.g. wider bands for
+-- illiquid tokens. A NULL column keeps the configured default for it.
+CREATE TABLE IF NOT EXISTS outlier_thresholds (
+    base_token_id INTEGER NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    quote_token_id INTEGER NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    max_deviation DOUBLE PRECISION CHECK (max_deviation > 0),
+    max_std_devs DOUBLE PRECISION CHECK (max_std_devs >= 0),
+    min_samples INTEGER CHECK (min_samples >= 2),
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base_token_id, quote_token_id)
+);
+
+CREATE TRIGGER update_outlier_thresholds_updated_at BEFORE UPDATE ON outlier_thresholds
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,5 @@
+-- Drop mapping reconciliation reports
+DROP TABLE IF EXISTS mapping_reconciliation_reports;
+
+ALTER TABLE token_exchange_symbols
+DROP COLUMN IF EXISTS last_seen_at;
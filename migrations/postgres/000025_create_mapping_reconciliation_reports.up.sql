@@ -0,0 +1,21 @@
+-- When each exchange symbol last appeared in a ticker, recorded by the
+-- nightly reconciliation job so mappings an exchange stopped quoting can be
+-- reported
+ALTER TABLE token_exchange_symbols
+ADD COLUMN last_seen_at TIMESTAMP;
+
+-- Nightly reconciliation reports comparing the symbols exchanges quote with
+-- token_exchange_symbols. report holds the full findings; the counts are
+-- kept alongside for listing.
+CREATE TABLE IF NOT EXISTS mapping_reconciliation_reports (
+    id SERIAL PRIMARY KEY,
+    unmapped_count INTEGER NOT NULL,
+    stale_count INTEGER NOT NULL,
+    duplicate_count INTEGER NOT NULL,
+    report JSONB NOT NULL,
+    delivered_at TIMESTAMP,
+    delivery_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_mapping_reconciliation_reports_created ON mapping_reconciliation_reports(created_at DESC);
@@ -0,0 +1,3 @@
+-- Drop the exchange trades endpoint
+ALTER TABLE exchanges
+DROP COLUMN IF EXISTS trades_endpoint;
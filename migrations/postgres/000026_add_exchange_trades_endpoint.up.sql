@@ -0,0 +1,14 @@
+-- Recent-trades endpoint of each exchange, with {symbol} standing for the
+-- pair symbol. The REST poller fetches trades from exchanges that have one.
+ALTER TABLE exchanges
+ADD COLUMN trades_endpoint VARCHAR(255);
+
+UPDATE exchanges SET trades_endpoint = v.endpoint
+FROM (VALUES
+    ('bybit', '/v5/market/recent-trade?category=spot&symbol={symbol}&limit=60'),
+    ('okx', '/api/v5/market/trades?instId={symbol}&limit=500'),
+    ('gateio', '/api/v4/spot/trades?currency_pair={symbol}&limit=1000'),
+    ('kucoin', '/api/v1/market/histories?symbol={symbol}'),
+    ('coinbase', '/products/{symbol}/trades')
+) AS v(exchange_id, endpoint)
+WHERE exchanges.exchange_id = v.exchange_id AND exchanges.trades_endpoint IS NULL;
@@ -0,0 +1,3 @@
+-- Drop the token taxonomy
+DROP TABLE IF EXISTS token_categories;
+DROP TABLE IF EXISTS categories;
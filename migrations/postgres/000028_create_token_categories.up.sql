@@ -0,0 +1,39 @@
+-- Token taxonomy: categories such as defi or layer-1, and looser tags, each
+-- linked to any number of tokens. tokens.categories is kept for the fiat
+-- currencies and copied in below.
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    name VARCHAR(200) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'category' CHECK (kind IN ('category', 'tag')),
+    source VARCHAR(20) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_categories_updated_at BEFORE UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- source says who linked the token, so an import from CoinGecko replaces
+-- only its own links
+CREATE TABLE IF NOT EXISTS token_categories (
+    token_id INTEGER NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (token_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_categories_category ON token_categories(category_id);
+
+INSERT INTO categories (slug, name, kind, source)
+SELECT DISTINCT ON (1) trim(both '-' FROM regexp_replace(lower(c.name), '[^a-z0-9]+', '-', 'g')), trim(c.name), 'tag', 'seed'
+FROM tokens t CROSS JOIN LATERAL unnest(t.categories) AS c(name)
+WHERE regexp_replace(lower(c.name), '[^a-z0-9]+', '', 'g') <> ''
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO token_categories (token_id, category_id, source)
+SELECT DISTINCT t.id, cat.id, 'seed'
+FROM tokens t CROSS JOIN LATERAL unnest(t.categories) AS c(name)
+JOIN categories cat ON cat.slug = trim(both '-' FROM regexp_replace(lower(c.name), '[^a-z0-9]+', '-', 'g'))
+ON CONFLICT DO NOTHING;
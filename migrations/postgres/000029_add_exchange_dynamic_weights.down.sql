@@ -0,0 +1,6 @@
+-- Drop the dynamic exchange weights
+DROP TABLE IF EXISTS exchange_weight_history;
+
+ALTER TABLE exchanges
+DROP COLUMN IF EXISTS dynamic_weight_updated_at,
+DROP COLUMN IF EXISTS dynamic_weight;
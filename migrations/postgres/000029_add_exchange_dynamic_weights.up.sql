@@ -0,0 +1,22 @@
+-- Dynamic VWAP weight of each exchange, recomputed daily from its share of
+-- observed volume and its poll uptime. VWAP uses it instead of weight while
+-- the dynamic_exchange_weights feature flag is on.
+ALTER TABLE exchanges
+ADD COLUMN dynamic_weight DECIMAL(6, 4) CHECK (dynamic_weight >= 0 AND dynamic_weight <= 1),
+ADD COLUMN dynamic_weight_updated_at TIMESTAMP;
+
+-- Every dynamic weight computed, with the inputs it came from
+CREATE TABLE IF NOT EXISTS exchange_weight_history (
+    id BIGSERIAL PRIMARY KEY,
+    exchange_id VARCHAR(50) NOT NULL REFERENCES exchanges(exchange_id) ON DELETE CASCADE,
+    static_weight DECIMAL(6, 4) NOT NULL,
+    previous_weight DECIMAL(6, 4), -- NULL on the first computation
+    target_weight DECIMAL(6, 4) NOT NULL, -- before smoothing
+    weight DECIMAL(6, 4) NOT NULL,
+    volume_share_pct DOUBLE PRECISION NOT NULL,
+    uptime_pct DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_weight_history_exchange
+    ON exchange_weight_history(exchange_id, computed_at DESC);
@@ -0,0 +1,8 @@
+-- Drop the exchange trust scores
+ALTER TABLE exchanges
+DROP COLUMN IF EXISTS trust_score_updated_at,
+DROP COLUMN IF EXISTS trust_mismatch_pct,
+DROP COLUMN IF EXISTS trust_pairs,
+DROP COLUMN IF EXISTS trust_round_trip_pct,
+DROP COLUMN IF EXISTS trust_trades,
+DROP COLUMN IF EXISTS trust_score;
@@ -0,0 +1,10 @@
+-- Trust score of each exchange's volume, 0 to 1, from the wash trading
+-- heuristics of internal/trust, with the signals it came from. VWAP
+-- multiplies weights by it while the vwap_trust_weighting feature flag is on.
+ALTER TABLE exchanges
+ADD COLUMN trust_score DECIMAL(4, 3) CHECK (trust_score >= 0 AND trust_score <= 1),
+ADD COLUMN trust_trades BIGINT,
+ADD COLUMN trust_round_trip_pct DOUBLE PRECISION,
+ADD COLUMN trust_pairs INTEGER,
+ADD COLUMN trust_mismatch_pct DOUBLE PRECISION,
+ADD COLUMN trust_score_updated_at TIMESTAMP;
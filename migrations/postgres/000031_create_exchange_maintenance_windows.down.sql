@@ -0,0 +1,2 @@
+-- Drop the exchange maintenance windows
+DROP TABLE IF EXISTS exchange_maintenance_windows;
@@ -0,0 +1,17 @@
+-- Scheduled maintenance of the exchanges, from configs/exchanges.json or the
+-- admin API. The poller does not poll an exchange during one of its windows,
+-- so the downtime counts neither as poll failures nor against its uptime.
+CREATE TABLE IF NOT EXISTS exchange_maintenance_windows (
+    id BIGSERIAL PRIMARY KEY,
+    exchange_id VARCHAR(50) NOT NULL REFERENCES exchanges(exchange_id) ON DELETE CASCADE,
+    starts_at TIMESTAMP NOT NULL, -- UTC
+    ends_at TIMESTAMP NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (ends_at > starts_at),
+    UNIQUE (exchange_id, starts_at, ends_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_maintenance_windows_ends
+    ON exchange_maintenance_windows(ends_at);
@@ -0,0 +1,4 @@
+-- Drop the exchange symbol filters
+ALTER TABLE exchanges
+DROP COLUMN IF EXISTS deny_symbols,
+DROP COLUMN IF EXISTS allow_symbols;
@@ -0,0 +1,6 @@
+-- Symbol patterns of each exchange kept (allow) and dropped (deny) when its
+-- responses are parsed, so scam or unwanted pairs never reach mapping or VWAP.
+-- Without allow patterns every symbol not denied is kept.
+ALTER TABLE exchanges
+ADD COLUMN allow_symbols TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN deny_symbols TEXT[] NOT NULL DEFAULT '{}';
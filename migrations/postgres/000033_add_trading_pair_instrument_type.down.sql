@@ -0,0 +1,5 @@
+-- Drop the trading pair instrument type
+DROP INDEX IF EXISTS idx_trading_pairs_non_spot;
+
+ALTER TABLE trading_pairs
+DROP COLUMN IF EXISTS instrument_type;
@@ -0,0 +1,9 @@
+-- Kind of market of each trading pair: spot, perp, futures or
+-- leveraged_token. Non-spot pairs are only discovered while the
+-- ingest_non_spot feature flag is on.
+ALTER TABLE trading_pairs
+ADD COLUMN instrument_type VARCHAR(20) NOT NULL DEFAULT 'spot'
+    CHECK (instrument_type IN ('spot', 'perp', 'futures', 'leveraged_token'));
+
+CREATE INDEX IF NOT EXISTS idx_trading_pairs_non_spot
+    ON trading_pairs(exchange_id, instrument_type) WHERE instrument_type <> 'spot';
@@ -0,0 +1,2 @@
+-- Renamed exchange IDs are not restored
+ALTER TABLE trading_pairs DROP CONSTRAINT IF EXISTS trading_pairs_exchange_id_fkey;
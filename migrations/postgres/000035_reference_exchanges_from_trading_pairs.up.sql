@@ -0,0 +1,46 @@
+-- trading_pairs rows written under another spelling of a registered exchange
+-- ("gate", "Gate.io", "crypto-com-exchange") are moved to the registry's ID.
+-- Spellings are compared with everything but letters and digits dropped, and
+-- known aliases are resolved as exchanges.NormalizeExchangeID does.
+CREATE TEMP TABLE exchange_id_renames AS
+WITH aliases (alias, exchange_id) AS (
+    VALUES ('gate', 'gateio'), ('coinbaseexchange', 'coinbase'), ('coinbasepro', 'coinbase'),
+           ('gdax', 'coinbase'), ('huobi', 'htx'), ('huobiglobal', 'htx'), ('okex', 'okx'),
+           ('cryptocomexchange', 'cryptocom'), ('biconomycom', 'biconomy'),
+           ('hashkeyexchange', 'hashkey'), ('bitmartexchange', 'bitmart')
+), unregistered AS (
+    SELECT DISTINCT tp.exchange_id AS old_id,
+           regexp_replace(lower(tp.exchange_id), '[^a-z0-9]', '', 'g') AS normalized
+    FROM trading_pairs tp
+    WHERE NOT EXISTS (SELECT 1 FROM exchanges e WHERE e.exchange_id = tp.exchange_id)
+)
+SELECT u.old_id, e.exchange_id AS new_id
+FROM unregistered u
+LEFT JOIN aliases a ON a.alias = u.normalized
+JOIN exchanges e ON e.exchange_id = COALESCE(a.exchange_id, u.normalized);
+
+-- A pair the registry's ID already has, or another spelling listed first,
+-- is a duplicate and is dropped
+DELETE FROM trading_pairs tp
+USING exchange_id_renames r
+WHERE tp.exchange_id = r.old_id
+  AND EXISTS (
+      SELECT 1 FROM trading_pairs other
+      LEFT JOIN exchange_id_renames ro ON ro.old_id = other.exchange_id
+      WHERE other.exchange_pair_symbol = tp.exchange_pair_symbol
+        AND (other.exchange_id = r.new_id OR (ro.new_id = r.new_id AND other.id < tp.id))
+  );
+
+UPDATE trading_pairs tp SET exchange_id = r.new_id, updated_at = NOW()
+FROM exchange_id_renames r
+WHERE tp.exchange_id = r.old_id;
+
+DROP TABLE exchange_id_renames;
+
+-- New pairs must be on a registered exchange. Rows of exchanges that were
+-- never registered are not checked; once they are registered or removed,
+-- VALIDATE CONSTRAINT checks the whole table.
+ALTER TABLE trading_pairs
+    ADD CONSTRAINT trading_pairs_exchange_id_fkey
+    FOREIGN KEY (exchange_id) REFERENCES exchanges(exchange_id) ON UPDATE CASCADE
+    NOT VALID;
@@ -0,0 +1,21 @@
+ALTER TABLE trading_pairs
+    DROP CONSTRAINT trading_pairs_base_token_id_fkey,
+    DROP CONSTRAINT trading_pairs_quote_token_id_fkey,
+    ADD CONSTRAINT trading_pairs_base_token_id_fkey
+        FOREIGN KEY (base_token_id) REFERENCES tokens(id) ON DELETE CASCADE,
+    ADD CONSTRAINT trading_pairs_quote_token_id_fkey
+        FOREIGN KEY (quote_token_id) REFERENCES tokens(id) ON DELETE CASCADE;
+
+ALTER TABLE token_exchange_symbols
+    DROP CONSTRAINT token_exchange_symbols_token_id_fkey,
+    ADD CONSTRAINT token_exchange_symbols_token_id_fkey
+        FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE CASCADE;
+
+ALTER TABLE trading_pairs DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE token_exchange_symbols DROP COLUMN IF EXISTS archived_at;
+
+DROP INDEX IF EXISTS idx_tokens_archived;
+ALTER TABLE tokens
+    DROP COLUMN IF EXISTS archive_reason,
+    DROP COLUMN IF EXISTS archived_at,
+    DROP COLUMN IF EXISTS is_archived;
@@ -0,0 +1,28 @@
+-- Tokens are archived instead of deleted: ClickHouse prices, VWAPs and candles
+-- refer to token IDs and cannot follow a delete, so the row stays and history
+-- remains queryable by ID. Archiving deactivates the token and tombstones its
+-- symbol mappings and trading pairs, recording when on each row.
+ALTER TABLE tokens
+    ADD COLUMN is_archived BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN archived_at TIMESTAMP,
+    ADD COLUMN archive_reason TEXT;
+
+CREATE INDEX idx_tokens_archived ON tokens(archived_at) WHERE is_archived;
+
+ALTER TABLE token_exchange_symbols ADD COLUMN archived_at TIMESTAMP;
+ALTER TABLE trading_pairs ADD COLUMN archived_at TIMESTAMP;
+
+-- A token with mappings or pairs, tombstoned or not, can no longer be deleted
+-- and take them with it
+ALTER TABLE token_exchange_symbols
+    DROP CONSTRAINT token_exchange_symbols_token_id_fkey,
+    ADD CONSTRAINT token_exchange_symbols_token_id_fkey
+        FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE RESTRICT;
+
+ALTER TABLE trading_pairs
+    DROP CONSTRAINT trading_pairs_base_token_id_fkey,
+    DROP CONSTRAINT trading_pairs_quote_token_id_fkey,
+    ADD CONSTRAINT trading_pairs_base_token_id_fkey
+        FOREIGN KEY (base_token_id) REFERENCES tokens(id) ON DELETE RESTRICT,
+    ADD CONSTRAINT trading_pairs_quote_token_id_fkey
+        FOREIGN KEY (quote_token_id) REFERENCES tokens(id) ON DELETE RESTRICT;
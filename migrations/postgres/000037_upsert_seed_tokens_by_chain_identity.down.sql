@@ -0,0 +1 @@
+-- The seed tokens stay; 000008's down migration removes the fiat currencies
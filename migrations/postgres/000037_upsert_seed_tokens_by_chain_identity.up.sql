@@ -0,0 +1,65 @@
+-- 000008 and 000009 upsert their seed tokens ON CONFLICT (symbol), which only
+-- works where tokens has a unique symbol constraint. No migration creates one,
+-- and 000013 replaced any that existed with idx_tokens_symbol_native. Upsert
+-- the same rows again on that index so the seeds are in place on every
+-- database that reaches this version.
+
+-- Fiat currencies from 000008
+INSERT INTO tokens (id, symbol, name, is_active, categories, created_at, updated_at)
+VALUES
+    (90001, 'USD', 'US Dollar', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90002, 'EUR', 'Euro', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90003, 'GBP', 'British Pound', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90004, 'JPY', 'Japanese Yen', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90005, 'KRW', 'South Korean Won', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90006, 'INR', 'Indian Rupee', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90007, 'TRY', 'Turkish Lira', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90008, 'BRL', 'Brazilian Real', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90009, 'MXN', 'Mexican Peso', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90010, 'ARS', 'Argentine Peso', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90011, 'ZAR', 'South African Rand', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90012, 'UAH', 'Ukrainian Hryvnia', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90013, 'COP', 'Colombian Peso', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90014, 'SGD', 'Singapore Dollar', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90015, 'AUD', 'Australian Dollar', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90016, 'CAD', 'Canadian Dollar', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90017, 'CHF', 'Swiss Franc', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90018, 'PLN', 'Polish Zloty', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90019, 'RUB', 'Russian Ruble', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90020, 'CNY', 'Chinese Yuan', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90021, 'HKD', 'Hong Kong Dollar', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90022, 'NZD', 'New Zealand Dollar', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90023, 'THB', 'Thai Baht', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90024, 'IDR', 'Indonesian Rupiah', true, ARRAY['fiat', 'currency'], NOW(), NOW()),
+    (90025, 'PHP', 'Philippine Peso', true, ARRAY['fiat', 'currency'], NOW(), NOW())
+ON CONFLICT (symbol) WHERE chain IS NULL DO UPDATE SET
+    categories = EXCLUDED.categories,
+    updated_at = NOW();
+
+-- Missing tokens from 000009
+INSERT INTO tokens (id, symbol, name, is_active, categories, created_at, updated_at)
+VALUES
+    (10001, 'WBTC', 'Wrapped Bitcoin', true, ARRAY['defi', 'wrapped'], NOW(), NOW()),
+    (10002, 'VELODROME', 'Velodrome Finance', true, ARRAY['defi', 'dex'], NOW(), NOW()),
+    (10003, 'BEAMX', 'Beam', true, ARRAY['gaming'], NOW(), NOW()),
+    (10004, 'RONIN', 'Ronin', true, ARRAY['gaming', 'sidechain'], NOW(), NOW()),
+    (10005, 'STETH', 'Lido Staked ETH', true, ARRAY['defi', 'liquid-staking'], NOW(), NOW()),
+    (10006, 'MSOL', 'Marinade Staked SOL', true, ARRAY['defi', 'liquid-staking'], NOW(), NOW()),
+    (10007, 'BNSOL', 'Binance Staked SOL', true, ARRAY['defi', 'liquid-staking'], NOW(), NOW()),
+    (10008, 'WBETH', 'Wrapped Beacon ETH', true, ARRAY['defi', 'wrapped'], NOW(), NOW()),
+    (10009, '1000SATS', '1000SATS', true, ARRAY['meme'], NOW(), NOW()),
+    (10010, '1000CAT', '1000CAT', true, ARRAY['meme'], NOW(), NOW()),
+    (10011, '1MBABYDOGE', '1M Baby Doge', true, ARRAY['meme'], NOW(), NOW()),
+    (10012, '1000CHEEMS', '1000 Cheems', true, ARRAY['meme'], NOW(), NOW()),
+    (10013, 'SAHARA', 'Sahara', true, ARRAY['ai'], NOW(), NOW()),
+    (10014, 'RESOLV', 'Resolv', true, ARRAY['defi'], NOW(), NOW()),
+    (10015, 'SPK', 'SparkPoint', true, ARRAY['defi'], NOW(), NOW()),
+    (10016, 'TREE', 'Tree', true, ARRAY['defi'], NOW(), NOW()),
+    (10017, 'HOME', 'Home', true, ARRAY['defi'], NOW(), NOW()),
+    (10018, 'NEWT', 'Newt', true, ARRAY['defi'], NOW(), NOW()),
+    (10019, 'ERA', 'Era', true, ARRAY['defi'], NOW(), NOW()),
+    (10020, 'PROVE', 'Prove', true, ARRAY['defi'], NOW(), NOW())
+ON CONFLICT (symbol) WHERE chain IS NULL DO UPDATE SET
+    name = EXCLUDED.name,
+    categories = EXCLUDED.categories,
+    updated_at = NOW();
@@ -1,56 +1,124 @@
 package utils
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/ashmitsharp/trading/internal/config"
+	"github.com/ashmitsharp/trading/internal/requestid"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-// InitLogger initializes and returns a configured zap logger
-func InitLogger() *zap.Logger {
-	// Get log level from environment
-	logLevel := getLogLevel()
+// InitLogger initializes and returns a zap logger configured by cfg. Loggers
+// derived with Named log at the level cfg.Modules gives their name, or the
+// closest named ancestor, and at cfg.Level otherwise.
+func InitLogger(cfg config.LogConfig) (*zap.Logger, error) {
+	base, err := parseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	modules := make(map[string]zapcore.Level, len(cfg.Modules))
+	lowest := base
+	for module, text := range cfg.Modules {
+		level, err := parseLevel(text)
+		if err != nil {
+			return nil, fmt.Errorf("log level of module %s: %w", module, err)
+		}
+		modules[module] = level
+		if level < lowest {
+			lowest = level
+		}
+	}
 
 	// Configure encoder
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.TimeKey = "timestamp"
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	encoderConfig.StacktraceKey = ""
 
-	// Create core
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout)),
-		logLevel,
-	)
+	var encoder zapcore.Encoder
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case "console":
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return nil, fmt.Errorf("unknown log format %q, want json or console", cfg.Format)
+	}
+
+	// The inner core admits every level some module logs at; moduleCore
+	// then applies the level of each entry's logger
+	var core zapcore.Core = zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), lowest)
+	if cfg.SamplingThereafter > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.SamplingInitial, cfg.SamplingThereafter)
+	}
+	if len(modules) > 0 {
+		core = &moduleCore{Core: core, base: base, modules: modules}
+	}
 
 	// Create logger with additional options
 	logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
 
-	return logger
+	return logger, nil
 }
 
-// getLogLevel returns the log level from environment variable
-func getLogLevel() zapcore.Level {
-	switch os.Getenv("LOG_LEVEL") {
-	case "DEBUG":
-		return zapcore.DebugLevel
-	case "INFO":
-		return zapcore.InfoLevel
-	case "WARN":
-		return zapcore.WarnLevel
-	case "ERROR":
-		return zapcore.ErrorLevel
-	default:
-		return zapcore.InfoLevel
+// parseLevel parses debug, info, warn or error, in any case
+func parseLevel(text string) (zapcore.Level, error) {
+	if text == "" {
+		return zapcore.InfoLevel, nil
+	}
+	level, err := zapcore.ParseLevel(text)
+	if err != nil {
+		return level, fmt.Errorf("unknown log level %q", text)
+	}
+	return level, nil
+}
+
+// moduleCore filters entries by the level configured for their logger name
+type moduleCore struct {
+	zapcore.Core
+	base    zapcore.Level
+	modules map[string]zapcore.Level
+}
+
+func (c *moduleCore) With(fields []zapcore.Field) zapcore.Core {
+	return &moduleCore{Core: c.Core.With(fields), base: c.base, modules: c.modules}
+}
+
+func (c *moduleCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < c.levelFor(entry.LoggerName) {
+		return ce
 	}
+	return c.Core.Check(entry, ce)
 }
 
-// LoggerMiddleware creates a Gin middleware for request logging
+// levelFor returns the level of the longest configured prefix of name, split
+// at dots as zap joins nested names
+func (c *moduleCore) levelFor(name string) zapcore.Level {
+	for name != "" {
+		if level, ok := c.modules[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return c.base
+}
+
+// LoggerMiddleware creates a Gin middleware for request logging. Server
+// errors are logged at error level, client errors at warn and the rest at
+// info.
 func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
+	// A failed request is not a failure of this code; its stack says nothing
+	logger = logger.WithOptions(zap.AddStacktrace(zapcore.DPanicLevel))
+
 	return func(c *gin.Context) {
 		start := time.Now()
 
@@ -59,16 +127,36 @@ func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 
 		// Log request details
 		duration := time.Since(start)
+		status := c.Writer.Status()
 
-		logger.Info("HTTP Request",
+		level := zapcore.InfoLevel
+		switch {
+		case status >= 500:
+			level = zapcore.ErrorLevel
+		case status >= 400:
+			level = zapcore.WarnLevel
+		}
+		ce := logger.Check(level, "HTTP Request")
+		if ce == nil {
+			return
+		}
+		fields := []zap.Field{
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.Request.URL.Path),
+			zap.String("route", c.FullPath()),
 			zap.String("query", c.Request.URL.RawQuery),
 			zap.String("user_agent", c.Request.UserAgent()),
 			zap.String("client_ip", c.ClientIP()),
-			zap.Int("status", c.Writer.Status()),
+			zap.Int("status", status),
 			zap.Duration("duration", duration),
 			zap.Int("response_size", c.Writer.Size()),
-		)
+		}
+		if id := requestid.FromContext(c.Request.Context()); id != "" {
+			fields = append(fields, zap.String("request_id", id))
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+			fields = append(fields, zap.String("errors", errs))
+		}
+		ce.Write(fields...)
 	}
 }
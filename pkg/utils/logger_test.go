@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/ashmitsharp/trading/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestModuleLevels(t *testing.T) {
+	inner, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&moduleCore{
+		Core: inner,
+		base: zapcore.InfoLevel,
+		modules: map[string]zapcore.Level{
+			"polling":    zapcore.DebugLevel,
+			"api.stream": zapcore.ErrorLevel,
+		},
+	})
+
+	logger.Debug("root debug")
+	logger.Info("root info")
+	logger.Named("polling").Debug("polling debug")
+	logger.Named("polling").Named("binance").With(zap.Int("n", 1)).Debug("child debug")
+	logger.Named("api").Info("api info")
+	logger.Named("api").Named("stream").Warn("stream warn")
+	logger.Named("api").Named("stream").Error("stream error")
+
+	var got []string
+	for _, e := range logs.All() {
+		got = append(got, e.Message)
+	}
+	want := []string{"root info", "polling debug", "child debug", "api info", "stream error"}
+	if len(got) != len(want) {
+		t.Fatalf("logged %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestInitLoggerRejectsBadConfig(t *testing.T) {
+	for _, cfg := range []config.LogConfig{
+		{Level: "loud"},
+		{Level: "info", Format: "xml"},
+		{Level: "info", Modules: map[string]string{"polling": "chatty"}},
+	} {
+		if _, err := InitLogger(cfg); err == nil {
+			t.Errorf("InitLogger(%+v) succeeded", cfg)
+		}
+	}
+	if _, err := InitLogger(config.LogConfig{Level: "DEBUG", Format: "console"}); err != nil {
+		t.Errorf("InitLogger: %v", err)
+	}
+}